@@ -2,30 +2,76 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/teleta/tg-hamster/internal/bot"
+	"github.com/teleta/tg-hamster/pkg/hamster"
 )
 
+const usage = `Использование: tg-hamster [команда] [аргументы]
+
+Команды:
+  serve              запустить бота (по умолчанию)
+  check-config       проверить настройки из окружения, не подключаясь к Telegram
+  export [файл]      выгрузить настройки, верификации и статистику в JSON (по умолчанию в stdout)
+  import <файл>      заменить состояние выгрузкой export или резервной копией ("-" — stdin)
+  export-data [файл] выгрузить настройки, статистику, верификации и журнал банов по чатам
+                     (-format json|csv; -chat ID — только этот чат, можно несколько раз)
+  replay <файл>      прогнать запись RECORD_UPDATES_FILE через обработчики без Telegram
+                     (-speed N — темп записи, 0 — без пауз; -wait D — ждать фоновые обработчики)
+  version            показать версию, коммит и дату сборки (или --version)
+`
+
 func main() {
 	_ = godotenv.Load()
 
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+	switch cmd {
+	case "serve":
+		serve()
+	case "check-config":
+		checkConfig()
+	case "export":
+		exportState(args)
+	case "import":
+		importState(args)
+	case "export-data":
+		exportData(args)
+	case "replay":
+		replay(args)
+	case "version", "--version", "-v":
+		fmt.Println("tg-hamster", hamster.BuildInfo())
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Неизвестная команда %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+}
+
+// ==========================
+// serve
+// ==========================
+
+func serve() {
 	token := os.Getenv("TELEGRAM_BOT_TOKEN")
 	if token == "" {
 		log.Fatal("❌ TELEGRAM_BOT_TOKEN не задан в .env")
 	}
 
-	timeoutFile := os.Getenv("TIMEOUT_FILE")
-	if timeoutFile == "" {
-		timeoutFile = "timeouts.json"
-	}
-
-	logger := bot.NewLogger()
+	logger := hamster.NewLogger()
+	cfg := hamster.ConfigFromEnv(logger)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -39,26 +85,196 @@ func main() {
 		cancel()
 	}()
 
-	b := bot.NewBot(token, timeoutFile, logger)
+	b, err := hamster.NewBot(token, cfg, hamster.WithLogger(logger))
+	if err != nil {
+		log.Fatalf("❌ Не удалось открыть хранилище: %v", err)
+	}
 
-	// Очистка устаревших сообщений каждые 10 секунд
-	go func() {
-		ticker := time.NewTicker(10 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				b.CleanupOldMessages()
-			}
-		}
-	}()
+	// Перечитывание settings.json при ручной правке
+	go b.WatchSettings(ctx)
+
+	// Резервные копии по расписанию
+	go b.RunBackups(ctx)
+
+	// Списки администраторов активных чатов
+	go b.RunAdminRefresh(ctx)
+
+	// Очередь рассылок /broadcast
+	go b.RunBroadcasts(ctx)
+
+	// REST API для операторов
+	go b.ServeAdminAPI(ctx)
 
-	// Запуск polling
+	// Выгрузка трассировки
+	go b.RunTracing(ctx)
+
+	// pprof и размеры состояния
+	go b.ServeDebug(ctx)
+
+	// Страница проверки через Mini App
+	go b.ServeWebApp(ctx)
+
+	// Запуск polling, а с ним очистки, сроков хранения и удаления заброшенных
+	// чатов; с LEADER_ELECTION — только на ведущем экземпляре
 	go b.StartWithContext(ctx)
 
 	<-ctx.Done()
+	if err := b.Close(); err != nil {
+		logger.Warn("Ошибка закрытия хранилища: %v", err)
+	}
 	logger.Info("✅ Бот корректно остановлен")
 	time.Sleep(time.Second)
 }
+
+// ==========================
+// check-config
+// ==========================
+
+func checkConfig() {
+	cfg := hamster.ConfigFromEnv(hamster.NewLogger())
+	ok := true
+	if os.Getenv("TELEGRAM_BOT_TOKEN") == "" {
+		fmt.Fprintln(os.Stderr, "❌ TELEGRAM_BOT_TOKEN не задан")
+		ok = false
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		ok = false
+	}
+	if !ok {
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Настройки корректны (хранилище %s)\n", cfg.Storage)
+}
+
+// ==========================
+// export / import
+// ==========================
+
+// openBot открывает хранилище из настроек окружения без подключения к Telegram.
+func openBot() (*hamster.Bot, *hamster.Logger) {
+	// логи — в stderr, чтобы не смешивать их с выгрузкой в stdout
+	logger := hamster.NewLoggerFrom(log.New(os.Stderr, "", 0))
+	b, err := hamster.NewBot(os.Getenv("TELEGRAM_BOT_TOKEN"), hamster.ConfigFromEnv(logger), hamster.WithLogger(logger))
+	if err != nil {
+		log.Fatalf("❌ Не удалось открыть хранилище: %v", err)
+	}
+	return b, logger
+}
+
+func exportState(args []string) {
+	b, logger := openBot()
+	defer b.Close()
+
+	var w io.Writer = os.Stdout
+	if len(args) > 0 && args[0] != "-" {
+		f, err := os.OpenFile(args[0], os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := b.ExportState(w); err != nil {
+		b.Close()
+		log.Fatalf("❌ Выгрузка не удалась: %v", err)
+	}
+	if w != os.Stdout {
+		logger.Info("📤 Состояние выгружено в %s", args[0])
+	}
+}
+
+// chatList — флаг -chat, который можно указать несколько раз.
+type chatList []int64
+
+func (c *chatList) String() string { return fmt.Sprint(*c) }
+
+func (c *chatList) Set(s string) error {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id == 0 {
+		return fmt.Errorf("некорректный ID чата %q", s)
+	}
+	*c = append(*c, id)
+	return nil
+}
+
+func exportData(args []string) {
+	fs := flag.NewFlagSet("export-data", flag.ExitOnError)
+	format := fs.String("format", hamster.ExportJSON, "формат: json или csv")
+	var chats chatList
+	fs.Var(&chats, "chat", "ID чата (по умолчанию — все)")
+	_ = fs.Parse(args)
+
+	b, logger := openBot()
+	defer b.Close()
+
+	var w io.Writer = os.Stdout
+	if fs.NArg() > 0 && fs.Arg(0) != "-" {
+		f, err := os.OpenFile(fs.Arg(0), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := b.ExportChats(w, chats, *format); err != nil {
+		b.Close()
+		log.Fatalf("❌ Выгрузка не удалась: %v", err)
+	}
+	if w != os.Stdout {
+		logger.Info("📤 Данные чатов выгружены в %s", fs.Arg(0))
+	}
+}
+
+func importState(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	b, logger := openBot()
+	defer b.Close()
+
+	var r io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	chats, err := b.ImportState(r)
+	if err != nil {
+		b.Close()
+		log.Fatalf("❌ Загрузка не удалась: %v", err)
+	}
+	logger.Info("📥 Состояние загружено из %s (%d чатов)", args[0], chats)
+}
+
+// ==========================
+// replay
+// ==========================
+
+func replay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := fs.Float64("speed", 0, "темп относительно записи: 1 — как было, 0 — без пауз")
+	wait := fs.Duration("wait", 2*time.Second, "сколько ждать фоновые обработчики после последнего обновления")
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer f.Close()
+	// логи — в stderr, вызовы Bot API — в stdout
+	logger := hamster.NewLoggerFrom(log.New(os.Stderr, "", 0))
+	opts := hamster.ReplayOptions{Speed: *speed, Wait: *wait, Out: os.Stdout}
+	if err := hamster.Replay(f, hamster.ConfigFromEnv(logger), logger, opts); err != nil {
+		f.Close()
+		log.Fatalf("❌ Воспроизведение не удалось: %v", err)
+	}
+}
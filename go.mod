@@ -2,4 +2,11 @@ module github.com/teleta/tg-hamster
 
 go 1.25.3
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	go.etcd.io/bbolt v1.5.0
+)
+
+require golang.org/x/sys v0.45.0 // indirect
@@ -1,57 +0,0 @@
-package bot
-
-import "math/rand"
-
-// phrases.go — список фраз и иконок для приветствий / кнопок
-
-var phrasesList = []string{
-	"Я пришёл с миром",
-	"Бот не пройдёт",
-	"Ключ!",
-	"Пароль!",
-	"Да пребудет со мной кнопка",
-	"Чайник, но свой",
-	"Сканирую QR из Матрицы",
-	"Я — Грут",
-	"Это точно не капча",
-	"Меч самурая",
-	"Век живи — век учись",
-	"Хакер в деле",
-	"Не трогай мои кнопки",
-	"Секретный агент",
-	"Ниндзя внутри",
-	"Мышь в проводах",
-	"Яблочный пирог",
-	"Код сломан",
-	"Привет из будущего",
-	"Выключи свет",
-	"Нажимаю как человек",
-	"Пропуск выдан",
-	"Проверено — человек",
-	"Отпечатал ладонь (шутка)",
-	"Никаких скриптов, честно",
-}
-
-// Список иконок
-var icons = []string{
-	"🟢", "🔑", "🛡️", "⚡", "🔥", "💡", "🎯", "🚀", "🧩", "🪐",
-	"🌍", "🤖", "🔒", "⌨️", "☕", "📱", "🌟", "🔍", "🕹️", "🎮",
-	"🌌", "⚔️", "📚", "👨‍💻", "🚫", "🕵️", "🥷", "🖱️", "🥧", "🔧",
-	"🔮", "💤", "🌈", "💾", "🛸", "🧠", "🔋", "🎭", "📡", "⏰",
-}
-
-// randomGreeting возвращает (phrase, icon)
-func randomGreeting() (string, string) {
-	if len(phrasesList) == 0 {
-		return "Привет!", "👋"
-	}
-	p := phrasesList[rand.Intn(len(phrasesList))]
-	i := icons[rand.Intn(len(icons))]
-	return p, i
-}
-
-// pickPhrase возвращает полную строку "ICON + SPACE + PHRASE"
-func pickPhrase() string {
-	p, i := randomGreeting()
-	return i + " " + p
-}
@@ -0,0 +1,228 @@
+// Package telegramtest — поддельный сервер Telegram Bot API на httptest для
+// сквозных тестов: бот ходит в него по HTTP через Config.APIURL, тест
+// подкладывает обновления и проверяет, какие методы бот вызвал.
+package telegramtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BotID — ID бота, которого возвращает getMe. getChatMember отвечает для него
+// администратором с правами удаления и блокировки, для остальных — участником.
+const BotID = 1
+
+// Call — вызов метода Bot API.
+type Call struct {
+	Method    string
+	Params    map[string]interface{}
+	MessageID int64 // ID отправленного сообщения для send*-методов
+}
+
+// Int возвращает целочисленный параметр вызова (JSON-числа приходят как float64).
+func (c Call) Int(name string) int64 {
+	v, _ := c.Params[name].(float64)
+	return int64(v)
+}
+
+// failure — заданный тестом ответ с ошибкой.
+type failure struct {
+	code        int
+	description string
+	times       int
+}
+
+// Server — поддельный Bot API. Нулевое значение непригодно, используйте NewServer.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	changed  chan struct{}     // закрывается и пересоздаётся при каждом изменении
+	updates  []json.RawMessage // updates[i] имеет update_id i+1
+	nextID   int64             // update_id следующего обновления
+	msgID    int64
+	calls    []Call
+	failures map[string]*failure
+}
+
+// NewServer запускает сервер; закройте его через Close.
+func NewServer() *Server {
+	s := &Server{changed: make(chan struct{}), nextID: 1, failures: make(map[string]*failure)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// notify будит ожидающих getUpdates и WaitFor. Вызывается под mu.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// PushUpdate ставит обновление в очередь getUpdates. update_id проставляется
+// сервером; update — любое значение, сериализуемое в объект Update.
+func (s *Server) PushUpdate(update interface{}) {
+	raw, err := json.Marshal(update)
+	if err != nil {
+		panic(err)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		panic(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj["update_id"] = s.nextID
+	s.nextID++
+	raw, _ = json.Marshal(obj)
+	s.updates = append(s.updates, raw)
+	s.notify()
+}
+
+// Fail заставляет следующие times вызовов method вернуть ошибку code с description.
+func (s *Server) Fail(method string, code int, description string, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = &failure{code: code, description: description, times: times}
+}
+
+// Calls возвращает все вызовы метода method (пустой — всех методов, кроме getUpdates).
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.callsLocked(method)
+}
+
+func (s *Server) callsLocked(method string) []Call {
+	var out []Call
+	for _, c := range s.calls {
+		if method == "" || c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// WaitFor ждёт до timeout первого вызова method, для которого match
+// (может быть nil) вернёт true.
+func (s *Server) WaitFor(method string, timeout time.Duration, match func(Call) bool) (Call, bool) {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		for _, c := range s.callsLocked(method) {
+			if match == nil || match(c) {
+				s.mu.Unlock()
+				return c, true
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-changed:
+		case <-deadline:
+			return Call{}, false
+		}
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	params := map[string]interface{}{}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		// загрузка файла: поля — строками, файлы — именами
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			for k, v := range r.MultipartForm.Value {
+				params[k] = v[0]
+			}
+			for k, f := range r.MultipartForm.File {
+				params[k] = f[0].Filename
+			}
+		}
+	} else {
+		_ = json.NewDecoder(r.Body).Decode(&params)
+	}
+
+	if method == "getUpdates" {
+		s.getUpdates(w, r, params)
+		return
+	}
+
+	s.mu.Lock()
+	call := Call{Method: method, Params: params}
+	if f := s.failures[method]; f != nil && f.times > 0 {
+		f.times--
+		s.calls = append(s.calls, call)
+		s.notify()
+		s.mu.Unlock()
+		writeJSON(w, f.code, map[string]interface{}{"ok": false, "error_code": f.code, "description": f.description})
+		return
+	}
+	result := s.result(method, params)
+	if msg, ok := result.(map[string]interface{}); ok && msg["message_id"] != nil {
+		call.MessageID = s.msgID
+	}
+	s.calls = append(s.calls, call)
+	s.notify()
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "result": result})
+}
+
+// result — ответ на успешный вызов. Вызывается под mu.
+func (s *Server) result(method string, params map[string]interface{}) interface{} {
+	switch method {
+	case "getMe":
+		return map[string]interface{}{"id": BotID, "is_bot": true, "first_name": "Hamster", "username": "hamster_test_bot"}
+	case "sendMessage", "sendPhoto", "sendAnimation", "sendSticker", "sendDocument":
+		s.msgID++
+		return map[string]interface{}{"message_id": s.msgID, "chat": map[string]interface{}{"id": params["chat_id"]}, "date": time.Now().Unix()}
+	case "getChat":
+		return map[string]interface{}{"id": params["chat_id"], "type": "supergroup"}
+	case "getChatMember":
+		user, _ := params["user_id"].(float64)
+		if int64(user) == BotID {
+			return map[string]interface{}{"status": "administrator", "user": map[string]interface{}{"id": BotID, "is_bot": true},
+				"can_delete_messages": true, "can_restrict_members": true}
+		}
+		return map[string]interface{}{"status": "member", "user": map[string]interface{}{"id": user}}
+	case "getChatAdministrators":
+		return []interface{}{}
+	default:
+		return true
+	}
+}
+
+// getUpdates отдаёт обновления с update_id >= offset, а если их нет — ждёт до timeout секунд.
+func (s *Server) getUpdates(w http.ResponseWriter, r *http.Request, params map[string]interface{}) {
+	offset, _ := params["offset"].(float64)
+	timeout, _ := params["timeout"].(float64)
+	deadline := time.After(time.Duration(timeout * float64(time.Second)))
+	for {
+		s.mu.Lock()
+		first := max(int64(offset)-1, 0) // update_id = индекс + 1
+		if first < int64(len(s.updates)) {
+			out := s.updates[first:]
+			s.mu.Unlock()
+			writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "result": out})
+			return
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-changed:
+		case <-deadline:
+			writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "result": []interface{}{}})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
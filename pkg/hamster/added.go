@@ -0,0 +1,106 @@
+package hamster
+
+import (
+	"strings"
+	"time"
+)
+
+// ==========================
+// Участники, добавленные администраторами
+// ==========================
+
+const (
+	// AdminAddedSkip — добавленные администратором не проходят проверку (по умолчанию).
+	AdminAddedSkip = "skip"
+	// AdminAddedWelcome — проверки нет, но приветствие отправляется.
+	AdminAddedWelcome = "welcome"
+	// AdminAddedCheck — добавленные администратором проверяются как все.
+	AdminAddedCheck = "check"
+)
+
+// addedWelcomeTTL — сколько висит приветствие добавленного администратором,
+// если приветствия в чате не оставляются (/keepgreeting).
+const addedWelcomeTTL = time.Minute
+
+// AdminAddedMode возвращает режим для добавленных администраторами.
+func (c ChatSettings) AdminAddedMode() string {
+	if c.AdminAdded == "" {
+		return AdminAddedSkip
+	}
+	return c.AdminAdded
+}
+
+// addedByAdmin сообщает, что участника добавил администратор чата, а не
+// он вступил сам: в сервисном сообщении и chat_member From — тот, кто добавил.
+func (b *Bot) addedByAdmin(msg *Message, user *User) bool {
+	if msg.From == nil || msg.From.ID == user.ID {
+		return false
+	}
+	if b.chatSettings(msg.Chat.ID).AdminAddedMode() == AdminAddedCheck {
+		return false
+	}
+	return b.isAdmin(b.ctx, msg.Chat.ID, msg.From.ID)
+}
+
+// trustAdded пропускает добавленного администратором без проверки и, если
+// так настроено, приветствует его.
+func (b *Bot) trustAdded(msg *Message, user *User) {
+	chatID := msg.Chat.ID
+	b.logger.Info("Участник %d добавлен администратором %d в чат %d — без проверки", user.ID, msg.From.ID, chatID)
+	b.markVerified(chatID, user.ID)
+	cs := b.chatSettings(chatID)
+	if cs.AdminAddedMode() != AdminAddedWelcome {
+		return
+	}
+	text := greetingHTML(cs.Welcome(), user)
+	if cs.PlainText {
+		text = stripEmoji(text)
+	}
+	opts := cs.sendOptions(MsgGreeting)
+	opts.ParseMode = ParseModeHTML
+	msgID := b.safeSend(b.ctx, chatID, text, nil, opts)
+	if cs.KeepGreeting {
+		b.keepSent(chatID, msgID)
+		return
+	}
+	time.AfterFunc(addedWelcomeTTL, func() {
+		b.safeDeleteMessage(b.ctx, chatID, msgID)
+	})
+}
+
+// ==========================
+// Команда /adminadd
+// ==========================
+
+var adminAddedNames = map[string]string{
+	AdminAddedSkip:    "без проверки",
+	AdminAddedWelcome: "без проверки, с приветствием",
+	AdminAddedCheck:   "обычная проверка",
+}
+
+func (b *Bot) handleAdminAddCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может это настраивать", 5*time.Second)
+		return
+	}
+
+	const usage = "⚙️ Использование: /adminadd skip|welcome|check"
+	switch mode := strings.ToLower(commandArg(msg.Text, 1)); mode {
+	case AdminAddedSkip, AdminAddedWelcome, AdminAddedCheck:
+		b.updateChatSettings(chatID, func(c *ChatSettings) {
+			c.AdminAdded = mode
+			if mode == AdminAddedSkip {
+				c.AdminAdded = ""
+			}
+		})
+		b.sendTemporary(chatID, "✅ Добавленные администратором: "+adminAddedNames[mode], 5*time.Second)
+	case "":
+		b.sendTemporary(chatID, "👤 Добавленные администратором: "+adminAddedNames[b.chatSettings(chatID).AdminAddedMode()]+"\n"+usage, 10*time.Second)
+	default:
+		b.sendTemporary(chatID, usage, 5*time.Second)
+	}
+}
@@ -0,0 +1,91 @@
+package hamster
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdminAddedSkipsVerification(t *testing.T) {
+	b := setupBot()
+	b.verified = newVerifiedUsers()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	var mu sync.Mutex
+	var greetings, sent []string
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		greetings = append(greetings, text)
+		return 10
+	}
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, text)
+		return 11
+	}
+
+	admin := &User{ID: 10, FirstName: "Админ"}
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, From: admin, NewChatMembers: []*User{{ID: 42, FirstName: "Аня"}}})
+	if !isVerified(b, -100, 42) || b.pendingProgress(-100, 42) != nil {
+		t.Error("добавленный администратором не проходит проверку")
+	}
+
+	b.settings.Update(-100, func(c *ChatSettings) { c.AdminAdded = AdminAddedWelcome })
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, From: admin, NewChatMembers: []*User{{ID: 43, FirstName: "Боря"}}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(greetings) != 0 {
+		t.Errorf("приветствия с проверкой быть не должно: %v", greetings)
+	}
+	if len(sent) != 1 || !strings.Contains(sent[0], "Боря") {
+		t.Errorf("в режиме welcome ожидали одно приветствие без проверки: %v", sent)
+	}
+}
+
+func TestAdminAddedCheckAndSelfJoin(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+		"-100:20": {status: "member", expiresAt: time.Now().Add(time.Minute)},
+	}
+	var mu sync.Mutex
+	greeted := 0
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		greeted++
+		return int64(greeted)
+	}
+
+	// сам вступил и добавлен обычным участником — проверка как обычно
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, From: &User{ID: 1}, NewChatMembers: []*User{{ID: 1, FirstName: "Сам"}}})
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, From: &User{ID: 20}, NewChatMembers: []*User{{ID: 2, FirstName: "Друг"}}})
+	// режим check — проверяются и добавленные администратором
+	b.settings.Update(-100, func(c *ChatSettings) { c.AdminAdded = AdminAddedCheck })
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, NewChatMembers: []*User{{ID: 3, FirstName: "Гость"}}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if greeted != 3 {
+		t.Errorf("ожидали три проверки, получили %d", greeted)
+	}
+}
+
+func TestAdminAddCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	b.handleAdminAddCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/adminadd welcome"})
+	if got := b.chatSettings(-100).AdminAddedMode(); got != AdminAddedWelcome {
+		t.Fatalf("ожидали режим welcome, получили %q", got)
+	}
+	b.handleAdminAddCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/adminadd skip"})
+	if got := b.chatSettings(-100).AdminAdded; got != "" {
+		t.Errorf("skip — значение по умолчанию и не хранится: %q", got)
+	}
+	if err := (ChatSettings{AdminAdded: "maybe"}).Validate(); err == nil {
+		t.Error("неизвестный режим admin_added должен отклоняться")
+	}
+}
@@ -0,0 +1,205 @@
+package hamster
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// REST API для операторов
+// ==========================
+
+// ChatInfo — сводка по чату в ответе GET /api/chats.
+type ChatInfo struct {
+	ChatID   int64        `json:"chat_id"`
+	Settings ChatSettings `json:"settings"`
+	Stats    ChatStats    `json:"stats"`
+	Pending  int          `json:"pending"`
+}
+
+// PendingVerification — незавершённая проверка нового участника.
+type PendingVerification struct {
+	ChatID    int64     `json:"chat_id"`
+	UserID    int64     `json:"user_id"`
+	StartedAt time.Time `json:"started_at"`
+	Deadline  time.Time `json:"deadline"`
+}
+
+// pendingVerifications возвращает незавершённые проверки, по времени начала.
+func (b *Bot) pendingVerifications() []PendingVerification {
+	out := make([]PendingVerification, 0, b.progressStore.len())
+	b.progressStore.each(func(p *progressData) {
+		out = append(out, PendingVerification{
+			ChatID:    p.chatID,
+			UserID:    p.userID,
+			StartedAt: p.startedAt,
+			Deadline:  p.startedAt.Add(time.Duration(p.timeout) * time.Second),
+		})
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt.Before(out[j].StartedAt) })
+	return out
+}
+
+// knownChats — чаты, о которых у бота есть настройки, статистика или проверки в процессе.
+func (b *Bot) knownChats() []ChatInfo {
+	pending := make(map[int64]int)
+	for _, p := range b.pendingVerifications() {
+		pending[p.ChatID]++
+	}
+	ids := make(map[int64]struct{})
+	for _, id := range b.settings.ChatIDs() {
+		ids[id] = struct{}{}
+	}
+	for id := range b.stats.Snapshot() {
+		ids[id] = struct{}{}
+	}
+	for id := range pending {
+		ids[id] = struct{}{}
+	}
+
+	out := make([]ChatInfo, 0, len(ids))
+	for id := range ids {
+		st := b.stats.Get(id)
+		st.Series = nil // ряд — в /api/chats/{id}/series, панель опрашивает список каждые 5 секунд
+		out = append(out, ChatInfo{ChatID: id, Settings: b.chatSettings(id), Stats: st, Pending: pending[id]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ChatID < out[j].ChatID })
+	return out
+}
+
+// AdminHandler возвращает HTTP-обработчик REST API и веб-панели. Все запросы
+// к /api/ требуют заголовка "Authorization: Bearer <ADMIN_API_TOKEN>".
+func (b *Bot) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/chats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, b.knownChats())
+	})
+	mux.HandleFunc("GET /api/chats/{chat}/settings", b.withChatID(func(w http.ResponseWriter, r *http.Request, chatID int64) {
+		writeJSON(w, http.StatusOK, b.chatSettings(chatID))
+	}))
+	mux.HandleFunc("PUT /api/chats/{chat}/settings", b.leaderOnly(b.withChatID(b.apiPutSettings)))
+	mux.HandleFunc("GET /api/chats/{chat}/stats", b.withChatID(func(w http.ResponseWriter, r *http.Request, chatID int64) {
+		writeJSON(w, http.StatusOK, b.stats.Get(chatID))
+	}))
+	mux.HandleFunc("GET /api/chats/{chat}/series", b.withChatID(b.apiChatSeries))
+	mux.HandleFunc("POST /api/chats/{chat}/unban/{user}", b.withChatID(b.apiUnban))
+	mux.HandleFunc("GET /api/chats/{chat}/banlog", b.withChatID(b.apiBanLog))
+	mux.HandleFunc("DELETE /api/users/{user}", b.leaderOnly(b.apiForgetUser))
+	mux.HandleFunc("GET /api/pending", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, b.pendingVerifications())
+	})
+	mux.HandleFunc("GET /api/stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, b.stats.Snapshot())
+	})
+	mux.HandleFunc("GET /api/series", b.apiSeries)
+	mux.HandleFunc("GET /api/bans", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, b.recentBans.list())
+	})
+	mux.HandleFunc("GET /api/audit", b.apiAudit)
+	mux.HandleFunc("GET /api/metrics", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, b.CacheMetrics())
+	})
+
+	root := http.NewServeMux()
+	root.Handle("/api/", b.requireToken(mux))
+	root.Handle("GET /metrics", b.requireToken(http.HandlerFunc(b.serveMetrics)))
+	root.HandleFunc("GET /{$}", serveDashboard)
+	return root
+}
+
+// apiPutSettings заменяет настройки чата целиком. Пустой объект сбрасывает их.
+func (b *Bot) apiPutSettings(w http.ResponseWriter, r *http.Request, chatID int64) {
+	var cs ChatSettings
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cs); err != nil {
+		writeError(w, http.StatusBadRequest, "некорректный JSON: "+err.Error())
+		return
+	}
+	if err := cs.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var old ChatSettings
+	b.updateChatSettings(chatID, func(c *ChatSettings) { old, *c = *c, cs })
+	b.auditSettingsPut(chatID, old, cs)
+	writeJSON(w, http.StatusOK, b.chatSettings(chatID))
+}
+
+func (b *Bot) apiUnban(w http.ResponseWriter, r *http.Request, chatID int64) {
+	userID, err := strconv.ParseInt(r.PathValue("user"), 10, 64)
+	if err != nil || userID <= 0 {
+		writeError(w, http.StatusBadRequest, "некорректный ID пользователя")
+		return
+	}
+	b.safeUnbanUser(b.ctx, chatID, userID)
+	b.logger.Info("REST API: пользователь %d разбанен в чате %d", userID, chatID)
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// withChatID разбирает {chat} из пути.
+func (b *Bot) withChatID(h func(w http.ResponseWriter, r *http.Request, chatID int64)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chatID, err := strconv.ParseInt(r.PathValue("chat"), 10, 64)
+		if err != nil || chatID == 0 {
+			writeError(w, http.StatusBadRequest, "некорректный ID чата")
+			return
+		}
+		h(w, r, chatID)
+	}
+}
+
+func (b *Bot) requireToken(next http.Handler) http.Handler {
+	want := []byte(b.cfg.AdminAPIToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || len(want) == 0 || subtle.ConstantTimeCompare([]byte(got), want) != 1 {
+			writeError(w, http.StatusUnauthorized, "требуется токен")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// ServeAdminAPI запускает REST API на AdminAPIAddr до отмены ctx.
+func (b *Bot) ServeAdminAPI(ctx context.Context) {
+	if b.cfg.AdminAPIAddr == "" {
+		return
+	}
+	if b.cfg.AdminAPIToken == "" {
+		b.logger.Warn("ADMIN_API_ADDR задан без ADMIN_API_TOKEN — REST API не запущен")
+		return
+	}
+	srv := &http.Server{
+		Addr:              b.cfg.AdminAPIAddr,
+		Handler:           b.AdminHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	b.logger.Info("🌐 REST API слушает %s", b.cfg.AdminAPIAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		b.logger.Error("REST API остановлен: %v", err)
+	}
+}
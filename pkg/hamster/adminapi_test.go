@@ -0,0 +1,132 @@
+package hamster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupAdminBot() *Bot {
+	b := setupBot()
+	b.stats = NewStats()
+	b.cfg.AdminAPIToken = "secret"
+	return b
+}
+
+func adminRequest(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminAPIRequiresToken(t *testing.T) {
+	h := setupAdminBot().AdminHandler()
+	for _, auth := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest("GET", "/api/chats", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: код %d, ожидали 401", auth, rec.Code)
+		}
+	}
+
+	// без настроенного токена API закрыт полностью
+	b := setupAdminBot()
+	b.cfg.AdminAPIToken = ""
+	req := httptest.NewRequest("GET", "/api/chats", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	b.AdminHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("пустой токен: код %d, ожидали 401", rec.Code)
+	}
+}
+
+func TestAdminAPISettings(t *testing.T) {
+	b := setupAdminBot()
+	h := b.AdminHandler()
+
+	rec := adminRequest(t, h, "PUT", "/api/chats/-100/settings", `{"timeout":120,"action":"kick"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT: код %d, тело %s", rec.Code, rec.Body)
+	}
+	cs := b.settings.Get(-100)
+	if cs.Timeout != 120 || cs.Action != ActionKick {
+		t.Errorf("настройки не применились: %+v", cs)
+	}
+
+	rec = adminRequest(t, h, "GET", "/api/chats/-100/settings", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"timeout":120`) {
+		t.Errorf("GET: код %d, тело %s", rec.Code, rec.Body)
+	}
+
+	for _, body := range []string{`{"timeout":1}`, `{"action":"nuke"}`, `{"unknown":1}`, `not json`} {
+		rec = adminRequest(t, h, "PUT", "/api/chats/-100/settings", body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("PUT %s: код %d, ожидали 400", body, rec.Code)
+		}
+	}
+	if b.settings.Get(-100).Timeout != 120 {
+		t.Error("некорректный запрос изменил настройки")
+	}
+
+	rec = adminRequest(t, h, "GET", "/api/chats/abc/settings", "")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("некорректный ID чата: код %d", rec.Code)
+	}
+}
+
+func TestAdminAPIChatsAndPending(t *testing.T) {
+	b := setupAdminBot()
+	b.settings.SetTimeout(-1, 30)
+	b.stats.add(-2, func(s *ChatStats) { s.Joins++ })
+	started := time.Now().Add(-10 * time.Second)
+	b.progressStore.add(&progressData{chatID: -3, userID: 42, greetMsgID: 7, startedAt: started, timeout: 60})
+	h := b.AdminHandler()
+
+	rec := adminRequest(t, h, "GET", "/api/chats", "")
+	body := rec.Body.String()
+	for _, want := range []string{`"chat_id":-1`, `"chat_id":-2`, `"chat_id":-3`, `"pending":1`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("в списке чатов нет %s: %s", want, body)
+		}
+	}
+
+	rec = adminRequest(t, h, "GET", "/api/pending", "")
+	if !strings.Contains(rec.Body.String(), `"user_id":42`) {
+		t.Errorf("нет проверки в процессе: %s", rec.Body)
+	}
+	pending := b.pendingVerifications()
+	if len(pending) != 1 || !pending[0].Deadline.Equal(started.Add(time.Minute)) {
+		t.Errorf("неверный дедлайн: %+v", pending)
+	}
+
+	rec = adminRequest(t, h, "GET", "/api/chats/-2/stats", "")
+	if !strings.Contains(rec.Body.String(), `"joins":1`) {
+		t.Errorf("статистика чата: %s", rec.Body)
+	}
+}
+
+func TestAdminAPIUnban(t *testing.T) {
+	b := setupAdminBot()
+	var gotChat, gotUser int64
+	fakeOf(b).unban = func(chatID, userID int64) { gotChat, gotUser = chatID, userID }
+	h := b.AdminHandler()
+
+	rec := adminRequest(t, h, "POST", "/api/chats/-100/unban/42", "")
+	if rec.Code != http.StatusOK || gotChat != -100 || gotUser != 42 {
+		t.Errorf("unban: код %d, chat %d, user %d", rec.Code, gotChat, gotUser)
+	}
+	rec = adminRequest(t, h, "GET", "/api/chats/-100/unban/42", "")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET на unban: код %d, ожидали 405", rec.Code)
+	}
+}
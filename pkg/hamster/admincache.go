@@ -0,0 +1,120 @@
+package hamster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Кэш статусов администраторов
+// ==========================
+
+// adminCacheTTL — сколько доверять статусу из getChatMember. Назначения и
+// снятия админов приходят в chat_member и сбрасывают запись раньше.
+const adminCacheTTL = 30 * time.Minute
+
+// adminCall — запрос getChatMember в процессе. Одновременные проверки одного
+// участника (несколько команд или вступлений подряд) ждут общий ответ.
+type adminCall struct {
+	done      chan struct{}
+	status    string
+	err       error
+	forgotten bool // статус сброшен во время запроса — ответ не кэшировать
+}
+
+// isAdminStatus — статус означает права администратора.
+func isAdminStatus(status string) bool {
+	return status == "creator" || status == "administrator"
+}
+
+// adminCacheKey — ключ статуса участника в adminCache.
+func adminCacheKey(chatID, userID int64) string {
+	return fmt.Sprintf("%d:%d", chatID, userID)
+}
+
+// adminStatus возвращает статус участника из кэша или из getChatMember.
+func (b *Bot) adminStatus(ctx context.Context, chatID, userID int64) (string, error) {
+	key := adminCacheKey(chatID, userID)
+	b.muAdmin.Lock()
+	if entry, ok := b.adminCache[key]; ok && time.Now().Before(entry.expiresAt) {
+		b.muAdmin.Unlock()
+		return entry.status, nil
+	}
+	if status, ok := b.adminListStatus(chatID, userID); ok {
+		b.muAdmin.Unlock()
+		return status, nil
+	}
+	if c, ok := b.adminCalls[key]; ok {
+		b.muAdmin.Unlock()
+		select {
+		case <-c.done:
+			return c.status, c.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	c := &adminCall{done: make(chan struct{})}
+	if b.adminCalls == nil {
+		b.adminCalls = make(map[string]*adminCall)
+	}
+	b.adminCalls[key] = c
+	b.muAdmin.Unlock()
+
+	member, err := b.safeGetChatMember(ctx, chatID, userID)
+	c.status, c.err = member.Status, err
+
+	b.muAdmin.Lock()
+	delete(b.adminCalls, key)
+	if err == nil && !c.forgotten {
+		b.cacheAdminStatus(key, adminCacheEntry{status: c.status, expiresAt: time.Now().Add(adminCacheTTL)})
+	}
+	b.muAdmin.Unlock()
+	close(c.done)
+	return c.status, c.err
+}
+
+// cacheAdminStatus запоминает статус участника, вытесняя самые давние записи
+// сверх MaxAdminCache. Вызывается под muAdmin.
+func (b *Bot) cacheAdminStatus(key string, entry adminCacheEntry) {
+	if b.adminCache == nil {
+		b.adminCache = make(map[string]adminCacheEntry)
+	}
+	b.adminCache[key] = entry
+	for _, old := range b.adminLRU.touch(key, b.cfg.MaxAdminCache) {
+		delete(b.adminCache, old)
+	}
+}
+
+// forgetAdminStatus сбрасывает статус участника: его права изменились.
+func (b *Bot) forgetAdminStatus(chatID, userID int64) {
+	key := adminCacheKey(chatID, userID)
+	b.muAdmin.Lock()
+	defer b.muAdmin.Unlock()
+	delete(b.adminCache, key)
+	b.adminLRU.remove(key)
+	if c, ok := b.adminCalls[key]; ok {
+		c.forgotten = true
+	}
+}
+
+// forgetChatAdmins убирает из кэша статусы участников и список админов чата.
+func (b *Bot) forgetChatAdmins(chatID int64) {
+	prefix := fmt.Sprintf("%d:", chatID)
+	b.muAdmin.Lock()
+	defer b.muAdmin.Unlock()
+	delete(b.adminLists, chatID)
+	delete(b.adminChats, chatID)
+	for key := range b.adminCache {
+		if strings.HasPrefix(key, prefix) {
+			delete(b.adminCache, key)
+			b.adminLRU.remove(key)
+		}
+	}
+	for key, c := range b.adminCalls {
+		if strings.HasPrefix(key, prefix) {
+			c.forgotten = true
+		}
+	}
+}
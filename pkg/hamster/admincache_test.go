@@ -0,0 +1,83 @@
+package hamster
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAdminStatusCoalescesLookups(t *testing.T) {
+	b := setupBot()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		calls.Add(1)
+		<-release
+		return ChatMember{Status: "administrator"}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]bool, 5)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = b.isAdmin(b.ctx, -100, 10)
+		}()
+	}
+	// ждём, пока первый запрос уйдёт в API, а остальные встанут в очередь за ним
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("одновременные проверки должны делать один запрос, сделано %d", n)
+	}
+	for i, ok := range results {
+		if !ok {
+			t.Errorf("проверка %d: ожидали администратора", i)
+		}
+	}
+	if !b.isAdmin(b.ctx, -100, 10) || calls.Load() != 1 {
+		t.Error("повторная проверка должна браться из кэша")
+	}
+}
+
+func TestChatMemberPromotionResetsAdminCache(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "member", expiresAt: time.Now().Add(time.Minute)},
+		"-100:11": {status: "member", expiresAt: time.Now().Add(time.Minute)},
+	}
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "administrator"}, nil
+	}
+
+	b.handleChatMember(&ChatMemberUpdated{
+		Chat:          Chat{ID: -100},
+		OldChatMember: ChatMember{Status: "member", User: &User{ID: 10}},
+		NewChatMember: ChatMember{Status: "administrator", User: &User{ID: 10}},
+	})
+	if !b.isAdmin(b.ctx, -100, 10) {
+		t.Error("назначенный админ должен сразу получать доступ к командам")
+	}
+	if _, ok := b.adminCache["-100:11"]; !ok {
+		t.Error("статусы других участников сбрасываться не должны")
+	}
+}
+
+func TestForgetDuringLookupSkipsCache(t *testing.T) {
+	b := setupBot()
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		b.forgetAdminStatus(chatID, userID) // права сменились, пока шёл запрос
+		return ChatMember{Status: "administrator"}, nil
+	}
+	b.isAdmin(b.ctx, -100, 10)
+	if _, ok := b.adminCache["-100:10"]; ok {
+		t.Error("устаревший ответ не должен попадать в кэш")
+	}
+}
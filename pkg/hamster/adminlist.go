@@ -0,0 +1,123 @@
+package hamster
+
+import (
+	"context"
+	"time"
+)
+
+// ==========================
+// Списки администраторов (getChatAdministrators)
+// ==========================
+
+const (
+	// defaultAdminRefresh — как часто обновлять списки админов по умолчанию.
+	defaultAdminRefresh = 10 * time.Minute
+	// adminChatIdle — список чата, где давно не проверяли админов, не обновляется.
+	adminChatIdle = time.Hour
+)
+
+// adminList — администраторы чата из getChatAdministrators. Кто не в списке,
+// тот не администратор: одного запроса хватает на всех участников чата.
+type adminList struct {
+	statuses  map[int64]string // userID → creator | administrator
+	fetchedAt time.Time
+}
+
+// adminListStatus возвращает статус из свежего списка админов чата и отмечает
+// чат активным, чтобы RunAdminRefresh держал его список. Вызывается под muAdmin.
+func (b *Bot) adminListStatus(chatID, userID int64) (string, bool) {
+	if b.adminChats == nil {
+		b.adminChats = make(map[int64]time.Time)
+	}
+	b.adminChats[chatID] = time.Now()
+	l, ok := b.adminLists[chatID]
+	// список считается устаревшим, если пропущено два обновления подряд
+	if !ok || b.cfg.AdminRefreshInterval <= 0 || time.Since(l.fetchedAt) > 2*b.cfg.AdminRefreshInterval {
+		return "", false
+	}
+	if status, ok := l.statuses[userID]; ok {
+		return status, true
+	}
+	return "member", true
+}
+
+// refreshAdminList загружает список администраторов чата.
+func (b *Bot) refreshAdminList(chatID int64) error {
+	admins, err := b.safeGetChatAdministrators(b.ctx, chatID)
+	if err != nil {
+		return err
+	}
+	l := &adminList{statuses: make(map[int64]string, len(admins)), fetchedAt: time.Now()}
+	for _, a := range admins {
+		if a.User != nil {
+			l.statuses[a.User.ID] = a.Status
+		}
+	}
+	b.muAdmin.Lock()
+	defer b.muAdmin.Unlock()
+	if b.adminLists == nil {
+		b.adminLists = make(map[int64]*adminList)
+	}
+	b.adminLists[chatID] = l
+	return nil
+}
+
+// updateAdminList применяет к списку чата назначение или снятие администратора
+// из chat_member, не дожидаясь следующего обновления.
+func (b *Bot) updateAdminList(chatID int64, m ChatMember) {
+	if m.User == nil {
+		return
+	}
+	b.muAdmin.Lock()
+	defer b.muAdmin.Unlock()
+	l, ok := b.adminLists[chatID]
+	if !ok {
+		return
+	}
+	if isAdminStatus(m.Status) {
+		l.statuses[m.User.ID] = m.Status
+	} else {
+		delete(l.statuses, m.User.ID)
+	}
+}
+
+// refreshAdminLists обновляет списки чатов, где недавно проверяли админов, и
+// забывает списки остальных.
+func (b *Bot) refreshAdminLists() {
+	b.muAdmin.Lock()
+	var active []int64
+	for chatID, used := range b.adminChats {
+		if time.Since(used) > adminChatIdle {
+			delete(b.adminChats, chatID)
+			delete(b.adminLists, chatID)
+			continue
+		}
+		active = append(active, chatID)
+	}
+	b.muAdmin.Unlock()
+
+	for _, chatID := range active {
+		if err := b.refreshAdminList(chatID); err != nil {
+			b.logger.Warn("Не удалось получить администраторов чата %d: %v", chatID, err)
+		}
+	}
+}
+
+// RunAdminRefresh обновляет списки администраторов активных чатов каждые
+// AdminRefreshInterval. Без него права проверяются через getChatMember по
+// каждому участнику.
+func (b *Bot) RunAdminRefresh(ctx context.Context) {
+	if b.cfg.AdminRefreshInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(b.cfg.AdminRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.refreshAdminLists()
+		}
+	}
+}
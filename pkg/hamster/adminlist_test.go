@@ -0,0 +1,68 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdminListServesIsAdmin(t *testing.T) {
+	b := setupBot()
+	b.cfg.AdminRefreshInterval = time.Minute
+	lists := 0
+	fakeOf(b).getChatAdministrators = func(chatID int64) ([]ChatMember, error) {
+		lists++
+		return []ChatMember{{Status: "creator", User: &User{ID: 1}}, {Status: "administrator", User: &User{ID: 2}}}, nil
+	}
+
+	b.isAdmin(b.ctx, -100, 1) // чат становится активным — обращение через getChatMember
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		t.Errorf("при свежем списке getChatMember для %d не нужен", userID)
+		return ChatMember{}, nil
+	}
+	b.refreshAdminLists()
+	if lists != 1 {
+		t.Fatalf("список активного чата должен загрузиться, запросов %d", lists)
+	}
+	if !b.isAdmin(b.ctx, -100, 2) || b.isAdmin(b.ctx, -100, 3) {
+		t.Error("статус должен браться из списка: 2 — админ, 3 — нет")
+	}
+}
+
+func TestAdminListFollowsChatMember(t *testing.T) {
+	b := setupBot()
+	b.cfg.AdminRefreshInterval = time.Minute
+	b.adminLists = map[int64]*adminList{-100: {statuses: map[int64]string{1: "creator", 2: "administrator"}, fetchedAt: time.Now()}}
+
+	b.handleChatMember(&ChatMemberUpdated{
+		Chat:          Chat{ID: -100},
+		OldChatMember: ChatMember{Status: "administrator", User: &User{ID: 2}},
+		NewChatMember: ChatMember{Status: "member", User: &User{ID: 2}},
+	})
+	b.handleChatMember(&ChatMemberUpdated{
+		Chat:          Chat{ID: -100},
+		OldChatMember: ChatMember{Status: "member", User: &User{ID: 3}},
+		NewChatMember: ChatMember{Status: "administrator", User: &User{ID: 3}},
+	})
+	if b.isAdmin(b.ctx, -100, 2) || !b.isAdmin(b.ctx, -100, 3) {
+		t.Error("назначения и снятия из chat_member должны сразу попадать в список")
+	}
+}
+
+func TestAdminListStaleOrIdle(t *testing.T) {
+	b := setupBot()
+	b.cfg.AdminRefreshInterval = time.Minute
+	b.adminLists = map[int64]*adminList{-100: {statuses: map[int64]string{}, fetchedAt: time.Now().Add(-time.Hour)}}
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "administrator"}, nil
+	}
+	if !b.isAdmin(b.ctx, -100, 1) {
+		t.Error("устаревший список не должен использоваться")
+	}
+
+	b.adminChats[-200] = time.Now().Add(-2 * adminChatIdle)
+	b.adminLists[-200] = &adminList{}
+	b.refreshAdminLists()
+	if _, ok := b.adminLists[-200]; ok {
+		t.Error("список чата без активности должен забываться")
+	}
+}
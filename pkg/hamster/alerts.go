@@ -0,0 +1,167 @@
+package hamster
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Письма о критических событиях
+// ==========================
+
+// Критические события. Письмо о каждом (для чата — о каждом чате) уходит не
+// чаще SMTPConfig.Interval.
+const (
+	alertAdminLost    = "admin_lost"    // бота лишили прав администратора
+	alertToken        = "token"         // Telegram не принимает токен
+	alertStorage      = "storage"       // не удалось записать в хранилище
+	alertRaid         = "raid"          // наплыв длится дольше sustainedRaid
+	alertCommandFlood = "command_flood" // слишком частые команды настройки
+)
+
+const (
+	defaultAlertInterval = time.Hour
+	// maxAlertsPerHour — сколько писем всего отправлять за час, даже если
+	// событий разных видов больше: например, бота разжаловали во многих чатах.
+	maxAlertsPerHour = 10
+	// sustainedRaid — через сколько наплыв считается затяжным.
+	sustainedRaid = 15 * time.Minute
+)
+
+// SMTPConfig — почтовый сервер и получатели писем о критических событиях.
+type SMTPConfig struct {
+	Addr     string // host:port сервера, например smtp.example.com:587
+	User     string // пусто — без авторизации
+	Password string
+	From     string
+	To       []string
+	// Interval — не чаще скольких писем об одном событии. 0 — раз в час.
+	Interval time.Duration
+}
+
+func (c SMTPConfig) enabled() bool {
+	return c.Addr != "" && len(c.To) > 0
+}
+
+func (c SMTPConfig) validate() error {
+	if c.Addr == "" && len(c.To) == 0 {
+		return nil
+	}
+	if c.Addr == "" || len(c.To) == 0 || c.From == "" {
+		return errors.New("для писем нужны SMTP_ADDR, SMTP_FROM и ALERT_EMAIL")
+	}
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		return fmt.Errorf("SMTP_ADDR должен быть в виде host:port: %q", c.Addr)
+	}
+	return nil
+}
+
+// emailAlerter отправляет письма, ограничивая их частоту.
+type emailAlerter struct {
+	cfg SMTPConfig
+	// send — smtp.SendMail, подменяется в тестах.
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+	mu   sync.Mutex
+	last map[string]time.Time // когда было последнее письмо по ключу
+	sent []time.Time          // письма за последний час
+}
+
+func newEmailAlerter(cfg SMTPConfig) *emailAlerter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultAlertInterval
+	}
+	return &emailAlerter{cfg: cfg, send: smtp.SendMail, last: make(map[string]time.Time)}
+}
+
+// allow решает, отправлять ли письмо по ключу, и учитывает его.
+func (a *emailAlerter) allow(key string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if t, ok := a.last[key]; ok && now.Sub(t) < a.cfg.Interval {
+		return false
+	}
+	kept := a.sent[:0]
+	for _, t := range a.sent {
+		if now.Sub(t) < time.Hour {
+			kept = append(kept, t)
+		}
+	}
+	a.sent = kept
+	if len(a.sent) >= maxAlertsPerHour {
+		return false
+	}
+	a.sent = append(a.sent, now)
+	a.last[key] = now
+	return true
+}
+
+// message собирает письмо: UTF-8, тема в кодировке RFC 2047.
+func (a *emailAlerter) message(subject, text string, now time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", a.cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(a.cfg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+func (a *emailAlerter) deliver(subject, text string, now time.Time) error {
+	var auth smtp.Auth
+	if a.cfg.User != "" {
+		host, _, _ := net.SplitHostPort(a.cfg.Addr)
+		auth = smtp.PlainAuth("", a.cfg.User, a.cfg.Password, host)
+	}
+	return a.send(a.cfg.Addr, auth, a.cfg.From, a.cfg.To, a.message(subject, text, now))
+}
+
+// alert отправляет письмо о критическом событии, если по ключу key
+// (событие или событие в чате) их не было за SMTPConfig.Interval.
+func (b *Bot) alert(key, subject, text string) {
+	if b.alerts == nil {
+		return
+	}
+	now := time.Now()
+	if !b.alerts.allow(key, now) {
+		return
+	}
+	go func() {
+		if err := b.alerts.deliver("tg-hamster: "+subject, text, now); err != nil {
+			b.logger.Warn("Не удалось отправить письмо «%s»: %v", subject, err)
+		}
+	}()
+}
+
+// storageWarn пишет в лог ошибку записи в хранилище и сообщает о ней письмом.
+func (b *Bot) storageWarn(what string, err error) {
+	b.logger.Warn("%s: %v", what, err)
+	b.alert(alertStorage, "ошибка записи в хранилище", fmt.Sprintf("%s: %v", what, err))
+}
+
+// isUnauthorized сообщает, что Telegram отверг токен бота.
+func isUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == 401
+}
+
+// sustained сообщает один раз за наплыв, что он длится дольше d.
+func (d *raidDetector) sustained(chatID int64, after time.Duration, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, ok := d.chats[chatID]
+	if !ok || !s.active || s.alerted || now.Sub(s.since) < after {
+		return false
+	}
+	s.alerted = true
+	return true
+}
@@ -0,0 +1,148 @@
+package hamster
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sentMail struct {
+	addr string
+	from string
+	to   []string
+	msg  string
+}
+
+// stubAlerter возвращает отправителя писем, складывающего их в канал.
+func stubAlerter(cfg SMTPConfig) (*emailAlerter, chan sentMail) {
+	a := newEmailAlerter(cfg)
+	mails := make(chan sentMail, 10)
+	a.send = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		mails <- sentMail{addr: addr, from: from, to: to, msg: string(msg)}
+		return nil
+	}
+	return a, mails
+}
+
+func waitMail(t *testing.T, mails <-chan sentMail) sentMail {
+	t.Helper()
+	select {
+	case m := <-mails:
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("письмо не отправлено")
+		return sentMail{}
+	}
+}
+
+func TestEmailAlerterRateLimit(t *testing.T) {
+	a := newEmailAlerter(SMTPConfig{Interval: 30 * time.Minute})
+	now := time.Now()
+	if !a.allow(alertToken, now) {
+		t.Fatal("первое письмо отправляется")
+	}
+	if a.allow(alertToken, now.Add(10*time.Minute)) {
+		t.Error("повторное письмо о том же событии раньше интервала не отправляется")
+	}
+	if !a.allow(alertToken, now.Add(31*time.Minute)) {
+		t.Error("после интервала письмо отправляется снова")
+	}
+
+	b := newEmailAlerter(SMTPConfig{})
+	for i := 0; i < maxAlertsPerHour; i++ {
+		if !b.allow(fmt.Sprintf("%s:%d", alertAdminLost, i), now) {
+			t.Fatalf("письмо %d в пределах часового лимита", i)
+		}
+	}
+	if b.allow(alertStorage, now) {
+		t.Error("сверх часового лимита письма не отправляются")
+	}
+	if !b.allow(alertStorage, now.Add(time.Hour)) {
+		t.Error("через час лимит обновляется")
+	}
+}
+
+func TestEmailAlerterMessage(t *testing.T) {
+	a, mails := stubAlerter(SMTPConfig{Addr: "smtp.example.com:587", From: "bot@example.com", To: []string{"ops@example.com", "dev@example.com"}})
+	if err := a.deliver("токен бота недействителен", "строка 1\nстрока 2", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	m := waitMail(t, mails)
+	if m.addr != "smtp.example.com:587" || m.from != "bot@example.com" || len(m.to) != 2 {
+		t.Errorf("адресаты: %+v", m)
+	}
+	for _, want := range []string{"To: ops@example.com, dev@example.com\r\n", "Subject: =?utf-8?q?", "charset=utf-8", "строка 1\r\nстрока 2"} {
+		if !strings.Contains(m.msg, want) {
+			t.Errorf("в письме нет %q:\n%s", want, m.msg)
+		}
+	}
+}
+
+func TestAlertAdminLost(t *testing.T) {
+	b := setupBot()
+	var mails chan sentMail
+	b.alerts, mails = stubAlerter(SMTPConfig{Addr: "smtp.example.com:25", From: "bot@example.com", To: []string{"ops@example.com"}})
+	b.handleMyChatMember(&ChatMemberUpdated{
+		Chat:          Chat{ID: -100, Type: "supergroup"},
+		OldChatMember: ChatMember{Status: "administrator"},
+		NewChatMember: ChatMember{Status: "member"},
+	})
+	if m := waitMail(t, mails); !strings.Contains(m.msg, "-100") {
+		t.Errorf("в письме нет чата: %s", m.msg)
+	}
+}
+
+func TestAlertStorageAndToken(t *testing.T) {
+	b := setupBot()
+	var mails chan sentMail
+	b.alerts, mails = stubAlerter(SMTPConfig{Addr: "smtp.example.com:25", From: "bot@example.com", To: []string{"ops@example.com"}})
+	b.storageWarn("Не удалось сохранить настройки", errors.New("диск заполнен"))
+	if m := waitMail(t, mails); !strings.Contains(m.msg, "диск заполнен") {
+		t.Errorf("письмо об ошибке хранилища: %s", m.msg)
+	}
+	b.storageWarn("Не удалось сохранить статистику", errors.New("диск заполнен"))
+	select {
+	case m := <-mails:
+		t.Errorf("второе письмо об ошибках хранилища в течение часа: %s", m.msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if !isUnauthorized(fmt.Errorf("очередь: %w", &APIError{Method: "getUpdates", Code: 401, Description: "Unauthorized"})) {
+		t.Error("401 от Bot API — недействительный токен")
+	}
+	if isUnauthorized(&APIError{Method: "getUpdates", Code: 409}) {
+		t.Error("другие ошибки — не токен")
+	}
+}
+
+func TestSustainedRaid(t *testing.T) {
+	d := newRaidDetector()
+	now := time.Now()
+	for i := 0; i < 120; i++ { // вступление каждые 10 секунд 20 минут подряд
+		d.hit(-100, 3, time.Minute, now.Add(time.Duration(i)*10*time.Second))
+	}
+	if d.sustained(-100, sustainedRaid, now.Add(10*time.Minute)) {
+		t.Error("наплыв короче sustainedRaid не затяжной")
+	}
+	if !d.sustained(-100, sustainedRaid, now.Add(19*time.Minute)) {
+		t.Fatal("наплыв дольше sustainedRaid — затяжной")
+	}
+	if d.sustained(-100, sustainedRaid, now.Add(19*time.Minute)) {
+		t.Error("о затяжном наплыве сообщается один раз")
+	}
+}
+
+func TestSMTPConfigValidate(t *testing.T) {
+	if err := (SMTPConfig{}).validate(); err != nil {
+		t.Errorf("без настроек письма выключены: %v", err)
+	}
+	if err := (SMTPConfig{Addr: "smtp.example.com:587", To: []string{"ops@example.com"}}).validate(); err == nil {
+		t.Error("без SMTP_FROM — ошибка")
+	}
+	if err := (SMTPConfig{Addr: "smtp.example.com", From: "a@b", To: []string{"ops@example.com"}}).validate(); err == nil {
+		t.Error("адрес без порта — ошибка")
+	}
+}
@@ -0,0 +1,248 @@
+package hamster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Очередь запросов к Telegram с приоритетами
+// ==========================
+
+// apiPriority — очередность запроса, когда все обработчики заняты
+// (например, ждут после 429).
+type apiPriority int
+
+const (
+	priorityHigh   apiPriority = iota // ответы на нажатия, баны и ограничения
+	priorityNormal                    // отправка и удаление сообщений, чтение
+	priorityLow                       // правки прогрессбаров и подписей
+	priorityLevels
+)
+
+const (
+	// apiWorkers — сколько запросов выполняется одновременно.
+	apiWorkers = 8
+	// apiQueuePressure — при стольких ждущих запросах новые правки отбрасываются:
+	// прогрессбар обновится следующим тиком, а бан ждать не должен.
+	apiQueuePressure = 32
+)
+
+// ErrEditDropped — правка отброшена, потому что очередь запросов переполнена.
+var ErrEditDropped = errors.New("правка отброшена: очередь запросов к Telegram переполнена")
+
+type apiJob struct {
+	run    func() error
+	err    error
+	done   chan struct{}
+	picked time.Time // когда запрос взял обработчик
+}
+
+// queuedAPI пропускает запросы к TelegramAPI через очередь с приоритетами.
+// getUpdates идёт мимо очереди: long polling занимал бы обработчик.
+type queuedAPI struct {
+	api    TelegramAPI
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues [priorityLevels][]*apiJob
+	closed bool
+	wg     sync.WaitGroup
+	tracer *tracer // nil — без трассировки
+}
+
+func newQueuedAPI(api TelegramAPI, workers int) *queuedAPI {
+	q := &queuedAPI{api: api}
+	q.cond = sync.NewCond(&q.mu)
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+func (q *queuedAPI) work() {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		job := q.next()
+		for job == nil && !q.closed {
+			q.cond.Wait()
+			job = q.next()
+		}
+		q.mu.Unlock()
+		if job == nil {
+			return
+		}
+		job.picked = time.Now()
+		job.err = job.run()
+		close(job.done)
+	}
+}
+
+// next снимает с очереди самый приоритетный запрос. Вызывается под mu.
+func (q *queuedAPI) next() *apiJob {
+	for p := range q.queues {
+		if len(q.queues[p]) > 0 {
+			job := q.queues[p][0]
+			q.queues[p] = q.queues[p][1:]
+			return job
+		}
+	}
+	return nil
+}
+
+// waiting — сколько запросов ждут обработчика. Вызывается под mu.
+func (q *queuedAPI) waiting() int {
+	n := 0
+	for _, jobs := range q.queues {
+		n += len(jobs)
+	}
+	return n
+}
+
+// depth — сколько запросов ждут обработчика сейчас.
+func (q *queuedAPI) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiting()
+}
+
+// do ставит запрос в очередь и ждёт его выполнения. Спан запроса включает
+// ожидание в очереди (queue.wait_ms) и повторы. Если ctx отменяют, пока
+// запрос ждёт обработчика, он снимается с очереди и не выполняется.
+func (q *queuedAPI) do(ctx context.Context, p apiPriority, method string, run func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return run()
+	}
+	if p == priorityLow && q.waiting() >= apiQueuePressure {
+		q.mu.Unlock()
+		return ErrEditDropped
+	}
+	sp := q.tracer.start("telegram."+method, nil, spanClient).set("priority", int(p))
+	job := &apiJob{run: run, done: make(chan struct{})}
+	q.queues[p] = append(q.queues[p], job)
+	q.cond.Signal()
+	q.mu.Unlock()
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		if q.cancel(p, job) {
+			sp.end(ctx.Err())
+			return ctx.Err()
+		}
+		<-job.done // уже выполняется — запрос сам прервётся по ctx
+	}
+	if sp != nil {
+		sp.set("queue.wait_ms", job.picked.Sub(sp.start).Milliseconds()).end(job.err)
+	}
+	return job.err
+}
+
+// cancel снимает ещё не взятый запрос с очереди; false — его уже взял обработчик.
+func (q *queuedAPI) cancel(p apiPriority, job *apiJob) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, j := range q.queues[p] {
+		if j == job {
+			q.queues[p] = append(q.queues[p][:i:i], q.queues[p][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// close дожидается уже поставленных запросов и останавливает обработчики;
+// дальнейшие запросы выполняются напрямую.
+func (q *queuedAPI) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+func (q *queuedAPI) GetUpdates(ctx context.Context, offset int64, timeout, limit int) ([]Update, error) {
+	return q.api.GetUpdates(ctx, offset, timeout, limit)
+}
+
+func (q *queuedAPI) GetMe(ctx context.Context) (u User, err error) {
+	err = q.do(ctx, priorityNormal, "getMe", func() error { u, err = q.api.GetMe(ctx); return err })
+	return u, err
+}
+
+func (q *queuedAPI) GetChat(ctx context.Context, chatRef string) (c Chat, err error) {
+	err = q.do(ctx, priorityNormal, "getChat", func() error { c, err = q.api.GetChat(ctx, chatRef); return err })
+	return c, err
+}
+
+func (q *queuedAPI) GetChatMember(ctx context.Context, chatID, userID int64) (m ChatMember, err error) {
+	err = q.do(ctx, priorityNormal, "getChatMember", func() error { m, err = q.api.GetChatMember(ctx, chatID, userID); return err })
+	return m, err
+}
+
+func (q *queuedAPI) GetChatAdministrators(ctx context.Context, chatID int64) (admins []ChatMember, err error) {
+	err = q.do(ctx, priorityNormal, "getChatAdministrators", func() error { admins, err = q.api.GetChatAdministrators(ctx, chatID); return err })
+	return admins, err
+}
+
+func (q *queuedAPI) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts SendOptions) (msgID int64, err error) {
+	err = q.do(ctx, priorityNormal, "sendMessage", func() error { msgID, err = q.api.SendMessage(ctx, chatID, text, markup, opts); return err })
+	return msgID, err
+}
+
+func (q *queuedAPI) EditMessage(ctx context.Context, chatID, msgID int64, text, parseMode string) error {
+	return q.do(ctx, priorityLow, "editMessageText", func() error { return q.api.EditMessage(ctx, chatID, msgID, text, parseMode) })
+}
+
+func (q *queuedAPI) SendMedia(ctx context.Context, chatID int64, media Media, caption string, markup interface{}, opts SendOptions) (msgID int64, err error) {
+	err = q.do(ctx, priorityNormal, "sendMedia", func() error { msgID, err = q.api.SendMedia(ctx, chatID, media, caption, markup, opts); return err })
+	return msgID, err
+}
+
+func (q *queuedAPI) EditCaption(ctx context.Context, chatID, msgID int64, caption, parseMode string) error {
+	return q.do(ctx, priorityLow, "editMessageCaption", func() error { return q.api.EditCaption(ctx, chatID, msgID, caption, parseMode) })
+}
+
+func (q *queuedAPI) SendDocument(ctx context.Context, chatID int64, name string, content []byte, caption string) (msgID int64, err error) {
+	err = q.do(ctx, priorityNormal, "sendDocument", func() error { msgID, err = q.api.SendDocument(ctx, chatID, name, content, caption); return err })
+	return msgID, err
+}
+
+func (q *queuedAPI) DeleteMessage(ctx context.Context, chatID, msgID int64) error {
+	return q.do(ctx, priorityNormal, "deleteMessage", func() error { return q.api.DeleteMessage(ctx, chatID, msgID) })
+}
+
+func (q *queuedAPI) AnswerCallback(ctx context.Context, callbackID, text string, showAlert bool) error {
+	return q.do(ctx, priorityHigh, "answerCallbackQuery", func() error { return q.api.AnswerCallback(ctx, callbackID, text, showAlert) })
+}
+
+func (q *queuedAPI) Ban(ctx context.Context, chatID, userID int64) error {
+	return q.do(ctx, priorityHigh, "banChatMember", func() error { return q.api.Ban(ctx, chatID, userID) })
+}
+
+func (q *queuedAPI) Unban(ctx context.Context, chatID, userID int64) error {
+	return q.do(ctx, priorityHigh, "unbanChatMember", func() error { return q.api.Unban(ctx, chatID, userID) })
+}
+
+func (q *queuedAPI) Restrict(ctx context.Context, chatID, userID int64, perms ChatPermissions, until time.Time) error {
+	return q.do(ctx, priorityHigh, "restrictChatMember", func() error { return q.api.Restrict(ctx, chatID, userID, perms, until) })
+}
+
+func (q *queuedAPI) BanSenderChat(ctx context.Context, chatID, senderChatID int64) error {
+	return q.do(ctx, priorityHigh, "banChatSenderChat", func() error { return q.api.BanSenderChat(ctx, chatID, senderChatID) })
+}
+
+func (q *queuedAPI) SetChatPermissions(ctx context.Context, chatID int64, perms ChatPermissions) error {
+	return q.do(ctx, priorityHigh, "setChatPermissions", func() error { return q.api.SetChatPermissions(ctx, chatID, perms) })
+}
+
+func (q *queuedAPI) LeaveChat(ctx context.Context, chatID int64) error {
+	return q.do(ctx, priorityNormal, "leaveChat", func() error { return q.api.LeaveChat(ctx, chatID) })
+}
@@ -0,0 +1,140 @@
+package hamster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueuedAPIPrioritizesBans(t *testing.T) {
+	f := &fakeAPI{}
+	started, release := make(chan struct{}), make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	f.sendOptions = func(chatID int64, text string, opts SendOptions) {
+		if text == "block" {
+			close(started)
+			<-release // единственный обработчик занят
+			return
+		}
+		mu.Lock()
+		order = append(order, "send")
+		mu.Unlock()
+	}
+	f.ban = func(chatID, userID int64) {
+		mu.Lock()
+		order = append(order, "ban")
+		mu.Unlock()
+	}
+	q := newQueuedAPI(f, 1)
+	defer q.close()
+
+	var wg sync.WaitGroup
+	start := func(fn func()) {
+		wg.Add(1)
+		go func() { defer wg.Done(); fn() }()
+	}
+	start(func() { q.SendMessage(context.Background(), 1, "block", nil, SendOptions{}) })
+	<-started
+	start(func() { q.SendMessage(context.Background(), 1, "hello", nil, SendOptions{}) })
+	waitQueued(t, q, 1)
+	start(func() { q.Ban(context.Background(), 1, 42) })
+	waitQueued(t, q, 2)
+	close(release)
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != "ban" {
+		t.Errorf("бан должен выполняться раньше отправки: %v", order)
+	}
+}
+
+func TestQueuedAPIDropsEditsUnderPressure(t *testing.T) {
+	f := &fakeAPI{}
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	f.deleteMessage = func(chatID, msgID int64) {
+		once.Do(func() { close(started) })
+		<-release
+	}
+	edits := 0
+	f.editMessage = func(chatID, msgID int64, text string) { edits++ }
+	q := newQueuedAPI(f, 1)
+	defer q.close()
+
+	var wg sync.WaitGroup
+	for i := 0; i <= apiQueuePressure; i++ {
+		wg.Add(1)
+		go func() { defer wg.Done(); q.DeleteMessage(context.Background(), 1, 1) }()
+	}
+	<-started
+	waitQueued(t, q, apiQueuePressure)
+	if err := q.EditMessage(context.Background(), 1, 2, "▓▓░░", ""); !errors.Is(err, ErrEditDropped) {
+		t.Errorf("при переполненной очереди правка отбрасывается, получили %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	if err := q.EditMessage(context.Background(), 1, 2, "▓▓▓░", ""); err != nil || edits != 1 {
+		t.Errorf("без нагрузки правка выполняется: %v, правок %d", err, edits)
+	}
+}
+
+// waitQueued ждёт, пока в очереди окажется n запросов.
+func waitQueued(t *testing.T, q *queuedAPI, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		q.mu.Lock()
+		got := q.waiting()
+		q.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("в очереди не оказалось %d запросов", n)
+}
+
+func TestQueuedAPISkipsCancelledRequests(t *testing.T) {
+	f := &fakeAPI{}
+	started, release := make(chan struct{}), make(chan struct{})
+	sent := 0
+	f.sendOptions = func(chatID int64, text string, opts SendOptions) {
+		if text == "block" {
+			close(started)
+			<-release
+			return
+		}
+		sent++
+	}
+	q := newQueuedAPI(f, 1)
+	defer q.close()
+	defer close(release)
+
+	go q.SendMessage(context.Background(), 1, "block", nil, SendOptions{})
+	<-started
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := q.SendMessage(ctx, 1, "hello", nil, SendOptions{})
+		errc <- err
+	}()
+	waitQueued(t, q, 1)
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ожидали context.Canceled, получили %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("отменённый запрос ждёт занятого обработчика")
+	}
+	if n := q.depth(); n != 0 || sent != 0 {
+		t.Errorf("отменённый запрос остался в очереди (%d) или выполнился (%d)", n, sent)
+	}
+	if _, err := q.SendMessage(ctx, 1, "late", nil, SendOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("запрос с уже отменённым ctx не ставится в очередь, получили %v", err)
+	}
+}
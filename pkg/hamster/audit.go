@@ -0,0 +1,205 @@
+package hamster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Аудит и ограничение команд настройки
+// ==========================
+
+const (
+	// configCommandLimit — сколько команд настройки один пользователь может
+	// дать в чате за configCommandWindow. Чаще — признак угнанного аккаунта
+	// администратора или попытки заспамить чат ответами бота.
+	configCommandLimit  = 10
+	configCommandWindow = time.Minute
+	// auditLogSize — сколько последних записей аудита хранить в памяти.
+	auditLogSize = 1000
+	// auditTextLen — сколько символов команды сохранять в записи.
+	auditTextLen = 200
+)
+
+// configCommands — команды, меняющие настройки чата или банящие от имени бота.
+var configCommands = map[string]bool{
+	"/timeout": true, "/namefilter": true, "/hamster": true, "/setrules": true,
+	"/rulesmode": true, "/setwelcomemedia": true, "/notify": true, "/protect": true,
+	"/phrases": true, "/cleanup": true, "/progress": true, "/plaintext": true,
+	"/keepgreeting": true, "/service": true, "/channels": true, "/links": true,
+	"/adminadd": true, "/night": true, "/spamwords": true, "/blacklist": true,
+	"/ratelimit": true, "/forwards": true, "/copysettings": true, "/fed": true, "/fban": true,
+	"/funban": true, "/trust": true, "/untrust": true,
+}
+
+// AuditEntry — команда настройки, данная в чате.
+type AuditEntry struct {
+	At      time.Time `json:"at"`
+	ChatID  int64     `json:"chat_id"`
+	UserID  int64     `json:"user_id"` // для анонимного администратора — ID чата, для REST API — 0
+	Command string    `json:"command"`
+	Limited bool      `json:"limited,omitempty"` // отклонена: превышен configCommandLimit
+}
+
+// windowCounter считает события по ключам за скользящее окно.
+type windowCounter struct {
+	mu   sync.Mutex
+	hits map[string][]time.Time
+}
+
+// hit учитывает событие и возвращает их число за window, включая это.
+func (w *windowCounter) hit(key string, window time.Duration, now time.Time) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.hits == nil {
+		w.hits = make(map[string][]time.Time)
+	}
+	for k, ts := range w.hits {
+		if len(ts) == 0 || now.Sub(ts[len(ts)-1]) > window {
+			delete(w.hits, k)
+		}
+	}
+	ts := w.hits[key]
+	i := 0
+	for i < len(ts) && now.Sub(ts[i]) > window {
+		i++
+	}
+	ts = append(ts[i:], now)
+	w.hits[key] = ts
+	return len(ts)
+}
+
+// reset забывает события по ключу.
+func (w *windowCounter) reset(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.hits, key)
+}
+
+// adminAudit считает команды настройки по пользователям и хранит последние
+// записи аудита.
+type adminAudit struct {
+	hits    windowCounter // команды по verifiedKey
+	mu      sync.Mutex
+	entries []AuditEntry // старые первыми
+}
+
+// allow учитывает команду и сообщает, укладывается ли она в лимит; first —
+// это первая отклонённая команда за окно.
+func (a *adminAudit) allow(chatID, userID int64, now time.Time) (ok, first bool) {
+	n := a.hits.hit(verifiedKey(chatID, userID), configCommandWindow, now)
+	return n <= configCommandLimit, n == configCommandLimit+1
+}
+
+func (a *adminAudit) add(e AuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.entries) >= auditLogSize {
+		a.entries = append(a.entries[:0:0], a.entries[len(a.entries)-auditLogSize+1:]...)
+	}
+	a.entries = append(a.entries, e)
+}
+
+// list возвращает записи чата (0 — всех чатов), новые первыми.
+func (a *adminAudit) list(chatID int64) []AuditEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := []AuditEntry{}
+	for i := len(a.entries) - 1; i >= 0; i-- {
+		if chatID == 0 || a.entries[i].ChatID == chatID {
+			out = append(out, a.entries[i])
+		}
+	}
+	return out
+}
+
+// auditConfigCommand записывает команду настройки в аудит и сообщает, можно
+// ли её выполнять. Личные чаты не считаются: там команды дают только
+// владельцы бота.
+func (b *Bot) auditConfigCommand(msg *Message, cmd string) bool {
+	if !configCommands[cmd] || msg.Chat.Type == "private" || msg.From == nil {
+		return true
+	}
+	userID := msg.From.ID
+	if msg.SenderChat != nil {
+		userID = msg.SenderChat.ID
+	}
+	now := time.Now()
+	ok, first := b.audit.allow(msg.Chat.ID, userID, now)
+	text := []rune(msg.Text)
+	if len(text) > auditTextLen {
+		text = append(text[:auditTextLen], '…')
+	}
+	b.audit.add(AuditEntry{At: now, ChatID: msg.Chat.ID, UserID: userID, Command: string(text), Limited: !ok})
+	if ok {
+		b.logger.Info("Аудит: %d в чате %d: %s", userID, msg.Chat.ID, string(text))
+		return true
+	}
+	if first {
+		b.logger.Warn("Аудит: %d в чате %d дал больше %d команд настройки за минуту — остальные отклоняются", userID, msg.Chat.ID, configCommandLimit)
+		b.sendTemporary(msg.Chat.ID, "⏳ Слишком много команд настройки подряд — подождите минуту", 10*time.Second)
+		text := fmt.Sprintf("Пользователь %d дал в чате %d больше %d команд настройки за минуту. Если это не он — аккаунт мог быть угнан.", userID, msg.Chat.ID, configCommandLimit)
+		b.notify(NotifyError, "⚠️ "+text)
+		b.alert(fmt.Sprintf("%s:%d", alertCommandFlood, msg.Chat.ID), "частые команды настройки", text)
+	}
+	return false
+}
+
+// auditSettingsPut записывает в аудит замену настроек чата через REST API:
+// так смена приветствия или действия в обход команд тоже видна. API
+// защищён общим токеном, поэтому автора нет и лимит не применяется.
+func (b *Bot) auditSettingsPut(chatID int64, old, cs ChatSettings) {
+	changed := changedSettings(old, cs)
+	if len(changed) == 0 {
+		changed = []string{"без изменений"}
+	}
+	text := fmt.Sprintf("PUT /api/chats/%d/settings: %s", chatID, strings.Join(changed, ", "))
+	b.audit.add(AuditEntry{At: time.Now(), ChatID: chatID, Command: text})
+	b.logger.Info("Аудит: REST API в чате %d: %s", chatID, text)
+}
+
+// changedSettings возвращает JSON-имена полей, которые различаются в old и cs.
+func changedSettings(old, cs ChatSettings) []string {
+	fields := func(c ChatSettings) map[string]json.RawMessage {
+		m := map[string]json.RawMessage{}
+		if data, err := json.Marshal(c); err == nil {
+			_ = json.Unmarshal(data, &m)
+		}
+		return m
+	}
+	a, c := fields(old), fields(cs)
+	var changed []string
+	for k, v := range c {
+		if !bytes.Equal(a[k], v) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range a {
+		if _, ok := c[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// apiAudit — GET /api/audit[?chat=<ID>]: последние команды настройки.
+func (b *Bot) apiAudit(w http.ResponseWriter, r *http.Request) {
+	var chatID int64
+	if v := r.URL.Query().Get("chat"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "некорректный chat")
+			return
+		}
+		chatID = id
+	}
+	writeJSON(w, http.StatusOK, b.audit.list(chatID))
+}
@@ -0,0 +1,113 @@
+package hamster
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminAuditLimit(t *testing.T) {
+	var a adminAudit
+	now := time.Now()
+	for i := 0; i < configCommandLimit; i++ {
+		if ok, _ := a.allow(-100, 10, now); !ok {
+			t.Fatalf("команда %d в пределах лимита отклонена", i+1)
+		}
+	}
+	if ok, first := a.allow(-100, 10, now); ok || !first {
+		t.Errorf("сверх лимита: ok=%v first=%v", ok, first)
+	}
+	if _, first := a.allow(-100, 10, now); first {
+		t.Error("о превышении сообщается один раз")
+	}
+	if ok, _ := a.allow(-100, 11, now); !ok {
+		t.Error("лимит считается по пользователям")
+	}
+	if ok, _ := a.allow(-100, 10, now.Add(configCommandWindow+time.Second)); !ok {
+		t.Error("после окна команды снова разрешены")
+	}
+}
+
+func TestAuditConfigCommand(t *testing.T) {
+	b := setupBot()
+	var warned int
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		if strings.Contains(text, "Слишком много команд") {
+			warned++
+		}
+		return 1
+	}
+	msg := &Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/timeout 60"}
+	for i := 0; i < configCommandLimit; i++ {
+		if !b.auditConfigCommand(msg, "/timeout") {
+			t.Fatal("команда в пределах лимита отклонена")
+		}
+	}
+	if b.auditConfigCommand(msg, "/timeout") || b.auditConfigCommand(msg, "/timeout") {
+		t.Error("команды сверх лимита должны отклоняться")
+	}
+	if warned != 1 {
+		t.Errorf("предупреждение в чат: %d раз", warned)
+	}
+	// обычные команды и личка не ограничиваются
+	if !b.auditConfigCommand(&Message{Chat: msg.Chat, From: msg.From, Text: "/stats"}, "/stats") ||
+		!b.auditConfigCommand(&Message{Chat: Chat{ID: 10, Type: "private"}, From: msg.From, Text: "/blacklist add 1"}, "/blacklist") {
+		t.Error("ограничиваются только команды настройки в группах")
+	}
+	entries := b.audit.list(-100)
+	if len(entries) != configCommandLimit+2 || !entries[0].Limited || entries[len(entries)-1].Limited || entries[0].Command != "/timeout 60" {
+		t.Errorf("журнал аудита: %+v", entries)
+	}
+}
+
+func TestAPIAudit(t *testing.T) {
+	b := setupAdminBot()
+	h := b.AdminHandler()
+	b.audit.add(AuditEntry{ChatID: -1, UserID: 10, Command: "/timeout 60"})
+	b.audit.add(AuditEntry{ChatID: -2, UserID: 20, Command: "/night on"})
+	rec := adminRequest(t, h, "GET", "/api/audit?chat=-2", "")
+	var got []AuditEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 1 || got[0].UserID != 20 {
+		t.Errorf("GET /api/audit?chat=-2: %s", rec.Body)
+	}
+	if rec := adminRequest(t, h, "GET", "/api/audit?chat=x", ""); rec.Code != 400 {
+		t.Errorf("некорректный chat: %d", rec.Code)
+	}
+}
+
+func TestAuditSettingsPut(t *testing.T) {
+	b := setupAdminBot()
+	h := b.AdminHandler()
+	b.updateChatSettings(-100, func(c *ChatSettings) { c.Timeout = 60; c.Rules = "без спама" })
+	rec := adminRequest(t, h, "PUT", "/api/chats/-100/settings", `{"timeout":60,"action":"kick","welcome_template":"Привет, {name}"}`)
+	if rec.Code != 200 {
+		t.Fatalf("PUT: код %d, тело %s", rec.Code, rec.Body)
+	}
+	if rec := adminRequest(t, h, "PUT", "/api/chats/-100/settings", `{"action":"nuke"}`); rec.Code != 400 {
+		t.Fatalf("некорректный PUT: код %d", rec.Code)
+	}
+	entries := b.audit.list(-100)
+	want := "PUT /api/chats/-100/settings: action, rules, welcome_template"
+	if len(entries) != 1 || entries[0].Command != want || entries[0].UserID != 0 || entries[0].Limited {
+		t.Errorf("аудит замены настроек через API: %+v", entries)
+	}
+}
+
+func TestWindowCounter(t *testing.T) {
+	var w windowCounter
+	now := time.Now()
+	if w.hit("a", time.Minute, now) != 1 || w.hit("a", time.Minute, now.Add(30*time.Second)) != 2 {
+		t.Fatal("события должны накапливаться")
+	}
+	if w.hit("b", time.Minute, now) != 1 {
+		t.Error("ключи считаются отдельно")
+	}
+	if n := w.hit("a", time.Minute, now.Add(80*time.Second)); n != 2 {
+		t.Errorf("события старше окна выпадают: %d", n)
+	}
+	w.reset("a")
+	if w.hit("a", time.Minute, now) != 1 {
+		t.Error("reset должен обнулять счётчик")
+	}
+}
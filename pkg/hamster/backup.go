@@ -0,0 +1,266 @@
+package hamster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Резервные копии состояния
+// ==========================
+
+const (
+	backupSchemaVersion = 1
+	backupPrefix        = "hamster-"
+	backupSuffix        = ".json"
+)
+
+// stateSnapshot — полное состояние бота в резервной копии, независимо от хранилища.
+type stateSnapshot struct {
+	Version   int                     `json:"version"`
+	CreatedAt time.Time               `json:"created_at"`
+	Settings  map[int64]*ChatSettings `json:"settings"`
+	Verified  map[string]time.Time    `json:"verified,omitempty"`
+	Stats     map[int64]ChatStats     `json:"stats,omitempty"`
+}
+
+func (b *Bot) snapshotState() stateSnapshot {
+	return stateSnapshot{
+		Version:   backupSchemaVersion,
+		CreatedAt: time.Now().UTC(),
+		Settings:  b.settings.Snapshot(),
+		Verified:  b.verified.snapshot(),
+		Stats:     b.stats.Snapshot(),
+	}
+}
+
+// BackupNow сохраняет копию состояния в BackupDir, удаляет лишние старые копии
+// и выгружает копию в S3, если он настроен. Возвращает имя файла.
+func (b *Bot) BackupNow(label string) (string, error) {
+	content, err := json.MarshalIndent(b.snapshotState(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if b.cfg.SettingsKey != "" {
+		sl, err := newSealer(b.cfg.SettingsKey)
+		if err != nil {
+			return "", err
+		}
+		if content, err = sl.seal(content); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(b.cfg.BackupDir, 0700); err != nil {
+		return "", err
+	}
+	name := backupPrefix + time.Now().UTC().Format("20060102-150405")
+	if label != "" {
+		name += "-" + label
+	}
+	name += backupSuffix
+	if err := writeFileAtomic(filepath.Join(b.cfg.BackupDir, name), content, 0600); err != nil {
+		return "", err
+	}
+	b.logger.Info("💾 Резервная копия %s сохранена", name)
+
+	b.pruneBackups()
+	if b.cfg.BackupS3.enabled() {
+		if err := b.s3Put(b.cfg.BackupS3, name, content); err != nil {
+			b.logger.Warn("Не удалось выгрузить %s в S3: %v", name, err)
+		}
+	}
+	return name, nil
+}
+
+// listBackups возвращает имена резервных копий, от новых к старым.
+func listBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && isBackupName(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	// в имени время UTC — лексикографический порядок совпадает с хронологическим
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+func isBackupName(name string) bool {
+	return strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, backupSuffix) && filepath.Base(name) == name
+}
+
+// pruneBackups оставляет BackupKeep последних копий.
+func (b *Bot) pruneBackups() {
+	if b.cfg.BackupKeep <= 0 {
+		return
+	}
+	names, err := listBackups(b.cfg.BackupDir)
+	if err != nil {
+		b.logger.Warn("Не удалось прочитать %s: %v", b.cfg.BackupDir, err)
+		return
+	}
+	for _, name := range names[min(len(names), b.cfg.BackupKeep):] {
+		if err := os.Remove(filepath.Join(b.cfg.BackupDir, name)); err != nil {
+			b.logger.Warn("Не удалось удалить старую копию %s: %v", name, err)
+		}
+	}
+}
+
+// restoreBackup заменяет текущее состояние содержимым копии и сразу записывает его в хранилище.
+func (b *Bot) restoreBackup(name string) error {
+	if !isBackupName(name) {
+		return errors.New("некорректное имя копии")
+	}
+	f, err := os.Open(filepath.Join(b.cfg.BackupDir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	chats, err := b.ImportState(f)
+	if err != nil {
+		return err
+	}
+	b.logger.Info("♻️ Состояние восстановлено из %s (%d чатов)", name, chats)
+	return nil
+}
+
+func decodeSnapshot(content []byte) (stateSnapshot, error) {
+	var snap stateSnapshot
+	if err := json.Unmarshal(content, &snap); err != nil {
+		return snap, fmt.Errorf("повреждённая копия: %w", err)
+	}
+	if snap.Version > backupSchemaVersion {
+		return snap, fmt.Errorf("копия схемы v%d новее поддерживаемой v%d", snap.Version, backupSchemaVersion)
+	}
+	return snap, nil
+}
+
+// applySnapshot заменяет текущее состояние копией и сразу записывает его в хранилище.
+func (b *Bot) applySnapshot(snap stateSnapshot) {
+	b.settings.Replace(snap.Settings)
+	if snap.Verified == nil {
+		snap.Verified = make(map[string]time.Time)
+	}
+	b.verified.replace(snap.Verified)
+	b.stats.Replace(snap.Stats)
+	if b.storage != nil {
+		b.writeSettings()
+		b.writeState()
+	}
+}
+
+// ExportState пишет в w настройки, верификации и статистику в формате
+// резервной копии, открытым текстом (tg-hamster export).
+func (b *Bot) ExportState(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b.snapshotState())
+}
+
+// ImportState заменяет состояние выгрузкой ExportState или резервной копией
+// (tg-hamster import). Зашифрованная копия расшифровывается ключом SETTINGS_KEY.
+// Возвращает число чатов.
+func (b *Bot) ImportState(r io.Reader) (int, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	if isEncrypted(content) {
+		if b.cfg.SettingsKey == "" {
+			return 0, errors.New("копия зашифрована, а SETTINGS_KEY не задан")
+		}
+		sl, err := newSealer(b.cfg.SettingsKey)
+		if err != nil {
+			return 0, err
+		}
+		if content, err = sl.open(content); err != nil {
+			return 0, err
+		}
+	}
+	snap, err := decodeSnapshot(content)
+	if err != nil {
+		return 0, err
+	}
+	b.applySnapshot(snap)
+	return len(snap.Settings), nil
+}
+
+// RunBackups делает резервные копии по расписанию BackupInterval.
+func (b *Bot) RunBackups(ctx context.Context) {
+	if b.cfg.BackupInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(b.cfg.BackupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := b.BackupNow(""); err != nil {
+				b.logger.Warn("Резервная копия не создана: %v", err)
+			}
+		}
+	}
+}
+
+// ==========================
+// Команда /restorebackup (владельцы, в личке)
+// ==========================
+
+func (b *Bot) handleRestoreBackupCommand(msg *Message) {
+	if msg.From == nil || msg.Chat.Type != "private" || !b.isOwner(msg.From.ID) {
+		return
+	}
+	chatID := msg.Chat.ID
+
+	name := commandArg(msg.Text, 1)
+	if name == "" {
+		names, err := listBackups(b.cfg.BackupDir)
+		if err != nil {
+			b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("❌ Не удалось прочитать копии: %v", err))
+			return
+		}
+		if len(names) == 0 {
+			b.safeSendSilent(b.ctx, chatID, "📭 Резервных копий нет")
+			return
+		}
+		var sb strings.Builder
+		sb.WriteString("💾 Резервные копии (новые сверху):\n")
+		for _, n := range names {
+			sb.WriteString(n + "\n")
+		}
+		sb.WriteString("\nВосстановить: /restorebackup <имя>")
+		b.safeSendSilent(b.ctx, chatID, sb.String())
+		return
+	}
+
+	// текущее состояние сохраняем, чтобы восстановление можно было откатить
+	safety, err := b.BackupNow("pre-restore")
+	if err != nil {
+		b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("❌ Не удалось сохранить текущее состояние перед восстановлением: %v", err))
+		return
+	}
+	if err := b.restoreBackup(name); err != nil {
+		b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("❌ Восстановление не удалось: %v", err))
+		return
+	}
+	b.logger.Info("Владелец %d восстановил копию %s", msg.From.ID, name)
+	b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("✅ Состояние восстановлено из %s\nПрежнее состояние сохранено в %s", name, safety))
+}
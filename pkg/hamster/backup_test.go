@@ -0,0 +1,168 @@
+package hamster
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupBackupBot(t *testing.T) *Bot {
+	b := setupBot()
+	b.verified = newVerifiedUsers()
+	b.stats = NewStats()
+	b.cfg.BackupDir = filepath.Join(t.TempDir(), "backups")
+	b.cfg.BackupKeep = 3
+	return b
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	b := setupBackupBot(t)
+	b.settings.SetTimeout(-100, 45)
+	b.verified.mark(-100, 7, time.Now())
+	b.stats.add(-100, func(c *ChatStats) { c.Joins = 5 })
+
+	name, err := b.BackupNow("")
+	if err != nil {
+		t.Fatalf("BackupNow вернул ошибку: %v", err)
+	}
+
+	// состояние меняется после копии
+	b.settings.SetTimeout(-100, 90)
+	b.settings.SetTimeout(-200, 10)
+	b.verified.forgetChat(-100)
+	b.stats.Delete(-100)
+
+	if err := b.restoreBackup(name); err != nil {
+		t.Fatalf("restoreBackup вернул ошибку: %v", err)
+	}
+	if got := b.settings.Timeout(-100); got != 45 {
+		t.Errorf("таймаут не восстановлен, получили %d", got)
+	}
+	if got := b.settings.Timeout(-200); got != DefaultTimeoutSec {
+		t.Errorf("настройки, которых не было в копии, должны исчезнуть, получили %d", got)
+	}
+	if _, ok := b.verified.since(-100, 7); !ok {
+		t.Error("верификация не восстановлена")
+	}
+	if got := b.stats.Get(-100).Joins; got != 5 {
+		t.Errorf("статистика не восстановлена, получили %d", got)
+	}
+}
+
+func TestExportImportState(t *testing.T) {
+	b := setupBackupBot(t)
+	b.settings.SetTimeout(-100, 45)
+	b.stats.add(-100, func(c *ChatStats) { c.Joins = 5 })
+
+	var buf bytes.Buffer
+	if err := b.ExportState(&buf); err != nil {
+		t.Fatalf("ExportState вернул ошибку: %v", err)
+	}
+
+	other := setupBackupBot(t)
+	other.settings.SetTimeout(-200, 10)
+	chats, err := other.ImportState(&buf)
+	if err != nil {
+		t.Fatalf("ImportState вернул ошибку: %v", err)
+	}
+	if chats != 1 || other.settings.Timeout(-100) != 45 || other.stats.Get(-100).Joins != 5 {
+		t.Errorf("состояние не перенесено: чатов %d, таймаут %d", chats, other.settings.Timeout(-100))
+	}
+	if _, ok := other.settings.Snapshot()[-200]; ok {
+		t.Error("импорт заменяет состояние, а не дополняет его")
+	}
+	if _, err := other.ImportState(strings.NewReader(`{"version":99}`)); err == nil {
+		t.Error("выгрузка новой схемы должна отклоняться")
+	}
+}
+
+func TestRestoreBackupRejectsPathTraversal(t *testing.T) {
+	b := setupBackupBot(t)
+	for _, name := range []string{"../settings.json", "hamster-../../x.json", "other.json"} {
+		if err := b.restoreBackup(name); err == nil {
+			t.Errorf("имя %q должно отклоняться", name)
+		}
+	}
+}
+
+func TestBackupRetention(t *testing.T) {
+	b := setupBackupBot(t)
+	if err := os.MkdirAll(b.cfg.BackupDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"hamster-20200101-000000.json", "hamster-20200102-000000.json", "hamster-20200103-000000.json", "notes.txt"} {
+		_ = os.WriteFile(filepath.Join(b.cfg.BackupDir, name), []byte("{}"), 0600)
+	}
+
+	if _, err := b.BackupNow(""); err != nil {
+		t.Fatal(err)
+	}
+	names, _ := listBackups(b.cfg.BackupDir)
+	if len(names) != 3 {
+		t.Fatalf("ожидалось 3 копии, получили %v", names)
+	}
+	for _, n := range names {
+		if n == "hamster-20200101-000000.json" {
+			t.Error("самая старая копия должна быть удалена")
+		}
+	}
+	if _, err := os.Stat(filepath.Join(b.cfg.BackupDir, "notes.txt")); err != nil {
+		t.Error("посторонние файлы трогать нельзя")
+	}
+}
+
+func TestBackupEncryptedAndUploaded(t *testing.T) {
+	b := setupBackupBot(t)
+	b.cfg.SettingsKey = testSettingsKey
+	b.cfg.BackupS3 = S3Config{Endpoint: "http://minio:9000", Bucket: "b", AccessKey: "k", SecretKey: "s"}
+	uploaded := ""
+	b.httpClient = &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		uploaded = req.URL.Path
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	}}
+	b.settings.SetTimeout(-100, 45)
+
+	name, err := b.BackupNow("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(filepath.Join(b.cfg.BackupDir, name))
+	if !isEncrypted(content) {
+		t.Error("при заданном SETTINGS_KEY копия должна шифроваться")
+	}
+	if !strings.HasSuffix(uploaded, "/"+name) {
+		t.Errorf("копия не выгружена в S3: %q", uploaded)
+	}
+
+	b.settings.SetTimeout(-100, 90)
+	if err := b.restoreBackup(name); err != nil || b.settings.Timeout(-100) != 45 {
+		t.Errorf("зашифрованная копия не восстановлена: %v", err)
+	}
+}
+
+func TestRestoreBackupCommandOwnersOnly(t *testing.T) {
+	b := setupBackupBot(t)
+	b.cfg.Owners = []int64{1}
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { sent = append(sent, text); return 1 }
+
+	b.handleRestoreBackupCommand(&Message{Chat: Chat{ID: 2, Type: "private"}, From: &User{ID: 2}, Text: "/restorebackup"})
+	if len(sent) != 0 {
+		t.Errorf("не владелец не должен получать ответ: %v", sent)
+	}
+
+	b.handleRestoreBackupCommand(&Message{Chat: Chat{ID: 1, Type: "private"}, From: &User{ID: 1}, Text: "/restorebackup"})
+	if len(sent) != 1 || !strings.Contains(sent[0], "нет") {
+		t.Errorf("ожидалось сообщение об отсутствии копий: %v", sent)
+	}
+
+	name, _ := b.BackupNow("")
+	b.handleRestoreBackupCommand(&Message{Chat: Chat{ID: 1, Type: "private"}, From: &User{ID: 1}, Text: "/restorebackup " + name})
+	if len(sent) != 2 || !strings.Contains(sent[1], "восстановлено") {
+		t.Errorf("ожидалось подтверждение восстановления: %v", sent)
+	}
+}
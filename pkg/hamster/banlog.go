@@ -0,0 +1,167 @@
+package hamster
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Журнал банов
+// ==========================
+
+const (
+	defaultBanLogShown = 10
+	maxBanLogShown     = 50
+	// defaultBanLogExport — сколько записей выгружает REST API без limit.
+	defaultBanLogExport = 1000
+)
+
+// banLog читает журнал банов из хранилища; без хранилища — из последних
+// банов в памяти.
+func (b *Bot) banLog(q BanLogQuery) ([]BanLogEntry, error) {
+	q.UserID = b.storedUserID(q.UserID)
+	if b.storage != nil {
+		return b.storage.BanLog(q)
+	}
+	var out []BanLogEntry
+	for _, e := range b.recentBans.list() {
+		if q.full(len(out)) {
+			break
+		}
+		if q.match(e) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// recordAdminBan записывает в журнал бан, выданный администратором вручную:
+// журнал должен показывать всё, что случилось с участниками, а не только
+// действия бота.
+func (b *Bot) recordAdminBan(u *ChatMemberUpdated) {
+	if u.NewChatMember.Status != "kicked" || u.OldChatMember.Status == "kicked" ||
+		u.From == nil || b.isSelf(u.From) || u.NewChatMember.User == nil {
+		return
+	}
+	b.writeBanLog(BanLogEntry{ChatID: u.Chat.ID, UserID: u.NewChatMember.User.ID, Reason: BanReasonAdmin, By: u.From.ID, At: time.Now()})
+}
+
+func formatBanLogEntry(e BanLogEntry) string {
+	s := fmt.Sprintf("• %s — %d — %s", e.At.Format(checkTimeFormat), e.UserID, banReasonName(e.Reason))
+	if e.By != 0 {
+		s += fmt.Sprintf(" (%d)", e.By)
+	}
+	return s
+}
+
+// ==========================
+// Команда /banlog
+// ==========================
+
+func (b *Bot) handleBanLogCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может смотреть журнал банов", 5*time.Second)
+		return
+	}
+
+	usage := fmt.Sprintf("⚙️ Использование: /banlog [сколько, до %d] [ID пользователя или причина: %s]", maxBanLogShown, strings.Join(banReasonCodes(), ", "))
+	q := BanLogQuery{ChatID: chatID, Limit: defaultBanLogShown}
+	args := strings.Fields(commandArg(msg.Text, 1))
+	if len(args) > 2 {
+		b.sendTemporary(chatID, usage, 10*time.Second)
+		return
+	}
+	for _, a := range args {
+		// ID пользователей Telegram больше maxBanLogShown — число отличает сам себя
+		n, err := strconv.ParseInt(a, 10, 64)
+		switch {
+		case err == nil && n >= 1 && n <= maxBanLogShown:
+			q.Limit = int(n)
+		case err == nil && n > maxBanLogShown:
+			q.UserID = n
+		case banReasonNames[a] != "":
+			q.Reason = a
+		default:
+			b.sendTemporary(chatID, usage, 10*time.Second)
+			return
+		}
+	}
+
+	entries, err := b.banLog(q)
+	if err != nil {
+		b.logger.Warn("Не удалось прочитать журнал банов чата %d: %v", chatID, err)
+		b.sendTemporary(chatID, "❌ Не удалось прочитать журнал банов", 5*time.Second)
+		return
+	}
+	if len(entries) == 0 {
+		b.sendTemporary(chatID, "📭 В журнале банов ничего не найдено", 10*time.Second)
+		return
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📜 Журнал банов (последние %d):", len(entries))
+	for _, e := range entries {
+		sb.WriteString("\n" + formatBanLogEntry(e))
+	}
+	b.sendTemporary(chatID, sb.String(), time.Minute)
+}
+
+// banReasonCodes — коды причин для подсказки, в порядке записи.
+func banReasonCodes() []string {
+	return []string{BanReasonTimeout, BanReasonWrongAnswer, BanReasonTooFast, BanReasonNameFilter,
+		BanReasonScore, BanReasonChannel, BanReasonInviteLink, BanReasonSpamWords, BanReasonBlacklist, BanReasonFederation, BanReasonAdmin}
+}
+
+// ==========================
+// Выгрузка журнала через REST API
+// ==========================
+
+// apiBanLog отдаёт журнал банов чата: ?user=, ?reason=, ?limit= (по умолчанию
+// defaultBanLogExport, 0 — весь), ?format=csv — для таблиц.
+func (b *Bot) apiBanLog(w http.ResponseWriter, r *http.Request, chatID int64) {
+	q := BanLogQuery{ChatID: chatID, Reason: r.URL.Query().Get("reason"), Limit: defaultBanLogExport}
+	if v := r.URL.Query().Get("user"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusBadRequest, "некорректный ID пользователя")
+			return
+		}
+		q.UserID = id
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "некорректный limit")
+			return
+		}
+		q.Limit = n
+	}
+	entries, err := b.banLog(q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if r.URL.Query().Get("format") != "csv" {
+		if entries == nil {
+			entries = []BanLogEntry{}
+		}
+		writeJSON(w, http.StatusOK, entries)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="banlog_%d.csv"`, chatID))
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"at", "chat_id", "user_id", "reason", "by"})
+	for _, e := range entries {
+		_ = cw.Write([]string{e.At.UTC().Format(time.RFC3339), strconv.FormatInt(e.ChatID, 10),
+			strconv.FormatInt(e.UserID, 10), e.Reason, strconv.FormatInt(e.By, 10)})
+	}
+	cw.Flush()
+}
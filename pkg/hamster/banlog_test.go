@@ -0,0 +1,119 @@
+package hamster
+
+import (
+	"encoding/csv"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileStorageBanLog(t *testing.T) {
+	fs := newFileStorage(filepath.Join(t.TempDir(), "settings.json"), NewLogger())
+	fs.banFile = filepath.Join(filepath.Dir(fs.file), "ban_log.jsonl")
+	if got, err := fs.BanLog(BanLogQuery{ChatID: -100}); err != nil || got != nil {
+		t.Fatalf("пустой журнал: %v %v", got, err)
+	}
+	at := time.Now()
+	for i, e := range []BanLogEntry{
+		{ChatID: -100, UserID: 1, Reason: BanReasonTimeout},
+		{ChatID: -200, UserID: 2, Reason: BanReasonTimeout},
+		{ChatID: -100, UserID: 3, Reason: BanReasonAdmin, By: 10},
+		{ChatID: -100, UserID: 1, Reason: BanReasonWrongAnswer},
+	} {
+		e.At = at.Add(time.Duration(i) * time.Second)
+		if err := fs.LogBan(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := fs.BanLog(BanLogQuery{ChatID: -100})
+	if err != nil || len(got) != 3 || got[0].Reason != BanReasonWrongAnswer || got[2].UserID != 1 {
+		t.Errorf("журнал чата, новые первыми: %+v %v", got, err)
+	}
+	if got, _ := fs.BanLog(BanLogQuery{ChatID: -100, UserID: 1, Limit: 1}); len(got) != 1 || got[0].Reason != BanReasonWrongAnswer {
+		t.Errorf("отбор по пользователю с лимитом: %+v", got)
+	}
+	if got, _ := fs.BanLog(BanLogQuery{ChatID: -100, Reason: BanReasonAdmin}); len(got) != 1 || got[0].By != 10 {
+		t.Errorf("отбор по причине: %+v", got)
+	}
+
+	if n, err := fs.ForgetUser(1); err != nil || n != 2 {
+		t.Fatalf("ForgetUser должен удалить две записи: %d %v", n, err)
+	}
+	if got, _ := fs.BanLog(BanLogQuery{ChatID: -100}); len(got) != 1 || got[0].UserID != 3 {
+		t.Errorf("после ForgetUser остаются чужие баны: %+v", got)
+	}
+}
+
+func TestRecordAdminBan(t *testing.T) {
+	b := setupBot()
+	b.recentBans = newBanHistory(10)
+	b.self = User{ID: 1}
+	kick := func(by int64, old string) {
+		b.handleChatMember(&ChatMemberUpdated{
+			Chat:          Chat{ID: -100},
+			From:          &User{ID: by},
+			OldChatMember: ChatMember{Status: old, User: &User{ID: 42}},
+			NewChatMember: ChatMember{Status: "kicked", User: &User{ID: 42}},
+		})
+	}
+	kick(1, "member")  // бан самого бота уже записан с причиной
+	kick(10, "member") // бан администратором
+	bans, _ := b.banLog(BanLogQuery{ChatID: -100})
+	if len(bans) != 1 || bans[0].Reason != BanReasonAdmin || bans[0].By != 10 || bans[0].UserID != 42 {
+		t.Errorf("в журнал попадает только бан администратором: %+v", bans)
+	}
+}
+
+func TestBanLogCommand(t *testing.T) {
+	b := setupBot()
+	b.recentBans = newBanHistory(10)
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { sent = append(sent, text); return 1 }
+	b.logBan(-100, 555, BanReasonTimeout)
+	b.logBan(-100, 777, BanReasonNameFilter)
+	cmd := func(text string) string {
+		sent = nil
+		b.handleBanLogCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: text})
+		if len(sent) != 1 {
+			t.Fatalf("%q: ожидали один ответ, получили %v", text, sent)
+		}
+		return sent[0]
+	}
+
+	if out := cmd("/banlog"); !strings.Contains(out, "555") || !strings.Contains(out, "фильтр имён") {
+		t.Errorf("журнал целиком: %s", out)
+	}
+	if out := cmd("/banlog 1"); strings.Contains(out, "555") || !strings.Contains(out, "777") {
+		t.Errorf("одна последняя запись: %s", out)
+	}
+	if out := cmd("/banlog timeout"); !strings.Contains(out, "555") || strings.Contains(out, "777") {
+		t.Errorf("отбор по причине: %s", out)
+	}
+	if out := cmd("/banlog 5 777"); strings.Contains(out, "555") || !strings.Contains(out, "777") {
+		t.Errorf("отбор по пользователю: %s", out)
+	}
+	if out := cmd("/banlog nonsense"); !strings.Contains(out, "Использование") {
+		t.Errorf("неизвестный аргумент: %s", out)
+	}
+}
+
+func TestAdminAPIBanLogCSV(t *testing.T) {
+	b := setupAdminBot()
+	b.recentBans = newBanHistory(10)
+	b.logBan(-100, 555, BanReasonTimeout)
+	b.logBan(-200, 777, BanReasonTimeout)
+	rec := adminRequest(t, b.AdminHandler(), "GET", "/api/chats/-100/banlog?format=csv", "")
+	if rec.Code != 200 {
+		t.Fatalf("код %d: %s", rec.Code, rec.Body)
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(rows) != 2 || rows[0][0] != "at" || rows[1][2] != "555" || rows[1][3] != BanReasonTimeout {
+		t.Errorf("CSV: %v %v", rows, err)
+	}
+	if rec := adminRequest(t, b.AdminHandler(), "GET", "/api/chats/-100/banlog?user=abc", ""); rec.Code != 400 {
+		t.Errorf("некорректный user: код %d", rec.Code)
+	}
+}
@@ -0,0 +1,146 @@
+package hamster
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Чёрный список пользователей
+// ==========================
+
+const (
+	// globalSettingsID — псевдочат, в настройках которого хранится общий
+	// чёрный список владельцев. Настоящих чатов с ID 0 не бывает.
+	globalSettingsID = 0
+	// maxBlacklist — сколько ID можно держать в одном чёрном списке.
+	maxBlacklist = 1000
+)
+
+// isBlacklisted сообщает, что пользователь в чёрном списке чата или в общем.
+func (b *Bot) isBlacklisted(chatID, userID int64) bool {
+	if b.settings == nil {
+		return false
+	}
+	return slices.Contains(b.chatSettings(chatID).Blacklist, userID) ||
+		slices.Contains(b.chatSettings(globalSettingsID).Blacklist, userID)
+}
+
+// banBlacklisted банит вступившего из чёрного списка (или списка федерации)
+// без проверки и молча удаляет сообщение о вступлении.
+func (b *Bot) banBlacklisted(msg *Message, user *User, reason string) {
+	chatID := msg.Chat.ID
+	b.logger.Info("Участник %d вступил в чат %d — бан без проверки: %s", user.ID, chatID, banReasonName(reason))
+	b.safeBanUser(b.ctx, chatID, user.ID)
+	if msg.MessageID != 0 {
+		b.safeDeleteMessage(b.ctx, chatID, msg.MessageID)
+	}
+	b.logBan(chatID, user.ID, reason)
+	b.recordStat(chatID, func(c *ChatStats) {
+		if !user.IsBot {
+			c.Joins++
+		}
+		c.Banned++
+	})
+}
+
+// updateBlacklist добавляет или убирает ID и сообщает, изменился ли список.
+func (b *Bot) updateBlacklist(chatID, userID int64, add bool) (changed bool) {
+	b.updateChatSettings(chatID, func(c *ChatSettings) {
+		i := slices.Index(c.Blacklist, userID)
+		switch {
+		case add && i < 0:
+			c.Blacklist = append(c.Blacklist, userID)
+			changed = true
+		case !add && i >= 0:
+			c.Blacklist = slices.Delete(c.Blacklist, i, i+1)
+			changed = true
+		}
+	})
+	return changed
+}
+
+// ==========================
+// Команда /blacklist
+// ==========================
+
+const blacklistUsage = "⚙️ Использование:\n/blacklist — показать список\n/blacklist add <ID пользователя>\n/blacklist remove <ID пользователя>"
+
+// handleBlacklistCommand ведёт чёрный список чата; владелец в личке — общий
+// для всех чатов.
+func (b *Bot) handleBlacklistCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if msg.Chat.Type == "private" {
+		if !b.isOwner(msg.From.ID) {
+			return
+		}
+		reply := func(text string) { b.safeSendSilent(b.ctx, chatID, text) }
+		b.blacklistCommand(msg, globalSettingsID, "все чаты", reply)
+		return
+	}
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять чёрный список", 5*time.Second)
+		return
+	}
+	reply := func(text string) { b.sendTemporary(chatID, text, 30*time.Second) }
+	b.blacklistCommand(msg, chatID, "этот чат", reply)
+}
+
+// blacklistCommand разбирает подкоманды /blacklist для списка listID; scope
+// описывает, где действует список.
+func (b *Bot) blacklistCommand(msg *Message, listID int64, scope string, reply func(string)) {
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 || parts[1] == "list" {
+		reply(formatBlacklist(b.chatSettings(listID).Blacklist, scope))
+		return
+	}
+	var userID int64
+	if len(parts) == 3 {
+		userID, _ = strconv.ParseInt(parts[2], 10, 64)
+	}
+	if userID <= 0 {
+		reply(blacklistUsage)
+		return
+	}
+
+	switch parts[1] {
+	case "add":
+		if len(b.chatSettings(listID).Blacklist) >= maxBlacklist {
+			reply(fmt.Sprintf("❌ Не больше %d ID в чёрном списке", maxBlacklist))
+			return
+		}
+		if !b.updateBlacklist(listID, userID, true) {
+			reply(fmt.Sprintf("ℹ️ %d уже в списке", userID))
+			return
+		}
+		b.logger.Info("Пользователь %d добавлен в чёрный список %d (%s) пользователем %d", userID, listID, scope, msg.From.ID)
+		reply(fmt.Sprintf("⛔️ %d в чёрном списке (%s): при вступлении — бан без проверки", userID, scope))
+	case "remove", "del":
+		if !b.updateBlacklist(listID, userID, false) {
+			reply(fmt.Sprintf("ℹ️ %d нет в списке", userID))
+			return
+		}
+		b.logger.Info("Пользователь %d убран из чёрного списка %d (%s) пользователем %d", userID, listID, scope, msg.From.ID)
+		reply(fmt.Sprintf("✅ %d убран из списка. Уже выданный бан снимается в настройках чата", userID))
+	default:
+		reply(blacklistUsage)
+	}
+}
+
+func formatBlacklist(ids []int64, scope string) string {
+	if len(ids) == 0 {
+		return fmt.Sprintf("📭 Чёрный список (%s) пуст. Добавить: /blacklist add <ID пользователя>", scope)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "⛔️ Чёрный список (%s), %d ID:", scope, len(ids))
+	for _, id := range ids {
+		fmt.Fprintf(&sb, "\n• %d", id)
+	}
+	return sb.String()
+}
@@ -0,0 +1,90 @@
+package hamster
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBlacklistCommand(t *testing.T) {
+	b := setupBot()
+	b.cfg.Owners = []int64{7}
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	var replies []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { replies = append(replies, text); return 1 }
+
+	b.handleBlacklistCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/blacklist add 42"})
+	b.handleBlacklistCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/blacklist add 42"})
+	b.handleBlacklistCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/blacklist add abc"})
+	if got := b.chatSettings(-100).Blacklist; len(got) != 1 || got[0] != 42 {
+		t.Fatalf("чёрный список чата: %v", got)
+	}
+
+	// не админ ничего не меняет
+	b.handleBlacklistCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 11}, Text: "/blacklist remove 42"})
+	if len(b.chatSettings(-100).Blacklist) != 1 {
+		t.Error("не администратор не должен менять список")
+	}
+
+	// владелец в личке ведёт общий список; остальных бот не слушает
+	b.handleBlacklistCommand(&Message{Chat: Chat{ID: 7, Type: "private"}, From: &User{ID: 7}, Text: "/blacklist add 99"})
+	b.handleBlacklistCommand(&Message{Chat: Chat{ID: 8, Type: "private"}, From: &User{ID: 8}, Text: "/blacklist add 100"})
+	if got := b.chatSettings(globalSettingsID).Blacklist; len(got) != 1 || got[0] != 99 {
+		t.Errorf("общий чёрный список: %v", got)
+	}
+	for _, id := range b.settings.ChatIDs() {
+		if id == globalSettingsID {
+			t.Error("общие настройки не должны считаться чатом")
+		}
+	}
+	if !b.isBlacklisted(-200, 99) || !b.isBlacklisted(-100, 42) || b.isBlacklisted(-200, 42) {
+		t.Error("общий список действует во всех чатах, список чата — только в нём")
+	}
+
+	b.handleBlacklistCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/blacklist remove 42"})
+	if len(b.chatSettings(-100).Blacklist) != 0 {
+		t.Error("ID должен удаляться из списка")
+	}
+	b.handleBlacklistCommand(&Message{Chat: Chat{ID: 7, Type: "private"}, From: &User{ID: 7}, Text: "/blacklist"})
+	if last := replies[len(replies)-1]; !strings.Contains(last, "все чаты") || !strings.Contains(last, "99") {
+		t.Errorf("список владельца: %q", last)
+	}
+}
+
+func TestBlacklistedJoinBannedSilently(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.recentBans = newBanHistory(10)
+	b.updateChatSettings(globalSettingsID, func(c *ChatSettings) { c.Blacklist = []int64{42} })
+	var mu sync.Mutex
+	var banned []int64
+	var deleted []int64
+	greeted := false
+	fakeOf(b).ban = func(chatID, userID int64) { mu.Lock(); banned = append(banned, userID); mu.Unlock() }
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { mu.Lock(); deleted = append(deleted, msgID); mu.Unlock() }
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { greeted = true; return 10 }
+
+	b.handleJoinMessage(&Message{MessageID: 5, Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 42, FirstName: "Спам"}}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(banned) != 1 || banned[0] != 42 {
+		t.Errorf("участник из чёрного списка должен быть забанен: %v", banned)
+	}
+	if len(deleted) != 1 || deleted[0] != 5 {
+		t.Errorf("сообщение о вступлении должно быть удалено: %v", deleted)
+	}
+	if greeted {
+		t.Error("проверка не должна начинаться")
+	}
+	if st := b.stats.Get(-100); st.Joins != 1 || st.Banned != 1 {
+		t.Errorf("статистика: %+v", st)
+	}
+	if bans := b.recentBans.list(); len(bans) != 1 || bans[0].Reason != BanReasonBlacklist {
+		t.Errorf("журнал банов: %+v", bans)
+	}
+	if err := (ChatSettings{Blacklist: []int64{-5}}).Validate(); err == nil {
+		t.Error("отрицательный ID в списке должен отклоняться")
+	}
+}
@@ -0,0 +1,335 @@
+package hamster
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ==========================
+// Хранилище bbolt
+// ==========================
+
+var (
+	boltSettingsBucket = []byte("settings") // chatID → ChatSettings (JSON)
+	boltVerifiedBucket = []byte("verified") // "chatID:userID" → unix nano (8 байт)
+	boltStatsBucket    = []byte("stats")    // chatID → ChatStats (JSON)
+	boltBanLogBucket   = []byte("banlog")   // порядковый номер (8 байт) → BanLogEntry (JSON)
+	boltSentBucket     = []byte("sent")     // chatID → []SentMessage (JSON)
+	boltMetaBucket     = []byte("meta")     // служебные значения: boltOffsetKey
+
+	boltOffsetKey = []byte("update_offset") // UpdateOffset (JSON)
+)
+
+// boltStorage хранит всё состояние в одном файле bbolt: каждая запись — транзакция,
+// поэтому после сбоя база остаётся в последнем целиком записанном состоянии.
+type boltStorage struct {
+	db     *bolt.DB
+	logger *Logger
+}
+
+func openBoltStorage(file string, logger *Logger) (*boltStorage, error) {
+	// Timeout: второй экземпляр бота с той же базой не повиснет навсегда на блокировке файла
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("открытие %s: %w", file, err)
+	}
+	logger.Info("Хранилище bbolt: %s", file)
+	return &boltStorage{db: db, logger: logger}, nil
+}
+
+func (s *boltStorage) LoadSettings() (map[int64]*ChatSettings, error) {
+	var chats map[int64]*ChatSettings
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSettingsBucket)
+		if bucket == nil {
+			return nil
+		}
+		chats = make(map[int64]*ChatSettings)
+		return bucket.ForEach(func(k, v []byte) error {
+			chatID, err := strconv.ParseInt(string(k), 10, 64)
+			if err != nil {
+				return fmt.Errorf("некорректный ключ %q: %w", k, err)
+			}
+			var cs ChatSettings
+			if err := json.Unmarshal(v, &cs); err != nil {
+				return fmt.Errorf("настройки чата %d: %w", chatID, err)
+			}
+			chats[chatID] = &cs
+			return nil
+		})
+	})
+	if err == nil && chats != nil {
+		s.logger.Info("Загружены настройки %d чатов из bbolt", len(chats))
+	}
+	return chats, err
+}
+
+func (s *boltStorage) SaveSettings(chats map[int64]*ChatSettings) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := recreateBucket(tx, boltSettingsBucket)
+		if err != nil {
+			return err
+		}
+		for chatID, cs := range chats {
+			v, err := json.Marshal(cs)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(strconv.FormatInt(chatID, 10)), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStorage) LoadVerified() (map[string]time.Time, error) {
+	var verified map[string]time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltVerifiedBucket)
+		if bucket == nil {
+			return nil
+		}
+		verified = make(map[string]time.Time)
+		return bucket.ForEach(func(k, v []byte) error {
+			if len(v) != 8 {
+				return fmt.Errorf("некорректная запись верификации %q", k)
+			}
+			verified[string(k)] = time.Unix(0, int64(binary.BigEndian.Uint64(v)))
+			return nil
+		})
+	})
+	return verified, err
+}
+
+func (s *boltStorage) SaveVerified(verified map[string]time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := recreateBucket(tx, boltVerifiedBucket)
+		if err != nil {
+			return err
+		}
+		v := make([]byte, 8)
+		for key, at := range verified {
+			binary.BigEndian.PutUint64(v, uint64(at.UnixNano()))
+			if err := bucket.Put([]byte(key), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStorage) LoadStats() (map[int64]ChatStats, error) {
+	var stats map[int64]ChatStats
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltStatsBucket)
+		if bucket == nil {
+			return nil
+		}
+		stats = make(map[int64]ChatStats)
+		return bucket.ForEach(func(k, v []byte) error {
+			chatID, err := strconv.ParseInt(string(k), 10, 64)
+			if err != nil {
+				return fmt.Errorf("некорректный ключ %q: %w", k, err)
+			}
+			var st ChatStats
+			if err := json.Unmarshal(v, &st); err != nil {
+				return fmt.Errorf("статистика чата %d: %w", chatID, err)
+			}
+			stats[chatID] = st
+			return nil
+		})
+	})
+	return stats, err
+}
+
+func (s *boltStorage) SaveStats(stats map[int64]ChatStats) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := recreateBucket(tx, boltStatsBucket)
+		if err != nil {
+			return err
+		}
+		for chatID, st := range stats {
+			v, err := json.Marshal(st)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(strconv.FormatInt(chatID, 10)), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStorage) LoadSentMessages() (map[int64][]SentMessage, error) {
+	var sent map[int64][]SentMessage
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltSentBucket)
+		if bucket == nil {
+			return nil
+		}
+		sent = make(map[int64][]SentMessage)
+		return bucket.ForEach(func(k, v []byte) error {
+			chatID, err := strconv.ParseInt(string(k), 10, 64)
+			if err != nil {
+				return fmt.Errorf("некорректный ключ %q: %w", k, err)
+			}
+			var list []SentMessage
+			if err := json.Unmarshal(v, &list); err != nil {
+				return fmt.Errorf("сообщения бота в чате %d: %w", chatID, err)
+			}
+			sent[chatID] = list
+			return nil
+		})
+	})
+	return sent, err
+}
+
+func (s *boltStorage) SaveSentMessages(sent map[int64][]SentMessage) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := recreateBucket(tx, boltSentBucket)
+		if err != nil {
+			return err
+		}
+		for chatID, list := range sent {
+			v, err := json.Marshal(list)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(strconv.FormatInt(chatID, 10)), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStorage) LoadUpdateOffset() (UpdateOffset, error) {
+	var o UpdateOffset
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltMetaBucket)
+		if bucket == nil {
+			return nil
+		}
+		v := bucket.Get(boltOffsetKey)
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(v, &o)
+	})
+	return o, err
+}
+
+func (s *boltStorage) SaveUpdateOffset(o UpdateOffset) error {
+	v, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(boltMetaBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(boltOffsetKey, v)
+	})
+}
+
+func (s *boltStorage) LogBan(entry BanLogEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(boltBanLogBucket)
+		if err != nil {
+			return err
+		}
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		v, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		return bucket.Put(key, v)
+	})
+}
+
+func (s *boltStorage) BanLog(q BanLogQuery) ([]BanLogEntry, error) {
+	var out []BanLogEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBanLogBucket)
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		for k, v := c.Last(); k != nil && !q.full(len(out)); k, v = c.Prev() {
+			var e BanLogEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("запись журнала банов %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			if q.match(e) {
+				out = append(out, e)
+			}
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (s *boltStorage) ForgetUser(userID int64) (int, error) {
+	return s.removeBanLog(func(e BanLogEntry) bool { return e.UserID == userID })
+}
+
+func (s *boltStorage) PruneBanLog(before time.Time) (int, error) {
+	return s.removeBanLog(func(e BanLogEntry) bool { return e.At.Before(before) })
+}
+
+// removeBanLog удаляет из журнала банов записи, подходящие под match.
+func (s *boltStorage) removeBanLog(match func(BanLogEntry) bool) (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBanLogBucket)
+		if bucket == nil {
+			return nil
+		}
+		var keys [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			var e BanLogEntry
+			if json.Unmarshal(v, &e) == nil && match(e) {
+				keys = append(keys, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys { // удалять во время ForEach нельзя
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(keys)
+		return nil
+	})
+	return removed, err
+}
+
+func (s *boltStorage) Close() error {
+	return s.db.Close()
+}
+
+// recreateBucket очищает бакет внутри транзакции: хранилище получает полный снимок состояния.
+func recreateBucket(tx *bolt.Tx, name []byte) (*bolt.Bucket, error) {
+	if tx.Bucket(name) != nil {
+		if err := tx.DeleteBucket(name); err != nil {
+			return nil, err
+		}
+	}
+	return tx.CreateBucket(name)
+}
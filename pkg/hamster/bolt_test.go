@@ -0,0 +1,134 @@
+package hamster
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltStorageRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "hamster.db")
+	s, err := openBoltStorage(file, NewLogger())
+	if err != nil {
+		t.Fatalf("openBoltStorage вернул ошибку: %v", err)
+	}
+
+	if chats, err := s.LoadSettings(); err != nil || chats != nil {
+		t.Fatalf("в пустой базе ожидался nil без ошибки: %v %v", chats, err)
+	}
+
+	at := time.Unix(1700000000, 123)
+	if err := s.SaveSettings(map[int64]*ChatSettings{-100: {Timeout: 30, NameFilters: []string{"spam"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveVerified(map[string]time.Time{"-100:7": at}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveStats(map[int64]ChatStats{-100: {Joins: 3, Passed: 2, Failed: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveSentMessages(map[int64][]SentMessage{-100: {{MsgID: 55, At: at}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveUpdateOffset(UpdateOffset{Offset: 1001, At: at}); err != nil {
+		t.Fatal(err)
+	}
+	// повторное сохранение — полный снимок, удалённые чаты исчезают
+	if err := s.SaveSettings(map[int64]*ChatSettings{-200: {Disabled: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = openBoltStorage(file, NewLogger())
+	if err != nil {
+		t.Fatalf("повторное открытие вернуло ошибку: %v", err)
+	}
+	defer s.Close()
+
+	chats, err := s.LoadSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := chats[-100]; ok || chats[-200] == nil || !chats[-200].Disabled {
+		t.Errorf("ожидались только настройки чата -200: %+v", chats)
+	}
+	verified, err := s.LoadVerified()
+	if err != nil || !verified["-100:7"].Equal(at) {
+		t.Errorf("верификация не восстановлена: %v %v", verified, err)
+	}
+	stats, err := s.LoadStats()
+	if err != nil || !reflect.DeepEqual(stats[-100], ChatStats{Joins: 3, Passed: 2, Failed: 1}) {
+		t.Errorf("статистика не восстановлена: %v %v", stats, err)
+	}
+	sent, err := s.LoadSentMessages()
+	if err != nil || len(sent[-100]) != 1 || sent[-100][0].MsgID != 55 || !sent[-100][0].At.Equal(at) {
+		t.Errorf("сообщения бота не восстановлены: %v %v", sent, err)
+	}
+	offset, err := s.LoadUpdateOffset()
+	if err != nil || offset.Offset != 1001 || !offset.At.Equal(at) {
+		t.Errorf("смещение обновлений не восстановлено: %v %v", offset, err)
+	}
+}
+
+func TestBoltStorageImportsSettingsFile(t *testing.T) {
+	dir := t.TempDir()
+	fs := newFileStorage(filepath.Join(dir, "settings.json"), NewLogger())
+	if err := fs.SaveSettings(map[int64]*ChatSettings{1: {Timeout: 77}}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewBot("token", Config{
+		Storage:      StorageBolt,
+		BoltFile:     filepath.Join(dir, "hamster.db"),
+		SettingsFile: fs.file,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if got := b.settings.Timeout(1); got != 77 {
+		t.Errorf("настройки из settings.json должны переноситься в bbolt, получили %d", got)
+	}
+}
+
+func TestBoltStorageLogBan(t *testing.T) {
+	s, err := openBoltStorage(filepath.Join(t.TempDir(), "hamster.db"), NewLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, reason := range []string{BanReasonTimeout, BanReasonNameFilter} {
+		if err := s.LogBan(BanLogEntry{ChatID: -100, UserID: 5, Reason: reason, At: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count := 0
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBanLogBucket).ForEach(func(k, v []byte) error {
+			count++
+			return nil
+		})
+	})
+	if count != 2 {
+		t.Errorf("ожидалось две записи в журнале, получили %d", count)
+	}
+	got, err := s.BanLog(BanLogQuery{ChatID: -100, Limit: 1})
+	if err != nil || len(got) != 1 || got[0].Reason != BanReasonNameFilter {
+		t.Errorf("журнал отдаётся новыми записями первыми: %+v %v", got, err)
+	}
+	if got, _ := s.BanLog(BanLogQuery{ChatID: -100, Reason: BanReasonTimeout}); len(got) != 1 {
+		t.Errorf("отбор по причине: %+v", got)
+	}
+	if n, err := s.ForgetUser(5); err != nil || n != 2 {
+		t.Errorf("ForgetUser должен удалить обе записи: %d %v", n, err)
+	}
+	if got, _ := s.BanLog(BanLogQuery{ChatID: -100}); len(got) != 0 {
+		t.Errorf("после ForgetUser журнал пуст: %+v", got)
+	}
+}
@@ -0,0 +1,1554 @@
+package hamster
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math/big"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ==========================
+// Базовые типы
+// ==========================
+
+// HTTPClient — транспорт для запросов к Telegram Bot API (*http.Client подходит).
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+	Get(url string) (*http.Response, error)
+	Post(url, contentType string, body io.Reader) (*http.Response, error)
+}
+
+type adminCacheEntry struct {
+	status    string
+	expiresAt time.Time
+}
+
+// Bot — бот проверки новых участников. Создаётся через NewBot, работает
+// через StartWithContext и должен закрываться через Close.
+type Bot struct {
+	settings       *Settings
+	stats          *Stats
+	storage        Storage
+	logger         *Logger
+	api            TelegramAPI
+	ctx            context.Context // отменяется в Close: прерывает запросы к Telegram
+	cancel         context.CancelFunc
+	httpClient     HTTPClient      // для запросов вне Bot API (выгрузка копий в S3, CAS)
+	tracer         *tracer         // nil — трассировка выключена
+	reporter       ErrorReporter   // nil — отчёты об ошибках выключены
+	notifiers      []Notifier      // уведомления в Discord, Slack, Matrix
+	alerts         *emailAlerter   // nil — письма о критических событиях выключены
+	recorder       *updateRecorder // nil — обновления не записываются
+	apiStreaks     apiStreaks
+	adminCache     map[string]adminCacheEntry // под muAdmin
+	cfg            Config
+	verified       *verifiedUsers
+	recentBans     *banHistory // последние баны для веб-панели
+	broadcasts     chan broadcastJob
+	foreignPresses *pressCounter    // нажатия на чужие кнопки проверки
+	callbackCounts callbackCounters // нажатия по исходам и наказания, для /metrics
+	raids          *raidDetector    // частота вступлений по чатам
+	linked         linkedChats      // каналы, привязанные к группам
+	joins          recentJoins      // недавние вступления, чтобы не проверять дважды
+	joinLinks      joinLinks        // по какой ссылке вступили участники на проверке
+	firstMessages  firstMessages    // сколько сообщений новичков осталось проверить на стоп-слова
+	rateMutes      rateMutes        // муты за частые сообщения, чтобы не выдавать их повторно
+	audit          adminAudit       // последние команды настройки и их частота по пользователям
+	reports        sentReports      // жалобы /report, ждущие разбора
+	hooks          memberHooks      // обработчики OnJoin, OnVerified, OnFailed, OnBanned
+	joinQueue      joinQueue        // идущие проверки и очередь сверх MaxPendingPerChat
+	sent           sentMessages     // неудалённые сообщения бота в группах, для /cleanup
+	offset         updateOffset     // смещение getUpdates, переживает перезапуск
+	self           User             // сам бот, из getMe
+	instanceID     string           // имя экземпляра для блокировок в общем хранилище
+	leading        atomic.Bool      // экземпляр сейчас ведущий (при LEADER_ELECTION)
+	webAppKey      []byte           // ключ проверки initData из Mini App
+
+	settingsSaver *debouncer // откладывает запись настроек (nil — писать сразу)
+	stateSaver    *debouncer // откладывает запись верификаций и статистики
+
+	userMessages map[int64]*list.List
+	userLRU      lruKeys[int64]  // порядок использования userMessages
+	adminLRU     lruKeys[string] // порядок использования adminCache
+
+	progressStore pendingStore // незавершённые проверки
+
+	muMessages sync.Mutex
+	muAdmin    sync.Mutex
+	adminCalls map[string]*adminCall // запросы getChatMember в процессе, под muAdmin
+	adminLists map[int64]*adminList  // списки админов по чатам, под muAdmin
+	adminChats map[int64]time.Time   // когда в чате последний раз проверяли админа, под muAdmin
+}
+
+type cachedMessage struct {
+	msg       Message
+	timestamp time.Time
+	isBot     bool
+	isPending bool // для непройденного прогрессбара
+}
+
+type Update struct {
+	UpdateID      int64              `json:"update_id"`
+	Message       *Message           `json:"message,omitempty"`
+	EditedMessage *Message           `json:"edited_message,omitempty"`
+	Callback      *Callback          `json:"callback_query,omitempty"`
+	MyChatMember  *ChatMemberUpdated `json:"my_chat_member,omitempty"`
+	ChatMember    *ChatMemberUpdated `json:"chat_member,omitempty"`
+}
+
+type Message struct {
+	MessageID          int64           `json:"message_id"`
+	Text               string          `json:"text"`
+	Chat               Chat            `json:"chat"`
+	From               *User           `json:"from,omitempty"`
+	SenderChat         *Chat           `json:"sender_chat,omitempty"`          // анонимный админ (сам чат) или канал
+	IsAutomaticForward bool            `json:"is_automatic_forward,omitempty"` // пост привязанного канала
+	NewChatMembers     []*User         `json:"new_chat_members,omitempty"`
+	LeftChatMember     *User           `json:"left_chat_member,omitempty"`
+	ReplyToMessage     *Message        `json:"reply_to_message,omitempty"`
+	Photo              []File          `json:"photo,omitempty"` // размеры по возрастанию
+	Animation          *File           `json:"animation,omitempty"`
+	Sticker            *File           `json:"sticker,omitempty"`
+	Entities           []MessageEntity `json:"entities,omitempty"`
+	MigrateToChatID    int64           `json:"migrate_to_chat_id,omitempty"`
+	MigrateFromChatID  int64           `json:"migrate_from_chat_id,omitempty"`
+	Caption            string          `json:"caption,omitempty"`
+	CaptionEntities    []MessageEntity `json:"caption_entities,omitempty"`
+	ForwardOrigin      *MessageOrigin  `json:"forward_origin,omitempty"`
+	ForwardFrom        *User           `json:"forward_from,omitempty"`
+	ForwardFromChat    *Chat           `json:"forward_from_chat,omitempty"`
+	ForwardDate        int64           `json:"forward_date,omitempty"`
+}
+
+// File — вложение сообщения; боту нужен только file_id.
+type File struct {
+	FileID string `json:"file_id"`
+}
+
+// MessageOrigin — источник пересланного сообщения (user, hidden_user, chat, channel).
+type MessageOrigin struct {
+	Type       string `json:"type"`
+	Date       int64  `json:"date"`
+	SenderUser *User  `json:"sender_user,omitempty"`
+	Chat       *Chat  `json:"chat,omitempty"`
+}
+
+// IsForwarded сообщает, что сообщение переслано из другого чата или от другого пользователя.
+func (m *Message) IsForwarded() bool {
+	return m.ForwardOrigin != nil || m.ForwardFrom != nil || m.ForwardFromChat != nil || m.ForwardDate != 0
+}
+
+// MessageEntity — разметка внутри текста (ссылки, упоминания и т.д.).
+// Offset и Length задаются в UTF-16 code units.
+type MessageEntity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	URL    string `json:"url,omitempty"`
+}
+
+type Chat struct {
+	ID           int64  `json:"id"`
+	Type         string `json:"type"`
+	LinkedChatID int64  `json:"linked_chat_id,omitempty"` // только в ответе getChat
+
+	Permissions *ChatPermissions `json:"permissions,omitempty"` // только в ответе getChat
+}
+
+type User struct {
+	ID        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name,omitempty"`
+	Username  string `json:"username,omitempty"`
+	IsBot     bool   `json:"is_bot"`
+	IsPremium bool   `json:"is_premium,omitempty"`
+}
+
+// ChatMemberUpdated — изменение статуса участника (для my_chat_member — самого бота).
+type ChatMemberUpdated struct {
+	Chat          Chat            `json:"chat"`
+	From          *User           `json:"from"`
+	Date          int64           `json:"date"`
+	OldChatMember ChatMember      `json:"old_chat_member"`
+	NewChatMember ChatMember      `json:"new_chat_member"`
+	InviteLink    *ChatInviteLink `json:"invite_link,omitempty"` // ссылка, по которой вступил участник
+}
+
+// ChatMember — статус и права участника чата.
+type ChatMember struct {
+	Status             string `json:"status"`
+	User               *User  `json:"user"`
+	IsMember           bool   `json:"is_member,omitempty"` // для restricted: находится ли в чате
+	CanDeleteMessages  bool   `json:"can_delete_messages,omitempty"`
+	CanRestrictMembers bool   `json:"can_restrict_members,omitempty"`
+	CanInviteUsers     bool   `json:"can_invite_users,omitempty"`
+}
+
+type Callback struct {
+	ID      string   `json:"id"`
+	From    *User    `json:"from"`
+	Message *Message `json:"message"`
+	Data    string   `json:"data"`
+}
+
+// ==========================
+// Прогрессбар
+// ==========================
+
+type progressData struct {
+	stopOnce      sync.Once
+	stopChan      chan struct{}
+	token         string
+	chatID        int64 // под progressStore.mu: меняется при миграции чата
+	userID        int64
+	greetMsgID    int64
+	msgProgressID int64 // id сообщения с прогрессбаром (⏳), под progressStore.mu
+	startedAt     time.Time
+	timeout       int // секунд на нажатие кнопки
+
+	challenge  Challenge         // nil — проверка одной кнопкой
+	session    *ChallengeSession // состояние проверки
+	failReason string            // причина досрочного провала, "" — не провалена (под progressStore.mu)
+	answerMu   sync.Mutex        // ответы разбираются по одному: многошаговые проверки меняют session
+
+	// cancel прерывает запросы этой проверки (прогрессбар, приветствие),
+	// когда она завершается или бот останавливается.
+	cancel context.CancelFunc
+}
+
+// ==========================
+// Конструктор
+// ==========================
+
+// NewBot создаёт бота. Без опций используются хранилище из cfg.Storage,
+// логгер в stdout и клиент Bot API поверх стандартного HTTP-клиента с
+// таймаутом чуть больше PollTimeout.
+func NewBot(token string, cfg Config, opts ...Option) (*Bot, error) {
+	o := options{
+		logger:     NewLogger(),
+		httpClient: &http.Client{Timeout: cfg.pollTimeout() + 10*time.Second},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.api == nil {
+		o.api = NewTelegramAPIWithURL(cfg.APIURL, token, o.httpClient)
+	}
+	storage := o.storage
+	if storage == nil {
+		var err error
+		if storage, err = OpenStorage(cfg, o.logger); err != nil {
+			return nil, err
+		}
+	}
+	if o.reporter == nil && cfg.SentryDSN != "" {
+		var err error
+		if o.reporter, err = NewSentryReporter(cfg.SentryDSN, cfg.SentryEnvironment, newInstanceID(cfg.InstanceID), o.httpClient, o.logger); err != nil {
+			return nil, fmt.Errorf("SENTRY_DSN: %w", err)
+		}
+	}
+	tr := newTracer(cfg.Trace, o.httpClient, o.logger)
+	queue := newQueuedAPI(o.api, apiWorkers)
+	queue.tracer = tr
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Bot{
+		settings:       NewSettings(),
+		stats:          NewStats(),
+		storage:        storage,
+		logger:         o.logger,
+		userMessages:   make(map[int64]*list.List),
+		api:            queue,
+		ctx:            ctx,
+		cancel:         cancel,
+		httpClient:     o.httpClient,
+		tracer:         tr,
+		reporter:       o.reporter,
+		notifiers:      append(NewNotifiers(cfg.Notify, o.httpClient), o.notifiers...),
+		adminCache:     make(map[string]adminCacheEntry),
+		cfg:            cfg,
+		verified:       newVerifiedUsers(),
+		recentBans:     newBanHistory(recentBansLimit),
+		broadcasts:     make(chan broadcastJob, broadcastQueueSize),
+		foreignPresses: newPressCounter(),
+		raids:          newRaidDetector(),
+		webAppKey:      webAppKey(token),
+		instanceID:     newInstanceID(cfg.InstanceID),
+	}
+	if cfg.RecordUpdatesFile != "" {
+		rec, err := openUpdateRecorder(cfg.RecordUpdatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("запись обновлений: %w", err)
+		}
+		b.recorder = rec
+		b.logger.Warn("⏺ Все обновления записываются в %s — в файле личные данные участников", cfg.RecordUpdatesFile)
+	}
+	if cfg.PhrasesDir != "" {
+		n, err := LoadPhrasePacks(cfg.PhrasesDir)
+		if err != nil {
+			return nil, fmt.Errorf("наборы фраз из %s: %w", cfg.PhrasesDir, err)
+		}
+		b.logger.Info("Загружено наборов фраз: %d из %s", n, cfg.PhrasesDir)
+	}
+	if cfg.Alerts.enabled() {
+		b.alerts = newEmailAlerter(cfg.Alerts)
+	}
+	if len(b.notifiers) > 0 {
+		b.OnFailed(b.notifyFailed)
+	}
+	b.OnBanned(b.federateBan)
+	b.settingsSaver = newDebouncer(settingsSaveDelay, settingsSaveMaxWait, b.writeSettings)
+	b.stateSaver = newDebouncer(stateSaveDelay, stateSaveMaxWait, b.writeState)
+	b.loadSettings()
+	b.loadState()
+	return b, nil
+}
+
+// ==========================
+// Запуск бота
+// ==========================
+
+// StartWithContext получает обновления до отмены ctx и выполняет фоновые
+// задачи, меняющие состояние (RunCleanup, RunJanitor, RunStaleChatGC).
+// С LeaderElection всё это делает только ведущий экземпляр, остальные ждут
+// своей очереди.
+func (b *Bot) StartWithContext(ctx context.Context) {
+	b.loadSelf()
+	if b.cfg.LeaderElection {
+		if _, ok := b.storage.(Locker); ok {
+			b.runAsLeader(ctx, leaderRenew, b.lead)
+			return
+		}
+		b.logger.Warn("LEADER_ELECTION требует общего хранилища (STORAGE=postgres), работаем без выборов")
+	}
+	b.lead(ctx)
+}
+
+// poll — цикл getUpdates.
+func (b *Bot) poll(ctx context.Context) {
+	b.sweepLeftovers()
+	b.restoreRaidLocks()
+	go b.restoreJoinQueues()
+	b.logger.Info("🤖 Бот запущен (polling)...")
+	offset := b.startOffset()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.logger.Info("🛑 Остановка polling по контексту")
+			return
+		default:
+		}
+
+		recv := b.tracer.start("receive", nil, spanClient)
+		updates, err := b.safeGetUpdates(ctx, offset)
+		if len(updates) > 0 || err != nil {
+			// пустые ответы long polling не интересны
+			recv.set("updates", len(updates)).end(err)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.logger.Warn("getUpdates error, retrying...")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.cfg.pollRetryDelay()):
+			}
+			continue
+		}
+
+		if err := b.recorder.record(updates); err != nil {
+			b.logger.Warn("Не удалось записать обновления: %v", err)
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			b.cacheMessage(u)
+			b.markChatSeen(u)
+			sp := b.traceUpdate(u, recv)
+			go func(u Update) {
+				defer func() {
+					if r := recover(); r != nil {
+						b.logger.Error("Паника в handleUpdate: %v", r)
+						b.reportPanic(u, r, debug.Stack())
+						sp.end(fmt.Errorf("паника: %v", r))
+						return
+					}
+					sp.end(nil)
+				}()
+				b.handleUpdate(u)
+			}(u)
+		}
+		if len(updates) > 0 {
+			b.markProcessed(offset)
+		}
+	}
+}
+
+// ==========================
+// Обработка обновлений
+// ==========================
+
+func (b *Bot) handleUpdate(u Update) {
+	if u.Message != nil {
+		msg := u.Message
+		if msg.MigrateToChatID != 0 {
+			b.migrateChat(msg.Chat.ID, msg.MigrateToChatID)
+			return
+		}
+		if msg.MigrateFromChatID != 0 {
+			b.migrateChat(msg.MigrateFromChatID, msg.Chat.ID)
+			return
+		}
+		if b.isSelf(msg.From) {
+			return
+		}
+		if !b.auditConfigCommand(msg, commandName(msg.Text)) {
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		}
+		switch commandName(msg.Text) {
+		case "/timeout":
+			b.handleTimeoutCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/namefilter":
+			b.handleNameFilterCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/hamster":
+			b.handleHamsterCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/help", "/start":
+			b.handleHelpCommand(msg)
+			if msg.Chat.Type != "private" {
+				b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			}
+			return
+		case "/stats":
+			b.handleStatsCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/diagnose":
+			b.handleDiagnoseCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/restorebackup":
+			b.handleRestoreBackupCommand(msg)
+			return
+		case "/chats", "/chatstats", "/leave", "/purge", "/version":
+			b.handleOwnerCommand(msg)
+			return
+		case "/setrules":
+			b.handleSetRulesCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/rulesmode":
+			b.handleRulesModeCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/setwelcomemedia":
+			b.handleSetWelcomeMediaCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/notify":
+			b.handleNotifyCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/protect":
+			b.handleProtectCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/phrases":
+			b.handlePhrasesCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/cleanup":
+			b.handleCleanupCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/progress":
+			b.handleProgressCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/plaintext":
+			b.handlePlainTextCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/keepgreeting":
+			b.handleKeepGreetingCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/service":
+			b.handleServiceCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/channels":
+			b.handleChannelsCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/links":
+			b.handleLinksCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/adminadd":
+			b.handleAdminAddCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/night":
+			b.handleNightCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/spamwords":
+			b.handleSpamWordsCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/fed":
+			b.handleFedCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/fban":
+			b.handleFedBanCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/funban":
+			b.handleFedUnbanCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/blacklist":
+			b.handleBlacklistCommand(msg)
+			if msg.Chat.Type != "private" {
+				b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			}
+			return
+		case "/ratelimit":
+			b.handleRateLimitCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/forwards":
+			b.handleForwardsCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/trust", "/untrust":
+			b.handleTrustCommand(msg, commandName(msg.Text) == "/trust")
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/verify":
+			b.handleVerifyCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/report":
+			b.handleReportCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/check":
+			b.handleCheckCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/banlog":
+			b.handleBanLogCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/copysettings":
+			b.handleCopySettingsCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/forgetme":
+			b.handleForgetMeCommand(msg)
+			if msg.Chat.Type != "private" {
+				b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			}
+			return
+		case "/export":
+			if msg.Chat.Type == "private" {
+				b.handleOwnerCommand(msg)
+				return
+			}
+			b.handleExportCommand(msg)
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			return
+		case "/broadcast":
+			b.handleBroadcastCommand(msg)
+			if msg.Chat.Type != "private" {
+				b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+			}
+			return
+		}
+		if len(msg.NewChatMembers) > 0 {
+			b.cleanServiceMessage(msg)
+			go b.handleJoinMessage(msg)
+			return
+		}
+		if msg.LeftChatMember != nil {
+			b.cleanServiceMessage(msg)
+			b.handleMemberLeft(msg.Chat.ID, msg.LeftChatMember.ID)
+			return
+		}
+		if b.applyChannelFilter(msg) {
+			return
+		}
+		if b.handleChallengeMessage(msg) {
+			return
+		}
+		if b.applyProbation(msg) {
+			return
+		}
+		if b.applySpamWords(msg) {
+			return
+		}
+		if b.applyRateLimit(msg) {
+			return
+		}
+	}
+
+	if u.EditedMessage != nil {
+		b.handleEditedMessage(u.EditedMessage)
+	}
+
+	if u.Callback != nil {
+		b.handleCallback(u.Callback)
+	}
+
+	if u.MyChatMember != nil {
+		b.handleMyChatMember(u.MyChatMember)
+	}
+
+	if u.ChatMember != nil {
+		go b.handleChatMember(u.ChatMember)
+	}
+}
+
+// ==========================
+// Команда /timeout
+// ==========================
+
+func (b *Bot) handleTimeoutCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+
+	var msgID int64
+	if !b.isAdminMessage(msg) {
+		msgID = b.safeSendSilent(b.ctx, msg.Chat.ID, "❌ Только администратор может задавать таймаут")
+		time.AfterFunc(5*time.Second, func() {
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msgID)
+		})
+		return
+	}
+
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 {
+		cs := b.chatSettings(msg.Chat.ID)
+		text := fmt.Sprintf("⏱ Текущий таймаут: %d сек. (по умолчанию)", cs.TimeoutSec())
+		if cs.Timeout > 0 {
+			text = fmt.Sprintf("⏱ Текущий таймаут: %d сек. (по умолчанию %d, сбросить: /timeout reset)", cs.Timeout, DefaultTimeoutSec)
+		}
+		if sec, ok := suggestTimeout(b.statsFor(msg.Chat.ID)); ok {
+			text += fmt.Sprintf("\n📈 По статистике нажатий подойдёт %d сек.: /timeout auto", sec)
+		}
+		msgID = b.safeSendSilent(b.ctx, msg.Chat.ID, text+"\n⚙️ Изменить: /timeout <секунд>")
+		time.AfterFunc(10*time.Second, func() {
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msgID)
+		})
+		return
+	}
+
+	if parts[1] == "auto" {
+		b.handleTimeoutAuto(msg)
+		return
+	}
+
+	if parts[1] == "reset" {
+		b.updateChatSettings(msg.Chat.ID, func(c *ChatSettings) { c.Timeout = 0 })
+		msgID = b.safeSendSilent(b.ctx, msg.Chat.ID, fmt.Sprintf("✅ Таймаут сброшен до значения по умолчанию: %d сек.", DefaultTimeoutSec))
+		time.AfterFunc(5*time.Second, func() {
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msgID)
+		})
+		return
+	}
+
+	timeoutSecVar, err := strconv.Atoi(parts[1])
+	if err != nil || timeoutSecVar < 5 || timeoutSecVar > 600 {
+		msgID = b.safeSendSilent(b.ctx, msg.Chat.ID, "⚙️ Укажите значение от 5 до 600 секунд")
+		time.AfterFunc(5*time.Second, func() {
+			b.safeDeleteMessage(b.ctx, msg.Chat.ID, msgID)
+		})
+		return
+	}
+
+	b.settings.SetTimeout(msg.Chat.ID, timeoutSecVar)
+	b.saveSettings()
+	msgID = b.safeSendSilent(b.ctx, msg.Chat.ID, fmt.Sprintf("✅ Таймаут установлен: %d сек.", timeoutSecVar))
+	time.AfterFunc(5*time.Second, func() {
+		b.safeDeleteMessage(b.ctx, msg.Chat.ID, msgID)
+	})
+}
+
+// ==========================
+// Приветствие новых участников
+// ==========================
+
+func (b *Bot) handleJoinMessage(msg *Message) {
+	b.handleJoin(msg, nil)
+}
+
+// handleJoin проверяет вступивших; link — ссылка-приглашение из chat_member,
+// nil — неизвестна (сервисное сообщение).
+func (b *Bot) handleJoin(msg *Message, link *ChatInviteLink) {
+	if !b.chatEnabled(msg.Chat.ID) {
+		return
+	}
+	for _, user := range msg.NewChatMembers {
+		if b.isSelf(user) {
+			continue
+		}
+		if b.joins.seen(msg.Chat.ID, user.ID, time.Now()) {
+			// уже пришло сервисным сообщением или chat_member; ссылка же
+			// бывает только в chat_member
+			b.recordLinkJoin(msg.Chat.ID, user.ID, link)
+			b.applyLateLinkPolicy(msg.Chat.ID, user, link)
+			if msg.MessageID != 0 && (b.isBlacklisted(msg.Chat.ID, user.ID) || b.isFedBanned(msg.Chat.ID, user.ID)) {
+				b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID) // бан уже выдан по chat_member
+			}
+			continue
+		}
+		if !b.claimJoin(msg.Chat.ID, user.ID) {
+			continue // проверку ведёт другой экземпляр бота
+		}
+		if b.isBlacklisted(msg.Chat.ID, user.ID) {
+			b.banBlacklisted(msg, user, BanReasonBlacklist)
+			continue
+		}
+		if b.isFedBanned(msg.Chat.ID, user.ID) {
+			b.banBlacklisted(msg, user, BanReasonFederation)
+			continue
+		}
+		if user.IsBot {
+			b.handleBotJoin(msg, user)
+			continue
+		}
+		b.recordStat(msg.Chat.ID, func(c *ChatStats) { c.Joins++ })
+		joined := *user
+		b.fireHook(hookJoin, MemberEvent{ChatID: msg.Chat.ID, UserID: user.ID, User: &joined})
+		if b.addedByAdmin(msg, user) {
+			b.trustAdded(msg, user)
+			continue
+		}
+		b.recordLinkJoin(msg.Chat.ID, user.ID, link)
+		policy := b.linkPolicy(msg.Chat.ID, link)
+		if policy == LinkBan {
+			b.banByLink(msg.Chat.ID, user.ID, link)
+			continue
+		}
+		night := b.nightMode(msg.Chat.ID, time.Now())
+		if night == NightLockdown {
+			b.lockdownJoin(msg.Chat.ID, user.ID)
+			continue
+		}
+		raid := b.raidJoin(msg.Chat.ID) || policy == LinkHard || night == NightHard
+		banned, strict := b.screenJoin(msg.Chat.ID, user)
+		strict = strict || night == NightStrict
+		if banned {
+			b.recordStat(msg.Chat.ID, func(c *ChatStats) { c.Banned++ })
+			b.recordLinkStat(msg.Chat.ID, user.ID, func(l *LinkStats) { l.Banned++ })
+			continue
+		}
+		if policy == LinkTrusted {
+			b.trustJoin(msg.Chat.ID, user.ID, link)
+			continue
+		}
+		soft := false
+		if !raid && !strict {
+			switch b.trustedAccount(msg.Chat.ID, user) {
+			case TrustSkip:
+				b.markVerified(msg.Chat.ID, user.ID)
+				continue
+			case TrustSoften:
+				soft = true
+			}
+		}
+		b.admitJoin(queuedJoin{msg: msg, user: user, raid: raid, strict: strict, soft: soft})
+	}
+}
+
+// startVerification отправляет приветствие с проверкой и ведёт её в фоне.
+// done вызывается, когда проверка завершилась.
+func (b *Bot) startVerification(j queuedJoin, done func()) {
+	msg, user := j.msg, j.user
+	cs := b.chatSettings(msg.Chat.ID)
+	timeout := cs.TimeoutSec()
+	if j.strict {
+		cs, timeout = b.strictChallenge(cs)
+	}
+	if j.raid {
+		cs, timeout = b.raidChallenge(cs, timeout)
+	}
+	if j.soft {
+		cs.CaptchaType = CaptchaButton
+	}
+	cs = plainCaptcha(cs)
+
+	token := randString(8)
+
+	// вопрос и кнопки задаёт тип проверки чата
+	ch := challengeFor(cs)
+	if cs.Captcha() == CaptchaWebApp && b.webAppLink() == "" {
+		ch, _ = lookupChallenge(CaptchaButton) // Mini App не настроен
+	}
+	session := &ChallengeSession{ChatID: msg.Chat.ID, User: user, Settings: cs}
+	prompt := ch.Render(session)
+	text := greetingHTML(cs.Welcome(), user)
+	if cs.PlainText {
+		prompt = plainPrompt(prompt)
+		text = stripEmoji(text)
+	}
+	if prompt.Text != "" {
+		text += "\n\n" + html.EscapeString(prompt.Text)
+	}
+
+	// Отправляем приветствие с кнопками; запросы проверки живут до её конца.
+	// Нажатие, пришедшее раньше, чем проверка зарегистрирована, её дождётся.
+	release := b.progressStore.reserve(msg.Chat.ID, user.ID)
+	defer release()
+	ctx, cancel := context.WithCancel(b.ctx)
+	greetMsgID := b.sendGreeting(ctx, msg.Chat.ID, text, challengeMarkup(prompt, user.ID, token, b.webAppLink()), session)
+	session.SentAt = time.Now()
+
+	// Кэшируем приветственное сообщение бота
+	b.muMessages.Lock()
+	b.userMessageList(user.ID).PushBack(cachedMessage{
+		msg:       Message{MessageID: greetMsgID, Chat: msg.Chat, From: &User{IsBot: true}},
+		timestamp: time.Now(),
+		isBot:     true,
+		isPending: true, // пока прогрессбар не завершён
+	})
+	if session.mediaMsgID != 0 {
+		b.userMessageList(user.ID).PushBack(cachedMessage{
+			msg:       Message{MessageID: session.mediaMsgID, Chat: msg.Chat, From: &User{IsBot: true}},
+			timestamp: time.Now(),
+			isBot:     true,
+			isPending: true,
+		})
+	}
+	b.muMessages.Unlock()
+
+	// Регистрируем проверку и запускаем прогрессбар для нового пользователя
+	p := newProgress(cancel, msg.Chat.ID, greetMsgID, user.ID, token, timeout, ch, session)
+	b.progressStore.add(p)
+	release()
+	go func() {
+		defer done()
+		b.runProgress(ctx, p)
+	}()
+}
+
+// ==========================
+// Прогрессбар и таймер с остановкой
+// ==========================
+
+func (b *Bot) startProgressbar(chatID int64, greetMsgID int64, userID int64, token string) {
+	b.startProgressbarWithTimeout(chatID, greetMsgID, userID, token, b.chatSettings(chatID).TimeoutSec())
+}
+
+func (b *Bot) startProgressbarWithTimeout(chatID int64, greetMsgID int64, userID int64, token string, timeout int) {
+	ctx, cancel := context.WithCancel(b.ctx)
+	b.runChallenge(ctx, cancel, chatID, greetMsgID, userID, token, timeout, nil, nil)
+}
+
+// newProgress создаёт незавершённую проверку для уже отправленного приветствия.
+// ch и session могут быть nil — тогда это проверка одной кнопкой; cancel
+// прерывает запросы проверки при её завершении.
+func newProgress(cancel context.CancelFunc, chatID int64, greetMsgID int64, userID int64, token string, timeout int, ch Challenge, session *ChallengeSession) *progressData {
+	return &progressData{
+		stopChan:   make(chan struct{}),
+		token:      token,
+		chatID:     chatID,
+		userID:     userID,
+		greetMsgID: greetMsgID,
+		startedAt:  time.Now(),
+		timeout:    timeout,
+		challenge:  ch,
+		session:    session,
+		cancel:     cancel,
+	}
+}
+
+// runChallenge регистрирует проверку и ведёт её прогрессбар до ответа или таймаута.
+func (b *Bot) runChallenge(ctx context.Context, cancel context.CancelFunc, chatID int64, greetMsgID int64, userID int64, token string, timeout int, ch Challenge, session *ChallengeSession) {
+	p := newProgress(cancel, chatID, greetMsgID, userID, token, timeout, ch, session)
+	b.progressStore.add(p)
+	b.runProgress(ctx, p)
+}
+
+// runProgress ведёт прогрессбар зарегистрированной проверки до ответа или
+// таймаута. Запросы прогрессбара идут с ctx проверки.
+func (b *Bot) runProgress(ctx context.Context, p *progressData) {
+	chatID, _ := b.progressStore.chatOf(p) // чат мог мигрировать в супергруппу
+	userID, timeout, stop := p.userID, p.timeout, p.stopChan
+	defer p.cancel()
+
+	// создаём сообщение с прогрессбаром; проверка уже зарегистрирована, и
+	// нажатие кнопки приветствия, пока идёт запрос, не теряется
+	cs := b.chatSettings(chatID)
+	first := "⏳⏳⏳⏳⏳⏳⏳⏳"
+	if cs.PlainText || cs.ProgressStyle != "" {
+		first = countdownText(cs, timeout, timeout, 0)
+	}
+	msgProgressID := b.safeSend(ctx, chatID, first, nil, cs.sendOptions(MsgProgress))
+	b.progressStore.mu.Lock()
+	p.msgProgressID = msgProgressID
+	b.progressStore.mu.Unlock()
+	if id, ok := b.progressStore.chatOf(p); !ok {
+		// проверка завершилась, пока отправлялся прогрессбар: finishProgress
+		// его ещё не знал
+		if msgProgressID != 0 {
+			b.safeDeleteMessage(b.ctx, id, msgProgressID)
+		}
+		return
+	}
+
+	// кэшируем сообщение прогрессбара как ботское
+	b.muMessages.Lock()
+	b.userMessageList(userID).PushBack(cachedMessage{
+		msg:       Message{MessageID: msgProgressID, Chat: Chat{ID: chatID}, From: &User{IsBot: true}},
+		timestamp: time.Now(),
+		isBot:     true,
+		isPending: false,
+	})
+	b.muMessages.Unlock()
+
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	remaining := timeout
+	step := 0
+
+	for remaining > 0 {
+		select {
+		case <-stop:
+			remaining = 0 // кнопка нажата
+		case <-ctx.Done():
+			remaining = 0 // проверка завершена или бот останавливается
+		case <-ticker.C:
+			if id, ok := b.progressStore.chatOf(p); ok {
+				chatID = id // чат мог мигрировать в супергруппу
+			}
+			b.safeEditMessage(ctx, chatID, msgProgressID, countdownText(cs, timeout, remaining, step))
+			step++
+			remaining--
+		}
+	}
+
+	if b.ctx.Err() != nil {
+		return // бот останавливается: оставшиеся сообщения уберёт sweepLeftovers
+	}
+
+	// Завершение прогрессбара
+	chatID, ok := b.progressStore.chatOf(p) // чат мог мигрировать в супергруппу
+	if !ok {
+		return
+	}
+
+	// Проверка, была ли нажата кнопка
+	b.progressStore.mu.Lock()
+	reason := p.failReason
+	b.progressStore.mu.Unlock()
+	select {
+	case <-p.stopChan:
+		if reason == "" {
+			// кнопка нажата — просто удаляем ботские и pending-сообщения
+			b.stopProgressbar(chatID, p)
+			return
+		}
+	default:
+		reason = BanReasonTimeout
+	}
+
+	// таймер истёк или ответ неверный
+	b.failVerification(chatID, p, reason)
+}
+
+// failVerification завершает проваленную проверку: банит (или исключает)
+// пользователя и удаляет только ботские/pending-сообщения. Если проверку уже
+// завершил кто-то другой, ничего не делает.
+func (b *Bot) failVerification(chatID int64, p *progressData, reason string) {
+	if !b.finishProgress(chatID, p, true) {
+		return
+	}
+	userID := p.userID
+	b.fireHook(hookFailed, MemberEvent{ChatID: chatID, UserID: userID, Reason: reason})
+	challenge, s := progressChallenge(p)
+	s.Settings = b.chatSettings(chatID) // настройки могли измениться за время проверки
+	if challenge.OnTimeout(s) == ActionKick {
+		// исключённый может вернуться: это не бан, в журнал и OnBanned не идёт
+		b.safeKickUser(b.ctx, chatID, userID)
+		b.logger.Info("Участник %d исключён из чата %d: %s", userID, chatID, banReasonName(reason))
+	} else {
+		b.safeBanUser(b.ctx, chatID, userID)
+		b.logBan(chatID, userID, reason)
+	}
+	b.recordStat(chatID, func(c *ChatStats) { c.Failed++ })
+	b.recordLinkStat(chatID, userID, func(l *LinkStats) { l.Failed++ })
+	b.deletePendingMessages(chatID, userID)
+}
+
+// verificationGrace — сколько сверх таймаута ждать завершения проверки её
+// собственным таймером, прежде чем SweepExpiredVerifications завершит её сам.
+const verificationGrace = 30 * time.Second
+
+// SweepExpiredVerifications завершает проверки, чей срок давно истёк, а
+// таймер так и не сработал (например, его горутина упала с паникой): бан и
+// уборка сообщений идут тем же путём, что и по таймауту. Проверки,
+// прерванные перезапуском, убирает sweepLeftovers.
+func (b *Bot) SweepExpiredVerifications() {
+	now := time.Now()
+	var expired []*progressData
+	var chats []int64 // chatID меняется при миграции чата, читаем под mu
+	b.progressStore.each(func(p *progressData) {
+		if now.Sub(p.startedAt) > time.Duration(p.timeout)*time.Second+verificationGrace {
+			expired = append(expired, p)
+			chats = append(chats, p.chatID)
+		}
+	})
+	for i, p := range expired {
+		b.logger.Warn("Проверка %d в чате %d не завершилась вовремя — завершаем по таймауту", p.userID, chats[i])
+		b.failVerification(chats[i], p, BanReasonTimeout)
+	}
+}
+
+// ==========================
+// Остановка прогрессбара
+// ==========================
+
+func (b *Bot) stopProgressbar(chatID int64, p *progressData) {
+	b.finishProgress(chatID, p, true)
+}
+
+// finishProgress останавливает прогрессбар и удаляет его сообщение, а при
+// deleteGreeting — и приветствие. false — проверка уже завершена.
+func (b *Bot) finishProgress(chatID int64, p *progressData, deleteGreeting bool) bool {
+	if !b.progressStore.remove(p) {
+		return false
+	}
+
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+		if p.cancel != nil {
+			p.cancel()
+		}
+	})
+
+	// удаляем только ботские сообщения
+	if p.greetMsgID != 0 && deleteGreeting {
+		b.safeDeleteMessage(b.ctx, chatID, p.greetMsgID)
+	}
+	if p.session != nil && p.session.mediaMsgID != 0 && deleteGreeting {
+		b.safeDeleteMessage(b.ctx, chatID, p.session.mediaMsgID)
+	}
+	b.progressStore.mu.Lock()
+	msgProgressID := p.msgProgressID
+	b.progressStore.mu.Unlock()
+	if msgProgressID != 0 {
+		b.safeDeleteMessage(b.ctx, chatID, msgProgressID)
+	}
+	return true
+}
+
+// ==========================
+// Обработка callback
+// ==========================
+
+func (b *Bot) handleCallback(cb *Callback) {
+	if cb.Message == nil || cb.From == nil {
+		b.safeAnswerCallback(b.ctx, cb.ID, "", false)
+		return
+	}
+
+	if strings.HasPrefix(cb.Data, "rules:") {
+		b.callbackCounts.inc(callbackOther)
+		b.handleRulesCallback(cb)
+		return
+	}
+	if strings.HasPrefix(cb.Data, "report:") {
+		b.callbackCounts.inc(callbackOther)
+		b.handleReportCallback(cb)
+		return
+	}
+
+	parts := strings.SplitN(cb.Data, ":", 4)
+	if len(parts) < 3 || parts[0] != "click" {
+		b.callbackCounts.inc(callbackOther)
+		b.safeAnswerCallback(b.ctx, cb.ID, "", false)
+		return
+	}
+	userID, _ := strconv.ParseInt(parts[1], 10, 64)
+	token := parts[2]
+	data := ""
+	if len(parts) == 4 {
+		data = parts[3]
+	}
+
+	// чужую кнопку отклоняем до поиска проверки
+	if cb.From.ID != userID {
+		b.callbackCounts.inc(callbackForeign)
+		b.handleForeignPress(cb)
+		return
+	}
+
+	// проверка по приветствию, под которым нажата кнопка; если приветствие
+	// ещё отправляется, ждём регистрации проверки
+	p := b.progressStore.byMessage(cb.Message.Chat.ID, cb.Message.MessageID)
+	if p == nil && b.progressStore.awaitStart(b.ctx, cb.Message.Chat.ID, userID) {
+		p = b.progressStore.byMessage(cb.Message.Chat.ID, cb.Message.MessageID)
+	}
+
+	// проверяем токен
+	if p == nil || p.token != token {
+		b.callbackCounts.inc(callbackExpired)
+		b.safeAnswerCallback(b.ctx, cb.ID, "⌛ Эта проверка уже завершена", false)
+		return
+	}
+
+	if b.clickTooFast(cb, p) {
+		b.callbackCounts.inc(callbackTooFast)
+		return
+	}
+	b.callbackCounts.inc(callbackOwn)
+
+	ch, s := progressChallenge(p)
+	p.answerMu.Lock()
+	result := ch.HandleCallback(s, data)
+	p.answerMu.Unlock()
+	switch result {
+	case ChallengePassed:
+		b.safeAnswerCallback(b.ctx, cb.ID, "✅ Добро пожаловать!", false)
+		b.passChallenge(cb.Message.Chat.ID, cb.From, p)
+	case ChallengeFailed:
+		b.safeAnswerCallback(b.ctx, cb.ID, "❌ Неверный ответ", true)
+		b.failChallenge(p, BanReasonWrongAnswer)
+	default:
+		b.safeAnswerCallback(b.ctx, cb.ID, "", false)
+	}
+}
+
+// passChallenge завершает проверку успешно. false — проверку уже завершили
+// (например, таймаут успел раньше), и участник не пропущен.
+func (b *Bot) passChallenge(chatID int64, user *User, p *progressData) bool {
+	// останавливаем прогрессбар и удаляем только ботские сообщения
+	cs := b.chatSettings(chatID)
+	if !b.finishProgress(chatID, p, !cs.KeepGreeting) {
+		return false
+	}
+	b.markVerified(chatID, user.ID)
+	b.recordStat(chatID, func(c *ChatStats) { c.Passed++ })
+	b.recordLinkStat(chatID, user.ID, func(l *LinkStats) { l.Passed++ })
+	if !b.showRules(chatID, user, cs) {
+		b.restrictNewcomerMedia(chatID, user.ID)
+	}
+
+	if cs.KeepGreeting && p.greetMsgID != 0 {
+		b.keepGreeting(chatID, p, cs, user)
+		return true
+	}
+
+	// сообщение пользователю
+	welcome := fmt.Sprintf("✨ %s, добро пожаловать!", mentionHTML(user))
+	if cs.PlainText {
+		welcome = stripEmoji(welcome)
+	}
+	msgID := b.safeSend(b.ctx, chatID, welcome, nil, htmlOptions)
+	time.AfterFunc(60*time.Second, func() {
+		b.safeDeleteMessage(b.ctx, chatID, msgID)
+	})
+	return true
+}
+
+// ==========================
+// Кэш сообщений пользователей
+// ==========================
+
+func (b *Bot) cacheMessage(u Update) {
+	if u.Message == nil || u.Message.From == nil || b.isSelf(u.Message.From) {
+		return
+	}
+
+	userID := u.Message.From.ID
+	b.muMessages.Lock()
+	defer b.muMessages.Unlock()
+
+	cm := cachedMessage{
+		msg:       *u.Message,
+		timestamp: time.Now(),
+		isBot:     u.Message.From.IsBot,
+		isPending: false,
+	}
+
+	// Если пользователь с прогрессбаром — помечаем его сообщения как pending
+	if !cm.isBot && b.isUserPending(u.Message.Chat.ID, userID) {
+		cm.isPending = true
+	}
+
+	l := b.userMessageList(userID)
+	l.PushBack(cm)
+
+	// Очистка старых сообщений
+	cutoff := time.Now().Add(-b.cfg.messageCacheTTL())
+	for e := l.Front(); e != nil; {
+		next := e.Next()
+		if e.Value.(cachedMessage).timestamp.Before(cutoff) {
+			l.Remove(e)
+		}
+		e = next
+	}
+	if l.Len() == 0 {
+		b.dropUserMessages(userID)
+	}
+}
+
+// ==========================
+// Удаление сообщений (универсальная функция)
+// ==========================
+func (b *Bot) deleteUserMessagesFiltered(chatID, userID int64, filter func(cachedMessage) bool) {
+	b.muMessages.Lock()
+	defer b.muMessages.Unlock()
+
+	msgs, ok := b.userMessages[userID]
+	if !ok {
+		return
+	}
+
+	for e := msgs.Front(); e != nil; {
+		next := e.Next()
+		m := e.Value.(cachedMessage)
+		if m.msg.Chat.ID == chatID && filter(m) {
+			b.safeDeleteMessage(b.ctx, chatID, m.msg.MessageID)
+			msgs.Remove(e)
+		}
+		e = next
+	}
+
+	if msgs.Len() == 0 {
+		b.dropUserMessages(userID)
+	}
+}
+
+func (b *Bot) deletePendingMessages(chatID, userID int64) {
+	b.deleteUserMessagesFiltered(chatID, userID, func(m cachedMessage) bool {
+		return m.isBot || m.isPending
+	})
+}
+
+func (b *Bot) deleteUserMessages(chatID, userID int64) {
+	b.deleteUserMessagesFiltered(chatID, userID, func(m cachedMessage) bool {
+		return true
+	})
+}
+
+func (b *Bot) deleteUserMessagesSince(chatID, userID int64, since time.Time) {
+	b.deleteUserMessagesFiltered(chatID, userID, func(m cachedMessage) bool {
+		return !m.timestamp.Before(since)
+	})
+}
+
+func removeIf(l *list.List, cond func(e *list.Element) bool) {
+	for e := l.Front(); e != nil; {
+		next := e.Next()
+		if cond(e) {
+			l.Remove(e)
+		}
+		e = next
+	}
+}
+
+func (b *Bot) CleanupOldMessages() {
+	now := time.Now()
+	b.muMessages.Lock()
+	defer b.muMessages.Unlock()
+
+	for userID, lst := range b.userMessages {
+		removeIf(lst, func(e *list.Element) bool {
+			cm := e.Value.(cachedMessage)
+			return now.Sub(cm.timestamp) > b.cfg.messageCacheTTL()
+		})
+		if lst.Len() == 0 {
+			b.dropUserMessages(userID)
+		}
+	}
+}
+
+// cleanupInterval — как часто RunCleanup чистит устаревшие данные.
+const cleanupInterval = 10 * time.Second
+
+// RunCleanup раз в cleanupInterval чистит кэш сообщений, истёкшие
+// верификации и зависшие проверки.
+func (b *Bot) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.CleanupOldMessages()
+			b.CleanupVerified()
+			b.SweepExpiredVerifications()
+		}
+	}
+}
+
+// Проверка, есть ли у пользователя активный прогрессбар в чате
+func (b *Bot) isUserPending(chatID, userID int64) bool {
+	return b.progressStore.get(chatID, userID) != nil
+}
+
+// ==========================
+// Генерация токена
+// ==========================
+
+func randString(n int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	res := make([]byte, n)
+	for i := 0; i < n; i++ {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
+		if err != nil {
+			res[i] = letters[int(time.Now().UnixNano())%len(letters)]
+			continue
+		}
+		res[i] = letters[num.Int64()]
+	}
+	return string(res)
+}
+
+// ==========================
+// Безопасные вызовы Telegram API
+// ==========================
+// Обёртки над TelegramAPI пишут ошибку в журнал и возвращают нулевое
+// значение: бот продолжает работу, даже если отдельный вызов не удался.
+
+func (b *Bot) safeGetUpdates(ctx context.Context, offset int64) ([]Update, error) {
+	updates, err := b.api.GetUpdates(ctx, offset, int(b.cfg.pollTimeout()/time.Second), b.cfg.PollLimit)
+	if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		b.logger.Warn("safeGetUpdates failed: %v", err)
+		b.noteAPIResult("getUpdates", 0, 0, err)
+		if isUnauthorized(err) {
+			b.alert(alertToken, "токен бота недействителен", fmt.Sprintf("Telegram отвечает на getUpdates: %v\nБот не получает обновлений, пока токен не заменят.", err))
+		}
+	} else if err == nil {
+		b.noteAPIResult("getUpdates", 0, 0, nil)
+	}
+	return updates, err
+}
+
+func (b *Bot) safeSendSilent(ctx context.Context, chatID int64, text string) int64 {
+	return b.safeSend(ctx, chatID, text, nil, SendOptions{})
+}
+
+func (b *Bot) safeSendSilentWithMarkup(ctx context.Context, chatID int64, text string, markup interface{}) int64 {
+	return b.safeSend(ctx, chatID, text, markup, SendOptions{})
+}
+
+// safeSend отправляет сообщение с параметрами opts (например, в HTML).
+func (b *Bot) safeSend(ctx context.Context, chatID int64, text string, markup interface{}, opts SendOptions) int64 {
+	msgID, err := b.api.SendMessage(ctx, chatID, text, markup, opts)
+	if err != nil {
+		b.logger.Warn("safeSend failed: %v", err)
+	}
+	b.noteAPIResult("sendMessage", chatID, 0, err)
+	b.recordSent(chatID, msgID)
+	return msgID
+}
+
+func (b *Bot) safeEditMessage(ctx context.Context, chatID int64, msgID int64, text string) {
+	b.safeEdit(ctx, chatID, msgID, text, "")
+}
+
+func (b *Bot) safeEdit(ctx context.Context, chatID int64, msgID int64, text, parseMode string) {
+	if err := b.api.EditMessage(ctx, chatID, msgID, text, parseMode); err != nil && !errors.Is(err, ErrEditDropped) && !errors.Is(err, context.Canceled) {
+		b.logger.Warn("safeEdit failed: %v", err)
+	}
+}
+
+func (b *Bot) safeSendMedia(ctx context.Context, chatID int64, media Media, caption string, markup interface{}, opts SendOptions) int64 {
+	msgID, err := b.api.SendMedia(ctx, chatID, media, caption, markup, opts)
+	if err != nil {
+		b.logger.Warn("safeSendMedia failed: %v", err)
+	}
+	b.noteAPIResult("sendMedia", chatID, 0, err)
+	b.recordSent(chatID, msgID)
+	return msgID
+}
+
+func (b *Bot) safeEditCaption(ctx context.Context, chatID int64, msgID int64, caption, parseMode string) {
+	if err := b.api.EditCaption(ctx, chatID, msgID, caption, parseMode); err != nil && !errors.Is(err, ErrEditDropped) && !errors.Is(err, context.Canceled) {
+		b.logger.Warn("safeEditCaption failed: %v", err)
+	}
+}
+
+func (b *Bot) safeDeleteMessage(ctx context.Context, chatID int64, msgID int64) {
+	b.sent.remove(chatID, msgID)
+	if err := b.api.DeleteMessage(ctx, chatID, msgID); err != nil {
+		b.logger.Warn("safeDeleteMessage failed: %v", err)
+	}
+}
+
+// safeGetMe возвращает информацию о самом боте или nil.
+func (b *Bot) safeGetMe(ctx context.Context) *User {
+	me, err := b.api.GetMe(ctx)
+	if err != nil {
+		b.logger.Warn("safeGetMe failed: %v", err)
+		return nil
+	}
+	return &me
+}
+
+// safeGetChatType возвращает тип чата ("channel", "supergroup", ...) по id или @username.
+func (b *Bot) safeGetChatType(ctx context.Context, chatRef string) string {
+	chat, err := b.api.GetChat(ctx, chatRef)
+	if err != nil {
+		b.logger.Warn("safeGetChatType failed: %v", err)
+	}
+	return chat.Type
+}
+
+// safeAnswerCallback убирает «часики» на кнопке; text показывается
+// всплывающей подсказкой или, при showAlert, окном с кнопкой OK.
+func (b *Bot) safeAnswerCallback(ctx context.Context, callbackID, text string, showAlert bool) {
+	if callbackID == "" {
+		return
+	}
+	if err := b.api.AnswerCallback(ctx, callbackID, text, showAlert); err != nil {
+		b.logger.Warn("safeAnswerCallback failed: %v", err)
+	}
+}
+
+// safeBanUser банит участника чата.
+func (b *Bot) safeBanUser(ctx context.Context, chatID, userID int64) {
+	err := b.api.Ban(ctx, chatID, userID)
+	if err != nil {
+		b.logger.Warn("safeBanUser failed: %v", err)
+	}
+	b.noteAPIResult("banChatMember", chatID, userID, err)
+}
+
+// safeUnbanUser снимает бан, не трогая тех, кто в чате (only_if_banned).
+func (b *Bot) safeUnbanUser(ctx context.Context, chatID, userID int64) {
+	if err := b.api.Unban(ctx, chatID, userID); err != nil {
+		b.logger.Warn("safeUnbanUser failed: %v", err)
+	}
+}
+
+// safeBanSenderChat запрещает каналу писать в чат от своего имени.
+func (b *Bot) safeBanSenderChat(ctx context.Context, chatID, senderChatID int64) {
+	if err := b.api.BanSenderChat(ctx, chatID, senderChatID); err != nil {
+		b.logger.Warn("safeBanSenderChat failed: %v", err)
+	}
+}
+
+// safeKickUser исключает участника без бессрочного бана: он сможет вернуться.
+func (b *Bot) safeKickUser(ctx context.Context, chatID, userID int64) {
+	b.safeBanUser(ctx, chatID, userID)
+	b.safeUnbanUser(ctx, chatID, userID)
+}
+
+// safeRestrictUser ограничивает права участника до момента until.
+func (b *Bot) safeRestrictUser(ctx context.Context, chatID, userID int64, perms ChatPermissions, until time.Time) {
+	err := b.api.Restrict(ctx, chatID, userID, perms, until)
+	if err != nil {
+		b.logger.Warn("safeRestrictUser failed: %v", err)
+	}
+	b.noteAPIResult("restrictChatMember", chatID, userID, err)
+}
+
+// safeGetChatMember возвращает статус и права участника чата.
+func (b *Bot) safeGetChatMember(ctx context.Context, chatID, userID int64) (ChatMember, error) {
+	return b.api.GetChatMember(ctx, chatID, userID)
+}
+
+// safeSetChatPermissions меняет права участников чата.
+func (b *Bot) safeSetChatPermissions(ctx context.Context, chatID int64, perms ChatPermissions) error {
+	err := b.api.SetChatPermissions(ctx, chatID, perms)
+	if err != nil {
+		b.logger.Warn("safeSetChatPermissions failed: %v", err)
+	}
+	return err
+}
+
+// safeSendDocument отправляет файл.
+func (b *Bot) safeSendDocument(ctx context.Context, chatID int64, name string, content []byte, caption string) error {
+	_, err := b.api.SendDocument(ctx, chatID, name, content, caption)
+	if err != nil {
+		b.logger.Warn("safeSendDocument failed: %v", err)
+	}
+	return err
+}
+
+// safeLeaveChat выводит бота из чата.
+func (b *Bot) safeLeaveChat(ctx context.Context, chatID int64) error {
+	return b.api.LeaveChat(ctx, chatID)
+}
+
+// safeGetChatAdministrators возвращает администраторов чата.
+func (b *Bot) safeGetChatAdministrators(ctx context.Context, chatID int64) ([]ChatMember, error) {
+	return b.api.GetChatAdministrators(ctx, chatID)
+}
+
+// ==========================
+// Проверка администраторов
+// ==========================
+
+func (b *Bot) isAdmin(ctx context.Context, chatID, userID int64) bool {
+	status, err := b.adminStatus(ctx, chatID, userID)
+	if err != nil {
+		b.logger.Warn("isAdmin failed with retry: %v", err)
+		return false
+	}
+	return isAdminStatus(status)
+}
+
+// isAdminMessage сообщает, что сообщение отправил администратор чата. Анонимные
+// администраторы пишут от имени самого чата: From у них — GroupAnonymousBot,
+// а sender_chat совпадает с чатом.
+func (b *Bot) isAdminMessage(msg *Message) bool {
+	if msg.SenderChat != nil {
+		return msg.SenderChat.ID == msg.Chat.ID
+	}
+	return msg.From != nil && b.isAdmin(b.ctx, msg.Chat.ID, msg.From.ID)
+}
+
+// ==========================
+// Утилиты
+// ==========================
+
+func progressBar(total int, remaining int) string {
+	const n = 8
+	if total <= 0 {
+		return "[" + strings.Repeat("⬛", n) + "]"
+	}
+	filled := remaining * n / total
+	if filled > n {
+		filled = n
+	}
+	bar := strings.Repeat("⬛", n-filled) + strings.Repeat("🟩", filled)
+	return "[" + bar + "]"
+}
+
+func nextClockEmoji(i int) string {
+	clocks := []string{
+		"🕛", "🕧", "🕐", "🕜", "🕑", "🕝", "🕒", "🕞",
+		"🕓", "🕟", "🕔", "🕠", "🕕", "🕡", "🕖", "🕢",
+		"🕗", "🕣", "🕘", "🕤", "🕙", "🕥", "🕚", "🕦",
+	}
+	return clocks[i%len(clocks)]
+}
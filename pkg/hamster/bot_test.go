@@ -0,0 +1,648 @@
+package hamster
+
+import (
+	"container/list"
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// мок HTTP-клиента для тестов
+type mockHTTPClient struct {
+	DoFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (m *mockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if m.DoFunc != nil {
+		return m.DoFunc(req)
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`{}`)),
+	}, nil
+}
+
+func (m *mockHTTPClient) Get(url string) (*http.Response, error) {
+	req, _ := http.NewRequest("GET", url, nil)
+	return m.Do(req)
+}
+
+func (m *mockHTTPClient) Post(url, contentType string, body io.Reader) (*http.Response, error) {
+	req, _ := http.NewRequest("POST", url, body)
+	req.Header.Set("Content-Type", contentType)
+	return m.Do(req)
+}
+
+// setupBot создаёт Bot с мокированными функциями и пустыми картами
+func setupBot() *Bot {
+	return &Bot{
+		logger:       NewLogger(),
+		userMessages: make(map[int64]*list.List),
+		settings:     NewSettings(),
+
+		api:        &fakeAPI{},
+		ctx:        context.Background(),
+		httpClient: &mockHTTPClient{},
+	}
+}
+
+// -------------------------
+// Тест pickPhrase
+// -------------------------
+func TestPickPhrase(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		p := pickPhrase()
+		if len(p) < 3 {
+			t.Errorf("слишком короткая фраза: %q", p)
+		}
+		if idx := strings.IndexRune(p, ' '); idx <= 0 {
+			t.Errorf("Ожидался пробел после иконки: %q", p)
+		}
+	}
+}
+
+// -------------------------
+// Тест таймаутов в Settings (in-memory)
+// -------------------------
+func TestTimeoutCommandSetGet(t *testing.T) {
+	to := NewSettings()
+
+	// Set/Get
+	to.SetTimeout(1, 42)
+	if got := to.Timeout(1); got != 42 {
+		t.Errorf("ожидалось 42, получили %d", got)
+	}
+
+	// "Load" симулируем через новый объект и повторные Set
+	loaded := NewSettings()
+	loaded.SetTimeout(1, to.Timeout(1)) // используем только публичный метод Timeout
+	if got := loaded.Timeout(1); got != 42 {
+		t.Errorf("после Load ожидалось 42, получили %d", got)
+	}
+}
+
+// -------------------------
+// Тест progressBar
+// -------------------------
+func TestProgressBarLength(t *testing.T) {
+	bar := progressBar(10, 5)
+	if len([]rune(bar)) != 10 {
+		t.Errorf("progressBar неверной длины: %q", bar)
+	}
+}
+
+// -------------------------
+// Тест progressBar цвета
+// -------------------------
+func TestProgressBarBlocks(t *testing.T) {
+	tests := []struct {
+		total, remaining int
+		expectBlack      int
+		expectGreen      int
+	}{
+		{10, 10, 0, 8},
+		{10, 5, 4, 4},
+		{10, 0, 8, 0},
+	}
+
+	for _, tt := range tests {
+		bar := progressBar(tt.total, tt.remaining)
+		if strings.Count(bar, "⬛") != tt.expectBlack {
+			t.Errorf("remaining=%d, ожидалось %d черных, получили %d", tt.remaining, tt.expectBlack, strings.Count(bar, "⬛"))
+		}
+		if strings.Count(bar, "🟩") != tt.expectGreen {
+			t.Errorf("remaining=%d, ожидалось %d зеленых, получили %d", tt.remaining, tt.expectGreen, strings.Count(bar, "🟩"))
+		}
+	}
+}
+
+// -------------------------
+// Тест nextClockEmoji
+// -------------------------
+func TestNextClockEmojiSequence(t *testing.T) {
+	for i := 0; i < 24; i++ {
+		e := nextClockEmoji(i)
+		if e == "" {
+			t.Errorf("emoji пустой для i=%d", i)
+		}
+	}
+}
+
+// -------------------------
+// Тест кэша сообщений
+// -------------------------
+func TestCacheAndCleanupMessages(t *testing.T) {
+	b := &Bot{
+		api:          &fakeAPI{},
+		ctx:          context.Background(),
+		logger:       NewLogger(),
+		userMessages: make(map[int64]*list.List),
+	}
+
+	msg := Message{
+		MessageID: 1,
+		Text:      "/test",
+		Chat:      Chat{ID: 1234},
+		From:      &User{ID: 42},
+	}
+	update := Update{UpdateID: 1, Message: &msg}
+	b.cacheMessage(update)
+
+	// Извлекаем элемент и меняем timestamp
+	elem := b.userMessages[42].Front()
+	if elem == nil {
+		t.Fatalf("в списке нет элементов")
+	}
+	elem.Value = cachedMessage{
+		msg:       msg,
+		timestamp: time.Now().Add(-61 * time.Second), // старее 60 секунд
+	}
+
+	// Вызываем очистку
+	b.CleanupOldMessages()
+
+	// Проверяем список сообщений
+	if l, ok := b.userMessages[42]; ok && l.Len() > 0 {
+		t.Errorf("Сообщение не удалено после истечения времени")
+	}
+}
+
+// -------------------------
+// Тест handleCallback
+// -------------------------
+func TestHandleCallbackStopsProgress(t *testing.T) {
+	b := setupBot()
+
+	stop := make(chan struct{})
+	b.progressStore.add(&progressData{
+		stopChan:      stop,
+		token:         "TOKEN123",
+		chatID:        1,
+		userID:        42,
+		greetMsgID:    100,
+		msgProgressID: 101,
+	})
+
+	var deleted, sent bool
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = true }
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { sent = true; return 1 }
+
+	cb := &Callback{
+		Message: &Message{MessageID: 100, Chat: Chat{ID: 1}},
+		From:    &User{ID: 42, FirstName: "Test"},
+		Data:    "click:42:TOKEN123",
+	}
+
+	b.handleCallback(cb)
+
+	select {
+	case <-stop:
+	default:
+		t.Errorf("stopChan не закрыт")
+	}
+
+	if b.progressStore.get(1, 42) != nil {
+		t.Errorf("прогрессбар не удалён после callback")
+	}
+	if !deleted {
+		t.Errorf("сообщение не удалено после callback")
+	}
+	if !sent {
+		t.Errorf("приветственное сообщение не отправлено")
+	}
+}
+
+// -------------------------
+// Тест handleTimeoutCommand
+// -------------------------
+func TestHandleTimeoutCommand(t *testing.T) {
+	b := &Bot{
+		api:        &fakeAPI{},
+		ctx:        context.Background(),
+		logger:     NewLogger(),
+		settings:   NewSettings(),
+		adminCache: make(map[string]adminCacheEntry),
+	}
+
+	var sentMsgs []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		sentMsgs = append(sentMsgs, text)
+		return 1
+	}
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) {}
+
+	b.adminCache["1:42"] = adminCacheEntry{status: "administrator", expiresAt: time.Now().Add(1 * time.Minute)}
+
+	msg := &Message{
+		Chat: Chat{ID: 1},
+		From: &User{ID: 42},
+		Text: "/timeout 10",
+	}
+	b.handleTimeoutCommand(msg)
+
+	if len(sentMsgs) == 0 || !strings.Contains(sentMsgs[0], "10") {
+		t.Errorf("таймаут не установлен или сообщение не отправлено: %v", sentMsgs)
+	}
+	if got := b.settings.Timeout(1); got != 10 {
+		t.Errorf("ожидалось 10, получили %d", got)
+	}
+}
+
+// -------------------------
+// Тест handleJoinMessage
+// -------------------------
+func TestHandleJoinMessage(t *testing.T) {
+	b := setupBot()
+
+	msg := &Message{
+		MessageID: 1,
+		Chat:      Chat{ID: 1234},
+		From:      &User{ID: 42},
+		Text:      "joined",
+	}
+
+	b.handleJoinMessage(msg) // просто вызываем, без присваивания
+}
+
+// -------------------------
+// Тест startProgressbar с моками
+// -------------------------
+func TestStartProgressbarStopsAndDeletes(t *testing.T) {
+	b := &Bot{
+		api:          &fakeAPI{},
+		ctx:          context.Background(),
+		logger:       NewLogger(),
+		userMessages: make(map[int64]*list.List),
+		settings:     NewSettings(),
+	}
+
+	b.settings.SetTimeout(1, 1)
+
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { return 1 }
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) {}
+	fakeOf(b).editMessage = func(chatID, msgID int64, text string) {}
+	fakeOf(b).ban = func(chatID, userID int64) {}
+
+	done := make(chan struct{})
+	go func() {
+		b.startProgressbar(1, 10, 42, "TOKEN")
+		close(done)
+	}()
+
+	<-done
+
+	if b.progressStore.get(1, 42) != nil {
+		t.Errorf("прогрессбар не удалён из хранилища")
+	}
+	if b.progressStore.byMessage(1, 10) != nil {
+		t.Errorf("приветствие не удалено из индекса")
+	}
+}
+
+// -------------------------
+// progressBar границы
+// -------------------------
+func TestProgressBarBoundaries(t *testing.T) {
+	if got := progressBar(0, 0); !strings.Contains(got, "⬛") {
+		t.Errorf("ожидалось только черные блоки, получили %s", got)
+	}
+	if got := progressBar(5, 10); strings.Count(got, "🟩") != 8 {
+		t.Errorf("слишком много/мало зеленых блоков: %s", got)
+	}
+}
+
+// -------------------------
+// nextClockEmoji границы
+// -------------------------
+func TestNextClockEmojiOverflow(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		e := nextClockEmoji(i)
+		if e == "" {
+			t.Errorf("emoji пустой для i=%d", i)
+		}
+	}
+}
+
+// -------------------------
+// cacheMessage + isUserPending
+// -------------------------
+func TestCacheMessagePendingFlag(t *testing.T) {
+	b := setupBot()
+	userID := int64(1)
+	b.progressStore.add(&progressData{chatID: 1, userID: userID, greetMsgID: 99, stopChan: make(chan struct{})})
+
+	msg := Message{MessageID: 1, Chat: Chat{ID: 1}, From: &User{ID: userID}}
+	b.cacheMessage(Update{Message: &msg})
+
+	elem := b.userMessages[userID].Back()
+	cm := elem.Value.(cachedMessage)
+	if !cm.isPending {
+		t.Error("сообщение пользователя с активным прогрессбаром должно быть pending")
+	}
+}
+
+// -------------------------
+// handleCallback неправильный токен
+// -------------------------
+func TestHandleCallbackWrongToken(t *testing.T) {
+	b := setupBot()
+	userID := int64(1)
+	b.progressStore.add(&progressData{
+		chatID:     1,
+		userID:     userID,
+		token:      "TOKEN",
+		stopChan:   make(chan struct{}),
+		greetMsgID: 100,
+	})
+	called := false
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { called = true; return 1 }
+
+	cb := &Callback{
+		Message: &Message{MessageID: 100, Chat: Chat{ID: 1}},
+		From:    &User{ID: userID},
+		Data:    "click:1:WRONG",
+	}
+	b.handleCallback(cb)
+	if called {
+		t.Error("callback с неправильным токеном не должен отправлять сообщение")
+	}
+}
+
+// -------------------------
+// /timeout без аргументов и /timeout reset
+// -------------------------
+func TestHandleTimeoutCommandShowAndReset(t *testing.T) {
+	b := &Bot{
+		api:        &fakeAPI{},
+		ctx:        context.Background(),
+		logger:     NewLogger(),
+		settings:   NewSettings(),
+		adminCache: map[string]adminCacheEntry{"1:42": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}},
+	}
+	var sentMsgs []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		sentMsgs = append(sentMsgs, text)
+		return 1
+	}
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) {}
+
+	b.handleTimeoutCommand(&Message{Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "/timeout"})
+	if len(sentMsgs) != 1 || !strings.Contains(sentMsgs[0], "по умолчанию") {
+		t.Errorf("ожидалось значение по умолчанию: %v", sentMsgs)
+	}
+
+	b.settings.SetTimeout(1, 90)
+	b.handleTimeoutCommand(&Message{Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "/timeout"})
+	if len(sentMsgs) != 2 || !strings.Contains(sentMsgs[1], "90") || !strings.Contains(sentMsgs[1], "reset") {
+		t.Errorf("ожидалось явно заданное значение 90: %v", sentMsgs)
+	}
+
+	b.handleTimeoutCommand(&Message{Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "/timeout reset"})
+	if b.settings.Get(1).Timeout != 0 {
+		t.Error("после /timeout reset таймаут должен быть по умолчанию")
+	}
+}
+
+// -------------------------
+// handleCallback всегда отвечает на callback
+// -------------------------
+func TestHandleCallbackAnswers(t *testing.T) {
+	type answer struct {
+		text  string
+		alert bool
+	}
+	cases := []struct {
+		name  string
+		from  int64
+		data  string
+		want  string
+		alert bool
+	}{
+		{"чужая кнопка", 7, "click:42:TOKEN", "не для вас", true},
+		{"устаревший токен", 42, "click:42:OLD", "завершена", false},
+		{"успех", 42, "click:42:TOKEN", "Добро пожаловать", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := setupBot()
+			b.progressStore.add(&progressData{
+				stopChan:   make(chan struct{}),
+				token:      "TOKEN",
+				chatID:     1,
+				userID:     42,
+				greetMsgID: 100,
+			})
+			var got []answer
+			fakeOf(b).answerCallback = func(id, text string, alert bool) {
+				if id != "cb1" {
+					t.Errorf("неверный callback_query_id %q", id)
+				}
+				got = append(got, answer{text, alert})
+			}
+			b.handleCallback(&Callback{
+				ID:      "cb1",
+				Message: &Message{MessageID: 100, Chat: Chat{ID: 1}},
+				From:    &User{ID: tc.from},
+				Data:    tc.data,
+			})
+			if len(got) != 1 || !strings.Contains(got[0].text, tc.want) || got[0].alert != tc.alert {
+				t.Errorf("ответ %+v, ожидали %q (alert=%v)", got, tc.want, tc.alert)
+			}
+		})
+	}
+
+	// чужие callback тоже получают ответ, чтобы кнопка не «крутилась»
+	b := setupBot()
+	answered := false
+	fakeOf(b).answerCallback = func(id, text string, alert bool) { answered = true }
+	b.handleCallback(&Callback{ID: "cb2", From: &User{ID: 1}, Message: &Message{}, Data: "other"})
+	if !answered {
+		t.Error("неизвестный callback должен получать пустой ответ")
+	}
+}
+
+func TestIsAdminMessageAnonymousAdmin(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{}
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "member"}, nil
+	}
+	anonymous := &User{ID: 1087968824, IsBot: true, Username: "GroupAnonymousBot"}
+
+	if !b.isAdminMessage(&Message{Chat: Chat{ID: -100}, From: anonymous, SenderChat: &Chat{ID: -100}}) {
+		t.Error("сообщение от имени самого чата пишет анонимный администратор")
+	}
+	if b.isAdminMessage(&Message{Chat: Chat{ID: -100}, From: &User{ID: 136817688}, SenderChat: &Chat{ID: -200, Type: "channel"}}) {
+		t.Error("сообщение от имени канала не даёт прав администратора")
+	}
+	if b.isAdminMessage(&Message{Chat: Chat{ID: -100}, From: &User{ID: 5}}) {
+		t.Error("обычный участник не администратор")
+	}
+
+	b.handleHamsterCommand(&Message{Chat: Chat{ID: -100}, From: anonymous, SenderChat: &Chat{ID: -100}, Text: "/hamster off"})
+	if b.chatEnabled(-100) {
+		t.Error("анонимный администратор должен управлять ботом")
+	}
+}
+
+// -------------------------
+// Отмена запросов к Telegram
+// -------------------------
+
+// hangingBot — бот поверх HTTP-клиента, у которого методы из hang висят до
+// отмены запроса; о начале и отмене такого запроса сообщают hung и aborted.
+func hangingBot(t *testing.T, hang ...string) (b *Bot, hung, aborted chan string) {
+	t.Helper()
+	hung, aborted = make(chan string, 10), make(chan string, 10)
+	client := &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		method := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+		for _, m := range hang {
+			if m == method {
+				hung <- method
+				<-req.Context().Done()
+				aborted <- method
+				return nil, req.Context().Err()
+			}
+		}
+		return jsonResponse(200, `{"ok":true,"result":{"message_id":7}}`), nil
+	}}
+	b, err := NewBot("T", Config{SettingsFile: filepath.Join(t.TempDir(), "settings.json")}, WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, hung, aborted
+}
+
+func TestCloseAbortsHangingRequests(t *testing.T) {
+	b, hung, aborted := hangingBot(t, "banChatMember")
+	go b.safeBanUser(b.ctx, -100, 42)
+
+	<-hung
+	closed := make(chan struct{})
+	go func() { b.Close(); close(closed) }()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close ждёт зависший запрос")
+	}
+	if got := <-aborted; got != "banChatMember" {
+		t.Errorf("прерван %s, ожидали banChatMember", got)
+	}
+}
+
+func TestFinishedVerificationAbortsItsRequests(t *testing.T) {
+	b, hung, aborted := hangingBot(t, "editMessageText")
+	defer b.Close()
+	go b.startProgressbarWithTimeout(-100, 10, 42, "TOK", 30)
+
+	<-hung // первая правка прогрессбара висит
+	b.stopProgressbar(-100, b.progressStore.get(-100, 42))
+	select {
+	case got := <-aborted:
+		if got != "editMessageText" {
+			t.Errorf("прерван %s, ожидали editMessageText", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("завершение проверки не прервало её запросы")
+	}
+	if err := b.ctx.Err(); err != nil {
+		t.Errorf("завершение одной проверки не должно отменять контекст бота: %v", err)
+	}
+}
+
+func TestSweepExpiredVerifications(t *testing.T) {
+	b := setupBot()
+	var banned []int64
+	fakeOf(b).ban = func(chatID, userID int64) { banned = append(banned, userID) }
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = append(deleted, msgID) }
+	stale := &progressData{stopChan: make(chan struct{}), chatID: 1, userID: 42, greetMsgID: 10, msgProgressID: 11,
+		startedAt: time.Now().Add(-2 * time.Minute), timeout: 60}
+	b.progressStore.add(stale)
+	b.progressStore.add(&progressData{stopChan: make(chan struct{}), chatID: 1, userID: 43, greetMsgID: 20, msgProgressID: 21,
+		startedAt: time.Now().Add(-70 * time.Second), timeout: 60})
+
+	b.SweepExpiredVerifications()
+	if len(banned) != 1 || banned[0] != 42 {
+		t.Errorf("забанен должен быть только участник с давно истёкшей проверкой: %v", banned)
+	}
+	if len(deleted) != 2 || deleted[0] != 10 || deleted[1] != 11 {
+		t.Errorf("удаляются приветствие и прогрессбар зависшей проверки: %v", deleted)
+	}
+	if b.progressStore.get(1, 42) != nil {
+		t.Error("зависшая проверка должна быть снята")
+	}
+	if b.progressStore.get(1, 43) == nil {
+		t.Error("проверку в пределах запаса завершает её собственный таймер")
+	}
+
+	// повторный вызов и запоздавший таймер не банят второй раз
+	b.SweepExpiredVerifications()
+	b.failVerification(1, stale, BanReasonTimeout)
+	if len(banned) != 1 {
+		t.Errorf("проверка завершается один раз: %v", banned)
+	}
+}
+
+func TestPassAfterFailIgnored(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	var welcomed bool
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { welcomed = true; return 1 }
+	p := &progressData{stopChan: make(chan struct{}), chatID: 1, userID: 42, greetMsgID: 10}
+	b.progressStore.add(p)
+
+	b.failVerification(1, p, BanReasonTimeout)
+	if b.passChallenge(1, &User{ID: 42}, p) {
+		t.Error("проверку, завершённую таймаутом, нельзя пройти")
+	}
+	if welcomed || b.stats.Get(1).Passed != 0 {
+		t.Errorf("забаненный не должен приветствоваться и считаться прошедшим: %+v", b.stats.Get(1))
+	}
+}
+
+func TestHandleCallbackConcurrentSequencePresses(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	p := &progressData{
+		stopChan:   make(chan struct{}),
+		token:      "TOKEN",
+		chatID:     1,
+		userID:     42,
+		greetMsgID: 100,
+		challenge:  sequenceChallenge{},
+		session:    &ChallengeSession{ChatID: 1, User: &User{ID: 42}, Answer: "3,0,7"},
+	}
+	b.progressStore.add(p)
+	var mu sync.Mutex
+	var answers []string
+	fakeOf(b).answerCallback = func(id, text string, alert bool) { mu.Lock(); answers = append(answers, text); mu.Unlock() }
+
+	// два одновременных нажатия первого эмодзи засчитываются как одно
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.handleCallback(&Callback{ID: "cb", Message: &Message{MessageID: 100, Chat: Chat{ID: 1}}, From: &User{ID: 42}, Data: "click:42:TOKEN:3"})
+		}()
+	}
+	wg.Wait()
+	b.handleCallback(&Callback{ID: "cb", Message: &Message{MessageID: 100, Chat: Chat{ID: 1}}, From: &User{ID: 42}, Data: "click:42:TOKEN:7"})
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, a := range answers {
+		if strings.Contains(a, "Добро пожаловать") {
+			t.Fatalf("проверка пройдена без второго эмодзи: %q", answers)
+		}
+	}
+	if p.failReason != BanReasonWrongAnswer {
+		t.Errorf("повторное нажатие первого эмодзи — неверный ответ, причина %q", p.failReason)
+	}
+}
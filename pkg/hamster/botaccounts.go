@@ -0,0 +1,23 @@
+package hamster
+
+// ==========================
+// Боты, добавленные в чат
+// ==========================
+
+// handleBotJoin решает судьбу добавленного бота: капчу он пройти не может,
+// поэтому бот остаётся только если его добавил администратор, иначе исключается.
+func (b *Bot) handleBotJoin(msg *Message, botUser *User) {
+	chatID := msg.Chat.ID
+	adder := msg.From
+	if adder != nil && adder.ID != botUser.ID && b.isAdmin(b.ctx, chatID, adder.ID) {
+		b.logger.Info("Бот %d добавлен администратором %d в чат %d — пропускаем", botUser.ID, adder.ID, chatID)
+		return
+	}
+
+	adderID := int64(0)
+	if adder != nil {
+		adderID = adder.ID
+	}
+	b.logger.Info("Бот %d добавлен не администратором (%d) в чат %d — исключаем", botUser.ID, adderID, chatID)
+	b.safeKickUser(b.ctx, chatID, botUser.ID)
+}
@@ -0,0 +1,52 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHandleJoinMessageKicksBotFromNonAdmin(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"1:10": {status: "member", expiresAt: time.Now().Add(time.Minute)},
+	}
+	var banned, unbanned []int64
+	fakeOf(b).ban = func(chatID, userID int64) { banned = append(banned, userID) }
+	fakeOf(b).unban = func(chatID, userID int64) { unbanned = append(unbanned, userID) }
+	greeted := false
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { greeted = true; return 1 }
+
+	b.handleJoinMessage(&Message{
+		Chat:           Chat{ID: 1},
+		From:           &User{ID: 10},
+		NewChatMembers: []*User{{ID: 99, IsBot: true, Username: "spam_bot"}},
+	})
+
+	if len(banned) != 1 || banned[0] != 99 || len(unbanned) != 1 {
+		t.Errorf("бот должен быть исключён: ban=%v unban=%v", banned, unbanned)
+	}
+	if greeted {
+		t.Error("боту не должна показываться капча")
+	}
+}
+
+func TestHandleJoinMessageKeepsBotFromAdmin(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"1:10": {status: "creator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	kicked := false
+	fakeOf(b).ban = func(chatID, userID int64) { kicked = true }
+	greeted := false
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { greeted = true; return 1 }
+
+	b.handleJoinMessage(&Message{
+		Chat:           Chat{ID: 1},
+		From:           &User{ID: 10},
+		NewChatMembers: []*User{{ID: 99, IsBot: true}},
+	})
+
+	if kicked || greeted {
+		t.Errorf("бот от администратора должен остаться без капчи: kicked=%v greeted=%v", kicked, greeted)
+	}
+}
@@ -0,0 +1,90 @@
+package hamster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Предохранитель запросов к Telegram
+// ==========================
+
+const (
+	// breakerThreshold — после скольких сбоев подряд перестать обращаться к API.
+	breakerThreshold = 5
+	// breakerCooldown — первая пауза после срабатывания; каждый неудачный пробный
+	// запрос удваивает её до breakerMaxCooldown.
+	breakerCooldown    = 5 * time.Second
+	breakerMaxCooldown = time.Minute
+)
+
+// ErrAPIUnavailable возвращается без обращения к сети, пока предохранитель
+// разомкнут: Telegram недоступен, и повторы только умножили бы нагрузку.
+var ErrAPIUnavailable = errors.New("Telegram API недоступен, запросы приостановлены")
+
+// circuitBreaker размыкается после breakerThreshold сбоев подряд. Пока он
+// разомкнут, все запросы (правки прогрессбаров, удаления, баны) сразу получают
+// ErrAPIUnavailable; по истечении паузы пропускается один пробный запрос.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	cooldown  time.Duration
+	openUntil time.Time
+	probing   bool // пробный запрос в процессе
+	now       func() time.Time
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{cooldown: breakerCooldown, now: time.Now}
+}
+
+// allow сообщает, можно ли выполнить запрос.
+func (c *circuitBreaker) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures < breakerThreshold {
+		return true
+	}
+	if c.probing || c.now().Before(c.openUntil) {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+// record учитывает результат запроса.
+func (c *circuitBreaker) record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	wasProbe := c.probing
+	c.probing = false
+	if !isOutage(err) {
+		c.failures = 0
+		c.cooldown = breakerCooldown
+		return
+	}
+	c.failures++
+	if c.failures < breakerThreshold {
+		return
+	}
+	if wasProbe {
+		c.cooldown = min(2*c.cooldown, breakerMaxCooldown)
+	}
+	c.openUntil = c.now().Add(c.cooldown)
+}
+
+// isOutage — ошибка говорит о недоступности Telegram, а не о конкретном
+// запросе: сеть или 5xx. Ответы вроде «message to delete not found»
+// означают, что API работает.
+func isOutage(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= 500
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package hamster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAndProbes(t *testing.T) {
+	now := time.Now()
+	c := newCircuitBreaker()
+	c.now = func() time.Time { return now }
+	outage := errors.New("connection refused")
+
+	for i := 0; i < breakerThreshold; i++ {
+		if !c.allow() {
+			t.Fatalf("запрос %d: до порога сбоев запросы идут", i)
+		}
+		c.record(outage)
+	}
+	if c.allow() {
+		t.Fatal("после порога сбоев запросы приостанавливаются")
+	}
+
+	now = now.Add(breakerCooldown)
+	if !c.allow() {
+		t.Fatal("после паузы пропускается пробный запрос")
+	}
+	if c.allow() {
+		t.Error("пока идёт пробный запрос, остальные ждут")
+	}
+	c.record(outage)
+	now = now.Add(breakerCooldown)
+	if c.allow() {
+		t.Error("неудачная проба удваивает паузу")
+	}
+
+	now = now.Add(breakerCooldown)
+	if !c.allow() {
+		t.Fatal("после удвоенной паузы — новая проба")
+	}
+	c.record(nil)
+	if !c.allow() || !c.allow() {
+		t.Error("удачная проба замыкает предохранитель")
+	}
+}
+
+func TestCircuitBreakerIgnoresRequestErrors(t *testing.T) {
+	c := newCircuitBreaker()
+	for i := 0; i < 2*breakerThreshold; i++ {
+		c.record(&APIError{Method: "deleteMessage", Code: 400, Description: "message to delete not found"})
+	}
+	if !c.allow() {
+		t.Error("ошибки отдельных запросов не означают сбоя Telegram")
+	}
+}
+
+func TestTelegramAPIBacksOffDuringOutage(t *testing.T) {
+	calls := 0
+	api := NewTelegramAPI("T", &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		calls++
+		return jsonResponse(502, `{"ok":false,"error_code":502,"description":"Bad Gateway"}`), nil
+	}})
+	for i := 0; i < 20; i++ {
+		api.DeleteMessage(context.Background(), 1, int64(i))
+	}
+	if calls != breakerThreshold {
+		t.Errorf("во время сбоя запросов к API: %d, ожидали %d", calls, breakerThreshold)
+	}
+	if err := api.Ban(context.Background(), 1, 2); !errors.Is(err, ErrAPIUnavailable) {
+		t.Errorf("ожидали ErrAPIUnavailable, получили %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package hamster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Рассылка объявлений /broadcast
+// ==========================
+
+const (
+	// broadcastQueueSize — сколько рассылок может ждать своей очереди.
+	broadcastQueueSize = 8
+	// broadcastInterval — пауза между сообщениями рассылки (лимит Telegram — около 30 в секунду).
+	broadcastInterval = 100 * time.Millisecond
+)
+
+// broadcastJob — рассылка, поставленная владельцем в очередь.
+type broadcastJob struct {
+	text       string
+	adminsOnly bool  // слать в личку администраторам чатов, а не в сами чаты
+	replyTo    int64 // личка владельца для отчёта
+}
+
+// handleBroadcastCommand: в личке владельца ставит рассылку в очередь,
+// в группе позволяет администраторам отказаться от рассылок.
+func (b *Bot) handleBroadcastCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	if msg.Chat.Type != "private" {
+		b.handleBroadcastOptOut(msg)
+		return
+	}
+	if !b.isOwner(msg.From.ID) {
+		return
+	}
+	chatID := msg.Chat.ID
+
+	job := broadcastJob{text: commandArg(msg.Text, 1), replyTo: chatID}
+	if fields := strings.Fields(msg.Text); len(fields) > 1 && strings.EqualFold(fields[1], "admins") {
+		job.adminsOnly = true
+		job.text = commandArg(msg.Text, 2)
+	}
+	if job.text == "" {
+		b.safeSendSilent(b.ctx, chatID, "Использование: /broadcast <текст> — во все группы\n/broadcast admins <текст> — в личку администраторам групп")
+		return
+	}
+	job.text = "🛠 " + job.text
+
+	select {
+	case b.broadcasts <- job:
+		b.logger.Info("Владелец %d поставил рассылку в очередь (админам: %v)", msg.From.ID, job.adminsOnly)
+		b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("📣 Рассылка в очереди (перед ней: %d)", len(b.broadcasts)-1))
+	default:
+		b.safeSendSilent(b.ctx, chatID, "❌ Очередь рассылок заполнена, попробуйте позже")
+	}
+}
+
+func (b *Bot) handleBroadcastOptOut(msg *Message) {
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может управлять объявлениями", 5*time.Second)
+		return
+	}
+	switch strings.ToLower(commandArg(msg.Text, 1)) {
+	case "on":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.NoBroadcast = false })
+		b.sendTemporary(chatID, "📣 Объявления от разработчиков бота включены", 5*time.Second)
+	case "off":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.NoBroadcast = true })
+		b.sendTemporary(chatID, "🔕 Объявления от разработчиков бота отключены. Включить: /broadcast on", 10*time.Second)
+	default:
+		status := "📣 включены"
+		if b.chatSettings(chatID).NoBroadcast {
+			status = "🔕 отключены"
+		}
+		b.sendTemporary(chatID, "Объявления от разработчиков бота "+status+"\n⚙️ /broadcast on|off", 10*time.Second)
+	}
+}
+
+// RunBroadcasts по одной выполняет рассылки из очереди до отмены ctx.
+func (b *Bot) RunBroadcasts(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-b.broadcasts:
+			sent, total := b.runBroadcast(ctx, job, broadcastInterval)
+			b.logger.Info("Рассылка завершена: %d из %d", sent, total)
+			b.safeSendSilent(b.ctx, job.replyTo, fmt.Sprintf("📣 Рассылка завершена: доставлено %d из %d", sent, total))
+		}
+	}
+}
+
+// broadcastChats возвращает группы, не отказавшиеся от рассылок.
+func (b *Bot) broadcastChats() []int64 {
+	var ids []int64
+	for _, c := range b.knownChats() {
+		if c.ChatID > 0 || c.Settings.NoBroadcast { // личные чаты не обслуживаются
+			continue
+		}
+		ids = append(ids, c.ChatID)
+	}
+	return ids
+}
+
+// broadcastRecipients — куда слать: сами группы или, для adminsOnly, их
+// администраторы-люди без повторов.
+func (b *Bot) broadcastRecipients(job broadcastJob) []int64 {
+	chats := b.broadcastChats()
+	if !job.adminsOnly {
+		return chats
+	}
+	seen := make(map[int64]bool)
+	var users []int64
+	for _, chatID := range chats {
+		admins, err := b.safeGetChatAdministrators(b.ctx, chatID)
+		if err != nil {
+			b.logger.Warn("Рассылка: не удалось получить админов чата %d: %v", chatID, err)
+			continue
+		}
+		for _, m := range admins {
+			if m.User == nil || m.User.IsBot || seen[m.User.ID] {
+				continue
+			}
+			seen[m.User.ID] = true
+			users = append(users, m.User.ID)
+		}
+	}
+	return users
+}
+
+// runBroadcast отправляет объявление с паузой interval между сообщениями и
+// возвращает число доставленных. Администраторам, не писавшим боту, Telegram
+// доставить сообщение не даст — они попадут в разницу между sent и total.
+func (b *Bot) runBroadcast(ctx context.Context, job broadcastJob, interval time.Duration) (sent, total int) {
+	for i, to := range b.broadcastRecipients(job) {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return sent, total
+			case <-time.After(interval):
+			}
+		}
+		total++
+		var opts SendOptions
+		if to < 0 { // группа; в личке администраторам — как обычно
+			opts = b.chatSettings(to).sendOptions(MsgAnnounce)
+		}
+		if msgID := b.safeSend(ctx, to, job.text, nil, opts); msgID != 0 {
+			b.keepSent(to, msgID) // объявление остаётся в чате
+			sent++
+		}
+	}
+	return sent, total
+}
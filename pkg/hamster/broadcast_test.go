@@ -0,0 +1,113 @@
+package hamster
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func setupBroadcastBot() *Bot {
+	b := setupBot()
+	b.stats = NewStats()
+	b.cfg.Owners = []int64{10}
+	b.broadcasts = make(chan broadcastJob, 1)
+	b.adminCache = make(map[string]adminCacheEntry)
+	return b
+}
+
+func TestBroadcastQueue(t *testing.T) {
+	b := setupBroadcastBot()
+	var reply string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { reply = text; return 1 }
+
+	b.handleBroadcastCommand(ownerMessage("/broadcast"))
+	if !strings.Contains(reply, "Использование") {
+		t.Errorf("пустой текст: %q", reply)
+	}
+
+	b.handleBroadcastCommand(ownerMessage("/broadcast admins Обновление в 22:00"))
+	job := <-b.broadcasts
+	if !job.adminsOnly || job.text != "🛠 Обновление в 22:00" || job.replyTo != 10 {
+		t.Errorf("неверное задание: %+v", job)
+	}
+
+	b.handleBroadcastCommand(ownerMessage("/broadcast раз"))
+	b.handleBroadcastCommand(ownerMessage("/broadcast два"))
+	if !strings.Contains(reply, "заполнена") {
+		t.Errorf("переполнение очереди: %q", reply)
+	}
+
+	msg := ownerMessage("/broadcast чужое")
+	msg.From.ID = 11
+	<-b.broadcasts
+	b.handleBroadcastCommand(msg)
+	if len(b.broadcasts) != 0 {
+		t.Error("рассылку может ставить только владелец")
+	}
+}
+
+func TestBroadcastSkipsPrivateAndOptedOutChats(t *testing.T) {
+	b := setupBroadcastBot()
+	b.settings.SetTimeout(-1, 30)
+	b.settings.SetTimeout(-2, 30)
+	b.settings.Update(-3, func(c *ChatSettings) { c.NoBroadcast = true })
+	b.stats.add(5, func(c *ChatStats) { c.Joins++ })
+	var got []int64
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		got = append(got, chatID)
+		if chatID == -2 {
+			return 0
+		}
+		return 1
+	}
+
+	sent, total := b.runBroadcast(context.Background(), broadcastJob{text: "🛠 работы"}, 0)
+	if sent != 1 || total != 2 || len(got) != 2 {
+		t.Errorf("ожидали 1 из 2 без личных и отказавшихся чатов, получили %d из %d, чаты %v", sent, total, got)
+	}
+}
+
+func TestBroadcastToAdmins(t *testing.T) {
+	b := setupBroadcastBot()
+	b.settings.SetTimeout(-1, 30)
+	b.settings.SetTimeout(-2, 30)
+	fakeOf(b).getChatAdministrators = func(chatID int64) ([]ChatMember, error) {
+		return []ChatMember{
+			{Status: "creator", User: &User{ID: 100}},
+			{Status: "administrator", User: &User{ID: 200, IsBot: true}},
+			{Status: "administrator", User: &User{ID: int64(-chatID)}},
+		}, nil
+	}
+	var got []int64
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { got = append(got, chatID); return 1 }
+
+	sent, total := b.runBroadcast(context.Background(), broadcastJob{text: "x", adminsOnly: true}, 0)
+	if sent != 3 || total != 3 {
+		t.Errorf("ожидали 3 админа без повторов и ботов, получили %d из %d: %v", sent, total, got)
+	}
+	for _, id := range got {
+		if id < 0 || id == 200 {
+			t.Errorf("сообщение ушло не в личку админу: %d", id)
+		}
+	}
+}
+
+func TestBroadcastOptOut(t *testing.T) {
+	b := setupBroadcastBot()
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "administrator"}, nil
+	}
+	msg := &Message{From: &User{ID: 1}, Chat: Chat{ID: -100, Type: "supergroup"}, Text: "/broadcast off"}
+	b.handleBroadcastCommand(msg)
+	if !b.settings.Get(-100).NoBroadcast {
+		t.Fatal("/broadcast off должен отключать рассылки в чате")
+	}
+	msg.Text = "/broadcast on"
+	b.handleBroadcastCommand(msg)
+	if b.settings.Get(-100).NoBroadcast {
+		t.Error("/broadcast on должен включать рассылки")
+	}
+	if len(b.broadcasts) != 0 {
+		t.Error("команда в группе не должна ставить рассылку")
+	}
+}
@@ -0,0 +1,69 @@
+package hamster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Частые нажатия на чужие кнопки и счётчики нажатий
+// ==========================
+
+// callbackBurstWindow — за какое время считаются частые нажатия на чужие кнопки
+// (CallbackBurstLimit).
+const callbackBurstWindow = time.Minute
+
+// Исходы нажатий на кнопки для /metrics.
+const (
+	callbackOwn     = "own"      // своя кнопка проверки
+	callbackForeign = "foreign"  // кнопка чужой проверки
+	callbackExpired = "expired"  // проверка уже завершена
+	callbackTooFast = "too_fast" // своя кнопка, но слишком быстро
+	callbackOther   = "other"    // правила, жалобы и неизвестные данные
+)
+
+// Наказания за частые нажатия на чужие кнопки.
+const (
+	callbackWarned = "warn"
+	callbackMuted  = "mute"
+)
+
+// callbackCounters — число нажатий по исходам и наказаний с запуска.
+type callbackCounters struct {
+	mu sync.Mutex
+	m  map[string]int64
+}
+
+func (c *callbackCounters) inc(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.m == nil {
+		c.m = make(map[string]int64)
+	}
+	c.m[key]++
+}
+
+func (c *callbackCounters) get(key string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.m[key]
+}
+
+// muteForeignPresser даёт мут на ForeignPressMute за нажатие чужих кнопок.
+// Администраторов не трогает.
+func (b *Bot) muteForeignPresser(cb *Callback) {
+	chatID := cb.Message.Chat.ID
+	if b.isAdmin(b.ctx, chatID, cb.From.ID) {
+		b.safeAnswerCallback(b.ctx, cb.ID, "🚫 Эта кнопка не для вас", true)
+		return
+	}
+
+	b.callbackCounts.inc(callbackMuted)
+	mute := b.cfg.ForeignPressMute
+	b.safeAnswerCallback(b.ctx, cb.ID, fmt.Sprintf("🔇 Мут на %d мин. за нажатие чужих кнопок", int(mute.Minutes())), true)
+	b.safeRestrictUser(b.ctx, chatID, cb.From.ID, ChatPermissions{}, time.Now().Add(mute))
+	b.logger.Info("Мут %d в чате %d на %v за нажатие чужих кнопок", cb.From.ID, chatID, mute)
+	b.sendTemporary(chatID, fmt.Sprintf("🔇 %s получает мут на %d мин. за нажатие чужих кнопок проверки",
+		displayName(cb.From), int(mute.Minutes())), 30*time.Second)
+}
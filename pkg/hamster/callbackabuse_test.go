@@ -0,0 +1,70 @@
+package hamster
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallbackBurstWarnsThenMutes(t *testing.T) {
+	b := setupBot()
+	b.adminCache = make(map[string]adminCacheEntry)
+	b.foreignPresses = newPressCounter()
+	b.cfg.CallbackBurstLimit = 4
+	b.cfg.ForeignPressMute = 5 * time.Minute
+	var alerts []string
+	fakeOf(b).answerCallback = func(id, text string, alert bool) { alerts = append(alerts, text) }
+	muted := false
+	fakeOf(b).restrict = func(chatID, userID int64, p ChatPermissions, until time.Time) {
+		muted = !p.CanSendMessages && time.Until(until) > 4*time.Minute
+	}
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "member"}, nil
+	}
+
+	for i := 0; i < 4; i++ {
+		b.handleCallback(foreignCallback())
+	}
+	if len(alerts) != 4 || !strings.Contains(alerts[1], "Хватит нажимать") || !strings.Contains(alerts[3], "Мут на 5 мин") {
+		t.Errorf("ответы: %q", alerts)
+	}
+	if !muted {
+		t.Error("после лимита за минуту — мут")
+	}
+	if b.callbackCounts.get(callbackForeign) != 4 || b.callbackCounts.get(callbackWarned) != 1 || b.callbackCounts.get(callbackMuted) != 1 {
+		t.Errorf("счётчики: %v", b.callbackCounts.m)
+	}
+}
+
+func TestCallbackBurstSparesAdmins(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-1:7": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	b.foreignPresses = newPressCounter()
+	b.cfg.CallbackBurstLimit = 2
+	restricted := false
+	fakeOf(b).restrict = func(chatID, userID int64, p ChatPermissions, until time.Time) { restricted = true }
+	for i := 0; i < 4; i++ {
+		b.handleCallback(foreignCallback())
+	}
+	if restricted || b.callbackCounts.get(callbackMuted) != 0 {
+		t.Error("администраторов не мутим")
+	}
+}
+
+func TestCallbackMetricsExported(t *testing.T) {
+	b := setupAdminBot()
+	b.callbackCounts.inc(callbackExpired)
+	b.callbackCounts.inc(callbackMuted)
+	rec := httptest.NewRecorder()
+	b.serveMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, want := range []string{
+		`tg_hamster_callbacks_total{result="expired"} 1`,
+		`tg_hamster_callbacks_total{result="own"} 0`,
+		`tg_hamster_foreign_press_actions_total{action="mute"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("нет %q в /metrics", want)
+		}
+	}
+}
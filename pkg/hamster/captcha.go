@@ -0,0 +1,318 @@
+package hamster
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ==========================
+// Встроенные типы проверки
+// ==========================
+
+const (
+	// CaptchaMath — пример на сложение, ответ кнопкой или сообщением.
+	CaptchaMath = "math"
+	// CaptchaEmoji — выбрать названный эмодзи из сетки.
+	CaptchaEmoji = "emoji"
+	// CaptchaHoneypot — кнопка-ловушка «Я бот» первой в ряду против автокликеров.
+	CaptchaHoneypot = "honeypot"
+	// CaptchaText — первым сообщением написать показанный код.
+	CaptchaText = "text"
+	// CaptchaSequence — нажать три эмодзи в показанном порядке.
+	CaptchaSequence = "sequence"
+)
+
+func init() {
+	RegisterChallenge(CaptchaButton, buttonChallenge{})
+	RegisterChallenge(CaptchaMath, mathChallenge{})
+	RegisterChallenge(CaptchaEmoji, emojiChallenge{})
+	RegisterChallenge(CaptchaHoneypot, honeypotChallenge{})
+	RegisterChallenge(CaptchaText, textChallenge{})
+	RegisterChallenge(CaptchaSequence, sequenceChallenge{})
+}
+
+// randIntn возвращает случайное число в [0, n).
+func randIntn(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(v.Int64())
+}
+
+// shuffle перемешивает срез на месте.
+func shuffle[T any](s []T) {
+	for i := len(s) - 1; i > 0; i-- {
+		j := randIntn(i + 1)
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// timeoutAction — действие по таймауту из настроек чата.
+func timeoutAction(s *ChallengeSession) string {
+	return s.Settings.FailAction()
+}
+
+// ==========================
+// button: одна кнопка
+// ==========================
+
+type buttonChallenge struct{}
+
+func (buttonChallenge) Render(s *ChallengeSession) ChallengePrompt {
+	return ChallengePrompt{Buttons: [][]ChallengeButton{{{Text: phrasePackFor(s.Settings).pick() + " 👉"}}}}
+}
+
+func (buttonChallenge) HandleCallback(s *ChallengeSession, data string) ChallengeResult {
+	return ChallengePassed
+}
+
+func (buttonChallenge) HandleMessage(s *ChallengeSession, msg *Message) ChallengeResult {
+	return ChallengePending
+}
+
+func (buttonChallenge) OnTimeout(s *ChallengeSession) string { return timeoutAction(s) }
+
+// ==========================
+// math: пример на сложение
+// ==========================
+
+// mathOptions — сколько вариантов ответа показывать.
+const mathOptions = 4
+
+type mathChallenge struct{}
+
+func (mathChallenge) Render(s *ChallengeSession) ChallengePrompt {
+	a, b := 1+randIntn(9), 1+randIntn(9)
+	answer := a + b
+	s.Answer = strconv.Itoa(answer)
+
+	options := []int{answer}
+	for len(options) < mathOptions {
+		v := 2 + randIntn(17) // возможные суммы: 2..18
+		dup := false
+		for _, o := range options {
+			dup = dup || o == v
+		}
+		if !dup {
+			options = append(options, v)
+		}
+	}
+	shuffle(options)
+
+	row := make([]ChallengeButton, 0, len(options))
+	for _, o := range options {
+		row = append(row, ChallengeButton{Text: strconv.Itoa(o), Data: strconv.Itoa(o)})
+	}
+	return ChallengePrompt{
+		Text:    fmt.Sprintf("🧮 Сколько будет %d + %d? Нажмите ответ или напишите его в чат", a, b),
+		Buttons: [][]ChallengeButton{row},
+	}
+}
+
+func (mathChallenge) HandleCallback(s *ChallengeSession, data string) ChallengeResult {
+	if data == s.Answer {
+		return ChallengePassed
+	}
+	return ChallengeFailed
+}
+
+func (mathChallenge) HandleMessage(s *ChallengeSession, msg *Message) ChallengeResult {
+	text := strings.TrimSpace(msg.Text)
+	if _, err := strconv.Atoi(text); err != nil {
+		return ChallengePending // не число — не ответ
+	}
+	if text == s.Answer {
+		return ChallengePassed
+	}
+	return ChallengeFailed
+}
+
+func (mathChallenge) OnTimeout(s *ChallengeSession) string { return timeoutAction(s) }
+
+// ==========================
+// emoji: выбрать эмодзи из сетки
+// ==========================
+
+// emojiNames — эмодзи и их названия в вопросе.
+var emojiNames = []struct{ emoji, name string }{
+	{"🐹", "хомяка"}, {"🐱", "кошку"}, {"🐶", "собаку"}, {"🦊", "лису"},
+	{"🐻", "медведя"}, {"🐼", "панду"}, {"🐸", "лягушку"}, {"🐰", "кролика"},
+	{"🦉", "сову"}, {"🐢", "черепаху"},
+}
+
+const (
+	emojiGridRows = 2
+	emojiGridCols = 3
+)
+
+// emojiGrid выбирает случайные эмодзи для сетки и раскладывает их по рядам;
+// Data кнопки — индекс в emojiNames.
+func emojiGrid() (idx []int, rows [][]ChallengeButton) {
+	idx = make([]int, len(emojiNames))
+	for i := range idx {
+		idx[i] = i
+	}
+	shuffle(idx)
+	idx = idx[:emojiGridRows*emojiGridCols]
+
+	rows = make([][]ChallengeButton, 0, emojiGridRows)
+	for r := 0; r < emojiGridRows; r++ {
+		row := make([]ChallengeButton, 0, emojiGridCols)
+		for _, i := range idx[r*emojiGridCols : (r+1)*emojiGridCols] {
+			row = append(row, ChallengeButton{Text: emojiNames[i].emoji, Data: strconv.Itoa(i)})
+		}
+		rows = append(rows, row)
+	}
+	return idx, rows
+}
+
+type emojiChallenge struct{}
+
+func (emojiChallenge) Render(s *ChallengeSession) ChallengePrompt {
+	idx, rows := emojiGrid()
+	target := idx[randIntn(len(idx))]
+	s.Answer = strconv.Itoa(target)
+	return ChallengePrompt{
+		Text:    fmt.Sprintf("🔎 Найдите %s и нажмите на эту кнопку", emojiNames[target].name),
+		Buttons: rows,
+	}
+}
+
+func (emojiChallenge) HandleCallback(s *ChallengeSession, data string) ChallengeResult {
+	if data == s.Answer {
+		return ChallengePassed
+	}
+	return ChallengeFailed
+}
+
+func (emojiChallenge) HandleMessage(s *ChallengeSession, msg *Message) ChallengeResult {
+	return ChallengePending
+}
+
+func (emojiChallenge) OnTimeout(s *ChallengeSession) string { return timeoutAction(s) }
+
+// ==========================
+// sequence: эмодзи по порядку
+// ==========================
+
+// sequenceLen — сколько эмодзи нужно нажать.
+const sequenceLen = 3
+
+// sequenceChallenge хранит в Answer индексы эмодзи через запятую,
+// а в Step — сколько из них уже нажато.
+type sequenceChallenge struct{}
+
+func (sequenceChallenge) Render(s *ChallengeSession) ChallengePrompt {
+	idx, rows := emojiGrid()
+	order := append([]int(nil), idx...)
+	shuffle(order)
+	order = order[:sequenceLen]
+
+	answer := make([]string, len(order))
+	shown := make([]string, len(order))
+	for i, e := range order {
+		answer[i] = strconv.Itoa(e)
+		shown[i] = emojiNames[e].emoji
+	}
+	s.Answer = strings.Join(answer, ",")
+	s.Step = 0
+	return ChallengePrompt{
+		Text:    "🔢 Нажмите по порядку: " + strings.Join(shown, " → "),
+		Buttons: rows,
+	}
+}
+
+func (sequenceChallenge) HandleCallback(s *ChallengeSession, data string) ChallengeResult {
+	want := strings.Split(s.Answer, ",")
+	if s.Step >= len(want) || data != want[s.Step] {
+		return ChallengeFailed
+	}
+	s.Step++
+	if s.Step == len(want) {
+		return ChallengePassed
+	}
+	return ChallengePending
+}
+
+func (sequenceChallenge) HandleMessage(s *ChallengeSession, msg *Message) ChallengeResult {
+	return ChallengePending
+}
+
+func (sequenceChallenge) OnTimeout(s *ChallengeSession) string { return timeoutAction(s) }
+
+// ==========================
+// honeypot: кнопка-ловушка
+// ==========================
+
+// Автокликеры обычно жмут первую кнопку под сообщением, поэтому ловушка
+// всегда стоит первой, а настоящая кнопка — на случайном месте после неё.
+const (
+	honeypotTrap  = "bot"
+	honeypotHuman = "human"
+)
+
+// honeypotDecoys — прочие неверные кнопки.
+var honeypotDecoys = []string{"📢 Реклама", "💰 Заработок"}
+
+type honeypotChallenge struct{}
+
+func (honeypotChallenge) Render(s *ChallengeSession) ChallengePrompt {
+	s.Answer = honeypotHuman
+	rest := []ChallengeButton{{Text: "🙋 Я человек", Data: honeypotHuman}}
+	for i, d := range honeypotDecoys {
+		rest = append(rest, ChallengeButton{Text: d, Data: "decoy" + strconv.Itoa(i)})
+	}
+	shuffle(rest)
+	row := append([]ChallengeButton{{Text: "🤖 Я бот", Data: honeypotTrap}}, rest...)
+	return ChallengePrompt{Text: "Нажмите «Я человек»", Buttons: [][]ChallengeButton{row}}
+}
+
+func (honeypotChallenge) HandleCallback(s *ChallengeSession, data string) ChallengeResult {
+	if data == s.Answer {
+		return ChallengePassed
+	}
+	return ChallengeFailed
+}
+
+func (honeypotChallenge) HandleMessage(s *ChallengeSession, msg *Message) ChallengeResult {
+	return ChallengePending
+}
+
+func (honeypotChallenge) OnTimeout(s *ChallengeSession) string { return timeoutAction(s) }
+
+// ==========================
+// text: написать код сообщением
+// ==========================
+
+// textCodeLen — длина кода; цифры одинаково набираются на любой раскладке.
+const textCodeLen = 4
+
+type textChallenge struct{}
+
+func (textChallenge) Render(s *ChallengeSession) ChallengePrompt {
+	code := make([]byte, textCodeLen)
+	for i := range code {
+		code[i] = byte('0' + randIntn(10))
+	}
+	s.Answer = string(code)
+	return ChallengePrompt{Text: fmt.Sprintf("✍️ Напишите в чат код %s первым сообщением", s.Answer)}
+}
+
+func (textChallenge) HandleCallback(s *ChallengeSession, data string) ChallengeResult {
+	return ChallengePending
+}
+
+// HandleMessage засчитывает только первое сообщение: любое другое, включая
+// стикеры и медиа без подписи, проваливает проверку.
+func (textChallenge) HandleMessage(s *ChallengeSession, msg *Message) ChallengeResult {
+	if strings.Join(strings.Fields(msg.Text), "") == s.Answer {
+		return ChallengePassed
+	}
+	return ChallengeFailed
+}
+
+func (textChallenge) OnTimeout(s *ChallengeSession) string { return timeoutAction(s) }
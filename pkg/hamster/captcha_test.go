@@ -0,0 +1,191 @@
+package hamster
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestButtonChallenge(t *testing.T) {
+	var c buttonChallenge
+	s := &ChallengeSession{}
+	p := c.Render(s)
+	if len(p.Buttons) != 1 || len(p.Buttons[0]) != 1 || p.Buttons[0][0].Data != "" {
+		t.Fatalf("ожидали одну кнопку без данных: %+v", p)
+	}
+	if c.HandleCallback(s, "") != ChallengePassed {
+		t.Error("нажатие кнопки должно засчитываться")
+	}
+	if c.HandleMessage(s, &Message{Text: "ok"}) != ChallengePending {
+		t.Error("сообщения не влияют на кнопку")
+	}
+	s.Settings.Action = ActionKick
+	if c.OnTimeout(s) != ActionKick {
+		t.Error("OnTimeout должен брать действие из настроек чата")
+	}
+}
+
+func TestMathChallenge(t *testing.T) {
+	var c mathChallenge
+	for i := 0; i < 50; i++ {
+		s := &ChallengeSession{}
+		p := c.Render(s)
+		if !strings.Contains(p.Text, "Сколько будет") {
+			t.Fatalf("нет вопроса: %q", p.Text)
+		}
+		seen := map[string]bool{}
+		for _, btn := range p.Buttons[0] {
+			if seen[btn.Data] {
+				t.Fatalf("варианты повторяются: %+v", p.Buttons)
+			}
+			seen[btn.Data] = true
+		}
+		if len(seen) != mathOptions || !seen[s.Answer] {
+			t.Fatalf("среди %d вариантов нет ответа %s: %+v", len(seen), s.Answer, p.Buttons)
+		}
+	}
+
+	s := &ChallengeSession{Answer: "7"}
+	if c.HandleCallback(s, "7") != ChallengePassed || c.HandleCallback(s, "8") != ChallengeFailed {
+		t.Error("неверная проверка ответа кнопкой")
+	}
+	cases := map[string]ChallengeResult{" 7 ": ChallengePassed, "8": ChallengeFailed, "семь": ChallengePending}
+	for text, want := range cases {
+		if got := c.HandleMessage(s, &Message{Text: text}); got != want {
+			t.Errorf("сообщение %q: %v, ожидали %v", text, got, want)
+		}
+	}
+}
+
+func TestEmojiChallenge(t *testing.T) {
+	var c emojiChallenge
+	for i := 0; i < 50; i++ {
+		s := &ChallengeSession{}
+		p := c.Render(s)
+		if len(p.Buttons) != emojiGridRows {
+			t.Fatalf("ожидали %d ряда: %+v", emojiGridRows, p.Buttons)
+		}
+		target, _ := strconv.Atoi(s.Answer)
+		if !strings.Contains(p.Text, emojiNames[target].name) {
+			t.Fatalf("вопрос %q не называет %s", p.Text, emojiNames[target].name)
+		}
+		found := 0
+		for _, row := range p.Buttons {
+			if len(row) != emojiGridCols {
+				t.Fatalf("ожидали %d кнопки в ряду", emojiGridCols)
+			}
+			for _, btn := range row {
+				if btn.Data == s.Answer {
+					found++
+					if btn.Text != emojiNames[target].emoji {
+						t.Fatalf("кнопка ответа %q не совпадает с %q", btn.Text, emojiNames[target].emoji)
+					}
+				}
+			}
+		}
+		if found != 1 {
+			t.Fatalf("ответ должен встречаться ровно один раз, встретился %d", found)
+		}
+		if c.HandleCallback(s, s.Answer) != ChallengePassed {
+			t.Fatal("верный эмодзи должен засчитываться")
+		}
+	}
+	if c.HandleCallback(&ChallengeSession{Answer: "1"}, "2") != ChallengeFailed {
+		t.Error("неверный эмодзи должен проваливать проверку")
+	}
+}
+
+func TestHoneypotChallenge(t *testing.T) {
+	var c honeypotChallenge
+	for i := 0; i < 20; i++ {
+		s := &ChallengeSession{}
+		p := c.Render(s)
+		row := p.Buttons[0]
+		if row[0].Data != honeypotTrap {
+			t.Fatalf("ловушка должна быть первой: %+v", row)
+		}
+		human := 0
+		for _, btn := range row {
+			if btn.Data == s.Answer {
+				human++
+			}
+		}
+		if human != 1 || len(row) != 2+len(honeypotDecoys) {
+			t.Fatalf("неверный набор кнопок: %+v", row)
+		}
+	}
+	s := &ChallengeSession{Answer: honeypotHuman}
+	if c.HandleCallback(s, honeypotTrap) != ChallengeFailed || c.HandleCallback(s, "decoy0") != ChallengeFailed {
+		t.Error("ловушка и приманки должны проваливать проверку")
+	}
+	if c.HandleCallback(s, honeypotHuman) != ChallengePassed {
+		t.Error("«Я человек» должна засчитываться")
+	}
+}
+
+func TestTextChallenge(t *testing.T) {
+	var c textChallenge
+	s := &ChallengeSession{}
+	p := c.Render(s)
+	if len(s.Answer) != textCodeLen || !strings.Contains(p.Text, s.Answer) {
+		t.Fatalf("вопрос %q не показывает код %q", p.Text, s.Answer)
+	}
+	if len(p.Buttons) != 0 {
+		t.Errorf("кнопки не нужны: %+v", p.Buttons)
+	}
+	if c.HandleCallback(s, "") != ChallengePending {
+		t.Error("нажатия не относятся к проверке")
+	}
+
+	s.Answer = "4071"
+	cases := map[string]ChallengeResult{
+		"4071":    ChallengePassed,
+		" 40 71 ": ChallengePassed,
+		"4072":    ChallengeFailed,
+		"привет":  ChallengeFailed,
+		"":        ChallengeFailed, // стикер или фото без подписи
+	}
+	for text, want := range cases {
+		if got := c.HandleMessage(s, &Message{Text: text}); got != want {
+			t.Errorf("сообщение %q: %v, ожидали %v", text, got, want)
+		}
+	}
+}
+
+func TestSequenceChallenge(t *testing.T) {
+	var c sequenceChallenge
+	for i := 0; i < 50; i++ {
+		s := &ChallengeSession{}
+		p := c.Render(s)
+		order := strings.Split(s.Answer, ",")
+		if len(order) != sequenceLen {
+			t.Fatalf("ожидали %d эмодзи: %q", sequenceLen, s.Answer)
+		}
+		onGrid := map[string]bool{}
+		for _, row := range p.Buttons {
+			for _, btn := range row {
+				onGrid[btn.Data] = true
+			}
+		}
+		for _, d := range order {
+			n, _ := strconv.Atoi(d)
+			if !onGrid[d] || !strings.Contains(p.Text, emojiNames[n].emoji) {
+				t.Fatalf("эмодзи %s нет в сетке или в вопросе %q", d, p.Text)
+			}
+		}
+	}
+
+	s := &ChallengeSession{Answer: "3,0,7"}
+	if c.HandleCallback(s, "3") != ChallengePending || c.HandleCallback(s, "0") != ChallengePending {
+		t.Fatal("верные промежуточные нажатия должны ждать продолжения")
+	}
+	if c.HandleCallback(s, "7") != ChallengePassed {
+		t.Error("последнее верное нажатие должно засчитать проверку")
+	}
+
+	s = &ChallengeSession{Answer: "3,0,7"}
+	c.HandleCallback(s, "3")
+	if c.HandleCallback(s, "7") != ChallengeFailed {
+		t.Error("нажатие не по порядку должно проваливать проверку")
+	}
+}
@@ -0,0 +1,175 @@
+package hamster
+
+import (
+	"fmt"
+	"time"
+)
+
+// ==========================
+// Типы проверки (Challenge)
+// ==========================
+
+// ChallengeResult — итог ответа участника.
+type ChallengeResult int
+
+const (
+	// ChallengePending — ответа ещё нет или он не относится к проверке.
+	ChallengePending ChallengeResult = iota
+	// ChallengePassed — проверка пройдена.
+	ChallengePassed
+	// ChallengeFailed — неверный ответ, участник обрабатывается как по таймауту.
+	ChallengeFailed
+)
+
+// ChallengeButton — кнопка под приветствием. Data попадает в callback_data
+// после токена и возвращается в HandleCallback; вместе с префиксом она
+// должна уложиться в 64 байта.
+type ChallengeButton struct {
+	Text string
+	Data string
+	// WebApp — кнопка открывает Mini App бота (ссылкой с токеном проверки) вместо callback.
+	WebApp bool
+}
+
+// ChallengePrompt — то, что показывается новому участнику.
+type ChallengePrompt struct {
+	Text    string // добавляется к приветствию, может быть пустым
+	Buttons [][]ChallengeButton
+}
+
+// ChallengeSession — состояние проверки одного участника. Challenge хранит
+// здесь правильный ответ: один экземпляр Challenge обслуживает все чаты.
+type ChallengeSession struct {
+	ChatID   int64
+	User     *User
+	Settings ChatSettings
+	Answer   string
+	Step     int       // сколько шагов ответа уже сделано в многошаговой проверке
+	SentAt   time.Time // когда отправлено приветствие
+
+	mediaMsgID int64 // стикер, отправленный перед приветствием
+	captioned  bool  // приветствие — подпись к фото или анимации
+}
+
+// Challenge — тип проверки новых участников. Новые типы подключаются через
+// RegisterChallenge и выбираются по captcha_type в настройках чата.
+type Challenge interface {
+	// Render формирует вопрос и запоминает правильный ответ в s.Answer.
+	Render(s *ChallengeSession) ChallengePrompt
+	// HandleCallback обрабатывает нажатие кнопки с данными data.
+	HandleCallback(s *ChallengeSession, data string) ChallengeResult
+	// HandleMessage обрабатывает сообщение участника в чате во время проверки.
+	HandleMessage(s *ChallengeSession, msg *Message) ChallengeResult
+	// OnTimeout возвращает действие при истечении времени: ActionBan или ActionKick.
+	OnTimeout(s *ChallengeSession) string
+}
+
+var challenges = newRegistry[Challenge]("тип проверки")
+
+// RegisterChallenge регистрирует тип проверки под именем name.
+func RegisterChallenge(name string, c Challenge) {
+	challenges.register(name, c)
+}
+
+// lookupChallenge возвращает зарегистрированный тип проверки.
+func lookupChallenge(name string) (Challenge, bool) {
+	return challenges.lookup(name)
+}
+
+// challengeFor возвращает проверку для чата; неизвестный тип заменяется кнопкой.
+func challengeFor(cs ChatSettings) Challenge {
+	if c, ok := lookupChallenge(cs.Captcha()); ok {
+		return c
+	}
+	c, _ := lookupChallenge(CaptchaButton)
+	return c
+}
+
+// ChallengeNames возвращает имена зарегистрированных типов проверки.
+func ChallengeNames() []string {
+	return challenges.names()
+}
+
+// challengeMarkup строит inline-клавиатуру; callback_data — "click:<user>:<token>[:<data>]".
+// Кнопки WebApp ведут на webAppLink с токеном в startapp.
+func challengeMarkup(prompt ChallengePrompt, userID int64, token, webAppLink string) map[string]interface{} {
+	rows := make([][]interface{}, 0, len(prompt.Buttons))
+	for _, row := range prompt.Buttons {
+		buttons := make([]interface{}, 0, len(row))
+		for _, btn := range row {
+			if btn.WebApp {
+				buttons = append(buttons, map[string]interface{}{"text": btn.Text, "url": webAppLink + "?startapp=" + token})
+				continue
+			}
+			data := fmt.Sprintf("click:%d:%s", userID, token)
+			if btn.Data != "" {
+				data += ":" + btn.Data
+			}
+			buttons = append(buttons, map[string]interface{}{"text": btn.Text, "callback_data": data})
+		}
+		rows = append(rows, buttons)
+	}
+	return map[string]interface{}{"inline_keyboard": rows}
+}
+
+// progressChallenge возвращает проверку и её состояние; для записей без
+// проверки (созданных напрямую через startProgressbar) — кнопку.
+func progressChallenge(p *progressData) (Challenge, *ChallengeSession) {
+	ch, s := p.challenge, p.session
+	if ch == nil {
+		ch, _ = lookupChallenge(CaptchaButton)
+	}
+	if s == nil {
+		s = &ChallengeSession{ChatID: p.chatID, User: &User{ID: p.userID}}
+	}
+	return ch, s
+}
+
+// failChallenge завершает проверку досрочно (reason — причина для журнала банов):
+// прогрессбар останавливается, а участник обрабатывается так же, как по таймауту.
+func (b *Bot) failChallenge(p *progressData, reason string) {
+	b.progressStore.mu.Lock()
+	p.failReason = reason
+	b.progressStore.mu.Unlock()
+	p.stopOnce.Do(func() { close(p.stopChan) })
+}
+
+// pendingProgress ищет незавершённую проверку участника в чате.
+func (b *Bot) pendingProgress(chatID, userID int64) *progressData {
+	p := b.progressStore.get(chatID, userID)
+	if p == nil {
+		return nil
+	}
+	b.progressStore.mu.Lock()
+	defer b.progressStore.mu.Unlock()
+	if p.failReason != "" {
+		return nil
+	}
+	return p
+}
+
+// handleChallengeMessage передаёт сообщение участника его проверке.
+// Возвращает true, если сообщение было ответом и обработано.
+func (b *Bot) handleChallengeMessage(msg *Message) bool {
+	if msg.From == nil {
+		return false
+	}
+	p := b.pendingProgress(msg.Chat.ID, msg.From.ID)
+	if p == nil {
+		return false
+	}
+	ch, s := progressChallenge(p)
+	p.answerMu.Lock()
+	result := ch.HandleMessage(s, msg)
+	p.answerMu.Unlock()
+	switch result {
+	case ChallengePassed:
+		b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+		b.passChallenge(msg.Chat.ID, msg.From, p)
+		return true
+	case ChallengeFailed:
+		b.failChallenge(p, BanReasonWrongAnswer)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package hamster
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedChallenge — проверка с заранее известным ответом для тестов.
+type fixedChallenge struct{ action string }
+
+func (fixedChallenge) Render(s *ChallengeSession) ChallengePrompt {
+	s.Answer = "42"
+	return ChallengePrompt{Text: "вопрос", Buttons: [][]ChallengeButton{{{Text: "42", Data: "42"}, {Text: "7", Data: "7"}}}}
+}
+
+func (fixedChallenge) HandleCallback(s *ChallengeSession, data string) ChallengeResult {
+	if data == s.Answer {
+		return ChallengePassed
+	}
+	return ChallengeFailed
+}
+
+func (fixedChallenge) HandleMessage(s *ChallengeSession, msg *Message) ChallengeResult {
+	if msg.Text == s.Answer {
+		return ChallengePassed
+	}
+	return ChallengePending
+}
+
+func (c fixedChallenge) OnTimeout(s *ChallengeSession) string { return c.action }
+
+func TestRegisterChallenge(t *testing.T) {
+	for _, name := range []string{CaptchaButton, CaptchaMath, CaptchaEmoji, CaptchaHoneypot} {
+		if _, ok := lookupChallenge(name); !ok {
+			t.Errorf("встроенная проверка %q не зарегистрирована", name)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("повторная регистрация должна паниковать")
+		}
+	}()
+	RegisterChallenge(CaptchaButton, buttonChallenge{})
+}
+
+func TestChallengeForFallsBackToButton(t *testing.T) {
+	if _, ok := challengeFor(ChatSettings{CaptchaType: "unknown"}).(buttonChallenge); !ok {
+		t.Error("неизвестный тип должен заменяться кнопкой")
+	}
+	if _, ok := challengeFor(ChatSettings{CaptchaType: CaptchaMath}).(mathChallenge); !ok {
+		t.Error("ожидали math")
+	}
+	if err := (ChatSettings{CaptchaType: "unknown"}).Validate(); err == nil {
+		t.Error("Validate должен отклонять неизвестный captcha_type")
+	}
+	if err := (ChatSettings{CaptchaType: CaptchaEmoji}).Validate(); err != nil {
+		t.Errorf("emoji должен проходить Validate: %v", err)
+	}
+}
+
+func TestChallengeMarkup(t *testing.T) {
+	prompt := ChallengePrompt{Buttons: [][]ChallengeButton{{{Text: "a"}, {Text: "b", Data: "2"}}}}
+	rows := challengeMarkup(prompt, 42, "TOK", "")["inline_keyboard"].([][]interface{})
+	if len(rows) != 1 || len(rows[0]) != 2 {
+		t.Fatalf("неверная клавиатура: %v", rows)
+	}
+	if got := rows[0][0].(map[string]interface{})["callback_data"]; got != "click:42:TOK" {
+		t.Errorf("кнопка без данных: %v", got)
+	}
+	if got := rows[0][1].(map[string]interface{})["callback_data"]; got != "click:42:TOK:2" {
+		t.Errorf("кнопка с данными: %v", got)
+	}
+}
+
+func startTestChallenge(b *Bot, action string, timeout int) (done chan struct{}) {
+	ch := fixedChallenge{action: action}
+	s := &ChallengeSession{ChatID: 1, User: &User{ID: 42}}
+	ch.Render(s)
+	done = make(chan struct{})
+	ctx, cancel := context.WithCancel(b.ctx)
+	go func() {
+		b.runChallenge(ctx, cancel, 1, 10, 42, "TOK", timeout, ch, s)
+		close(done)
+	}()
+	for b.pendingProgress(1, 42) == nil {
+		time.Sleep(time.Millisecond)
+	}
+	return done
+}
+
+func TestChallengeWrongAnswerFails(t *testing.T) {
+	b := setupBot()
+	fakeOf(b).editMessage = func(chatID, msgID int64, text string) {}
+	var banned, kicked bool
+	fakeOf(b).ban = func(chatID, userID int64) { banned = true }
+	fakeOf(b).unban = func(chatID, userID int64) { kicked = banned }
+	done := startTestChallenge(b, ActionKick, 60)
+
+	b.handleCallback(&Callback{
+		Message: &Message{MessageID: 10, Chat: Chat{ID: 1}},
+		From:    &User{ID: 42},
+		Data:    "click:42:TOK:7",
+	})
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("неверный ответ должен завершать проверку сразу")
+	}
+	if !kicked {
+		t.Error("OnTimeout вернул kick — участник должен быть исключён")
+	}
+}
+
+func TestChallengeMessageAnswerPasses(t *testing.T) {
+	b := setupBot()
+	fakeOf(b).editMessage = func(chatID, msgID int64, text string) {}
+	var banned bool
+	fakeOf(b).ban = func(chatID, userID int64) { banned = true }
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = append(deleted, msgID) }
+	var greeting string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { greeting = text; return 1 }
+	done := startTestChallenge(b, ActionBan, 60)
+
+	if b.handleChallengeMessage(&Message{MessageID: 77, Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "привет"}) {
+		t.Error("сообщение не по теме не должно считаться ответом")
+	}
+	if !b.handleChallengeMessage(&Message{MessageID: 78, Chat: Chat{ID: 1}, From: &User{ID: 42, FirstName: "Аня"}, Text: "42"}) {
+		t.Fatal("верный ответ сообщением должен засчитываться")
+	}
+	<-done
+	if banned {
+		t.Error("прошедший проверку не должен баниться")
+	}
+	if !strings.Contains(greeting, "Аня") {
+		t.Errorf("нет приветствия: %q", greeting)
+	}
+	found := false
+	for _, id := range deleted {
+		found = found || id == 78
+	}
+	if !found {
+		t.Error("сообщение с ответом должно удаляться")
+	}
+}
@@ -0,0 +1,117 @@
+package hamster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Сообщения от имени каналов
+// ==========================
+
+const (
+	// ChannelFilterDelete — удалять сообщения, отправленные от имени чужих каналов.
+	ChannelFilterDelete = "delete"
+	// ChannelFilterBan — удалять и запрещать каналу писать в чат (banChatSenderChat).
+	ChannelFilterBan = "ban"
+)
+
+// linkedChatTTL — сколько помнить привязанный к группе канал.
+const linkedChatTTL = 30 * time.Minute
+
+type linkedChatEntry struct {
+	id        int64
+	expiresAt time.Time
+}
+
+// linkedChats кэширует linked_chat_id групп из getChat.
+type linkedChats struct {
+	mu sync.Mutex
+	m  map[int64]linkedChatEntry
+}
+
+// linkedChannel возвращает ID канала, привязанного к группе (0 — нет или неизвестно).
+// Сообщения этого канала — автопересылки постов в обсуждения, их не трогаем.
+func (b *Bot) linkedChannel(chatID int64) int64 {
+	b.linked.mu.Lock()
+	defer b.linked.mu.Unlock()
+	if e, ok := b.linked.m[chatID]; ok && time.Now().Before(e.expiresAt) {
+		return e.id
+	}
+	chat, err := b.api.GetChat(b.ctx, strconv.FormatInt(chatID, 10))
+	if err != nil {
+		b.logger.Warn("Не удалось узнать привязанный канал чата %d: %v", chatID, err)
+		return 0
+	}
+	if b.linked.m == nil {
+		b.linked.m = make(map[int64]linkedChatEntry)
+	}
+	b.linked.m[chatID] = linkedChatEntry{id: chat.LinkedChatID, expiresAt: time.Now().Add(linkedChatTTL)}
+	return chat.LinkedChatID
+}
+
+// applyChannelFilter удаляет сообщения от имени чужих каналов, если это включено
+// в чате командой /channels. Возвращает true, если сообщение удалено.
+func (b *Bot) applyChannelFilter(msg *Message) bool {
+	sc := msg.SenderChat
+	if sc == nil || sc.Type != "channel" || sc.ID == msg.Chat.ID || msg.IsAutomaticForward {
+		return false
+	}
+	chatID := msg.Chat.ID
+	mode := b.chatSettings(chatID).ChannelFilter
+	if mode == "" || sc.ID == b.linkedChannel(chatID) {
+		return false
+	}
+	b.safeDeleteMessage(b.ctx, chatID, msg.MessageID)
+	if mode != ChannelFilterBan {
+		b.logger.Info("Удалено сообщение от имени канала %d в чате %d", sc.ID, chatID)
+		return true
+	}
+	b.logger.Info("Канал %d забанен в чате %d", sc.ID, chatID)
+	b.safeBanSenderChat(b.ctx, chatID, sc.ID)
+	b.logBan(chatID, sc.ID, BanReasonChannel)
+	b.recordStat(chatID, func(c *ChatStats) { c.Banned++ })
+	return true
+}
+
+// ==========================
+// Команда /channels
+// ==========================
+
+func (b *Bot) handleChannelsCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может настраивать фильтр каналов", 5*time.Second)
+		return
+	}
+
+	switch arg := strings.ToLower(commandArg(msg.Text, 1)); arg {
+	case "off":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.ChannelFilter = "" })
+		b.sendTemporary(chatID, "✅ Сообщения от имени каналов больше не удаляются", 5*time.Second)
+	case ChannelFilterDelete, ChannelFilterBan:
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.ChannelFilter = arg })
+		text := "✅ Сообщения от имени чужих каналов будут удаляться"
+		if arg == ChannelFilterBan {
+			text += ", а сами каналы — блокироваться"
+		}
+		b.sendTemporary(chatID, text, 5*time.Second)
+	case "":
+		status := "выключен"
+		switch b.chatSettings(chatID).ChannelFilter {
+		case ChannelFilterDelete:
+			status = "удалять сообщения"
+		case ChannelFilterBan:
+			status = "удалять и блокировать канал"
+		}
+		b.sendTemporary(chatID, fmt.Sprintf("📢 Фильтр каналов: %s\n⚙️ /channels off|delete|ban", status), 10*time.Second)
+	default:
+		b.sendTemporary(chatID, "⚙️ Использование: /channels off|delete|ban", 5*time.Second)
+	}
+}
@@ -0,0 +1,87 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func setupChannelBot(mode string) *Bot {
+	b := setupBot()
+	b.stats = NewStats()
+	b.settings.Update(-100, func(c *ChatSettings) { c.ChannelFilter = mode })
+	fakeOf(b).getChat = func(chatRef string) (Chat, error) {
+		return Chat{ID: -100, Type: "supergroup", LinkedChatID: -500}, nil
+	}
+	return b
+}
+
+func channelMessage(channelID int64) *Message {
+	return &Message{
+		MessageID:  7,
+		Chat:       Chat{ID: -100, Type: "supergroup"},
+		From:       &User{ID: 136817688, IsBot: true, Username: "Channel_Bot"},
+		SenderChat: &Chat{ID: channelID, Type: "channel"},
+		Text:       "Лучший канал о крипте",
+	}
+}
+
+func TestChannelFilterBan(t *testing.T) {
+	b := setupChannelBot(ChannelFilterBan)
+	var deleted, bannedChannel int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = msgID }
+	fakeOf(b).banSenderChat = func(chatID, senderChatID int64) { bannedChannel = senderChatID }
+
+	b.handleUpdate(Update{Message: channelMessage(-300)})
+	if deleted != 7 || bannedChannel != -300 {
+		t.Errorf("сообщение чужого канала должно удаляться, а канал — блокироваться: deleted=%d banned=%d", deleted, bannedChannel)
+	}
+	if b.stats.Get(-100).Banned != 1 {
+		t.Error("бан канала должен попасть в статистику")
+	}
+}
+
+func TestChannelFilterKeepsLinkedAndAnonymous(t *testing.T) {
+	b := setupChannelBot(ChannelFilterDelete)
+	deleted := false
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = true }
+	fakeOf(b).banSenderChat = func(chatID, senderChatID int64) {
+		t.Error("в режиме delete каналы не блокируются")
+	}
+
+	for name, msg := range map[string]*Message{
+		"привязанный канал": channelMessage(-500),
+		"автопересылка":     func() *Message { m := channelMessage(-300); m.IsAutomaticForward = true; return m }(),
+		"анонимный админ":   {MessageID: 7, Chat: Chat{ID: -100}, SenderChat: &Chat{ID: -100, Type: "supergroup"}},
+	} {
+		deleted = false
+		if b.applyChannelFilter(msg) || deleted {
+			t.Errorf("%s: сообщение не должно удаляться", name)
+		}
+	}
+
+	if !b.applyChannelFilter(channelMessage(-300)) || !deleted {
+		t.Error("сообщение чужого канала должно удаляться")
+	}
+}
+
+func TestChannelFilterOffByDefault(t *testing.T) {
+	b := setupChannelBot("")
+	if b.applyChannelFilter(channelMessage(-300)) {
+		t.Error("без /channels фильтр выключен")
+	}
+}
+
+func TestChannelsCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	b.handleChannelsCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/channels ban"})
+	if got := b.chatSettings(-100).ChannelFilter; got != ChannelFilterBan {
+		t.Fatalf("режим: %q", got)
+	}
+	b.handleChannelsCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/channels off"})
+	if got := b.chatSettings(-100).ChannelFilter; got != "" {
+		t.Errorf("/channels off должен выключать фильтр: %q", got)
+	}
+}
@@ -0,0 +1,230 @@
+package hamster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Команда /check
+// ==========================
+
+// checkTimeFormat — формат времени в ответе /check.
+const checkTimeFormat = "02.01.2006 15:04"
+
+var memberStatusNames = map[string]string{
+	"creator":       "владелец",
+	"administrator": "администратор",
+	"member":        "участник",
+	"restricted":    "ограничен",
+	"left":          "не в чате",
+	"kicked":        "забанен",
+}
+
+// cachedUser ищет по @username среди тех, кто недавно писал: Bot API не
+// позволяет найти пользователя по имени.
+func (b *Bot) cachedUser(username string) *User {
+	username = strings.TrimPrefix(username, "@")
+	b.muMessages.Lock()
+	defer b.muMessages.Unlock()
+	for _, l := range b.userMessages {
+		for e := l.Back(); e != nil; e = e.Prev() {
+			if u := e.Value.(cachedMessage).msg.From; u != nil && strings.EqualFold(u.Username, username) {
+				return u
+			}
+		}
+	}
+	return nil
+}
+
+// checkTarget определяет, о ком спрашивают: ответ на сообщение, ID или @username.
+func (b *Bot) checkTarget(msg *Message) (int64, error) {
+	if msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil {
+		return msg.ReplyToMessage.From.ID, nil
+	}
+	arg := commandArg(msg.Text, 1)
+	switch {
+	case arg == "":
+		return 0, fmt.Errorf("⚙️ Использование: /check <ID|@username> или ответом на сообщение")
+	case strings.HasPrefix(arg, "@"):
+		if u := b.cachedUser(arg); u != nil {
+			return u.ID, nil
+		}
+		return 0, fmt.Errorf("❌ %s недавно не писал в чат — укажите ID или ответьте на его сообщение", arg)
+	}
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("❌ Некорректный ID: %s", arg)
+	}
+	return id, nil
+}
+
+// commandTarget — checkTarget для других команд: подсказка называет cmd.
+func (b *Bot) commandTarget(msg *Message, cmd string) (int64, error) {
+	if msg.ReplyToMessage == nil && commandArg(msg.Text, 1) == "" {
+		return 0, fmt.Errorf("⚙️ Использование: %s <ID|@username> или ответом на сообщение", cmd)
+	}
+	return b.checkTarget(msg)
+}
+
+func (b *Bot) handleCheckCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.canModerateMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор или модератор может проверять участников", 5*time.Second)
+		return
+	}
+	userID, err := b.checkTarget(msg)
+	if err != nil {
+		b.sendTemporary(chatID, err.Error(), 10*time.Second)
+		return
+	}
+	b.sendTemporaryHTML(chatID, b.checkReport(chatID, userID), time.Minute)
+}
+
+// checkReport собирает всё, что бот знает об участнике чата.
+func (b *Bot) checkReport(chatID, userID int64) string {
+	var sb strings.Builder
+	member, err := b.api.GetChatMember(b.ctx, chatID, userID)
+	if err == nil && member.User != nil {
+		fmt.Fprintf(&sb, "🔎 %s (ID <code>%d</code>)\n", mentionHTML(member.User), userID)
+	} else {
+		fmt.Fprintf(&sb, "🔎 ID <code>%d</code>\n", userID)
+	}
+	switch {
+	case err != nil:
+		sb.WriteString("Статус в чате: не удалось узнать\n")
+	case memberStatusNames[member.Status] != "":
+		fmt.Fprintf(&sb, "Статус в чате: %s\n", memberStatusNames[member.Status])
+	default:
+		fmt.Fprintf(&sb, "Статус в чате: %s\n", html.EscapeString(member.Status))
+	}
+
+	var at time.Time
+	var ok bool
+	if b.verified != nil {
+		at, ok = b.verified.since(chatID, b.storedUserID(userID))
+	}
+	switch p := b.pendingProgress(chatID, userID); {
+	case p != nil:
+		fmt.Fprintf(&sb, "Проверка: идёт с %s\n", p.startedAt.Format(checkTimeFormat))
+	case ok:
+		fmt.Fprintf(&sb, "Проверка: пройдена %s\n", at.Format(checkTimeFormat))
+	default:
+		sb.WriteString("Проверка: нет данных (вступил давно или без бота)\n")
+	}
+	if member.User != nil {
+		if sign := trustedSign(member.User, b.cfg.TrustedAccountSigns, b.cfg.OldAccountID); sign != "" {
+			fmt.Fprintf(&sb, "Похож на человека: %s\n", sign)
+		}
+	}
+
+	sb.WriteString(b.checkLists(chatID, userID) + "\n")
+	if b.cfg.CASURL != "" {
+		sb.WriteString(b.checkCAS(userID) + "\n")
+	}
+
+	bans, err := b.banLog(BanLogQuery{ChatID: chatID, UserID: userID, Limit: 10})
+	if err != nil {
+		b.logger.Warn("Не удалось прочитать журнал банов чата %d: %v", chatID, err)
+	}
+	if len(bans) == 0 {
+		sb.WriteString("Провалов и банов не было")
+		return sb.String()
+	}
+	sb.WriteString("Провалы и баны:")
+	for _, e := range bans {
+		fmt.Fprintf(&sb, "\n• %s — %s", e.At.Format(checkTimeFormat), html.EscapeString(banReasonName(e.Reason)))
+	}
+	return sb.String()
+}
+
+// checkLists перечисляет списки чата, в которых состоит пользователь.
+func (b *Bot) checkLists(chatID, userID int64) string {
+	cs := b.chatSettings(chatID)
+	var lists []string
+	if slices.Contains(cs.Moderators, userID) {
+		lists = append(lists, "модератор")
+	}
+	if slices.Contains(cs.Blacklist, userID) {
+		lists = append(lists, "чёрный список чата")
+	}
+	if slices.Contains(b.chatSettings(globalSettingsID).Blacklist, userID) {
+		lists = append(lists, "общий чёрный список")
+	}
+	if b.isFedBanned(chatID, userID) {
+		lists = append(lists, "бан федерации")
+	}
+	if len(lists) == 0 {
+		return "Списки: нет"
+	}
+	return "Списки: " + strings.Join(lists, ", ")
+}
+
+// ==========================
+// CAS (Combot Anti-Spam)
+// ==========================
+
+// DefaultCASURL — адрес публичного CAS.
+const DefaultCASURL = "https://api.cas.chat"
+
+// casTimeout — сколько /check ждёт ответа CAS.
+const casTimeout = 5 * time.Second
+
+// casResponse — ответ CAS на /check?user_id=: ok=false — пользователя нет в базе.
+type casResponse struct {
+	OK     bool `json:"ok"`
+	Result struct {
+		Offenses int `json:"offenses"`
+	} `json:"result"`
+}
+
+// casOffenses спрашивает CAS о пользователе; 0 — его нет в базе спамеров.
+func (b *Bot) casOffenses(ctx context.Context, userID int64) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, casTimeout)
+	defer cancel()
+	url := strings.TrimRight(b.cfg.CASURL, "/") + "/check?user_id=" + strconv.FormatInt(userID, 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("CAS ответил %s", resp.Status)
+	}
+	var r casResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&r); err != nil {
+		return 0, err
+	}
+	if !r.OK {
+		return 0, nil
+	}
+	return max(r.Result.Offenses, 1), nil
+}
+
+// checkCAS — строка /check о статусе пользователя в CAS.
+func (b *Bot) checkCAS(userID int64) string {
+	n, err := b.casOffenses(b.ctx, userID)
+	switch {
+	case err != nil:
+		b.logger.Warn("Не удалось запросить CAS о %d: %v", userID, err)
+		return "CAS: не удалось узнать"
+	case n > 0:
+		return fmt.Sprintf("CAS: ⚠️ в базе спамеров, нарушений %d", n)
+	}
+	return "CAS: не числится"
+}
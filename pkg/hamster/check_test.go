@@ -0,0 +1,108 @@
+package hamster
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckTarget(t *testing.T) {
+	b := setupBot()
+	b.cacheMessage(Update{Message: &Message{Chat: Chat{ID: -100}, From: &User{ID: 42, Username: "Anya"}, Text: "привет"}})
+	cases := []struct {
+		msg  *Message
+		want int64
+	}{
+		{&Message{Text: "/check 77"}, 77},
+		{&Message{Text: "/check @anya"}, 42},
+		{&Message{Text: "/check", ReplyToMessage: &Message{From: &User{ID: 5}}}, 5},
+	}
+	for _, c := range cases {
+		if got, err := b.checkTarget(c.msg); err != nil || got != c.want {
+			t.Errorf("%q: %d, %v; ожидали %d", c.msg.Text, got, err, c.want)
+		}
+	}
+	for _, text := range []string{"/check", "/check @nobody", "/check abc"} {
+		if _, err := b.checkTarget(&Message{Text: text}); err == nil {
+			t.Errorf("%q: ожидали ошибку", text)
+		}
+	}
+}
+
+func TestCheckReport(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.verified = newVerifiedUsers()
+	b.recentBans = newBanHistory(10)
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "member", User: &User{ID: userID, FirstName: "Аня", IsPremium: true}}, nil
+	}
+	b.logBan(-100, 42, BanReasonWrongAnswer)
+	b.logBan(-200, 42, BanReasonTimeout)
+	b.markVerified(-100, 42)
+
+	report := b.checkReport(-100, 42)
+	for _, want := range []string{"Аня", "Статус в чате: участник", "Проверка: пройдена", "Похож на человека: premium", "неверный ответ"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("в отчёте нет %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "не прошёл проверку вовремя") {
+		t.Errorf("баны в других чатах не показываются:\n%s", report)
+	}
+}
+
+func TestCheckCommandAdminsOnly(t *testing.T) {
+	b := setupBot()
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { sent = append(sent, text); return 1 }
+	b.handleCheckCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/check 42"})
+	if len(sent) != 1 || !strings.Contains(sent[0], "Только администратор") {
+		t.Errorf("не админ получает отказ: %v", sent)
+	}
+}
+
+func TestCheckReportListsAndCAS(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "member", User: &User{ID: userID, FirstName: "Аня"}}, nil
+	}
+	b.updateChatSettings(-100, func(c *ChatSettings) { c.Blacklist = []int64{42}; c.Moderators = []int64{43} })
+	newFederation(b, -100)
+	b.addFederationBan("fed1", 42)
+
+	report := b.checkReport(-100, 42)
+	if !strings.Contains(report, "Списки: чёрный список чата, бан федерации") || strings.Contains(report, "CAS") {
+		t.Errorf("без CAS_CHECK отчёт только о списках:\n%s", report)
+	}
+	if report := b.checkReport(-100, 43); !strings.Contains(report, "Списки: модератор") {
+		t.Errorf("модератор в отчёте:\n%s", report)
+	}
+	if report := b.checkReport(-100, 44); !strings.Contains(report, "Списки: нет") {
+		t.Errorf("пользователь без списков:\n%s", report)
+	}
+
+	b.cfg.CASURL = "https://cas.example"
+	var asked string
+	b.httpClient = &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		asked = req.URL.String()
+		switch req.URL.Query().Get("user_id") {
+		case "42":
+			return jsonResponse(200, `{"ok":true,"result":{"offenses":3}}`), nil
+		case "43":
+			return jsonResponse(200, `{"ok":false,"description":"Record not found."}`), nil
+		}
+		return nil, errors.New("нет связи")
+	}}
+	if report := b.checkReport(-100, 42); !strings.Contains(report, "CAS: ⚠️ в базе спамеров, нарушений 3") || asked != "https://cas.example/check?user_id=42" {
+		t.Errorf("CAS о спамере (%s):\n%s", asked, report)
+	}
+	if report := b.checkReport(-100, 43); !strings.Contains(report, "CAS: не числится") {
+		t.Errorf("CAS без записи:\n%s", report)
+	}
+	if report := b.checkReport(-100, 44); !strings.Contains(report, "CAS: не удалось узнать") {
+		t.Errorf("ошибка CAS:\n%s", report)
+	}
+}
@@ -0,0 +1,196 @@
+package hamster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Учёт сообщений бота в группах
+// ==========================
+
+const (
+	// maxSentPerChat — сколько последних сообщений бота помнить в одном чате.
+	maxSentPerChat = 500
+	// sentMessageTTL — дольше нет смысла помнить: Telegram не даёт ботам
+	// удалять в группах сообщения старше 48 часов.
+	sentMessageTTL = 48 * time.Hour
+)
+
+// SentMessage — сообщение, отправленное ботом в группу.
+type SentMessage struct {
+	MsgID int64     `json:"msg_id"`
+	At    time.Time `json:"at"`
+}
+
+// sentMessages — сообщения бота по чатам, которые ещё не удалены.
+type sentMessages struct {
+	mu sync.Mutex
+	m  map[int64][]SentMessage
+}
+
+func (s *sentMessages) add(chatID, msgID int64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.m == nil {
+		s.m = make(map[int64][]SentMessage)
+	}
+	list := append(s.m[chatID], SentMessage{MsgID: msgID, At: at})
+	if len(list) > maxSentPerChat {
+		list = append([]SentMessage(nil), list[len(list)-maxSentPerChat:]...)
+	}
+	s.m[chatID] = list
+}
+
+func (s *sentMessages) remove(chatID, msgID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := s.m[chatID]
+	for i, m := range list {
+		if m.MsgID == msgID {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(s.m, chatID)
+		return
+	}
+	s.m[chatID] = list
+}
+
+// take забирает из учёта сообщения чата, кроме тех, для которых keep вернул true.
+func (s *sentMessages) take(chatID int64, keep func(msgID int64) bool) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var taken []int64
+	var kept []SentMessage
+	for _, m := range s.m[chatID] {
+		if keep(m.MsgID) {
+			kept = append(kept, m)
+		} else {
+			taken = append(taken, m.MsgID)
+		}
+	}
+	if len(kept) == 0 {
+		delete(s.m, chatID)
+	} else {
+		s.m[chatID] = kept
+	}
+	return taken
+}
+
+// snapshot возвращает копию учёта без сообщений старше sentMessageTTL.
+func (s *sentMessages) snapshot(now time.Time) map[int64][]SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[int64][]SentMessage, len(s.m))
+	for chatID, list := range s.m {
+		var fresh []SentMessage
+		for _, m := range list {
+			if now.Sub(m.At) < sentMessageTTL {
+				fresh = append(fresh, m)
+			}
+		}
+		if len(fresh) > 0 {
+			out[chatID] = fresh
+		}
+	}
+	return out
+}
+
+// replace заменяет учёт (загрузка из хранилища).
+func (s *sentMessages) replace(m map[int64][]SentMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m = m
+}
+
+func (s *sentMessages) forgetChat(chatID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, chatID)
+}
+
+// recordSent запоминает сообщение бота в группе. Личные чаты не учитываются:
+// /cleanup в них не работает.
+func (b *Bot) recordSent(chatID, msgID int64) {
+	if chatID < 0 && msgID != 0 {
+		b.sent.add(chatID, msgID, time.Now())
+		b.saveState()
+	}
+}
+
+// keepSent исключает сообщение из учёта: оно должно остаться в чате, и ни
+// /cleanup, ни уборка после перезапуска его не удалят.
+func (b *Bot) keepSent(chatID, msgID int64) {
+	b.sent.remove(chatID, msgID)
+	b.saveState()
+}
+
+// sweepLeftovers удаляет сообщения бота, оставшиеся от прошлого запуска:
+// проверки в памяти не переживают перезапуск, и их приветствия и прогрессбары
+// иначе висели бы в чатах навсегда.
+// Вызывается до начала polling; удаление идёт в фоне.
+func (b *Bot) sweepLeftovers() {
+	leftovers := b.sent.snapshot(time.Now()) // более старые удалить уже нельзя
+	b.sent.replace(nil)
+	if len(leftovers) == 0 {
+		return
+	}
+	b.saveState()
+	go func() {
+		total := 0
+		for chatID, list := range leftovers {
+			for _, m := range list {
+				b.safeDeleteMessage(b.ctx, chatID, m.MsgID)
+			}
+			total += len(list)
+		}
+		b.logger.Info("🧹 Удалено сообщений бота, оставшихся после перезапуска: %d", total)
+	}()
+}
+
+// activeMessages — сообщения незавершённых проверок чата: их /cleanup не трогает.
+func (b *Bot) activeMessages(chatID int64) map[int64]bool {
+	active := make(map[int64]bool)
+	b.progressStore.each(func(p *progressData) {
+		if p.chatID != chatID {
+			return
+		}
+		active[p.greetMsgID] = true
+		active[p.msgProgressID] = true
+		if p.session != nil && p.session.mediaMsgID != 0 {
+			active[p.session.mediaMsgID] = true
+		}
+	})
+	return active
+}
+
+// ==========================
+// Команда /cleanup
+// ==========================
+
+func (b *Bot) handleCleanupCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может удалять сообщения бота", 5*time.Second)
+		return
+	}
+
+	active := b.activeMessages(chatID)
+	ids := b.sent.take(chatID, func(id int64) bool { return active[id] })
+	for _, id := range ids {
+		b.safeDeleteMessage(b.ctx, chatID, id)
+	}
+	b.logger.Info("/cleanup в чате %d: удалено сообщений бота %d", chatID, len(ids))
+	text := fmt.Sprintf("🧹 Удалено сообщений бота: %d", len(ids))
+	if len(active) > 0 {
+		text += "\nСообщения идущих проверок оставлены"
+	}
+	b.sendTemporary(chatID, text, 5*time.Second)
+}
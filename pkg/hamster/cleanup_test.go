@@ -0,0 +1,118 @@
+package hamster
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSentMessagesLimit(t *testing.T) {
+	var s sentMessages
+	for i := 1; i <= maxSentPerChat+5; i++ {
+		s.add(-1, int64(i), time.Now())
+	}
+	ids := s.take(-1, func(int64) bool { return false })
+	if len(ids) != maxSentPerChat || ids[0] != 6 {
+		t.Errorf("должны остаться последние %d сообщений, первое %d", len(ids), ids[0])
+	}
+	if len(s.take(-1, func(int64) bool { return false })) != 0 {
+		t.Error("take должен забирать сообщения из учёта")
+	}
+}
+
+func TestCleanupDeletesBotMessagesExceptActive(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	next := int64(100)
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { next++; return next }
+	b.safeSendSilent(b.ctx, -100, "старое уведомление")      // 101
+	b.safeSendSilent(b.ctx, -100, "забытое приветствие")     // 102
+	deletedEarly := b.safeSendSilent(b.ctx, -100, "удалено") // 103
+	b.safeDeleteMessage(b.ctx, -100, deletedEarly)
+	b.safeSendSilent(b.ctx, 5, "личка") // в личке не учитывается
+	b.progressStore.add(&progressData{chatID: -100, greetMsgID: 102, stopChan: make(chan struct{})})
+
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) {
+		if chatID == -100 {
+			deleted = append(deleted, msgID)
+		}
+	}
+	b.handleCleanupCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/cleanup"})
+
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i] < deleted[j] })
+	if len(deleted) != 1 || deleted[0] != 101 {
+		t.Errorf("удалены %v, ожидали только 101", deleted)
+	}
+}
+
+func TestSentMessagesSnapshotDropsExpired(t *testing.T) {
+	var s sentMessages
+	now := time.Now()
+	s.add(-1, 1, now.Add(-sentMessageTTL-time.Minute))
+	s.add(-1, 2, now.Add(-time.Hour))
+	s.add(-2, 3, now.Add(-sentMessageTTL-time.Minute))
+	snap := s.snapshot(now)
+	if len(snap) != 1 || len(snap[-1]) != 1 || snap[-1][0].MsgID != 2 {
+		t.Errorf("в снимке должны остаться только свежие сообщения: %v", snap)
+	}
+}
+
+func TestFileStorageSentMessages(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenStorage(Config{Storage: StorageFile, SettingsFile: filepath.Join(dir, "settings.json")}, NewLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent, err := s.LoadSentMessages(); err != nil || sent != nil {
+		t.Fatalf("без файла ожидался nil: %v %v", sent, err)
+	}
+	at := time.Unix(1700000000, 0)
+	if err := s.SaveSentMessages(map[int64][]SentMessage{-100: {{MsgID: 7, At: at}}}); err != nil {
+		t.Fatal(err)
+	}
+	sent, err := s.LoadSentMessages()
+	if err != nil || len(sent[-100]) != 1 || sent[-100][0].MsgID != 7 {
+		t.Errorf("сообщения бота не восстановлены: %v %v", sent, err)
+	}
+}
+
+func TestSweepLeftoversAfterRestart(t *testing.T) {
+	b := setupBot()
+	now := time.Now()
+	b.sent.replace(map[int64][]SentMessage{
+		-100: {{MsgID: 10, At: now.Add(-time.Minute)}, {MsgID: 11, At: now.Add(-sentMessageTTL - time.Hour)}},
+	})
+	deleted := make(chan int64, 4)
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted <- msgID }
+
+	b.sweepLeftovers()
+	select {
+	case id := <-deleted:
+		if id != 10 {
+			t.Errorf("удалено %d, ожидали 10", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("оставшееся приветствие не удалено")
+	}
+	select {
+	case id := <-deleted:
+		t.Errorf("сообщение %d старше 48 часов удалять бесполезно", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if len(b.sent.snapshot(now)) != 0 {
+		t.Error("после уборки учёт должен быть пуст")
+	}
+}
+
+func TestKeptGreetingIsNotCleanedUp(t *testing.T) {
+	b := setupBot()
+	b.recordSent(-100, 10)
+	b.keepGreeting(-100, &progressData{greetMsgID: 10}, ChatSettings{}, &User{ID: 42})
+	if ids := b.sent.take(-100, func(int64) bool { return false }); len(ids) != 0 {
+		t.Errorf("оставленное приветствие не должно удаляться /cleanup: %v", ids)
+	}
+}
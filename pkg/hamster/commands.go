@@ -0,0 +1,52 @@
+package hamster
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ==========================
+// Вспомогательные функции для команд
+// ==========================
+
+// commandName возвращает имя команды без упоминания бота: "/timeout@hamster_bot 10" → "/timeout".
+func commandName(text string) string {
+	if !strings.HasPrefix(text, "/") {
+		return ""
+	}
+	name := strings.Fields(text)[0]
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	return strings.ToLower(name)
+}
+
+// commandArg возвращает остаток текста команды после первых n слов.
+func commandArg(text string, n int) string {
+	rest := strings.TrimSpace(text)
+	for i := 0; i < n; i++ {
+		idx := strings.IndexFunc(rest, unicode.IsSpace)
+		if idx < 0 {
+			return ""
+		}
+		rest = strings.TrimSpace(rest[idx:])
+	}
+	return rest
+}
+
+// sendTemporary отправляет беззвучное сообщение и удаляет его через ttl.
+func (b *Bot) sendTemporary(chatID int64, text string, ttl time.Duration) {
+	msgID := b.safeSendSilent(b.ctx, chatID, text)
+	time.AfterFunc(ttl, func() {
+		b.safeDeleteMessage(b.ctx, chatID, msgID)
+	})
+}
+
+// sendTemporaryHTML — sendTemporary для текста с HTML-разметкой.
+func (b *Bot) sendTemporaryHTML(chatID int64, text string, ttl time.Duration) {
+	msgID := b.safeSend(b.ctx, chatID, text, nil, htmlOptions)
+	time.AfterFunc(ttl, func() {
+		b.safeDeleteMessage(b.ctx, chatID, msgID)
+	})
+}
@@ -0,0 +1,28 @@
+package hamster
+
+import "testing"
+
+func TestCommandName(t *testing.T) {
+	tests := map[string]string{
+		"/timeout 10":             "/timeout",
+		"/Timeout@hamster_bot 10": "/timeout",
+		"/namefilter":             "/namefilter",
+		"привет":                  "",
+		"":                        "",
+	}
+	for in, want := range tests {
+		if got := commandName(in); got != want {
+			t.Errorf("commandName(%q) = %q, ожидалось %q", in, got, want)
+		}
+	}
+}
+
+func TestCommandArg(t *testing.T) {
+	text := "/namefilter add   t\\.me/ .*crypto"
+	if got := commandArg(text, 2); got != "t\\.me/ .*crypto" {
+		t.Errorf("получили %q", got)
+	}
+	if got := commandArg("/namefilter", 1); got != "" {
+		t.Errorf("ожидалась пустая строка, получили %q", got)
+	}
+}
@@ -0,0 +1,536 @@
+package hamster
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config — глобальные настройки бота, задаваемые через переменные окружения.
+type Config struct {
+	// ProbationPeriod — сколько после верификации у пользователя удаляются
+	// ссылки, инвайты и упоминания каналов. 0 отключает фильтр.
+	ProbationPeriod time.Duration
+
+	// MediaRestrictPeriod — сколько после верификации запрещены медиа, стикеры и превью ссылок.
+	// 0 отключает ограничение.
+	MediaRestrictPeriod time.Duration
+
+	// ForwardFilterPeriod — сколько после верификации удаляются пересланные сообщения.
+	// 0 отключает фильтр.
+	ForwardFilterPeriod time.Duration
+
+	// NewcomerPeriod — сколько после верификации участник считается новичком
+	// для ограничения частоты сообщений (/ratelimit) и проверки правок на стоп-слова.
+	NewcomerPeriod time.Duration
+
+	// ForeignPressLimit — после скольких нажатий на чужие кнопки проверки за 10 минут
+	// давать мут. 0 отключает наказание.
+	ForeignPressLimit int
+	// ForeignPressMute — длительность мута за нажатие чужих кнопок.
+	ForeignPressMute time.Duration
+	// CallbackBurstLimit — после скольких нажатий на чужие кнопки за минуту
+	// давать мут; на половине — предупреждение. 0 отключает.
+	CallbackBurstLimit int
+
+	// MinClickDelay — нажатие своей кнопки быстрее этого после приветствия
+	// считается автоматическим и проваливает проверку. 0 отключает проверку.
+	MinClickDelay time.Duration
+
+	// RaidJoins — сколько вступлений за RaidWindow считать наплывом. 0 отключает защиту.
+	RaidJoins int
+	// RaidWindow — окно подсчёта вступлений; наплыв заканчивается, когда за окно их меньше RaidJoins.
+	RaidWindow time.Duration
+	// RaidCaptcha — тип проверки вместо простой кнопки во время наплыва.
+	RaidCaptcha string
+	// RaidLockChat — на время наплыва оставлять право писать только администраторам.
+	RaidLockChat bool
+	// RaidTimeout — таймаут проверки во время наплыва, секунд (не больше настроенного в чате).
+	RaidTimeout int
+	// MaxPendingPerChat — сколько проверок может идти в чате одновременно;
+	// вступившие сверх лимита ждут очереди без права писать. 0 — без лимита.
+	MaxPendingPerChat int
+
+	// Storage — где хранить состояние: StorageFile (по умолчанию), StorageBolt или StoragePostgres.
+	Storage string
+	// BoltFile — файл базы bbolt для StorageBolt.
+	BoltFile string
+	// StorageDSN — строка подключения к PostgreSQL для StoragePostgres.
+	StorageDSN string
+
+	// SettingsFile — JSON-файл с настройками всех групп. Пустая строка — без сохранения.
+	// При StorageBolt читается один раз для переноса настроек в базу.
+	SettingsFile string
+	// SettingsKey — ключ AES (hex или base64) для шифрования SettingsFile. Пустой — без шифрования.
+	SettingsKey string
+	// PrivacyKey — ключ, с которым ID пользователей в верификациях и журнале
+	// банов заменяются псевдонимами (HMAC-SHA256). Пустой — ID хранятся как есть.
+	PrivacyKey string
+	// TimeoutFile, NameFilterFile, DisabledChatsFile — файлы прежних версий.
+	// Читаются один раз для переноса в SettingsFile, если его ещё нет.
+	TimeoutFile       string
+	NameFilterFile    string
+	DisabledChatsFile string
+
+	// Owners — ID владельцев бота (BOT_OWNERS): им доступны глобальные команды в личке.
+	Owners []int64
+
+	// AdminAPIAddr — адрес REST API для операторов, например 127.0.0.1:8081. Пустой — API выключен.
+	AdminAPIAddr string
+	// AdminAPIToken — bearer-токен для REST API.
+	AdminAPIToken string
+
+	// DebugAddr — адрес отладочного сервера (pprof и размеры состояния), например
+	// 127.0.0.1:6060. Пустой — сервер выключен.
+	DebugAddr string
+
+	// Trace — выгрузка трассировки обработки обновлений по OTLP/HTTP.
+	Trace TraceConfig
+
+	// RecordUpdatesFile — файл, куда дописываются все полученные обновления
+	// (JSON Lines) для воспроизведения командой tg-hamster replay. Пустой — запись выключена.
+	RecordUpdatesFile string
+
+	// SentryDSN — DSN проекта Sentry для отчётов о паниках и повторяющихся сбоях
+	// Bot API. Пустой — отчёты не отправляются.
+	SentryDSN string
+	// SentryEnvironment — окружение в отчётах (production, staging).
+	SentryEnvironment string
+
+	// WebAppName — короткое имя Mini App бота из BotFather для проверки CaptchaWebApp.
+	WebAppName string
+	// WebAppAddr — адрес сервера страницы Mini App; снаружи он должен быть доступен по HTTPS.
+	WebAppAddr string
+
+	// PhrasesDir — каталог с наборами фраз кнопки (*.json). Пустой — только встроенные наборы.
+	PhrasesDir string
+
+	// BackupDir — каталог резервных копий состояния.
+	BackupDir string
+	// BackupInterval — как часто делать резервную копию. 0 отключает расписание.
+	BackupInterval time.Duration
+	// BackupKeep — сколько последних копий хранить в BackupDir.
+	BackupKeep int
+	// BackupS3 — необязательная выгрузка копий в S3-совместимое хранилище.
+	BackupS3 S3Config
+
+	// Notify — уведомления о провалах проверки, наплывах и ошибках в Discord, Slack или Matrix.
+	Notify NotifyConfig
+	// Alerts — письма о критических событиях: бот лишился прав администратора,
+	// токен недействителен, ошибки записи в хранилище, затяжной наплыв.
+	Alerts SMTPConfig
+
+	// APIURL — адрес Bot API; пустой — облачный DefaultAPIURL. Свой сервер
+	// telegram-bot-api снимает лимиты облака (например, на размер файлов).
+	APIURL string
+
+	// InstanceID — имя экземпляра бота для блокировок в общем хранилище, когда
+	// несколько экземпляров работают с одной базой postgres. Пустое — имя хоста
+	// со случайным суффиксом.
+	InstanceID string
+	// LeaderElection — получать обновления только ведущим экземпляром; остальные
+	// ждут и подхватывают polling, если ведущий пропал. Нужен STORAGE=postgres.
+	LeaderElection bool
+
+	// CASURL — адрес CAS (Combot Anti-Spam), который /check спрашивает о
+	// пользователе; пустой — не спрашивать. CAS_CHECK=on задаёт DefaultCASURL.
+	CASURL string
+
+	// PollTimeout — сколько getUpdates ждёт новых обновлений (long polling).
+	// Таймаут HTTP-клиента по умолчанию выставляется на 10 секунд больше.
+	PollTimeout time.Duration
+	// PollLimit — сколько обновлений забирать за раз (1–100). 0 — по умолчанию Telegram.
+	PollLimit int
+	// PollRetryDelay — пауза перед повтором getUpdates после ошибки.
+	PollRetryDelay time.Duration
+
+	// MaxCachedUsers — сколько пользователей держать в кэше недавних сообщений;
+	// самые давние вытесняются. 0 — без ограничения.
+	MaxCachedUsers int
+	// MessageCacheTTL — сколько помнить сообщения пользователей (для удаления при
+	// бане и подсчёта /ratelimit). 0 — по умолчанию, 60 секунд.
+	MessageCacheTTL time.Duration
+	// BanLogRetention — сколько хранить записи журнала банов. 0 — бессрочно.
+	BanLogRetention time.Duration
+	// StatsRetention — через сколько без событий удалять статистику чата. 0 — бессрочно.
+	StatsRetention time.Duration
+	// StaleChatAge — через сколько без обновлений из чата удалять его настройки
+	// и статистику. 0 — не удалять.
+	StaleChatAge time.Duration
+	// MaxAdminCache — сколько статусов участников держать в кэше проверки админов. 0 — без ограничения.
+	MaxAdminCache int
+
+	// AdminRefreshInterval — как часто обновлять списки администраторов активных
+	// чатов через getChatAdministrators. 0 — проверять каждого через getChatMember.
+	AdminRefreshInterval time.Duration
+
+	// ScoreWeights — веса эвристической оценки новых участников.
+	ScoreWeights ScoreWeights
+	// ScoreStrictThreshold — с какой оценки давать строгую проверку (0 — выкл.).
+	ScoreStrictThreshold int
+	// StrictCaptcha — тип проверки вместо простой кнопки при строгой проверке:
+	// по оценке, по фильтру имён в режиме strict и ночью в режиме strict.
+	StrictCaptcha string
+	// ScoreBanThreshold — с какой оценки банить без проверки (0 — выкл.).
+	ScoreBanThreshold int
+
+	// TrustedAccounts — как проверять вступивших с признаками TrustedAccountSigns:
+	// TrustSkip — без проверки, TrustSoften — одной кнопкой. Пустое — как всех.
+	TrustedAccounts string
+	// TrustedAccountSigns — признаки аккаунта, похожего на человека (SignUsername...).
+	TrustedAccountSigns []string
+	// OldAccountID — аккаунты с ID меньше этого считаются давними (признак SignOld).
+	OldAccountID int64
+}
+
+// DefaultConfig возвращает настройки по умолчанию.
+func DefaultConfig() Config {
+	return Config{
+		Storage:              StorageFile,
+		BoltFile:             "hamster.db",
+		SettingsFile:         "settings.json",
+		TimeoutFile:          "timeouts.json",
+		NameFilterFile:       "namefilters.json",
+		DisabledChatsFile:    "disabled_chats.json",
+		ScoreWeights:         DefaultScoreWeights(),
+		BackupDir:            "backups",
+		BackupKeep:           7,
+		ForeignPressMute:     10 * time.Minute,
+		CallbackBurstLimit:   6,
+		RaidWindow:           time.Minute,
+		RaidCaptcha:          CaptchaMath,
+		StrictCaptcha:        CaptchaSequence,
+		RaidTimeout:          30,
+		MaxPendingPerChat:    50,
+		PollTimeout:          defaultPollTimeout,
+		PollRetryDelay:       defaultPollRetryDelay,
+		MaxCachedUsers:       defaultMaxCachedUsers,
+		MessageCacheTTL:      defaultMessageCacheTTL,
+		MaxAdminCache:        defaultMaxAdminCache,
+		AdminRefreshInterval: defaultAdminRefresh,
+		TrustedAccountSigns:  defaultTrustSigns,
+		OldAccountID:         1_000_000_000,
+		NewcomerPeriod:       24 * time.Hour,
+	}
+}
+
+// ConfigFromEnv читает настройки из переменных окружения поверх значений по умолчанию.
+func ConfigFromEnv(logger *Logger) Config {
+	cfg := DefaultConfig()
+	cfg.ProbationPeriod = envMinutes("PROBATION_MINUTES", cfg.ProbationPeriod, logger)
+	cfg.MediaRestrictPeriod = envHours("MEDIA_RESTRICT_HOURS", cfg.MediaRestrictPeriod, logger)
+	cfg.ForwardFilterPeriod = envMinutes("FORWARD_FILTER_MINUTES", cfg.ForwardFilterPeriod, logger)
+	cfg.NewcomerPeriod = envHours("NEWCOMER_HOURS", cfg.NewcomerPeriod, logger)
+	cfg.ForeignPressLimit = envInt("FOREIGN_PRESS_LIMIT", cfg.ForeignPressLimit, logger)
+	cfg.ForeignPressMute = envMinutes("FOREIGN_PRESS_MUTE_MINUTES", cfg.ForeignPressMute, logger)
+	cfg.CallbackBurstLimit = envInt("CALLBACK_BURST_LIMIT", cfg.CallbackBurstLimit, logger)
+	cfg.MinClickDelay = envUnits("MIN_CLICK_DELAY_MS", cfg.MinClickDelay, time.Millisecond, logger)
+	cfg.RaidJoins = envInt("RAID_JOINS", cfg.RaidJoins, logger)
+	cfg.RaidWindow = envUnits("RAID_WINDOW_SECONDS", cfg.RaidWindow, time.Second, logger)
+	cfg.RaidTimeout = envInt("RAID_TIMEOUT", cfg.RaidTimeout, logger)
+	cfg.RaidLockChat = envBool("RAID_LOCK_CHAT", logger)
+	cfg.MaxPendingPerChat = envInt("MAX_PENDING_PER_CHAT", cfg.MaxPendingPerChat, logger)
+	if v := os.Getenv("RAID_CAPTCHA"); v != "" {
+		if _, ok := lookupChallenge(v); ok {
+			cfg.RaidCaptcha = v
+		} else {
+			logger.Warn("Неизвестный тип проверки RAID_CAPTCHA=%q, используем %s", v, cfg.RaidCaptcha)
+		}
+	}
+	if v := os.Getenv("STRICT_CAPTCHA"); v != "" {
+		if _, ok := lookupChallenge(v); ok {
+			cfg.StrictCaptcha = v
+		} else {
+			logger.Warn("Неизвестный тип проверки STRICT_CAPTCHA=%q, используем %s", v, cfg.StrictCaptcha)
+		}
+	}
+	if v := os.Getenv("STORAGE"); v != "" {
+		cfg.Storage = v
+	}
+	if v := os.Getenv("BOLT_FILE"); v != "" {
+		cfg.BoltFile = v
+	}
+	if v := os.Getenv("STORAGE_DSN"); v != "" {
+		cfg.StorageDSN = v
+	}
+	cfg.SettingsKey = os.Getenv("SETTINGS_KEY")
+	cfg.PrivacyKey = os.Getenv("PRIVACY_KEY")
+	if v := os.Getenv("SETTINGS_FILE"); v != "" {
+		cfg.SettingsFile = v
+	}
+	if v := os.Getenv("TIMEOUT_FILE"); v != "" {
+		cfg.TimeoutFile = v
+	}
+	if v := os.Getenv("NAMEFILTER_FILE"); v != "" {
+		cfg.NameFilterFile = v
+	}
+	if v := os.Getenv("DISABLED_CHATS_FILE"); v != "" {
+		cfg.DisabledChatsFile = v
+	}
+	if v := os.Getenv("BOT_OWNERS"); v != "" {
+		owners, err := ParseOwners(v)
+		if err != nil {
+			logger.Warn("Некорректное значение BOT_OWNERS: %v", err)
+		} else {
+			cfg.Owners = owners
+		}
+	}
+	cfg.AdminAPIAddr = os.Getenv("ADMIN_API_ADDR")
+	cfg.AdminAPIToken = os.Getenv("ADMIN_API_TOKEN")
+	cfg.DebugAddr = os.Getenv("DEBUG_ADDR")
+	cfg.Trace = traceConfigFromEnv(logger)
+	cfg.RecordUpdatesFile = os.Getenv("RECORD_UPDATES_FILE")
+	cfg.SentryDSN = os.Getenv("SENTRY_DSN")
+	cfg.SentryEnvironment = os.Getenv("SENTRY_ENVIRONMENT")
+	cfg.WebAppName = os.Getenv("WEBAPP_NAME")
+	cfg.WebAppAddr = os.Getenv("WEBAPP_ADDR")
+	cfg.PhrasesDir = os.Getenv("PHRASES_DIR")
+	if v := os.Getenv("BACKUP_DIR"); v != "" {
+		cfg.BackupDir = v
+	}
+	cfg.BackupInterval = envHours("BACKUP_INTERVAL_HOURS", cfg.BackupInterval, logger)
+	cfg.BackupKeep = envInt("BACKUP_KEEP", cfg.BackupKeep, logger)
+	cfg.BackupS3 = S3Config{
+		Endpoint:  os.Getenv("BACKUP_S3_ENDPOINT"),
+		Region:    os.Getenv("BACKUP_S3_REGION"),
+		Bucket:    os.Getenv("BACKUP_S3_BUCKET"),
+		Prefix:    os.Getenv("BACKUP_S3_PREFIX"),
+		AccessKey: os.Getenv("BACKUP_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("BACKUP_S3_SECRET_KEY"),
+	}
+	cfg.Notify = NotifyConfig{
+		DiscordWebhook:   os.Getenv("NOTIFY_DISCORD_WEBHOOK"),
+		SlackWebhook:     os.Getenv("NOTIFY_SLACK_WEBHOOK"),
+		MatrixHomeserver: os.Getenv("NOTIFY_MATRIX_HOMESERVER"),
+		MatrixToken:      os.Getenv("NOTIFY_MATRIX_TOKEN"),
+		MatrixRoom:       os.Getenv("NOTIFY_MATRIX_ROOM"),
+	}
+	for _, e := range strings.Split(os.Getenv("NOTIFY_EVENTS"), ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			cfg.Notify.Events = append(cfg.Notify.Events, e)
+		}
+	}
+	cfg.Alerts = SMTPConfig{
+		Addr:     os.Getenv("SMTP_ADDR"),
+		User:     os.Getenv("SMTP_USER"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+		Interval: envMinutes("ALERT_EMAIL_INTERVAL_MINUTES", 0, logger),
+	}
+	for _, to := range strings.Split(os.Getenv("ALERT_EMAIL"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			cfg.Alerts.To = append(cfg.Alerts.To, to)
+		}
+	}
+	cfg.APIURL = os.Getenv("TELEGRAM_API_URL")
+	cfg.InstanceID = os.Getenv("INSTANCE_ID")
+	cfg.LeaderElection = envBool("LEADER_ELECTION", logger)
+	if envBool("CAS_CHECK", logger) {
+		cfg.CASURL = DefaultCASURL
+	}
+	cfg.PollTimeout = envUnits("POLL_TIMEOUT_SECONDS", cfg.PollTimeout, time.Second, logger)
+	cfg.PollLimit = envInt("POLL_LIMIT", cfg.PollLimit, logger)
+	if cfg.PollLimit > maxPollLimit {
+		logger.Warn("POLL_LIMIT=%d больше допустимого, используем %d", cfg.PollLimit, maxPollLimit)
+		cfg.PollLimit = maxPollLimit
+	}
+	cfg.PollRetryDelay = envUnits("POLL_RETRY_MS", cfg.PollRetryDelay, time.Millisecond, logger)
+	cfg.MaxCachedUsers = envInt("MAX_CACHED_USERS", cfg.MaxCachedUsers, logger)
+	cfg.MessageCacheTTL = envUnits("MESSAGE_CACHE_SECONDS", cfg.MessageCacheTTL, time.Second, logger)
+	cfg.BanLogRetention = envUnits("BAN_LOG_DAYS", cfg.BanLogRetention, 24*time.Hour, logger)
+	cfg.StatsRetention = envUnits("STATS_DAYS", cfg.StatsRetention, 24*time.Hour, logger)
+	cfg.StaleChatAge = envUnits("STALE_CHAT_DAYS", cfg.StaleChatAge, 24*time.Hour, logger)
+	cfg.MaxAdminCache = envInt("MAX_ADMIN_CACHE", cfg.MaxAdminCache, logger)
+	cfg.AdminRefreshInterval = envMinutes("ADMIN_REFRESH_MINUTES", cfg.AdminRefreshInterval, logger)
+	if v := os.Getenv("SCORE_WEIGHTS"); v != "" {
+		w, err := ParseScoreWeights(v, cfg.ScoreWeights)
+		if err != nil {
+			logger.Warn("Некорректное значение SCORE_WEIGHTS: %v", err)
+		} else {
+			cfg.ScoreWeights = w
+		}
+	}
+	cfg.ScoreStrictThreshold = envInt("SCORE_STRICT_THRESHOLD", cfg.ScoreStrictThreshold, logger)
+	cfg.ScoreBanThreshold = envInt("SCORE_BAN_THRESHOLD", cfg.ScoreBanThreshold, logger)
+	switch v := strings.ToLower(os.Getenv("TRUSTED_ACCOUNTS")); v {
+	case "", "off":
+	case TrustSkip, TrustSoften:
+		cfg.TrustedAccounts = v
+	default:
+		logger.Warn("Некорректное значение TRUSTED_ACCOUNTS=%q, облегчённая проверка выключена", v)
+	}
+	if v := os.Getenv("TRUSTED_ACCOUNT_SIGNS"); v != "" {
+		signs, unknown := parseTrustSigns(v)
+		if len(unknown) > 0 {
+			logger.Warn("Неизвестные признаки в TRUSTED_ACCOUNT_SIGNS: %s", strings.Join(unknown, ", "))
+		}
+		cfg.TrustedAccountSigns = signs
+	}
+	cfg.OldAccountID = int64(envInt("OLD_ACCOUNT_ID", int(cfg.OldAccountID), logger))
+	return cfg
+}
+
+// Validate проверяет согласованность настроек без обращения к Telegram и к
+// хранилищу. Возвращает все найденные ошибки разом.
+func (c Config) Validate() error {
+	var errs []error
+	switch c.Storage {
+	case "", StorageFile:
+	case StorageBolt:
+		if c.BoltFile == "" {
+			errs = append(errs, errors.New("STORAGE=bolt требует BOLT_FILE"))
+		}
+	case StoragePostgres:
+		if c.StorageDSN == "" {
+			errs = append(errs, errors.New("STORAGE=postgres требует STORAGE_DSN"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("неизвестное хранилище STORAGE=%q", c.Storage))
+	}
+	if c.SettingsKey != "" {
+		if _, err := newSealer(c.SettingsKey); err != nil {
+			errs = append(errs, fmt.Errorf("SETTINGS_KEY: %w", err))
+		}
+	}
+	if c.LeaderElection && c.Storage != StoragePostgres {
+		errs = append(errs, errors.New("LEADER_ELECTION требует STORAGE=postgres"))
+	}
+	if c.AdminAPIAddr != "" && c.AdminAPIToken == "" {
+		errs = append(errs, errors.New("ADMIN_API_ADDR задан без ADMIN_API_TOKEN"))
+	}
+	if c.WebAppAddr != "" && c.WebAppName == "" {
+		errs = append(errs, errors.New("WEBAPP_ADDR задан без WEBAPP_NAME"))
+	}
+	if err := c.Notify.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Alerts.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.BackupS3.enabled() && (c.BackupS3.AccessKey == "" || c.BackupS3.SecretKey == "") {
+		errs = append(errs, errors.New("BACKUP_S3_BUCKET задан без ключей доступа"))
+	}
+	if c.SentryDSN != "" {
+		if _, err := NewSentryReporter(c.SentryDSN, "", "", nil, nil); err != nil {
+			errs = append(errs, fmt.Errorf("SENTRY_DSN: %w", err))
+		}
+	}
+	if c.APIURL != "" {
+		if u, err := url.Parse(c.APIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("некорректный TELEGRAM_API_URL=%q", c.APIURL))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+const (
+	defaultPollTimeout    = 30 * time.Second
+	defaultPollRetryDelay = time.Second
+	// maxPollLimit — больше обновлений за раз Telegram не отдаёт.
+	maxPollLimit = 100
+)
+
+// pollTimeout — PollTimeout или значение по умолчанию, если он не задан.
+func (c Config) pollTimeout() time.Duration {
+	if c.PollTimeout <= 0 {
+		return defaultPollTimeout
+	}
+	return c.PollTimeout
+}
+
+// pollRetryDelay — PollRetryDelay или значение по умолчанию, если она не задана.
+func (c Config) pollRetryDelay() time.Duration {
+	if c.PollRetryDelay <= 0 {
+		return defaultPollRetryDelay
+	}
+	return c.PollRetryDelay
+}
+
+// verifiedRetention — сколько помнить время верификации: самый длинный из фильтров новичков.
+func (c Config) verifiedRetention() time.Duration {
+	d := c.ProbationPeriod
+	if c.ForwardFilterPeriod > d {
+		d = c.ForwardFilterPeriod
+	}
+	if c.NewcomerPeriod > d {
+		d = c.NewcomerPeriod
+	}
+	return d
+}
+
+// traceConfigFromEnv читает стандартные переменные OpenTelemetry.
+func traceConfigFromEnv(logger *Logger) TraceConfig {
+	tc := TraceConfig{
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+	}
+	if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); tc.Endpoint == "" && base != "" {
+		tc.Endpoint = strings.TrimRight(base, "/") + "/v1/traces"
+	}
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); v != "" {
+		headers, err := ParseTraceHeaders(v)
+		if err != nil {
+			logger.Warn("Некорректное значение OTEL_EXPORTER_OTLP_HEADERS: %v", err)
+		} else {
+			tc.Headers = headers
+		}
+	}
+	return tc
+}
+
+// envInt читает неотрицательное целое из переменной окружения.
+func envInt(name string, def int, logger *Logger) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		logger.Warn("Некорректное значение %s=%q, используем %d", name, v, def)
+		return def
+	}
+	return n
+}
+
+// envBool читает флаг из переменной окружения (1/true/on и 0/false/off).
+func envBool(name string, logger *Logger) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return false
+	}
+	switch strings.ToLower(v) {
+	case "1", "true", "on", "yes":
+		return true
+	case "0", "false", "off", "no":
+		return false
+	}
+	logger.Warn("Некорректное значение %s=%q, используем false", name, v)
+	return false
+}
+
+// envMinutes читает целое число минут из переменной окружения.
+func envMinutes(name string, def time.Duration, logger *Logger) time.Duration {
+	return envUnits(name, def, time.Minute, logger)
+}
+
+// envHours читает целое число часов из переменной окружения.
+func envHours(name string, def time.Duration, logger *Logger) time.Duration {
+	return envUnits(name, def, time.Hour, logger)
+}
+
+func envUnits(name string, def, unit time.Duration, logger *Logger) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		logger.Warn("Некорректное значение %s=%q, используем %v", name, v, def)
+		return def
+	}
+	return time.Duration(n) * unit
+}
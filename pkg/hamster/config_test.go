@@ -0,0 +1,74 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("PROBATION_MINUTES", "15")
+	cfg := ConfigFromEnv(NewLogger())
+	if cfg.ProbationPeriod != 15*time.Minute {
+		t.Errorf("ожидалось 15m, получили %v", cfg.ProbationPeriod)
+	}
+}
+
+func TestConfigFromEnvInvalid(t *testing.T) {
+	t.Setenv("PROBATION_MINUTES", "abc")
+	cfg := ConfigFromEnv(NewLogger())
+	if cfg.ProbationPeriod != DefaultConfig().ProbationPeriod {
+		t.Errorf("некорректное значение должно давать значение по умолчанию, получили %v", cfg.ProbationPeriod)
+	}
+}
+
+func TestConfigFromEnvHours(t *testing.T) {
+	t.Setenv("MEDIA_RESTRICT_HOURS", "6")
+	cfg := ConfigFromEnv(NewLogger())
+	if cfg.MediaRestrictPeriod != 6*time.Hour {
+		t.Errorf("ожидалось 6h, получили %v", cfg.MediaRestrictPeriod)
+	}
+}
+
+func TestConfigPollTuning(t *testing.T) {
+	t.Setenv("POLL_TIMEOUT_SECONDS", "50")
+	t.Setenv("POLL_LIMIT", "500")
+	t.Setenv("POLL_RETRY_MS", "250")
+	cfg := ConfigFromEnv(NewLogger())
+	if cfg.PollTimeout != 50*time.Second || cfg.PollLimit != maxPollLimit || cfg.PollRetryDelay != 250*time.Millisecond {
+		t.Errorf("PollTimeout=%v PollLimit=%d PollRetryDelay=%v", cfg.PollTimeout, cfg.PollLimit, cfg.PollRetryDelay)
+	}
+	if (Config{}).pollTimeout() != defaultPollTimeout || (Config{}).pollRetryDelay() != defaultPollRetryDelay {
+		t.Error("нулевые значения означают значения по умолчанию")
+	}
+}
+
+func TestConfigLeaderElection(t *testing.T) {
+	t.Setenv("LEADER_ELECTION", "yes")
+	if !ConfigFromEnv(NewLogger()).LeaderElection {
+		t.Error("LEADER_ELECTION=yes должен включать выборы ведущего")
+	}
+	t.Setenv("LEADER_ELECTION", "maybe")
+	if ConfigFromEnv(NewLogger()).LeaderElection {
+		t.Error("некорректное значение должно оставлять выборы выключенными")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("настройки по умолчанию корректны, получили %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Storage = StoragePostgres
+	cfg.SettingsKey = "short"
+	cfg.AdminAPIAddr = ":8081"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("ожидали ошибки проверки")
+	}
+	for _, want := range []string{"STORAGE_DSN", "SETTINGS_KEY", "ADMIN_API_TOKEN"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("в ошибке нет %s: %v", want, err)
+		}
+	}
+}
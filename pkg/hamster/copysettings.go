@@ -0,0 +1,69 @@
+package hamster
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ==========================
+// Копирование настроек между чатами
+// ==========================
+
+// copiedSettings возвращает настройки src для другого чата dst. Остаётся
+// своим то, что относится только к самому чату: пауза проверки, отказ от
+// рассылок, сохранённые права на время наплыва, очередь проверок и политики
+// ссылок-приглашений (ссылки у каждого чата свои), а также то, что даёт
+// права или банит: модераторы, чёрный список и членство в федерации.
+func copiedSettings(src, dst ChatSettings) ChatSettings {
+	out := src.clone()
+	out.Disabled = dst.Disabled
+	out.NoBroadcast = dst.NoBroadcast
+	out.RaidLock = dst.RaidLock
+	out.JoinQueue = dst.JoinQueue
+	out.LinkPolicies = dst.LinkPolicies
+	out.Moderators = dst.Moderators
+	out.Blacklist = dst.Blacklist
+	out.Federation = dst.Federation
+	out.FederationOptOut = dst.FederationOptOut
+	return out
+}
+
+func (b *Bot) handleCopySettingsCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может копировать настройки", 5*time.Second)
+		return
+	}
+	if msg.SenderChat != nil {
+		// от имени чата не узнать, администратор ли автор в чате-источнике
+		b.sendTemporary(chatID, "❌ Отправьте команду от своего имени, а не анонимно", 10*time.Second)
+		return
+	}
+
+	source, err := strconv.ParseInt(commandArg(msg.Text, 1), 10, 64)
+	if err != nil || source == 0 {
+		b.sendTemporary(chatID, "⚙️ Использование: /copysettings <ID чата-источника>", 10*time.Second)
+		return
+	}
+	if source == chatID {
+		b.sendTemporary(chatID, "❌ Это и есть текущий чат", 5*time.Second)
+		return
+	}
+	if !b.isAdmin(b.ctx, source, msg.From.ID) {
+		b.sendTemporary(chatID, fmt.Sprintf("❌ Вы не администратор чата %d", source), 10*time.Second)
+		return
+	}
+	src := b.chatSettings(source)
+	if src.isZero() {
+		b.sendTemporary(chatID, fmt.Sprintf("📭 В чате %d настройки по умолчанию — копировать нечего", source), 10*time.Second)
+		return
+	}
+
+	b.updateChatSettings(chatID, func(c *ChatSettings) { *c = copiedSettings(src, *c) })
+	b.logger.Info("Администратор %d скопировал настройки чата %d в чат %d", msg.From.ID, source, chatID)
+	b.sendTemporary(chatID, fmt.Sprintf("✅ Настройки скопированы из чата %d (кроме паузы, рассылок, политик ссылок, модераторов, чёрного списка и федерации)", source), 10*time.Second)
+}
@@ -0,0 +1,49 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCopiedSettingsKeepsChatSpecific(t *testing.T) {
+	src := ChatSettings{Timeout: 60, CaptchaType: CaptchaMath, WelcomeTemplate: "Привет, {name}", Language: "ru",
+		NameFilters: []string{"spam"}, Disabled: true, LinkPolicies: map[string]string{"a": LinkBan}}
+	dst := ChatSettings{NoBroadcast: true, LinkPolicies: map[string]string{"b": LinkTrusted}, RaidLock: &ChatPermissions{CanSendMessages: true}}
+	got := copiedSettings(src, dst)
+	if got.Timeout != 60 || got.CaptchaType != CaptchaMath || got.WelcomeTemplate != src.WelcomeTemplate || got.NameFilters[0] != "spam" {
+		t.Errorf("настройки не скопированы: %+v", got)
+	}
+	if got.Disabled || !got.NoBroadcast || got.RaidLock == nil || got.LinkPolicies["b"] != LinkTrusted || got.LinkPolicies["a"] != "" {
+		t.Errorf("свои настройки чата должны сохраниться: %+v", got)
+	}
+
+	roles := copiedSettings(ChatSettings{Timeout: 60, Moderators: []int64{1}, Blacklist: []int64{2}, Federation: "fedA", FederationOptOut: true},
+		ChatSettings{Moderators: []int64{3}, Federation: "fedB"})
+	if len(roles.Moderators) != 1 || roles.Moderators[0] != 3 || len(roles.Blacklist) != 0 || roles.Federation != "fedB" || roles.FederationOptOut {
+		t.Errorf("модераторы, чёрный список и федерация не копируются: %+v", roles)
+	}
+
+	got.NameFilters[0] = "изменено"
+	if src.NameFilters[0] != "spam" {
+		t.Error("копия не должна делить срезы с источником")
+	}
+}
+
+func TestCopySettingsRequiresAdminInBoth(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	b.settings.Update(-200, func(c *ChatSettings) { c.Timeout = 90 })
+	cmd := func() {
+		b.handleCopySettingsCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/copysettings -200"})
+	}
+
+	cmd()
+	if b.chatSettings(-100).Timeout != 0 {
+		t.Fatal("без прав в чате-источнике настройки не копируются")
+	}
+	b.adminCache["-200:10"] = adminCacheEntry{status: "creator", expiresAt: time.Now().Add(time.Minute)}
+	cmd()
+	if b.chatSettings(-100).Timeout != 90 {
+		t.Errorf("администратору обоих чатов настройки копируются: %+v", b.chatSettings(-100))
+	}
+}
@@ -0,0 +1,93 @@
+package hamster
+
+import (
+	_ "embed"
+	"net/http"
+	"sync"
+)
+
+// ==========================
+// Веб-панель
+// ==========================
+
+// recentBansLimit — сколько последних банов держать в памяти для панели.
+const recentBansLimit = 100
+
+//go:embed web/dashboard.html
+var dashboardHTML []byte
+
+// banHistory — кольцевой буфер последних банов. Полный журнал пишется в Storage,
+// здесь только то, что нужно показать в панели без запросов к базе.
+type banHistory struct {
+	mu      sync.Mutex
+	entries []BanLogEntry
+	next    int
+	full    bool
+}
+
+func newBanHistory(size int) *banHistory {
+	return &banHistory{entries: make([]BanLogEntry, size)}
+}
+
+func (h *banHistory) add(e BanLogEntry) {
+	if h == nil || len(h.entries) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries[h.next] = e
+	h.next = (h.next + 1) % len(h.entries)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// remove удаляет баны, подходящие под match, и возвращает их число.
+func (h *banHistory) remove(match func(BanLogEntry) bool) int {
+	if h == nil || len(h.entries) == 0 {
+		return 0
+	}
+	kept := h.list()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = make([]BanLogEntry, len(h.entries))
+	h.next, h.full = 0, false
+	removed := 0
+	for i := len(kept) - 1; i >= 0; i-- {
+		if match(kept[i]) {
+			removed++
+			continue
+		}
+		h.entries[h.next] = kept[i]
+		h.next++
+	}
+	return removed
+}
+
+// list возвращает баны, новые первыми.
+func (h *banHistory) list() []BanLogEntry {
+	if h == nil {
+		return []BanLogEntry{}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.next
+	if h.full {
+		n = len(h.entries)
+	}
+	out := make([]BanLogEntry, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, h.entries[(h.next-i+len(h.entries))%len(h.entries)])
+	}
+	return out
+}
+
+// serveDashboard отдаёт страницу панели. Сама страница статична и не содержит данных:
+// токен вводится в браузере и передаётся в заголовке запросов к /api/.
+func serveDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	_, _ = w.Write(dashboardHTML)
+}
@@ -0,0 +1,65 @@
+package hamster
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBanHistoryRing(t *testing.T) {
+	h := newBanHistory(3)
+	if got := h.list(); len(got) != 0 {
+		t.Fatalf("пустая история: %v", got)
+	}
+	for i := int64(1); i <= 5; i++ {
+		h.add(BanLogEntry{UserID: i})
+	}
+	got := h.list()
+	if len(got) != 3 || got[0].UserID != 5 || got[1].UserID != 4 || got[2].UserID != 3 {
+		t.Errorf("ожидали 5,4,3, получили %+v", got)
+	}
+
+	var nilHistory *banHistory
+	nilHistory.add(BanLogEntry{UserID: 1})
+	if len(nilHistory.list()) != 0 {
+		t.Error("nil-история должна быть пустой")
+	}
+}
+
+func TestDashboardServedWithoutToken(t *testing.T) {
+	h := setupAdminBot().AdminHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "tg-hamster") {
+		t.Fatalf("панель: код %d", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("Content-Type: %s", rec.Header().Get("Content-Type"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/other", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("неизвестный путь: код %d, ожидали 404", rec.Code)
+	}
+}
+
+func TestAdminAPIRecentBans(t *testing.T) {
+	b := setupAdminBot()
+	b.recentBans = newBanHistory(10)
+	b.logBan(-100, 42, BanReasonTimeout)
+	h := b.AdminHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/api/bans", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("без токена: код %d", rec.Code)
+	}
+
+	rec = adminRequest(t, h, "GET", "/api/bans", "")
+	body := rec.Body.String()
+	if !strings.Contains(body, `"user_id":42`) || !strings.Contains(body, `"reason":"timeout"`) {
+		t.Errorf("список банов: %s", body)
+	}
+}
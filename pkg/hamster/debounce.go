@@ -0,0 +1,71 @@
+package hamster
+
+import (
+	"sync"
+	"time"
+)
+
+// ==========================
+// Отложенное сохранение
+// ==========================
+
+const (
+	// settingsSaveDelay — сколько ждать после последнего изменения настроек перед записью на диск.
+	settingsSaveDelay = 2 * time.Second
+	// settingsSaveMaxWait — дольше этого запись не откладывается даже при непрерывных изменениях.
+	settingsSaveMaxWait = 10 * time.Second
+)
+
+// debouncer объединяет серию вызовов Trigger в один вызов fn после паузы delay.
+type debouncer struct {
+	mu      sync.Mutex
+	delay   time.Duration
+	maxWait time.Duration
+	fn      func()
+
+	timer *time.Timer
+	first time.Time // время первого Trigger в текущей серии
+}
+
+func newDebouncer(delay, maxWait time.Duration, fn func()) *debouncer {
+	return &debouncer{delay: delay, maxWait: maxWait, fn: fn}
+}
+
+// Trigger откладывает вызов fn на delay, но не дальше maxWait от первого Trigger серии.
+func (d *debouncer) Trigger() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if d.timer == nil {
+		d.first = now
+		d.timer = time.AfterFunc(d.delay, d.fire)
+		return
+	}
+	wait := d.delay
+	if left := d.first.Add(d.maxWait).Sub(now); left < wait {
+		wait = left
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	d.timer.Reset(wait)
+}
+
+// Flush немедленно выполняет отложенный вызов, если он есть.
+func (d *debouncer) Flush() {
+	d.mu.Lock()
+	pending := d.timer != nil && d.timer.Stop()
+	d.timer = nil
+	d.mu.Unlock()
+	if pending {
+		d.fn()
+	}
+}
+
+func (d *debouncer) fire() {
+	d.mu.Lock()
+	d.timer = nil
+	d.mu.Unlock()
+	d.fn()
+}
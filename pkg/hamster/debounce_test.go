@@ -0,0 +1,72 @@
+package hamster
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDebouncerCoalescesBursts(t *testing.T) {
+	var calls int32
+	d := newDebouncer(30*time.Millisecond, time.Second, func() { atomic.AddInt32(&calls, 1) })
+
+	for i := 0; i < 5; i++ {
+		d.Trigger()
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("серия изменений должна дать одну запись, получили %d", got)
+	}
+}
+
+func TestDebouncerMaxWait(t *testing.T) {
+	var calls int32
+	d := newDebouncer(50*time.Millisecond, 60*time.Millisecond, func() { atomic.AddInt32(&calls, 1) })
+
+	// изменения идут чаще delay, но запись не откладывается дольше maxWait
+	deadline := time.Now().Add(150 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		d.Trigger()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&calls); got == 0 {
+		t.Error("при непрерывных изменениях запись должна произойти по maxWait")
+	}
+	d.Flush()
+}
+
+func TestDebouncerFlush(t *testing.T) {
+	var calls int32
+	d := newDebouncer(time.Hour, time.Hour, func() { atomic.AddInt32(&calls, 1) })
+
+	d.Flush()
+	if got := atomic.LoadInt32(&calls); got != 0 {
+		t.Errorf("Flush без изменений не должен ничего записывать, получили %d", got)
+	}
+	d.Trigger()
+	d.Flush()
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Flush должен сразу выполнить отложенную запись, получили %d", got)
+	}
+}
+
+func TestSaveSettingsDebounced(t *testing.T) {
+	b := setupBot()
+	file := t.TempDir() + "/settings.json"
+	b.storage = newFileStorage(file, b.logger)
+	b.settingsSaver = newDebouncer(time.Hour, time.Hour, b.writeSettings)
+
+	b.settings.SetTimeout(1, 30)
+	b.saveSettings()
+	if _, err := os.Stat(file); err == nil {
+		t.Fatal("запись должна быть отложена")
+	}
+	b.FlushSettings()
+
+	chats, err := newFileStorage(file, b.logger).LoadSettings()
+	if err != nil || chats[1] == nil || chats[1].Timeout != 30 {
+		t.Errorf("после FlushSettings настройки должны быть на диске: %v %v", err, chats)
+	}
+}
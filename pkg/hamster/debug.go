@@ -0,0 +1,86 @@
+package hamster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// ==========================
+// Отладочный сервер (pprof и размеры состояния)
+// ==========================
+
+// DebugState — размеры внутреннего состояния для поиска утечек памяти и горутин.
+type DebugState struct {
+	Caches       CacheMetrics `json:"caches"`
+	APIQueue     int          `json:"api_queue"`     // запросов к Telegram ждут обработчика
+	Broadcasts   int          `json:"broadcasts"`    // рассылок в очереди
+	SentMessages int          `json:"sent_messages"` // неудалённых сообщений бота
+	Goroutines   int          `json:"goroutines"`
+	HeapAlloc    uint64       `json:"heap_alloc"` // байт
+	HeapObjects  uint64       `json:"heap_objects"`
+	NumGC        uint32       `json:"num_gc"`
+}
+
+// DebugState возвращает текущие размеры состояния и данные рантайма.
+func (b *Bot) DebugState() DebugState {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	s := DebugState{
+		Caches:      b.CacheMetrics(),
+		Broadcasts:  len(b.broadcasts),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapObjects: mem.HeapObjects,
+		NumGC:       mem.NumGC,
+	}
+	if q, ok := b.api.(*queuedAPI); ok {
+		s.APIQueue = q.depth()
+	}
+	b.sent.mu.Lock()
+	for _, list := range b.sent.m {
+		s.SentMessages += len(list)
+	}
+	b.sent.mu.Unlock()
+	return s
+}
+
+// DebugHandler — net/http/pprof под /debug/pprof/ и DebugState под /debug/state.
+func (b *Bot) DebugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/state", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, b.DebugState())
+	})
+	return mux
+}
+
+// ServeDebug запускает отладочный сервер на DebugAddr до отмены ctx. Токена у
+// него нет: слушайте только локальный адрес.
+func (b *Bot) ServeDebug(ctx context.Context) {
+	if b.cfg.DebugAddr == "" {
+		return
+	}
+	srv := &http.Server{
+		Addr:              b.cfg.DebugAddr,
+		Handler:           b.DebugHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	b.logger.Info("🔧 Отладочный сервер слушает %s", b.cfg.DebugAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		b.logger.Error("Отладочный сервер остановлен: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package hamster
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDebugState(t *testing.T) {
+	b := setupBot()
+	b.progressStore.add(&progressData{chatID: -1, userID: 42, greetMsgID: 7})
+	b.sent.add(-1, 10, time.Now())
+	b.sent.add(-1, 11, time.Now())
+
+	rec := httptest.NewRecorder()
+	b.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/state", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ожидали 200, получили %d", rec.Code)
+	}
+	var s DebugState
+	if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Caches.Pending != 1 || s.SentMessages != 2 || s.Goroutines == 0 {
+		t.Errorf("неверное состояние: %+v", s)
+	}
+
+	rec = httptest.NewRecorder()
+	b.DebugHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/goroutine?debug=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("pprof должен отвечать 200, получили %d", rec.Code)
+	}
+}
@@ -0,0 +1,29 @@
+// Package hamster — движок проверки новых участников Telegram-групп.
+//
+// Bot приветствует вошедших, предлагает пройти проверку (кнопка, пример,
+// сетка эмодзи — см. Challenge) и банит или исключает тех, кто не ответил
+// за отведённое время. Настройки хранятся по чатам и управляются командами
+// администраторов (/timeout, /namefilter, /hamster), REST API или вручную
+// через файл настроек.
+//
+// Минимальное встраивание:
+//
+//	cfg := hamster.ConfigFromEnv(logger)
+//	b, err := hamster.NewBot(token, cfg,
+//		hamster.WithLogger(logger),
+//		hamster.WithHTTPClient(client),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer b.Close()
+//	go b.RunBroadcasts(ctx)
+//	b.StartWithContext(ctx) // polling до отмены ctx
+//
+// Config можно заполнить и вручную; нулевые значения означают значения по
+// умолчанию. Хранилище выбирается по Config.Storage или передаётся готовым
+// через WithStorage. Фоновые задачи (WatchSettings, RunBackups,
+// RunBroadcasts, RunAdminRefresh, ServeAdminAPI, ServeDebug, RunTracing,
+// CleanupOldMessages, SweepExpiredVerifications) запускает приложение —
+// пример есть в cmd/tg-hamster.
+package hamster
@@ -0,0 +1,112 @@
+package hamster
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/teleta/tg-hamster/internal/telegramtest"
+)
+
+// startE2E запускает бота против поддельного Bot API.
+func startE2E(t *testing.T) (*Bot, *telegramtest.Server) {
+	t.Helper()
+	srv := telegramtest.NewServer()
+	cfg := DefaultConfig()
+	cfg.APIURL = srv.URL
+	cfg.SettingsFile = filepath.Join(t.TempDir(), "settings.json")
+	cfg.TimeoutFile, cfg.NameFilterFile, cfg.DisabledChatsFile = "", "", ""
+	cfg.PollTimeout = time.Second
+	b, err := NewBot("TOKEN", cfg, WithLogger(NewLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { b.StartWithContext(ctx); close(done) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		b.Close()
+		srv.Close()
+	})
+	return b, srv
+}
+
+func joinUpdate(chatID, userID int64) Update {
+	user := &User{ID: userID, FirstName: "Ann"}
+	return Update{Message: &Message{MessageID: 100, Chat: Chat{ID: chatID, Type: "supergroup"}, From: user, NewChatMembers: []*User{user}}}
+}
+
+// greetingButton возвращает callback_data первой кнопки приветствия.
+func greetingButton(t *testing.T, c telegramtest.Call) string {
+	t.Helper()
+	markup, _ := c.Params["reply_markup"].(map[string]interface{})
+	rows, _ := markup["inline_keyboard"].([]interface{})
+	if len(rows) == 0 {
+		t.Fatalf("в приветствии нет кнопок: %v", c.Params)
+	}
+	btn := rows[0].([]interface{})[0].(map[string]interface{})
+	return btn["callback_data"].(string)
+}
+
+func TestE2EJoinAndPass(t *testing.T) {
+	t.Parallel()
+	_, srv := startE2E(t)
+	srv.PushUpdate(joinUpdate(-100, 42))
+
+	greet, ok := srv.WaitFor("sendMessage", 5*time.Second, func(c telegramtest.Call) bool {
+		return c.Int("chat_id") == -100 && c.Params["reply_markup"] != nil
+	})
+	if !ok {
+		t.Fatal("бот не прислал приветствие с кнопкой")
+	}
+	data := greetingButton(t, greet)
+	if !strings.HasPrefix(data, "click:42:") {
+		t.Fatalf("кнопка не для вступившего: %s", data)
+	}
+
+	srv.PushUpdate(Update{Callback: &Callback{ID: "cb1", From: &User{ID: 42}, Data: data,
+		Message: &Message{MessageID: greet.MessageID, Chat: Chat{ID: -100, Type: "supergroup"}}}})
+	if _, ok := srv.WaitFor("answerCallbackQuery", 5*time.Second, nil); !ok {
+		t.Fatal("бот не ответил на нажатие")
+	}
+	if _, ok := srv.WaitFor("deleteMessage", 5*time.Second, func(c telegramtest.Call) bool { return c.Int("message_id") == greet.MessageID }); !ok {
+		t.Error("после прохождения приветствие удаляется")
+	}
+	if bans := srv.Calls("banChatMember"); len(bans) != 0 {
+		t.Errorf("прошедшего проверку не банят: %v", bans)
+	}
+}
+
+func TestE2EJoinTimeoutBan(t *testing.T) {
+	t.Parallel()
+	b, srv := startE2E(t)
+	b.settings.SetTimeout(-100, MinTimeoutSec)
+	srv.PushUpdate(joinUpdate(-100, 43))
+
+	if _, ok := srv.WaitFor("sendMessage", 5*time.Second, nil); !ok {
+		t.Fatal("бот не прислал приветствие")
+	}
+	ban, ok := srv.WaitFor("banChatMember", time.Duration(MinTimeoutSec+5)*time.Second, nil)
+	if !ok {
+		t.Fatal("не нажавшего кнопку банят по таймауту")
+	}
+	if ban.Int("chat_id") != -100 || ban.Int("user_id") != 43 {
+		t.Errorf("забанен не тот: %v", ban.Params)
+	}
+	// бан записывается в журнал уже после запроса к Telegram
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		entries, err := b.banLog(BanLogQuery{ChatID: -100})
+		if err == nil && len(entries) == 1 && entries[0].Reason == BanReasonTimeout {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("бан по таймауту не попал в журнал: %v, %v", entries, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
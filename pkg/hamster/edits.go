@@ -0,0 +1,30 @@
+package hamster
+
+// ==========================
+// Проверка отредактированных сообщений
+// ==========================
+
+// Спамеры пишут безобидное сообщение, а после проверки дописывают в него
+// ссылку. Поэтому правки новичков проходят те же фильтры ссылок и стоп-слов,
+// что и новые сообщения.
+
+// handleEditedMessage удаляет отредактированное сообщение недавно прошедшего
+// проверку, если в нём появилась ссылка или стоп-слово.
+func (b *Bot) handleEditedMessage(msg *Message) {
+	if msg.From == nil || msg.SenderChat != nil || b.isSelf(msg.From) {
+		return
+	}
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	if b.inProbation(chatID, userID, b.cfg.ProbationPeriod) && b.hasForbiddenLinks(msg) {
+		b.logger.Info("Удаляем отредактированное сообщение новичка %d в чате %d: ссылка", userID, chatID)
+		b.safeDeleteMessage(b.ctx, chatID, msg.MessageID)
+		return
+	}
+	cs := b.chatSettings(chatID)
+	if len(cs.SpamWords) == 0 || !b.inProbation(chatID, userID, b.cfg.NewcomerPeriod) {
+		return
+	}
+	if word, ok := matchSpamWords(cs.SpamWords, msg.Text+"\n"+msg.Caption); ok {
+		b.punishSpamWord(msg, cs, word)
+	}
+}
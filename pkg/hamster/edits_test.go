@@ -0,0 +1,53 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEditedMessageLinks(t *testing.T) {
+	b := setupBot()
+	b.cfg.ProbationPeriod = 10 * time.Minute
+	b.verified = newVerifiedUsers()
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = append(deleted, msgID) }
+
+	edit := Message{MessageID: 5, Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "привет, заходите t.me/spam"}
+	b.handleUpdate(Update{EditedMessage: &edit})
+	if len(deleted) != 0 {
+		t.Fatal("правки старых участников не проверяются")
+	}
+
+	b.verified.mark(1, 42, time.Now())
+	b.handleUpdate(Update{EditedMessage: &edit})
+	if len(deleted) != 1 || deleted[0] != 5 {
+		t.Errorf("дописанная правкой ссылка новичка удаляется: %v", deleted)
+	}
+}
+
+func TestEditedMessageSpamWords(t *testing.T) {
+	b := setupBot()
+	b.cfg.NewcomerPeriod = time.Hour
+	b.verified = newVerifiedUsers()
+	b.settings.Update(-100, func(c *ChatSettings) { c.SpamWords = []string{"казино"}; c.SpamWordsAction = SpamWordsBan })
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = append(deleted, msgID) }
+	banned := false
+	fakeOf(b).ban = func(chatID, userID int64) { banned = true }
+
+	b.markVerified(-100, 42)
+	for i := int64(1); i <= defaultSpamWordsMessages; i++ {
+		b.applySpamWords(&Message{MessageID: i, Chat: Chat{ID: -100}, From: &User{ID: 42}, Text: "привет"})
+	}
+	b.handleEditedMessage(&Message{MessageID: 2, Chat: Chat{ID: -100}, From: &User{ID: 42}, Text: "лучшее казино"})
+	if len(deleted) != 1 || deleted[0] != 2 || !banned {
+		t.Errorf("правка со стоп-словом удаляется и наказывается и после первых сообщений: %v, бан %v", deleted, banned)
+	}
+}
+
+func TestUpdateContextEdited(t *testing.T) {
+	kind, chatID, userID := updateContext(Update{EditedMessage: &Message{Chat: Chat{ID: -5}, From: &User{ID: 7}}})
+	if kind != "edited_message" || chatID != -5 || userID != 7 {
+		t.Errorf("updateContext: %q %d %d", kind, chatID, userID)
+	}
+}
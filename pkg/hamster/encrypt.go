@@ -0,0 +1,86 @@
+package hamster
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ==========================
+// Шифрование файла настроек (AES-GCM)
+// ==========================
+
+// encryptedPrefix отмечает зашифрованный файл: дальше идёт base64(nonce || шифротекст).
+var encryptedPrefix = []byte("tg-hamster:aes-gcm:v1:")
+
+// sealer шифрует и расшифровывает содержимое файла состояния.
+type sealer struct {
+	aead cipher.AEAD
+}
+
+// newSealer создаёт шифратор из ключа в base64 или hex (16, 24 или 32 байта).
+func newSealer(key string) (*sealer, error) {
+	raw, err := decodeKey(strings.TrimSpace(key))
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+func decodeKey(key string) ([]byte, error) {
+	validLen := func(b []byte) bool { return len(b) == 16 || len(b) == 24 || len(b) == 32 }
+	if raw, err := hex.DecodeString(key); err == nil && validLen(raw) {
+		return raw, nil
+	}
+	if raw, err := base64.StdEncoding.DecodeString(key); err == nil && validLen(raw) {
+		return raw, nil
+	}
+	return nil, errors.New("ключ должен быть 16, 24 или 32 байта в hex или base64")
+}
+
+// seal шифрует данные, каждый раз со случайным nonce.
+func (s *sealer) seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := s.aead.Seal(nonce, nonce, plain, encryptedPrefix)
+	out := make([]byte, 0, len(encryptedPrefix)+base64.StdEncoding.EncodedLen(len(sealed)))
+	out = append(out, encryptedPrefix...)
+	return base64.StdEncoding.AppendEncode(out, sealed), nil
+}
+
+// open расшифровывает данные, записанные seal.
+func (s *sealer) open(data []byte) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data[len(encryptedPrefix):])))
+	if err != nil {
+		return nil, fmt.Errorf("повреждённый зашифрованный файл: %w", err)
+	}
+	n := s.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errors.New("повреждённый зашифрованный файл")
+	}
+	plain, err := s.aead.Open(nil, sealed[:n], sealed[n:], encryptedPrefix)
+	if err != nil {
+		return nil, errors.New("не удалось расшифровать: неверный ключ или файл изменён")
+	}
+	return plain, nil
+}
+
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedPrefix)
+}
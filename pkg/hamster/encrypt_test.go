@@ -0,0 +1,145 @@
+package hamster
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSettingsKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestSealerRoundTrip(t *testing.T) {
+	s, err := newSealer(testSettingsKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := s.seal([]byte(`{"chat": -100}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isEncrypted(sealed) || bytes.Contains(sealed, []byte("-100")) {
+		t.Errorf("данные должны быть зашифрованы: %s", sealed)
+	}
+	plain, err := s.open(sealed)
+	if err != nil || string(plain) != `{"chat": -100}` {
+		t.Errorf("расшифровка не удалась: %q %v", plain, err)
+	}
+
+	other, _ := newSealer("AAECAwQFBgcICQoLDA0ODw==") // 16 байт в base64
+	if _, err := other.open(sealed); err == nil {
+		t.Error("чужой ключ не должен расшифровывать файл")
+	}
+}
+
+func TestNewSealerRejectsBadKey(t *testing.T) {
+	for _, key := range []string{"", "short", "0011"} {
+		if _, err := newSealer(key); err == nil {
+			t.Errorf("ключ %q должен отклоняться", key)
+		}
+	}
+}
+
+func TestFileStorageEncrypted(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "settings.json")
+
+	// открытый файл прежней версии читается и шифруется при записи
+	plain := newFileStorage(file, NewLogger())
+	if err := plain.SaveSettings(map[int64]*ChatSettings{-100: {Timeout: 30}}); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := OpenStorage(Config{Storage: StorageFile, SettingsFile: file, SettingsKey: testSettingsKey}, NewLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := st.(*fileStorage)
+	chats, err := enc.LoadSettings()
+	if err != nil || chats[-100].Timeout != 30 {
+		t.Fatalf("открытый файл не прочитан: %v %v", chats, err)
+	}
+	if err := enc.SaveSettings(chats); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(file)
+	if !isEncrypted(content) || bytes.Contains(content, []byte("-100")) {
+		t.Errorf("после записи файл должен быть зашифрован: %s", content)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0600 {
+		t.Errorf("зашифрованный файл должен быть доступен только владельцу, права %v", info.Mode().Perm())
+	}
+
+	if chats, err := enc.LoadSettings(); err != nil || chats[-100].Timeout != 30 {
+		t.Errorf("зашифрованный файл не прочитан: %v %v", chats, err)
+	}
+	if _, err := plain.LoadSettings(); err == nil {
+		t.Error("без ключа зашифрованный файл читаться не должен")
+	}
+}
+
+func TestFileStorageEncryptedBanLog(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "settings.json")
+	banFile := filepath.Join(dir, "ban_log.jsonl")
+	open := func(key string) *fileStorage {
+		st, err := OpenStorage(Config{Storage: StorageFile, SettingsFile: file, SettingsKey: key}, NewLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.(*fileStorage)
+	}
+
+	// строка прежней версии — открытая и доступная всем
+	plain := open("")
+	if err := plain.LogBan(BanLogEntry{ChatID: -100, UserID: 111111, Reason: BanReasonTimeout, At: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(banFile, 0644)
+
+	enc := open(testSettingsKey)
+	if err := enc.LogBan(BanLogEntry{ChatID: -100, UserID: 222222, Reason: BanReasonSpamWords, At: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(banFile)
+	if bytes.Contains(content, []byte("222222")) || bytes.Contains(content, []byte(BanReasonSpamWords)) {
+		t.Errorf("новая строка журнала должна быть зашифрована: %s", content)
+	}
+	if info, _ := os.Stat(banFile); info.Mode().Perm() != 0600 {
+		t.Errorf("журнал банов должен быть доступен только владельцу, права %v", info.Mode().Perm())
+	}
+
+	if got, err := enc.BanLog(BanLogQuery{ChatID: -100}); err != nil || len(got) != 2 || got[0].UserID != 222222 {
+		t.Errorf("с ключом читаются обе строки: %+v %v", got, err)
+	}
+	if got, _ := plain.BanLog(BanLogQuery{ChatID: -100}); len(got) != 1 || got[0].UserID != 111111 {
+		t.Errorf("без ключа читаются только открытые строки: %+v", got)
+	}
+	if n, err := enc.ForgetUser(222222); err != nil || n != 1 {
+		t.Errorf("зашифрованная строка должна удаляться: %d %v", n, err)
+	}
+	if got, _ := enc.BanLog(BanLogQuery{ChatID: -100}); len(got) != 1 || got[0].UserID != 111111 {
+		t.Errorf("после удаления остаётся открытая строка: %+v", got)
+	}
+}
+
+func TestFileStorageStateFilesPrivate(t *testing.T) {
+	dir := t.TempDir()
+	st, err := OpenStorage(Config{Storage: StorageFile, SettingsFile: filepath.Join(dir, "settings.json")}, NewLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := st.(*fileStorage)
+	if err := fs.SaveSentMessages(map[int64][]SentMessage{-100: {{MsgID: 1, At: time.Now()}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.SaveUpdateOffset(UpdateOffset{Offset: 5, At: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"sent_messages.json", "update_offset.json"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("%s должен быть доступен только владельцу: %v %v", name, info, err)
+		}
+	}
+}
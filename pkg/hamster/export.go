@@ -0,0 +1,201 @@
+package hamster
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Выгрузка данных модерации
+// ==========================
+
+// Форматы выгрузки.
+const (
+	ExportJSON = "json"
+	ExportCSV  = "csv"
+)
+
+// ChatExport — всё, что бот хранит о чате: для резервных копий по чатам и
+// анализа во внешних инструментах. Для переноса состояния целиком служит
+// ExportState.
+type ChatExport struct {
+	ChatID   int64            `json:"chat_id"`
+	Settings ChatSettings     `json:"settings"`
+	Stats    ChatStats        `json:"stats"`
+	Verified []VerifiedExport `json:"verified"`
+	Bans     []BanLogEntry    `json:"bans"`
+}
+
+// VerifiedExport — участник, прошедший проверку, и когда.
+type VerifiedExport struct {
+	UserID int64     `json:"user_id"`
+	At     time.Time `json:"at"`
+}
+
+// chatExport собирает выгрузку одного чата.
+func (b *Bot) chatExport(chatID int64, verified map[string]time.Time) (ChatExport, error) {
+	e := ChatExport{ChatID: chatID, Settings: b.chatSettings(chatID), Verified: []VerifiedExport{}}
+	if b.stats != nil {
+		e.Stats = b.stats.Get(chatID)
+	}
+	for key, at := range verified {
+		if c, u, ok := parseVerifiedKey(key); ok && c == chatID {
+			e.Verified = append(e.Verified, VerifiedExport{UserID: u, At: at})
+		}
+	}
+	sort.Slice(e.Verified, func(i, j int) bool { return e.Verified[i].At.Before(e.Verified[j].At) })
+	bans, err := b.banLog(BanLogQuery{ChatID: chatID})
+	if err != nil {
+		return e, fmt.Errorf("журнал банов чата %d: %w", chatID, err)
+	}
+	e.Bans = bans
+	if e.Bans == nil {
+		e.Bans = []BanLogEntry{}
+	}
+	return e, nil
+}
+
+// ExportChats пишет в w выгрузку чатов chatIDs (пусто — всех известных) в
+// формате ExportJSON или ExportCSV (tg-hamster export-data, /export).
+func (b *Bot) ExportChats(w io.Writer, chatIDs []int64, format string) error {
+	if format != ExportJSON && format != ExportCSV {
+		return fmt.Errorf("формат выгрузки должен быть %q или %q", ExportJSON, ExportCSV)
+	}
+	if len(chatIDs) == 0 {
+		for _, c := range b.knownChats() {
+			chatIDs = append(chatIDs, c.ChatID)
+		}
+	}
+	var verified map[string]time.Time
+	if b.verified != nil {
+		verified = b.verified.snapshot()
+	}
+	chats := make([]ChatExport, 0, len(chatIDs))
+	for _, id := range chatIDs {
+		e, err := b.chatExport(id, verified)
+		if err != nil {
+			return err
+		}
+		chats = append(chats, e)
+	}
+	if format == ExportJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(chats)
+	}
+	return writeExportCSV(w, chats)
+}
+
+// writeExportCSV пишет выгрузку одной таблицей: section — settings, stats,
+// verified или ban. Настройки и счётчики — парами name/value, у банов name —
+// причина, value — ID забанившего администратора.
+func writeExportCSV(w io.Writer, chats []ChatExport) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"chat_id", "section", "user_id", "at", "name", "value"})
+	for _, c := range chats {
+		chat := strconv.FormatInt(c.ChatID, 10)
+		sections := []struct {
+			name string
+			v    interface{}
+		}{{"settings", c.Settings}, {"stats", c.Stats}}
+		for _, s := range sections {
+			fields, err := jsonFields(s.v)
+			if err != nil {
+				return err
+			}
+			for _, f := range fields {
+				_ = cw.Write([]string{chat, s.name, "", "", f[0], f[1]})
+			}
+		}
+		for _, v := range c.Verified {
+			_ = cw.Write([]string{chat, "verified", strconv.FormatInt(v.UserID, 10), v.At.UTC().Format(time.RFC3339), "", ""})
+		}
+		for _, e := range c.Bans {
+			by := ""
+			if e.By != 0 {
+				by = strconv.FormatInt(e.By, 10)
+			}
+			_ = cw.Write([]string{chat, "ban", strconv.FormatInt(e.UserID, 10), e.At.UTC().Format(time.RFC3339), e.Reason, by})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// jsonFields раскладывает структуру на пары «поле JSON — значение» по
+// алфавиту; строки — без кавычек, остальное — как в JSON.
+func jsonFields(v interface{}) ([][2]string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	out := make([][2]string, 0, len(m))
+	for k, val := range m {
+		var s string
+		if json.Unmarshal(val, &s) != nil {
+			s = string(val)
+		}
+		out = append(out, [2]string{k, s})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+	return out, nil
+}
+
+// ==========================
+// Команда /export
+// ==========================
+
+// handleExportCommand присылает администратору выгрузку чата файлом в личку:
+// в группе её увидели бы все участники.
+func (b *Bot) handleExportCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может выгружать данные чата", 5*time.Second)
+		return
+	}
+	if msg.SenderChat != nil {
+		b.sendTemporary(chatID, "❌ Анонимному администратору некуда прислать файл — отправьте команду от своего имени", 10*time.Second)
+		return
+	}
+	format := strings.ToLower(commandArg(msg.Text, 1))
+	if format == "" {
+		format = ExportJSON
+	}
+	if format != ExportJSON && format != ExportCSV {
+		b.sendTemporary(chatID, "⚙️ Использование: /export [json|csv]", 5*time.Second)
+		return
+	}
+	if err := b.sendExport(msg.From.ID, []int64{chatID}, format); err != nil {
+		b.sendTemporary(chatID, "❌ Не удалось отправить выгрузку в личку — напишите боту /start и повторите", 10*time.Second)
+		return
+	}
+	b.sendTemporary(chatID, "📤 Выгрузка отправлена в личные сообщения", 5*time.Second)
+}
+
+// sendExport отправляет выгрузку чатов файлом в чат to.
+func (b *Bot) sendExport(to int64, chatIDs []int64, format string) error {
+	var buf bytes.Buffer
+	if err := b.ExportChats(&buf, chatIDs, format); err != nil {
+		b.logger.Warn("Не удалось выгрузить чаты %v: %v", chatIDs, err)
+		return err
+	}
+	name := "hamster-export-" + time.Now().UTC().Format("20060102-150405") + "." + format
+	if len(chatIDs) == 1 {
+		name = fmt.Sprintf("hamster-%d-%s.%s", chatIDs[0], time.Now().UTC().Format("20060102-150405"), format)
+	}
+	return b.safeSendDocument(b.ctx, to, name, buf.Bytes(), "📤 Выгрузка данных модерации")
+}
@@ -0,0 +1,102 @@
+package hamster
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupExportBot() *Bot {
+	b := setupBot()
+	b.stats = NewStats()
+	b.verified = newVerifiedUsers()
+	b.recentBans = newBanHistory(10)
+	b.settings.Update(-100, func(c *ChatSettings) { c.Timeout = 45; c.Language = "ru" })
+	b.stats.add(-100, func(c *ChatStats) { c.Joins = 3; c.Passed = 2 })
+	b.verified.mark(-100, 7, time.Now())
+	b.verified.mark(-200, 8, time.Now())
+	b.logBan(-100, 9, BanReasonTimeout)
+	b.logBan(-200, 10, BanReasonTimeout)
+	return b
+}
+
+func TestExportChatsJSON(t *testing.T) {
+	b := setupExportBot()
+	var buf bytes.Buffer
+	if err := b.ExportChats(&buf, []int64{-100}, ExportJSON); err != nil {
+		t.Fatal(err)
+	}
+	var chats []ChatExport
+	if err := json.Unmarshal(buf.Bytes(), &chats); err != nil {
+		t.Fatal(err)
+	}
+	if len(chats) != 1 {
+		t.Fatalf("ожидали один чат: %+v", chats)
+	}
+	c := chats[0]
+	if c.Settings.Timeout != 45 || c.Stats.Joins != 3 || len(c.Verified) != 1 || c.Verified[0].UserID != 7 ||
+		len(c.Bans) != 1 || c.Bans[0].UserID != 9 {
+		t.Errorf("выгрузка чата: %+v", c)
+	}
+}
+
+func TestExportChatsCSV(t *testing.T) {
+	b := setupExportBot()
+	var buf bytes.Buffer
+	if err := b.ExportChats(&buf, []int64{-100}, ExportCSV); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	has := func(want ...string) bool {
+		for _, r := range rows {
+			if strings.Join(r, ",") == strings.Join(want, ",") {
+				return true
+			}
+		}
+		return false
+	}
+	if !has("-100", "settings", "", "", "timeout", "45") || !has("-100", "settings", "", "", "language", "ru") ||
+		!has("-100", "stats", "", "", "joins", "3") {
+		t.Errorf("настройки и статистика: %v", rows)
+	}
+	sections := map[string]int{}
+	for _, r := range rows[1:] {
+		sections[r[1]]++
+		if r[0] != "-100" {
+			t.Errorf("строка чужого чата: %v", r)
+		}
+	}
+	if sections["verified"] != 1 || sections["ban"] != 1 {
+		t.Errorf("верификации и баны: %v", rows)
+	}
+	if err := b.ExportChats(&buf, nil, "xml"); err == nil {
+		t.Error("неизвестный формат должен отклоняться")
+	}
+}
+
+func TestExportCommandSendsToDM(t *testing.T) {
+	b := setupExportBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	var to int64
+	var name string
+	fakeOf(b).sendDocument = func(chatID int64, n string, content []byte) error {
+		to, name = chatID, n
+		return nil
+	}
+	b.handleExportCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/export csv"})
+	if to != 10 || !strings.HasPrefix(name, "hamster--100-") || !strings.HasSuffix(name, ".csv") {
+		t.Errorf("выгрузка уходит администратору в личку: %d %q", to, name)
+	}
+
+	to = 0
+	b.handleExportCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 11}, Text: "/export"})
+	if to != 0 {
+		t.Error("не администратор выгрузку не получает")
+	}
+}
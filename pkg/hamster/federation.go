@@ -0,0 +1,315 @@
+package hamster
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Федерации: общий список банов группы чатов
+// ==========================
+
+const (
+	// maxFederationBans — сколько банов хранит федерация; старые вытесняются.
+	maxFederationBans = 10000
+	// maxFederationName — длина названия федерации в символах.
+	maxFederationName = 64
+	// federationIDLen — длина ID федерации. ID — секрет: кто его знает, тот
+	// может подключить свой чат и банить во всех чатах федерации.
+	federationIDLen = 16
+)
+
+// Federation — группа чатов с общим списком банов. Хранится в общих
+// настройках (globalSettingsID); чаты ссылаются на неё по ID.
+type Federation struct {
+	Name string  `json:"name"`
+	Bans []int64 `json:"bans,omitempty"` // ID пользователей в порядке бана
+}
+
+// federation возвращает федерацию по ID.
+func (b *Bot) federation(fedID string) (Federation, bool) {
+	f, ok := b.chatSettings(globalSettingsID).Federations[fedID]
+	return f, ok
+}
+
+// federationChats возвращает чаты федерации.
+func (b *Bot) federationChats(fedID string) []int64 {
+	var out []int64
+	for _, id := range b.settings.ChatIDs() {
+		if b.chatSettings(id).Federation == fedID {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// isFedBanned сообщает, что пользователь забанен в федерации чата, а чат
+// принимает её баны.
+func (b *Bot) isFedBanned(chatID, userID int64) bool {
+	if b.settings == nil {
+		return false
+	}
+	cs := b.chatSettings(chatID)
+	if cs.Federation == "" || cs.FederationOptOut {
+		return false
+	}
+	f, _ := b.federation(cs.Federation)
+	return slices.Contains(f.Bans, userID)
+}
+
+// addFederationBan добавляет пользователя в список федерации; false — уже там.
+func (b *Bot) addFederationBan(fedID string, userID int64) (added bool) {
+	b.updateChatSettings(globalSettingsID, func(c *ChatSettings) {
+		f, ok := c.Federations[fedID]
+		if !ok || slices.Contains(f.Bans, userID) {
+			return
+		}
+		f.Bans = append(f.Bans, userID)
+		if len(f.Bans) > maxFederationBans {
+			f.Bans = f.Bans[len(f.Bans)-maxFederationBans:]
+		}
+		c.Federations[fedID] = f
+		added = true
+	})
+	return added
+}
+
+// removeFederationBan убирает пользователя из списка федерации.
+func (b *Bot) removeFederationBan(fedID string, userID int64) (removed bool) {
+	b.updateChatSettings(globalSettingsID, func(c *ChatSettings) {
+		f, ok := c.Federations[fedID]
+		i := slices.Index(f.Bans, userID)
+		if !ok || i < 0 {
+			return
+		}
+		f.Bans = slices.Delete(f.Bans, i, i+1)
+		c.Federations[fedID] = f
+		removed = true
+	})
+	return removed
+}
+
+// federates сообщает, разносится ли бан с этой причиной по федерации: только
+// баны администраторов и модераторов. Автоматические баны бота (проваленная
+// проверка, фильтры) остаются в своём чате — иначе одна ошибка с капчей
+// закрывала бы человеку всю сеть чатов навсегда.
+func federates(reason string) bool {
+	return reason == BanReasonAdmin
+}
+
+// federateBan — обработчик OnBanned: бан администратора или модератора в чате
+// федерации попадает в общий список и применяется в остальных её чатах.
+func (b *Bot) federateBan(ctx context.Context, e MemberEvent) {
+	if !federates(e.Reason) {
+		return
+	}
+	fedID := b.chatSettings(e.ChatID).Federation
+	if fedID == "" {
+		return
+	}
+	b.propagateFederationBan(fedID, e.ChatID, e.UserID)
+}
+
+// propagateFederationBan вносит пользователя в список федерации и банит его
+// во всех её чатах, кроме from и отказавшихся от общих банов.
+func (b *Bot) propagateFederationBan(fedID string, from, userID int64) int {
+	if !b.addFederationBan(fedID, userID) {
+		return 0
+	}
+	n := 0
+	for _, chatID := range b.federationChats(fedID) {
+		if chatID == from || b.chatSettings(chatID).FederationOptOut {
+			continue
+		}
+		if b.isAdmin(b.ctx, chatID, userID) {
+			continue
+		}
+		b.safeBanUser(b.ctx, chatID, userID)
+		b.logBan(chatID, userID, BanReasonFederation)
+		n++
+	}
+	b.logger.Info("Бан %d из чата %d разнесён по федерации %s: чатов %d", userID, from, fedID, n)
+	return n
+}
+
+// ==========================
+// Команды /fed, /fban и /funban
+// ==========================
+
+const fedUsage = "⚙️ Использование:\n/fed — состояние\n/fed new <название> — создать федерацию (ID придёт в личку)\n" +
+	"/fed join <ID> — подключить чат\n/fed leave — отключить чат\n/fed optout on|off — не применять баны других чатов"
+
+func (b *Bot) handleFedCommand(msg *Message) {
+	if msg.From == nil || msg.Chat.Type == "private" {
+		return
+	}
+	chatID := msg.Chat.ID
+	parts := strings.Fields(msg.Text)
+	if len(parts) > 1 && parts[1] == "join" {
+		// в команде ID федерации — секрет, убираем её из чата сразу, кто бы её ни дал
+		b.safeDeleteMessage(b.ctx, chatID, msg.MessageID)
+	}
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может управлять федерацией", 5*time.Second)
+		return
+	}
+	if len(parts) < 2 {
+		b.sendTemporary(chatID, b.formatFederation(chatID), 30*time.Second)
+		return
+	}
+	cs := b.chatSettings(chatID)
+	switch parts[1] {
+	case "new":
+		name := commandArg(msg.Text, 2)
+		if name == "" || len([]rune(name)) > maxFederationName {
+			b.sendTemporary(chatID, fmt.Sprintf("⚙️ Укажите название до %d символов: /fed new <название>", maxFederationName), 10*time.Second)
+			return
+		}
+		if cs.Federation != "" {
+			b.sendTemporary(chatID, "❌ Чат уже в федерации — сначала /fed leave", 10*time.Second)
+			return
+		}
+		fedID := randString(federationIDLen)
+		if b.safeSendSilent(b.ctx, msg.From.ID, fmt.Sprintf("🤝 Федерация «%s» создана. Чтобы подключить другой чат, отправьте в нём:\n/fed join %s\n\nНе показывайте ID посторонним.", name, fedID)) == 0 {
+			b.sendTemporary(chatID, "❌ Не удалось написать вам в личку: сначала отправьте мне /start в личных сообщениях", 15*time.Second)
+			return
+		}
+		b.updateChatSettings(globalSettingsID, func(c *ChatSettings) {
+			if c.Federations == nil {
+				c.Federations = make(map[string]Federation)
+			}
+			c.Federations[fedID] = Federation{Name: name}
+		})
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Federation = fedID })
+		b.logger.Info("Федерация %s «%s» создана в чате %d", fedID, name, chatID)
+		b.sendTemporary(chatID, fmt.Sprintf("🤝 Чат в федерации «%s». ID для подключения других чатов отправлен в личку", name), 15*time.Second)
+	case "join":
+		fedID := ""
+		if len(parts) > 2 {
+			fedID = parts[2]
+		}
+		f, ok := b.federation(fedID)
+		if !ok {
+			b.sendTemporary(chatID, "❌ Федерация не найдена", 10*time.Second)
+			return
+		}
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Federation = fedID })
+		b.logger.Info("Чат %d подключён к федерации %s", chatID, fedID)
+		b.sendTemporary(chatID, fmt.Sprintf("🤝 Чат подключён к федерации «%s»: банов в списке %d", f.Name, len(f.Bans)), 15*time.Second)
+	case "leave":
+		if cs.Federation == "" {
+			b.sendTemporary(chatID, "ℹ️ Чат не в федерации", 5*time.Second)
+			return
+		}
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Federation, c.FederationOptOut = "", false })
+		b.logger.Info("Чат %d вышел из федерации %s", chatID, cs.Federation)
+		b.sendTemporary(chatID, "👋 Чат вышел из федерации", 10*time.Second)
+	case "optout":
+		if len(parts) != 3 || (parts[2] != "on" && parts[2] != "off") || cs.Federation == "" {
+			b.sendTemporary(chatID, fedUsage, 10*time.Second)
+			return
+		}
+		on := parts[2] == "on"
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.FederationOptOut = on })
+		if on {
+			b.sendTemporary(chatID, "✅ Баны других чатов федерации здесь не применяются; баны этого чата по-прежнему общие", 10*time.Second)
+		} else {
+			b.sendTemporary(chatID, "✅ Баны других чатов федерации применяются здесь", 10*time.Second)
+		}
+	default:
+		b.sendTemporary(chatID, fedUsage, 10*time.Second)
+	}
+}
+
+// formatFederation описывает федерацию чата.
+func (b *Bot) formatFederation(chatID int64) string {
+	cs := b.chatSettings(chatID)
+	if cs.Federation == "" {
+		return "ℹ️ Чат не в федерации.\n\n" + fedUsage
+	}
+	f, _ := b.federation(cs.Federation)
+	s := fmt.Sprintf("🤝 Федерация «%s»: чатов %d, банов в общем списке %d", f.Name, len(b.federationChats(cs.Federation)), len(f.Bans))
+	if cs.FederationOptOut {
+		s += "\n⏸ Баны других чатов здесь не применяются (/fed optout off)"
+	}
+	return s
+}
+
+// handleFedBanCommand — /fban <ID> или ответом: бан во всех чатах федерации.
+func (b *Bot) handleFedBanCommand(msg *Message) {
+	chatID, fedID, ok := b.fedCommandChat(msg, "/fban")
+	if !ok {
+		return
+	}
+	userID, err := b.commandTarget(msg, "/fban")
+	if err != nil {
+		b.sendTemporary(chatID, err.Error(), 10*time.Second)
+		return
+	}
+	b.safeBanUser(b.ctx, chatID, userID)
+	b.writeBanLog(BanLogEntry{ChatID: chatID, UserID: userID, Reason: BanReasonFederation, By: msg.From.ID, At: time.Now()})
+	n := b.propagateFederationBan(fedID, chatID, userID)
+	b.logger.Info("Администратор %d выдал бан федерации пользователю %d из чата %d", msg.From.ID, userID, chatID)
+	b.sendTemporary(chatID, fmt.Sprintf("🔨 %d забанен здесь и ещё в %d чатах федерации", userID, n), 15*time.Second)
+}
+
+// handleFedUnbanCommand — /funban <ID>: убрать из списка федерации и снять
+// бан в её чатах, где он выдан по федерации. Собственные баны чатов и чаты,
+// отказавшиеся от общих банов, не трогаются.
+func (b *Bot) handleFedUnbanCommand(msg *Message) {
+	chatID, fedID, ok := b.fedCommandChat(msg, "/funban")
+	if !ok {
+		return
+	}
+	userID, err := b.commandTarget(msg, "/funban")
+	if err != nil {
+		b.sendTemporary(chatID, err.Error(), 10*time.Second)
+		return
+	}
+	if !b.removeFederationBan(fedID, userID) {
+		b.sendTemporary(chatID, fmt.Sprintf("ℹ️ %d нет в списке федерации", userID), 10*time.Second)
+		return
+	}
+	n := 0
+	for _, id := range b.federationChats(fedID) {
+		if id != chatID && b.chatSettings(id).FederationOptOut || !b.lastBanFederated(id, userID) {
+			continue
+		}
+		b.safeUnbanUser(b.ctx, id, userID)
+		n++
+	}
+	b.logger.Info("Администратор %d снял бан федерации %s с пользователя %d: чатов %d", msg.From.ID, fedID, userID, n)
+	b.sendTemporary(chatID, fmt.Sprintf("✅ %d убран из списка федерации, бан федерации снят в %d чатах", userID, n), 15*time.Second)
+}
+
+// lastBanFederated сообщает, что последний бан пользователя в чате выдан по
+// федерации, а не самим чатом.
+func (b *Bot) lastBanFederated(chatID, userID int64) bool {
+	entries, err := b.banLog(BanLogQuery{ChatID: chatID, UserID: userID, Limit: 1})
+	if err != nil {
+		b.logger.Warn("Не удалось прочитать журнал банов чата %d: %v", chatID, err)
+		return false
+	}
+	return len(entries) == 1 && entries[0].Reason == BanReasonFederation
+}
+
+// fedCommandChat проверяет, что команду дал админ чата из федерации.
+func (b *Bot) fedCommandChat(msg *Message, cmd string) (chatID int64, fedID string, ok bool) {
+	if msg.From == nil || msg.Chat.Type == "private" {
+		return 0, "", false
+	}
+	chatID = msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может банить по федерации", 5*time.Second)
+		return 0, "", false
+	}
+	fedID = b.chatSettings(chatID).Federation
+	if fedID == "" {
+		b.sendTemporary(chatID, fmt.Sprintf("ℹ️ %s работает только в чатах федерации: /fed", cmd), 10*time.Second)
+		return 0, "", false
+	}
+	return chatID, fedID, true
+}
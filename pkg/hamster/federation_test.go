@@ -0,0 +1,268 @@
+package hamster
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFederationCommands(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+		"-200:20": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	var private string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		if chatID == 10 {
+			private = text
+		}
+		return 1
+	}
+
+	b.handleFedCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 11}, Text: "/fed new Сеть"})
+	if b.chatSettings(-100).Federation != "" {
+		t.Fatal("не администратор не должен создавать федерацию")
+	}
+	b.handleFedCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/fed new Сеть чатов"})
+	fedID := b.chatSettings(-100).Federation
+	if len(fedID) != federationIDLen || !strings.Contains(private, "/fed join "+fedID) {
+		t.Fatalf("ID федерации %q должен прийти в личку: %q", fedID, private)
+	}
+	if f, ok := b.federation(fedID); !ok || f.Name != "Сеть чатов" {
+		t.Fatalf("федерация: %+v %v", f, ok)
+	}
+
+	b.handleFedCommand(&Message{Chat: Chat{ID: -200, Type: "supergroup"}, From: &User{ID: 20}, Text: "/fed join nope"})
+	if b.chatSettings(-200).Federation != "" {
+		t.Error("нельзя подключиться к несуществующей федерации")
+	}
+	var (
+		delMu   sync.Mutex
+		deleted []int64
+	)
+	// Временные ответы бота удаляются по таймеру, поэтому учитываем только
+	// команды пользователей.
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) {
+		if msgID == 7 || msgID == 8 {
+			delMu.Lock()
+			deleted = append(deleted, msgID)
+			delMu.Unlock()
+		}
+	}
+	b.handleFedCommand(&Message{MessageID: 7, Chat: Chat{ID: -200, Type: "supergroup"}, From: &User{ID: 21}, Text: "/fed join " + fedID})
+	if len(b.federationChats(fedID)) != 1 || len(deleted) != 1 || deleted[0] != 7 {
+		t.Fatalf("команда с ID федерации удаляется, даже если её дал не администратор: %v", deleted)
+	}
+	b.handleFedCommand(&Message{MessageID: 8, Chat: Chat{ID: -200, Type: "supergroup"}, From: &User{ID: 20}, Text: "/fed join " + fedID})
+	if got := b.federationChats(fedID); len(got) != 2 {
+		t.Fatalf("чаты федерации: %v", got)
+	}
+	if len(deleted) != 2 || deleted[1] != 8 {
+		t.Errorf("/fed join с ID федерации не должна оставаться в чате: %v", deleted)
+	}
+
+	b.handleFedCommand(&Message{Chat: Chat{ID: -200, Type: "supergroup"}, From: &User{ID: 20}, Text: "/fed optout on"})
+	if !b.chatSettings(-200).FederationOptOut {
+		t.Error("optout on должен включаться")
+	}
+	b.handleFedCommand(&Message{Chat: Chat{ID: -200, Type: "supergroup"}, From: &User{ID: 20}, Text: "/fed leave"})
+	if cs := b.chatSettings(-200); cs.Federation != "" || cs.FederationOptOut {
+		t.Errorf("после leave чат не в федерации: %+v", cs)
+	}
+}
+
+func TestFederationNewNeedsPrivateChat(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		if chatID == 10 {
+			return 0 // бот не может написать в личку
+		}
+		return 1
+	}
+	b.handleFedCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/fed new Сеть"})
+	if b.chatSettings(-100).Federation != "" || len(b.chatSettings(globalSettingsID).Federations) != 0 {
+		t.Error("без лички федерация не создаётся: ID некуда отправить")
+	}
+}
+
+// newFederation создаёт федерацию из чатов с заданными настройками.
+func newFederation(b *Bot, chats ...int64) string {
+	const fedID = "fed1"
+	b.updateChatSettings(globalSettingsID, func(c *ChatSettings) {
+		c.Federations = map[string]Federation{fedID: {Name: "Сеть"}}
+	})
+	for _, id := range chats {
+		b.updateChatSettings(id, func(c *ChatSettings) { c.Federation = fedID })
+	}
+	return fedID
+}
+
+func TestFederateAdminBan(t *testing.T) {
+	b := setupBot()
+	b.recentBans = newBanHistory(10)
+	fedID := newFederation(b, -100, -200, -300)
+	b.updateChatSettings(-300, func(c *ChatSettings) { c.FederationOptOut = true })
+	var mu sync.Mutex
+	banned := map[int64]bool{}
+	fakeOf(b).ban = func(chatID, userID int64) { mu.Lock(); banned[chatID] = true; mu.Unlock() }
+
+	// автоматические баны бота по федерации не расходятся
+	for _, reason := range []string{BanReasonTimeout, BanReasonWrongAnswer, BanReasonSpamWords, BanReasonChannel, BanReasonFederation} {
+		b.federateBan(context.Background(), MemberEvent{ChatID: -100, UserID: 42, Reason: reason})
+	}
+	if len(banned) != 0 {
+		t.Fatalf("автоматический бан не должен расходиться: %v", banned)
+	}
+
+	b.federateBan(context.Background(), MemberEvent{ChatID: -100, UserID: 42, Reason: BanReasonAdmin})
+	mu.Lock()
+	if !banned[-200] || banned[-100] || banned[-300] {
+		t.Errorf("бан должен прийти только в -200: %v", banned)
+	}
+	mu.Unlock()
+	if f, _ := b.federation(fedID); len(f.Bans) != 1 || f.Bans[0] != 42 {
+		t.Errorf("список федерации: %v", f.Bans)
+	}
+	// повторный бан не рассылается заново
+	if n := b.propagateFederationBan(fedID, -200, 42); n != 0 {
+		t.Errorf("повторный бан разослан в %d чатов", n)
+	}
+
+	if !b.isFedBanned(-200, 42) || b.isFedBanned(-300, 42) || b.isFedBanned(-400, 42) {
+		t.Error("бан федерации действует в её чатах, кроме отказавшихся")
+	}
+}
+
+func TestFederationSkipsKickedTimeout(t *testing.T) {
+	b := setupBot()
+	b.recentBans = newBanHistory(10)
+	fedID := newFederation(b, -100, -200)
+	b.updateChatSettings(-100, func(c *ChatSettings) { c.Action = ActionKick })
+	var mu sync.Mutex
+	banned, unbanned := map[int64]bool{}, map[int64]bool{}
+	fakeOf(b).ban = func(chatID, userID int64) { mu.Lock(); banned[chatID] = true; mu.Unlock() }
+	fakeOf(b).unban = func(chatID, userID int64) { mu.Lock(); unbanned[chatID] = true; mu.Unlock() }
+
+	p := &progressData{stopChan: make(chan struct{}), chatID: -100, userID: 42, greetMsgID: 10}
+	b.progressStore.add(p)
+	b.failVerification(-100, p, BanReasonTimeout)
+
+	mu.Lock()
+	if !unbanned[-100] || banned[-200] {
+		t.Errorf("участник должен быть только исключён из -100: бан %v, разбан %v", banned, unbanned)
+	}
+	mu.Unlock()
+	if got := b.recentBans.list(); len(got) != 0 {
+		t.Errorf("исключение не должно попадать в журнал банов: %v", got)
+	}
+	if f, _ := b.federation(fedID); len(f.Bans) != 0 || b.isFedBanned(-200, 42) {
+		t.Errorf("исключение не должно попадать в список федерации: %v", f.Bans)
+	}
+}
+
+func TestFedBanAndUnbanCommands(t *testing.T) {
+	b := setupBot()
+	b.recentBans = newBanHistory(10)
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	fedID := newFederation(b, -100, -200)
+	var mu sync.Mutex
+	banned, unbanned := map[int64]bool{}, map[int64]bool{}
+	fakeOf(b).ban = func(chatID, userID int64) { mu.Lock(); banned[chatID] = true; mu.Unlock() }
+	fakeOf(b).unban = func(chatID, userID int64) { mu.Lock(); unbanned[chatID] = true; mu.Unlock() }
+
+	b.handleFedBanCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 11}, Text: "/fban 42"})
+	if len(banned) != 0 {
+		t.Fatal("не администратор не может банить по федерации")
+	}
+	b.handleFedBanCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/fban 42"})
+	mu.Lock()
+	if !banned[-100] || !banned[-200] {
+		t.Errorf("/fban банит во всех чатах федерации: %v", banned)
+	}
+	mu.Unlock()
+	var byAdmin bool
+	for _, e := range b.recentBans.list() {
+		byAdmin = byAdmin || e.ChatID == -100 && e.By == 10 && e.Reason == BanReasonFederation
+	}
+	if !byAdmin {
+		t.Errorf("в журнале должен быть бан администратора: %+v", b.recentBans.list())
+	}
+
+	b.handleFedUnbanCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/funban 42"})
+	mu.Lock()
+	if !unbanned[-100] || !unbanned[-200] {
+		t.Errorf("/funban снимает бан во всех чатах: %v", unbanned)
+	}
+	mu.Unlock()
+	if f, _ := b.federation(fedID); len(f.Bans) != 0 {
+		t.Errorf("после /funban список пуст: %v", f.Bans)
+	}
+}
+
+func TestFedUnbanKeepsLocalBans(t *testing.T) {
+	b := setupBot()
+	b.recentBans = newBanHistory(20)
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	fedID := newFederation(b, -100, -200, -300, -400)
+	b.updateChatSettings(-300, func(c *ChatSettings) { c.FederationOptOut = true })
+	var mu sync.Mutex
+	unbanned := map[int64]bool{}
+	fakeOf(b).unban = func(chatID, userID int64) { mu.Lock(); unbanned[chatID] = true; mu.Unlock() }
+
+	b.handleFedBanCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/fban 42"})
+	// в -400 участника потом забанил свой администратор, в -300 — тоже свой
+	// (чат не принимает общих банов)
+	b.writeBanLog(BanLogEntry{ChatID: -400, UserID: 42, Reason: BanReasonAdmin, By: 40, At: time.Now()})
+	b.writeBanLog(BanLogEntry{ChatID: -300, UserID: 42, Reason: BanReasonFederation, By: 30, At: time.Now()})
+
+	b.handleFedUnbanCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/funban 42"})
+	mu.Lock()
+	defer mu.Unlock()
+	if !unbanned[-100] || !unbanned[-200] {
+		t.Errorf("бан федерации снимается: %v", unbanned)
+	}
+	if unbanned[-300] || unbanned[-400] {
+		t.Errorf("/funban не трогает собственные баны чатов и отказавшиеся чаты: %v", unbanned)
+	}
+	if f, _ := b.federation(fedID); len(f.Bans) != 0 {
+		t.Errorf("после /funban список пуст: %v", f.Bans)
+	}
+}
+
+func TestFedBannedJoin(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.recentBans = newBanHistory(10)
+	fedID := newFederation(b, -100)
+	b.addFederationBan(fedID, 42)
+	var mu sync.Mutex
+	var banned []int64
+	greeted := false
+	fakeOf(b).ban = func(chatID, userID int64) { mu.Lock(); banned = append(banned, userID); mu.Unlock() }
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { greeted = true; return 10 }
+
+	b.handleJoinMessage(&Message{MessageID: 5, Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 42, FirstName: "Спам"}}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(banned) != 1 || banned[0] != 42 || greeted {
+		t.Errorf("забаненный в федерации банится без проверки: %v, greeted=%v", banned, greeted)
+	}
+}
+
+func TestFederationSettingsValidate(t *testing.T) {
+	c := ChatSettings{Federations: map[string]Federation{"x": {Name: ""}}}
+	if c.Validate() == nil {
+		t.Error("федерация без названия должна отклоняться")
+	}
+	c = ChatSettings{Federations: map[string]Federation{"x": {Name: "a", Bans: []int64{1}}}}
+	cl := c.clone()
+	cl.Federations["x"].Bans[0] = 2
+	if c.Federations["x"].Bans[0] != 1 {
+		t.Error("clone должен копировать списки банов")
+	}
+}
@@ -0,0 +1,83 @@
+package hamster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Нажатия на чужие кнопки
+// ==========================
+
+// foreignPressWindow — за какой период считаются нажатия на чужие кнопки.
+const foreignPressWindow = 10 * time.Minute
+
+// pressCounter считает нажатия на чужие кнопки по паре чат:пользователь за
+// скользящее окно foreignPressWindow.
+type pressCounter struct {
+	mu sync.Mutex
+	m  map[string][]time.Time
+}
+
+func newPressCounter() *pressCounter {
+	return &pressCounter{m: make(map[string][]time.Time)}
+}
+
+// hit учитывает нажатие и возвращает число нажатий за foreignPressWindow и
+// за последние recent, включая это.
+func (c *pressCounter) hit(chatID, userID int64, recent time.Duration, now time.Time) (total, burst int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, ts := range c.m {
+		if now.Sub(ts[len(ts)-1]) > foreignPressWindow {
+			delete(c.m, k)
+		}
+	}
+	key := fmt.Sprintf("%d:%d", chatID, userID)
+	ts := c.m[key]
+	i := 0
+	for i < len(ts) && now.Sub(ts[i]) > foreignPressWindow {
+		i++
+	}
+	ts = append(ts[i:], now)
+	c.m[key] = ts
+	for _, t := range ts {
+		if now.Sub(t) <= recent {
+			burst++
+		}
+	}
+	return len(ts), burst
+}
+
+// reset забывает нажатия пользователя в чате.
+func (c *pressCounter) reset(chatID, userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.m, fmt.Sprintf("%d:%d", chatID, userID))
+}
+
+// handleForeignPress отвечает тому, кто нажал кнопку чужой проверки, и выдаёт
+// мут за частые (CallbackBurstLimit за минуту, на половине — предупреждение)
+// или, при ForeignPressLimit > 0, повторные нажатия. Администраторов не трогает.
+func (b *Bot) handleForeignPress(cb *Callback) {
+	limit, burstLimit := b.cfg.ForeignPressLimit, b.cfg.CallbackBurstLimit
+	if (limit <= 0 && burstLimit <= 0) || b.foreignPresses == nil {
+		b.safeAnswerCallback(b.ctx, cb.ID, "🚫 Эта кнопка не для вас", true)
+		return
+	}
+	chatID := cb.Message.Chat.ID
+	n, burst := b.foreignPresses.hit(chatID, cb.From.ID, callbackBurstWindow, time.Now())
+	switch {
+	case limit > 0 && n >= limit, burstLimit > 0 && burst >= burstLimit:
+		b.foreignPresses.reset(chatID, cb.From.ID)
+		b.muteForeignPresser(cb)
+	case burstLimit > 0 && burst == (burstLimit+1)/2:
+		b.callbackCounts.inc(callbackWarned)
+		b.safeAnswerCallback(b.ctx, cb.ID, fmt.Sprintf("⚠️ Хватит нажимать чужие кнопки: ещё %d за минуту — и мут", burstLimit-burst), true)
+	case limit > 0:
+		b.safeAnswerCallback(b.ctx, cb.ID, fmt.Sprintf("🚫 Эта кнопка не для вас. Ещё %d — и мут", limit-n), true)
+	default:
+		b.safeAnswerCallback(b.ctx, cb.ID, "🚫 Эта кнопка не для вас", true)
+	}
+}
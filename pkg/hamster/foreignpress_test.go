@@ -0,0 +1,131 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPressCounterWindow(t *testing.T) {
+	c := newPressCounter()
+	now := time.Now()
+	hit := func(chatID, userID int64, at time.Time) int {
+		n, _ := c.hit(chatID, userID, time.Minute, at)
+		return n
+	}
+	if hit(1, 2, now) != 1 || hit(1, 2, now.Add(time.Minute)) != 2 {
+		t.Fatal("нажатия должны накапливаться")
+	}
+	if hit(1, 3, now) != 1 || hit(2, 2, now) != 1 {
+		t.Error("счётчики разных пользователей и чатов не должны смешиваться")
+	}
+	if n := hit(1, 2, now.Add(foreignPressWindow+time.Second)); n != 2 {
+		t.Errorf("нажатия старше окна выпадают, получили %d", n)
+	}
+	if n := hit(1, 2, now.Add(3*foreignPressWindow)); n != 1 {
+		t.Errorf("после окна без нажатий счёт начинается заново, получили %d", n)
+	}
+	c.reset(1, 2)
+	if hit(1, 2, now) != 1 {
+		t.Error("reset должен обнулять счётчик")
+	}
+}
+
+func TestPressCounterBurst(t *testing.T) {
+	c := newPressCounter()
+	now := time.Now()
+	c.hit(1, 2, time.Minute, now)
+	c.hit(1, 2, time.Minute, now.Add(90*time.Second))
+	if total, burst := c.hit(1, 2, time.Minute, now.Add(100*time.Second)); total != 3 || burst != 2 {
+		t.Errorf("за окно 3 нажатия, за минуту 2, получили %d и %d", total, burst)
+	}
+}
+
+func TestForeignPressOneCounter(t *testing.T) {
+	b := setupBot()
+	b.adminCache = make(map[string]adminCacheEntry)
+	b.foreignPresses = newPressCounter()
+	b.cfg.ForeignPressLimit = 3
+	b.cfg.CallbackBurstLimit = 6
+	var alerts []string
+	fakeOf(b).answerCallback = func(id, text string, alert bool) { alerts = append(alerts, text) }
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "member"}, nil
+	}
+
+	// третье нажатие — и предупреждение за частоту, и лимит за 10 минут:
+	// оба порога считают одни и те же нажатия
+	for i := 0; i < 3; i++ {
+		b.handleCallback(foreignCallback())
+	}
+	if len(alerts) != 3 || !strings.Contains(alerts[2], "Мут") {
+		t.Errorf("предупреждение не должно сбивать счёт нажатий: %q", alerts)
+	}
+}
+
+func foreignCallback() *Callback {
+	return &Callback{
+		ID:      "cb",
+		Message: &Message{MessageID: 100, Chat: Chat{ID: -1}},
+		From:    &User{ID: 7, FirstName: "Петя"},
+		Data:    "click:42:TOKEN",
+	}
+}
+
+func TestForeignPressMute(t *testing.T) {
+	b := setupBot()
+	b.adminCache = make(map[string]adminCacheEntry)
+	b.foreignPresses = newPressCounter()
+	b.cfg.ForeignPressLimit = 3
+	b.cfg.ForeignPressMute = 5 * time.Minute
+	var alerts []string
+	fakeOf(b).answerCallback = func(id, text string, alert bool) {
+		if !alert {
+			t.Errorf("ответ чужому должен быть alert: %q", text)
+		}
+		alerts = append(alerts, text)
+	}
+	var mutedUntil time.Time
+	var perms ChatPermissions
+	fakeOf(b).restrict = func(chatID, userID int64, p ChatPermissions, until time.Time) {
+		if chatID != -1 || userID != 7 {
+			t.Errorf("мут не тому: чат %d, пользователь %d", chatID, userID)
+		}
+		perms, mutedUntil = p, until
+	}
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "member"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		b.handleCallback(foreignCallback())
+	}
+	if len(alerts) != 3 || !strings.Contains(alerts[0], "Ещё 2") || !strings.Contains(alerts[2], "Мут на 5 мин") {
+		t.Errorf("ответы: %q", alerts)
+	}
+	if perms.CanSendMessages || time.Until(mutedUntil) < 4*time.Minute {
+		t.Errorf("ожидали полный мут на 5 минут: %+v до %v", perms, mutedUntil)
+	}
+}
+
+func TestForeignPressAdminsAndDisabled(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-1:7": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	b.foreignPresses = newPressCounter()
+	b.cfg.ForeignPressLimit = 1
+	restricted := false
+	fakeOf(b).restrict = func(chatID, userID int64, p ChatPermissions, until time.Time) { restricted = true }
+
+	b.handleCallback(foreignCallback())
+	if restricted {
+		t.Error("администраторов не мутим")
+	}
+
+	b.cfg.ForeignPressLimit = 0
+	var text string
+	fakeOf(b).answerCallback = func(id, t string, alert bool) { text = t }
+	b.handleCallback(foreignCallback())
+	if restricted || text != "🚫 Эта кнопка не для вас" {
+		t.Errorf("без лимита — только предупреждение: %q", text)
+	}
+}
@@ -0,0 +1,57 @@
+package hamster
+
+import (
+	"strings"
+	"time"
+)
+
+// ==========================
+// Приветствие после проверки
+// ==========================
+
+// keepGreeting заменяет кнопки под приветствием отметкой о прохождении
+// проверки. Редактирование без reply_markup убирает клавиатуру.
+func (b *Bot) keepGreeting(chatID int64, p *progressData, cs ChatSettings, user *User) {
+	text := greetingHTML(cs.Welcome(), user) + "\n\n✅ Проверка пройдена"
+	if cs.PlainText {
+		text = stripEmoji(text)
+	}
+	b.keepSent(chatID, p.greetMsgID)
+	if p.session != nil && p.session.mediaMsgID != 0 {
+		b.keepSent(chatID, p.session.mediaMsgID)
+	}
+	if p.session != nil && p.session.captioned {
+		b.safeEditCaption(b.ctx, chatID, p.greetMsgID, text, ParseModeHTML)
+		return
+	}
+	b.safeEdit(b.ctx, chatID, p.greetMsgID, text, ParseModeHTML)
+}
+
+// ==========================
+// Команда /keepgreeting
+// ==========================
+
+func (b *Bot) handleKeepGreetingCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять приветствие", 5*time.Second)
+		return
+	}
+
+	switch strings.ToLower(commandArg(msg.Text, 1)) {
+	case "on":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.KeepGreeting = true })
+		b.sendTemporary(chatID, "✅ Приветствие будет оставаться в чате после прохождения проверки", 5*time.Second)
+	case "off":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.KeepGreeting = false })
+		b.sendTemporary(chatID, "✅ Приветствие будет удаляться после прохождения проверки", 5*time.Second)
+	case "":
+		b.sendTemporary(chatID, "👋 Оставлять приветствие после проверки: "+onOff(b.chatSettings(chatID).KeepGreeting)+
+			"\n⚙️ /keepgreeting on|off", 10*time.Second)
+	default:
+		b.sendTemporary(chatID, "⚙️ Использование: /keepgreeting on|off", 5*time.Second)
+	}
+}
@@ -0,0 +1,53 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPassKeepsGreeting(t *testing.T) {
+	b := setupBot()
+	b.settings.Update(1, func(c *ChatSettings) { c.KeepGreeting = true; c.WelcomeTemplate = "Привет, {name}!" })
+	var edited string
+	fakeOf(b).editMessage = func(chatID, msgID int64, text string) {
+		if msgID == 10 {
+			edited = text
+		}
+	}
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = append(deleted, msgID) }
+	done := startTestChallenge(b, ActionBan, 60)
+
+	sent := false
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { sent = true; return 2 }
+	b.passChallenge(1, &User{ID: 42, FirstName: "Аня"}, b.pendingProgress(1, 42))
+	<-done
+
+	for _, id := range deleted {
+		if id == 10 {
+			t.Error("приветствие не должно удаляться")
+		}
+	}
+	if !strings.Contains(edited, `Привет, <a href="tg://user?id=42">Аня</a>!`) || !strings.Contains(edited, "Проверка пройдена") {
+		t.Errorf("приветствие должно смениться отметкой о проверке: %q", edited)
+	}
+	if sent {
+		t.Error("отдельное приветствие не нужно, когда остаётся основное")
+	}
+}
+
+func TestKeepGreetingCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	b.handleKeepGreetingCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/keepgreeting on"})
+	if !b.chatSettings(-100).KeepGreeting {
+		t.Fatal("/keepgreeting on должен включать настройку")
+	}
+	b.handleKeepGreetingCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/keepgreeting off"})
+	if b.chatSettings(-100).KeepGreeting {
+		t.Error("/keepgreeting off должен выключать настройку")
+	}
+}
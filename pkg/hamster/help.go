@@ -0,0 +1,130 @@
+package hamster
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Version, Commit и BuildDate — сведения о сборке для /start, /version и
+// tg-hamster version. Задаются при сборке:
+// -ldflags "-X github.com/teleta/tg-hamster/pkg/hamster.Version=…".
+// Пустые Commit и BuildDate берутся из данных VCS, которые go build встраивает сам.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// BuildInfo — версия, коммит и дата сборки одной строкой, например
+// «v1.4.0 (коммит 3f2a9c1, собран 2026-10-01T12:00:00Z)».
+func BuildInfo() string {
+	commit, date, dirty := Commit, BuildDate, false
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if commit == "" {
+					commit = s.Value
+				}
+			case "vcs.time":
+				if date == "" {
+					date = s.Value
+				}
+			case "vcs.modified":
+				dirty = s.Value == "true" && Commit == ""
+			}
+		}
+	}
+	if commit == "" {
+		return Version
+	}
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	if dirty {
+		commit += "-dirty"
+	}
+	if date == "" {
+		return fmt.Sprintf("%s (коммит %s)", Version, commit)
+	}
+	return fmt.Sprintf("%s (коммит %s, собран %s)", Version, commit, date)
+}
+
+// RepoURL — страница проекта.
+const RepoURL = "https://github.com/Teleta/tg-hamster"
+
+// ==========================
+// /help и /start
+// ==========================
+
+func helpText() string {
+	return "🐹 Команды администратора:\n" +
+		"/timeout <секунд>|auto — время на нажатие кнопки (5–600) или подбор по статистике\n" +
+		"/hamster on|off — включить или приостановить проверку\n" +
+		"/namefilter add|del|mode — шаблоны имён для автобана\n" +
+		"/stats — статистика проверок в чате\n" +
+		"/diagnose — проверить права бота\n" +
+		"/setrules <текст> — правила чата (или ответом на сообщение)\n" +
+		"/rulesmode off|show|accept — показывать правила новичкам, требовать принятия\n" +
+		"/setwelcomemedia — фото, GIF или стикер к приветствию (ответом на сообщение)\n" +
+		"/phrases [набор|set|reset] — фразы на кнопке проверки\n" +
+		"/cleanup — удалить недавние сообщения бота в чате\n" +
+		"/progress blocks|percent|dots|clock — стиль прогрессбара\n" +
+		"/plaintext on|off — проверка без эмодзи для экранных дикторов\n" +
+		"/keepgreeting on|off — оставлять приветствие после проверки\n" +
+		"/notify greeting|progress|announce on|off — отправлять со звуком\n" +
+		"/protect greeting|progress|announce on|off — запретить пересылку и сохранение\n" +
+		"/service join|leave on|off — удалять сообщения о входе и выходе\n" +
+		"/channels off|delete|ban — сообщения от имени чужих каналов\n" +
+		"/links <ссылка|имя> trusted|hard|ban|reset — политика ссылки-приглашения\n" +
+		"/adminadd skip|welcome|check — проверять ли добавленных администратором\n" +
+		"/night 23:00-07:00 strict|hard|lockdown [пояс]|off — ночной режим\n" +
+		"/spamwords list|add|remove|mode|count — стоп-слова в первых сообщениях новичков\n" +
+		"/ratelimit N [минут] [all|new]|off — мут за больше N сообщений в минуту\n" +
+		"/forwards <минут>|off|default — удалять пересылки новичков после проверки\n" +
+		"/blacklist [add|remove <ID>] — банить пользователя при вступлении без проверки\n" +
+		"/fed [new|join|leave|optout] — федерация чатов с общим списком банов\n" +
+		"/fban и /funban <ID|@username> или ответом — бан во всех чатах федерации\n" +
+		"/check <ID|@username> или ответом — что бот знает об участнике\n" +
+		"/trust и /untrust <ID|@username> или ответом — назначить или снять модератора\n" +
+		"/verify <ID|@username> или ответом — пропустить участника без проверки\n" +
+		"/report ответом на сообщение — пожаловаться модераторам\n" +
+		"/banlog [N] [ID|причина] — журнал банов\n" +
+		"/export [json|csv] — выгрузка данных чата файлом в личку\n" +
+		"/copysettings <ID чата> — скопировать настройки из другого своего чата\n" +
+		"/broadcast on|off — получать ли объявления от разработчиков бота\n" +
+		"/forgetme — в личке боту: удалить все данные о себе\n" +
+		"/help — эта справка"
+}
+
+func startText() string {
+	return fmt.Sprintf("🐹 Привет! Я tg-hamster — проверяю новых участников групп: "+
+		"они должны нажать кнопку, иначе будут забанены.\n\n"+
+		"Как подключить:\n"+
+		"1. Добавьте меня в группу.\n"+
+		"2. Сделайте администратором с правами «Удаление сообщений» и «Блокировка участников».\n"+
+		"3. При желании настройте таймаут: /timeout 60\n"+
+		"4. Проверьте права командой /diagnose прямо в группе.\n\n"+
+		"%s\n\n"+
+		"Версия: %s\n"+
+		"Исходный код и вопросы: %s", helpText(), BuildInfo(), RepoURL)
+}
+
+// handleHelpCommand отвечает на /help и /start: в личке — подробной инструкцией,
+// в группе — справкой только для администраторов, с автоудалением.
+func (b *Bot) handleHelpCommand(msg *Message) {
+	chatID := msg.Chat.ID
+	if msg.Chat.Type == "private" {
+		text := startText()
+		if msg.From != nil && b.isOwner(msg.From.ID) {
+			text += "\n\n" + ownerHelpText()
+		}
+		b.safeSendSilent(b.ctx, chatID, text)
+		return
+	}
+	if msg.From == nil || !b.isAdminMessage(msg) {
+		return
+	}
+	b.sendTemporary(chatID, helpText(), time.Minute)
+}
@@ -0,0 +1,61 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartInPrivateChat(t *testing.T) {
+	b := setupBot()
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		sent = append(sent, text)
+		return 1
+	}
+	deleted := false
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = true }
+
+	b.handleUpdate(Update{Message: &Message{MessageID: 1, Chat: Chat{ID: 42, Type: "private"}, From: &User{ID: 42}, Text: "/start"}})
+
+	if len(sent) != 1 || !strings.Contains(sent[0], Version) || !strings.Contains(sent[0], RepoURL) {
+		t.Errorf("ожидалась инструкция с версией и ссылкой, получили %v", sent)
+	}
+	if deleted {
+		t.Error("в личке команду и ответ удалять не нужно")
+	}
+}
+
+func TestHelpInGroupAdminOnly(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-1:42": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+		"-1:43": {status: "member", expiresAt: time.Now().Add(time.Minute)},
+	}
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		sent = append(sent, text)
+		return 1
+	}
+
+	b.handleHelpCommand(&Message{Chat: Chat{ID: -1, Type: "supergroup"}, From: &User{ID: 43}, Text: "/help"})
+	if len(sent) != 0 {
+		t.Errorf("обычному участнику справка не отправляется: %v", sent)
+	}
+
+	b.handleHelpCommand(&Message{Chat: Chat{ID: -1, Type: "supergroup"}, From: &User{ID: 42}, Text: "/help"})
+	if len(sent) != 1 || !strings.Contains(sent[0], "/timeout") {
+		t.Errorf("администратор должен получить справку: %v", sent)
+	}
+}
+
+func TestBuildInfo(t *testing.T) {
+	oldCommit, oldDate := Commit, BuildDate
+	defer func() { Commit, BuildDate = oldCommit, oldDate }()
+
+	Commit, BuildDate = "0123456789abcdef", "2026-10-01T12:00:00Z"
+	want := Version + " (коммит 0123456, собран 2026-10-01T12:00:00Z)"
+	if got := BuildInfo(); got != want {
+		t.Errorf("BuildInfo() = %q, ожидали %q", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package hamster
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// ==========================
+// Обработчики событий проверки
+// ==========================
+
+// MemberEvent — событие с участником чата для обработчиков OnJoin, OnVerified,
+// OnFailed и OnBanned.
+type MemberEvent struct {
+	ChatID int64
+	UserID int64  // для банов по BanReasonChannel — ID канала
+	User   *User  // только в OnJoin; в остальных событиях бот знает лишь ID
+	Reason string // причина бана (BanReason...) в OnFailed и OnBanned
+}
+
+// MemberHook обрабатывает событие. Вызывается в отдельной горутине, ctx
+// отменяется при остановке бота; паника обработчика не роняет бота.
+type MemberHook func(ctx context.Context, e MemberEvent)
+
+type hookKind int
+
+const (
+	hookJoin hookKind = iota
+	hookVerified
+	hookFailed
+	hookBanned
+	hookKinds
+)
+
+var hookNames = [hookKinds]string{"OnJoin", "OnVerified", "OnFailed", "OnBanned"}
+
+type memberHooks struct {
+	mu sync.RWMutex
+	m  [hookKinds][]MemberHook
+}
+
+// OnJoin добавляет обработчик вступления человека в чат (ботов и вступлений,
+// уже учтённых другим экземпляром, он не получает).
+func (b *Bot) OnJoin(h MemberHook) { b.addHook(hookJoin, h) }
+
+// OnVerified добавляет обработчик успешной проверки. Вызывается и для
+// пропущенных без проверки: добавленных админом, по доверенной ссылке,
+// похожих на человека (TRUSTED_ACCOUNTS=skip).
+func (b *Bot) OnVerified(h MemberHook) { b.addHook(hookVerified, h) }
+
+// OnFailed добавляет обработчик проваленной проверки: таймаута, неверного
+// ответа, слишком быстрого нажатия.
+func (b *Bot) OnFailed(h MemberHook) { b.addHook(hookFailed, h) }
+
+// OnBanned добавляет обработчик любого бана, попавшего в журнал: ботом
+// (в том числе за проваленную проверку, вместе с OnFailed) или администратором.
+func (b *Bot) OnBanned(h MemberHook) { b.addHook(hookBanned, h) }
+
+func (b *Bot) addHook(kind hookKind, h MemberHook) {
+	b.hooks.mu.Lock()
+	defer b.hooks.mu.Unlock()
+	b.hooks.m[kind] = append(b.hooks.m[kind], h)
+}
+
+// fireHook вызывает обработчики события, не задерживая обработку обновления.
+func (b *Bot) fireHook(kind hookKind, e MemberEvent) {
+	b.hooks.mu.RLock()
+	hooks := b.hooks.m[kind]
+	b.hooks.mu.RUnlock()
+	for _, h := range hooks {
+		go b.runHook(kind, h, e)
+	}
+}
+
+func (b *Bot) runHook(kind hookKind, h MemberHook, e MemberEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("Паника в обработчике %s: %v", hookNames[kind], r)
+			b.report(ErrorReport{
+				Err:    fmt.Errorf("%v", r),
+				Panic:  true,
+				Stack:  debug.Stack(),
+				Where:  hookNames[kind],
+				ChatID: e.ChatID,
+				UserID: e.UserID,
+			})
+		}
+	}()
+	h(b.ctx, e)
+}
@@ -0,0 +1,61 @@
+package hamster
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitEvent ждёт событие из обработчика: они вызываются в отдельных горутинах.
+func waitEvent(t *testing.T, ch <-chan MemberEvent) MemberEvent {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("обработчик не вызван")
+		return MemberEvent{}
+	}
+}
+
+func TestMemberHooks(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.verified = newVerifiedUsers()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	joined, verified, failed, banned := make(chan MemberEvent, 4), make(chan MemberEvent, 4), make(chan MemberEvent, 4), make(chan MemberEvent, 4)
+	b.OnJoin(func(ctx context.Context, e MemberEvent) { joined <- e })
+	b.OnVerified(func(ctx context.Context, e MemberEvent) { verified <- e })
+	b.OnFailed(func(ctx context.Context, e MemberEvent) { failed <- e })
+	b.OnBanned(func(ctx context.Context, e MemberEvent) { banned <- e })
+
+	// добавленный администратором вступает и сразу считается проверенным
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, NewChatMembers: []*User{{ID: 42, FirstName: "Аня"}}})
+	if e := waitEvent(t, joined); e.ChatID != -100 || e.UserID != 42 || e.User == nil || e.User.FirstName != "Аня" {
+		t.Errorf("OnJoin: %+v", e)
+	}
+	if e := waitEvent(t, verified); e.UserID != 42 {
+		t.Errorf("OnVerified: %+v", e)
+	}
+
+	p := &progressData{stopChan: make(chan struct{}), chatID: -100, userID: 43, greetMsgID: 10}
+	b.progressStore.add(p)
+	b.failVerification(-100, p, BanReasonWrongAnswer)
+	if e := waitEvent(t, failed); e.UserID != 43 || e.Reason != BanReasonWrongAnswer {
+		t.Errorf("OnFailed: %+v", e)
+	}
+	if e := waitEvent(t, banned); e.UserID != 43 || e.Reason != BanReasonWrongAnswer {
+		t.Errorf("OnBanned после провала: %+v", e)
+	}
+}
+
+func TestMemberHookPanicRecovered(t *testing.T) {
+	b := setupBot()
+	done := make(chan MemberEvent, 1)
+	b.OnBanned(func(ctx context.Context, e MemberEvent) { panic("сбой обработчика") })
+	b.OnBanned(func(ctx context.Context, e MemberEvent) { done <- e })
+	b.logBan(-100, 5, BanReasonScore)
+	if e := waitEvent(t, done); e.Reason != BanReasonScore {
+		t.Errorf("остальные обработчики получают событие: %+v", e)
+	}
+}
@@ -0,0 +1,75 @@
+package hamster
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ==========================
+// Перечитывание settings.json при ручной правке
+// ==========================
+
+// settingsReloadDelay — пауза после последнего события файловой системы перед перечитыванием:
+// редакторы сохраняют файл несколькими операциями подряд.
+const settingsReloadDelay = 500 * time.Millisecond
+
+// WatchSettings следит за файлом настроек и перечитывает его, если он изменён извне.
+// Следим за каталогом, а не за файлом: атомарная запись подменяет файл переименованием.
+func (b *Bot) WatchSettings(ctx context.Context) {
+	fs, ok := b.storage.(*fileStorage)
+	if !ok || fs.file == "" {
+		return
+	}
+	file := fs.file
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		b.logger.Warn("Не удалось запустить слежение за %s: %v", file, err)
+		return
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		b.logger.Warn("Не удалось следить за каталогом %s: %v", filepath.Dir(file), err)
+		return
+	}
+	b.logger.Info("👀 Слежение за изменениями %s", file)
+
+	reload := newDebouncer(settingsReloadDelay, settingsReloadDelay, func() { b.reloadSettings(fs) })
+	defer reload.Flush()
+	target := filepath.Clean(file)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != target || !ev.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			reload.Trigger()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			b.logger.Warn("Ошибка слежения за %s: %v", file, err)
+		}
+	}
+}
+
+// reloadSettings перечитывает файл настроек, если его содержимое отличается от последней записи бота.
+func (b *Bot) reloadSettings(fs *fileStorage) {
+	chats, changed, err := fs.reload()
+	if err != nil {
+		// при ошибке оставляем прежние настройки — оператор увидит предупреждение и исправит файл
+		b.logger.Warn("Настройки из %s не применены: %v", fs.file, err)
+		return
+	}
+	if changed {
+		b.settings.Replace(chats)
+		b.logger.Info("🔄 Настройки перечитаны из %s (%d чатов)", fs.file, len(chats))
+	}
+}
@@ -0,0 +1,78 @@
+package hamster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStorageReloadSkipsOwnWrites(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "settings.json")
+	fs := newFileStorage(file, NewLogger())
+	if err := fs.SaveSettings(map[int64]*ChatSettings{1: {Timeout: 30}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, changed, err := fs.reload(); err != nil || changed {
+		t.Errorf("собственная запись не должна перечитываться: changed=%v err=%v", changed, err)
+	}
+
+	if err := os.WriteFile(file, []byte(`{"version": 1, "chats": {"1": {"timeout": 90}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	chats, changed, err := fs.reload()
+	if err != nil || !changed {
+		t.Fatalf("изменённый файл должен перечитываться: changed=%v err=%v", changed, err)
+	}
+	if got := chats[1].Timeout; got != 90 {
+		t.Errorf("ожидалось 90 после перечитывания, получили %d", got)
+	}
+}
+
+func TestReloadSettingsKeepsOnError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "settings.json")
+	b := setupBot()
+	fs := newFileStorage(file, b.logger)
+	b.storage = fs
+	b.settings.SetTimeout(1, 30)
+	if err := os.WriteFile(file, []byte(`{"version": 1, "chats": {`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b.reloadSettings(fs)
+	if got := b.settings.Timeout(1); got != 30 {
+		t.Errorf("при ошибке настройки не должны меняться, получили %d", got)
+	}
+}
+
+func TestWatchSettingsReloadsExternalEdit(t *testing.T) {
+	b := setupBot()
+	file := filepath.Join(t.TempDir(), "settings.json")
+	b.storage = newFileStorage(file, b.logger)
+	b.settings.SetTimeout(1, 30)
+	b.writeSettings()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.WatchSettings(ctx)
+	time.Sleep(100 * time.Millisecond) // даём watcher'у подписаться
+
+	// правка «вручную» через временный файл, как делают редакторы
+	tmp := file + ".swp"
+	if err := os.WriteFile(tmp, []byte(`{"version": 1, "chats": {"1": {"timeout": 120}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if b.settings.Timeout(1) == 120 {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Errorf("изменения не подхвачены, таймаут %d", b.settings.Timeout(1))
+}
@@ -0,0 +1,161 @@
+package hamster
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Лимит одновременных проверок в чате
+// ==========================
+
+const (
+	// joinQueueLimit — сколько вступивших сверх MaxPendingPerChat ждут своей
+	// проверки в одном чате. Остальные исключаются и могут вступить снова.
+	joinQueueLimit = 500
+	// joinQueueMute — на сколько ждущий очереди лишается права писать. Очередь
+	// восстанавливается после перезапуска, но если бот так и не вернётся,
+	// ограничение снимет сам Telegram.
+	joinQueueMute = 24 * time.Hour
+)
+
+// queuedJoin — вступление, проверка которого ещё не началась.
+type queuedJoin struct {
+	msg    *Message
+	user   *User
+	raid   bool
+	strict bool
+	soft   bool // проверка одной кнопкой (TrustSoften)
+
+	restricted bool // уже лишён права писать: ждал очереди до перезапуска
+}
+
+// joinQueue считает идущие проверки по чатам и держит очередь вступивших
+// сверх лимита.
+type joinQueue struct {
+	mu      sync.Mutex
+	active  map[int64]int
+	waiting map[int64][]queuedJoin
+}
+
+// acquire занимает место под проверку. Если мест нет, вступление ставится в
+// очередь (queued) — или не ставится, когда и очередь полна. limit <= 0 — без лимита.
+func (q *joinQueue) acquire(chatID int64, limit int, j queuedJoin) (ok, queued bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.active == nil {
+		q.active = make(map[int64]int)
+		q.waiting = make(map[int64][]queuedJoin)
+	}
+	if limit <= 0 || q.active[chatID] < limit {
+		q.active[chatID]++
+		return true, false
+	}
+	if len(q.waiting[chatID]) >= joinQueueLimit {
+		return false, false
+	}
+	q.waiting[chatID] = append(q.waiting[chatID], j)
+	return false, true
+}
+
+// release освобождает место. Если кто-то ждёт, место сразу переходит ему,
+// и release возвращает его вступление.
+func (q *joinQueue) release(chatID int64) (next queuedJoin, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if w := q.waiting[chatID]; len(w) > 0 {
+		next = w[0]
+		if len(w) == 1 {
+			delete(q.waiting, chatID)
+		} else {
+			q.waiting[chatID] = w[1:]
+		}
+		return next, true
+	}
+	if q.active[chatID]--; q.active[chatID] <= 0 {
+		delete(q.active, chatID)
+	}
+	return queuedJoin{}, false
+}
+
+// queued — сколько вступивших ждут проверки в чате.
+func (q *joinQueue) queued(chatID int64) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiting[chatID])
+}
+
+// admitJoin начинает проверку вступившего или, если в чате уже идут
+// MaxPendingPerChat проверок, молча лишает его права писать и ставит в
+// очередь: приветствие он получит, когда освободится место. Когда полна и
+// очередь, вступивший исключается без бана.
+func (b *Bot) admitJoin(j queuedJoin) {
+	chatID := j.msg.Chat.ID
+	ok, queued := b.joinQueue.acquire(chatID, b.cfg.MaxPendingPerChat, j)
+	if ok {
+		if j.restricted {
+			b.safeRestrictUser(b.ctx, chatID, j.user.ID, fullPermissions(), time.Time{})
+		}
+		b.startVerification(j, func() { b.releaseJoin(chatID) })
+		return
+	}
+	if !queued {
+		b.logger.Warn("Очередь проверок чата %d заполнена: %d исключён и может вступить снова", chatID, j.user.ID)
+		b.safeKickUser(b.ctx, chatID, j.user.ID)
+		return
+	}
+	b.safeRestrictUser(b.ctx, chatID, j.user.ID, ChatPermissions{}, time.Now().Add(joinQueueMute))
+	b.updateChatSettings(chatID, func(c *ChatSettings) { c.JoinQueue = append(c.JoinQueue, j.user.ID) })
+	if b.joinQueue.queued(chatID) == 1 {
+		b.logger.Warn("В чате %d идут %d проверок — новые участники ждут очереди", chatID, b.cfg.MaxPendingPerChat)
+		b.sendTemporary(chatID, "⏳ Слишком много проверок одновременно — новые участники получат свою по очереди", time.Minute)
+	}
+}
+
+// releaseJoin вызывается по окончании проверки: место в чате переходит
+// первому из очереди, если он ещё в чате.
+func (b *Bot) releaseJoin(chatID int64) {
+	for {
+		next, ok := b.joinQueue.release(chatID)
+		if !ok {
+			return
+		}
+		b.updateChatSettings(chatID, func(c *ChatSettings) {
+			if i := slices.Index(c.JoinQueue, next.user.ID); i >= 0 {
+				c.JoinQueue = slices.Delete(c.JoinQueue, i, i+1)
+			}
+		})
+		member, err := b.safeGetChatMember(b.ctx, chatID, next.user.ID)
+		if err == nil && (member.Status == "left" || member.Status == "kicked" || member.Status == "restricted" && !member.IsMember) {
+			continue // не дождался — место следующему
+		}
+		b.safeRestrictUser(b.ctx, chatID, next.user.ID, fullPermissions(), time.Time{})
+		b.startVerification(next, func() { b.releaseJoin(chatID) })
+		return
+	}
+}
+
+// restoreJoinQueues после перезапуска снова ставит в очередь тех, кто ждал
+// проверки: очередь в памяти не пережила перезапуск, а без права писать они
+// остались. Ушедшие из чата просто забываются.
+func (b *Bot) restoreJoinQueues() {
+	for chatID, cs := range b.settings.Snapshot() {
+		if len(cs.JoinQueue) == 0 {
+			continue
+		}
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.JoinQueue = nil })
+		b.logger.Info("Восстанавливаем очередь проверок чата %d: %d участников", chatID, len(cs.JoinQueue))
+		for _, userID := range cs.JoinQueue {
+			member, err := b.safeGetChatMember(b.ctx, chatID, userID)
+			if err != nil || member.Status == "left" || member.Status == "kicked" || member.Status == "restricted" && !member.IsMember {
+				continue
+			}
+			user := member.User
+			if user == nil {
+				user = &User{ID: userID}
+			}
+			b.admitJoin(queuedJoin{msg: &Message{Chat: Chat{ID: chatID}}, user: user, restricted: true})
+		}
+	}
+}
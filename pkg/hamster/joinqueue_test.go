@@ -0,0 +1,191 @@
+package hamster
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJoinQueue(t *testing.T) {
+	var q joinQueue
+	j := func(id int64) queuedJoin { return queuedJoin{msg: &Message{}, user: &User{ID: id}} }
+
+	if ok, _ := q.acquire(1, 2, j(11)); !ok {
+		t.Fatal("первое место свободно")
+	}
+	q.acquire(1, 2, j(12))
+	if ok, queued := q.acquire(1, 2, j(13)); ok || !queued {
+		t.Fatalf("сверх лимита вступление ждёт очереди: ok=%v queued=%v", ok, queued)
+	}
+	if ok, _ := q.acquire(2, 2, j(21)); !ok {
+		t.Error("лимит считается для каждого чата отдельно")
+	}
+	if ok, _ := q.acquire(3, 0, j(31)); !ok {
+		t.Error("лимит 0 — без ограничений")
+	}
+
+	next, ok := q.release(1)
+	if !ok || next.user.ID != 13 {
+		t.Fatalf("освободившееся место переходит ожидающему: %v %+v", ok, next.user)
+	}
+	if _, ok := q.release(1); ok {
+		t.Error("очередь пуста")
+	}
+	if ok, _ := q.acquire(1, 2, j(14)); !ok {
+		t.Error("после release место снова свободно")
+	}
+	if ok, _ := q.acquire(1, 2, j(15)); ok {
+		t.Error("занято два места из двух")
+	}
+}
+
+func TestHandleJoinMessageQueuesOverLimit(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.cfg.MaxPendingPerChat = 1
+	var mu sync.Mutex
+	var greeted []int64
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		greeted = append(greeted, int64(len(greeted)+1))
+		return int64(len(greeted)) * 10
+	}
+	var notices []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.Contains(text, "по очереди") {
+			notices = append(notices, text)
+		}
+		return 100
+	}
+	type restriction struct {
+		userID int64
+		perms  ChatPermissions
+	}
+	var restricted []restriction
+	fakeOf(b).restrict = func(chatID, userID int64, perms ChatPermissions, until time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		restricted = append(restricted, restriction{userID, perms})
+	}
+
+	b.handleJoinMessage(&Message{
+		Chat:           Chat{ID: 1},
+		NewChatMembers: []*User{{ID: 11, FirstName: "А"}, {ID: 12, FirstName: "Б"}, {ID: 13, FirstName: "В"}},
+	})
+
+	mu.Lock()
+	if len(greeted) != 1 || len(restricted) != 2 || restricted[0].perms != (ChatPermissions{}) {
+		t.Fatalf("ожидали одно приветствие и двоих без права писать: %v, %+v", greeted, restricted)
+	}
+	if len(notices) != 1 {
+		t.Errorf("чат уведомляется об очереди один раз: %q", notices)
+	}
+	mu.Unlock()
+
+	for b.pendingProgress(1, 11) == nil {
+		time.Sleep(time.Millisecond)
+	}
+	b.stopProgressbar(1, b.pendingProgress(1, 11))
+	for b.pendingProgress(1, 12) == nil {
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(greeted) != 2 {
+		t.Errorf("место должно перейти следующему в очереди, приветствий: %d", len(greeted))
+	}
+	if last := restricted[len(restricted)-1]; last.userID != 12 || last.perms != fullPermissions() {
+		t.Errorf("перед проверкой с ожидавшего снимаются ограничения: %+v", last)
+	}
+	if b.joinQueue.queued(1) != 1 {
+		t.Errorf("в очереди остаётся один участник, а не %d", b.joinQueue.queued(1))
+	}
+}
+
+func TestJoinQueueOverflowAndPersistence(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.cfg.MaxPendingPerChat = 1
+	j := func(id int64) queuedJoin { return queuedJoin{msg: &Message{Chat: Chat{ID: 1}}, user: &User{ID: id}} }
+	b.joinQueue.acquire(1, 1, j(10)) // место занято идущей проверкой
+
+	var mu sync.Mutex
+	var until time.Time
+	restricted := map[int64]bool{}
+	fakeOf(b).restrict = func(chatID, userID int64, perms ChatPermissions, u time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		restricted[userID], until = true, u
+	}
+	kicked := map[int64]bool{}
+	fakeOf(b).unban = func(chatID, userID int64) { mu.Lock(); kicked[userID] = true; mu.Unlock() }
+
+	b.admitJoin(j(11))
+	mu.Lock()
+	if !restricted[11] || until.IsZero() || time.Until(until) > joinQueueMute {
+		t.Errorf("ждущий очереди лишается права писать на время, а не навсегда: до %v", until)
+	}
+	mu.Unlock()
+	if got := b.chatSettings(1).JoinQueue; len(got) != 1 || got[0] != 11 {
+		t.Errorf("очередь должна сохраняться в настройках: %v", got)
+	}
+
+	for i := 1; i < joinQueueLimit; i++ {
+		b.joinQueue.acquire(1, 1, j(int64(1000+i)))
+	}
+	b.admitJoin(j(12))
+	mu.Lock()
+	if restricted[12] || !kicked[12] {
+		t.Errorf("при полной очереди вступивший исключается, а не остаётся без права писать: %v %v", restricted, kicked)
+	}
+	mu.Unlock()
+
+	// место переходит первому в очереди — он уходит из сохранённой очереди
+	b.releaseJoin(1)
+	for b.pendingProgress(1, 11) == nil {
+		time.Sleep(time.Millisecond)
+	}
+	if got := b.chatSettings(1).JoinQueue; len(got) != 0 {
+		t.Errorf("начавший проверку не должен оставаться в сохранённой очереди: %v", got)
+	}
+}
+
+func TestRestoreJoinQueues(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.cfg.MaxPendingPerChat = 1
+	b.updateChatSettings(1, func(c *ChatSettings) { c.JoinQueue = []int64{12, 13, 14} })
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		if userID == 13 {
+			return ChatMember{Status: "left"}, nil
+		}
+		return ChatMember{Status: "restricted", IsMember: true, User: &User{ID: userID, FirstName: "Аня"}}, nil
+	}
+	var mu sync.Mutex
+	freed := map[int64]bool{}
+	fakeOf(b).restrict = func(chatID, userID int64, perms ChatPermissions, until time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		freed[userID] = perms == fullPermissions()
+	}
+
+	b.restoreJoinQueues()
+	for b.pendingProgress(1, 12) == nil {
+		time.Sleep(time.Millisecond)
+	}
+	mu.Lock()
+	if !freed[12] || freed[14] {
+		t.Errorf("первый из очереди получает проверку без ограничений, второй ждёт: %v", freed)
+	}
+	mu.Unlock()
+	if b.joinQueue.queued(1) != 1 {
+		t.Errorf("в очереди должен остаться один участник, а не %d", b.joinQueue.queued(1))
+	}
+	if got := b.chatSettings(1).JoinQueue; len(got) != 1 || got[0] != 14 {
+		t.Errorf("ушедший забывается, ждущий снова сохраняется: %v", got)
+	}
+}
@@ -0,0 +1,102 @@
+package hamster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Вступления и выходы участников
+// ==========================
+
+// allowedUpdates — типы обновлений для getUpdates. chat_member Telegram
+// присылает только по явному запросу.
+var allowedUpdates = []string{"message", "edited_message", "callback_query", "my_chat_member", "chat_member"}
+
+// joinDedupWindow — в течение какого времени повторное вступление того же
+// участника считается тем же событием: в обычных группах оно приходит дважды —
+// сервисным сообщением и обновлением chat_member.
+const joinDedupWindow = 2 * time.Minute
+
+// recentJoins помнит недавние вступления для отсева дублей.
+type recentJoins struct {
+	mu sync.Mutex
+	m  map[string]time.Time
+}
+
+// seen отмечает вступление и сообщает, было ли оно уже учтено.
+func (r *recentJoins) seen(chatID, userID int64, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.m == nil {
+		r.m = make(map[string]time.Time)
+	}
+	for k, t := range r.m {
+		if now.Sub(t) > joinDedupWindow {
+			delete(r.m, k)
+		}
+	}
+	key := fmt.Sprintf("%d:%d", chatID, userID)
+	if _, ok := r.m[key]; ok {
+		return true
+	}
+	r.m[key] = now
+	return false
+}
+
+// forget забывает вступление: вышедший и вернувшийся участник проверяется заново.
+func (r *recentJoins) forget(chatID, userID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.m, fmt.Sprintf("%d:%d", chatID, userID))
+}
+
+// inChat сообщает, что участник находится в чате; restricted бывает и у тех,
+// кто уже вышел (is_member=false).
+func inChat(m ChatMember) bool {
+	if m.Status == "restricted" {
+		return m.IsMember
+	}
+	return isMemberStatus(m.Status)
+}
+
+// handleChatMember запускает проверку, когда участник появляется в чате. Так
+// вступления видны и там, где сервисные сообщения скрыты или не приходят.
+func (b *Bot) handleChatMember(u *ChatMemberUpdated) {
+	user := u.NewChatMember.User
+	if user == nil {
+		return
+	}
+	if u.OldChatMember.Status != u.NewChatMember.Status &&
+		(isAdminStatus(u.OldChatMember.Status) || isAdminStatus(u.NewChatMember.Status)) {
+		// назначен или снят администратор
+		b.forgetAdminStatus(u.Chat.ID, user.ID)
+		b.updateAdminList(u.Chat.ID, u.NewChatMember)
+	}
+	if inChat(u.OldChatMember) && !inChat(u.NewChatMember) {
+		b.recordAdminBan(u)
+		b.handleMemberLeft(u.Chat.ID, user.ID)
+		return
+	}
+	if inChat(u.OldChatMember) || !inChat(u.NewChatMember) {
+		return
+	}
+	if isAdminStatus(u.NewChatMember.Status) {
+		return // назначен сразу администратором
+	}
+	b.handleJoin(&Message{Chat: u.Chat, From: u.From, NewChatMembers: []*User{user}}, u.InviteLink)
+}
+
+// handleMemberLeft отменяет проверку участника, вышедшего до её окончания:
+// иначе по таймауту бот забанил бы того, кого в чате уже нет.
+func (b *Bot) handleMemberLeft(chatID, userID int64) {
+	b.joins.forget(chatID, userID)
+	p := b.pendingProgress(chatID, userID)
+	if p == nil {
+		return
+	}
+	b.logger.Info("Участник %d вышел из чата %d до окончания проверки — проверка отменена", userID, chatID)
+	b.stopProgressbar(chatID, p)
+	b.deletePendingMessages(chatID, userID)
+}
@@ -0,0 +1,117 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecentJoinsDedup(t *testing.T) {
+	var r recentJoins
+	now := time.Now()
+	if r.seen(1, 42, now) {
+		t.Fatal("первое вступление — не дубль")
+	}
+	if !r.seen(1, 42, now.Add(time.Second)) {
+		t.Error("повтор в пределах окна — дубль")
+	}
+	if r.seen(2, 42, now) {
+		t.Error("вступление в другой чат — не дубль")
+	}
+	if r.seen(1, 42, now.Add(joinDedupWindow+time.Second)) {
+		t.Error("после окна вступление снова учитывается")
+	}
+}
+
+func TestHandleChatMemberStartsChallenge(t *testing.T) {
+	b := setupBot()
+	greeted := 0
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { greeted++; return int64(greeted) }
+	user := &User{ID: 42, FirstName: "Аня"}
+
+	b.handleChatMember(&ChatMemberUpdated{
+		Chat:          Chat{ID: -100, Type: "supergroup"},
+		From:          user,
+		OldChatMember: ChatMember{Status: "left", User: user},
+		NewChatMember: ChatMember{Status: "member", User: user},
+	})
+	if greeted != 1 {
+		t.Fatalf("вступление по chat_member должно запускать проверку, приветствий: %d", greeted)
+	}
+
+	// то же вступление сервисным сообщением не проверяется второй раз
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, From: user, NewChatMembers: []*User{user}})
+	if greeted != 1 {
+		t.Errorf("дубль вступления не должен давать второе приветствие, приветствий: %d", greeted)
+	}
+}
+
+func TestHandleChatMemberIgnoresOtherTransitions(t *testing.T) {
+	b := setupBot()
+	var current string
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		t.Errorf("%s: проверка не должна запускаться", current)
+		return 1
+	}
+	user := &User{ID: 42}
+	for name, tr := range map[string][2]ChatMember{
+		"ограничение новичка": {{Status: "member"}, {Status: "restricted", IsMember: true}},
+		"снятие ограничений":  {{Status: "restricted", IsMember: true}, {Status: "member"}},
+		"выход":               {{Status: "member"}, {Status: "left"}},
+		"разбан":              {{Status: "kicked"}, {Status: "left"}},
+		"сразу админом":       {{Status: "left"}, {Status: "administrator"}},
+		"ограничен вне чата":  {{Status: "left"}, {Status: "restricted"}},
+	} {
+		current = name
+		tr[0].User, tr[1].User = user, user
+		b.handleChatMember(&ChatMemberUpdated{Chat: Chat{ID: -100}, OldChatMember: tr[0], NewChatMember: tr[1]})
+	}
+}
+
+func TestMemberLeftCancelsChallenge(t *testing.T) {
+	for name, leave := range map[string]func(b *Bot){
+		"left_chat_member": func(b *Bot) {
+			b.handleUpdate(Update{Message: &Message{MessageID: 5, Chat: Chat{ID: 1}, From: &User{ID: 42}, LeftChatMember: &User{ID: 42}}})
+		},
+		"chat_member": func(b *Bot) {
+			b.handleChatMember(&ChatMemberUpdated{
+				Chat:          Chat{ID: 1},
+				OldChatMember: ChatMember{Status: "restricted", IsMember: true, User: &User{ID: 42}},
+				NewChatMember: ChatMember{Status: "left", User: &User{ID: 42}},
+			})
+		},
+	} {
+		b := setupBot()
+		fakeOf(b).editMessage = func(chatID, msgID int64, text string) {}
+		banned := false
+		fakeOf(b).ban = func(chatID, userID int64) { banned = true }
+		done := startTestChallenge(b, ActionBan, 60)
+
+		leave(b)
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: выход должен сразу завершать проверку", name)
+		}
+		if banned {
+			t.Errorf("%s: вышедшего участника банить не нужно", name)
+		}
+		if b.pendingProgress(1, 42) != nil {
+			t.Errorf("%s: проверка должна быть удалена", name)
+		}
+	}
+}
+
+func TestRejoinAfterLeaveIsVerified(t *testing.T) {
+	b := setupBot()
+	greeted := 0
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { greeted++; return int64(greeted) }
+	user := &User{ID: 42, FirstName: "Аня"}
+	join := &Message{Chat: Chat{ID: -100}, From: user, NewChatMembers: []*User{user}}
+
+	b.handleJoinMessage(join)
+	b.handleMemberLeft(-100, 42)
+	b.handleJoinMessage(join)
+	if greeted != 2 {
+		t.Errorf("вернувшийся участник должен проверяться заново, приветствий: %d", greeted)
+	}
+}
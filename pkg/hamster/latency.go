@@ -0,0 +1,93 @@
+package hamster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Задержка нажатия кнопки
+// ==========================
+
+// clickLatency — время от отправки приветствия до нажатия.
+func clickLatency(p *progressData, now time.Time) time.Duration {
+	sent := p.startedAt
+	if p.session != nil && !p.session.SentAt.IsZero() {
+		sent = p.session.SentAt
+	}
+	if sent.IsZero() {
+		return -1
+	}
+	return now.Sub(sent)
+}
+
+// clickTooFast учитывает задержку нажатия в статистике и, если она меньше
+// MinClickDelay, проваливает проверку как автоматическое нажатие.
+func (b *Bot) clickTooFast(cb *Callback, p *progressData) bool {
+	latency := clickLatency(p, time.Now())
+	if latency < 0 {
+		return false
+	}
+	chatID := cb.Message.Chat.ID
+	tooFast := b.cfg.MinClickDelay > 0 && latency < b.cfg.MinClickDelay
+	b.recordStat(chatID, func(c *ChatStats) {
+		c.Clicks++
+		c.ClickMsSum += latency.Milliseconds()
+		if tooFast {
+			c.TooFast++
+		} else {
+			c.addClick(latency)
+		}
+	})
+	if !tooFast {
+		return false
+	}
+	b.logger.Info("Нажатие %d в чате %d через %v — слишком быстро, считаем ботом", cb.From.ID, chatID, latency)
+	b.safeAnswerCallback(b.ctx, cb.ID, "🤖 Слишком быстро", true)
+	b.failChallenge(p, BanReasonTooFast)
+	return true
+}
+
+// ==========================
+// Распределение задержек в /stats
+// ==========================
+
+// clickPercentiles — какие перцентили задержки показывать в /stats.
+var clickPercentiles = [...]float64{0.5, 0.9, 0.95}
+
+// formatClickPercentile — p-й перцентиль задержки словами.
+func formatClickPercentile(st ChatStats, p float64) string {
+	if d, ok := st.ClickPercentile(p); ok {
+		return fmt.Sprintf("%d%% — до %d сек.", int(p*100), int(d.Seconds()))
+	}
+	return fmt.Sprintf("%d%% — дольше %d сек.", int(p*100), int(clickBuckets[len(clickBuckets)-1].Seconds()))
+}
+
+// formatClickDistribution описывает, за сколько участники нажимают кнопку.
+func formatClickDistribution(st ChatStats) string {
+	if st.clickSamples() == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(clickPercentiles))
+	for _, p := range clickPercentiles {
+		parts = append(parts, formatClickPercentile(st, p))
+	}
+	return "📈 Нажимают кнопку: " + strings.Join(parts, ", ")
+}
+
+// formatTimeoutAdvice сравнивает задержки чата со всеми чатами и подсказывает
+// таймаут, если текущий заметно отличается от предложенного /timeout auto.
+func formatTimeoutAdvice(st, global ChatStats, timeoutSec int) string {
+	var sb strings.Builder
+	if global.clickSamples() > st.clickSamples() {
+		fmt.Fprintf(&sb, "🌍 По всем чатам: %s", formatClickPercentile(global, autoTimeoutPercentile))
+	}
+	if sec, ok := suggestTimeout(st); ok && (sec > timeoutSec || sec*2 < timeoutSec) {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "💡 Подходящий таймаут — около %d сек. (сейчас %d): /timeout auto", sec, timeoutSec)
+	}
+	return sb.String()
+}
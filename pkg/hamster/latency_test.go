@@ -0,0 +1,130 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClickLatency(t *testing.T) {
+	now := time.Now()
+	p := &progressData{startedAt: now.Add(-3 * time.Second)}
+	if got := clickLatency(p, now); got != 3*time.Second {
+		t.Errorf("без сессии считаем от startedAt: %v", got)
+	}
+	p.session = &ChallengeSession{SentAt: now.Add(-5 * time.Second)}
+	if got := clickLatency(p, now); got != 5*time.Second {
+		t.Errorf("с сессией считаем от отправки приветствия: %v", got)
+	}
+	if got := clickLatency(&progressData{}, now); got >= 0 {
+		t.Errorf("без времени отправки задержка неизвестна: %v", got)
+	}
+}
+
+func latencyCallback() *Callback {
+	return &Callback{
+		ID:      "cb",
+		Message: &Message{MessageID: 100, Chat: Chat{ID: -1}},
+		From:    &User{ID: 42, FirstName: "Аня"},
+		Data:    "click:42:TOKEN",
+	}
+}
+
+func TestClickTooFastFails(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.cfg.MinClickDelay = 500 * time.Millisecond
+	p := &progressData{
+		stopChan:   make(chan struct{}),
+		token:      "TOKEN",
+		chatID:     -1,
+		userID:     42,
+		greetMsgID: 100,
+		startedAt:  time.Now().Add(-100 * time.Millisecond),
+	}
+	b.progressStore.add(p)
+	var answer string
+	fakeOf(b).answerCallback = func(id, text string, alert bool) { answer = text }
+
+	b.handleCallback(latencyCallback())
+
+	if p.failReason != BanReasonTooFast {
+		t.Errorf("быстрое нажатие должно проваливать проверку, причина %q", p.failReason)
+	}
+	select {
+	case <-p.stopChan:
+	default:
+		t.Error("прогрессбар должен остановиться")
+	}
+	if !strings.Contains(answer, "Слишком быстро") {
+		t.Errorf("ответ: %q", answer)
+	}
+	st := b.stats.Get(-1)
+	if st.TooFast != 1 || st.Clicks != 1 || st.Passed != 0 {
+		t.Errorf("статистика: %+v", st)
+	}
+}
+
+func TestClickLatencyRecordedOnPass(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.cfg.MinClickDelay = 500 * time.Millisecond
+	b.progressStore.add(&progressData{
+		stopChan:   make(chan struct{}),
+		token:      "TOKEN",
+		chatID:     -1,
+		userID:     42,
+		greetMsgID: 100,
+		startedAt:  time.Now().Add(-2 * time.Second),
+	})
+
+	b.handleCallback(latencyCallback())
+
+	st := b.stats.Get(-1)
+	if st.Passed != 1 || st.Clicks != 1 || st.TooFast != 0 {
+		t.Fatalf("статистика: %+v", st)
+	}
+	if avg := st.AvgClick(); avg < 2*time.Second || avg > 3*time.Second {
+		t.Errorf("средняя задержка %v, ожидали около 2 сек.", avg)
+	}
+}
+
+func TestFormatClickDistribution(t *testing.T) {
+	if got := formatClickDistribution(ChatStats{}); got != "" {
+		t.Errorf("без нажатий распределения нет: %q", got)
+	}
+	var st ChatStats
+	for i := 0; i < 18; i++ {
+		st.addClick(4 * time.Second)
+	}
+	st.addClick(25 * time.Second)
+	st.addClick(time.Hour)
+	got := formatClickDistribution(st)
+	for _, want := range []string{"50% — до 5 сек.", "90% — до 5 сек.", "95% — до 30 сек."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("ожидали %q в %q", want, got)
+		}
+	}
+	st.addClick(time.Hour)
+	st.addClick(time.Hour)
+	if got := formatClickDistribution(st); !strings.Contains(got, "95% — дольше 600 сек.") {
+		t.Errorf("перцентиль в открытом интервале: %q", got)
+	}
+}
+
+func TestFormatTimeoutAdvice(t *testing.T) {
+	var st ChatStats
+	for i := 0; i < autoTimeoutMinClicks; i++ {
+		st.addClick(4 * time.Second)
+	}
+	global := st
+	global.addClick(50 * time.Second)
+
+	got := formatTimeoutAdvice(st, global, 120)
+	if !strings.Contains(got, "По всем чатам") || !strings.Contains(got, "около 15 сек. (сейчас 120)") {
+		t.Errorf("ожидали сравнение и подсказку: %q", got)
+	}
+	if got := formatTimeoutAdvice(st, st, 20); got != "" {
+		t.Errorf("подходящий таймаут и только этот чат — без подсказок: %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package hamster
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Выбор ведущего экземпляра
+// ==========================
+
+const (
+	// leaderKey — блокировка ведущего экземпляра в общем хранилище.
+	leaderKey = "leader:polling"
+	// leaderTTL — через сколько без продления ведущим может стать другой экземпляр.
+	leaderTTL = 30 * time.Second
+	// leaderRenew — как часто ведущий продлевает блокировку, а остальные пытаются её захватить.
+	leaderRenew = 10 * time.Second
+)
+
+// runAsLeader выполняет lead, пока этот экземпляр — ведущий, и раз в every
+// продлевает или пытается захватить блокировку. Telegram отдаёт обновления
+// только одному получателю, поэтому остальные экземпляры ждут и подхватывают
+// polling, если ведущий перестал продлевать блокировку.
+func (b *Bot) runAsLeader(ctx context.Context, every time.Duration, lead func(ctx context.Context)) {
+	locker := b.storage.(Locker)
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if ok, err := locker.TryLock(leaderKey, b.instanceID, leaderTTL); err != nil {
+			b.logger.Warn("Не удалось проверить, кто ведущий: %v", err)
+		} else if ok {
+			b.leadWhileLocked(ctx, locker, ticker, lead)
+		}
+		select {
+		case <-ctx.Done():
+			if err := locker.Unlock(leaderKey, b.instanceID); err != nil {
+				b.logger.Warn("Не удалось снять блокировку ведущего: %v", err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead — работа ведущего: polling и фоновые задачи, которые пишут состояние.
+// Хранилище сохраняет настройки и верификации целиком, поэтому запись из
+// устаревшей копии ведомого экземпляра затёрла бы изменения ведущего.
+func (b *Bot) lead(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range []func(context.Context){b.RunCleanup, b.RunJanitor, b.RunStaleChatGC} {
+		wg.Go(func() { job(ctx) })
+	}
+	b.poll(ctx)
+	wg.Wait()
+}
+
+// electsLeader сообщает, выбирается ли ведущий среди нескольких экземпляров.
+func (b *Bot) electsLeader() bool {
+	_, ok := b.storage.(Locker)
+	return b.cfg.LeaderElection && ok
+}
+
+// isLeader сообщает, может ли экземпляр менять общее состояние: без выборов
+// ведущего — всегда, с ними — пока он держит блокировку.
+func (b *Bot) isLeader() bool {
+	return !b.electsLeader() || b.leading.Load()
+}
+
+// leaderOnly пропускает запрос к REST API только на ведущем экземпляре;
+// остальные отвечают 503, чтобы оператор повторил запрос к ведущему.
+func (b *Bot) leaderOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !b.isLeader() {
+			writeError(w, http.StatusServiceUnavailable, "экземпляр "+b.instanceID+" не ведущий: изменения принимает только ведущий")
+			return
+		}
+		h(w, r)
+	}
+}
+
+// leadWhileLocked запускает lead и продлевает блокировку, пока это удаётся.
+func (b *Bot) leadWhileLocked(ctx context.Context, locker Locker, ticker *time.Ticker, lead func(ctx context.Context)) {
+	b.logger.Info("👑 Экземпляр %s стал ведущим", b.instanceID)
+	// пока экземпляр ждал, состояние в хранилище обновлял прежний ведущий
+	b.loadSettings()
+	b.loadState()
+	b.leading.Store(true)
+
+	leadCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+	defer func() {
+		b.leading.Store(false)
+		stop()
+		<-done
+		// смещение, сообщения бота и настройки — для следующего ведущего
+		b.FlushSettings()
+		if b.stateSaver != nil {
+			b.stateSaver.Flush()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		// при ошибке хранилища уступаем: двое ведущих хуже паузы в polling
+		if ok, err := locker.TryLock(leaderKey, b.instanceID, leaderTTL); err != nil || !ok {
+			b.logger.Warn("Экземпляр %s больше не ведущий: %v", b.instanceID, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package hamster
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLeaderElectionHandover(t *testing.T) {
+	shared := newMemLockStorage()
+	first, second := setupBot(), setupBot()
+	first.storage, first.instanceID = shared, "a"
+	second.storage, second.instanceID = shared, "b"
+
+	leading := make(chan string, 2)
+	lead := func(name string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			leading <- name
+			<-ctx.Done()
+		}
+	}
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	go func() { first.runAsLeader(ctxA, 5*time.Millisecond, lead("a")); close(doneA) }()
+	if got := <-leading; got != "a" {
+		t.Fatalf("ведущим должен стать первый экземпляр, стал %s", got)
+	}
+
+	ctxB, stopB := context.WithCancel(context.Background())
+	defer stopB()
+	go second.runAsLeader(ctxB, 5*time.Millisecond, lead("b"))
+	select {
+	case got := <-leading:
+		t.Fatalf("пока первый ведущий, второй ждёт; ведущим стал %s", got)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	stopA()
+	<-doneA
+	select {
+	case got := <-leading:
+		if got != "b" {
+			t.Errorf("после остановки первого ведущим должен стать второй, стал %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("второй экземпляр не подхватил polling")
+	}
+}
+
+func TestLeaderStepsDownWhenLockLost(t *testing.T) {
+	shared := newMemLockStorage()
+	b := setupBot()
+	b.storage, b.instanceID = shared, "a"
+
+	stopped := make(chan struct{})
+	started := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.runAsLeader(ctx, 5*time.Millisecond, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+	})
+	<-started
+	// блокировку перехватил другой экземпляр (например, истекла во время паузы GC)
+	shared.mu.Lock()
+	shared.locks[leaderKey] = "b"
+	shared.mu.Unlock()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("потеряв блокировку, экземпляр должен остановить polling")
+	}
+}
+
+func TestOnlyLeaderWritesState(t *testing.T) {
+	shared := newMemLockStorage()
+	shared.fileStorage = newFileStorage(filepath.Join(t.TempDir(), "settings.json"), NewLogger())
+	first, second := setupAdminBot(), setupAdminBot()
+	for i, b := range []*Bot{first, second} {
+		b.storage, b.instanceID = shared, []string{"a", "b"}[i]
+		b.cfg.LeaderElection = true
+		b.cfg.BanLogRetention = time.Hour
+		b.recentBans = newBanHistory(10)
+		b.recentBans.add(BanLogEntry{ChatID: -100, UserID: 5, At: time.Now().Add(-2 * time.Hour)})
+	}
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	doneA := make(chan struct{})
+	go func() { first.runAsLeader(ctxA, 5*time.Millisecond, first.lead); close(doneA) }()
+	waitFor(t, first.isLeader, "первый экземпляр не стал ведущим")
+	waitFor(t, func() bool { return len(first.recentBans.list()) == 0 }, "ведущий применяет сроки хранения")
+
+	ctxB, stopB := context.WithCancel(context.Background())
+	doneB := make(chan struct{})
+	defer func() { stopB(); <-doneB }()
+	go func() { second.runAsLeader(ctxB, 5*time.Millisecond, second.lead); close(doneB) }()
+	time.Sleep(30 * time.Millisecond)
+	if second.isLeader() || len(second.recentBans.list()) != 1 {
+		t.Fatal("ведомый экземпляр не должен запускать задачи, меняющие состояние")
+	}
+
+	// изменения через REST API принимает только ведущий
+	if rec := adminRequest(t, second.AdminHandler(), "PUT", "/api/chats/-100/settings", `{"timeout":120}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("PUT на ведомом: код %d, ожидали 503", rec.Code)
+	}
+	if rec := adminRequest(t, second.AdminHandler(), "DELETE", "/api/users/5", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("DELETE на ведомом: код %d, ожидали 503", rec.Code)
+	}
+	if second.settings.Get(-100).Timeout == 120 {
+		t.Error("ведомый изменил настройки")
+	}
+	if rec := adminRequest(t, first.AdminHandler(), "PUT", "/api/chats/-100/settings", `{"timeout":120}`); rec.Code != http.StatusOK {
+		t.Fatalf("PUT на ведущем: код %d, тело %s", rec.Code, rec.Body)
+	}
+
+	// новый ведущий перечитывает настройки прежнего и берёт на себя задачи
+	stopA()
+	<-doneA
+	waitFor(t, second.isLeader, "второй экземпляр не подхватил работу ведущего")
+	if got := second.settings.Get(-100).Timeout; got != 120 {
+		t.Errorf("новый ведущий не перечитал настройки: timeout=%d", got)
+	}
+	waitFor(t, func() bool { return len(second.recentBans.list()) == 0 }, "новый ведущий применяет сроки хранения")
+}
+
+// waitFor ждёт до секунды, пока cond не станет истинным.
+func waitFor(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,166 @@
+package hamster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Политики ссылок-приглашений
+// ==========================
+
+const (
+	// LinkTrusted — вступившие по ссылке не проходят проверку.
+	LinkTrusted = "trusted"
+	// LinkHard — усиленная проверка, как во время наплыва.
+	LinkHard = "hard"
+	// LinkBan — вступившие по ссылке сразу банятся.
+	LinkBan = "ban"
+)
+
+// ChatInviteLink — ссылка-приглашение из обновления chat_member. Ссылки,
+// созданные другими администраторами, Telegram присылает обрезанными
+// («https://t.me/+AbC...»), но одинаково для каждого вступления.
+type ChatInviteLink struct {
+	InviteLink         string `json:"invite_link"`
+	Name               string `json:"name,omitempty"`
+	CreatesJoinRequest bool   `json:"creates_join_request,omitempty"`
+	IsPrimary          bool   `json:"is_primary,omitempty"`
+}
+
+// String — имя ссылки, если оно задано, иначе сама ссылка.
+func (l *ChatInviteLink) String() string {
+	if l.Name != "" {
+		return l.Name
+	}
+	return l.InviteLink
+}
+
+// normalizeLinkKey приводит ссылку из команды к виду, в котором её присылает
+// Telegram; имена ссылок не меняются.
+func normalizeLinkKey(key string) string {
+	key = strings.TrimSpace(key)
+	if strings.HasPrefix(key, "t.me/") || strings.HasPrefix(key, "telegram.me/") {
+		key = "https://" + key
+	}
+	return strings.Replace(key, "http://", "https://", 1)
+}
+
+// linkPolicy возвращает политику ссылки: сначала по самой ссылке, затем по
+// её имени. "" — политики нет.
+func (b *Bot) linkPolicy(chatID int64, link *ChatInviteLink) string {
+	if link == nil {
+		return ""
+	}
+	policies := b.chatSettings(chatID).LinkPolicies
+	if p, ok := policies[link.InviteLink]; ok {
+		return p
+	}
+	if link.Name != "" {
+		return policies[link.Name]
+	}
+	return ""
+}
+
+// banByLink банит вступившего по ссылке с политикой LinkBan.
+func (b *Bot) banByLink(chatID, userID int64, link *ChatInviteLink) {
+	b.logger.Info("Участник %d вступил в чат %d по ссылке %s — бан по политике ссылки", userID, chatID, link)
+	b.safeBanUser(b.ctx, chatID, userID)
+	b.logBan(chatID, userID, BanReasonInviteLink)
+	b.recordStat(chatID, func(c *ChatStats) { c.Banned++ })
+	b.recordLinkStat(chatID, userID, func(l *LinkStats) { l.Banned++ })
+}
+
+// trustJoin пропускает вступившего по доверенной ссылке без проверки.
+func (b *Bot) trustJoin(chatID, userID int64, link *ChatInviteLink) {
+	b.logger.Info("Участник %d вступил в чат %d по доверенной ссылке %s — без проверки", userID, chatID, link)
+	b.markVerified(chatID, userID)
+}
+
+// applyLateLinkPolicy применяет политику ссылки, когда chat_member пришёл
+// после сервисного сообщения и проверка уже идёт: по доверенной ссылке она
+// засчитывается, по запрещённой — проваливается.
+func (b *Bot) applyLateLinkPolicy(chatID int64, user *User, link *ChatInviteLink) {
+	policy := b.linkPolicy(chatID, link)
+	if policy != LinkTrusted && policy != LinkBan {
+		return
+	}
+	p := b.pendingProgress(chatID, user.ID)
+	if p == nil {
+		return
+	}
+	if policy == LinkTrusted {
+		b.logger.Info("Участник %d вступил в чат %d по доверенной ссылке %s — проверка засчитана", user.ID, chatID, link)
+		b.passChallenge(chatID, user, p)
+		return
+	}
+	b.logger.Info("Участник %d вступил в чат %d по ссылке %s — бан по политике ссылки", user.ID, chatID, link)
+	b.failVerification(chatID, p, BanReasonInviteLink)
+}
+
+// ==========================
+// Команда /links
+// ==========================
+
+var linkPolicyNames = map[string]string{
+	LinkTrusted: "без проверки",
+	LinkHard:    "усиленная проверка",
+	LinkBan:     "бан",
+}
+
+func (b *Bot) handleLinksCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может настраивать политики ссылок", 5*time.Second)
+		return
+	}
+
+	const usage = "⚙️ Использование: /links <ссылка или имя> trusted|hard|ban|reset"
+	args := strings.Fields(commandArg(msg.Text, 1))
+	switch {
+	case len(args) == 0:
+		b.sendTemporary(chatID, linksStatus(b.chatSettings(chatID).LinkPolicies)+"\n"+usage, 20*time.Second)
+	case len(args) != 2:
+		b.sendTemporary(chatID, usage, 5*time.Second)
+	default:
+		key, policy := normalizeLinkKey(args[0]), strings.ToLower(args[1])
+		switch policy {
+		case "reset":
+			b.updateChatSettings(chatID, func(c *ChatSettings) { delete(c.LinkPolicies, key) })
+			b.sendTemporary(chatID, fmt.Sprintf("✅ Для %s снова обычная проверка", key), 5*time.Second)
+		case LinkTrusted, LinkHard, LinkBan:
+			b.updateChatSettings(chatID, func(c *ChatSettings) {
+				if c.LinkPolicies == nil {
+					c.LinkPolicies = make(map[string]string)
+				}
+				c.LinkPolicies[key] = policy
+			})
+			b.sendTemporary(chatID, fmt.Sprintf("✅ %s: %s", key, linkPolicyNames[policy]), 5*time.Second)
+		default:
+			b.sendTemporary(chatID, usage, 5*time.Second)
+		}
+	}
+}
+
+// linksStatus — список политик ссылок чата.
+func linksStatus(policies map[string]string) string {
+	if len(policies) == 0 {
+		return "🔗 Политик ссылок нет: все проходят обычную проверку"
+	}
+	keys := make([]string, 0, len(policies))
+	for k := range policies {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.WriteString("🔗 Политики ссылок:")
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "\n%s — %s", k, linkPolicyNames[policies[k]])
+	}
+	return sb.String()
+}
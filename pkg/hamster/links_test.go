@@ -0,0 +1,134 @@
+package hamster
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// joinVia присылает chat_member о вступлении по ссылке.
+func joinVia(b *Bot, userID int64, link *ChatInviteLink) {
+	user := &User{ID: userID, FirstName: "Гость"}
+	b.handleChatMember(&ChatMemberUpdated{
+		Chat:          Chat{ID: -100},
+		OldChatMember: ChatMember{Status: "left", User: user},
+		NewChatMember: ChatMember{Status: "member", User: user},
+		InviteLink:    link,
+	})
+}
+
+func isVerified(b *Bot, chatID, userID int64) bool {
+	_, ok := b.verified.since(chatID, userID)
+	return ok
+}
+
+func TestLinkPolicies(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.verified = newVerifiedUsers()
+	b.settings.Update(-100, func(c *ChatSettings) {
+		c.LinkPolicies = map[string]string{
+			"https://t.me/+spam": LinkBan,
+			"Друзья":             LinkTrusted,
+			"https://t.me/+open": LinkHard,
+		}
+	})
+	var mu sync.Mutex
+	greetings := map[int64]string{}
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		greetings[int64(len(greetings)+1)] = text
+		return int64(len(greetings))
+	}
+	var banned []int64
+	fakeOf(b).ban = func(chatID, userID int64) { banned = append(banned, userID) }
+
+	joinVia(b, 1, &ChatInviteLink{InviteLink: "https://t.me/+spam"})
+	joinVia(b, 2, &ChatInviteLink{InviteLink: "https://t.me/+AbC...", Name: "Друзья"})
+	joinVia(b, 3, &ChatInviteLink{InviteLink: "https://t.me/+open"})
+	joinVia(b, 4, &ChatInviteLink{InviteLink: "https://t.me/+other"})
+
+	if len(banned) != 1 || banned[0] != 1 {
+		t.Errorf("забанен должен быть только вступивший по запрещённой ссылке: %v", banned)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(greetings) != 2 {
+		t.Fatalf("проверку проходят только вступившие по ссылкам без политики и с усиленной: %v", greetings)
+	}
+	if !strings.Contains(greetings[1], "Сколько будет") {
+		t.Errorf("по ссылке hard ожидали усиленную проверку: %q", greetings[1])
+	}
+	if strings.Contains(greetings[2], "Сколько будет") {
+		t.Errorf("по ссылке без политики — обычная проверка: %q", greetings[2])
+	}
+	if !isVerified(b, -100, 2) {
+		t.Error("вступивший по доверенной ссылке считается проверенным")
+	}
+}
+
+func TestLateLinkPolicy(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.verified = newVerifiedUsers()
+	b.settings.Update(-100, func(c *ChatSettings) { c.LinkPolicies = map[string]string{"Друзья": LinkTrusted} })
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { return 10 }
+	banned := false
+	fakeOf(b).ban = func(chatID, userID int64) { banned = true }
+
+	// сервисное сообщение пришло раньше chat_member
+	user := &User{ID: 42, FirstName: "Аня"}
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, From: user, NewChatMembers: []*User{user}})
+	deadline := time.Now().Add(time.Second)
+	for b.pendingProgress(-100, 42) == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	joinVia(b, 42, &ChatInviteLink{InviteLink: "https://t.me/+AbC...", Name: "Друзья"})
+
+	if b.pendingProgress(-100, 42) != nil {
+		t.Error("проверка вступившего по доверенной ссылке должна засчитываться")
+	}
+	if banned || !isVerified(b, -100, 42) {
+		t.Errorf("участник должен считаться проверенным: banned=%v", banned)
+	}
+}
+
+func TestLinksCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:42": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { sent = append(sent, text); return 1 }
+	links := func(from int64, text string) {
+		b.handleLinksCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: from}, Text: text})
+	}
+
+	links(42, "/links t.me/+AbC ban")
+	links(42, "/links Друзья TRUSTED")
+	if got := b.chatSettings(-100).LinkPolicies; got["https://t.me/+AbC"] != LinkBan || got["Друзья"] != LinkTrusted {
+		t.Fatalf("политики не сохранены: %v", got)
+	}
+	links(42, "/links")
+	if last := sent[len(sent)-1]; !strings.Contains(last, "https://t.me/+AbC — бан") || !strings.Contains(last, "Друзья — без проверки") {
+		t.Errorf("список политик: %q", last)
+	}
+	links(42, "/links https://t.me/+AbC reset")
+	if got := b.chatSettings(-100).LinkPolicies; len(got) != 1 {
+		t.Errorf("reset снимает политику ссылки: %v", got)
+	}
+	links(42, "/links Друзья whatever")
+	if last := sent[len(sent)-1]; !strings.Contains(last, "Использование") {
+		t.Errorf("неизвестная политика — подсказка: %q", last)
+	}
+
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) { return ChatMember{Status: "member"}, nil }
+	links(7, "/links Друзья reset")
+	if got := b.chatSettings(-100).LinkPolicies; len(got) != 1 {
+		t.Errorf("не администратор не меняет политики: %v", got)
+	}
+
+	if err := (ChatSettings{LinkPolicies: map[string]string{"x": "allow"}}).Validate(); err == nil {
+		t.Error("неизвестная политика ссылки не проходит Validate")
+	}
+}
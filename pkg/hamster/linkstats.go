@@ -0,0 +1,146 @@
+package hamster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Статистика по ссылкам-приглашениям
+// ==========================
+
+// LinkStats — счётчики вступивших по одной ссылке-приглашению.
+type LinkStats struct {
+	Joins  int64 `json:"joins"`
+	Passed int64 `json:"passed"`
+	Failed int64 `json:"failed"`
+	Banned int64 `json:"banned"` // забанены без проверки (фильтр имён, оценка, политика ссылки)
+}
+
+const (
+	// maxLinkStats — сколько разных ссылок учитывается в одном чате; вступления
+	// по остальным попадают только в общие счётчики.
+	maxLinkStats = 100
+	// linkAttributionWindow — сколько помнить, по какой ссылке вступил
+	// участник, чтобы отнести к ней исход проверки.
+	linkAttributionWindow = time.Hour
+	// linkStatsTop — сколько ссылок показывает /stats.
+	linkStatsTop = 10
+)
+
+// joinLinks помнит, по какой ссылке вступил участник, пока идёт его проверка.
+type joinLinks struct {
+	mu sync.Mutex
+	m  map[string]joinLink
+}
+
+type joinLink struct {
+	link string
+	at   time.Time
+}
+
+// put запоминает ссылку вступившего, попутно забывая устаревшие.
+func (j *joinLinks) put(chatID, userID int64, link string, now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.m == nil {
+		j.m = make(map[string]joinLink)
+	}
+	for k, l := range j.m {
+		if now.Sub(l.at) > linkAttributionWindow {
+			delete(j.m, k)
+		}
+	}
+	j.m[fmt.Sprintf("%d:%d", chatID, userID)] = joinLink{link: link, at: now}
+}
+
+// take возвращает и забывает ссылку вступившего; "" — неизвестна.
+func (j *joinLinks) take(chatID, userID int64, now time.Time) string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	key := fmt.Sprintf("%d:%d", chatID, userID)
+	l, ok := j.m[key]
+	if !ok {
+		return ""
+	}
+	delete(j.m, key)
+	if now.Sub(l.at) > linkAttributionWindow {
+		return ""
+	}
+	return l.link
+}
+
+// recordLinkJoin учитывает вступление по ссылке и запоминает её до исхода
+// проверки. Без ссылки ничего не делает.
+func (b *Bot) recordLinkJoin(chatID, userID int64, link *ChatInviteLink) {
+	if link == nil {
+		return
+	}
+	key := link.String()
+	b.joinLinks.put(chatID, userID, key, time.Now())
+	b.recordStat(chatID, func(c *ChatStats) {
+		if _, ok := c.Links[key]; !ok && len(c.Links) >= maxLinkStats {
+			return
+		}
+		if c.Links == nil {
+			c.Links = make(map[string]LinkStats)
+		}
+		l := c.Links[key]
+		l.Joins++
+		c.Links[key] = l
+	})
+}
+
+// recordLinkStat относит исход проверки к ссылке, по которой вступил
+// участник. Если ссылка неизвестна, ничего не делает.
+func (b *Bot) recordLinkStat(chatID, userID int64, fn func(l *LinkStats)) {
+	key := b.joinLinks.take(chatID, userID, time.Now())
+	if key == "" {
+		return
+	}
+	b.recordStat(chatID, func(c *ChatStats) {
+		l, ok := c.Links[key]
+		if !ok {
+			return // ссылка не попала в лимит maxLinkStats
+		}
+		fn(&l)
+		c.Links[key] = l
+	})
+}
+
+// formatLinkStats описывает ссылки с наибольшим числом вступлений; "" — по
+// ссылкам никто не вступал.
+func formatLinkStats(links map[string]LinkStats) string {
+	if len(links) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(links))
+	for k := range links {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := links[keys[i]], links[keys[j]]
+		if a.Joins != b.Joins {
+			return a.Joins > b.Joins
+		}
+		return keys[i] < keys[j]
+	})
+	var sb strings.Builder
+	sb.WriteString("🔗 По ссылкам:")
+	for i, k := range keys {
+		if i == linkStatsTop {
+			fmt.Fprintf(&sb, "\n…и ещё %d", len(keys)-linkStatsTop)
+			break
+		}
+		l := links[k]
+		fmt.Fprintf(&sb, "\n%s — вступили %d, прошли %d", k, l.Joins, l.Passed)
+		if done := l.Passed + l.Failed; done > 0 {
+			fmt.Fprintf(&sb, " (%d%%)", l.Passed*100/done)
+		}
+		fmt.Fprintf(&sb, ", не прошли %d, забанены %d", l.Failed, l.Banned)
+	}
+	return sb.String()
+}
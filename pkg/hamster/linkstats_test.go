@@ -0,0 +1,129 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLinkStatsRecordsOutcomes(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.stats = NewStats()
+	b.settings.Update(-100, func(c *ChatSettings) { c.LinkPolicies = map[string]string{"https://t.me/+spam": LinkBan} })
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { return 10 }
+
+	joinVia(b, 1, &ChatInviteLink{InviteLink: "https://t.me/+spam"})
+	joinVia(b, 2, &ChatInviteLink{InviteLink: "https://t.me/+AbC...", Name: "Реклама"})
+	deadline := time.Now().Add(time.Second)
+	for b.pendingProgress(-100, 2) == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	p := b.pendingProgress(-100, 2)
+	if p == nil {
+		t.Fatal("проверка вступившего по ссылке без политики не началась")
+	}
+	b.passChallenge(-100, &User{ID: 2}, p)
+
+	links := b.stats.Get(-100).Links
+	if got := links["https://t.me/+spam"]; got != (LinkStats{Joins: 1, Banned: 1}) {
+		t.Errorf("по запрещённой ссылке ожидали вход и бан: %+v", got)
+	}
+	if got := links["Реклама"]; got != (LinkStats{Joins: 1, Passed: 1}) {
+		t.Errorf("ссылка учитывается по имени, ожидали вход и прохождение: %+v", got)
+	}
+}
+
+func TestLinkStatsLateChatMember(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.stats = NewStats()
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { return 10 }
+
+	// сервисное сообщение пришло раньше chat_member
+	user := &User{ID: 42, FirstName: "Аня"}
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, From: user, NewChatMembers: []*User{user}})
+	deadline := time.Now().Add(time.Second)
+	for b.pendingProgress(-100, 42) == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	joinVia(b, 42, &ChatInviteLink{InviteLink: "https://t.me/+x"})
+	p := b.pendingProgress(-100, 42)
+	if p == nil {
+		t.Fatal("проверка должна идти")
+	}
+	b.failVerification(-100, p, BanReasonTimeout)
+
+	st := b.stats.Get(-100)
+	if st.Joins != 1 {
+		t.Errorf("вступление считается один раз: %d", st.Joins)
+	}
+	if got := st.Links["https://t.me/+x"]; got != (LinkStats{Joins: 1, Failed: 1}) {
+		t.Errorf("исход проверки относится к ссылке из запоздавшего chat_member: %+v", got)
+	}
+}
+
+func TestLinkStatsLimit(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	for i := 0; i <= maxLinkStats; i++ {
+		b.recordLinkJoin(-100, int64(i), &ChatInviteLink{InviteLink: "https://t.me/+" + strings.Repeat("a", i+1)})
+	}
+	if got := len(b.stats.Get(-100).Links); got != maxLinkStats {
+		t.Errorf("ожидали не больше %d ссылок, получили %d", maxLinkStats, got)
+	}
+	// исход по неучтённой ссылке не создаёт её заново
+	b.recordLinkStat(-100, maxLinkStats, func(l *LinkStats) { l.Passed++ })
+	if got := len(b.stats.Get(-100).Links); got != maxLinkStats {
+		t.Errorf("ссылок стало %d", got)
+	}
+}
+
+func TestJoinLinksExpire(t *testing.T) {
+	var j joinLinks
+	now := time.Now()
+	j.put(1, 2, "Друзья", now)
+	if got := j.take(1, 2, now.Add(linkAttributionWindow+time.Second)); got != "" {
+		t.Errorf("устаревшая ссылка не должна возвращаться: %q", got)
+	}
+	j.put(1, 2, "Друзья", now)
+	if got := j.take(1, 2, now); got != "Друзья" {
+		t.Errorf("ожидали ссылку Друзья, получили %q", got)
+	}
+	if got := j.take(1, 2, now); got != "" {
+		t.Errorf("ссылка отдаётся один раз: %q", got)
+	}
+}
+
+func TestStatsMoveMergesLinks(t *testing.T) {
+	s := NewStats()
+	s.add(1, func(c *ChatStats) { c.Links = map[string]LinkStats{"a": {Joins: 1, Failed: 1}} })
+	s.add(2, func(c *ChatStats) { c.Links = map[string]LinkStats{"a": {Joins: 2, Passed: 2}} })
+	s.Move(1, 2)
+	if got := s.Get(2).Links["a"]; got != (LinkStats{Joins: 3, Passed: 2, Failed: 1}) {
+		t.Errorf("счётчики ссылок не сложились: %+v", got)
+	}
+	// Get отдаёт копию
+	s.Get(2).Links["a"] = LinkStats{}
+	if s.Get(2).Links["a"].Joins != 3 {
+		t.Error("изменение копии не должно менять статистику")
+	}
+}
+
+func TestFormatStatsLinks(t *testing.T) {
+	text := formatStats(ChatStats{Joins: 5, Links: map[string]LinkStats{
+		"Друзья":             {Joins: 1, Passed: 1},
+		"https://t.me/+spam": {Joins: 4, Passed: 1, Failed: 3, Banned: 0},
+	}})
+	for _, want := range []string{"🔗 По ссылкам:", "https://t.me/+spam — вступили 4, прошли 1 (25%), не прошли 3", "Друзья — вступили 1, прошли 1 (100%)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("нет %q в %q", want, text)
+		}
+	}
+	if strings.Index(text, "spam") > strings.Index(text, "Друзья") {
+		t.Errorf("ссылки сортируются по числу вступлений: %q", text)
+	}
+	if strings.Contains(formatStats(ChatStats{}), "По ссылкам") {
+		t.Error("без ссылок раздел не нужен")
+	}
+}
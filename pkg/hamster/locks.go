@@ -0,0 +1,60 @@
+package hamster
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ==========================
+// Блокировки между экземплярами бота
+// ==========================
+
+// Locker — хранилище, общее для нескольких экземпляров бота, которое умеет
+// выдавать блокировки с истечением. Реализует его StoragePostgres; с
+// файловым хранилищем и bbolt экземпляр всегда один и блокировки не нужны.
+type Locker interface {
+	// TryLock захватывает key для owner на ttl и сообщает, удалось ли.
+	// Повторный захват тем же owner продлевает блокировку.
+	TryLock(key, owner string, ttl time.Duration) (bool, error)
+	// Unlock снимает блокировку, если её держит owner.
+	Unlock(key, owner string) error
+}
+
+// newInstanceID возвращает имя экземпляра бота для блокировок: INSTANCE_ID
+// или имя хоста со случайным суффиксом.
+func newInstanceID(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	host, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// tryLock захватывает блокировку в общем хранилище. Без него, как и при ошибке
+// хранилища, считается захваченной: лучше проверить участника дважды, чем ни разу.
+func (b *Bot) tryLock(key string, ttl time.Duration) bool {
+	l, ok := b.storage.(Locker)
+	if !ok {
+		return true
+	}
+	got, err := l.TryLock(key, b.instanceID, ttl)
+	if err != nil {
+		b.logger.Warn("Блокировка %s не получена: %v", key, err)
+		return true
+	}
+	return got
+}
+
+// claimJoin закрепляет проверку участника за этим экземпляром, чтобы другие
+// не отправили второе приветствие и не забанили его повторно. Блокировка
+// держится до конца проверки с запасом и не снимается: повторное событие о
+// том же вступлении должно отсеиваться и после неё.
+func (b *Bot) claimJoin(chatID, userID int64) bool {
+	ttl := time.Duration(b.chatSettings(chatID).TimeoutSec())*time.Second + joinDedupWindow
+	return b.tryLock(fmt.Sprintf("join:%d:%d", chatID, userID), ttl)
+}
@@ -0,0 +1,76 @@
+package hamster
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// memLockStorage — общее хранилище в памяти для нескольких экземпляров бота.
+type memLockStorage struct {
+	*fileStorage
+	mu    sync.Mutex
+	locks map[string]string // key → owner; истечение в тестах не нужно
+}
+
+func newMemLockStorage() *memLockStorage {
+	return &memLockStorage{fileStorage: newFileStorage("", NewLogger()), locks: make(map[string]string)}
+}
+
+func (m *memLockStorage) TryLock(key, owner string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cur, ok := m.locks[key]; ok && cur != owner {
+		return false, nil
+	}
+	m.locks[key] = owner
+	return true, nil
+}
+
+func (m *memLockStorage) Unlock(key, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.locks[key] == owner {
+		delete(m.locks, key)
+	}
+	return nil
+}
+
+func TestClaimJoinAcrossInstances(t *testing.T) {
+	shared := newMemLockStorage()
+	first, second := setupBot(), setupBot()
+	first.storage, first.instanceID = shared, "a"
+	second.storage, second.instanceID = shared, "b"
+
+	if !first.claimJoin(-100, 42) {
+		t.Fatal("первый экземпляр должен получить проверку")
+	}
+	if second.claimJoin(-100, 42) {
+		t.Error("второй экземпляр не должен проверять того же участника")
+	}
+	if !second.claimJoin(-100, 43) {
+		t.Error("другого участника может проверять любой экземпляр")
+	}
+}
+
+func TestJoinSkippedWhenClaimedElsewhere(t *testing.T) {
+	shared := newMemLockStorage()
+	b := setupBot()
+	b.storage, b.instanceID = shared, "a"
+	shared.TryLock("join:-100:42", "b", time.Minute)
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		t.Error("приветствие отправляет экземпляр, захвативший проверку")
+		return 1
+	}
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 42, FirstName: "Аня"}}})
+	if b.pendingProgress(-100, 42) != nil {
+		t.Error("проверка не должна запускаться")
+	}
+}
+
+func TestClaimJoinWithoutSharedStorage(t *testing.T) {
+	b := setupBot()
+	if !b.claimJoin(-100, 42) || !b.claimJoin(-100, 42) {
+		t.Error("без общего хранилища экземпляр один и блокировки не нужны")
+	}
+}
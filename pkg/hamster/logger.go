@@ -1,4 +1,4 @@
-package bot
+package hamster
 
 import (
 	"fmt"
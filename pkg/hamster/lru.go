@@ -0,0 +1,104 @@
+package hamster
+
+import "container/list"
+
+// ==========================
+// Ограничение размера кэшей
+// ==========================
+
+const (
+	// defaultMaxCachedUsers — сколько пользователей держать в кэше сообщений по умолчанию.
+	defaultMaxCachedUsers = 10000
+	// defaultMaxAdminCache — сколько статусов участников держать в кэше админов по умолчанию.
+	defaultMaxAdminCache = 10000
+)
+
+// lruKeys — порядок использования ключей кэша. Сам кэш хранит владелец, под
+// своей блокировкой; lruKeys лишь подсказывает, какие ключи вытеснить.
+// Нулевое значение готово к работе.
+type lruKeys[K comparable] struct {
+	order *list.List // от давних к свежим
+	elems map[K]*list.Element
+}
+
+// touch отмечает использование ключа и возвращает ключи, вытесненные сверх
+// max. max <= 0 — без ограничения.
+func (l *lruKeys[K]) touch(k K, max int) []K {
+	if l.order == nil {
+		l.order = list.New()
+		l.elems = make(map[K]*list.Element)
+	}
+	if e, ok := l.elems[k]; ok {
+		l.order.MoveToBack(e)
+	} else {
+		l.elems[k] = l.order.PushBack(k)
+	}
+	var evicted []K
+	for max > 0 && l.order.Len() > max {
+		e := l.order.Front()
+		l.order.Remove(e)
+		old := e.Value.(K)
+		delete(l.elems, old)
+		evicted = append(evicted, old)
+	}
+	return evicted
+}
+
+func (l *lruKeys[K]) remove(k K) {
+	if e, ok := l.elems[k]; ok {
+		l.order.Remove(e)
+		delete(l.elems, k)
+	}
+}
+
+func (l *lruKeys[K]) len() int {
+	if l.order == nil {
+		return 0
+	}
+	return l.order.Len()
+}
+
+// userMessageList возвращает кэш сообщений пользователя, создавая его при
+// необходимости, и вытесняет самых давних пользователей сверх MaxCachedUsers.
+// Вызывается под muMessages.
+func (b *Bot) userMessageList(userID int64) *list.List {
+	l, ok := b.userMessages[userID]
+	if !ok {
+		l = list.New()
+		b.userMessages[userID] = l
+	}
+	for _, old := range b.userLRU.touch(userID, b.cfg.MaxCachedUsers) {
+		delete(b.userMessages, old)
+	}
+	return l
+}
+
+// dropUserMessages убирает кэш сообщений пользователя. Вызывается под muMessages.
+func (b *Bot) dropUserMessages(userID int64) {
+	delete(b.userMessages, userID)
+	b.userLRU.remove(userID)
+}
+
+// CacheMetrics — текущие размеры кэшей для мониторинга.
+type CacheMetrics struct {
+	UserMessages    int `json:"user_messages"`     // пользователей в кэше сообщений
+	UserMessagesMax int `json:"user_messages_max"` // 0 — без ограничения
+	AdminCache      int `json:"admin_cache"`       // статусов в кэше админов
+	AdminCacheMax   int `json:"admin_cache_max"`
+	AdminLists      int `json:"admin_lists"` // чатов со списком админов из getChatAdministrators
+	Pending         int `json:"pending"`     // незавершённых проверок
+}
+
+// CacheMetrics возвращает текущие размеры кэшей.
+func (b *Bot) CacheMetrics() CacheMetrics {
+	m := CacheMetrics{UserMessagesMax: b.cfg.MaxCachedUsers, AdminCacheMax: b.cfg.MaxAdminCache}
+	b.muMessages.Lock()
+	m.UserMessages = len(b.userMessages)
+	b.muMessages.Unlock()
+	b.muAdmin.Lock()
+	m.AdminCache = len(b.adminCache)
+	m.AdminLists = len(b.adminLists)
+	b.muAdmin.Unlock()
+	m.Pending = b.progressStore.len()
+	return m
+}
@@ -0,0 +1,83 @@
+package hamster
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLRUKeysEvictsOldest(t *testing.T) {
+	var l lruKeys[int]
+	l.touch(1, 2)
+	l.touch(2, 2)
+	l.touch(1, 2) // 1 снова свежий
+	if got := l.touch(3, 2); !slices.Equal(got, []int{2}) {
+		t.Errorf("вытеснен %v, ожидали [2]", got)
+	}
+	l.remove(1)
+	if l.len() != 1 {
+		t.Errorf("len = %d, ожидали 1", l.len())
+	}
+	if got := l.touch(4, 0); got != nil {
+		t.Errorf("без ограничения ничего не вытесняется: %v", got)
+	}
+}
+
+func TestUserMessagesBounded(t *testing.T) {
+	b := setupBot()
+	b.cfg.MaxCachedUsers = 2
+	for _, id := range []int64{1, 2, 1, 3} {
+		b.cacheMessage(Update{Message: &Message{MessageID: id, Chat: Chat{ID: -100}, From: &User{ID: id}}})
+	}
+	if _, ok := b.userMessages[2]; ok || len(b.userMessages) != 2 {
+		t.Errorf("должен вытесняться давний пользователь 2, в кэше %d", len(b.userMessages))
+	}
+
+	b.muMessages.Lock()
+	b.dropUserMessages(1)
+	b.muMessages.Unlock()
+	if b.userLRU.len() != 1 {
+		t.Errorf("удалённый пользователь должен пропадать из очереди вытеснения: %d", b.userLRU.len())
+	}
+}
+
+func TestAdminCacheBounded(t *testing.T) {
+	b := setupBot()
+	b.adminCache = make(map[string]adminCacheEntry)
+	b.cfg.MaxAdminCache = 2
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		return ChatMember{Status: "administrator"}, nil
+	}
+	for _, id := range []int64{1, 2, 3} {
+		b.isAdmin(b.ctx, -100, id)
+	}
+	if _, ok := b.adminCache[adminCacheKey(-100, 1)]; ok || len(b.adminCache) != 2 {
+		t.Errorf("должен вытесняться давний статус, в кэше %v", b.adminCache)
+	}
+
+	b.forgetChatAdmins(-100)
+	if len(b.adminCache) != 0 || b.adminLRU.len() != 0 {
+		t.Error("статусы забытого чата должны удаляться вместе с очередью")
+	}
+}
+
+func TestAdminAPIMetrics(t *testing.T) {
+	b := setupAdminBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "member", expiresAt: time.Now().Add(time.Minute)}}
+	b.cfg.MaxCachedUsers = 5
+	b.cacheMessage(Update{Message: &Message{MessageID: 1, Chat: Chat{ID: -100}, From: &User{ID: 7}}})
+
+	rec := adminRequest(t, b.AdminHandler(), "GET", "/api/metrics", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("код %d", rec.Code)
+	}
+	var m CacheMetrics
+	if err := json.Unmarshal(rec.Body.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.UserMessages != 1 || m.UserMessagesMax != 5 || m.AdminCache != 1 {
+		t.Errorf("метрики %+v", m)
+	}
+}
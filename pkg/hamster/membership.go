@@ -0,0 +1,192 @@
+package hamster
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Статус самого бота в чатах (my_chat_member)
+// ==========================
+
+// isMemberStatus — статус означает, что участник находится в чате.
+func isMemberStatus(status string) bool {
+	switch status {
+	case "creator", "administrator", "member", "restricted":
+		return true
+	}
+	return false
+}
+
+// missingRights возвращает список прав администратора, без которых бот не справится с проверкой.
+func missingRights(m ChatMember) []string {
+	if m.Status == "creator" {
+		return nil
+	}
+	if m.Status != "administrator" {
+		return []string{"права администратора"}
+	}
+	var missing []string
+	if !m.CanDeleteMessages {
+		missing = append(missing, "удаление сообщений")
+	}
+	if !m.CanRestrictMembers {
+		missing = append(missing, "блокировка участников")
+	}
+	if !m.CanInviteUsers {
+		missing = append(missing, "пригласительные ссылки")
+	}
+	return missing
+}
+
+// ==========================
+// Команда /diagnose
+// ==========================
+
+func (b *Bot) handleDiagnoseCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может запускать диагностику", 5*time.Second)
+		return
+	}
+	if b.self.ID == 0 {
+		b.loadSelf()
+	}
+	if b.self.ID == 0 {
+		b.sendTemporary(chatID, "❌ Не удалось выполнить getMe — проверьте токен бота", 30*time.Second)
+		return
+	}
+
+	me, err := b.safeGetChatMember(b.ctx, chatID, b.self.ID)
+	if err != nil {
+		b.sendTemporary(chatID, fmt.Sprintf("❌ getChatMember не удался: %v", err), 30*time.Second)
+		return
+	}
+	b.sendTemporary(chatID, formatDiagnostics(me), time.Minute)
+}
+
+// formatDiagnostics описывает права бота в чате.
+func formatDiagnostics(me ChatMember) string {
+	mark := func(ok bool) string {
+		if ok {
+			return "✅"
+		}
+		return "❌"
+	}
+	isCreator := me.Status == "creator"
+	isAdmin := isCreator || me.Status == "administrator"
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🩺 Диагностика: статус бота — %s\n", me.Status)
+	fmt.Fprintf(&sb, "%s Администратор\n", mark(isAdmin))
+	fmt.Fprintf(&sb, "%s Удаление сообщений\n", mark(isCreator || (isAdmin && me.CanDeleteMessages)))
+	fmt.Fprintf(&sb, "%s Блокировка участников\n", mark(isCreator || (isAdmin && me.CanRestrictMembers)))
+	fmt.Fprintf(&sb, "%s Пригласительные ссылки\n", mark(isCreator || (isAdmin && me.CanInviteUsers)))
+	if missing := missingRights(me); len(missing) > 0 {
+		fmt.Fprintf(&sb, "\n⚠️ Без этих прав баны и удаление сообщений молча не сработают: %s", strings.Join(missing, ", "))
+	} else {
+		sb.WriteString("\n👌 Все права на месте")
+	}
+	return sb.String()
+}
+
+func (b *Bot) handleMyChatMember(upd *ChatMemberUpdated) {
+	chatID := upd.Chat.ID
+	oldIn := isMemberStatus(upd.OldChatMember.Status)
+	newIn := isMemberStatus(upd.NewChatMember.Status)
+
+	switch {
+	case !oldIn && newIn:
+		b.logger.Info("Бот добавлен в чат %d (%s)", chatID, upd.NewChatMember.Status)
+		b.sendOnboarding(upd)
+	case oldIn && !newIn:
+		b.logger.Info("Бот удалён из чата %d (%s) — очищаем состояние", chatID, upd.NewChatMember.Status)
+		b.forgetChat(chatID)
+	case oldIn && newIn && upd.OldChatMember.Status != upd.NewChatMember.Status:
+		if upd.OldChatMember.Status == "administrator" && upd.NewChatMember.Status != "administrator" {
+			b.logger.Warn("Бота лишили прав администратора в чате %d", chatID)
+			b.alert(fmt.Sprintf("%s:%d", alertAdminLost, chatID), "бот больше не администратор",
+				fmt.Sprintf("В чате %d бота лишили прав администратора: новые участники не проверяются.", chatID))
+		}
+		// права изменились — предупреждаем, если их по-прежнему не хватает
+		if missing := missingRights(upd.NewChatMember); len(missing) > 0 {
+			b.sendTemporary(chatID, fmt.Sprintf("⚠️ Не хватает прав: %s", strings.Join(missing, ", ")), time.Minute)
+		} else {
+			b.sendTemporary(chatID, "✅ Права получены, проверка новых участников работает", 30*time.Second)
+		}
+	}
+}
+
+// forgetChat удаляет всё, что бот хранит о чате, без обращений к API:
+// писать в чат, откуда бота удалили, уже нельзя.
+func (b *Bot) forgetChat(chatID int64) {
+	// незавершённые проверки — останавливаем без бана и удаления сообщений
+	for _, p := range b.progressStore.removeChat(chatID) {
+		p.stopOnce.Do(func() { close(p.stopChan) })
+	}
+
+	b.muMessages.Lock()
+	for userID, lst := range b.userMessages {
+		removeIf(lst, func(e *list.Element) bool {
+			return e.Value.(cachedMessage).msg.Chat.ID == chatID
+		})
+		if lst.Len() == 0 {
+			b.dropUserMessages(userID)
+		}
+	}
+	b.muMessages.Unlock()
+
+	b.forgetChatAdmins(chatID)
+	if b.verified != nil {
+		b.verified.forgetChat(chatID)
+	}
+	b.sent.forgetChat(chatID)
+	if b.stats != nil {
+		b.stats.Delete(chatID)
+	}
+	b.saveState()
+
+	if b.settings != nil && b.settings.Delete(chatID) {
+		b.saveSettings()
+	}
+}
+
+// sendOnboarding отправляет приветствие с инструкцией по настройке при добавлении бота в группу.
+func (b *Bot) sendOnboarding(upd *ChatMemberUpdated) {
+	if upd.Chat.Type == "private" || upd.Chat.Type == "channel" {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("🐹 Привет! Я проверяю новых участников: они должны нажать кнопку, иначе будут забанены.\n")
+	fmt.Fprintf(&sb, "⏱ Таймаут: %d сек. (изменить: /timeout <секунд>)\n", b.chatSettings(upd.Chat.ID).TimeoutSec())
+
+	if missing := missingRights(upd.NewChatMember); len(missing) > 0 {
+		adder := ""
+		if upd.From != nil {
+			adder = displayName(upd.From) + ", "
+		}
+		fmt.Fprintf(&sb, "\n⚠️ %sсделайте меня администратором. Не хватает прав: %s", adder, strings.Join(missing, ", "))
+	} else {
+		sb.WriteString("\n✅ Все нужные права есть — можно работать")
+	}
+
+	b.sendTemporary(upd.Chat.ID, sb.String(), 5*time.Minute)
+}
+
+// displayName возвращает имя пользователя для сообщений бота.
+func displayName(u *User) string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" {
+		name = u.Username
+	}
+	if name == "" {
+		name = fmt.Sprintf("ID:%d", u.ID)
+	}
+	return name
+}
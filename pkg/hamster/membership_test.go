@@ -0,0 +1,159 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMissingRights(t *testing.T) {
+	if got := missingRights(ChatMember{Status: "creator"}); len(got) != 0 {
+		t.Errorf("создателю хватает прав, получили %v", got)
+	}
+	if got := missingRights(ChatMember{Status: "member"}); len(got) != 1 {
+		t.Errorf("обычному участнику нужны права администратора, получили %v", got)
+	}
+	full := ChatMember{Status: "administrator", CanDeleteMessages: true, CanRestrictMembers: true, CanInviteUsers: true}
+	if got := missingRights(full); len(got) != 0 {
+		t.Errorf("всех прав достаточно, получили %v", got)
+	}
+	if got := missingRights(ChatMember{Status: "administrator", CanDeleteMessages: true, CanInviteUsers: true}); len(got) != 1 || got[0] != "блокировка участников" {
+		t.Errorf("ожидалось отсутствие права блокировки, получили %v", got)
+	}
+}
+
+func TestHandleMyChatMemberOnboarding(t *testing.T) {
+	b := setupBot()
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		sent = append(sent, text)
+		return 1
+	}
+
+	b.handleMyChatMember(&ChatMemberUpdated{
+		Chat:          Chat{ID: -100, Type: "supergroup"},
+		From:          &User{ID: 5, FirstName: "Админ"},
+		OldChatMember: ChatMember{Status: "left"},
+		NewChatMember: ChatMember{Status: "member"},
+	})
+
+	if len(sent) != 1 {
+		t.Fatalf("ожидалось одно сообщение, получили %v", sent)
+	}
+	if !strings.Contains(sent[0], "Админ") || !strings.Contains(sent[0], "Не хватает прав") {
+		t.Errorf("ожидалось предупреждение добавившему о правах: %q", sent[0])
+	}
+}
+
+func TestHandleMyChatMemberPromotion(t *testing.T) {
+	b := setupBot()
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		sent = append(sent, text)
+		return 1
+	}
+
+	b.handleMyChatMember(&ChatMemberUpdated{
+		Chat:          Chat{ID: -100, Type: "supergroup"},
+		OldChatMember: ChatMember{Status: "member"},
+		NewChatMember: ChatMember{Status: "administrator", CanDeleteMessages: true, CanRestrictMembers: true, CanInviteUsers: true},
+	})
+
+	if len(sent) != 1 || !strings.Contains(sent[0], "Права получены") {
+		t.Errorf("ожидалось подтверждение прав, получили %v", sent)
+	}
+}
+
+func TestHandleDiagnoseCommand(t *testing.T) {
+	b := setupBot()
+	b.self = User{ID: 777, IsBot: true}
+	b.adminCache = map[string]adminCacheEntry{}
+	fakeOf(b).getChatMember = func(chatID, userID int64) (ChatMember, error) {
+		if userID == 777 {
+			return ChatMember{Status: "administrator", CanDeleteMessages: true}, nil
+		}
+		return ChatMember{Status: "administrator"}, nil
+	}
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		sent = append(sent, text)
+		return 1
+	}
+
+	b.handleDiagnoseCommand(&Message{Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "/diagnose"})
+
+	if len(sent) != 1 {
+		t.Fatalf("ожидался один отчёт, получили %v", sent)
+	}
+	report := sent[0]
+	if !strings.Contains(report, "✅ Удаление сообщений") || !strings.Contains(report, "❌ Блокировка участников") {
+		t.Errorf("отчёт не отражает права: %q", report)
+	}
+	if !strings.Contains(report, "пригласительные ссылки") {
+		t.Errorf("в отчёте нет недостающих прав: %q", report)
+	}
+}
+
+func TestHandleMyChatMemberRemovalForgetsChat(t *testing.T) {
+	b := setupBot()
+	b.verified = newVerifiedUsers()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:1": {status: "administrator"},
+		"-200:1": {status: "administrator"},
+	}
+	b.settings.SetTimeout(-100, 42)
+	b.settings.SetTimeout(-200, 42)
+	_ = b.settings.AddNameFilter(-100, "spam")
+	b.verified.mark(-100, 5, time.Now())
+
+	stop := make(chan struct{})
+	b.progressStore.add(&progressData{stopChan: stop, chatID: -100, userID: 5, greetMsgID: 10})
+	b.progressStore.add(&progressData{stopChan: make(chan struct{}), chatID: -200, userID: 6, greetMsgID: 20})
+	b.cacheMessage(Update{Message: &Message{MessageID: 1, Chat: Chat{ID: -100}, From: &User{ID: 5}}})
+
+	apiCalls := 0
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { apiCalls++ }
+	fakeOf(b).ban = func(chatID, userID int64) { apiCalls++ }
+
+	b.handleMyChatMember(&ChatMemberUpdated{
+		Chat:          Chat{ID: -100, Type: "supergroup"},
+		OldChatMember: ChatMember{Status: "administrator"},
+		NewChatMember: ChatMember{Status: "kicked"},
+	})
+
+	select {
+	case <-stop:
+	default:
+		t.Error("прогрессбар удалённого чата должен быть остановлен")
+	}
+	if b.progressStore.get(-100, 5) != nil {
+		t.Error("прогрессбар удалённого чата должен быть удалён")
+	}
+	if b.progressStore.get(-200, 6) == nil {
+		t.Error("прогрессбар другого чата должен остаться")
+	}
+	if _, ok := b.adminCache["-100:1"]; ok {
+		t.Error("кэш админов удалённого чата должен быть очищен")
+	}
+	if _, ok := b.adminCache["-200:1"]; !ok {
+		t.Error("кэш админов другого чата должен остаться")
+	}
+	if got := b.settings.Timeout(-100); got != DefaultTimeoutSec {
+		t.Errorf("таймаут удалённого чата должен быть сброшен, получили %d", got)
+	}
+	if got := b.settings.Timeout(-200); got != 42 {
+		t.Errorf("таймаут другого чата должен остаться, получили %d", got)
+	}
+	if len(b.settings.Get(-100).NameFilters) != 0 {
+		t.Error("фильтр имён удалённого чата должен быть очищен")
+	}
+	if _, ok := b.verified.since(-100, 5); ok {
+		t.Error("верификации удалённого чата должны быть забыты")
+	}
+	if _, ok := b.userMessages[5]; ok {
+		t.Error("кэш сообщений удалённого чата должен быть очищен")
+	}
+	if apiCalls != 0 {
+		t.Errorf("после удаления из чата не должно быть вызовов API, получили %d", apiCalls)
+	}
+}
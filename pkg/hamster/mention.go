@@ -0,0 +1,30 @@
+package hamster
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ==========================
+// HTML-разметка и упоминания
+// ==========================
+
+// ParseModeHTML — parse_mode для сообщений с HTML-разметкой. Всё, что
+// пришло от пользователей, перед отправкой экранируется через html.EscapeString.
+const ParseModeHTML = "HTML"
+
+// mentionHTML — упоминание участника ссылкой tg://user?id=: работает и для
+// тех, у кого нет username, и присылает им уведомление.
+func mentionHTML(u *User) string {
+	return fmt.Sprintf(`<a href="tg://user?id=%d">%s</a>`, u.ID, html.EscapeString(displayName(u)))
+}
+
+// greetingHTML подставляет упоминание участника в шаблон приветствия,
+// экранируя сам шаблон.
+func greetingHTML(template string, u *User) string {
+	return strings.ReplaceAll(html.EscapeString(template), "{name}", mentionHTML(u))
+}
+
+// htmlOptions — параметры отправки сообщений с HTML-разметкой.
+var htmlOptions = SendOptions{ParseMode: ParseModeHTML}
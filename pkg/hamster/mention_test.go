@@ -0,0 +1,19 @@
+package hamster
+
+import "testing"
+
+func TestMentionHTMLEscapesName(t *testing.T) {
+	got := mentionHTML(&User{ID: 42, FirstName: "<b>Аня</b>", LastName: "&_co"})
+	want := `<a href="tg://user?id=42">&lt;b&gt;Аня&lt;/b&gt; &amp;_co</a>`
+	if got != want {
+		t.Errorf("mentionHTML = %q, want %q", got, want)
+	}
+}
+
+func TestGreetingHTMLEscapesTemplate(t *testing.T) {
+	got := greetingHTML("Привет, {name}! Жми <кнопку>", &User{ID: 7, Username: "bob"})
+	want := `Привет, <a href="tg://user?id=7">bob</a>! Жми &lt;кнопку&gt;`
+	if got != want {
+		t.Errorf("greetingHTML = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package hamster
+
+// ==========================
+// Миграция группы в супергруппу
+// ==========================
+
+// migrateChat переносит настройки и незавершённые проверки со старого ID чата на новый.
+// Telegram присылает и migrate_to_chat_id, и migrate_from_chat_id — повторный вызов ничего не делает.
+func (b *Bot) migrateChat(from, to int64) {
+	if from == 0 || to == 0 || from == to {
+		return
+	}
+
+	moved := b.settings != nil && b.settings.Move(from, to)
+	if moved {
+		b.saveSettings()
+	}
+	if b.verified != nil {
+		b.verified.moveChat(from, to)
+	}
+	if b.stats != nil {
+		b.stats.Move(from, to)
+	}
+	b.saveState()
+
+	// незавершённые проверки продолжают работать уже в новом чате
+	pending := b.progressStore.moveChat(from, to)
+
+	b.muMessages.Lock()
+	for _, lst := range b.userMessages {
+		for e := lst.Front(); e != nil; e = e.Next() {
+			cm := e.Value.(cachedMessage)
+			if cm.msg.Chat.ID == from {
+				cm.msg.Chat.ID = to
+				e.Value = cm
+			}
+		}
+	}
+	b.muMessages.Unlock()
+
+	// статусы админов в новом чате перепроверим
+	b.forgetChatAdmins(from)
+
+	if moved || pending > 0 {
+		b.logger.Info("Чат %d мигрировал в %d: настройки перенесены, проверок в процессе: %d", from, to, pending)
+	}
+}
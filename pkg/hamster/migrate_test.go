@@ -0,0 +1,48 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMigrateChat(t *testing.T) {
+	b := setupBot()
+	b.verified = newVerifiedUsers()
+	b.adminCache = map[string]adminCacheEntry{"-1:5": {status: "administrator"}}
+
+	b.settings.SetTimeout(-1, 120)
+	_ = b.settings.AddNameFilter(-1, "spam")
+	b.verified.mark(-1, 7, time.Now())
+	b.progressStore.add(&progressData{stopChan: make(chan struct{}), chatID: -1, userID: 8, greetMsgID: 10})
+	b.cacheMessage(Update{Message: &Message{MessageID: 3, Chat: Chat{ID: -1}, From: &User{ID: 8}}})
+
+	b.handleUpdate(Update{Message: &Message{Chat: Chat{ID: -1}, MigrateToChatID: -1001}})
+
+	if got := b.settings.Timeout(-1001); got != 120 {
+		t.Errorf("таймаут не перенесён, получили %d", got)
+	}
+	if got := b.settings.Timeout(-1); got != DefaultTimeoutSec {
+		t.Errorf("старый таймаут должен быть удалён, получили %d", got)
+	}
+	if got := b.settings.Get(-1001).NameFilters; len(got) != 1 {
+		t.Errorf("фильтр имён не перенесён: %v", got)
+	}
+	if _, ok := b.verified.since(-1001, 7); !ok {
+		t.Error("верификация не перенесена")
+	}
+	if p := b.progressStore.byMessage(-1001, 10); p == nil || b.progressStore.get(-1001, 8) != p || b.progressStore.get(-1, 8) != nil {
+		t.Error("проверка должна продолжиться в новом чате")
+	}
+	if cm := b.userMessages[8].Front().Value.(cachedMessage); cm.msg.Chat.ID != -1001 {
+		t.Errorf("кэш сообщений не перенесён: %d", cm.msg.Chat.ID)
+	}
+	if _, ok := b.adminCache["-1:5"]; ok {
+		t.Error("кэш админов старого чата должен быть сброшен")
+	}
+
+	// второе служебное сообщение из новой супергруппы ничего не ломает
+	b.handleUpdate(Update{Message: &Message{Chat: Chat{ID: -1001}, MigrateFromChatID: -1}})
+	if got := b.settings.Timeout(-1001); got != 120 {
+		t.Errorf("повторная миграция не должна сбрасывать таймаут, получили %d", got)
+	}
+}
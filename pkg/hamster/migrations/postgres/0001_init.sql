@@ -0,0 +1,34 @@
+-- Настройки групп: документ ChatSettings целиком
+CREATE TABLE chat_settings (
+    chat_id  BIGINT PRIMARY KEY,
+    settings JSONB  NOT NULL
+);
+
+-- Пользователи, недавно прошедшие проверку
+CREATE TABLE verified_users (
+    chat_id     BIGINT      NOT NULL,
+    user_id     BIGINT      NOT NULL,
+    verified_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, user_id)
+);
+
+-- Счётчики проверок
+CREATE TABLE chat_stats (
+    chat_id BIGINT PRIMARY KEY,
+    joins   BIGINT NOT NULL DEFAULT 0,
+    passed  BIGINT NOT NULL DEFAULT 0,
+    failed  BIGINT NOT NULL DEFAULT 0,
+    banned  BIGINT NOT NULL DEFAULT 0
+);
+
+-- Журнал банов
+CREATE TABLE ban_log (
+    id         BIGSERIAL   PRIMARY KEY,
+    chat_id    BIGINT      NOT NULL,
+    user_id    BIGINT      NOT NULL,
+    reason     TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX ban_log_chat_created_idx ON ban_log (chat_id, created_at);
+CREATE INDEX ban_log_user_idx ON ban_log (user_id);
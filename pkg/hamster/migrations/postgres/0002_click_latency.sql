@@ -0,0 +1,5 @@
+-- Задержка нажатия кнопки проверки и провалы из-за слишком быстрых нажатий.
+ALTER TABLE chat_stats
+    ADD COLUMN clicks       BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN click_ms_sum BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN too_fast     BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Гистограмма задержек нажатия для /timeout auto (интервалы — clickBuckets в stats.go).
+ALTER TABLE chat_stats
+    ADD COLUMN click_hist BIGINT[] NOT NULL DEFAULT '{}';
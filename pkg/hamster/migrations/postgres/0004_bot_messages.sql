@@ -0,0 +1,7 @@
+-- Неудалённые сообщения бота: убираются /cleanup и после перезапуска.
+CREATE TABLE bot_messages (
+    chat_id    BIGINT      NOT NULL,
+    message_id BIGINT      NOT NULL,
+    sent_at    TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (chat_id, message_id)
+);
@@ -0,0 +1,6 @@
+-- Смещение getUpdates: после перезапуска обновления не обрабатываются повторно.
+CREATE TABLE update_offset (
+    id            SMALLINT    PRIMARY KEY CHECK (id = 1),
+    update_offset BIGINT      NOT NULL,
+    saved_at      TIMESTAMPTZ NOT NULL
+);
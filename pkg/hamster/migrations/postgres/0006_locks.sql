@@ -0,0 +1,6 @@
+-- Блокировки между экземплярами бота, работающими с одной базой.
+CREATE TABLE locks (
+    key        TEXT        PRIMARY KEY,
+    owner      TEXT        NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Счётчики по ссылкам-приглашениям (ChatStats.Links, ключ — имя или ссылка).
+ALTER TABLE chat_stats
+    ADD COLUMN links JSONB NOT NULL DEFAULT '{}';
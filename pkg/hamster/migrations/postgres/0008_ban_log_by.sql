@@ -0,0 +1,2 @@
+-- Кто забанил: администратор (его ID) или бот (0)
+ALTER TABLE ban_log ADD COLUMN banned_by BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Когда счётчики чата последний раз менялись (ChatStats.Updated, STATS_DAYS).
+ALTER TABLE chat_stats
+    ADD COLUMN updated_at TIMESTAMPTZ;
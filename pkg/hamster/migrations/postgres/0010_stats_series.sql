@@ -0,0 +1,3 @@
+-- Почасовые приращения счётчиков чата за 30 дней (ChatStats.Series).
+ALTER TABLE chat_stats
+    ADD COLUMN series JSONB NOT NULL DEFAULT '[]';
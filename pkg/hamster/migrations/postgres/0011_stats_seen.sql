@@ -0,0 +1,3 @@
+-- Когда из чата последний раз пришло обновление (ChatStats.Seen, STALE_CHAT_DAYS).
+ALTER TABLE chat_stats
+    ADD COLUMN seen_at TIMESTAMPTZ;
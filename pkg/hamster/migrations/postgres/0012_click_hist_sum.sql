@@ -0,0 +1,3 @@
+-- Сумма задержек нажатий из гистограммы, мс (ChatStats.ClickHistMsSum).
+ALTER TABLE chat_stats
+    ADD COLUMN click_hist_ms_sum BIGINT NOT NULL DEFAULT 0;
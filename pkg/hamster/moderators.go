@@ -0,0 +1,295 @@
+package hamster
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Модераторы: помощники без прав администратора
+// ==========================
+
+// maxModerators — сколько модераторов можно назначить в одном чате.
+const maxModerators = 50
+
+// isModerator сообщает, что пользователь назначен модератором чата через /trust.
+func (b *Bot) isModerator(chatID, userID int64) bool {
+	if b.settings == nil {
+		return false
+	}
+	return slices.Contains(b.chatSettings(chatID).Moderators, userID)
+}
+
+// canModerate сообщает, что пользователь — администратор или модератор чата.
+func (b *Bot) canModerate(chatID, userID int64) bool {
+	return b.isModerator(chatID, userID) || b.isAdmin(b.ctx, chatID, userID)
+}
+
+// canModerateMessage — canModerate для автора команды; анонимный
+// администратор (сообщение от имени чата) тоже может.
+func (b *Bot) canModerateMessage(msg *Message) bool {
+	if msg.SenderChat == nil && msg.From != nil && b.isModerator(msg.Chat.ID, msg.From.ID) {
+		return true
+	}
+	return b.isAdminMessage(msg)
+}
+
+// updateModerators добавляет или убирает модератора и сообщает, изменился ли список.
+func (b *Bot) updateModerators(chatID, userID int64, add bool) (changed bool) {
+	b.updateChatSettings(chatID, func(c *ChatSettings) {
+		i := slices.Index(c.Moderators, userID)
+		switch {
+		case add && i < 0:
+			c.Moderators = append(c.Moderators, userID)
+			changed = true
+		case !add && i >= 0:
+			c.Moderators = slices.Delete(c.Moderators, i, i+1)
+			changed = true
+		}
+	})
+	return changed
+}
+
+// ==========================
+// Команды /trust и /untrust
+// ==========================
+
+// handleTrustCommand назначает (/trust) или снимает (/untrust) модератора.
+// Назначать могут только администраторы: модератор не раздаёт роль дальше.
+func (b *Bot) handleTrustCommand(msg *Message, add bool) {
+	if msg.From == nil || msg.Chat.Type == "private" {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может назначать модераторов", 5*time.Second)
+		return
+	}
+	cmd := "/untrust"
+	if add {
+		cmd = "/trust"
+	}
+	if add && msg.ReplyToMessage == nil && commandArg(msg.Text, 1) == "" {
+		b.sendTemporary(chatID, formatModerators(b.chatSettings(chatID).Moderators), 30*time.Second)
+		return
+	}
+	userID, err := b.commandTarget(msg, cmd)
+	if err != nil {
+		b.sendTemporary(chatID, err.Error(), 10*time.Second)
+		return
+	}
+
+	if !add {
+		if !b.updateModerators(chatID, userID, false) {
+			b.sendTemporary(chatID, fmt.Sprintf("ℹ️ %d не модератор", userID), 5*time.Second)
+			return
+		}
+		b.logger.Info("Администратор %d снял модератора %d в чате %d", msg.From.ID, userID, chatID)
+		b.sendTemporary(chatID, fmt.Sprintf("✅ %d больше не модератор", userID), 10*time.Second)
+		return
+	}
+	if len(b.chatSettings(chatID).Moderators) >= maxModerators {
+		b.sendTemporary(chatID, fmt.Sprintf("❌ Не больше %d модераторов в чате", maxModerators), 10*time.Second)
+		return
+	}
+	if !b.updateModerators(chatID, userID, true) {
+		b.sendTemporary(chatID, fmt.Sprintf("ℹ️ %d уже модератор", userID), 5*time.Second)
+		return
+	}
+	b.logger.Info("Администратор %d назначил модератором %d в чате %d", msg.From.ID, userID, chatID)
+	b.sendTemporary(chatID, fmt.Sprintf("🛡 %d — модератор: может пропускать новичков (/verify), разбирать жалобы (/report) и проверять участников (/check)", userID), 15*time.Second)
+}
+
+func formatModerators(ids []int64) string {
+	if len(ids) == 0 {
+		return "📭 Модераторов нет. Назначить: /trust <ID|@username> или ответом на сообщение"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🛡 Модераторы (%d):", len(ids))
+	for _, id := range ids {
+		fmt.Fprintf(&sb, "\n• %d", id)
+	}
+	sb.WriteString("\nСнять: /untrust <ID>")
+	return sb.String()
+}
+
+// ==========================
+// Команда /verify
+// ==========================
+
+// handleVerifyCommand пропускает участника, который ещё проходит проверку,
+// как если бы он нажал свою кнопку.
+func (b *Bot) handleVerifyCommand(msg *Message) {
+	if msg.From == nil || msg.Chat.Type == "private" {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.canModerateMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор или модератор может пропускать без проверки", 5*time.Second)
+		return
+	}
+	userID, err := b.commandTarget(msg, "/verify")
+	if err != nil {
+		b.sendTemporary(chatID, err.Error(), 10*time.Second)
+		return
+	}
+	p := b.pendingProgress(chatID, userID)
+	if p == nil {
+		b.sendTemporary(chatID, fmt.Sprintf("ℹ️ У %d нет незавершённой проверки", userID), 10*time.Second)
+		return
+	}
+	b.logger.Info("Участник %d пропущен в чате %d без проверки пользователем %d", userID, chatID, msg.From.ID)
+	b.passChallenge(chatID, b.memberUser(msg, userID), p)
+}
+
+// memberUser возвращает участника для приветствия: из ответа, из getChatMember
+// или хотя бы с одним ID.
+func (b *Bot) memberUser(msg *Message, userID int64) *User {
+	if r := msg.ReplyToMessage; r != nil && r.From != nil && r.From.ID == userID {
+		return r.From
+	}
+	if m, err := b.safeGetChatMember(b.ctx, msg.Chat.ID, userID); err == nil && m.User != nil {
+		return m.User
+	}
+	return &User{ID: userID}
+}
+
+// ==========================
+// Жалобы: /report
+// ==========================
+
+const (
+	// reportExcerpt — сколько символов сообщения показывать в жалобе.
+	reportExcerpt = 100
+	// reportTTL — сколько жалоба ждёт разбора; кнопки более старых не действуют.
+	reportTTL = 48 * time.Hour
+)
+
+// reportedMessage — на что жалуется отправленная ботом жалоба.
+type reportedMessage struct {
+	userID, msgID int64
+	at            time.Time
+}
+
+// sentReports помнит жалобы, отправленные ботом: кнопки жалобы действуют,
+// только если данные кнопки совпадают с тем, на что жаловались.
+type sentReports struct {
+	mu sync.Mutex
+	m  map[messageKey]reportedMessage // по сообщению жалобы
+}
+
+func (s *sentReports) add(chatID, reportID int64, r reportedMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.m == nil {
+		s.m = make(map[messageKey]reportedMessage)
+	}
+	for k, old := range s.m {
+		if r.at.Sub(old.at) > reportTTL {
+			delete(s.m, k)
+		}
+	}
+	s.m[messageKey{chatID, reportID}] = r
+}
+
+// take забирает жалобу, если она жалуется на сообщение msgID участника
+// userID; false — такой жалобы нет, она устарела или уже разобрана.
+func (s *sentReports) take(chatID, reportID, userID, msgID int64, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := messageKey{chatID, reportID}
+	r, ok := s.m[k]
+	if !ok || r.userID != userID || r.msgID != msgID || now.Sub(r.at) > reportTTL {
+		return false
+	}
+	delete(s.m, k)
+	return true
+}
+
+// handleReportCommand — /report ответом на сообщение: жалоба с кнопками,
+// которые нажимают администраторы и модераторы.
+func (b *Bot) handleReportCommand(msg *Message) {
+	if msg.From == nil || msg.Chat.Type == "private" {
+		return
+	}
+	chatID := msg.Chat.ID
+	r := msg.ReplyToMessage
+	if r == nil || r.From == nil {
+		b.sendTemporary(chatID, "⚙️ Использование: /report ответом на сообщение нарушителя", 10*time.Second)
+		return
+	}
+	if b.isSelf(r.From) || b.canModerate(chatID, r.From.ID) {
+		b.sendTemporary(chatID, "ℹ️ На администраторов и модераторов жалобы не принимаются", 10*time.Second)
+		return
+	}
+	text := r.Text
+	if text == "" {
+		text = r.Caption
+	}
+	if runes := []rune(text); len(runes) > reportExcerpt {
+		text = string(runes[:reportExcerpt]) + "…"
+	}
+	notice := fmt.Sprintf("⚠️ %s жалуется на сообщение %s", mentionHTML(msg.From), mentionHTML(r.From))
+	if text != "" {
+		notice += ":\n«" + html.EscapeString(text) + "»"
+	}
+	data := fmt.Sprintf("%d:%d", r.From.ID, r.MessageID)
+	markup := map[string]interface{}{"inline_keyboard": [][]interface{}{{
+		map[string]interface{}{"text": "🔨 Забанить", "callback_data": "report:ban:" + data},
+		map[string]interface{}{"text": "🗑 Удалить", "callback_data": "report:delete:" + data},
+		map[string]interface{}{"text": "✖️ Отклонить", "callback_data": "report:dismiss:" + data},
+	}}}
+	if id := b.safeSend(b.ctx, chatID, notice, markup, SendOptions{ParseMode: ParseModeHTML, Notify: true}); id != 0 {
+		b.reports.add(chatID, id, reportedMessage{userID: r.From.ID, msgID: r.MessageID, at: time.Now()})
+	}
+	b.logger.Info("Жалоба %d на сообщение %d участника %d в чате %d", msg.From.ID, r.MessageID, r.From.ID, chatID)
+}
+
+// handleReportCallback разбирает кнопки жалобы: "report:<действие>:<user>:<msg>".
+// Действует только кнопка жалобы, которую отправил бот, и только на то
+// сообщение, на которое в ней жаловались.
+func (b *Bot) handleReportCallback(cb *Callback) {
+	chatID := cb.Message.Chat.ID
+	parts := strings.Split(cb.Data, ":")
+	if len(parts) != 4 {
+		b.safeAnswerCallback(b.ctx, cb.ID, "", false)
+		return
+	}
+	userID, _ := strconv.ParseInt(parts[2], 10, 64)
+	msgID, _ := strconv.ParseInt(parts[3], 10, 64)
+	if !b.canModerate(chatID, cb.From.ID) {
+		b.safeAnswerCallback(b.ctx, cb.ID, "🚫 Жалобы разбирают администраторы и модераторы", true)
+		return
+	}
+	switch parts[1] {
+	case "ban", "delete", "dismiss":
+	default:
+		b.safeAnswerCallback(b.ctx, cb.ID, "", false)
+		return
+	}
+	if !b.reports.take(chatID, cb.Message.MessageID, userID, msgID, time.Now()) {
+		b.logger.Warn("Кнопка жалобы в чате %d от %d не совпадает с отправленной жалобой: %s", chatID, cb.From.ID, cb.Data)
+		b.safeAnswerCallback(b.ctx, cb.ID, "⌛ Жалоба уже разобрана или устарела", false)
+		return
+	}
+
+	switch parts[1] {
+	case "ban":
+		b.safeDeleteMessage(b.ctx, chatID, msgID)
+		b.safeBanUser(b.ctx, chatID, userID)
+		b.writeBanLog(BanLogEntry{ChatID: chatID, UserID: userID, Reason: BanReasonAdmin, By: cb.From.ID, At: time.Now()})
+		b.safeAnswerCallback(b.ctx, cb.ID, "🔨 Забанен", false)
+	case "delete":
+		b.safeDeleteMessage(b.ctx, chatID, msgID)
+		b.safeAnswerCallback(b.ctx, cb.ID, "🗑 Сообщение удалено", false)
+	case "dismiss":
+		b.safeAnswerCallback(b.ctx, cb.ID, "✖️ Жалоба отклонена", false)
+	}
+	b.safeDeleteMessage(b.ctx, chatID, cb.Message.MessageID)
+	b.logger.Info("Жалоба на %d в чате %d разобрана пользователем %d: %s", userID, chatID, cb.From.ID, parts[1])
+}
@@ -0,0 +1,140 @@
+package hamster
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTrustCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	var replies []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { replies = append(replies, text); return 1 }
+
+	b.handleTrustCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/trust 42"}, true)
+	if !b.isModerator(-100, 42) || b.isModerator(-200, 42) {
+		t.Fatal("модератор назначается в своём чате")
+	}
+	// модератор не может назначать других
+	b.handleTrustCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 42}, Text: "/trust 43"}, true)
+	if b.isModerator(-100, 43) {
+		t.Error("модератор не должен назначать модераторов")
+	}
+	b.handleTrustCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10}, Text: "/trust"}, true)
+	if last := replies[len(replies)-1]; !strings.Contains(last, "42") {
+		t.Errorf("список модераторов: %q", last)
+	}
+	b.handleTrustCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 10},
+		ReplyToMessage: &Message{From: &User{ID: 42}}, Text: "/untrust"}, false)
+	if b.isModerator(-100, 42) {
+		t.Error("/untrust ответом должен снимать роль")
+	}
+}
+
+func TestModeratorCanCheckAndVerify(t *testing.T) {
+	b := setupBot()
+	b.updateChatSettings(-100, func(c *ChatSettings) { c.Moderators = []int64{42} })
+	var replies []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { replies = append(replies, text); return 1 }
+
+	b.handleCheckCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 43}, Text: "/check 5"})
+	if !strings.Contains(replies[len(replies)-1], "Только администратор или модератор") {
+		t.Errorf("обычный участник не может /check: %q", replies[len(replies)-1])
+	}
+
+	stop := make(chan struct{})
+	b.progressStore.add(&progressData{stopChan: stop, token: "T", chatID: -100, userID: 5, greetMsgID: 100})
+	b.handleVerifyCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 43}, Text: "/verify 5"})
+	if b.pendingProgress(-100, 5) == nil {
+		t.Fatal("обычный участник не может пропускать без проверки")
+	}
+	b.handleVerifyCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 42}, Text: "/verify 5"})
+	select {
+	case <-stop:
+	default:
+		t.Error("проверка должна завершиться")
+	}
+	if b.pendingProgress(-100, 5) != nil {
+		t.Error("после /verify проверки быть не должно")
+	}
+}
+
+func TestReportFlow(t *testing.T) {
+	b := setupBot()
+	b.recentBans = newBanHistory(10)
+	b.updateChatSettings(-100, func(c *ChatSettings) { c.Moderators = []int64{42} })
+	var mu sync.Mutex
+	var notice string
+	var banned, deleted []int64
+	var alerts []string
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { notice = text; return 77 }
+	fakeOf(b).ban = func(chatID, userID int64) { mu.Lock(); banned = append(banned, userID); mu.Unlock() }
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { mu.Lock(); deleted = append(deleted, msgID); mu.Unlock() }
+	fakeOf(b).answerCallback = func(id, text string, alert bool) { alerts = append(alerts, text) }
+
+	b.handleReportCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 43, FirstName: "Аня"}, Text: "/report",
+		ReplyToMessage: &Message{MessageID: 9, From: &User{ID: 5, FirstName: "Спам"}, Text: "купи <b>крипту</b>"}})
+	if !strings.Contains(notice, "&lt;b&gt;крипту") {
+		t.Fatalf("жалоба с экранированным текстом: %q", notice)
+	}
+
+	cb := &Callback{ID: "c", Message: &Message{MessageID: 77, Chat: Chat{ID: -100}}, From: &User{ID: 43}, Data: "report:ban:5:9"}
+	b.handleCallback(cb)
+	if len(banned) != 0 || !strings.Contains(alerts[len(alerts)-1], "модераторы") {
+		t.Fatalf("участник без роли не разбирает жалобы: %v %v", banned, alerts)
+	}
+
+	cb.From = &User{ID: 42}
+	b.handleCallback(cb)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(banned) != 1 || banned[0] != 5 {
+		t.Errorf("модератор банит по жалобе: %v", banned)
+	}
+	if len(deleted) != 2 || deleted[0] != 9 || deleted[1] != 77 {
+		t.Errorf("удаляются сообщение нарушителя и жалоба: %v", deleted)
+	}
+	if bans := b.recentBans.list(); len(bans) != 1 || bans[0].By != 42 || bans[0].Reason != BanReasonAdmin {
+		t.Errorf("журнал банов: %+v", bans)
+	}
+}
+
+func TestReportCallbackOnlyForSentReports(t *testing.T) {
+	b := setupBot()
+	b.recentBans = newBanHistory(10)
+	b.updateChatSettings(-100, func(c *ChatSettings) { c.Moderators = []int64{42} })
+	var mu sync.Mutex
+	var banned []int64
+	var alerts []string
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { return 77 }
+	fakeOf(b).ban = func(chatID, userID int64) { mu.Lock(); banned = append(banned, userID); mu.Unlock() }
+	fakeOf(b).answerCallback = func(id, text string, alert bool) { alerts = append(alerts, text) }
+
+	b.handleReportCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 43, FirstName: "Аня"}, Text: "/report",
+		ReplyToMessage: &Message{MessageID: 9, From: &User{ID: 5, FirstName: "Спам"}, Text: "реклама"}})
+
+	for _, cb := range []*Callback{
+		{ID: "a", Message: &Message{MessageID: 77, Chat: Chat{ID: -100}}, From: &User{ID: 42}, Data: "report:ban:6:9"},
+		{ID: "b", Message: &Message{MessageID: 77, Chat: Chat{ID: -100}}, From: &User{ID: 42}, Data: "report:ban:5:10"},
+		{ID: "c", Message: &Message{MessageID: 78, Chat: Chat{ID: -100}}, From: &User{ID: 42}, Data: "report:ban:5:9"},
+	} {
+		b.handleCallback(cb)
+	}
+	mu.Lock()
+	if len(banned) != 0 {
+		t.Errorf("кнопка с чужими данными не должна банить: %v", banned)
+	}
+	mu.Unlock()
+
+	dismiss := &Callback{ID: "d", Message: &Message{MessageID: 77, Chat: Chat{ID: -100}}, From: &User{ID: 42}, Data: "report:dismiss:5:9"}
+	b.handleCallback(dismiss)
+	ban := &Callback{ID: "e", Message: &Message{MessageID: 77, Chat: Chat{ID: -100}}, From: &User{ID: 42}, Data: "report:ban:5:9"}
+	b.handleCallback(ban)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(banned) != 0 || !strings.Contains(alerts[len(alerts)-1], "устарела") {
+		t.Errorf("разобранная жалоба больше не действует: %v %v", banned, alerts)
+	}
+}
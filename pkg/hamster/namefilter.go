@@ -0,0 +1,165 @@
+package hamster
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// NameFilterBan — совпадение с шаблоном приводит к бану без проверки.
+	NameFilterBan = "ban"
+	// NameFilterStrict — совпадение даёт строгую проверку (strictChallenge).
+	NameFilterStrict = "strict"
+)
+
+// AddNameFilter добавляет шаблон (регистронезависимый) после проверки компиляции.
+func (s *Settings) AddNameFilter(chatID int64, pattern string) error {
+	if _, err := compileNamePattern(pattern); err != nil {
+		return err
+	}
+	s.Update(chatID, func(c *ChatSettings) {
+		c.NameFilters = append(c.NameFilters, pattern)
+	})
+	return nil
+}
+
+// RemoveNameFilter удаляет шаблон по номеру (с 1) и возвращает его.
+func (s *Settings) RemoveNameFilter(chatID int64, idx int) (removed string, ok bool) {
+	s.Update(chatID, func(c *ChatSettings) {
+		if idx < 1 || idx > len(c.NameFilters) {
+			return
+		}
+		removed, ok = c.NameFilters[idx-1], true
+		c.NameFilters = append(c.NameFilters[:idx-1], c.NameFilters[idx:]...)
+	})
+	return removed, ok
+}
+
+// SetNameFilterAction задаёт действие при совпадении: NameFilterBan или NameFilterStrict.
+func (s *Settings) SetNameFilterAction(chatID int64, action string) {
+	s.Update(chatID, func(c *ChatSettings) { c.NameFilterAction = action })
+}
+
+// MatchName возвращает первый шаблон чата, совпавший с именем или username пользователя.
+func (s *Settings) MatchName(chatID int64, user *User) (string, bool) {
+	candidates := []string{
+		user.FirstName,
+		user.LastName,
+		strings.TrimSpace(user.FirstName + " " + user.LastName),
+		user.Username,
+	}
+	for _, p := range s.Get(chatID).NameFilters {
+		re, err := compileNamePattern(p)
+		if err != nil {
+			continue
+		}
+		for _, c := range candidates {
+			if c != "" && re.MatchString(c) {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+func compileNamePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// ==========================
+// Проверка имени при входе
+// ==========================
+
+// applyNameFilter проверяет нового участника по шаблонам чата.
+// Возвращает true, если пользователь забанен и приветствовать его не нужно,
+// и strict=true, если ему полагается строгая проверка.
+func (b *Bot) applyNameFilter(chatID int64, user *User) (banned, strict bool) {
+	pattern, ok := b.settings.MatchName(chatID, user)
+	if !ok {
+		return false, false
+	}
+	if b.chatSettings(chatID).NameAction() == NameFilterStrict {
+		b.logger.Info("Имя %d в чате %d совпало с %q — строгая проверка", user.ID, chatID, pattern)
+		return false, true
+	}
+	b.logger.Info("Имя %d в чате %d совпало с %q — бан", user.ID, chatID, pattern)
+	b.safeBanUser(b.ctx, chatID, user.ID)
+	b.logBan(chatID, user.ID, BanReasonNameFilter)
+	return true, false
+}
+
+// ==========================
+// Команда /namefilter
+// ==========================
+
+func (b *Bot) handleNameFilterCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять фильтр имён", 5*time.Second)
+		return
+	}
+
+	usage := "⚙️ Использование:\n/namefilter — список\n/namefilter add <regex>\n/namefilter del <номер>\n/namefilter mode ban|strict"
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 {
+		b.sendTemporary(chatID, b.formatNameFilters(chatID), 30*time.Second)
+		return
+	}
+
+	switch parts[1] {
+	case "add":
+		pattern := commandArg(msg.Text, 2)
+		if pattern == "" {
+			b.sendTemporary(chatID, usage, 10*time.Second)
+			return
+		}
+		if err := b.settings.AddNameFilter(chatID, pattern); err != nil {
+			b.sendTemporary(chatID, fmt.Sprintf("❌ Некорректное выражение: %v", err), 10*time.Second)
+			return
+		}
+		b.saveSettings()
+		b.sendTemporary(chatID, fmt.Sprintf("✅ Шаблон добавлен: %s", pattern), 5*time.Second)
+	case "del", "remove":
+		idx := 0
+		if len(parts) > 2 {
+			idx, _ = strconv.Atoi(parts[2])
+		}
+		removed, ok := b.settings.RemoveNameFilter(chatID, idx)
+		if !ok {
+			b.sendTemporary(chatID, "⚙️ Укажите номер шаблона из /namefilter", 5*time.Second)
+			return
+		}
+		b.saveSettings()
+		b.sendTemporary(chatID, fmt.Sprintf("🗑 Шаблон удалён: %s", removed), 5*time.Second)
+	case "mode":
+		if len(parts) < 3 || (parts[2] != NameFilterBan && parts[2] != NameFilterStrict) {
+			b.sendTemporary(chatID, usage, 10*time.Second)
+			return
+		}
+		b.settings.SetNameFilterAction(chatID, parts[2])
+		b.saveSettings()
+		b.sendTemporary(chatID, fmt.Sprintf("✅ Действие при совпадении: %s", parts[2]), 5*time.Second)
+	default:
+		b.sendTemporary(chatID, usage, 10*time.Second)
+	}
+}
+
+func (b *Bot) formatNameFilters(chatID int64) string {
+	cs := b.chatSettings(chatID)
+	patterns := cs.NameFilters
+	if len(patterns) == 0 {
+		return "📭 Фильтр имён пуст. Добавить: /namefilter add <regex>"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🚫 Фильтр имён (действие: %s):\n", cs.NameAction())
+	for i, p := range patterns {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, p)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,82 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNameFiltersAddMatchRemove(t *testing.T) {
+	n := NewSettings()
+	if err := n.AddNameFilter(1, "t\\.me/"); err != nil {
+		t.Fatalf("Add вернул ошибку: %v", err)
+	}
+	if err := n.AddNameFilter(1, "crypto"); err != nil {
+		t.Fatalf("Add вернул ошибку: %v", err)
+	}
+	if err := n.AddNameFilter(1, "("); err == nil {
+		t.Error("некорректное выражение должно отклоняться")
+	}
+
+	if p, ok := n.MatchName(1, &User{FirstName: "Free", LastName: "CRYPTO signals"}); !ok || p != "crypto" {
+		t.Errorf("ожидалось совпадение с crypto, получили %q %v", p, ok)
+	}
+	if _, ok := n.MatchName(1, &User{FirstName: "Иван", Username: "ivan"}); ok {
+		t.Error("обычное имя не должно совпадать")
+	}
+	if _, ok := n.MatchName(2, &User{FirstName: "crypto"}); ok {
+		t.Error("шаблоны одного чата не должны влиять на другой")
+	}
+
+	if removed, ok := n.RemoveNameFilter(1, 1); !ok || removed != "t\\.me/" {
+		t.Errorf("ожидалось удаление первого шаблона, получили %q %v", removed, ok)
+	}
+	if _, ok := n.RemoveNameFilter(1, 5); ok {
+		t.Error("удаление по несуществующему номеру должно возвращать false")
+	}
+	if got := n.Get(1).NameFilters; len(got) != 1 {
+		t.Errorf("ожидался один шаблон, получили %v", got)
+	}
+}
+
+func TestApplyNameFilterBans(t *testing.T) {
+	b := setupBot()
+	_ = b.settings.AddNameFilter(1, "airdrop")
+
+	var banned []int64
+	fakeOf(b).ban = func(chatID, userID int64) { banned = append(banned, userID) }
+
+	if ban, strict := b.applyNameFilter(1, &User{ID: 7, Username: "best_airdrop_bot"}); !ban || strict {
+		t.Errorf("ожидался бан, получили ban=%v strict=%v", ban, strict)
+	}
+	if len(banned) != 1 || banned[0] != 7 {
+		t.Errorf("пользователь 7 должен быть забанен: %v", banned)
+	}
+
+	b.settings.SetNameFilterAction(1, NameFilterStrict)
+	if ban, strict := b.applyNameFilter(1, &User{ID: 8, FirstName: "AIRDROP"}); ban || !strict {
+		t.Errorf("ожидалась строгая проверка, получили ban=%v strict=%v", ban, strict)
+	}
+}
+
+func TestHandleNameFilterCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"1:42": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		sent = append(sent, text)
+		return 1
+	}
+
+	b.handleNameFilterCommand(&Message{Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "/namefilter add free\\s+money"})
+	if got := b.settings.Get(1).NameFilters; len(got) != 1 || got[0] != "free\\s+money" {
+		t.Errorf("шаблон не добавлен: %v", got)
+	}
+
+	b.handleNameFilterCommand(&Message{Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "/namefilter"})
+	if len(sent) < 2 || !strings.Contains(sent[1], "free\\s+money") {
+		t.Errorf("список шаблонов не отправлен: %v", sent)
+	}
+}
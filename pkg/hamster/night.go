@@ -0,0 +1,154 @@
+package hamster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Ночной режим
+// ==========================
+
+const (
+	// NightStrict — строгая проверка (strictChallenge).
+	NightStrict = "strict"
+	// NightHard — проверка как во время наплыва (RAID_CAPTCHA, RAID_TIMEOUT).
+	NightHard = "hard"
+	// NightLockdown — вступившие сразу исключаются (без бана: днём смогут вернуться).
+	NightLockdown = "lockdown"
+)
+
+// NightMode — часы, когда в чате действуют строгие настройки: большинство
+// наплывов ботов приходится на ночь, когда администраторов нет.
+type NightMode struct {
+	From string `json:"from"`         // начало, "23:00"
+	To   string `json:"to"`           // конец, "07:00"; меньше From — через полночь
+	TZ   string `json:"tz,omitempty"` // часовой пояс IANA, пусто — UTC
+	Mode string `json:"mode"`         // NightStrict | NightHard | NightLockdown
+}
+
+var nightModeNames = map[string]string{
+	NightStrict:   "строгая проверка с минимальным таймаутом",
+	NightHard:     "усиленная проверка",
+	NightLockdown: "вступившие исключаются",
+}
+
+// parseClock разбирает время суток "ЧЧ:ММ" в минуты от полуночи.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("время должно быть в виде ЧЧ:ММ: %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (n NightMode) validate() error {
+	from, err := parseClock(n.From)
+	if err != nil {
+		return err
+	}
+	to, err := parseClock(n.To)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("начало и конец ночного режима совпадают")
+	}
+	if _, err := time.LoadLocation(n.TZ); err != nil {
+		return fmt.Errorf("неизвестный часовой пояс %q", n.TZ)
+	}
+	if _, ok := nightModeNames[n.Mode]; !ok {
+		return fmt.Errorf("режим должен быть %q, %q или %q", NightStrict, NightHard, NightLockdown)
+	}
+	return nil
+}
+
+// active сообщает, действует ли ночной режим в момент t. Некорректные
+// настройки не действуют никогда.
+func (n NightMode) active(t time.Time) bool {
+	from, err1 := parseClock(n.From)
+	to, err2 := parseClock(n.To)
+	loc, err3 := time.LoadLocation(n.TZ)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return false
+	}
+	t = t.In(loc)
+	now := t.Hour()*60 + t.Minute()
+	if from < to {
+		return now >= from && now < to
+	}
+	return now >= from || now < to
+}
+
+func (n NightMode) String() string {
+	tz := n.TZ
+	if tz == "" {
+		tz = "UTC"
+	}
+	return fmt.Sprintf("%s–%s (%s): %s", n.From, n.To, tz, nightModeNames[n.Mode])
+}
+
+// nightMode возвращает режим, действующий в чате сейчас; "" — день или
+// ночной режим не настроен.
+func (b *Bot) nightMode(chatID int64, now time.Time) string {
+	n := b.chatSettings(chatID).Night
+	if n == nil || !n.active(now) {
+		return ""
+	}
+	return n.Mode
+}
+
+// lockdownJoin исключает вступившего ночью в режиме NightLockdown.
+func (b *Bot) lockdownJoin(chatID, userID int64) {
+	b.logger.Info("Участник %d вступил в чат %d в ночной режим — исключаем", userID, chatID)
+	b.safeKickUser(b.ctx, chatID, userID)
+	b.recordStat(chatID, func(c *ChatStats) { c.Banned++ })
+	b.recordLinkStat(chatID, userID, func(l *LinkStats) { l.Banned++ })
+}
+
+// ==========================
+// Команда /night
+// ==========================
+
+func (b *Bot) handleNightCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может настраивать ночной режим", 5*time.Second)
+		return
+	}
+
+	const usage = "⚙️ Использование: /night 23:00-07:00 strict|hard|lockdown [часовой пояс, например Europe/Moscow] или /night off"
+	args := strings.Fields(commandArg(msg.Text, 1))
+	switch {
+	case len(args) == 0:
+		status := "🌙 Ночной режим выключен"
+		if n := b.chatSettings(chatID).Night; n != nil {
+			status = "🌙 Ночной режим: " + n.String()
+			if n.active(time.Now()) {
+				status += " — действует сейчас"
+			}
+		}
+		b.sendTemporary(chatID, status+"\n"+usage, 10*time.Second)
+	case len(args) == 1 && strings.EqualFold(args[0], "off"):
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Night = nil })
+		b.sendTemporary(chatID, "✅ Ночной режим выключен", 5*time.Second)
+	case len(args) == 2 || len(args) == 3:
+		from, to, _ := strings.Cut(args[0], "-")
+		n := &NightMode{From: from, To: to, Mode: strings.ToLower(args[1])}
+		if len(args) == 3 {
+			n.TZ = args[2]
+		}
+		if err := n.validate(); err != nil {
+			b.sendTemporary(chatID, "❌ "+err.Error()+"\n"+usage, 10*time.Second)
+			return
+		}
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Night = n })
+		b.sendTemporary(chatID, "✅ Ночной режим: "+n.String(), 5*time.Second)
+	default:
+		b.sendTemporary(chatID, usage, 5*time.Second)
+	}
+}
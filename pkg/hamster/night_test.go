@@ -0,0 +1,113 @@
+package hamster
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// nightAround — ночной режим, который действует сейчас по UTC.
+func nightAround(mode string) *NightMode {
+	now := time.Now().UTC()
+	return &NightMode{From: now.Add(-time.Hour).Format("15:04"), To: now.Add(time.Hour).Format("15:04"), Mode: mode}
+}
+
+func TestNightModeActive(t *testing.T) {
+	n := NightMode{From: "23:00", To: "07:00", TZ: "Europe/Moscow", Mode: NightStrict}
+	cases := []struct {
+		utc  string
+		want bool
+	}{
+		{"2026-10-15T19:59:00Z", false}, // 22:59 МСК
+		{"2026-10-15T20:00:00Z", true},  // 23:00 МСК
+		{"2026-10-16T02:30:00Z", true},  // 05:30 МСК
+		{"2026-10-16T04:00:00Z", false}, // 07:00 МСК
+	}
+	for _, c := range cases {
+		at, _ := time.Parse(time.RFC3339, c.utc)
+		if got := n.active(at); got != c.want {
+			t.Errorf("%s: ожидали %v", c.utc, c.want)
+		}
+	}
+	day := NightMode{From: "01:00", To: "05:00", Mode: NightHard}
+	if at, _ := time.Parse(time.RFC3339, "2026-10-15T06:00:00Z"); day.active(at) {
+		t.Error("интервал без перехода через полночь не должен действовать в 06:00")
+	}
+}
+
+func TestNightModeValidate(t *testing.T) {
+	for _, n := range []NightMode{
+		{From: "25:00", To: "07:00", Mode: NightStrict},
+		{From: "23:00", To: "23:00", Mode: NightStrict},
+		{From: "23:00", To: "07:00", TZ: "Mars/Olympus", Mode: NightStrict},
+		{From: "23:00", To: "07:00", Mode: "panic"},
+	} {
+		if err := (ChatSettings{Night: &n}).Validate(); err == nil {
+			t.Errorf("%+v: ожидали ошибку", n)
+		}
+	}
+	if err := (ChatSettings{Night: &NightMode{From: "23:00", To: "07:00", TZ: "Europe/Moscow", Mode: NightLockdown}}).Validate(); err != nil {
+		t.Errorf("корректный ночной режим: %v", err)
+	}
+}
+
+func TestNightLockdownKicks(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.settings.Update(-100, func(c *ChatSettings) { c.Night = nightAround(NightLockdown) })
+	greeted := false
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { greeted = true; return 10 }
+	var mu sync.Mutex
+	var banned, unbanned []int64
+	fakeOf(b).ban = func(chatID, userID int64) { mu.Lock(); banned = append(banned, userID); mu.Unlock() }
+	fakeOf(b).unban = func(chatID, userID int64) { mu.Lock(); unbanned = append(unbanned, userID); mu.Unlock() }
+
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 42, FirstName: "Сова"}}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if greeted || len(banned) != 1 || len(unbanned) != 1 {
+		t.Errorf("ночью вступивший исключается без проверки: greeted=%v ban=%v unban=%v", greeted, banned, unbanned)
+	}
+	if got := b.stats.Get(-100).Banned; got != 1 {
+		t.Errorf("исключение учитывается в статистике: %d", got)
+	}
+}
+
+func TestNightStrictShortensTimeout(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.settings.Update(-100, func(c *ChatSettings) { c.Timeout = 120; c.Night = nightAround(NightStrict) })
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { return 10 }
+
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 42, FirstName: "Сова"}}})
+	deadline := time.Now().Add(time.Second)
+	for b.pendingProgress(-100, 42) == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	p := b.pendingProgress(-100, 42)
+	if p == nil {
+		t.Fatal("проверка не началась")
+	}
+	if p.timeout != MinTimeoutSec {
+		t.Errorf("ночью таймаут минимальный, получили %d", p.timeout)
+	}
+}
+
+func TestNightCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	b.handleNightCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/night 23:00-07:00 hard Europe/Moscow"})
+	want := NightMode{From: "23:00", To: "07:00", TZ: "Europe/Moscow", Mode: NightHard}
+	if n := b.chatSettings(-100).Night; n == nil || *n != want {
+		t.Fatalf("ожидали %+v, получили %+v", want, n)
+	}
+	b.handleNightCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/night 23:00-07:00 snooze"})
+	if n := b.chatSettings(-100).Night; n == nil || n.Mode != NightHard {
+		t.Error("некорректная команда не меняет настройку")
+	}
+	b.handleNightCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/night off"})
+	if b.chatSettings(-100).Night != nil {
+		t.Error("/night off выключает ночной режим")
+	}
+}
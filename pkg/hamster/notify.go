@@ -0,0 +1,212 @@
+package hamster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ==========================
+// Уведомления в Discord, Slack и Matrix
+// ==========================
+
+// События для уведомлений (NOTIFY_EVENTS).
+const (
+	NotifyFailed = "failed" // провал проверки
+	NotifyRaid   = "raid"   // начало и конец наплыва вступлений
+	NotifyError  = "error"  // паники и повторяющиеся сбои Bot API
+)
+
+// notifyTimeout ограничивает отправку одного уведомления.
+const notifyTimeout = 10 * time.Second
+
+// NotifyConfig — куда отправлять уведомления для администраторов, которые
+// следят за ботом не из Telegram.
+type NotifyConfig struct {
+	DiscordWebhook   string // URL вебхука канала Discord
+	SlackWebhook     string // URL входящего вебхука Slack
+	MatrixHomeserver string // например https://matrix.org
+	MatrixToken      string // access token пользователя-бота Matrix
+	MatrixRoom       string // ID комнаты, !abc:matrix.org
+	// Events — какие события отправлять (NotifyFailed...). Пусто — все.
+	Events []string
+}
+
+func (c NotifyConfig) matrixEnabled() bool {
+	return c.MatrixHomeserver != "" || c.MatrixToken != "" || c.MatrixRoom != ""
+}
+
+func (c NotifyConfig) validate() error {
+	for name, u := range map[string]string{
+		"NOTIFY_DISCORD_WEBHOOK":   c.DiscordWebhook,
+		"NOTIFY_SLACK_WEBHOOK":     c.SlackWebhook,
+		"NOTIFY_MATRIX_HOMESERVER": c.MatrixHomeserver,
+	} {
+		if u == "" {
+			continue
+		}
+		if p, err := url.Parse(u); err != nil || p.Scheme == "" || p.Host == "" {
+			return fmt.Errorf("%s должен быть полным URL: %q", name, u)
+		}
+	}
+	if c.matrixEnabled() && (c.MatrixHomeserver == "" || c.MatrixToken == "" || c.MatrixRoom == "") {
+		return errors.New("для Matrix нужны все три: NOTIFY_MATRIX_HOMESERVER, NOTIFY_MATRIX_TOKEN, NOTIFY_MATRIX_ROOM")
+	}
+	for _, e := range c.Events {
+		if e != NotifyFailed && e != NotifyRaid && e != NotifyError {
+			return fmt.Errorf("NOTIFY_EVENTS: неизвестное событие %q (%s, %s, %s)", e, NotifyFailed, NotifyRaid, NotifyError)
+		}
+	}
+	return nil
+}
+
+// wants сообщает, нужно ли отправлять событие.
+func (c NotifyConfig) wants(event string) bool {
+	if len(c.Events) == 0 {
+		return true
+	}
+	for _, e := range c.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Notifier доставляет текстовое уведомление. Встроенные реализации создаются
+// из NotifyConfig; свою можно добавить через WithNotifier.
+type Notifier interface {
+	Notify(ctx context.Context, text string) error
+}
+
+// NewNotifiers создаёт получателей уведомлений по настройкам.
+func NewNotifiers(c NotifyConfig, client HTTPClient) []Notifier {
+	var out []Notifier
+	if c.DiscordWebhook != "" {
+		out = append(out, &webhookNotifier{name: "Discord", url: c.DiscordWebhook, field: "content", client: client})
+	}
+	if c.SlackWebhook != "" {
+		out = append(out, &webhookNotifier{name: "Slack", url: c.SlackWebhook, field: "text", client: client})
+	}
+	if c.matrixEnabled() {
+		out = append(out, &matrixNotifier{homeserver: strings.TrimRight(c.MatrixHomeserver, "/"), token: c.MatrixToken, room: c.MatrixRoom, client: client})
+	}
+	return out
+}
+
+// webhookNotifier отправляет JSON с одним полем текста: так устроены вебхуки
+// Discord ("content") и Slack ("text").
+type webhookNotifier struct {
+	name   string
+	url    string
+	field  string
+	client HTTPClient
+}
+
+func (w *webhookNotifier) Notify(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]string{w.field: text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doNotify(w.client, req, w.name)
+}
+
+// matrixNotifier отправляет сообщение в комнату через Client-Server API.
+type matrixNotifier struct {
+	homeserver string
+	token      string
+	room       string
+	client     HTTPClient
+	txn        atomic.Int64
+}
+
+func (m *matrixNotifier) Notify(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]string{"msgtype": "m.text", "body": text})
+	if err != nil {
+		return err
+	}
+	// идентификатор транзакции защищает от дублей при повторе запроса
+	txn := strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(m.txn.Add(1), 10)
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", m.homeserver, url.PathEscape(m.room), txn)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.token)
+	return doNotify(m.client, req, "Matrix")
+}
+
+func doNotify(client HTTPClient, req *http.Request, name string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s ответил %s: %s", name, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// notify отправляет уведомление о событии всем получателям, не задерживая
+// обработку обновления.
+func (b *Bot) notify(event, text string) {
+	if len(b.notifiers) == 0 || !b.cfg.Notify.wants(event) {
+		return
+	}
+	for _, n := range b.notifiers {
+		go func(n Notifier) {
+			ctx, cancel := context.WithTimeout(b.ctx, notifyTimeout)
+			defer cancel()
+			if err := n.Notify(ctx, "🐹 "+text); err != nil {
+				b.logger.Warn("Не удалось отправить уведомление: %v", err)
+			}
+		}(n)
+	}
+}
+
+// notifyFailed — обработчик OnFailed. Во время наплыва провалы не
+// отправляются по одному: о наплыве уже пришло уведомление.
+func (b *Bot) notifyFailed(ctx context.Context, e MemberEvent) {
+	if b.raids != nil && b.raids.active(e.ChatID) {
+		return
+	}
+	b.notify(NotifyFailed, fmt.Sprintf("❌ Участник %d не прошёл проверку в чате %d: %s", e.UserID, e.ChatID, banReasonName(e.Reason)))
+}
+
+// notifyError пересылает отчёт об ошибке.
+func (b *Bot) notifyError(r ErrorReport) {
+	kind := "Сбой"
+	if r.Panic {
+		kind = "Паника"
+	}
+	text := fmt.Sprintf("⚠️ %s в %s: %v", kind, r.Where, r.Err)
+	if r.ChatID != 0 {
+		text += fmt.Sprintf(" (чат %d)", r.ChatID)
+	}
+	b.notify(NotifyError, text)
+}
+
+// active сообщает, идёт ли в чате наплыв.
+func (d *raidDetector) active(chatID int64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, ok := d.chats[chatID]
+	return ok && s.active
+}
@@ -0,0 +1,127 @@
+package hamster
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNotifiersPayloads(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]*http.Request{}
+	bodies := map[string]map[string]string{}
+	client := &mockHTTPClient{DoFunc: func(r *http.Request) (*http.Response, error) {
+		var body map[string]string
+		raw, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(raw, &body)
+		mu.Lock()
+		requests[r.URL.Host] = r
+		bodies[r.URL.Host] = body
+		mu.Unlock()
+		return jsonResponse(200, `{}`), nil
+	}}
+	notifiers := NewNotifiers(NotifyConfig{
+		DiscordWebhook:   "https://discord.example/api/webhooks/1/x",
+		SlackWebhook:     "https://hooks.slack.example/services/T/B/X",
+		MatrixHomeserver: "https://matrix.example/",
+		MatrixToken:      "tok",
+		MatrixRoom:       "!room:matrix.example",
+	}, client)
+	if len(notifiers) != 3 {
+		t.Fatalf("ожидали три получателя, получили %d", len(notifiers))
+	}
+	for _, n := range notifiers {
+		if err := n.Notify(context.Background(), "наплыв"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if bodies["discord.example"]["content"] != "наплыв" {
+		t.Errorf("Discord: %v", bodies["discord.example"])
+	}
+	if bodies["hooks.slack.example"]["text"] != "наплыв" {
+		t.Errorf("Slack: %v", bodies["hooks.slack.example"])
+	}
+	m := requests["matrix.example"]
+	if m.Method != http.MethodPut || !strings.HasPrefix(m.URL.EscapedPath(), "/_matrix/client/v3/rooms/%21room:matrix.example/send/m.room.message/") {
+		t.Errorf("Matrix: %s %s", m.Method, m.URL.EscapedPath())
+	}
+	if m.Header.Get("Authorization") != "Bearer tok" || bodies["matrix.example"]["body"] != "наплыв" || bodies["matrix.example"]["msgtype"] != "m.text" {
+		t.Errorf("Matrix: %v %v", m.Header, bodies["matrix.example"])
+	}
+}
+
+func TestNotifierHTTPError(t *testing.T) {
+	client := &mockHTTPClient{DoFunc: func(r *http.Request) (*http.Response, error) {
+		return jsonResponse(404, `{"message": "Unknown Webhook"}`), nil
+	}}
+	n := NewNotifiers(NotifyConfig{DiscordWebhook: "https://discord.example/api/webhooks/1/x"}, client)[0]
+	if err := n.Notify(context.Background(), "текст"); err == nil || !strings.Contains(err.Error(), "Unknown Webhook") {
+		t.Errorf("ошибка вебхука должна возвращаться: %v", err)
+	}
+}
+
+type recordingNotifier struct {
+	ch chan string
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, text string) error {
+	r.ch <- text
+	return nil
+}
+
+func TestNotifyEvents(t *testing.T) {
+	b := setupBot()
+	b.raids = newRaidDetector()
+	rec := &recordingNotifier{ch: make(chan string, 4)}
+	b.notifiers = []Notifier{rec}
+	b.cfg.Notify.Events = []string{NotifyFailed}
+	b.OnFailed(b.notifyFailed)
+
+	b.report(ErrorReport{Where: "banChatMember"})
+	p := &progressData{stopChan: make(chan struct{}), chatID: -100, userID: 42, greetMsgID: 10}
+	b.progressStore.add(p)
+	b.failVerification(-100, p, BanReasonTimeout)
+	select {
+	case text := <-rec.ch:
+		if !strings.Contains(text, "42") || !strings.Contains(text, "-100") {
+			t.Errorf("уведомление о провале: %q", text)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("уведомление о провале не отправлено")
+	}
+	select {
+	case text := <-rec.ch:
+		t.Errorf("ошибки не входят в NOTIFY_EVENTS, но пришло %q", text)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// во время наплыва провалы по одному не отправляются
+	b.raids.hit(-200, 1, time.Minute, time.Now())
+	b.notifyFailed(context.Background(), MemberEvent{ChatID: -200, UserID: 43, Reason: BanReasonTimeout})
+	select {
+	case text := <-rec.ch:
+		t.Errorf("во время наплыва пришло %q", text)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNotifyConfigValidate(t *testing.T) {
+	if err := (NotifyConfig{MatrixHomeserver: "https://matrix.example"}).validate(); err == nil {
+		t.Error("Matrix без токена и комнаты должен отклоняться")
+	}
+	if err := (NotifyConfig{SlackWebhook: "hooks.slack.com/x"}).validate(); err == nil {
+		t.Error("вебхук без схемы должен отклоняться")
+	}
+	if err := (NotifyConfig{Events: []string{"joins"}}).validate(); err == nil {
+		t.Error("неизвестное событие должно отклоняться")
+	}
+	if err := (NotifyConfig{DiscordWebhook: "https://discord.com/api/webhooks/1/x", Events: []string{NotifyRaid, NotifyError}}).validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,56 @@
+package hamster
+
+import (
+	"sync"
+	"time"
+)
+
+// ==========================
+// Смещение getUpdates между перезапусками
+// ==========================
+
+// updateOffsetTTL — дольше Telegram обновления не хранит, а после недели
+// простоя нумерация может начаться заново, и старое смещение всё бы скрыло.
+const updateOffsetTTL = 24 * time.Hour
+
+// UpdateOffset — смещение getUpdates после последнего обработанного обновления.
+type UpdateOffset struct {
+	Offset int64     `json:"offset"`
+	At     time.Time `json:"at"`
+}
+
+// updateOffset хранит смещение, чтобы после сбоя не обработать те же
+// вступления повторно и не отправить приветствия дважды.
+type updateOffset struct {
+	mu sync.Mutex
+	v  UpdateOffset
+}
+
+func (o *updateOffset) get() UpdateOffset {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.v
+}
+
+func (o *updateOffset) set(v UpdateOffset) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.v = v
+}
+
+// startOffset возвращает смещение, с которого продолжить polling: сохранённое,
+// если оно не старше updateOffsetTTL, иначе 0.
+func (b *Bot) startOffset() int64 {
+	v := b.offset.get()
+	if v.Offset == 0 || time.Since(v.At) > updateOffsetTTL {
+		return 0
+	}
+	b.logger.Info("Продолжаем с обновления %d (сохранено %s)", v.Offset, v.At.Format(time.DateTime))
+	return v.Offset
+}
+
+// markProcessed запоминает смещение после пачки обновлений.
+func (b *Bot) markProcessed(offset int64) {
+	b.offset.set(UpdateOffset{Offset: offset, At: time.Now()})
+	b.saveState()
+}
@@ -0,0 +1,39 @@
+package hamster
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdateOffsetSurvivesRestart(t *testing.T) {
+	cfg := Config{SettingsFile: filepath.Join(t.TempDir(), "settings.json")}
+	b, err := NewBot("token", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.startOffset(); got != 0 {
+		t.Errorf("при первом запуске смещение 0, получили %d", got)
+	}
+	b.markProcessed(501)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := NewBot("token", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restarted.Close()
+	if got := restarted.startOffset(); got != 501 {
+		t.Errorf("после перезапуска ожидали смещение 501, получили %d", got)
+	}
+}
+
+func TestStaleUpdateOffsetIgnored(t *testing.T) {
+	b := setupBot()
+	b.offset.set(UpdateOffset{Offset: 501, At: time.Now().Add(-updateOffsetTTL - time.Hour)})
+	if got := b.startOffset(); got != 0 {
+		t.Errorf("смещение старше суток не используется, получили %d", got)
+	}
+}
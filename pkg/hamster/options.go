@@ -0,0 +1,59 @@
+package hamster
+
+import "log"
+
+// ==========================
+// Опции конструктора
+// ==========================
+
+// Option настраивает Bot при создании через NewBot.
+type Option func(*options)
+
+type options struct {
+	storage    Storage
+	httpClient HTTPClient
+	api        TelegramAPI
+	logger     *Logger
+	reporter   ErrorReporter
+	notifiers  []Notifier
+}
+
+// WithStorage задаёт готовое хранилище вместо открываемого по cfg.Storage.
+// Bot.Close закрывает и его.
+func WithStorage(s Storage) Option {
+	return func(o *options) { o.storage = s }
+}
+
+// WithHTTPClient задаёт HTTP-клиент для запросов к Telegram Bot API —
+// например, с прокси или своими таймаутами. Таймаут клиента должен быть
+// больше Config.PollTimeout: getUpdates использует long polling.
+func WithHTTPClient(c HTTPClient) Option {
+	return func(o *options) { o.httpClient = c }
+}
+
+// WithTelegramAPI задаёт клиент Bot API вместо NewTelegramAPIWithURL(cfg.APIURL, token, httpClient).
+func WithTelegramAPI(api TelegramAPI) Option {
+	return func(o *options) { o.api = api }
+}
+
+// WithErrorReporter задаёт получателя отчётов о паниках и повторяющихся сбоях
+// Bot API вместо Sentry из Config.SentryDSN.
+func WithErrorReporter(r ErrorReporter) Option {
+	return func(o *options) { o.reporter = r }
+}
+
+// WithNotifier добавляет получателя уведомлений к заданным в Config.Notify.
+func WithNotifier(n Notifier) Option {
+	return func(o *options) { o.notifiers = append(o.notifiers, n) }
+}
+
+// WithLogger задаёт логгер бота.
+func WithLogger(l *Logger) Option {
+	return func(o *options) { o.logger = l }
+}
+
+// NewLoggerFrom создаёт Logger поверх стандартного *log.Logger, чтобы
+// писать журнал бота туда же, куда пишет остальное приложение.
+func NewLoggerFrom(l *log.Logger) *Logger {
+	return &Logger{logger: l}
+}
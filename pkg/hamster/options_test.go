@@ -0,0 +1,49 @@
+package hamster
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewBotOptions(t *testing.T) {
+	dir := t.TempDir()
+	storage := newFileStorage(filepath.Join(dir, "settings.json"), NewLogger())
+	client := &mockHTTPClient{}
+	var buf bytes.Buffer
+	logger := NewLoggerFrom(log.New(&buf, "", 0))
+
+	b, err := NewBot("token", Config{}, WithStorage(storage), WithHTTPClient(client), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if b.storage != storage {
+		t.Error("WithStorage не применился")
+	}
+	if b.httpClient != client {
+		t.Error("WithHTTPClient не применился")
+	}
+	b.logger.Info("проверка %d", 1)
+	if !strings.Contains(buf.String(), "проверка 1") {
+		t.Errorf("WithLogger не применился, в журнале: %q", buf.String())
+	}
+}
+
+func TestNewBotDefaults(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewBot("token", Config{SettingsFile: filepath.Join(dir, "settings.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	if b.logger == nil || b.httpClient == nil {
+		t.Error("без опций должны использоваться логгер и HTTP-клиент по умолчанию")
+	}
+	if _, ok := b.storage.(*fileStorage); !ok {
+		t.Errorf("по умолчанию ожидали файловое хранилище, получили %T", b.storage)
+	}
+}
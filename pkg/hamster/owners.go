@@ -0,0 +1,161 @@
+package hamster
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ==========================
+// Владельцы бота
+// ==========================
+
+// ParseOwners разбирает список ID через запятую (BOT_OWNERS).
+func ParseOwners(s string) ([]int64, error) {
+	var owners []int64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("некорректный ID владельца %q", part)
+		}
+		owners = append(owners, id)
+	}
+	return owners, nil
+}
+
+// isOwner сообщает, входит ли пользователь в BOT_OWNERS.
+func (b *Bot) isOwner(userID int64) bool {
+	for _, id := range b.cfg.Owners {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ownerHelpText — справка по командам владельца, показывается владельцам в /start.
+func ownerHelpText() string {
+	return "👑 Команды владельца (только в личке):\n" +
+		"/chats — чаты, которые обслуживает бот\n" +
+		"/chatstats <id чата> — статистика и настройки чата\n" +
+		"/leave <id чата> — выйти из чата и забыть его\n" +
+		"/export <id чата|all> [json|csv] — выгрузить настройки, статистику, верификации и баны\n" +
+		"/purge <id пользователя> — удалить все данные о пользователе\n" +
+		"/blacklist [add|remove <id>] — общий чёрный список для всех чатов\n" +
+		"/broadcast [admins] <текст> — объявление во все чаты или их админам\n" +
+		"/restorebackup [имя] — восстановить состояние из копии\n" +
+		"/version — версия сборки"
+}
+
+// maxChatsListed ограничивает /chats, чтобы ответ поместился в одно сообщение.
+const maxChatsListed = 50
+
+// handleOwnerCommand обрабатывает глобальные команды владельца. Команды
+// работают только в личке и молча игнорируются для остальных.
+func (b *Bot) handleOwnerCommand(msg *Message) {
+	if msg.From == nil || msg.Chat.Type != "private" || !b.isOwner(msg.From.ID) {
+		return
+	}
+	chatID := msg.Chat.ID
+
+	switch commandName(msg.Text) {
+	case "/chats":
+		b.safeSendSilent(b.ctx, chatID, formatChatList(b.knownChats()))
+	case "/version":
+		b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("🐹 tg-hamster %s\nGo: %s, %s/%s", BuildInfo(), runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	case "/chatstats":
+		target, ok := parseChatArg(msg.Text)
+		if !ok {
+			b.safeSendSilent(b.ctx, chatID, "Использование: /chatstats <id чата>")
+			return
+		}
+		b.safeSendSilent(b.ctx, chatID, formatChatStats(target, b.chatSettings(target), b.stats.Get(target)))
+	case "/export":
+		args := strings.Fields(commandArg(msg.Text, 1))
+		const usage = "Использование: /export <id чата|all> [json|csv]"
+		if len(args) == 0 || len(args) > 2 {
+			b.safeSendSilent(b.ctx, chatID, usage)
+			return
+		}
+		var chats []int64 // пусто — все чаты
+		if args[0] != "all" {
+			target, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || target == 0 {
+				b.safeSendSilent(b.ctx, chatID, usage)
+				return
+			}
+			chats = []int64{target}
+		}
+		format := ExportJSON
+		if len(args) == 2 {
+			format = strings.ToLower(args[1])
+		}
+		if format != ExportJSON && format != ExportCSV {
+			b.safeSendSilent(b.ctx, chatID, usage)
+			return
+		}
+		if err := b.sendExport(chatID, chats, format); err != nil {
+			b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("❌ Выгрузка не удалась: %v", err))
+		}
+	case "/purge":
+		b.ownerPurge(msg)
+	case "/leave":
+		target, ok := parseChatArg(msg.Text)
+		if !ok {
+			b.safeSendSilent(b.ctx, chatID, "Использование: /leave <id чата>")
+			return
+		}
+		if err := b.safeLeaveChat(b.ctx, target); err != nil {
+			b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("❌ Не удалось выйти из чата %d: %v", target, err))
+			return
+		}
+		b.forgetChat(target)
+		b.logger.Info("Владелец %d вывел бота из чата %d", msg.From.ID, target)
+		b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("👋 Бот вышел из чата %d, его настройки и статистика удалены", target))
+	}
+}
+
+func parseChatArg(text string) (int64, bool) {
+	id, err := strconv.ParseInt(commandArg(text, 1), 10, 64)
+	return id, err == nil && id != 0
+}
+
+func formatChatList(chats []ChatInfo) string {
+	if len(chats) == 0 {
+		return "📭 Бот пока не обслуживает ни одного чата"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "💬 Чатов: %d\n", len(chats))
+	for i, c := range chats {
+		if i == maxChatsListed {
+			fmt.Fprintf(&sb, "… и ещё %d", len(chats)-maxChatsListed)
+			break
+		}
+		state := ""
+		if c.Settings.Disabled {
+			state = " (выкл.)"
+		}
+		fmt.Fprintf(&sb, "%d%s — вход %d, прошли %d, не прошли %d, забанены %d\n",
+			c.ChatID, state, c.Stats.Joins, c.Stats.Passed, c.Stats.Failed, c.Stats.Banned)
+	}
+	return sb.String()
+}
+
+func formatChatStats(chatID int64, cs ChatSettings, st ChatStats) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📊 Чат %d\n", chatID)
+	sb.WriteString(formatStats(st))
+	fmt.Fprintf(&sb, "\n\n⏱ Таймаут: %d сек., при провале: %s", cs.TimeoutSec(), cs.FailAction())
+	if cs.Disabled {
+		sb.WriteString("\n⏸ Проверка приостановлена")
+	}
+	if len(cs.NameFilters) > 0 {
+		fmt.Fprintf(&sb, "\n🔎 Шаблонов имён: %d", len(cs.NameFilters))
+	}
+	return sb.String()
+}
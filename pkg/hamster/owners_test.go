@@ -0,0 +1,120 @@
+package hamster
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseOwners(t *testing.T) {
+	owners, err := ParseOwners(" 1, 22 ,,333")
+	if err != nil || len(owners) != 3 || owners[2] != 333 {
+		t.Errorf("ожидалось [1 22 333], получили %v %v", owners, err)
+	}
+	if _, err := ParseOwners("1,abc"); err == nil {
+		t.Error("нечисловой ID должен отклоняться")
+	}
+	if _, err := ParseOwners("-5"); err == nil {
+		t.Error("отрицательный ID должен отклоняться")
+	}
+}
+
+func TestIsOwner(t *testing.T) {
+	b := setupBot()
+	b.cfg.Owners = []int64{10, 20}
+	if !b.isOwner(20) || b.isOwner(30) {
+		t.Error("isOwner должен проверять BOT_OWNERS")
+	}
+}
+
+func ownerMessage(text string) *Message {
+	return &Message{
+		MessageID: 1,
+		From:      &User{ID: 10},
+		Chat:      Chat{ID: 10, Type: "private"},
+		Text:      text,
+	}
+}
+
+func TestOwnerCommandsIgnoredForOthers(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.cfg.Owners = []int64{10}
+	sent := 0
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { sent++; return 1 }
+
+	msg := ownerMessage("/chats")
+	msg.From.ID = 11
+	b.handleOwnerCommand(msg)
+
+	msg = ownerMessage("/chats")
+	msg.Chat = Chat{ID: -100, Type: "supergroup"}
+	b.handleOwnerCommand(msg)
+
+	if sent != 0 {
+		t.Errorf("команды владельца не должны отвечать посторонним и в группах, отправлено %d", sent)
+	}
+}
+
+func TestOwnerChatsAndStats(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.cfg.Owners = []int64{10}
+	b.settings.SetTimeout(-100, 90)
+	b.stats.add(-100, func(c *ChatStats) { c.Joins = 3; c.Passed = 2 })
+	var reply string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { reply = text; return 1 }
+
+	b.handleOwnerCommand(ownerMessage("/chats"))
+	if !strings.Contains(reply, "-100") || !strings.Contains(reply, "вход 3") {
+		t.Errorf("/chats: %q", reply)
+	}
+
+	b.handleOwnerCommand(ownerMessage("/chatstats -100"))
+	if !strings.Contains(reply, "Прошли проверку: 2") || !strings.Contains(reply, "90 сек.") {
+		t.Errorf("/chatstats: %q", reply)
+	}
+
+	b.handleOwnerCommand(ownerMessage("/chatstats abc"))
+	if !strings.Contains(reply, "Использование") {
+		t.Errorf("/chatstats без ID: %q", reply)
+	}
+}
+
+func TestOwnerLeave(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.cfg.Owners = []int64{10}
+	b.settings.SetTimeout(-100, 90)
+	var left int64
+	fakeOf(b).leaveChat = func(chatID int64) error { left = chatID; return nil }
+
+	b.handleOwnerCommand(ownerMessage("/leave -100"))
+	if left != -100 {
+		t.Fatalf("leaveChat не вызван, chat %d", left)
+	}
+	if len(b.settings.ChatIDs()) != 0 {
+		t.Error("после /leave настройки чата должны удаляться")
+	}
+
+	var reply string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { reply = text; return 1 }
+	fakeOf(b).leaveChat = func(chatID int64) error { return errors.New("chat not found") }
+	b.handleOwnerCommand(ownerMessage("/leave -200"))
+	if !strings.Contains(reply, "chat not found") {
+		t.Errorf("ошибка leaveChat должна сообщаться владельцу: %q", reply)
+	}
+}
+
+func TestOwnerVersion(t *testing.T) {
+	b := setupBot()
+	b.cfg.Owners = []int64{10}
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { sent = append(sent, text); return 1 }
+
+	b.handleOwnerCommand(ownerMessage("/version"))
+	if len(sent) != 1 || !strings.Contains(sent[0], Version) || !strings.Contains(sent[0], runtime.Version()) {
+		t.Errorf("ожидалась версия сборки и Go, получили %v", sent)
+	}
+}
@@ -0,0 +1,192 @@
+package hamster
+
+import (
+	"context"
+	"sync"
+)
+
+// ==========================
+// Незавершённые проверки
+// ==========================
+
+// pendingKey — проверка участника в чате. Один человек может одновременно
+// проходить проверки в нескольких чатах, но в одном чате — только одну.
+type pendingKey struct {
+	chatID, userID int64
+}
+
+// messageKey — сообщение в чате: ID сообщений уникальны только в пределах чата.
+type messageKey struct {
+	chatID, msgID int64
+}
+
+// pendingStore хранит незавершённые проверки по паре (чат, пользователь) и
+// индексирует их по приветствию, чтобы нажатие кнопки находило проверку без
+// перебора. Нулевое значение готово к работе. mu защищает и поля
+// progressData, помеченные «под mu» (chatID меняется при миграции чата).
+type pendingStore struct {
+	mu       sync.Mutex
+	data     map[pendingKey]*progressData
+	byMsg    map[messageKey]*progressData // по приветствию
+	starting map[pendingKey]chan struct{} // приветствие отправляется, проверки ещё нет
+}
+
+func (p *progressData) key() pendingKey { return pendingKey{p.chatID, p.userID} }
+
+func (p *progressData) greetKey() messageKey { return messageKey{p.chatID, p.greetMsgID} }
+
+// index добавляет проверку в индексы; вызывается под mu.
+func (s *pendingStore) index(p *progressData) {
+	if s.data == nil {
+		s.data = make(map[pendingKey]*progressData)
+		s.byMsg = make(map[messageKey]*progressData)
+	}
+	s.data[p.key()] = p
+	if p.greetMsgID != 0 {
+		s.byMsg[p.greetKey()] = p
+	}
+}
+
+// unindex убирает проверку из индексов; вызывается под mu.
+func (s *pendingStore) unindex(p *progressData) {
+	delete(s.data, p.key())
+	if s.byMsg[p.greetKey()] == p {
+		delete(s.byMsg, p.greetKey())
+	}
+}
+
+// add сохраняет проверку, заменяя прежнюю проверку участника в том же чате.
+func (s *pendingStore) add(p *progressData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.data[p.key()]; ok {
+		s.unindex(old)
+	}
+	s.index(p)
+}
+
+// reserve отмечает, что для участника отправляется приветствие: нажатие его
+// кнопки может прийти раньше, чем проверка попадёт в add. release снимает
+// отметку; повторный вызов ничего не делает.
+func (s *pendingStore) reserve(chatID, userID int64) (release func()) {
+	k := pendingKey{chatID, userID}
+	ch := make(chan struct{})
+	s.mu.Lock()
+	if s.starting == nil {
+		s.starting = make(map[pendingKey]chan struct{})
+	}
+	s.starting[k] = ch
+	s.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			if s.starting[k] == ch {
+				delete(s.starting, k)
+			}
+			s.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// awaitStart ждёт, пока отправка приветствия участнику завершится; false —
+// приветствие не отправлялось или ctx отменён.
+func (s *pendingStore) awaitStart(ctx context.Context, chatID, userID int64) bool {
+	s.mu.Lock()
+	ch := s.starting[pendingKey{chatID, userID}]
+	s.mu.Unlock()
+	if ch == nil {
+		return false
+	}
+	select {
+	case <-ch:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// get возвращает проверку участника в чате или nil.
+func (s *pendingStore) get(chatID, userID int64) *progressData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[pendingKey{chatID, userID}]
+}
+
+// byMessage возвращает проверку, чьё приветствие — сообщение msgID чата, или nil.
+func (s *pendingStore) byMessage(chatID, msgID int64) *progressData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.byMsg[messageKey{chatID, msgID}]
+}
+
+// chatOf возвращает текущий чат проверки (после миграции он меняется);
+// false — проверка уже завершена.
+func (s *pendingStore) chatOf(p *progressData) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return p.chatID, s.data[p.key()] == p
+}
+
+// remove убирает проверку; false — её уже убрал кто-то другой.
+func (s *pendingStore) remove(p *progressData) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data[p.key()] != p {
+		return false
+	}
+	s.unindex(p)
+	return true
+}
+
+// removeChat убирает все проверки чата и возвращает их.
+func (s *pendingStore) removeChat(chatID int64) []*progressData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*progressData
+	for k, p := range s.data {
+		if k.chatID == chatID {
+			s.unindex(p)
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// moveChat переносит проверки чата from в чат to и возвращает их число.
+func (s *pendingStore) moveChat(from, to int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var moved []*progressData
+	for k, p := range s.data {
+		if k.chatID == from {
+			s.unindex(p)
+			moved = append(moved, p)
+		}
+	}
+	for _, p := range moved {
+		p.chatID = to
+		if old, ok := s.data[p.key()]; ok {
+			s.unindex(old)
+		}
+		s.index(p)
+	}
+	return len(moved)
+}
+
+// each вызывает fn для каждой проверки под mu: fn не должна обращаться к s.
+func (s *pendingStore) each(fn func(p *progressData)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.data {
+		fn(p)
+	}
+}
+
+// len возвращает число незавершённых проверок.
+func (s *pendingStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.data)
+}
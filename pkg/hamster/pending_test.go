@@ -0,0 +1,132 @@
+package hamster
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPendingStoreSameUserInTwoChats(t *testing.T) {
+	var s pendingStore
+	a := &progressData{chatID: -1, userID: 42, greetMsgID: 10}
+	c := &progressData{chatID: -2, userID: 42, greetMsgID: 10}
+	s.add(a)
+	s.add(c)
+
+	if s.len() != 2 {
+		t.Fatalf("ожидали 2 проверки, получили %d", s.len())
+	}
+	if s.get(-1, 42) != a || s.get(-2, 42) != c {
+		t.Error("проверки одного участника в разных чатах не должны пересекаться")
+	}
+	if s.byMessage(-1, 10) != a || s.byMessage(-2, 10) != c {
+		t.Error("приветствие должно искаться в пределах своего чата")
+	}
+	if s.byMessage(-3, 10) != nil {
+		t.Error("в чужом чате приветствия быть не должно")
+	}
+}
+
+func TestPendingStoreAddReplaces(t *testing.T) {
+	var s pendingStore
+	old := &progressData{chatID: -1, userID: 42, greetMsgID: 10}
+	s.add(old)
+	fresh := &progressData{chatID: -1, userID: 42, greetMsgID: 20}
+	s.add(fresh)
+
+	if s.len() != 1 || s.get(-1, 42) != fresh {
+		t.Error("новая проверка должна заменить прежнюю")
+	}
+	if s.byMessage(-1, 10) != nil {
+		t.Error("прежнее приветствие должно уйти из индекса")
+	}
+	if s.remove(old) {
+		t.Error("заменённую проверку нельзя убрать повторно")
+	}
+	if s.byMessage(-1, 20) != fresh {
+		t.Error("удаление заменённой проверки не должно трогать новую")
+	}
+}
+
+func TestPendingStoreRemove(t *testing.T) {
+	var s pendingStore
+	p := &progressData{chatID: -1, userID: 42, greetMsgID: 10}
+	s.add(p)
+
+	if !s.remove(p) {
+		t.Fatal("первое удаление должно сработать")
+	}
+	if s.remove(p) {
+		t.Error("повторное удаление должно вернуть false")
+	}
+	if _, ok := s.chatOf(p); ok {
+		t.Error("удалённая проверка не должна считаться активной")
+	}
+	if s.get(-1, 42) != nil || s.byMessage(-1, 10) != nil {
+		t.Error("проверка должна уйти из обоих индексов")
+	}
+}
+
+func TestPendingStoreMoveChat(t *testing.T) {
+	var s pendingStore
+	p := &progressData{chatID: -1, userID: 42, greetMsgID: 10}
+	other := &progressData{chatID: -5, userID: 43, greetMsgID: 10}
+	s.add(p)
+	s.add(other)
+
+	if n := s.moveChat(-1, -1001); n != 1 {
+		t.Fatalf("ожидали перенос 1 проверки, получили %d", n)
+	}
+	if chatID, ok := s.chatOf(p); !ok || chatID != -1001 {
+		t.Errorf("проверка должна жить в новом чате, получили %d, %v", chatID, ok)
+	}
+	if s.get(-1001, 42) != p || s.byMessage(-1001, 10) != p {
+		t.Error("оба индекса должны указывать на новый чат")
+	}
+	if s.get(-1, 42) != nil || s.byMessage(-1, 10) != nil {
+		t.Error("в старом чате проверки остаться не должно")
+	}
+	if s.get(-5, 43) != other {
+		t.Error("проверки других чатов переноситься не должны")
+	}
+}
+
+func TestPendingStoreRemoveChat(t *testing.T) {
+	var s pendingStore
+	s.add(&progressData{chatID: -1, userID: 1, greetMsgID: 10})
+	s.add(&progressData{chatID: -1, userID: 2, greetMsgID: 11})
+	s.add(&progressData{chatID: -2, userID: 1, greetMsgID: 10})
+
+	if got := s.removeChat(-1); len(got) != 2 {
+		t.Errorf("ожидали 2 проверки чата, получили %d", len(got))
+	}
+	if s.len() != 1 || s.byMessage(-2, 10) == nil {
+		t.Error("проверки другого чата должны остаться")
+	}
+}
+
+func TestPendingStoreAwaitStart(t *testing.T) {
+	var s pendingStore
+	if s.awaitStart(context.Background(), -1, 42) {
+		t.Error("без отправки приветствия ждать нечего")
+	}
+
+	release := s.reserve(-1, 42)
+	p := &progressData{chatID: -1, userID: 42, greetMsgID: 10}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.add(p)
+		release()
+	}()
+	if !s.awaitStart(context.Background(), -1, 42) || s.byMessage(-1, 10) != p {
+		t.Error("нажатие должно дождаться регистрации проверки")
+	}
+	release() // повторный вызов безопасен
+
+	defer s.reserve(-1, 43)()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if s.awaitStart(ctx, -1, 43) {
+		t.Error("ожидание прерывается отменой контекста")
+	}
+}
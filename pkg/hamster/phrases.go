@@ -0,0 +1,272 @@
+package hamster
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// phrases.go — наборы фраз и иконок для кнопки проверки
+
+// PhrasePack — набор фраз и иконок для кнопки проверки. Наборы, кроме
+// встроенных, загружаются из JSON-файлов каталога PHRASES_DIR.
+type PhrasePack struct {
+	Name    string   `json:"name"`
+	Title   string   `json:"title,omitempty"` // описание для /phrases
+	Phrases []string `json:"phrases"`
+	Icons   []string `json:"icons,omitempty"` // без иконок кнопка — только фраза
+}
+
+const (
+	// DefaultPhrasePack — набор, которым пользуются чаты без /phrases.
+	DefaultPhrasePack = "default"
+	// maxPhraseLen — чтобы фраза с иконкой помещалась на кнопке.
+	maxPhraseLen = 48
+	// maxChatPhrases — сколько своих фраз можно задать в чате.
+	maxChatPhrases = 20
+)
+
+var phrasesList = []string{
+	"Я пришёл с миром",
+	"Бот не пройдёт",
+	"Ключ!",
+	"Пароль!",
+	"Да пребудет со мной кнопка",
+	"Чайник, но свой",
+	"Сканирую QR из Матрицы",
+	"Я — Грут",
+	"Это точно не капча",
+	"Меч самурая",
+	"Век живи — век учись",
+	"Хакер в деле",
+	"Не трогай мои кнопки",
+	"Секретный агент",
+	"Ниндзя внутри",
+	"Мышь в проводах",
+	"Яблочный пирог",
+	"Код сломан",
+	"Привет из будущего",
+	"Выключи свет",
+	"Нажимаю как человек",
+	"Пропуск выдан",
+	"Проверено — человек",
+	"Отпечатал ладонь (шутка)",
+	"Никаких скриптов, честно",
+}
+
+// Список иконок
+var icons = []string{
+	"🟢", "🔑", "🛡️", "⚡", "🔥", "💡", "🎯", "🚀", "🧩", "🪐",
+	"🌍", "🤖", "🔒", "⌨️", "☕", "📱", "🌟", "🔍", "🕹️", "🎮",
+	"🌌", "⚔️", "📚", "👨‍💻", "🚫", "🕵️", "🥷", "🖱️", "🥧", "🔧",
+	"🔮", "💤", "🌈", "💾", "🛸", "🧠", "🔋", "🎭", "📡", "⏰",
+}
+
+// plainPhrases — набор без шуток для серьёзных чатов
+var plainPhrases = []string{
+	"Я человек",
+	"Подтверждаю",
+	"Я не бот",
+	"Вхожу в чат",
+	"Готово",
+}
+
+func init() {
+	RegisterPhrasePack(PhrasePack{Name: DefaultPhrasePack, Title: "шуточные фразы", Phrases: phrasesList, Icons: icons})
+	RegisterPhrasePack(PhrasePack{Name: "plain", Title: "короткие нейтральные фразы", Phrases: plainPhrases, Icons: []string{"✅", "👋", "🙋"}})
+}
+
+var phrasePacks = struct {
+	mu sync.RWMutex
+	m  map[string]PhrasePack
+}{m: make(map[string]PhrasePack)}
+
+// RegisterPhrasePack регистрирует набор фраз; набор с тем же именем заменяется,
+// так что файл из PHRASES_DIR может переопределить встроенный.
+func RegisterPhrasePack(p PhrasePack) {
+	phrasePacks.mu.Lock()
+	defer phrasePacks.mu.Unlock()
+	phrasePacks.m[p.Name] = p
+}
+
+// lookupPhrasePack возвращает зарегистрированный набор фраз.
+func lookupPhrasePack(name string) (PhrasePack, bool) {
+	phrasePacks.mu.RLock()
+	defer phrasePacks.mu.RUnlock()
+	p, ok := phrasePacks.m[name]
+	return p, ok
+}
+
+// PhrasePackNames возвращает имена зарегистрированных наборов фраз.
+func PhrasePackNames() []string {
+	phrasePacks.mu.RLock()
+	defer phrasePacks.mu.RUnlock()
+	names := make([]string, 0, len(phrasePacks.m))
+	for name := range phrasePacks.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate проверяет, что набором можно пользоваться.
+func (p PhrasePack) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("у набора нет имени")
+	}
+	if len(p.Phrases) == 0 {
+		return fmt.Errorf("в наборе %q нет фраз", p.Name)
+	}
+	for _, ph := range p.Phrases {
+		if strings.TrimSpace(ph) == "" || len([]rune(ph)) > maxPhraseLen {
+			return fmt.Errorf("фраза %q в наборе %q пустая или длиннее %d символов", ph, p.Name, maxPhraseLen)
+		}
+	}
+	return nil
+}
+
+// LoadPhrasePacks регистрирует наборы из файлов *.json каталога dir.
+// Имя набора — поле name, а без него — имя файла без расширения.
+func LoadPhrasePacks(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return 0, err
+		}
+		var p PhrasePack
+		if err := json.Unmarshal(data, &p); err != nil {
+			return 0, fmt.Errorf("%s: %w", f, err)
+		}
+		if p.Name == "" {
+			p.Name = strings.TrimSuffix(filepath.Base(f), ".json")
+		}
+		if err := p.validate(); err != nil {
+			return 0, fmt.Errorf("%s: %w", f, err)
+		}
+		RegisterPhrasePack(p)
+	}
+	return len(files), nil
+}
+
+// phrasePackFor — набор чата: свои фразы из /phrases set поверх выбранного
+// набора. Неизвестный набор (файл убрали из PHRASES_DIR) заменяется набором по умолчанию.
+func phrasePackFor(cs ChatSettings) PhrasePack {
+	p, ok := lookupPhrasePack(cs.PhrasePack)
+	if !ok {
+		p, _ = lookupPhrasePack(DefaultPhrasePack)
+	}
+	if len(cs.Phrases) > 0 {
+		p.Phrases = cs.Phrases
+	}
+	return p
+}
+
+// pick возвращает случайную строку "ICON + SPACE + PHRASE".
+func (p PhrasePack) pick() string {
+	if len(p.Phrases) == 0 {
+		return "👋 Привет!"
+	}
+	phrase := p.Phrases[rand.Intn(len(p.Phrases))]
+	if len(p.Icons) == 0 {
+		return phrase
+	}
+	return p.Icons[rand.Intn(len(p.Icons))] + " " + phrase
+}
+
+// randomGreeting возвращает (phrase, icon)
+func randomGreeting() (string, string) {
+	if len(phrasesList) == 0 {
+		return "Привет!", "👋"
+	}
+	p := phrasesList[rand.Intn(len(phrasesList))]
+	i := icons[rand.Intn(len(icons))]
+	return p, i
+}
+
+// pickPhrase возвращает полную строку "ICON + SPACE + PHRASE" из набора по умолчанию
+func pickPhrase() string {
+	p, i := randomGreeting()
+	return i + " " + p
+}
+
+// ==========================
+// Команда /phrases
+// ==========================
+
+func (b *Bot) handlePhrasesCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять фразы кнопки", 5*time.Second)
+		return
+	}
+
+	arg := commandArg(msg.Text, 1)
+	switch name := strings.ToLower(arg); {
+	case name == "":
+		b.sendTemporary(chatID, b.phrasesStatus(chatID), 30*time.Second)
+	case name == "reset":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.PhrasePack = ""; c.Phrases = nil })
+		b.sendTemporary(chatID, "✅ Кнопка снова использует набор по умолчанию", 5*time.Second)
+	case strings.HasPrefix(name, "set "):
+		var phrases []string
+		for _, p := range strings.Split(strings.TrimSpace(arg[len("set "):]), "|") {
+			if p = strings.TrimSpace(p); p != "" {
+				phrases = append(phrases, p)
+			}
+		}
+		if err := (PhrasePack{Name: "set", Phrases: phrases}).validate(); err != nil || len(phrases) > maxChatPhrases {
+			b.sendTemporary(chatID, fmt.Sprintf("❌ Нужно от 1 до %d фраз через «|», каждая не длиннее %d символов", maxChatPhrases, maxPhraseLen), 10*time.Second)
+			return
+		}
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Phrases = phrases })
+		b.sendTemporary(chatID, fmt.Sprintf("✅ Свои фразы кнопки: %d\nПример: %s", len(phrases), phrasePackFor(b.chatSettings(chatID)).pick()), 10*time.Second)
+	default:
+		if _, ok := lookupPhrasePack(name); !ok {
+			b.sendTemporary(chatID, "❌ Нет такого набора. Доступны: "+strings.Join(PhrasePackNames(), ", "), 10*time.Second)
+			return
+		}
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.PhrasePack = name })
+		text := "✅ Выбран набор " + name + "\nПример: " + phrasePackFor(b.chatSettings(chatID)).pick()
+		if len(b.chatSettings(chatID).Phrases) > 0 {
+			text += "\n⚠️ Свои фразы чата важнее набора; убрать их: /phrases reset"
+		}
+		b.sendTemporary(chatID, text, 10*time.Second)
+	}
+}
+
+// phrasesStatus — список наборов с примерами для /phrases без аргументов.
+func (b *Bot) phrasesStatus(chatID int64) string {
+	cs := b.chatSettings(chatID)
+	current := cs.PhrasePack
+	if current == "" {
+		current = DefaultPhrasePack
+	}
+	var sb strings.Builder
+	sb.WriteString("🎭 Наборы фраз для кнопки:\n")
+	for _, name := range PhrasePackNames() {
+		p, _ := lookupPhrasePack(name)
+		mark := "▫️"
+		if name == current {
+			mark = "▪️"
+		}
+		fmt.Fprintf(&sb, "%s %s — %s, например «%s»\n", mark, name, p.Title, p.pick())
+	}
+	if len(cs.Phrases) > 0 {
+		fmt.Fprintf(&sb, "\n✍️ Свои фразы чата (%d): %s\n", len(cs.Phrases), strings.Join(cs.Phrases, " | "))
+	}
+	sb.WriteString("\n⚙️ /phrases <набор> | set фраза1 | фраза2 | reset")
+	return sb.String()
+}
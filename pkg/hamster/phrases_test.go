@@ -0,0 +1,132 @@
+package hamster
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRandomGreetingReturnsNonEmpty(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		phrase, icon := randomGreeting()
+		if phrase == "" {
+			t.Errorf("Ожидалась непустая фраза")
+		}
+		if icon == "" {
+			t.Errorf("Ожидалась непустая иконка")
+		}
+	}
+}
+
+func TestPickPhraseFormat(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		result := pickPhrase()
+		if len(result) < 3 {
+			t.Errorf("слишком короткая строка: %q", result)
+		}
+
+		idx := strings.IndexRune(result, ' ')
+		if idx <= 0 {
+			t.Errorf("Ожидался пробел после иконки: %q", result)
+		}
+
+		if idx+1 >= len(result) {
+			t.Errorf("После пробела нет текста: %q", result)
+		}
+	}
+}
+
+func TestPickPhraseRandomness(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		p := pickPhrase()
+		if p == "" {
+			t.Errorf("pickPhrase вернул пустую строку")
+		}
+		seen[p] = true
+	}
+	if len(seen) < 5 {
+		t.Errorf("слишком мало уникальных фраз: %v", seen)
+	}
+}
+
+// Дополнительно можно проверить, что фраза начинается с известной иконки
+func TestPickPhraseStartsWithIcon(t *testing.T) {
+	icons := []string{
+		"🟢", "🔑", "🛡️", "⚡", "🔥", "💡", "🎯", "🚀", "🧩", "🪐",
+		"🌍", "🤖", "🔒", "⌨️", "☕", "📱", "🌟", "🔍", "🕹️", "🎮",
+		"🌌", "⚔️", "📚", "👨‍💻", "🚫", "🕵️", "🥷", "🖱️", "🥧", "🔧",
+		"🔮", "💤", "🌈", "💾", "🛸", "🧠", "🔋", "🎭", "📡", "⏰",
+	} // список основных иконок
+	for i := 0; i < 20; i++ {
+		p := pickPhrase()
+		found := false
+		for _, icon := range icons {
+			if strings.HasPrefix(p, icon) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Фраза не начинается с известной иконки: %q", p)
+		}
+	}
+}
+
+func TestLoadPhrasePacks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "test-office.json"), []byte(`{"title":"офис","phrases":["Я на созвоне"],"icons":["📎"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n, err := LoadPhrasePacks(dir)
+	if err != nil || n != 1 {
+		t.Fatalf("LoadPhrasePacks = %d, %v", n, err)
+	}
+	p, ok := lookupPhrasePack("test-office")
+	if !ok || p.pick() != "📎 Я на созвоне" {
+		t.Errorf("имя набора берётся из файла: %+v", p)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"phrases":[]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPhrasePacks(dir); err == nil {
+		t.Error("набор без фраз должен отклоняться")
+	}
+}
+
+func TestPhrasePackForChat(t *testing.T) {
+	if p := phrasePackFor(ChatSettings{PhrasePack: "нет-такого"}); p.Name != DefaultPhrasePack {
+		t.Errorf("неизвестный набор заменяется набором по умолчанию: %q", p.Name)
+	}
+	p := phrasePackFor(ChatSettings{PhrasePack: "plain", Phrases: []string{"Свой"}})
+	if got := p.pick(); !strings.HasSuffix(got, " Свой") {
+		t.Errorf("свои фразы чата важнее набора: %q", got)
+	}
+}
+
+func TestPhrasesCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	run := func(text string) {
+		b.handlePhrasesCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: text})
+	}
+	run("/phrases plain")
+	run("/phrases set Я свой | Пустите ")
+	cs := b.chatSettings(-100)
+	if cs.PhrasePack != "plain" || len(cs.Phrases) != 2 || cs.Phrases[1] != "Пустите" {
+		t.Fatalf("настройки: %+v", cs)
+	}
+	run("/phrases нет-такого")
+	if b.chatSettings(-100).PhrasePack != "plain" {
+		t.Error("неизвестный набор не должен выбираться")
+	}
+	run("/phrases reset")
+	if cs := b.chatSettings(-100); cs.PhrasePack != "" || cs.Phrases != nil {
+		t.Errorf("/phrases reset должен сбрасывать набор и фразы: %+v", cs)
+	}
+}
@@ -0,0 +1,110 @@
+package hamster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Режим без эмодзи
+// ==========================
+
+// isEmojiRune — символы, которые экранные дикторы зачитывают длинными
+// названиями, а старые клиенты показывают квадратиками.
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // пиктограммы, смайлики, флаги
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // ⌨️ ⏳ ⏰
+		return true
+	case r >= 0x2580 && r <= 0x25FF: // ▓ ░ и геометрические фигуры
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // ☕ ⚡ ✅ ✨
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF:
+		return true
+	case r == 0xFE0F || r == 0x200D || r == 0x20E3: // селекторы вариантов, ZWJ, keycap
+		return true
+	}
+	return false
+}
+
+// stripEmoji убирает эмодзи и лишние пробелы, сохраняя переводы строк.
+func stripEmoji(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if isEmojiRune(r) {
+			return -1
+		}
+		return r
+	}, s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// plainPrompt убирает эмодзи из вопроса и кнопок проверки. Кнопка, от которой
+// ничего не осталось, сохраняет исходный текст.
+func plainPrompt(p ChallengePrompt) ChallengePrompt {
+	p.Text = stripEmoji(p.Text)
+	rows := make([][]ChallengeButton, len(p.Buttons))
+	for i, row := range p.Buttons {
+		rows[i] = make([]ChallengeButton, len(row))
+		for j, btn := range row {
+			if text := stripEmoji(btn.Text); text != "" {
+				btn.Text = text
+			}
+			rows[i][j] = btn
+		}
+	}
+	p.Buttons = rows
+	return p
+}
+
+// plainCaptcha заменяет проверки, построенные на эмодзи, на пример с числами.
+func plainCaptcha(cs ChatSettings) ChatSettings {
+	if cs.PlainText && (cs.Captcha() == CaptchaEmoji || cs.Captcha() == CaptchaSequence) {
+		cs.CaptchaType = CaptchaMath
+	}
+	return cs
+}
+
+// countdownText — текст прогрессбара в стиле чата или, в режиме без
+// эмодзи, число оставшихся секунд.
+func countdownText(cs ChatSettings, timeout, remaining, step int) string {
+	if cs.PlainText {
+		return fmt.Sprintf("Осталось: %d сек.", remaining)
+	}
+	return progressStyleFor(cs).Render(timeout, remaining, step)
+}
+
+// ==========================
+// Команда /plaintext
+// ==========================
+
+func (b *Bot) handlePlainTextCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять оформление проверки", 5*time.Second)
+		return
+	}
+
+	switch strings.ToLower(commandArg(msg.Text, 1)) {
+	case "on":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.PlainText = true })
+		b.sendTemporary(chatID, "Проверка будет показываться без эмодзи, с обратным отсчётом в секундах", 5*time.Second)
+	case "off":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.PlainText = false })
+		b.sendTemporary(chatID, "✅ Проверка снова показывается с эмодзи", 5*time.Second)
+	case "":
+		b.sendTemporary(chatID, "Режим без эмодзи: "+onOff(b.chatSettings(chatID).PlainText)+
+			"\n/plaintext on|off", 10*time.Second)
+	default:
+		b.sendTemporary(chatID, "Использование: /plaintext on|off", 5*time.Second)
+	}
+}
@@ -0,0 +1,59 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStripEmoji(t *testing.T) {
+	cases := map[string]string{
+		"🧮 Сколько будет 2 + 3?":         "Сколько будет 2 + 3?",
+		"🛡️ Пропуск выдан 👉":             "Пропуск выдан",
+		"Привет!\n\n✅ Проверка пройдена": "Привет!\n\nПроверка пройдена",
+		"👨‍💻":                "",
+		"Нажмите: кот → дом": "Нажмите: кот → дом",
+	}
+	for in, want := range cases {
+		if got := stripEmoji(in); got != want {
+			t.Errorf("stripEmoji(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPlainPromptKeepsEmojiOnlyButtons(t *testing.T) {
+	p := plainPrompt(ChallengePrompt{
+		Text:    "🙋 Вопрос",
+		Buttons: [][]ChallengeButton{{{Text: "🤖 Я бот", Data: "a"}, {Text: "🐱", Data: "b"}}},
+	})
+	if p.Text != "Вопрос" || p.Buttons[0][0].Text != "Я бот" || p.Buttons[0][1].Text != "🐱" {
+		t.Errorf("plainPrompt = %+v", p)
+	}
+}
+
+func TestPlainCaptchaAndCountdown(t *testing.T) {
+	cs := ChatSettings{PlainText: true, CaptchaType: CaptchaEmoji}
+	if got := plainCaptcha(cs).Captcha(); got != CaptchaMath {
+		t.Errorf("emoji в режиме без эмодзи заменяется на math, получили %q", got)
+	}
+	if got := plainCaptcha(ChatSettings{CaptchaType: CaptchaEmoji}).Captcha(); got != CaptchaEmoji {
+		t.Errorf("без режима тип не меняется, получили %q", got)
+	}
+	if got := countdownText(cs, 30, 12, 3); got != "Осталось: 12 сек." {
+		t.Errorf("countdownText = %q", got)
+	}
+}
+
+func TestPlainTextCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	b.handlePlainTextCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/plaintext on"})
+	if !b.chatSettings(-100).PlainText {
+		t.Fatal("/plaintext on должен включать режим")
+	}
+	b.handlePlainTextCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/plaintext off"})
+	if b.chatSettings(-100).PlainText {
+		t.Error("/plaintext off должен выключать режим")
+	}
+}
@@ -0,0 +1,443 @@
+package hamster
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq" // драйвер postgres для database/sql
+)
+
+// ==========================
+// Хранилище PostgreSQL
+// ==========================
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// postgresStorage — хранилище для больших инсталляций: сотни групп, журнал банов для анализа.
+type postgresStorage struct {
+	db     *sql.DB
+	logger *Logger
+	locks  atomic.Int64 // число захватов блокировок, для очистки истёкших
+}
+
+func openPostgresStorage(dsn string, logger *Logger) (*postgresStorage, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("не задан STORAGE_DSN")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("подключение к postgres: %w", err)
+	}
+	s := &postgresStorage{db: db, logger: logger}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("миграции postgres: %w", err)
+	}
+	logger.Info("Хранилище postgres подключено")
+	return s, nil
+}
+
+// migration — SQL-файл вида 0001_init.sql.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations читает встроенные миграции, отсортированные по номеру.
+func loadMigrations(fsys fs.FS, dir string) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []migration
+	seen := make(map[int]string)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			return nil, fmt.Errorf("имя миграции без номера: %s", e.Name())
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("некорректный номер миграции: %s", e.Name())
+		}
+		if prev, dup := seen[version]; dup {
+			return nil, fmt.Errorf("повторный номер миграции %d: %s и %s", version, prev, e.Name())
+		}
+		seen[version] = e.Name()
+		content, err := fs.ReadFile(fsys, dir+"/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, migration{version: version, name: e.Name(), sql: string(content)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].version < out[j].version })
+	return out, nil
+}
+
+// migrate применяет ещё не применённые миграции, каждую в своей транзакции.
+func (s *postgresStorage) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+	var current int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	migrations, err := loadMigrations(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", m.name, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		s.logger.Info("Применена миграция %s", m.name)
+	}
+	return nil
+}
+
+func (s *postgresStorage) LoadSettings() (map[int64]*ChatSettings, error) {
+	rows, err := s.db.Query(`SELECT chat_id, settings FROM chat_settings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chats := make(map[int64]*ChatSettings)
+	for rows.Next() {
+		var chatID int64
+		var raw []byte
+		if err := rows.Scan(&chatID, &raw); err != nil {
+			return nil, err
+		}
+		var cs ChatSettings
+		if err := json.Unmarshal(raw, &cs); err != nil {
+			return nil, fmt.Errorf("настройки чата %d: %w", chatID, err)
+		}
+		chats[chatID] = &cs
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// пустая таблица — настройки ещё не сохранялись, можно перенести старые файлы
+	if len(chats) == 0 {
+		return nil, nil
+	}
+	return chats, nil
+}
+
+func (s *postgresStorage) SaveSettings(chats map[int64]*ChatSettings) error {
+	return s.replaceAll(`DELETE FROM chat_settings`,
+		`INSERT INTO chat_settings (chat_id, settings) VALUES ($1, $2)`,
+		func(insert *sql.Stmt) error {
+			for chatID, cs := range chats {
+				raw, err := json.Marshal(cs)
+				if err != nil {
+					return err
+				}
+				if _, err := insert.Exec(chatID, raw); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func (s *postgresStorage) LoadVerified() (map[string]time.Time, error) {
+	rows, err := s.db.Query(`SELECT chat_id, user_id, verified_at FROM verified_users`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verified := make(map[string]time.Time)
+	for rows.Next() {
+		var chatID, userID int64
+		var at time.Time
+		if err := rows.Scan(&chatID, &userID, &at); err != nil {
+			return nil, err
+		}
+		verified[verifiedKey(chatID, userID)] = at
+	}
+	return verified, rows.Err()
+}
+
+func (s *postgresStorage) SaveVerified(verified map[string]time.Time) error {
+	return s.replaceAll(`DELETE FROM verified_users`,
+		`INSERT INTO verified_users (chat_id, user_id, verified_at) VALUES ($1, $2, $3)`,
+		func(insert *sql.Stmt) error {
+			for key, at := range verified {
+				chatID, userID, ok := parseVerifiedKey(key)
+				if !ok {
+					continue
+				}
+				if _, err := insert.Exec(chatID, userID, at); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func (s *postgresStorage) LoadStats() (map[int64]ChatStats, error) {
+	rows, err := s.db.Query(`SELECT chat_id, joins, passed, failed, banned, clicks, click_ms_sum, too_fast, click_hist, click_hist_ms_sum, links, updated_at, series, seen_at FROM chat_stats`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[int64]ChatStats)
+	for rows.Next() {
+		var chatID int64
+		var st ChatStats
+		var hist []int64
+		var links, series []byte
+		var updated, seen sql.NullTime
+		if err := rows.Scan(&chatID, &st.Joins, &st.Passed, &st.Failed, &st.Banned, &st.Clicks, &st.ClickMsSum, &st.TooFast, pq.Array(&hist), &st.ClickHistMsSum, &links, &updated, &series, &seen); err != nil {
+			return nil, err
+		}
+		st.Updated = updated.Time
+		st.Seen = seen.Time
+		copy(st.ClickHist[:], hist)
+		if err := json.Unmarshal(links, &st.Links); err != nil {
+			return nil, fmt.Errorf("статистика ссылок чата %d: %w", chatID, err)
+		}
+		if len(st.Links) == 0 {
+			st.Links = nil
+		}
+		if err := json.Unmarshal(series, &st.Series); err != nil {
+			return nil, fmt.Errorf("почасовой ряд чата %d: %w", chatID, err)
+		}
+		if len(st.Series) == 0 {
+			st.Series = nil
+		}
+		stats[chatID] = st
+	}
+	return stats, rows.Err()
+}
+
+func (s *postgresStorage) SaveStats(stats map[int64]ChatStats) error {
+	return s.replaceAll(`DELETE FROM chat_stats`,
+		`INSERT INTO chat_stats (chat_id, joins, passed, failed, banned, clicks, click_ms_sum, too_fast, click_hist, click_hist_ms_sum, links, updated_at, series, seen_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
+		func(insert *sql.Stmt) error {
+			for chatID, st := range stats {
+				links := []byte("{}")
+				if len(st.Links) > 0 {
+					var err error
+					if links, err = json.Marshal(st.Links); err != nil {
+						return err
+					}
+				}
+				series := []byte("[]")
+				if len(st.Series) > 0 {
+					var err error
+					if series, err = json.Marshal(st.Series); err != nil {
+						return err
+					}
+				}
+				if _, err := insert.Exec(chatID, st.Joins, st.Passed, st.Failed, st.Banned, st.Clicks, st.ClickMsSum, st.TooFast, pq.Array(st.ClickHist[:]), st.ClickHistMsSum, links, sql.NullTime{Time: st.Updated, Valid: !st.Updated.IsZero()}, series,
+					sql.NullTime{Time: st.Seen, Valid: !st.Seen.IsZero()}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func (s *postgresStorage) LoadSentMessages() (map[int64][]SentMessage, error) {
+	rows, err := s.db.Query(`SELECT chat_id, message_id, sent_at FROM bot_messages ORDER BY sent_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sent := make(map[int64][]SentMessage)
+	for rows.Next() {
+		var chatID int64
+		var m SentMessage
+		if err := rows.Scan(&chatID, &m.MsgID, &m.At); err != nil {
+			return nil, err
+		}
+		sent[chatID] = append(sent[chatID], m)
+	}
+	return sent, rows.Err()
+}
+
+func (s *postgresStorage) SaveSentMessages(sent map[int64][]SentMessage) error {
+	return s.replaceAll(`DELETE FROM bot_messages`,
+		`INSERT INTO bot_messages (chat_id, message_id, sent_at) VALUES ($1, $2, $3)`,
+		func(insert *sql.Stmt) error {
+			for chatID, list := range sent {
+				for _, m := range list {
+					if _, err := insert.Exec(chatID, m.MsgID, m.At); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+}
+
+func (s *postgresStorage) LoadUpdateOffset() (UpdateOffset, error) {
+	var o UpdateOffset
+	err := s.db.QueryRow(`SELECT update_offset, saved_at FROM update_offset WHERE id = 1`).Scan(&o.Offset, &o.At)
+	if err == sql.ErrNoRows {
+		return UpdateOffset{}, nil
+	}
+	return o, err
+}
+
+func (s *postgresStorage) SaveUpdateOffset(o UpdateOffset) error {
+	_, err := s.db.Exec(`INSERT INTO update_offset (id, update_offset, saved_at) VALUES (1, $1, $2)
+		ON CONFLICT (id) DO UPDATE SET update_offset = EXCLUDED.update_offset, saved_at = EXCLUDED.saved_at`,
+		o.Offset, o.At)
+	return err
+}
+
+// lockPurgeEvery — через сколько захватов удалять истёкшие блокировки.
+const lockPurgeEvery = 100
+
+func (s *postgresStorage) TryLock(key, owner string, ttl time.Duration) (bool, error) {
+	if s.locks.Add(1)%lockPurgeEvery == 0 {
+		if _, err := s.db.Exec(`DELETE FROM locks WHERE expires_at < now()`); err != nil {
+			s.logger.Warn("Не удалось удалить истёкшие блокировки: %v", err)
+		}
+	}
+	// время сравнивается по часам базы: у экземпляров они могут расходиться
+	res, err := s.db.Exec(`INSERT INTO locks (key, owner, expires_at)
+		VALUES ($1, $2, now() + $3 * interval '1 millisecond')
+		ON CONFLICT (key) DO UPDATE SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		WHERE locks.owner = EXCLUDED.owner OR locks.expires_at < now()`,
+		key, owner, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *postgresStorage) Unlock(key, owner string) error {
+	_, err := s.db.Exec(`DELETE FROM locks WHERE key = $1 AND owner = $2`, key, owner)
+	return err
+}
+
+func (s *postgresStorage) LogBan(entry BanLogEntry) error {
+	_, err := s.db.Exec(`INSERT INTO ban_log (chat_id, user_id, reason, banned_by, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		entry.ChatID, entry.UserID, entry.Reason, entry.By, entry.At)
+	return err
+}
+
+func (s *postgresStorage) BanLog(q BanLogQuery) ([]BanLogEntry, error) {
+	var limit interface{} // NULL — без ограничения
+	if q.Limit > 0 {
+		limit = q.Limit
+	}
+	rows, err := s.db.Query(`SELECT chat_id, user_id, reason, banned_by, created_at FROM ban_log
+		WHERE chat_id = $1 AND ($2::BIGINT = 0 OR user_id = $2) AND ($3::TEXT = '' OR reason = $3)
+		ORDER BY created_at DESC, id DESC LIMIT $4::BIGINT`, q.ChatID, q.UserID, q.Reason, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []BanLogEntry
+	for rows.Next() {
+		var e BanLogEntry
+		if err := rows.Scan(&e.ChatID, &e.UserID, &e.Reason, &e.By, &e.At); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (s *postgresStorage) ForgetUser(userID int64) (int, error) {
+	res, err := s.db.Exec(`DELETE FROM ban_log WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (s *postgresStorage) PruneBanLog(before time.Time) (int, error) {
+	res, err := s.db.Exec(`DELETE FROM ban_log WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func (s *postgresStorage) Close() error {
+	return s.db.Close()
+}
+
+// replaceAll в одной транзакции очищает таблицу и заполняет её снимком состояния.
+func (s *postgresStorage) replaceAll(deleteSQL, insertSQL string, fill func(insert *sql.Stmt) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // после Commit ничего не делает
+
+	if _, err := tx.Exec(deleteSQL); err != nil {
+		return err
+	}
+	insert, err := tx.Prepare(insertSQL)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	if err := fill(insert); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// parseVerifiedKey разбирает ключ "chatID:userID".
+func parseVerifiedKey(key string) (chatID, userID int64, ok bool) {
+	c, u, found := strings.Cut(key, ":")
+	if !found {
+		return 0, 0, false
+	}
+	chatID, err1 := strconv.ParseInt(c, 10, 64)
+	userID, err2 := strconv.ParseInt(u, 10, 64)
+	return chatID, userID, err1 == nil && err2 == nil
+}
@@ -0,0 +1,128 @@
+package hamster
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoadMigrationsEmbedded(t *testing.T) {
+	migrations, err := loadMigrations(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		t.Fatalf("встроенные миграции не читаются: %v", err)
+	}
+	if len(migrations) == 0 || migrations[0].version != 1 {
+		t.Fatalf("ожидалась первая миграция 0001, получили %+v", migrations)
+	}
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version <= migrations[i-1].version {
+			t.Errorf("миграции не отсортированы: %s после %s", migrations[i].name, migrations[i-1].name)
+		}
+	}
+}
+
+func TestLoadMigrationsValidatesNames(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_second.sql": {Data: []byte("SELECT 2")},
+		"m/0001_first.sql":  {Data: []byte("SELECT 1")},
+		"m/README.md":       {Data: []byte("не миграция")},
+	}
+	migrations, err := loadMigrations(fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].name != "0001_first.sql" {
+		t.Errorf("ожидались две миграции по порядку, получили %+v", migrations)
+	}
+
+	fsys["m/0002_dup.sql"] = &fstest.MapFile{Data: []byte("SELECT 3")}
+	if _, err := loadMigrations(fsys, "m"); err == nil {
+		t.Error("повторный номер миграции должен давать ошибку")
+	}
+}
+
+func TestParseVerifiedKey(t *testing.T) {
+	if c, u, ok := parseVerifiedKey(verifiedKey(-1001, 42)); !ok || c != -1001 || u != 42 {
+		t.Errorf("ключ не разобран: %d %d %v", c, u, ok)
+	}
+	if _, _, ok := parseVerifiedKey("мусор"); ok {
+		t.Error("некорректный ключ должен отклоняться")
+	}
+}
+
+// TestPostgresStorageRoundTrip запускается только при заданном TEST_POSTGRES_DSN.
+func TestPostgresStorageRoundTrip(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN не задан")
+	}
+	s, err := openPostgresStorage(dsn, NewLogger())
+	if err != nil {
+		t.Fatalf("openPostgresStorage вернул ошибку: %v", err)
+	}
+	defer s.Close()
+
+	at := time.Now().UTC().Truncate(time.Microsecond)
+	if err := s.SaveSettings(map[int64]*ChatSettings{-100: {Timeout: 30}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveVerified(map[string]time.Time{verifiedKey(-100, 7): at}); err != nil {
+		t.Fatal(err)
+	}
+	series := []StatsBucket{{Time: at.Truncate(time.Hour), Joins: 2, Passed: 1}}
+	if err := s.SaveStats(map[int64]ChatStats{-100: {Joins: 2, Passed: 1, Links: map[string]LinkStats{"Друзья": {Joins: 1, Passed: 1}}, Series: series}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LogBan(BanLogEntry{ChatID: -100, UserID: 8, Reason: BanReasonTimeout, At: at}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.LogBan(BanLogEntry{ChatID: -100, UserID: 9, Reason: BanReasonAdmin, By: 10, At: at.Add(time.Second)}); err != nil {
+		t.Fatal(err)
+	}
+	bans, err := s.BanLog(BanLogQuery{ChatID: -100, Limit: 1})
+	if err != nil || len(bans) != 1 || bans[0].UserID != 9 || bans[0].By != 10 {
+		t.Errorf("журнал банов не читается: %+v %v", bans, err)
+	}
+	if bans, _ := s.BanLog(BanLogQuery{ChatID: -100, UserID: 8}); len(bans) != 1 || bans[0].Reason != BanReasonTimeout {
+		t.Errorf("отбор по пользователю: %+v", bans)
+	}
+	if n, err := s.ForgetUser(8); err != nil || n != 1 {
+		t.Errorf("ForgetUser: %d %v", n, err)
+	}
+	if bans, _ := s.BanLog(BanLogQuery{ChatID: -100}); len(bans) != 1 || bans[0].UserID != 9 {
+		t.Errorf("после ForgetUser остаются чужие баны: %+v", bans)
+	}
+
+	chats, err := s.LoadSettings()
+	if err != nil || chats[-100] == nil || chats[-100].Timeout != 30 {
+		t.Errorf("настройки не восстановлены: %v %v", chats, err)
+	}
+	verified, err := s.LoadVerified()
+	if err != nil || !verified[verifiedKey(-100, 7)].Equal(at) {
+		t.Errorf("верификация не восстановлена: %v %v", verified, err)
+	}
+	stats, err := s.LoadStats()
+	if err != nil || !reflect.DeepEqual(stats[-100], ChatStats{Joins: 2, Passed: 1, Links: map[string]LinkStats{"Друзья": {Joins: 1, Passed: 1}}, Series: series}) {
+		t.Errorf("статистика не восстановлена: %v %v", stats, err)
+	}
+
+	if ok, err := s.TryLock("test:lock", "a", time.Minute); err != nil || !ok {
+		t.Errorf("блокировка не захвачена: %v %v", ok, err)
+	}
+	if ok, _ := s.TryLock("test:lock", "b", time.Minute); ok {
+		t.Error("чужая блокировка не должна захватываться")
+	}
+	if err := s.Unlock("test:lock", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.TryLock("test:lock", "b", time.Millisecond); !ok {
+		t.Error("снятая блокировка должна захватываться")
+	}
+
+	// повторный запуск миграций ничего не ломает
+	if err := s.migrate(); err != nil {
+		t.Errorf("повторные миграции вернули ошибку: %v", err)
+	}
+}
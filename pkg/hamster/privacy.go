@@ -0,0 +1,213 @@
+package hamster
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Приватность: псевдонимы и удаление данных пользователя
+// ==========================
+
+// pseudonymUserID превращает ID пользователя в псевдоним: HMAC-SHA256 от ID
+// с ключом, обрезанный до 62 бит. Псевдонимы отрицательные, чтобы не
+// совпадать с настоящими ID пользователей и оставаться int64 в хранилищах.
+func pseudonymUserID(key []byte, userID int64) int64 {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strconv.FormatInt(userID, 10)))
+	v := binary.BigEndian.Uint64(mac.Sum(nil)) & (1<<62 - 1)
+	return -int64(v) - 1
+}
+
+// storedUserID возвращает ID, под которым пользователь хранится в
+// верификациях и журнале банов: с PrivacyKey — псевдоним, иначе сам ID.
+func (b *Bot) storedUserID(userID int64) int64 {
+	if b.cfg.PrivacyKey == "" || userID <= 0 {
+		return userID
+	}
+	return pseudonymUserID([]byte(b.cfg.PrivacyKey), userID)
+}
+
+// ForgetReport — что удалено о пользователе.
+type ForgetReport struct {
+	Verified int `json:"verified"` // записей о прохождении проверки
+	Bans     int `json:"bans"`     // записей журнала банов
+	Settings int `json:"settings"` // упоминаний в списках настроек чатов
+}
+
+// forgetUser удаляет всё, что бот хранит о пользователе: верификации во всех
+// чатах, журнал банов, упоминания в настройках чатов, кэш сообщений и
+// временные отметки в памяти. Статистика чатов хранит только счётчики и ID
+// пользователей не содержит.
+func (b *Bot) forgetUser(userID int64) (ForgetReport, error) {
+	stored := b.storedUserID(userID)
+	var report ForgetReport
+	if b.verified != nil {
+		report.Verified = b.verified.forgetUser(stored)
+	}
+	bans := b.recentBans.remove(func(e BanLogEntry) bool { return e.UserID == stored })
+	if b.storage != nil {
+		n, err := b.storage.ForgetUser(stored)
+		if err != nil {
+			return report, err
+		}
+		bans = n
+	}
+	report.Bans = bans
+	report.Settings = b.forgetUserSettings(userID)
+
+	b.muMessages.Lock()
+	b.dropUserMessages(userID)
+	b.muMessages.Unlock()
+
+	b.joinLinks.mu.Lock()
+	deleteUserKeys(b.joinLinks.m, userID)
+	b.joinLinks.mu.Unlock()
+	b.firstMessages.mu.Lock()
+	deleteUserKeys(b.firstMessages.m, userID)
+	b.firstMessages.mu.Unlock()
+	b.joins.mu.Lock()
+	deleteUserKeys(b.joins.m, userID)
+	b.joins.mu.Unlock()
+	b.rateMutes.mu.Lock()
+	deleteUserKeys(b.rateMutes.until, userID)
+	b.rateMutes.mu.Unlock()
+	b.muAdmin.Lock()
+	deleteUserKeys(b.adminCache, userID)
+	b.muAdmin.Unlock()
+
+	if b.storage != nil {
+		// не откладываем: после ответа пользователю данных быть не должно
+		b.writeState()
+		if report.Settings > 0 {
+			b.writeSettings()
+		}
+	}
+	b.logger.Info("Данные пользователя %d удалены: верификаций %d, банов %d", userID, report.Verified, report.Bans)
+	return report, nil
+}
+
+// forgetUserSettings убирает пользователя из чёрных списков, модераторов и
+// очередей проверок всех чатов и из банов федераций. Эти списки хранят
+// настоящие ID даже с PrivacyKey: по ним бот банит и снимает ограничения.
+func (b *Bot) forgetUserSettings(userID int64) int {
+	drop := func(ids []int64) ([]int64, int) {
+		n := len(ids)
+		ids = slices.DeleteFunc(ids, func(id int64) bool { return id == userID })
+		return ids, n - len(ids)
+	}
+	removed := 0
+	// в общих настройках — чёрный список владельцев и федерации
+	for _, chatID := range append(b.settings.ChatIDs(), globalSettingsID) {
+		cs := b.chatSettings(chatID)
+		mentioned := slices.Contains(cs.Blacklist, userID) || slices.Contains(cs.Moderators, userID) || slices.Contains(cs.JoinQueue, userID)
+		for _, f := range cs.Federations {
+			mentioned = mentioned || slices.Contains(f.Bans, userID)
+		}
+		if !mentioned {
+			continue
+		}
+		b.settings.Update(chatID, func(c *ChatSettings) {
+			var n int
+			c.Blacklist, n = drop(c.Blacklist)
+			removed += n
+			c.Moderators, n = drop(c.Moderators)
+			removed += n
+			c.JoinQueue, n = drop(c.JoinQueue)
+			removed += n
+			for id, f := range c.Federations {
+				f.Bans, n = drop(f.Bans)
+				removed += n
+				c.Federations[id] = f
+			}
+		})
+	}
+	return removed
+}
+
+// deleteUserKeys удаляет из карты с ключами "chatID:userID" записи пользователя.
+func deleteUserKeys[V any](m map[string]V, userID int64) {
+	suffix := fmt.Sprintf(":%d", userID)
+	for k := range m {
+		if strings.HasSuffix(k, suffix) {
+			delete(m, k)
+		}
+	}
+}
+
+// forgetUser удаляет верификации пользователя во всех чатах и возвращает их число.
+func (v *verifiedUsers) forgetUser(userID int64) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	n := len(v.data)
+	deleteUserKeys(v.data, userID)
+	return n - len(v.data)
+}
+
+func formatForgetReport(r ForgetReport) string {
+	return fmt.Sprintf("записей о проверке: %d, записей о банах: %d, упоминаний в настройках чатов: %d", r.Verified, r.Bans, r.Settings)
+}
+
+// ==========================
+// Команды /forgetme и /purge, REST
+// ==========================
+
+// handleForgetMeCommand удаляет данные написавшего. Работает только в личке,
+// чтобы просьба не оставалась на виду в группе.
+func (b *Bot) handleForgetMeCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if msg.Chat.Type != "private" {
+		b.sendTemporary(chatID, "🔒 Напишите /forgetme мне в личные сообщения", 10*time.Second)
+		return
+	}
+	report, err := b.forgetUser(msg.From.ID)
+	if err != nil {
+		b.logger.Error("Не удалось удалить данные пользователя %d: %v", msg.From.ID, err)
+		b.safeSendSilent(b.ctx, chatID, "❌ Не удалось удалить данные, попробуйте позже")
+		return
+	}
+	b.safeSendSilent(b.ctx, chatID, "🗑 Данные о вас удалены: "+formatForgetReport(report))
+}
+
+// ownerPurge — команда владельца /purge <id пользователя>.
+func (b *Bot) ownerPurge(msg *Message) {
+	chatID := msg.Chat.ID
+	userID, err := strconv.ParseInt(commandArg(msg.Text, 1), 10, 64)
+	if err != nil || userID <= 0 {
+		b.safeSendSilent(b.ctx, chatID, "Использование: /purge <id пользователя>")
+		return
+	}
+	report, err := b.forgetUser(userID)
+	if err != nil {
+		b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("❌ Не удалось удалить данные пользователя %d: %v", userID, err))
+		return
+	}
+	b.logger.Info("Владелец %d удалил данные пользователя %d", msg.From.ID, userID)
+	b.safeSendSilent(b.ctx, chatID, fmt.Sprintf("🗑 Данные пользователя %d удалены: %s", userID, formatForgetReport(report)))
+}
+
+// apiForgetUser удаляет данные пользователя: DELETE /api/users/{user}.
+func (b *Bot) apiForgetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(r.PathValue("user"), 10, 64)
+	if err != nil || userID <= 0 {
+		writeError(w, http.StatusBadRequest, "некорректный ID пользователя")
+		return
+	}
+	report, err := b.forgetUser(userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	b.logger.Info("REST API: данные пользователя %d удалены", userID)
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,174 @@
+package hamster
+
+import (
+	"container/list"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPseudonymUserID(t *testing.T) {
+	a := pseudonymUserID([]byte("ключ"), 42)
+	if a >= 0 || a != pseudonymUserID([]byte("ключ"), 42) {
+		t.Fatalf("псевдоним должен быть отрицательным и постоянным: %d", a)
+	}
+	if a == pseudonymUserID([]byte("другой"), 42) || a == pseudonymUserID([]byte("ключ"), 43) {
+		t.Error("псевдоним зависит от ключа и ID")
+	}
+
+	b := setupBot()
+	if b.storedUserID(42) != 42 {
+		t.Error("без PRIVACY_KEY ID хранится как есть")
+	}
+	b.cfg.PrivacyKey = "ключ"
+	if b.storedUserID(42) != a || b.storedUserID(-100500) != -100500 {
+		t.Error("псевдонимы только для пользователей, ID каналов не меняются")
+	}
+}
+
+func TestPrivacyModeStoresPseudonyms(t *testing.T) {
+	b := setupBot()
+	b.verified = newVerifiedUsers()
+	b.recentBans = newBanHistory(10)
+	b.cfg.PrivacyKey = "ключ"
+
+	b.markVerified(-100, 42)
+	for key := range b.verified.snapshot() {
+		if strings.HasSuffix(key, ":42") {
+			t.Errorf("в верификациях хранится настоящий ID: %s", key)
+		}
+	}
+	if !b.inProbation(-100, 42, time.Hour) {
+		t.Error("проверка по настоящему ID должна находить псевдоним")
+	}
+
+	b.logBan(-100, 7, BanReasonTimeout)
+	if bans := b.recentBans.list(); len(bans) != 1 || bans[0].UserID == 7 {
+		t.Fatalf("в журнале банов хранится псевдоним: %+v", bans)
+	}
+	if got, _ := b.banLog(BanLogQuery{ChatID: -100, UserID: 7}); len(got) != 1 {
+		t.Errorf("поиск в журнале по настоящему ID: %+v", got)
+	}
+}
+
+func TestForgetUser(t *testing.T) {
+	b := setupBot()
+	b.verified = newVerifiedUsers()
+	b.recentBans = newBanHistory(10)
+	b.stats = NewStats()
+	fs := newFileStorage(filepath.Join(t.TempDir(), "settings.json"), NewLogger())
+	fs.banFile = filepath.Join(filepath.Dir(fs.file), "ban_log.jsonl")
+	b.storage = fs
+
+	b.markVerified(-100, 42)
+	b.markVerified(-200, 42)
+	b.markVerified(-100, 43)
+	b.logBan(-100, 42, BanReasonTimeout)
+	b.logBan(-100, 43, BanReasonTimeout)
+	b.muMessages.Lock()
+	b.userMessages[42] = list.New()
+	b.muMessages.Unlock()
+	b.joins.seen(-100, 42, time.Now())
+
+	report, err := b.forgetUser(42)
+	if err != nil || report.Verified != 2 || report.Bans != 1 {
+		t.Fatalf("отчёт об удалении: %+v %v", report, err)
+	}
+	if b.inProbation(-100, 42, time.Hour) || b.inProbation(-200, 42, time.Hour) {
+		t.Error("верификации пользователя должны удаляться во всех чатах")
+	}
+	if !b.inProbation(-100, 43, time.Hour) {
+		t.Error("чужие верификации остаются")
+	}
+	if got, _ := b.banLog(BanLogQuery{ChatID: -100}); len(got) != 1 || got[0].UserID != 43 {
+		t.Errorf("в журнале остаются только чужие баны: %+v", got)
+	}
+	if bans := b.recentBans.list(); len(bans) != 1 || bans[0].UserID != 43 {
+		t.Errorf("баны в памяти: %+v", bans)
+	}
+	if _, ok := b.userMessages[42]; ok {
+		t.Error("кэш сообщений пользователя должен удаляться")
+	}
+	if b.joins.seen(-100, 42, time.Now()) {
+		t.Error("отметка о вступлении должна удаляться")
+	}
+	verified, _ := fs.LoadVerified()
+	if _, ok := verified[verifiedKey(-100, 42)]; ok {
+		t.Error("верификации в хранилище сохраняются сразу")
+	}
+}
+
+func TestForgetUserSettings(t *testing.T) {
+	b := setupBot()
+	b.verified = newVerifiedUsers()
+	b.stats = NewStats()
+	fs := newFileStorage(filepath.Join(t.TempDir(), "settings.json"), NewLogger())
+	b.storage = fs
+	b.updateChatSettings(-100, func(c *ChatSettings) {
+		c.Blacklist = []int64{42, 43}
+		c.Moderators = []int64{42}
+		c.JoinQueue = []int64{42}
+	})
+	fedID := newFederation(b, -100)
+	b.addFederationBan(fedID, 42)
+
+	report, err := b.forgetUser(42)
+	if err != nil || report.Settings != 4 {
+		t.Fatalf("отчёт об удалении: %+v %v", report, err)
+	}
+	cs := b.chatSettings(-100)
+	if len(cs.Blacklist) != 1 || cs.Blacklist[0] != 43 || len(cs.Moderators) != 0 || len(cs.JoinQueue) != 0 {
+		t.Errorf("пользователь должен уйти из списков чата: %+v", cs)
+	}
+	if f, _ := b.federation(fedID); len(f.Bans) != 0 {
+		t.Errorf("пользователь должен уйти из банов федерации: %v", f.Bans)
+	}
+	saved, err := fs.LoadSettings()
+	if err != nil || slices.Contains(saved[-100].Moderators, 42) || slices.Contains(saved[-100].Blacklist, 42) {
+		t.Errorf("настройки в хранилище сохраняются сразу: %+v %v", saved[-100], err)
+	}
+	if info, err := os.Stat(fs.file); err != nil || info.Mode().Perm() != stateFilePerm {
+		t.Errorf("файл настроек с ID пользователей закрыт от чужих: %v %v", info.Mode(), err)
+	}
+}
+
+func TestForgetMeCommand(t *testing.T) {
+	b := setupBot()
+	b.verified = newVerifiedUsers()
+	b.markVerified(-100, 42)
+	var sent []string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { sent = append(sent, text); return 1 }
+
+	b.handleForgetMeCommand(&Message{Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 42}, Text: "/forgetme"})
+	if !b.inProbation(-100, 42, time.Hour) {
+		t.Fatal("в группе команда только подсказывает написать в личку")
+	}
+	b.handleForgetMeCommand(&Message{Chat: Chat{ID: 42, Type: "private"}, From: &User{ID: 42}, Text: "/forgetme"})
+	if b.inProbation(-100, 42, time.Hour) {
+		t.Error("в личке данные удаляются")
+	}
+	if len(sent) == 0 || !strings.Contains(sent[len(sent)-1], "записей о проверке: 1") {
+		t.Errorf("ответ об удалении: %v", sent)
+	}
+}
+
+func TestAdminAPIForgetUser(t *testing.T) {
+	b := setupAdminBot()
+	b.verified = newVerifiedUsers()
+	b.markVerified(-100, 42)
+	h := b.AdminHandler()
+
+	if rec := adminRequest(t, h, "DELETE", "/api/users/abc", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("некорректный ID: %d", rec.Code)
+	}
+	rec := adminRequest(t, h, "DELETE", "/api/users/42", "")
+	var report ForgetReport
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &report) != nil || report.Verified != 1 {
+		t.Errorf("удаление через API: %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,275 @@
+package hamster
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// ==========================
+// Недавно верифицированные пользователи
+// ==========================
+
+// verifiedUsers хранит время прохождения проверки по ключу "chatID:userID".
+type verifiedUsers struct {
+	mu   sync.Mutex
+	data map[string]time.Time
+}
+
+func newVerifiedUsers() *verifiedUsers {
+	return &verifiedUsers{data: make(map[string]time.Time)}
+}
+
+func verifiedKey(chatID, userID int64) string {
+	return fmt.Sprintf("%d:%d", chatID, userID)
+}
+
+// mark запоминает момент успешной верификации.
+func (v *verifiedUsers) mark(chatID, userID int64, at time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.data[verifiedKey(chatID, userID)] = at
+}
+
+// since возвращает время верификации, если пользователь проходил проверку.
+func (v *verifiedUsers) since(chatID, userID int64) (time.Time, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	at, ok := v.data[verifiedKey(chatID, userID)]
+	return at, ok
+}
+
+// forgetChat удаляет все записи чата.
+func (v *verifiedUsers) forgetChat(chatID int64) {
+	prefix := fmt.Sprintf("%d:", chatID)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for k := range v.data {
+		if strings.HasPrefix(k, prefix) {
+			delete(v.data, k)
+		}
+	}
+}
+
+// moveChat переносит записи чата на новый ID.
+func (v *verifiedUsers) moveChat(from, to int64) {
+	prefix := fmt.Sprintf("%d:", from)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for k, at := range v.data {
+		if strings.HasPrefix(k, prefix) {
+			delete(v.data, k)
+			v.data[fmt.Sprintf("%d:%s", to, strings.TrimPrefix(k, prefix))] = at
+		}
+	}
+}
+
+// prune удаляет записи старше maxAge.
+func (v *verifiedUsers) prune(maxAge time.Duration) {
+	cutoff := time.Now().Add(-maxAge)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for k, at := range v.data {
+		if at.Before(cutoff) {
+			delete(v.data, k)
+		}
+	}
+}
+
+// snapshot возвращает копию всех записей.
+func (v *verifiedUsers) snapshot() map[string]time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	out := make(map[string]time.Time, len(v.data))
+	for k, at := range v.data {
+		out[k] = at
+	}
+	return out
+}
+
+// replace заменяет все записи (загрузка из хранилища).
+func (v *verifiedUsers) replace(data map[string]time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.data = data
+}
+
+// markVerified отмечает, что участник прошёл проверку или был пропущен без неё.
+func (b *Bot) markVerified(chatID, userID int64) {
+	if b.verified != nil {
+		b.verified.mark(chatID, b.storedUserID(userID), time.Now())
+	}
+	b.watchFirstMessages(chatID, userID)
+	b.fireHook(hookVerified, MemberEvent{ChatID: chatID, UserID: userID})
+}
+
+// inProbation проверяет, что пользователь верифицирован не раньше чем period назад.
+func (b *Bot) inProbation(chatID, userID int64, period time.Duration) bool {
+	if period <= 0 || b.verified == nil {
+		return false
+	}
+	at, ok := b.verified.since(chatID, b.storedUserID(userID))
+	return ok && time.Since(at) < period
+}
+
+// CleanupVerified забывает пользователей, у которых закончился испытательный срок.
+func (b *Bot) CleanupVerified() {
+	if b.verified == nil {
+		return
+	}
+	b.verified.prune(b.verifiedRetention())
+}
+
+// verifiedRetention — сколько помнить время верификации: самый длинный из
+// фильтров новичков, включая собственные сроки фильтра пересылок в чатах.
+func (b *Bot) verifiedRetention() time.Duration {
+	d := b.cfg.verifiedRetention()
+	if b.settings == nil {
+		return d
+	}
+	for _, cs := range b.settings.Snapshot() {
+		d = max(d, cs.ForwardFilterPeriod(0))
+	}
+	return d
+}
+
+// ==========================
+// Фильтры на испытательном сроке
+// ==========================
+
+var inviteLinkRe = regexp.MustCompile(`(?i)(https?://)?(t\.me|telegram\.me|telegram\.dog)/\S+`)
+
+// applyProbation удаляет сообщение новичка, если оно нарушает ограничения испытательного срока
+// (пересылка, ссылки, упоминания каналов). Возвращает true, если сообщение удалено.
+func (b *Bot) applyProbation(msg *Message) bool {
+	if msg.From == nil {
+		return false
+	}
+	reason := b.probationViolation(msg)
+	if reason == "" {
+		return false
+	}
+	b.logger.Info("Удаляем сообщение новичка %d в чате %d: %s", msg.From.ID, msg.Chat.ID, reason)
+	b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+	return true
+}
+
+// probationViolation возвращает причину удаления или пустую строку.
+func (b *Bot) probationViolation(msg *Message) string {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	if msg.IsForwarded() && b.inProbation(chatID, userID, b.chatSettings(chatID).ForwardFilterPeriod(b.cfg.ForwardFilterPeriod)) {
+		return "пересылка"
+	}
+	if b.inProbation(chatID, userID, b.cfg.ProbationPeriod) && b.hasForbiddenLinks(msg) {
+		return "ссылка"
+	}
+	return ""
+}
+
+// hasForbiddenLinks ищет URL, t.me-инвайты и @упоминания каналов в тексте и подписи.
+func (b *Bot) hasForbiddenLinks(msg *Message) bool {
+	check := func(text string, entities []MessageEntity) bool {
+		for _, e := range entities {
+			switch e.Type {
+			case "url", "text_link":
+				return true
+			case "mention":
+				if b.isChannelMention(entityText(text, e)) {
+					return true
+				}
+			}
+		}
+		return inviteLinkRe.MatchString(text)
+	}
+	return check(msg.Text, msg.Entities) || check(msg.Caption, msg.CaptionEntities)
+}
+
+// isChannelMention проверяет через getChat, что @username принадлежит каналу.
+func (b *Bot) isChannelMention(mention string) bool {
+	username := strings.TrimPrefix(mention, "@")
+	if username == "" {
+		return false
+	}
+	return b.safeGetChatType(b.ctx, "@"+username) == "channel"
+}
+
+// entityText вырезает текст сущности. Смещения Telegram считаются в UTF-16.
+func entityText(text string, e MessageEntity) string {
+	u := utf16.Encode([]rune(text))
+	if e.Offset < 0 || e.Length <= 0 || e.Offset+e.Length > len(u) {
+		return ""
+	}
+	return string(utf16.Decode(u[e.Offset : e.Offset+e.Length]))
+}
+
+// ==========================
+// Команда /forwards
+// ==========================
+
+const (
+	// ForwardFilterOff в ChatSettings.ForwardFilter выключает фильтр пересылок
+	// в чате, даже если задан FORWARD_FILTER_MINUTES.
+	ForwardFilterOff = -1
+	maxForwardFilter = 7 * 24 * 60 // минут
+)
+
+// ForwardFilterPeriod возвращает, сколько после проверки удалять пересланные
+// сообщения: срок чата или def (FORWARD_FILTER_MINUTES); 0 — фильтр выключен.
+func (c ChatSettings) ForwardFilterPeriod(def time.Duration) time.Duration {
+	switch {
+	case c.ForwardFilter == ForwardFilterOff:
+		return 0
+	case c.ForwardFilter <= 0:
+		return def
+	}
+	return time.Duration(c.ForwardFilter) * time.Minute
+}
+
+func (b *Bot) handleForwardsCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять фильтр пересылок", 5*time.Second)
+		return
+	}
+
+	usage := fmt.Sprintf("⚙️ Использование: /forwards <минут, 1–%d> | off | default", maxForwardFilter)
+	arg := strings.ToLower(commandArg(msg.Text, 1))
+	var value int
+	switch arg {
+	case "":
+		b.sendTemporary(chatID, b.formatForwardFilter(b.chatSettings(chatID))+"\n"+usage, 10*time.Second)
+		return
+	case "off":
+		value = ForwardFilterOff
+	case "default":
+		value = 0
+	default:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > maxForwardFilter {
+			b.sendTemporary(chatID, usage, 10*time.Second)
+			return
+		}
+		value = n
+	}
+	b.updateChatSettings(chatID, func(c *ChatSettings) { c.ForwardFilter = value })
+	b.sendTemporary(chatID, "✅ "+b.formatForwardFilter(b.chatSettings(chatID)), 5*time.Second)
+}
+
+func (b *Bot) formatForwardFilter(cs ChatSettings) string {
+	d := cs.ForwardFilterPeriod(b.cfg.ForwardFilterPeriod)
+	s := "↪️ Пересланные сообщения новичков не удаляются"
+	if d > 0 {
+		s = fmt.Sprintf("↪️ Пересланные сообщения удаляются %d мин. после проверки", int(d.Minutes()))
+	}
+	if cs.ForwardFilter == 0 {
+		s += " (по умолчанию бота)"
+	}
+	return s
+}
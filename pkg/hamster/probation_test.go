@@ -0,0 +1,193 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+// -------------------------
+// entityText с UTF-16 смещениями
+// -------------------------
+func TestEntityTextUTF16(t *testing.T) {
+	text := "🐹 привет @spam_channel"
+	// 🐹 занимает две UTF-16 единицы
+	e := MessageEntity{Type: "mention", Offset: 10, Length: 13}
+	if got := entityText(text, e); got != "@spam_channel" {
+		t.Errorf("ожидалось @spam_channel, получили %q", got)
+	}
+	if got := entityText(text, MessageEntity{Offset: 100, Length: 5}); got != "" {
+		t.Errorf("ожидалась пустая строка для выхода за границы, получили %q", got)
+	}
+}
+
+// -------------------------
+// hasForbiddenLinks
+// -------------------------
+func TestHasForbiddenLinks(t *testing.T) {
+	b := setupBot()
+	fakeOf(b).getChatType = func(chatRef string) string {
+		if chatRef == "@spam_channel" {
+			return "channel"
+		}
+		return "private"
+	}
+
+	tests := []struct {
+		name string
+		msg  Message
+		want bool
+	}{
+		{"обычный текст", Message{Text: "всем привет"}, false},
+		{"url сущность", Message{Text: "example.com", Entities: []MessageEntity{{Type: "url", Offset: 0, Length: 11}}}, true},
+		{"инвайт без сущности", Message{Text: "заходи t.me/+abcdef"}, true},
+		{"инвайт в подписи", Message{Caption: "https://telegram.me/joinchat/xyz"}, true},
+		{"упоминание канала", Message{Text: "@spam_channel", Entities: []MessageEntity{{Type: "mention", Offset: 0, Length: 13}}}, true},
+		{"упоминание человека", Message{Text: "@friend", Entities: []MessageEntity{{Type: "mention", Offset: 0, Length: 7}}}, false},
+	}
+	for _, tt := range tests {
+		if got := b.hasForbiddenLinks(&tt.msg); got != tt.want {
+			t.Errorf("%s: ожидалось %v, получили %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+// -------------------------
+// applyProbation удаляет ссылки только у новичков
+// -------------------------
+func TestApplyProbation(t *testing.T) {
+	b := setupBot()
+	b.cfg.ProbationPeriod = 10 * time.Minute
+	b.verified = newVerifiedUsers()
+
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = append(deleted, msgID) }
+
+	link := Message{MessageID: 5, Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "t.me/spam"}
+
+	// пользователь не верифицирован ботом — не трогаем
+	if b.applyProbation(&link) {
+		t.Error("сообщение старого участника не должно удаляться")
+	}
+
+	b.verified.mark(1, 42, time.Now())
+	if !b.applyProbation(&link) {
+		t.Error("ссылка от новичка должна удаляться")
+	}
+	if len(deleted) != 1 || deleted[0] != 5 {
+		t.Errorf("ожидалось удаление сообщения 5, получили %v", deleted)
+	}
+
+	// испытательный срок истёк
+	b.verified.mark(1, 42, time.Now().Add(-11*time.Minute))
+	if b.applyProbation(&link) {
+		t.Error("после испытательного срока ссылки разрешены")
+	}
+}
+
+// -------------------------
+// verifiedUsers.prune
+// -------------------------
+func TestVerifiedUsersPrune(t *testing.T) {
+	v := newVerifiedUsers()
+	v.mark(1, 1, time.Now().Add(-2*time.Hour))
+	v.mark(1, 2, time.Now())
+	v.prune(time.Hour)
+
+	if _, ok := v.since(1, 1); ok {
+		t.Error("старая запись должна быть удалена")
+	}
+	if _, ok := v.since(1, 2); !ok {
+		t.Error("свежая запись должна остаться")
+	}
+}
+
+// -------------------------
+// Фильтр пересланных сообщений
+// -------------------------
+func TestApplyProbationForwarded(t *testing.T) {
+	b := setupBot()
+	b.cfg.ForwardFilterPeriod = 5 * time.Minute
+	b.verified = newVerifiedUsers()
+	b.verified.mark(1, 42, time.Now())
+
+	deleted := 0
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted++ }
+
+	plain := Message{MessageID: 1, Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "привет"}
+	if b.applyProbation(&plain) {
+		t.Error("обычное сообщение не должно удаляться")
+	}
+
+	fwd := Message{
+		MessageID:     2,
+		Chat:          Chat{ID: 1},
+		From:          &User{ID: 42},
+		Text:          "реклама",
+		ForwardOrigin: &MessageOrigin{Type: "channel", Chat: &Chat{ID: -100500, Type: "channel"}},
+	}
+	if !b.applyProbation(&fwd) {
+		t.Error("пересланное сообщение новичка должно удаляться")
+	}
+
+	// другой чат — пользователь там не новичок
+	fwd.Chat.ID = 2
+	if b.applyProbation(&fwd) {
+		t.Error("фильтр должен учитывать чат верификации")
+	}
+	if deleted != 1 {
+		t.Errorf("ожидалось одно удаление, получили %d", deleted)
+	}
+}
+
+func TestForwardFilterChatOverride(t *testing.T) {
+	b := setupBot()
+	b.cfg.ForwardFilterPeriod = 5 * time.Minute
+	b.verified = newVerifiedUsers()
+	for _, chatID := range []int64{1, 2, 3} {
+		b.verified.mark(chatID, 42, time.Now().Add(-10*time.Minute))
+	}
+	b.adminCache = map[string]adminCacheEntry{
+		"1:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+		"3:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	b.handleForwardsCommand(&Message{Chat: Chat{ID: 1, Type: "supergroup"}, From: &User{ID: 10}, Text: "/forwards 60"})
+	b.handleForwardsCommand(&Message{Chat: Chat{ID: 3, Type: "supergroup"}, From: &User{ID: 10}, Text: "/forwards off"})
+	b.handleForwardsCommand(&Message{Chat: Chat{ID: 2, Type: "supergroup"}, From: &User{ID: 11}, Text: "/forwards 60"})
+	if b.chatSettings(1).ForwardFilter != 60 || b.chatSettings(3).ForwardFilter != ForwardFilterOff || b.chatSettings(2).ForwardFilter != 0 {
+		t.Fatalf("настройки: %+v %+v %+v", b.chatSettings(1), b.chatSettings(2), b.chatSettings(3))
+	}
+
+	fwd := func(chatID int64) *Message {
+		return &Message{Chat: Chat{ID: chatID}, From: &User{ID: 42}, ForwardDate: 1700000000}
+	}
+	if b.probationViolation(fwd(1)) != "пересылка" {
+		t.Error("срок чата длиннее общего: пересылка удаляется")
+	}
+	if b.probationViolation(fwd(2)) != "" {
+		t.Error("без своего срока действует FORWARD_FILTER_MINUTES")
+	}
+	b.verified.mark(3, 42, time.Now())
+	if b.probationViolation(fwd(3)) != "" {
+		t.Error("выключенный в чате фильтр не удаляет пересылки")
+	}
+	if got := b.verifiedRetention(); got != time.Hour {
+		t.Errorf("верификации хранятся по самому длинному сроку чатов: %v", got)
+	}
+
+	b.handleForwardsCommand(&Message{Chat: Chat{ID: 1, Type: "supergroup"}, From: &User{ID: 10}, Text: "/forwards default"})
+	if b.chatSettings(1).ForwardFilter != 0 {
+		t.Error("default возвращает общий срок")
+	}
+}
+
+func TestMessageIsForwarded(t *testing.T) {
+	if (&Message{}).IsForwarded() {
+		t.Error("пустое сообщение не пересланное")
+	}
+	if !(&Message{ForwardFrom: &User{ID: 1}}).IsForwarded() {
+		t.Error("forward_from означает пересылку")
+	}
+	if !(&Message{ForwardDate: 1700000000}).IsForwarded() {
+		t.Error("forward_date означает пересылку")
+	}
+}
@@ -0,0 +1,118 @@
+package hamster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Стили прогрессбара
+// ==========================
+
+// ProgressStyle рисует строку обратного отсчёта проверки. Стили
+// регистрируются так же, как типы проверки, и выбираются в чате /progress.
+type ProgressStyle interface {
+	// Render вызывается раз в секунду; step — номер тика с начала отсчёта.
+	Render(timeout, remaining, step int) string
+}
+
+// ProgressStyleFunc позволяет зарегистрировать функцию как стиль.
+type ProgressStyleFunc func(timeout, remaining, step int) string
+
+func (f ProgressStyleFunc) Render(timeout, remaining, step int) string {
+	return f(timeout, remaining, step)
+}
+
+// Встроенные стили прогрессбара.
+const (
+	ProgressBlocks  = "blocks"  // шкала из квадратов и часы (по умолчанию)
+	ProgressPercent = "percent" // оставшееся время в процентах
+	ProgressDots    = "dots"    // тающая строка точек
+	ProgressClock   = "clock"   // только часы
+)
+
+var progressStyles = newRegistry[ProgressStyle]("стиль прогрессбара")
+
+// RegisterProgressStyle регистрирует стиль прогрессбара под именем name.
+func RegisterProgressStyle(name string, s ProgressStyle) {
+	progressStyles.register(name, s)
+}
+
+// ProgressStyleNames возвращает имена зарегистрированных стилей прогрессбара.
+func ProgressStyleNames() []string {
+	return progressStyles.names()
+}
+
+func init() {
+	RegisterProgressStyle(ProgressBlocks, ProgressStyleFunc(func(timeout, remaining, step int) string {
+		return fmt.Sprintf("⏳ Осталось: %s %s", progressBar(timeout, remaining), nextClockEmoji(step))
+	}))
+	RegisterProgressStyle(ProgressPercent, ProgressStyleFunc(func(timeout, remaining, step int) string {
+		percent := 0
+		if timeout > 0 {
+			percent = remaining * 100 / timeout
+		}
+		return fmt.Sprintf("⏳ Осталось: %d%%", percent)
+	}))
+	RegisterProgressStyle(ProgressDots, ProgressStyleFunc(func(timeout, remaining, step int) string {
+		const n = 10
+		dots := n
+		if timeout > 0 {
+			dots = (remaining*n + timeout - 1) / timeout // последняя точка гаснет с последней секундой
+		}
+		return "⏳ " + strings.Repeat("•", dots)
+	}))
+	RegisterProgressStyle(ProgressClock, ProgressStyleFunc(func(timeout, remaining, step int) string {
+		return nextClockEmoji(step)
+	}))
+}
+
+// progressStyleFor возвращает стиль чата; неизвестный заменяется шкалой.
+func progressStyleFor(cs ChatSettings) ProgressStyle {
+	if s, ok := progressStyles.lookup(cs.ProgressStyle); ok {
+		return s
+	}
+	s, _ := progressStyles.lookup(ProgressBlocks)
+	return s
+}
+
+// ==========================
+// Команда /progress
+// ==========================
+
+func (b *Bot) handleProgressCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять оформление проверки", 5*time.Second)
+		return
+	}
+	usage := "⚙️ /progress " + strings.Join(ProgressStyleNames(), "|")
+
+	name := strings.ToLower(commandArg(msg.Text, 1))
+	if name == "" {
+		cs := b.chatSettings(chatID)
+		current := cs.ProgressStyle
+		if current == "" {
+			current = ProgressBlocks
+		}
+		b.sendTemporary(chatID, fmt.Sprintf("⏳ Стиль прогрессбара: %s\n%s\n%s", current,
+			progressStyleFor(cs).Render(60, 24, 7), usage), 10*time.Second)
+		return
+	}
+	style, ok := progressStyles.lookup(name)
+	if !ok {
+		b.sendTemporary(chatID, "❌ Нет такого стиля. "+usage, 5*time.Second)
+		return
+	}
+	b.updateChatSettings(chatID, func(c *ChatSettings) {
+		c.ProgressStyle = name
+		if name == ProgressBlocks {
+			c.ProgressStyle = ""
+		}
+	})
+	b.sendTemporary(chatID, "✅ Стиль прогрессбара: "+name+"\n"+style.Render(60, 24, 7), 5*time.Second)
+}
@@ -0,0 +1,59 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressStyles(t *testing.T) {
+	cases := []struct {
+		style string
+		want  string
+	}{
+		{ProgressPercent, "⏳ Осталось: 40%"},
+		{ProgressDots, "⏳ ••••"},
+		{ProgressClock, nextClockEmoji(3)},
+		{ProgressBlocks, "⏳ Осталось: " + progressBar(30, 12) + " " + nextClockEmoji(3)},
+		{"", "⏳ Осталось: " + progressBar(30, 12) + " " + nextClockEmoji(3)},
+		{"нет-такого", "⏳ Осталось: " + progressBar(30, 12) + " " + nextClockEmoji(3)},
+	}
+	for _, c := range cases {
+		if got := countdownText(ChatSettings{ProgressStyle: c.style}, 30, 12, 3); got != c.want {
+			t.Errorf("%q: %q, want %q", c.style, got, c.want)
+		}
+	}
+	if got := countdownText(ChatSettings{ProgressStyle: ProgressDots}, 30, 1, 29); got != "⏳ •" {
+		t.Errorf("последняя точка гаснет с последней секундой: %q", got)
+	}
+}
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("повторная регистрация стиля должна паниковать")
+		}
+	}()
+	RegisterProgressStyle(ProgressClock, ProgressStyleFunc(func(int, int, int) string { return "" }))
+}
+
+func TestProgressCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	run := func(text string) {
+		b.handleProgressCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: text})
+	}
+	run("/progress dots")
+	if got := b.chatSettings(-100).ProgressStyle; got != ProgressDots {
+		t.Fatalf("стиль = %q", got)
+	}
+	run("/progress rainbow")
+	if got := b.chatSettings(-100).ProgressStyle; got != ProgressDots {
+		t.Errorf("неизвестный стиль не должен выбираться: %q", got)
+	}
+	run("/progress blocks")
+	if got := b.chatSettings(-100).ProgressStyle; got != "" {
+		t.Errorf("стиль по умолчанию хранится пустой строкой: %q", got)
+	}
+}
@@ -0,0 +1,73 @@
+package hamster
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ==========================
+// Метрики для Prometheus
+// ==========================
+
+// chatCounters — счётчики чата в /metrics.
+var chatCounters = []struct {
+	name, help string
+	value      func(ChatStats) int64
+}{
+	{"tg_hamster_joins_total", "Новых участников (без ботов).", func(c ChatStats) int64 { return c.Joins }},
+	{"tg_hamster_passed_total", "Прошли проверку.", func(c ChatStats) int64 { return c.Passed }},
+	{"tg_hamster_failed_total", "Не прошли проверку.", func(c ChatStats) int64 { return c.Failed }},
+	{"tg_hamster_banned_total", "Забанены без проверки.", func(c ChatStats) int64 { return c.Banned }},
+	{"tg_hamster_too_fast_total", "Провалены из-за слишком быстрого нажатия.", func(c ChatStats) int64 { return c.TooFast }},
+}
+
+// serveMetrics отдаёт счётчики чатов и гистограмму задержек нажатия в
+// текстовом формате Prometheus. Общие значения — sum without (chat).
+func (b *Bot) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	stats := b.stats.Snapshot()
+	ids := make([]int64, 0, len(stats))
+	for id := range stats {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	for _, m := range chatCounters {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s counter\n", m.name, m.help, m.name)
+		for _, id := range ids {
+			fmt.Fprintf(out, "%s{chat=\"%d\"} %d\n", m.name, id, m.value(stats[id]))
+		}
+	}
+
+	fmt.Fprintf(out, "# HELP tg_hamster_callbacks_total Нажатия на кнопки с запуска по исходам.\n# TYPE tg_hamster_callbacks_total counter\n")
+	for _, res := range []string{callbackOwn, callbackForeign, callbackExpired, callbackTooFast, callbackOther} {
+		fmt.Fprintf(out, "tg_hamster_callbacks_total{result=\"%s\"} %d\n", res, b.callbackCounts.get(res))
+	}
+	fmt.Fprintf(out, "# HELP tg_hamster_foreign_press_actions_total Предупреждения и муты за нажатие чужих кнопок с запуска.\n# TYPE tg_hamster_foreign_press_actions_total counter\n")
+	for _, action := range []string{callbackWarned, callbackMuted} {
+		fmt.Fprintf(out, "tg_hamster_foreign_press_actions_total{action=\"%s\"} %d\n", action, b.callbackCounts.get(action))
+	}
+
+	const hist = "tg_hamster_click_latency_seconds"
+	fmt.Fprintf(out, "# HELP %s Время от приветствия до нажатия своей кнопки (без слишком быстрых).\n# TYPE %s histogram\n", hist, hist)
+	for _, id := range ids {
+		st := stats[id]
+		var cum int64
+		for i, n := range st.ClickHist {
+			cum += n
+			le := "+Inf"
+			if i < len(clickBuckets) {
+				le = strconv.FormatFloat(clickBuckets[i].Seconds(), 'g', -1, 64)
+			}
+			fmt.Fprintf(out, "%s_bucket{chat=\"%d\",le=\"%s\"} %d\n", hist, id, le, cum)
+		}
+		fmt.Fprintf(out, "%s_sum{chat=\"%d\"} %s\n", hist, id, strconv.FormatFloat(float64(st.ClickHistMsSum)/1000, 'g', -1, 64))
+		fmt.Fprintf(out, "%s_count{chat=\"%d\"} %d\n", hist, id, cum)
+	}
+}
@@ -0,0 +1,48 @@
+package hamster
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeMetrics(t *testing.T) {
+	b := setupAdminBot()
+	b.stats.add(-100, func(c *ChatStats) {
+		c.Joins = 3
+		c.Passed = 2
+		c.addClick(1500 * time.Millisecond)
+		c.addClick(4 * time.Second)
+		c.addClick(time.Hour)
+	})
+	h := b.AdminHandler()
+
+	rec := adminRequest(t, h, "GET", "/metrics", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("код %d, тип %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE tg_hamster_joins_total counter",
+		`tg_hamster_joins_total{chat="-100"} 3`,
+		`tg_hamster_passed_total{chat="-100"} 2`,
+		"# TYPE tg_hamster_click_latency_seconds histogram",
+		`tg_hamster_click_latency_seconds_bucket{chat="-100",le="1"} 0`,
+		`tg_hamster_click_latency_seconds_bucket{chat="-100",le="2"} 1`,
+		`tg_hamster_click_latency_seconds_bucket{chat="-100",le="5"} 2`,
+		`tg_hamster_click_latency_seconds_bucket{chat="-100",le="600"} 2`,
+		`tg_hamster_click_latency_seconds_bucket{chat="-100",le="+Inf"} 3`,
+		`tg_hamster_click_latency_seconds_sum{chat="-100"} 3605.5`,
+		`tg_hamster_click_latency_seconds_count{chat="-100"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("в /metrics нет %q:\n%s", want, body)
+		}
+	}
+
+	b.cfg.AdminAPIToken = "other"
+	if rec := adminRequest(t, b.AdminHandler(), "GET", "/metrics", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("без верного токена /metrics закрыт: код %d", rec.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package hamster
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Защита от наплыва вступлений
+// ==========================
+
+// raidState — вступления одного чата за последнее окно.
+type raidState struct {
+	joins    []time.Time
+	lastHigh time.Time // когда поток последний раз был выше порога
+	active   bool
+	since    time.Time // когда начался текущий наплыв
+	alerted  bool      // письмо о затяжном наплыве отправлено
+}
+
+// raidDetector отслеживает частоту вступлений по чатам. Режим наплыва
+// включается, когда за окно вступили limit человек, и держится ещё одно
+// окно после последнего превышения, чтобы не переключаться на каждой волне.
+type raidDetector struct {
+	mu    sync.Mutex
+	chats map[int64]*raidState
+}
+
+func newRaidDetector() *raidDetector {
+	return &raidDetector{chats: make(map[int64]*raidState)}
+}
+
+// hit учитывает вступление и возвращает, идёт ли наплыв, и изменился ли режим.
+func (d *raidDetector) hit(chatID int64, limit int, window time.Duration, now time.Time) (active, changed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, s := range d.chats {
+		if id != chatID && now.Sub(s.lastHigh) > window && len(s.joins) > 0 && now.Sub(s.joins[len(s.joins)-1]) > window {
+			delete(d.chats, id) // давно без вступлений
+		}
+	}
+	s, ok := d.chats[chatID]
+	if !ok {
+		s = &raidState{}
+		d.chats[chatID] = s
+	}
+	kept := s.joins[:0]
+	for _, t := range s.joins {
+		if now.Sub(t) <= window {
+			kept = append(kept, t)
+		}
+	}
+	s.joins = append(kept, now)
+	if len(s.joins) >= limit {
+		s.lastHigh = now
+	}
+	was := s.active
+	s.active = !s.lastHigh.IsZero() && now.Sub(s.lastHigh) <= window
+	if s.active && !was {
+		s.since, s.alerted = now, false
+	}
+	return s.active, s.active != was
+}
+
+// raidJoin учитывает вступление в чат и сообщает, действует ли режим наплыва.
+// При смене режима администраторы чата получают временное уведомление.
+func (b *Bot) raidJoin(chatID int64) bool {
+	if b.cfg.RaidJoins <= 0 || b.raids == nil {
+		return false
+	}
+	now := time.Now()
+	active, changed := b.raids.hit(chatID, b.cfg.RaidJoins, b.cfg.RaidWindow, now)
+	if changed && active {
+		b.logger.Warn("Наплыв вступлений в чате %d — проверка усилена", chatID)
+		b.sendTemporary(chatID, "🚨 Много вступлений подряд — проверка новых участников временно усилена", time.Minute)
+		b.raidStarted(chatID)
+		b.notify(NotifyRaid, fmt.Sprintf("🚨 Наплыв вступлений в чате %d: %d и больше за %s — проверка усилена", chatID, b.cfg.RaidJoins, b.cfg.RaidWindow))
+	} else if changed {
+		b.logger.Info("Наплыв вступлений в чате %d закончился", chatID)
+		b.notify(NotifyRaid, fmt.Sprintf("✅ Наплыв вступлений в чате %d закончился", chatID))
+	}
+	if active && b.raids.sustained(chatID, sustainedRaid, now) {
+		b.alert(fmt.Sprintf("%s:%d", alertRaid, chatID), "затяжной наплыв",
+			fmt.Sprintf("В чате %d наплыв вступлений длится больше %v.", chatID, sustainedRaid))
+	}
+	return active
+}
+
+// raidChallenge усиливает проверку на время наплыва: простая кнопка заменяется
+// на RaidCaptcha, таймаут сокращается до RaidTimeout. Более сложные типы,
+// выбранные администраторами, не меняются.
+func (b *Bot) raidChallenge(cs ChatSettings, timeout int) (ChatSettings, int) {
+	if cs.Captcha() == CaptchaButton && b.cfg.RaidCaptcha != "" {
+		cs.CaptchaType = b.cfg.RaidCaptcha
+	}
+	if b.cfg.RaidTimeout > 0 && timeout > b.cfg.RaidTimeout {
+		timeout = b.cfg.RaidTimeout
+	}
+	return cs, timeout
+}
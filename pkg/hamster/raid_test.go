@@ -0,0 +1,94 @@
+package hamster
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRaidDetector(t *testing.T) {
+	d := newRaidDetector()
+	now := time.Now()
+	window := time.Minute
+
+	if active, _ := d.hit(1, 3, window, now); active {
+		t.Fatal("одно вступление — не наплыв")
+	}
+	d.hit(1, 3, window, now.Add(time.Second))
+	active, changed := d.hit(1, 3, window, now.Add(2*time.Second))
+	if !active || !changed {
+		t.Fatalf("третье вступление за окно должно включить наплыв: active=%v changed=%v", active, changed)
+	}
+	if active, _ := d.hit(2, 3, window, now.Add(2*time.Second)); active {
+		t.Error("наплыв в одном чате не должен влиять на другой")
+	}
+
+	// в пределах окна после последнего превышения режим держится
+	if active, changed := d.hit(1, 3, window, now.Add(50*time.Second)); !active || changed {
+		t.Errorf("режим должен держаться окно после превышения: active=%v changed=%v", active, changed)
+	}
+	// волна прошла
+	if active, changed := d.hit(1, 3, window, now.Add(3*time.Minute)); active || !changed {
+		t.Errorf("режим должен выключиться: active=%v changed=%v", active, changed)
+	}
+}
+
+func TestRaidChallenge(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+
+	cs, timeout := b.raidChallenge(ChatSettings{}, 90)
+	if cs.Captcha() != CaptchaMath || timeout != 30 {
+		t.Errorf("кнопка должна смениться на %s с таймаутом 30: %s, %d", CaptchaMath, cs.Captcha(), timeout)
+	}
+	cs, timeout = b.raidChallenge(ChatSettings{CaptchaType: CaptchaSequence}, 10)
+	if cs.Captcha() != CaptchaSequence || timeout != 10 {
+		t.Errorf("выбранный админами тип и короткий таймаут не меняются: %s, %d", cs.Captcha(), timeout)
+	}
+}
+
+func TestHandleJoinMessageRaid(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.cfg.RaidJoins = 2
+	b.raids = newRaidDetector()
+	// прогрессбары отправляются из горутин проверок
+	var mu sync.Mutex
+	var greetings []string
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		greetings = append(greetings, text)
+		return int64(len(greetings))
+	}
+	var notice string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.Contains(text, "Много вступлений") {
+			notice = text
+		}
+		return 100
+	}
+
+	b.handleJoinMessage(&Message{
+		Chat:           Chat{ID: 1},
+		NewChatMembers: []*User{{ID: 11, FirstName: "А"}, {ID: 12, FirstName: "Б"}},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(greetings) != 2 {
+		t.Fatalf("ожидали 2 приветствия, получили %d", len(greetings))
+	}
+	if strings.Contains(greetings[0], "Сколько будет") {
+		t.Error("до наплыва проверка не должна усиливаться")
+	}
+	if !strings.Contains(greetings[1], "Сколько будет") {
+		t.Errorf("во время наплыва ожидали пример: %q", greetings[1])
+	}
+	if !strings.Contains(notice, "Много вступлений") {
+		t.Errorf("чат должен получить уведомление о наплыве: %q", notice)
+	}
+}
@@ -0,0 +1,106 @@
+package hamster
+
+import (
+	"strconv"
+	"time"
+)
+
+// ==========================
+// Закрытие чата на время наплыва
+// ==========================
+
+// Медленный режим (slow_mode_delay) в Bot API только читается через getChat —
+// включить его бот не может. Поэтому во время наплыва с RaidLockChat чат
+// закрывается целиком: писать могут только администраторы.
+
+// ended сообщает, что наплыв в чате закончился: за окно после последнего
+// превышения порога новых превышений не было. Режим при этом выключается.
+func (d *raidDetector) ended(chatID int64, window time.Duration, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, ok := d.chats[chatID]
+	if !ok {
+		return true
+	}
+	if s.active && now.Sub(s.lastHigh) <= window {
+		return false
+	}
+	s.active = false
+	return true
+}
+
+// raidStarted закрывает чат и следит за окончанием наплыва.
+func (b *Bot) raidStarted(chatID int64) {
+	if b.cfg.RaidLockChat && b.lockChat(chatID) {
+		go b.watchRaidEnd(chatID)
+	}
+}
+
+// watchRaidEnd ждёт окончания наплыва — вступлений, по которым raidJoin
+// заметил бы его сам, может больше и не быть — и открывает чат.
+func (b *Bot) watchRaidEnd(chatID int64) {
+	ticker := time.NewTicker(max(b.cfg.RaidWindow/4, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return // откроется при следующем запуске (restoreRaidLocks)
+		case now := <-ticker.C:
+			if !b.raids.ended(chatID, b.cfg.RaidWindow, now) {
+				continue
+			}
+			b.unlockChat(chatID)
+			if b.chatSettings(chatID).RaidLock == nil {
+				return
+			}
+		}
+	}
+}
+
+// lockChat запоминает права участников чата в настройках и оставляет право
+// писать только администраторам. Без текущих прав чат не закрывается:
+// восстановить их потом было бы не из чего. Возвращает, закрыт ли чат сейчас
+// (а не был закрыт раньше).
+func (b *Bot) lockChat(chatID int64) bool {
+	if b.chatSettings(chatID).RaidLock != nil {
+		return false // уже закрыт, за окончанием наплыва следят
+	}
+	chat, err := b.api.GetChat(b.ctx, strconv.FormatInt(chatID, 10))
+	if err != nil || chat.Permissions == nil {
+		b.logger.Warn("Не удалось узнать права участников чата %d, не закрываем его: %v", chatID, err)
+		return false
+	}
+	saved := *chat.Permissions
+	b.updateChatSettings(chatID, func(c *ChatSettings) { c.RaidLock = &saved })
+	if b.safeSetChatPermissions(b.ctx, chatID, ChatPermissions{}) != nil {
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.RaidLock = nil })
+		return false
+	}
+	b.logger.Warn("Чат %d закрыт на время наплыва", chatID)
+	b.sendTemporary(chatID, "🔒 Чат временно закрыт из-за наплыва ботов: писать могут только администраторы", time.Minute)
+	return true
+}
+
+// unlockChat возвращает права участников, сохранённые lockChat.
+func (b *Bot) unlockChat(chatID int64) {
+	saved := b.chatSettings(chatID).RaidLock
+	if saved == nil {
+		return
+	}
+	if b.safeSetChatPermissions(b.ctx, chatID, *saved) != nil {
+		return // права остаются сохранёнными до следующей попытки
+	}
+	b.updateChatSettings(chatID, func(c *ChatSettings) { c.RaidLock = nil })
+	b.logger.Info("Чат %d снова открыт", chatID)
+	b.sendTemporary(chatID, "🔓 Наплыв закончился — чат снова открыт", time.Minute)
+}
+
+// restoreRaidLocks открывает чаты, закрытые до перезапуска: наплыв в памяти
+// не пережил его, и следить за окончанием уже некому.
+func (b *Bot) restoreRaidLocks() {
+	for chatID, cs := range b.settings.Snapshot() {
+		if cs.RaidLock != nil {
+			b.unlockChat(chatID)
+		}
+	}
+}
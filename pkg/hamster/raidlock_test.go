@@ -0,0 +1,111 @@
+package hamster
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRaidDetectorEnded(t *testing.T) {
+	d := newRaidDetector()
+	now := time.Now()
+	d.hit(1, 1, time.Minute, now)
+	if d.ended(1, time.Minute, now.Add(30*time.Second)) {
+		t.Error("в пределах окна наплыв продолжается")
+	}
+	if !d.ended(1, time.Minute, now.Add(2*time.Minute)) {
+		t.Error("через окно без вступлений наплыв заканчивается")
+	}
+	if active, changed := d.hit(1, 2, time.Minute, now.Add(3*time.Minute)); active || changed {
+		t.Errorf("после ended режим уже выключен: active=%v changed=%v", active, changed)
+	}
+}
+
+func TestRaidLocksChat(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.cfg.RaidJoins, b.cfg.RaidWindow, b.cfg.RaidLockChat = 2, 100*time.Millisecond, true
+	b.raids = newRaidDetector()
+	before := ChatPermissions{CanSendMessages: true, CanSendPhotos: true, CanInviteUsers: true}
+	fakeOf(b).getChat = func(chatRef string) (Chat, error) { return Chat{ID: -100, Permissions: &before}, nil }
+	var mu sync.Mutex
+	var set []ChatPermissions
+	fakeOf(b).setChatPermissions = func(chatID int64, perms ChatPermissions) {
+		mu.Lock()
+		defer mu.Unlock()
+		set = append(set, perms)
+	}
+
+	b.raidJoin(-100)
+	b.raidJoin(-100)
+	if got := b.chatSettings(-100).RaidLock; got == nil || *got != before {
+		t.Fatalf("права до наплыва должны сохраниться: %+v", got)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for b.chatSettings(-100).RaidLock != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(set) != 2 || set[0] != (ChatPermissions{}) || set[1] != before {
+		t.Errorf("ожидали закрытие чата и возврат прав: %+v", set)
+	}
+}
+
+func TestRaidLockNeedsPermissions(t *testing.T) {
+	b := setupBot()
+	fakeOf(b).getChat = func(chatRef string) (Chat, error) { return Chat{}, errors.New("chat not found") }
+	called := false
+	fakeOf(b).setChatPermissions = func(chatID int64, perms ChatPermissions) { called = true }
+	if b.lockChat(-100) || called {
+		t.Error("без текущих прав чат не закрывается")
+	}
+}
+
+func TestRestoreRaidLocks(t *testing.T) {
+	b := setupBot()
+	saved := ChatPermissions{CanSendMessages: true}
+	b.settings.Update(-100, func(c *ChatSettings) { c.RaidLock = &saved })
+	var got *ChatPermissions
+	fakeOf(b).setChatPermissions = func(chatID int64, perms ChatPermissions) { got = &perms }
+	b.restoreRaidLocks()
+	if got == nil || *got != saved || b.chatSettings(-100).RaidLock != nil {
+		t.Errorf("закрытый до перезапуска чат открывается: %+v", got)
+	}
+}
+
+func TestRaidLockKeepsAllPermissions(t *testing.T) {
+	const perms = `{"can_send_messages":true,"can_send_audios":true,"can_send_documents":true,` +
+		`"can_send_photos":true,"can_send_videos":true,"can_send_video_notes":true,` +
+		`"can_send_voice_notes":true,"can_send_polls":true,"can_send_other_messages":true,` +
+		`"can_add_web_page_previews":true,"can_change_info":true,"can_invite_users":true,` +
+		`"can_pin_messages":true,"can_manage_topics":true}`
+	var chat Chat
+	if err := json.Unmarshal([]byte(`{"id":-100,"type":"supergroup","permissions":`+perms+`}`), &chat); err != nil {
+		t.Fatal(err)
+	}
+	b := setupBot()
+	fakeOf(b).getChat = func(chatRef string) (Chat, error) { return chat, nil }
+	var set []ChatPermissions
+	fakeOf(b).setChatPermissions = func(chatID int64, perms ChatPermissions) { set = append(set, perms) }
+
+	if !b.lockChat(-100) {
+		t.Fatal("чат должен закрыться")
+	}
+	b.unlockChat(-100)
+	if len(set) != 2 {
+		t.Fatalf("ожидали закрытие и открытие чата: %+v", set)
+	}
+	data, _ := json.Marshal(set[1])
+	var want, got map[string]bool
+	json.Unmarshal([]byte(perms), &want)
+	json.Unmarshal(data, &got)
+	for k := range want {
+		if !got[k] {
+			t.Errorf("после открытия чата потеряно право %s", k)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package hamster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Ограничение частоты сообщений
+// ==========================
+
+const (
+	defaultRateLimitMute = 10 // минут
+	maxRateLimit         = 100
+	maxRateLimitMute     = 24 * 60
+	// rateLimitWindow — за какое время считаются сообщения. Считаются по кэшу
+	// сообщений: с MessageCacheTTL короче окна лимит срабатывает позже.
+	rateLimitWindow = time.Minute
+)
+
+// RateLimitMuteDuration возвращает длительность мута за превышение частоты.
+func (c ChatSettings) RateLimitMuteDuration() time.Duration {
+	if c.RateLimitMute <= 0 {
+		return defaultRateLimitMute * time.Minute
+	}
+	return time.Duration(c.RateLimitMute) * time.Minute
+}
+
+// rateMutes помнит, кто уже получил мут, чтобы сообщения, пришедшие до
+// ограничения, не давали повторных мутов и уведомлений.
+type rateMutes struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+// mute отмечает мут до until и сообщает, не действовал ли он уже.
+func (r *rateMutes) mute(chatID, userID int64, until, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.until == nil {
+		r.until = make(map[string]time.Time)
+	}
+	for k, t := range r.until {
+		if now.After(t) {
+			delete(r.until, k)
+		}
+	}
+	key := verifiedKey(chatID, userID)
+	if t, ok := r.until[key]; ok && now.Before(t) {
+		return false
+	}
+	r.until[key] = until
+	return true
+}
+
+// recentMessageCount — сколько сообщений пользователь написал в чат за
+// rateLimitWindow по кэшу сообщений.
+func (b *Bot) recentMessageCount(chatID, userID int64, now time.Time) int {
+	b.muMessages.Lock()
+	defer b.muMessages.Unlock()
+	l, ok := b.userMessages[userID]
+	if !ok {
+		return 0
+	}
+	n := 0
+	for e := l.Front(); e != nil; e = e.Next() {
+		cm := e.Value.(cachedMessage)
+		if !cm.isBot && cm.msg.Chat.ID == chatID && now.Sub(cm.timestamp) <= rateLimitWindow {
+			n++
+		}
+	}
+	return n
+}
+
+// rateLimited сообщает, что к автору применяется ограничение частоты: без
+// RateLimitAll — только к недавно прошедшим проверку (NewcomerPeriod).
+func (b *Bot) rateLimited(cs ChatSettings, chatID, userID int64) bool {
+	if cs.RateLimit <= 0 {
+		return false
+	}
+	return cs.RateLimitAll || b.inProbation(chatID, userID, b.cfg.NewcomerPeriod)
+}
+
+// applyRateLimit даёт мут тому, кто пишет чаще RateLimit сообщений в минуту.
+// Возвращает true, если сообщение удалено.
+func (b *Bot) applyRateLimit(msg *Message) bool {
+	if msg.From == nil || msg.SenderChat != nil {
+		return false
+	}
+	chatID, user := msg.Chat.ID, msg.From
+	cs := b.chatSettings(chatID)
+	if !b.rateLimited(cs, chatID, user.ID) {
+		return false
+	}
+	now := time.Now()
+	n := b.recentMessageCount(chatID, user.ID, now)
+	if n <= cs.RateLimit || b.isAdmin(b.ctx, chatID, user.ID) {
+		return false
+	}
+	b.safeDeleteMessage(b.ctx, chatID, msg.MessageID)
+	until := now.Add(cs.RateLimitMuteDuration())
+	if !b.rateMutes.mute(chatID, user.ID, until, now) {
+		return true
+	}
+	b.logger.Info("Участник %d пишет в чат %d слишком часто (%d за минуту) — мут до %s", user.ID, chatID, n, until.Format("15:04"))
+	b.safeRestrictUser(b.ctx, chatID, user.ID, ChatPermissions{}, until)
+	b.sendTemporaryHTML(chatID, fmt.Sprintf("🔇 %s пишет слишком часто — мут на %d мин.",
+		mentionHTML(user), int(cs.RateLimitMuteDuration().Minutes())), 30*time.Second)
+	return true
+}
+
+// ==========================
+// Команда /ratelimit
+// ==========================
+
+func (b *Bot) handleRateLimitCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять ограничение частоты", 5*time.Second)
+		return
+	}
+
+	usage := fmt.Sprintf("⚙️ Использование: /ratelimit <сообщений в минуту, 1–%d> [минут мута] [all|new] или /ratelimit off", maxRateLimit)
+	args := strings.Fields(commandArg(msg.Text, 1))
+	if len(args) == 0 {
+		b.sendTemporary(chatID, formatRateLimit(b.chatSettings(chatID))+"\n"+usage, 10*time.Second)
+		return
+	}
+	if strings.EqualFold(args[0], "off") {
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.RateLimit, c.RateLimitMute, c.RateLimitAll = 0, 0, false })
+		b.sendTemporary(chatID, "✅ Ограничение частоты сообщений выключено", 5*time.Second)
+		return
+	}
+
+	limit, err := strconv.Atoi(args[0])
+	if err != nil || limit < 1 || limit > maxRateLimit || len(args) > 3 {
+		b.sendTemporary(chatID, usage, 10*time.Second)
+		return
+	}
+	mute, all := 0, false
+	for _, a := range args[1:] {
+		switch strings.ToLower(a) {
+		case "all":
+			all = true
+		case "new":
+			all = false
+		default:
+			if mute, err = strconv.Atoi(a); err != nil || mute < 1 || mute > maxRateLimitMute {
+				b.sendTemporary(chatID, usage, 10*time.Second)
+				return
+			}
+		}
+	}
+	b.updateChatSettings(chatID, func(c *ChatSettings) { c.RateLimit, c.RateLimitMute, c.RateLimitAll = limit, mute, all })
+	b.sendTemporary(chatID, "✅ "+formatRateLimit(b.chatSettings(chatID)), 5*time.Second)
+}
+
+func formatRateLimit(cs ChatSettings) string {
+	if cs.RateLimit <= 0 {
+		return "⏱ Ограничение частоты сообщений выключено"
+	}
+	who := "недавно прошедших проверку"
+	if cs.RateLimitAll {
+		who = "всех участников"
+	}
+	return fmt.Sprintf("⏱ Больше %d сообщений в минуту — мут на %d мин. (для %s)",
+		cs.RateLimit, int(cs.RateLimitMuteDuration().Minutes()), who)
+}
@@ -0,0 +1,115 @@
+package hamster
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateMutesDedup(t *testing.T) {
+	var r rateMutes
+	now := time.Now()
+	if !r.mute(1, 2, now.Add(time.Minute), now) {
+		t.Fatal("первый мут должен выдаваться")
+	}
+	if r.mute(1, 2, now.Add(time.Minute), now.Add(time.Second)) {
+		t.Error("пока мут действует, повторный не выдаётся")
+	}
+	if !r.mute(1, 2, now.Add(3*time.Minute), now.Add(2*time.Minute)) {
+		t.Error("после окончания мута можно выдать новый")
+	}
+}
+
+func TestRateLimitMutesNewcomer(t *testing.T) {
+	b := setupBot()
+	b.verified = newVerifiedUsers()
+	b.cfg.NewcomerPeriod = time.Hour
+	b.settings.Update(-100, func(c *ChatSettings) { c.RateLimit = 3; c.RateLimitMute = 5 })
+	var mu sync.Mutex
+	var deleted []int64
+	var until time.Time
+	restricts := 0
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { mu.Lock(); deleted = append(deleted, msgID); mu.Unlock() }
+	fakeOf(b).restrict = func(chatID, userID int64, perms ChatPermissions, u time.Time) {
+		mu.Lock()
+		restricts++
+		until = u
+		mu.Unlock()
+	}
+	user := &User{ID: 42, FirstName: "Аня"}
+	say := func(id int64) bool {
+		msg := &Message{MessageID: id, Chat: Chat{ID: -100}, From: user, Text: "флуд"}
+		b.cacheMessage(Update{Message: msg})
+		return b.applyRateLimit(msg)
+	}
+
+	for i := int64(1); i <= 5; i++ {
+		if say(i) {
+			t.Fatal("до проверки участник не новичок — частота не ограничивается")
+		}
+	}
+	b.dropUserMessages(42)
+
+	b.markVerified(-100, 42)
+	for i := int64(11); i <= 13; i++ {
+		if say(i) {
+			t.Fatalf("сообщение %d в пределах лимита", i)
+		}
+	}
+	if !say(14) || !say(15) {
+		t.Fatal("сообщения сверх лимита удаляются")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if restricts != 1 {
+		t.Errorf("мут должен выдаваться один раз, выдано %d", restricts)
+	}
+	if d := time.Until(until); d < 4*time.Minute || d > 5*time.Minute {
+		t.Errorf("мут должен длиться 5 минут, до конца %v", d)
+	}
+	if len(deleted) != 2 || deleted[0] != 14 || deleted[1] != 15 {
+		t.Errorf("ожидали удаление сообщений 14 и 15: %v", deleted)
+	}
+}
+
+func TestRateLimitSkipsAdmins(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	b.settings.Update(-100, func(c *ChatSettings) { c.RateLimit = 1; c.RateLimitAll = true })
+	restricted := false
+	fakeOf(b).restrict = func(chatID, userID int64, perms ChatPermissions, u time.Time) { restricted = true }
+	for i := int64(1); i <= 3; i++ {
+		msg := &Message{MessageID: i, Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "объявление"}
+		b.cacheMessage(Update{Message: msg})
+		if b.applyRateLimit(msg) {
+			t.Fatal("сообщения администратора не удаляются")
+		}
+	}
+	if restricted {
+		t.Error("администратор не получает мут")
+	}
+}
+
+func TestRateLimitCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	cmd := func(text string) {
+		b.handleRateLimitCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: text})
+	}
+	cmd("/ratelimit 5 15 all")
+	cs := b.chatSettings(-100)
+	if cs.RateLimit != 5 || cs.RateLimitMuteDuration() != 15*time.Minute || !cs.RateLimitAll {
+		t.Fatalf("настройки ограничения: %+v", cs)
+	}
+	cmd("/ratelimit 500")
+	if b.chatSettings(-100).RateLimit != 5 {
+		t.Error("лимит вне диапазона не принимается")
+	}
+	cmd("/ratelimit off")
+	if cs := b.chatSettings(-100); cs.RateLimit != 0 || cs.RateLimitAll {
+		t.Errorf("после off ограничение выключено: %+v", cs)
+	}
+	if err := (ChatSettings{RateLimit: -1}).Validate(); err == nil {
+		t.Error("отрицательный лимит должен отклоняться")
+	}
+}
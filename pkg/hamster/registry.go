@@ -0,0 +1,52 @@
+package hamster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ==========================
+// Реестр расширений
+// ==========================
+
+// registry хранит именованные расширения одного вида: типы проверки,
+// стили прогрессбара. Регистрация обычно происходит в init.
+type registry[T any] struct {
+	kind string // для сообщения о повторной регистрации
+	mu   sync.RWMutex
+	m    map[string]T
+}
+
+func newRegistry[T any](kind string) *registry[T] {
+	return &registry[T]{kind: kind, m: make(map[string]T)}
+}
+
+// register добавляет расширение; повторное имя — ошибка программиста.
+func (r *registry[T]) register(name string, v T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.m[name]; dup {
+		panic(fmt.Sprintf("%s %q уже зарегистрирован", r.kind, name))
+	}
+	r.m[name] = v
+}
+
+func (r *registry[T]) lookup(name string) (T, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.m[name]
+	return v, ok
+}
+
+// names возвращает отсортированные имена зарегистрированных расширений.
+func (r *registry[T]) names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.m))
+	for name := range r.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,244 @@
+package hamster
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Запись и воспроизведение обновлений
+// ==========================
+
+// RecordedUpdate — строка файла записи обновлений (JSON Lines).
+type RecordedUpdate struct {
+	At     time.Time `json:"at"`
+	Update Update    `json:"update"`
+}
+
+// updateRecorder дописывает полученные обновления в файл RecordUpdatesFile.
+type updateRecorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+func openUpdateRecorder(file string) (*updateRecorder, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &updateRecorder{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// record записывает пачку обновлений. Методы nil-записи ничего не делают.
+func (r *updateRecorder) record(updates []Update) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	for _, u := range updates {
+		if err := r.enc.Encode(RecordedUpdate{At: now, Update: u}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *updateRecorder) close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.f.Close()
+}
+
+// ReplayOptions — параметры воспроизведения записи.
+type ReplayOptions struct {
+	// Speed — темп относительно записи: 1 — как было, 10 — в десять раз
+	// быстрее. 0 — без пауз между обновлениями.
+	Speed float64
+	// Wait — сколько после последнего обновления ждать обработчиков, которые
+	// работают в фоне: приветствий, таймеров проверок, удаления сообщений.
+	Wait time.Duration
+	// Out — куда писать вызовы Bot API, по одному в строке.
+	Out io.Writer
+}
+
+// Replay прогоняет записанные обновления через обработчики бота. Вместо
+// Telegram вызовы Bot API пишутся в opts.Out; настройки чатов читаются из
+// хранилища cfg, но ничего в него не записывается.
+func Replay(r io.Reader, cfg Config, logger *Logger, opts ReplayOptions) error {
+	var settings map[int64]*ChatSettings
+	if storage, err := OpenStorage(cfg, logger); err != nil {
+		logger.Warn("Хранилище недоступно, воспроизводим с настройками по умолчанию: %v", err)
+	} else {
+		settings, err = storage.LoadSettings()
+		storage.Close()
+		if err != nil {
+			return fmt.Errorf("настройки: %w", err)
+		}
+	}
+
+	cfg.RecordUpdatesFile, cfg.SentryDSN, cfg.Trace = "", "", TraceConfig{}
+	cfg.LeaderElection, cfg.AdminRefreshInterval = false, 0
+	api := &replayAPI{out: opts.Out}
+	b, err := NewBot("replay", cfg, WithTelegramAPI(api), WithStorage(newFileStorage("", logger)), WithLogger(logger))
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+	b.settings.Replace(settings)
+	b.loadSelf()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var prev time.Time
+	n := 0
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec RecordedUpdate
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("строка %d: %w", n+1, err)
+		}
+		if opts.Speed > 0 && !prev.IsZero() && rec.At.After(prev) {
+			time.Sleep(time.Duration(float64(rec.At.Sub(prev)) / opts.Speed))
+		}
+		prev = rec.At
+		n++
+		api.printf("# update %d", rec.Update.UpdateID)
+		b.cacheMessage(rec.Update)
+		b.handleUpdate(rec.Update)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	time.Sleep(opts.Wait)
+	logger.Info("▶️ Воспроизведено обновлений: %d", n)
+	return nil
+}
+
+// replayAPI вместо запросов к Telegram пишет их в out. Все участники —
+// обычные, сообщения получают номера по порядку.
+type replayAPI struct {
+	mu    sync.Mutex
+	out   io.Writer
+	msgID int64
+}
+
+func (a *replayAPI) printf(format string, args ...interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.out != nil {
+		fmt.Fprintf(a.out, format+"\n", args...)
+	}
+}
+
+func (a *replayAPI) nextID() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.msgID++
+	return a.msgID
+}
+
+func (a *replayAPI) GetUpdates(ctx context.Context, offset int64, timeout, limit int) ([]Update, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (a *replayAPI) GetMe(ctx context.Context) (User, error) {
+	return User{ID: 1, IsBot: true, FirstName: "Hamster", Username: "hamster_replay_bot"}, nil
+}
+
+func (a *replayAPI) GetChat(ctx context.Context, chatRef string) (Chat, error) {
+	return Chat{Type: "supergroup"}, nil
+}
+
+func (a *replayAPI) GetChatMember(ctx context.Context, chatID, userID int64) (ChatMember, error) {
+	if userID == 1 {
+		return ChatMember{Status: "administrator", User: &User{ID: 1, IsBot: true}, CanDeleteMessages: true, CanRestrictMembers: true}, nil
+	}
+	return ChatMember{Status: "member", User: &User{ID: userID}}, nil
+}
+
+func (a *replayAPI) GetChatAdministrators(ctx context.Context, chatID int64) ([]ChatMember, error) {
+	return nil, nil
+}
+
+func (a *replayAPI) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts SendOptions) (int64, error) {
+	id := a.nextID()
+	a.printf("sendMessage chat=%d id=%d %q", chatID, id, text)
+	return id, nil
+}
+
+func (a *replayAPI) EditMessage(ctx context.Context, chatID, msgID int64, text, parseMode string) error {
+	a.printf("editMessageText chat=%d id=%d %q", chatID, msgID, text)
+	return nil
+}
+
+func (a *replayAPI) SendMedia(ctx context.Context, chatID int64, media Media, caption string, markup interface{}, opts SendOptions) (int64, error) {
+	id := a.nextID()
+	a.printf("sendMedia chat=%d id=%d %s %q", chatID, id, media.Type, caption)
+	return id, nil
+}
+
+func (a *replayAPI) EditCaption(ctx context.Context, chatID, msgID int64, caption, parseMode string) error {
+	a.printf("editMessageCaption chat=%d id=%d %q", chatID, msgID, caption)
+	return nil
+}
+
+func (a *replayAPI) SendDocument(ctx context.Context, chatID int64, name string, content []byte, caption string) (int64, error) {
+	id := a.nextID()
+	a.printf("sendDocument chat=%d id=%d %s (%d байт)", chatID, id, name, len(content))
+	return id, nil
+}
+
+func (a *replayAPI) DeleteMessage(ctx context.Context, chatID, msgID int64) error {
+	a.printf("deleteMessage chat=%d id=%d", chatID, msgID)
+	return nil
+}
+
+func (a *replayAPI) AnswerCallback(ctx context.Context, callbackID, text string, showAlert bool) error {
+	a.printf("answerCallbackQuery %q", text)
+	return nil
+}
+
+func (a *replayAPI) Ban(ctx context.Context, chatID, userID int64) error {
+	a.printf("banChatMember chat=%d user=%d", chatID, userID)
+	return nil
+}
+
+func (a *replayAPI) Unban(ctx context.Context, chatID, userID int64) error {
+	a.printf("unbanChatMember chat=%d user=%d", chatID, userID)
+	return nil
+}
+
+func (a *replayAPI) Restrict(ctx context.Context, chatID, userID int64, perms ChatPermissions, until time.Time) error {
+	a.printf("restrictChatMember chat=%d user=%d", chatID, userID)
+	return nil
+}
+
+func (a *replayAPI) BanSenderChat(ctx context.Context, chatID, senderChatID int64) error {
+	a.printf("banChatSenderChat chat=%d sender=%d", chatID, senderChatID)
+	return nil
+}
+
+func (a *replayAPI) SetChatPermissions(ctx context.Context, chatID int64, perms ChatPermissions) error {
+	a.printf("setChatPermissions chat=%d", chatID)
+	return nil
+}
+
+func (a *replayAPI) LeaveChat(ctx context.Context, chatID int64) error {
+	a.printf("leaveChat chat=%d", chatID)
+	return nil
+}
@@ -0,0 +1,52 @@
+package hamster
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateRecorderRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "updates.jsonl")
+	rec, err := openUpdateRecorder(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	join := Update{UpdateID: 5, Message: &Message{MessageID: 10, Chat: Chat{ID: -100, Type: "supergroup"}, From: &User{ID: 42, FirstName: "Ann"}, NewChatMembers: []*User{{ID: 42, FirstName: "Ann"}}}}
+	if err := rec.record([]Update{join}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var out bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.SettingsFile = filepath.Join(t.TempDir(), "settings.json")
+	if err := Replay(f, cfg, NewLogger(), ReplayOptions{Out: &out, Wait: 200 * time.Millisecond}); err != nil {
+		t.Fatalf("Replay вернул ошибку: %v", err)
+	}
+	if !strings.Contains(out.String(), "# update 5") || !strings.Contains(out.String(), "sendMessage chat=-100") {
+		t.Errorf("вступление должно дать приветствие, журнал:\n%s", out.String())
+	}
+	if _, err := os.Stat(cfg.SettingsFile); err == nil {
+		t.Error("воспроизведение не должно ничего записывать в хранилище")
+	}
+}
+
+func TestReplayRejectsBrokenLine(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SettingsFile = ""
+	err := Replay(strings.NewReader("{not json}\n"), cfg, NewLogger(), ReplayOptions{})
+	if err == nil || !strings.Contains(err.Error(), "строка 1") {
+		t.Errorf("ожидали ошибку с номером строки, получили %v", err)
+	}
+}
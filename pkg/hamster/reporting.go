@@ -0,0 +1,275 @@
+package hamster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Отчёты об ошибках (Sentry)
+// ==========================
+
+// apiFailureStreak — после скольких неудач подряд одного метода Bot API
+// отправлять отчёт. Отдельные ошибки (удалённое сообщение, нет прав в одном
+// чате) только пишутся в лог.
+const apiFailureStreak = 5
+
+// ErrorReport — паника или повторяющаяся ошибка с контекстом чата.
+type ErrorReport struct {
+	Err      error
+	Panic    bool
+	Stack    []byte // стек горутины для паники
+	Where    string // например handleUpdate или banChatMember
+	ChatID   int64
+	UserID   int64
+	UpdateID int64
+}
+
+// ErrorReporter получает отчёты о паниках и повторяющихся сбоях Bot API.
+// Встроенная реализация — NewSentryReporter; свою можно передать через
+// WithErrorReporter. Report вызывается в отдельной горутине.
+type ErrorReporter interface {
+	Report(r ErrorReport)
+}
+
+// report отправляет отчёт, не задерживая обработку обновления.
+func (b *Bot) report(r ErrorReport) {
+	b.notifyError(r)
+	if b.reporter == nil {
+		return
+	}
+	go b.reporter.Report(r)
+}
+
+// reportPanic сообщает о панике при обработке обновления.
+func (b *Bot) reportPanic(u Update, recovered interface{}, stack []byte) {
+	_, chatID, userID := updateContext(u)
+	b.report(ErrorReport{
+		Err:      fmt.Errorf("%v", recovered),
+		Panic:    true,
+		Stack:    stack,
+		Where:    "handleUpdate",
+		ChatID:   chatID,
+		UserID:   userID,
+		UpdateID: u.UpdateID,
+	})
+}
+
+// apiStreaks считает неудачи подряд по методам Bot API.
+type apiStreaks struct {
+	mu sync.Mutex
+	m  map[string]int
+}
+
+// noteAPIResult учитывает результат вызова метода и отправляет отчёт, когда
+// неудач подряд становится apiFailureStreak. Следующий отчёт — только после
+// успешного вызова.
+func (b *Bot) noteAPIResult(method string, chatID, userID int64, err error) {
+	if errors.Is(err, ErrEditDropped) || errors.Is(err, context.Canceled) {
+		return // не сбой Telegram: правку вытеснила очередь или запрос отменён
+	}
+	b.apiStreaks.mu.Lock()
+	if err == nil {
+		delete(b.apiStreaks.m, method)
+		b.apiStreaks.mu.Unlock()
+		return
+	}
+	if b.apiStreaks.m == nil {
+		b.apiStreaks.m = make(map[string]int)
+	}
+	b.apiStreaks.m[method]++
+	n := b.apiStreaks.m[method]
+	b.apiStreaks.mu.Unlock()
+
+	if n == apiFailureStreak {
+		b.report(ErrorReport{
+			Err:    fmt.Errorf("%d неудач подряд, последняя: %w", n, err),
+			Where:  method,
+			ChatID: chatID,
+			UserID: userID,
+		})
+	}
+}
+
+// updateContext — тип обновления, чат и автор.
+func updateContext(u Update) (kind string, chatID, userID int64) {
+	switch {
+	case u.Message != nil:
+		if u.Message.From != nil {
+			userID = u.Message.From.ID
+		}
+		return "message", u.Message.Chat.ID, userID
+	case u.EditedMessage != nil:
+		if u.EditedMessage.From != nil {
+			userID = u.EditedMessage.From.ID
+		}
+		return "edited_message", u.EditedMessage.Chat.ID, userID
+	case u.Callback != nil:
+		if u.Callback.Message != nil {
+			chatID = u.Callback.Message.Chat.ID
+		}
+		if u.Callback.From != nil {
+			userID = u.Callback.From.ID
+		}
+		return "callback_query", chatID, userID
+	case u.ChatMember != nil:
+		if u.ChatMember.NewChatMember.User != nil {
+			userID = u.ChatMember.NewChatMember.User.ID
+		}
+		return "chat_member", u.ChatMember.Chat.ID, userID
+	case u.MyChatMember != nil:
+		if u.MyChatMember.From != nil {
+			userID = u.MyChatMember.From.ID
+		}
+		return "my_chat_member", u.MyChatMember.Chat.ID, userID
+	}
+	return "", 0, 0
+}
+
+// ==========================
+// Sentry (envelope API без SDK)
+// ==========================
+
+type sentryReporter struct {
+	endpoint    string // .../api/<project>/envelope/
+	key         string
+	dsn         string
+	environment string
+	serverName  string
+	client      HTTPClient
+	logger      *Logger
+}
+
+// NewSentryReporter возвращает ErrorReporter, отправляющий события в Sentry
+// (или совместимый сервис вроде GlitchTip) по DSN вида
+// https://<ключ>@<хост>/<проект>.
+func NewSentryReporter(dsn, environment, serverName string, client HTTPClient, logger *Logger) (ErrorReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	key := u.User.Username()
+	path := strings.Trim(u.Path, "/")
+	project := path[strings.LastIndex(path, "/")+1:]
+	if key == "" || project == "" || u.Host == "" {
+		return nil, errors.New("ожидался DSN вида https://<ключ>@<хост>/<проект>")
+	}
+	prefix := strings.TrimSuffix(path, project)
+	endpoint := fmt.Sprintf("%s://%s/%sapi/%s/envelope/", u.Scheme, u.Host, prefix, project)
+	return &sentryReporter{
+		endpoint:    endpoint,
+		key:         key,
+		dsn:         dsn,
+		environment: environment,
+		serverName:  serverName,
+		client:      client,
+		logger:      logger,
+	}, nil
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sentryEvent struct {
+	EventID     string `json:"event_id"`
+	Timestamp   string `json:"timestamp"`
+	Platform    string `json:"platform"`
+	Level       string `json:"level"`
+	Logger      string `json:"logger"`
+	Release     string `json:"release"`
+	Environment string `json:"environment,omitempty"`
+	ServerName  string `json:"server_name,omitempty"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+	Tags  map[string]string      `json:"tags"`
+	User  map[string]string      `json:"user,omitempty"`
+	Extra map[string]interface{} `json:"extra,omitempty"`
+}
+
+func (s *sentryReporter) event(r ErrorReport) sentryEvent {
+	ev := sentryEvent{
+		EventID:     randomHex(16),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Platform:    "go",
+		Level:       "error",
+		Logger:      "tg-hamster",
+		Release:     "tg-hamster@" + Version,
+		Environment: s.environment,
+		ServerName:  s.serverName,
+		Tags:        map[string]string{"where": r.Where},
+		Extra:       map[string]interface{}{},
+	}
+	kind := "error"
+	if r.Panic {
+		ev.Level, kind = "fatal", "panic"
+	}
+	msg := "<nil>"
+	if r.Err != nil {
+		msg = r.Err.Error()
+	}
+	ev.Exception.Values = []sentryException{{Type: kind, Value: msg}}
+	if r.ChatID != 0 {
+		ev.Tags["chat_id"] = strconv.FormatInt(r.ChatID, 10)
+	}
+	if r.UserID != 0 {
+		ev.User = map[string]string{"id": strconv.FormatInt(r.UserID, 10)}
+	}
+	if r.UpdateID != 0 {
+		ev.Extra["update_id"] = r.UpdateID
+	}
+	if len(r.Stack) > 0 {
+		ev.Extra["stack"] = string(r.Stack)
+	}
+	return ev
+}
+
+func (s *sentryReporter) Report(r ErrorReport) {
+	if err := s.send(s.event(r)); err != nil {
+		s.logger.Warn("Не удалось отправить отчёт об ошибке в Sentry: %v", err)
+	}
+}
+
+func (s *sentryReporter) send(ev sentryEvent) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	header, _ := json.Marshal(map[string]string{"event_id": ev.EventID, "dsn": s.dsn, "sent_at": ev.Timestamp})
+	item, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(payload)})
+	var body bytes.Buffer
+	body.Write(header)
+	body.WriteByte('\n')
+	body.Write(item)
+	body.WriteByte('\n')
+	body.Write(payload)
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=tg-hamster/%s, sentry_key=%s", Version, s.key))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Sentry ответил %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package hamster
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type chanReporter chan ErrorReport
+
+func (c chanReporter) Report(r ErrorReport) { c <- r }
+
+func TestReportPanicWithContext(t *testing.T) {
+	b := setupBot()
+	reports := make(chanReporter, 1)
+	b.reporter = reports
+
+	u := Update{UpdateID: 9, Callback: &Callback{From: &User{ID: 42}, Message: &Message{Chat: Chat{ID: -100}}}}
+	b.reportPanic(u, "nil map", []byte("goroutine 1"))
+	select {
+	case r := <-reports:
+		if !r.Panic || r.ChatID != -100 || r.UserID != 42 || r.UpdateID != 9 || r.Err.Error() != "nil map" {
+			t.Errorf("неполный отчёт: %+v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("отчёт о панике не отправлен")
+	}
+}
+
+func TestAPIFailureStreakReportedOnce(t *testing.T) {
+	b := setupBot()
+	reports := make(chanReporter, 10)
+	b.reporter = reports
+	fail := errors.New("banChatMember: 400 not enough rights")
+
+	for i := 0; i < 2*apiFailureStreak; i++ {
+		b.noteAPIResult("banChatMember", -100, 7, fail)
+	}
+	b.noteAPIResult("banChatMember", -100, 7, nil)
+	for i := 0; i < apiFailureStreak; i++ {
+		b.noteAPIResult("banChatMember", -100, 7, fail)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-reports:
+			if r.Where != "banChatMember" || r.ChatID != -100 || r.UserID != 7 || !errors.Is(r.Err, fail) {
+				t.Errorf("неполный отчёт: %+v", r)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("ожидали 2 отчёта — по одному на серию, получили %d", i)
+		}
+	}
+	select {
+	case r := <-reports:
+		t.Errorf("лишний отчёт: %+v", r)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestSentryReporterEnvelope(t *testing.T) {
+	var req *http.Request
+	var body []byte
+	client := &mockHTTPClient{DoFunc: func(r *http.Request) (*http.Response, error) {
+		req = r
+		body, _ = io.ReadAll(r.Body)
+		return jsonResponse(200, `{}`), nil
+	}}
+	rep, err := NewSentryReporter("https://abc123@sentry.example.com/sub/42", "staging", "node-1", client, NewLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep.Report(ErrorReport{Err: errors.New("boom"), Panic: true, Where: "handleUpdate", ChatID: -100, UserID: 7})
+
+	if req.URL.String() != "https://sentry.example.com/sub/api/42/envelope/" {
+		t.Errorf("адрес: %s", req.URL)
+	}
+	if !strings.Contains(req.Header.Get("X-Sentry-Auth"), "sentry_key=abc123") {
+		t.Errorf("заголовок авторизации: %s", req.Header.Get("X-Sentry-Auth"))
+	}
+	lines := bytes.Split(body, []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("envelope из трёх строк, получили %d", len(lines))
+	}
+	var ev sentryEvent
+	if err := json.Unmarshal(lines[2], &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Level != "fatal" || ev.Environment != "staging" || ev.Tags["chat_id"] != "-100" || ev.User["id"] != "7" || ev.Exception.Values[0].Value != "boom" {
+		t.Errorf("событие: %+v", ev)
+	}
+
+	if _, err := NewSentryReporter("https://sentry.example.com/42", "", "", client, NewLogger()); err == nil {
+		t.Error("DSN без ключа должен отклоняться")
+	}
+}
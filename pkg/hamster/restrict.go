@@ -0,0 +1,45 @@
+package hamster
+
+import "time"
+
+// ==========================
+// Ограничения прав участников
+// ==========================
+
+// ChatPermissions — набор прав участника для restrictChatMember.
+type ChatPermissions struct {
+	CanSendMessages       bool `json:"can_send_messages"`
+	CanSendAudios         bool `json:"can_send_audios"`
+	CanSendDocuments      bool `json:"can_send_documents"`
+	CanSendPhotos         bool `json:"can_send_photos"`
+	CanSendVideos         bool `json:"can_send_videos"`
+	CanSendVideoNotes     bool `json:"can_send_video_notes"`
+	CanSendVoiceNotes     bool `json:"can_send_voice_notes"`
+	CanSendPolls          bool `json:"can_send_polls"`
+	CanSendOtherMessages  bool `json:"can_send_other_messages"` // стикеры, GIF, игры
+	CanAddWebPagePreviews bool `json:"can_add_web_page_previews"`
+	CanChangeInfo         bool `json:"can_change_info"`
+	CanInviteUsers        bool `json:"can_invite_users"`
+	CanPinMessages        bool `json:"can_pin_messages"`
+	CanManageTopics       bool `json:"can_manage_topics"`
+}
+
+// textOnlyPermissions — можно писать текст, но без медиа, стикеров и превью ссылок.
+func textOnlyPermissions() ChatPermissions {
+	return ChatPermissions{
+		CanSendMessages: true,
+		CanInviteUsers:  true,
+	}
+}
+
+// restrictNewcomerMedia запрещает только что проверенному пользователю медиа на MediaRestrictPeriod.
+// Снятие ограничения выполняет сам Telegram по until_date.
+func (b *Bot) restrictNewcomerMedia(chatID, userID int64) {
+	period := b.cfg.MediaRestrictPeriod
+	if period <= 0 {
+		return
+	}
+	until := time.Now().Add(period)
+	b.safeRestrictUser(b.ctx, chatID, userID, textOnlyPermissions(), until)
+	b.logger.Info("Медиа для %d в чате %d ограничены до %s", userID, chatID, until.Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,40 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestrictNewcomerMediaDisabled(t *testing.T) {
+	b := setupBot()
+	called := false
+	fakeOf(b).restrict = func(chatID, userID int64, perms ChatPermissions, until time.Time) { called = true }
+
+	b.restrictNewcomerMedia(1, 42)
+	if called {
+		t.Error("при MediaRestrictPeriod=0 ограничение не должно применяться")
+	}
+}
+
+func TestRestrictNewcomerMedia(t *testing.T) {
+	b := setupBot()
+	b.cfg.MediaRestrictPeriod = 2 * time.Hour
+
+	var gotPerms ChatPermissions
+	var gotUntil time.Time
+	fakeOf(b).restrict = func(chatID, userID int64, perms ChatPermissions, until time.Time) {
+		gotPerms = perms
+		gotUntil = until
+	}
+
+	b.restrictNewcomerMedia(1, 42)
+	if !gotPerms.CanSendMessages {
+		t.Error("текстовые сообщения должны оставаться разрешены")
+	}
+	if gotPerms.CanSendPhotos || gotPerms.CanSendOtherMessages || gotPerms.CanAddWebPagePreviews {
+		t.Errorf("медиа должны быть запрещены: %+v", gotPerms)
+	}
+	if d := time.Until(gotUntil); d < time.Hour || d > 2*time.Hour {
+		t.Errorf("неверный срок ограничения: %v", d)
+	}
+}
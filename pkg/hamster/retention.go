@@ -0,0 +1,86 @@
+package hamster
+
+import (
+	"context"
+	"time"
+)
+
+// ==========================
+// Сроки хранения данных
+// ==========================
+
+const (
+	// defaultMessageCacheTTL — сколько по умолчанию помнить сообщения пользователей.
+	defaultMessageCacheTTL = 60 * time.Second
+	// janitorInterval — как часто удалять данные старше сроков хранения.
+	janitorInterval = time.Hour
+)
+
+// messageCacheTTL — MessageCacheTTL или значение по умолчанию, если он не задан.
+func (c Config) messageCacheTTL() time.Duration {
+	if c.MessageCacheTTL <= 0 {
+		return defaultMessageCacheTTL
+	}
+	return c.MessageCacheTTL
+}
+
+// prune удаляет счётчики чатов, которые не обновлялись дольше maxAge, и
+// возвращает их число. Счётчики без отметки времени (сохранённые прежними
+// версиями) отсчитываются от первой проверки.
+func (s *Stats) prune(maxAge time.Duration, now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for id, c := range s.chats {
+		if c.Updated.IsZero() {
+			c.Updated = now
+			continue
+		}
+		if now.Sub(c.Updated) > maxAge {
+			delete(s.chats, id)
+			n++
+		}
+	}
+	return n
+}
+
+// EnforceRetention удаляет записи журнала банов старше BanLogRetention и
+// счётчики чатов, где ничего не происходило дольше StatsRetention.
+func (b *Bot) EnforceRetention(now time.Time) {
+	if d := b.cfg.BanLogRetention; d > 0 {
+		before := now.Add(-d)
+		b.recentBans.remove(func(e BanLogEntry) bool { return e.At.Before(before) })
+		if b.storage != nil {
+			if n, err := b.storage.PruneBanLog(before); err != nil {
+				b.logger.Warn("Не удалось удалить старые записи журнала банов: %v", err)
+			} else if n > 0 {
+				b.logger.Info("Удалено записей журнала банов старше %s: %d", before.Format("2006-01-02"), n)
+			}
+		}
+	}
+	if d := b.cfg.StatsRetention; d > 0 && b.stats != nil {
+		if n := b.stats.prune(d, now); n > 0 {
+			b.logger.Info("Удалена статистика неактивных чатов: %d", n)
+			b.saveState()
+		}
+	}
+}
+
+// RunJanitor раз в janitorInterval применяет сроки хранения. Без заданных
+// сроков ничего не делает: данные хранятся бессрочно.
+func (b *Bot) RunJanitor(ctx context.Context) {
+	if b.cfg.BanLogRetention <= 0 && b.cfg.StatsRetention <= 0 {
+		return
+	}
+	b.EnforceRetention(time.Now())
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			b.EnforceRetention(now)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package hamster
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatsPrune(t *testing.T) {
+	s := NewStats()
+	now := time.Now()
+	s.add(-100, func(c *ChatStats) { c.Joins++ })
+	s.Replace(map[int64]ChatStats{
+		-100: s.Get(-100),
+		-200: {Joins: 1, Updated: now.Add(-48 * time.Hour)},
+		-300: {Joins: 1}, // сохранена прежней версией, без отметки времени
+	})
+	if n := s.prune(24*time.Hour, now); n != 1 {
+		t.Fatalf("удалить нужно только заброшенный чат, удалено %d", n)
+	}
+	if _, ok := s.Snapshot()[-200]; ok {
+		t.Error("статистика заброшенного чата удаляется")
+	}
+	if s.Get(-300).Updated.IsZero() {
+		t.Error("без отметки времени срок отсчитывается от первой проверки")
+	}
+}
+
+func TestEnforceRetention(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.verified = newVerifiedUsers()
+	b.recentBans = newBanHistory(10)
+	fs := newFileStorage(filepath.Join(t.TempDir(), "settings.json"), NewLogger())
+	fs.banFile = filepath.Join(filepath.Dir(fs.file), "ban_log.jsonl")
+	b.storage = fs
+	b.cfg.BanLogRetention = 90 * 24 * time.Hour
+	b.cfg.StatsRetention = 365 * 24 * time.Hour
+
+	now := time.Now()
+	b.writeBanLog(BanLogEntry{ChatID: -100, UserID: 1, Reason: BanReasonTimeout, At: now.Add(-100 * 24 * time.Hour)})
+	b.writeBanLog(BanLogEntry{ChatID: -100, UserID: 2, Reason: BanReasonTimeout, At: now.Add(-time.Hour)})
+	b.stats.Replace(map[int64]ChatStats{-200: {Joins: 1, Updated: now.Add(-400 * 24 * time.Hour)}})
+
+	b.EnforceRetention(now)
+	if got, _ := b.banLog(BanLogQuery{ChatID: -100}); len(got) != 1 || got[0].UserID != 2 {
+		t.Errorf("в журнале остаются только свежие баны: %+v", got)
+	}
+	if bans := b.recentBans.list(); len(bans) != 1 || bans[0].UserID != 2 {
+		t.Errorf("баны в памяти: %+v", bans)
+	}
+	if _, ok := b.stats.Snapshot()[-200]; ok {
+		t.Error("статистика чата без событий дольше года удаляется")
+	}
+}
+
+func TestMessageCacheTTL(t *testing.T) {
+	b := setupBot()
+	b.cfg.MessageCacheTTL = time.Nanosecond
+	b.cacheMessage(Update{Message: &Message{MessageID: 1, Chat: Chat{ID: -100}, From: &User{ID: 5}}})
+	time.Sleep(time.Millisecond)
+	b.CleanupOldMessages()
+	b.muMessages.Lock()
+	defer b.muMessages.Unlock()
+	if _, ok := b.userMessages[5]; ok {
+		t.Error("сообщения старше MESSAGE_CACHE_SECONDS удаляются из кэша")
+	}
+	if (Config{}).messageCacheTTL() != defaultMessageCacheTTL {
+		t.Error("без настройки кэш живёт 60 секунд")
+	}
+}
@@ -0,0 +1,146 @@
+package hamster
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Правила чата
+// ==========================
+
+const (
+	// RulesShow — показывать правила только что проверенным участникам.
+	RulesShow = "show"
+	// RulesAccept — показывать правила и не давать писать, пока участник их не примет.
+	RulesAccept = "accept"
+
+	// maxRulesLen — с запасом до лимита Telegram в 4096 символов на сообщение.
+	maxRulesLen = 3500
+	// rulesShowTTL — сколько висят правила в режиме RulesShow.
+	rulesShowTTL = 3 * time.Minute
+)
+
+// fullPermissions — все права: restrictChatMember с ними снимает ограничения.
+func fullPermissions() ChatPermissions {
+	return ChatPermissions{
+		CanSendMessages:       true,
+		CanSendAudios:         true,
+		CanSendDocuments:      true,
+		CanSendPhotos:         true,
+		CanSendVideos:         true,
+		CanSendVideoNotes:     true,
+		CanSendVoiceNotes:     true,
+		CanSendPolls:          true,
+		CanSendOtherMessages:  true,
+		CanAddWebPagePreviews: true,
+		CanInviteUsers:        true,
+	}
+}
+
+// showRules показывает правила только что проверенному участнику. Возвращает
+// true, если участник должен сначала принять их: до этого он в муте, а
+// обычные ограничения новичка применяются после нажатия кнопки.
+func (b *Bot) showRules(chatID int64, user *User, cs ChatSettings) bool {
+	if cs.Rules == "" || cs.RulesMode == "" {
+		return false
+	}
+	text := fmt.Sprintf("📜 %s, правила чата:\n\n%s", mentionHTML(user), html.EscapeString(cs.Rules))
+	if cs.RulesMode != RulesAccept {
+		b.sendTemporaryHTML(chatID, text, rulesShowTTL)
+		return false
+	}
+	b.safeRestrictUser(b.ctx, chatID, user.ID, ChatPermissions{}, time.Time{})
+	markup := map[string]interface{}{"inline_keyboard": [][]interface{}{{
+		map[string]interface{}{"text": "✅ Принимаю правила", "callback_data": fmt.Sprintf("rules:%d", user.ID)},
+	}}}
+	msgID := b.safeSend(b.ctx, chatID, text+"\n\nЧтобы писать в чат, примите правила кнопкой ниже", markup, htmlOptions)
+	b.keepSent(chatID, msgID) // без кнопки участник не снимет мут
+	return true
+}
+
+// handleRulesCallback обрабатывает нажатие «Принимаю правила».
+func (b *Bot) handleRulesCallback(cb *Callback) {
+	userID, _ := strconv.ParseInt(strings.TrimPrefix(cb.Data, "rules:"), 10, 64)
+	if cb.From.ID != userID {
+		b.safeAnswerCallback(b.ctx, cb.ID, "🚫 Эта кнопка не для вас", true)
+		return
+	}
+	chatID := cb.Message.Chat.ID
+	b.safeAnswerCallback(b.ctx, cb.ID, "✅ Спасибо!", false)
+	b.safeDeleteMessage(b.ctx, chatID, cb.Message.MessageID)
+	if b.cfg.MediaRestrictPeriod > 0 {
+		b.restrictNewcomerMedia(chatID, userID)
+	} else {
+		b.safeRestrictUser(b.ctx, chatID, userID, fullPermissions(), time.Time{})
+	}
+	b.logger.Info("Участник %d принял правила чата %d", userID, chatID)
+}
+
+// ==========================
+// Команды /setrules и /rulesmode
+// ==========================
+
+func (b *Bot) handleSetRulesCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять правила", 5*time.Second)
+		return
+	}
+
+	rules := commandArg(msg.Text, 1)
+	if rules == "" && msg.ReplyToMessage != nil {
+		rules = strings.TrimSpace(msg.ReplyToMessage.Text)
+	}
+	switch {
+	case rules == "":
+		b.sendTemporary(chatID, "⚙️ Использование: /setrules <текст> (или ответом на сообщение с правилами), /setrules clear — удалить", 10*time.Second)
+	case rules == "clear":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Rules = "" })
+		b.sendTemporary(chatID, "✅ Правила удалены", 5*time.Second)
+	case len([]rune(rules)) > maxRulesLen:
+		b.sendTemporary(chatID, fmt.Sprintf("❌ Правила длиннее %d символов", maxRulesLen), 5*time.Second)
+	default:
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Rules = rules })
+		text := "✅ Правила сохранены"
+		if b.chatSettings(chatID).RulesMode == "" {
+			text += ". Показывать их новичкам: /rulesmode show|accept"
+		}
+		b.sendTemporary(chatID, text, 10*time.Second)
+	}
+}
+
+func (b *Bot) handleRulesModeCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять правила", 5*time.Second)
+		return
+	}
+
+	switch mode := strings.ToLower(commandArg(msg.Text, 1)); mode {
+	case "off":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.RulesMode = "" })
+		b.sendTemporary(chatID, "✅ Правила больше не показываются новичкам", 5*time.Second)
+	case RulesShow, RulesAccept:
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.RulesMode = mode })
+		text := "✅ Правила будут показываться прошедшим проверку"
+		if mode == RulesAccept {
+			text += "; писать в чат можно будет только после их принятия"
+		}
+		if b.chatSettings(chatID).Rules == "" {
+			text += "\n⚠️ Правила ещё не заданы: /setrules <текст>"
+		}
+		b.sendTemporary(chatID, text, 10*time.Second)
+	default:
+		b.sendTemporary(chatID, "⚙️ Использование: /rulesmode off|show|accept", 5*time.Second)
+	}
+}
@@ -0,0 +1,109 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type restriction struct {
+	userID int64
+	perms  ChatPermissions
+	until  time.Time
+}
+
+func setupRulesBot(mode string) (*Bot, *[]restriction) {
+	b := setupBot()
+	b.settings.Update(-100, func(c *ChatSettings) { c.Rules = "Без рекламы"; c.RulesMode = mode })
+	var got []restriction
+	fakeOf(b).restrict = func(chatID, userID int64, perms ChatPermissions, until time.Time) {
+		got = append(got, restriction{userID, perms, until})
+	}
+	return b, &got
+}
+
+func TestRulesAcceptFlow(t *testing.T) {
+	b, restricted := setupRulesBot(RulesAccept)
+	var markup interface{}
+	var text string
+	fakeOf(b).sendWithMarkup = func(chatID int64, t string, m interface{}) int64 { text, markup = t, m; return 30 }
+
+	if !b.showRules(-100, &User{ID: 42, FirstName: "Аня"}, b.chatSettings(-100)) {
+		t.Fatal("в режиме accept нужно принятие правил")
+	}
+	if !strings.Contains(text, "Без рекламы") || markup == nil {
+		t.Fatalf("правила должны показываться с кнопкой: %q", text)
+	}
+	if len(*restricted) != 1 || (*restricted)[0].perms.CanSendMessages || !(*restricted)[0].until.IsZero() {
+		t.Fatalf("до принятия правил участник должен быть в бессрочном муте: %+v", *restricted)
+	}
+
+	var alert string
+	fakeOf(b).answerCallback = func(id, text string, showAlert bool) { alert = text }
+	cb := &Callback{ID: "1", From: &User{ID: 7}, Message: &Message{MessageID: 30, Chat: Chat{ID: -100}}, Data: "rules:42"}
+	b.handleCallback(cb)
+	if !strings.Contains(alert, "не для вас") || len(*restricted) != 1 {
+		t.Fatalf("чужое нажатие не снимает мут: %q %+v", alert, *restricted)
+	}
+
+	var deleted int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = msgID }
+	cb.From = &User{ID: 42}
+	b.handleCallback(cb)
+	if len(*restricted) != 2 || (*restricted)[1].perms != fullPermissions() {
+		t.Fatalf("после принятия ограничения снимаются: %+v", *restricted)
+	}
+	if deleted != 30 {
+		t.Error("сообщение с правилами удаляется после принятия")
+	}
+}
+
+func TestRulesAcceptKeepsMediaRestriction(t *testing.T) {
+	b, restricted := setupRulesBot(RulesAccept)
+	b.cfg.MediaRestrictPeriod = time.Hour
+	b.handleRulesCallback(&Callback{From: &User{ID: 42}, Message: &Message{MessageID: 30, Chat: Chat{ID: -100}}, Data: "rules:42"})
+	if len(*restricted) != 1 || (*restricted)[0].perms != textOnlyPermissions() {
+		t.Errorf("после принятия действует обычное ограничение медиа: %+v", *restricted)
+	}
+}
+
+func TestRulesShowDoesNotMute(t *testing.T) {
+	b, restricted := setupRulesBot(RulesShow)
+	var text string
+	fakeOf(b).sendSilent = func(chatID int64, t string) int64 { text = t; return 1 }
+	if b.showRules(-100, &User{ID: 42}, b.chatSettings(-100)) {
+		t.Error("в режиме show принятие не требуется")
+	}
+	if !strings.Contains(text, "Без рекламы") || len(*restricted) != 0 {
+		t.Errorf("правила показываются без мута: %q %+v", text, *restricted)
+	}
+
+	b.settings.Update(-100, func(c *ChatSettings) { c.RulesMode = "" })
+	text = ""
+	b.showRules(-100, &User{ID: 42}, b.chatSettings(-100))
+	if text != "" {
+		t.Error("без /rulesmode правила не показываются")
+	}
+}
+
+func TestSetRulesCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	admin := &User{ID: 10}
+
+	b.handleSetRulesCommand(&Message{Chat: Chat{ID: -100}, From: admin, Text: "/setrules",
+		ReplyToMessage: &Message{Text: "1. Не спамить\n2. Не ругаться"}})
+	if got := b.chatSettings(-100).Rules; got != "1. Не спамить\n2. Не ругаться" {
+		t.Fatalf("правила из ответа: %q", got)
+	}
+	b.handleRulesModeCommand(&Message{Chat: Chat{ID: -100}, From: admin, Text: "/rulesmode accept"})
+	if got := b.chatSettings(-100).RulesMode; got != RulesAccept {
+		t.Errorf("режим: %q", got)
+	}
+	b.handleSetRulesCommand(&Message{Chat: Chat{ID: -100}, From: admin, Text: "/setrules clear"})
+	if got := b.chatSettings(-100).Rules; got != "" {
+		t.Errorf("/setrules clear должен удалять правила: %q", got)
+	}
+}
@@ -0,0 +1,168 @@
+package hamster
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ==========================
+// Эвристическая оценка аккаунтов
+// ==========================
+
+// ScoreWeights — веса признаков подозрительного аккаунта. Отрицательный вес снижает оценку.
+type ScoreWeights struct {
+	NoUsername int
+	NoLastName int
+	NewAccount int // ID больше NewAccountID
+	RTL        int // символы письма справа налево или управляющие RTL-символы
+	EmojiName  int // имя в основном из эмодзи/символов
+	Premium    int
+
+	// NewAccountID — граница, выше которой ID считается «свежим» аккаунтом.
+	NewAccountID int64
+}
+
+// DefaultScoreWeights возвращает веса по умолчанию.
+func DefaultScoreWeights() ScoreWeights {
+	return ScoreWeights{
+		NoUsername:   1,
+		NoLastName:   1,
+		NewAccount:   2,
+		RTL:          3,
+		EmojiName:    2,
+		Premium:      -3,
+		NewAccountID: 7_000_000_000,
+	}
+}
+
+// Score считает оценку подозрительности пользователя.
+func (w ScoreWeights) Score(u *User) int {
+	score := 0
+	if u.Username == "" {
+		score += w.NoUsername
+	}
+	if u.LastName == "" {
+		score += w.NoLastName
+	}
+	if w.NewAccountID > 0 && u.ID > w.NewAccountID {
+		score += w.NewAccount
+	}
+	name := u.FirstName + u.LastName
+	if hasRTL(name) {
+		score += w.RTL
+	}
+	if isEmojiHeavy(name) {
+		score += w.EmojiName
+	}
+	if u.IsPremium {
+		score += w.Premium
+	}
+	return score
+}
+
+// ParseScoreWeights разбирает строку вида "no_username=1,rtl=3,premium=-3" поверх base.
+func ParseScoreWeights(s string, base ScoreWeights) (ScoreWeights, error) {
+	w := base
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return base, fmt.Errorf("ожидалось ключ=значение: %q", pair)
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return base, fmt.Errorf("некорректное число в %q", pair)
+		}
+		switch strings.TrimSpace(k) {
+		case "no_username":
+			w.NoUsername = int(n)
+		case "no_last_name":
+			w.NoLastName = int(n)
+		case "new_account":
+			w.NewAccount = int(n)
+		case "rtl":
+			w.RTL = int(n)
+		case "emoji_name":
+			w.EmojiName = int(n)
+		case "premium":
+			w.Premium = int(n)
+		case "new_account_id":
+			w.NewAccountID = n
+		default:
+			return base, fmt.Errorf("неизвестный признак %q", k)
+		}
+	}
+	return w, nil
+}
+
+func hasRTL(s string) bool {
+	for _, r := range s {
+		if unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko) {
+			return true
+		}
+		switch r {
+		case '\u200f', '\u202b', '\u202e', '\u2067':
+			return true
+		}
+	}
+	return false
+}
+
+// isEmojiHeavy — в имени не меньше трёх символов-пиктограмм и они составляют больше половины.
+func isEmojiHeavy(s string) bool {
+	symbols, total := 0, 0
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '\u200d' || r == '\ufe0f' {
+			continue
+		}
+		total++
+		if unicode.Is(unicode.So, r) || unicode.Is(unicode.Sk, r) || (r >= 0x1F000 && r <= 0x1FAFF) {
+			symbols++
+		}
+	}
+	return symbols >= 3 && symbols*2 > total
+}
+
+// ==========================
+// Проверка нового участника
+// ==========================
+
+// screenJoin прогоняет нового участника через фильтр имён и эвристическую оценку.
+// banned — пользователь забанен без проверки, strict — строгая проверка (strictChallenge).
+func (b *Bot) screenJoin(chatID int64, user *User) (banned, strict bool) {
+	banned, strict = b.applyNameFilter(chatID, user)
+	if banned {
+		return true, false
+	}
+
+	if b.cfg.ScoreBanThreshold <= 0 && b.cfg.ScoreStrictThreshold <= 0 {
+		return false, strict
+	}
+	score := b.cfg.ScoreWeights.Score(user)
+	if b.cfg.ScoreBanThreshold > 0 && score >= b.cfg.ScoreBanThreshold {
+		b.logger.Info("Оценка %d для %d в чате %d — бан", score, user.ID, chatID)
+		b.safeBanUser(b.ctx, chatID, user.ID)
+		b.logBan(chatID, user.ID, BanReasonScore)
+		return true, false
+	}
+	if b.cfg.ScoreStrictThreshold > 0 && score >= b.cfg.ScoreStrictThreshold {
+		b.logger.Info("Оценка %d для %d в чате %d — строгая проверка", score, user.ID, chatID)
+		strict = true
+	}
+	return false, strict
+}
+
+// strictChallenge усиливает проверку подозрительного вступившего: простая
+// кнопка заменяется на StrictCaptcha, таймаут сокращается до MinTimeoutSec.
+// Более сложные типы, выбранные администраторами, не меняются.
+func (b *Bot) strictChallenge(cs ChatSettings) (ChatSettings, int) {
+	if cs.Captcha() == CaptchaButton && b.cfg.StrictCaptcha != "" {
+		cs.CaptchaType = b.cfg.StrictCaptcha
+	}
+	return cs, MinTimeoutSec
+}
@@ -0,0 +1,109 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScoreWeights(t *testing.T) {
+	w := DefaultScoreWeights()
+
+	human := &User{ID: 100500, FirstName: "Иван", LastName: "Петров", Username: "ivan"}
+	if got := w.Score(human); got != 0 {
+		t.Errorf("ожидалась оценка 0 для обычного аккаунта, получили %d", got)
+	}
+
+	suspicious := &User{ID: 8_000_000_000, FirstName: "💰💰💰🚀"}
+	// нет username (1) + нет фамилии (1) + новый ID (2) + эмодзи (2)
+	if got := w.Score(suspicious); got != 6 {
+		t.Errorf("ожидалась оценка 6, получили %d", got)
+	}
+
+	rtl := &User{ID: 1, FirstName: "abc\u202eтекст", LastName: "x", Username: "x"}
+	if got := w.Score(rtl); got != w.RTL {
+		t.Errorf("ожидалась оценка %d за RTL, получили %d", w.RTL, got)
+	}
+
+	premium := &User{ID: 1, FirstName: "A", IsPremium: true}
+	if got := w.Score(premium); got != w.NoUsername+w.NoLastName+w.Premium {
+		t.Errorf("премиум должен снижать оценку, получили %d", got)
+	}
+}
+
+func TestParseScoreWeights(t *testing.T) {
+	w, err := ParseScoreWeights("rtl=5, premium=-1,new_account_id=100", DefaultScoreWeights())
+	if err != nil {
+		t.Fatalf("ParseScoreWeights вернул ошибку: %v", err)
+	}
+	if w.RTL != 5 || w.Premium != -1 || w.NewAccountID != 100 {
+		t.Errorf("веса разобраны неверно: %+v", w)
+	}
+	if w.NoUsername != DefaultScoreWeights().NoUsername {
+		t.Error("неуказанные веса должны остаться по умолчанию")
+	}
+	if _, err := ParseScoreWeights("unknown=1", DefaultScoreWeights()); err == nil {
+		t.Error("неизвестный признак должен давать ошибку")
+	}
+	if _, err := ParseScoreWeights("rtl", DefaultScoreWeights()); err == nil {
+		t.Error("пара без значения должна давать ошибку")
+	}
+}
+
+func TestScreenJoinThresholds(t *testing.T) {
+	b := setupBot()
+	b.cfg.ScoreWeights = DefaultScoreWeights()
+	b.cfg.ScoreStrictThreshold = 2
+	b.cfg.ScoreBanThreshold = 6
+
+	var banned []int64
+	fakeOf(b).ban = func(chatID, userID int64) { banned = append(banned, userID) }
+
+	if ban, strict := b.screenJoin(1, &User{ID: 1, FirstName: "A", LastName: "B", Username: "ab"}); ban || strict {
+		t.Errorf("обычный пользователь: ban=%v strict=%v", ban, strict)
+	}
+	if ban, strict := b.screenJoin(1, &User{ID: 2, FirstName: "A"}); ban || !strict {
+		t.Errorf("оценка 2 должна давать строгую проверку: ban=%v strict=%v", ban, strict)
+	}
+	if ban, _ := b.screenJoin(1, &User{ID: 8_000_000_000, FirstName: "🔥🔥🔥"}); !ban {
+		t.Error("оценка 6 должна давать бан")
+	}
+	if len(banned) != 1 || banned[0] != 8_000_000_000 {
+		t.Errorf("ожидался бан одного пользователя, получили %v", banned)
+	}
+}
+
+func TestStrictJoinGetsHarderChallenge(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.cfg.ScoreStrictThreshold = 2
+	b.stats = NewStats()
+	b.settings.Update(-200, func(c *ChatSettings) { c.CaptchaType = CaptchaMath })
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { return 10 }
+
+	started := func(chatID, userID int64) *progressData {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for b.pendingProgress(chatID, userID) == nil && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		p := b.pendingProgress(chatID, userID)
+		if p == nil {
+			t.Fatalf("проверка %d в чате %d не началась", userID, chatID)
+		}
+		return p
+	}
+	// оценка 2: без фамилии и username
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 42, FirstName: "A"}}})
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 43, FirstName: "A", LastName: "B", Username: "ab"}}})
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -200}, NewChatMembers: []*User{{ID: 42, FirstName: "A"}}})
+
+	if p := started(-100, 42); p.session.Settings.Captcha() != CaptchaSequence || p.timeout != MinTimeoutSec {
+		t.Errorf("строгая проверка вместо кнопки: %s, таймаут %d", p.session.Settings.Captcha(), p.timeout)
+	}
+	if p := started(-100, 43); p.session.Settings.Captcha() != CaptchaButton {
+		t.Errorf("обычному вступившему — проверка чата: %s", p.session.Settings.Captcha())
+	}
+	if p := started(-200, 42); p.session.Settings.Captcha() != CaptchaMath {
+		t.Errorf("выбранный администраторами тип сложнее кнопки не меняется: %s", p.session.Settings.Captcha())
+	}
+}
@@ -0,0 +1,21 @@
+package hamster
+
+// ==========================
+// Информация о самом боте
+// ==========================
+
+// loadSelf запрашивает getMe и запоминает ID и username бота.
+func (b *Bot) loadSelf() {
+	me := b.safeGetMe(b.ctx)
+	if me == nil || me.ID == 0 {
+		b.logger.Warn("Не удалось получить getMe — собственные сообщения не будут отфильтрованы")
+		return
+	}
+	b.self = *me
+	b.logger.Info("Бот: @%s (ID %d)", me.Username, me.ID)
+}
+
+// isSelf сообщает, что пользователь — сам бот.
+func (b *Bot) isSelf(u *User) bool {
+	return u != nil && b.self.ID != 0 && u.ID == b.self.ID
+}
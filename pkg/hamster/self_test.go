@@ -0,0 +1,46 @@
+package hamster
+
+import "testing"
+
+func TestLoadSelfAndIsSelf(t *testing.T) {
+	b := setupBot()
+	if b.isSelf(&User{ID: 0}) {
+		t.Error("до getMe никто не считается ботом")
+	}
+
+	fakeOf(b).getMe = func() *User { return &User{ID: 777, IsBot: true, Username: "hamster_bot"} }
+	b.loadSelf()
+
+	if !b.isSelf(&User{ID: 777}) {
+		t.Error("ID из getMe должен распознаваться как сам бот")
+	}
+	if b.isSelf(&User{ID: 1}) || b.isSelf(nil) {
+		t.Error("другие пользователи не должны считаться ботом")
+	}
+}
+
+func TestSelfJoinIsNotChallenged(t *testing.T) {
+	b := setupBot()
+	b.self = User{ID: 777, IsBot: true}
+	kicked, greeted := false, false
+	fakeOf(b).ban = func(chatID, userID int64) { kicked = true }
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { greeted = true; return 1 }
+
+	b.handleJoinMessage(&Message{
+		Chat:           Chat{ID: 1},
+		From:           &User{ID: 10},
+		NewChatMembers: []*User{{ID: 777, IsBot: true}},
+	})
+	if kicked || greeted {
+		t.Errorf("бот не должен проверять сам себя: kicked=%v greeted=%v", kicked, greeted)
+	}
+}
+
+func TestSelfMessagesNotCached(t *testing.T) {
+	b := setupBot()
+	b.self = User{ID: 777, IsBot: true}
+	b.cacheMessage(Update{Message: &Message{MessageID: 1, Chat: Chat{ID: 1}, From: &User{ID: 777, IsBot: true}}})
+	if _, ok := b.userMessages[777]; ok {
+		t.Error("собственные сообщения бота не должны кэшироваться")
+	}
+}
@@ -0,0 +1,92 @@
+package hamster
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Звук и защита от пересылки по видам сообщений
+// ==========================
+
+// Виды сообщений бота, для которых настраиваются звук и protect_content.
+const (
+	MsgGreeting = "greeting" // приветствие с проверкой
+	MsgProgress = "progress" // прогрессбар оставшегося времени
+	MsgAnnounce = "announce" // объявления /broadcast
+)
+
+// messageClasses — виды сообщений в порядке показа в /notify и /protect.
+var messageClasses = []string{MsgGreeting, MsgProgress, MsgAnnounce}
+
+// sendOptions возвращает параметры отправки сообщения вида class в чате.
+func (c ChatSettings) sendOptions(class string) SendOptions {
+	return SendOptions{Notify: slices.Contains(c.Notify, class), Protect: slices.Contains(c.Protect, class)}
+}
+
+// setClass включает или выключает class в списке, сохраняя порядок.
+func setClass(list []string, class string, on bool) []string {
+	out := make([]string, 0, len(list)+1)
+	for _, v := range list {
+		if v != class {
+			out = append(out, v)
+		}
+	}
+	if on {
+		out = append(out, class)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// ==========================
+// Команды /notify и /protect
+// ==========================
+
+func (b *Bot) handleNotifyCommand(msg *Message) {
+	b.handleSendOptionCommand(msg, "/notify", "🔔 Со звуком", func(c *ChatSettings) *[]string { return &c.Notify })
+}
+
+func (b *Bot) handleProtectCommand(msg *Message) {
+	b.handleSendOptionCommand(msg, "/protect", "🔒 Запрет пересылки", func(c *ChatSettings) *[]string { return &c.Protect })
+}
+
+// handleSendOptionCommand — общая часть /notify и /protect: field выбирает
+// список видов сообщений в настройках чата.
+func (b *Bot) handleSendOptionCommand(msg *Message, cmd, title string, field func(c *ChatSettings) *[]string) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять настройки сообщений", 5*time.Second)
+		return
+	}
+	usage := fmt.Sprintf("⚙️ Использование: %s %s on|off", cmd, strings.Join(messageClasses, "|"))
+
+	args := strings.Fields(strings.ToLower(commandArg(msg.Text, 1)))
+	if len(args) == 0 {
+		cs := b.chatSettings(chatID)
+		var sb strings.Builder
+		for _, class := range messageClasses {
+			fmt.Fprintf(&sb, "%s (%s): %s\n", title, class, onOff(slices.Contains(*field(&cs), class)))
+		}
+		sb.WriteString(usage)
+		b.sendTemporary(chatID, sb.String(), 10*time.Second)
+		return
+	}
+	if len(args) != 2 || !slices.Contains(messageClasses, args[0]) || (args[1] != "on" && args[1] != "off") {
+		b.sendTemporary(chatID, usage, 5*time.Second)
+		return
+	}
+	on := args[1] == "on"
+	b.updateChatSettings(chatID, func(c *ChatSettings) {
+		f := field(c)
+		*f = setClass(*f, args[0], on)
+	})
+	b.sendTemporary(chatID, fmt.Sprintf("✅ %s (%s): %s", title, args[0], onOff(on)), 5*time.Second)
+}
@@ -0,0 +1,63 @@
+package hamster
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChatSettingsSendOptions(t *testing.T) {
+	cs := ChatSettings{Notify: []string{MsgGreeting}, Protect: []string{MsgGreeting, MsgAnnounce}}
+	if got := cs.sendOptions(MsgGreeting); !got.Notify || !got.Protect {
+		t.Errorf("greeting: %+v", got)
+	}
+	if got := cs.sendOptions(MsgProgress); got.Notify || got.Protect {
+		t.Errorf("progress должен остаться беззвучным и без защиты: %+v", got)
+	}
+	if got := cs.sendOptions(MsgAnnounce); got.Notify || !got.Protect {
+		t.Errorf("announce: %+v", got)
+	}
+}
+
+func TestSetClass(t *testing.T) {
+	list := setClass(nil, MsgGreeting, true)
+	list = setClass(list, MsgProgress, true)
+	list = setClass(list, MsgGreeting, true)
+	if !reflect.DeepEqual(list, []string{MsgProgress, MsgGreeting}) {
+		t.Errorf("повторное включение не должно дублировать: %v", list)
+	}
+	list = setClass(setClass(list, MsgGreeting, false), MsgProgress, false)
+	if list != nil {
+		t.Errorf("пустой список должен становиться nil: %v", list)
+	}
+}
+
+func TestNotifyCommandAffectsGreeting(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	b.handleNotifyCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/notify greeting on"})
+	b.handleProtectCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/protect progress on"})
+	b.handleProtectCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/protect nothing on"})
+	cs := b.chatSettings(-100)
+	if !reflect.DeepEqual(cs.Notify, []string{MsgGreeting}) || !reflect.DeepEqual(cs.Protect, []string{MsgProgress}) {
+		t.Fatalf("notify = %v, protect = %v", cs.Notify, cs.Protect)
+	}
+
+	var got SendOptions
+	fakeOf(b).sendOptions = func(chatID int64, text string, opts SendOptions) { got = opts }
+	b.sendGreeting(b.ctx, -100, "Привет", nil, &ChallengeSession{Settings: cs})
+	if !got.Notify || got.Protect || got.ParseMode != ParseModeHTML {
+		t.Errorf("приветствие: %+v", got)
+	}
+}
+
+func TestValidateMessageClasses(t *testing.T) {
+	if err := (ChatSettings{Notify: []string{"everything"}}).Validate(); err == nil {
+		t.Error("неизвестный вид сообщений должен отклоняться")
+	}
+	if err := (ChatSettings{Protect: []string{MsgAnnounce}}).Validate(); err != nil {
+		t.Errorf("announce допустим: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package hamster
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ==========================
+// Почасовые ряды статистики
+// ==========================
+
+// seriesHours — сколько последних часов хранить в ChatStats.Series.
+const seriesHours = 30 * 24
+
+// StatsBucket — счётчики чата за один час (или сутки в ответе API с step=day).
+type StatsBucket struct {
+	Time   time.Time `json:"time"` // начало интервала, UTC
+	Joins  int64     `json:"joins"`
+	Passed int64     `json:"passed"`
+	Failed int64     `json:"failed"`
+	Banned int64     `json:"banned"`
+}
+
+func (s *StatsBucket) add(o StatsBucket) {
+	s.Joins += o.Joins
+	s.Passed += o.Passed
+	s.Failed += o.Failed
+	s.Banned += o.Banned
+}
+
+func (s StatsBucket) empty() bool {
+	return s.Joins == 0 && s.Passed == 0 && s.Failed == 0 && s.Banned == 0
+}
+
+// recordSeries добавляет приращение счётчиков в ряд за час now и удаляет
+// часы старше seriesHours.
+func (c *ChatStats) recordSeries(d StatsBucket, now time.Time) {
+	if d.empty() {
+		return
+	}
+	d.Time = now.UTC().Truncate(time.Hour)
+	if n := len(c.Series); n > 0 && c.Series[n-1].Time.Equal(d.Time) {
+		c.Series[n-1].add(d)
+	} else {
+		c.Series = mergeSeries(c.Series, []StatsBucket{d})
+	}
+	c.trimSeries(now)
+}
+
+// trimSeries удаляет часы старше seriesHours.
+func (c *ChatStats) trimSeries(now time.Time) {
+	oldest := now.UTC().Truncate(time.Hour).Add(-(seriesHours - 1) * time.Hour)
+	i := sort.Search(len(c.Series), func(i int) bool { return !c.Series[i].Time.Before(oldest) })
+	if i > 0 {
+		c.Series = append(c.Series[:0:0], c.Series[i:]...)
+	}
+}
+
+// mergeSeries складывает два ряда, упорядоченных по времени, в новый.
+func mergeSeries(a, b []StatsBucket) []StatsBucket {
+	out := make([]StatsBucket, 0, len(a)+len(b))
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || len(a) > 0 && a[0].Time.Before(b[0].Time):
+			out = append(out, a[0])
+			a = a[1:]
+		case len(a) == 0 || b[0].Time.Before(a[0].Time):
+			out = append(out, b[0])
+			b = b[1:]
+		default:
+			sum := a[0]
+			sum.add(b[0])
+			out = append(out, sum)
+			a, b = a[1:], b[1:]
+		}
+	}
+	return out
+}
+
+// resampleSeries возвращает ряд с шагом step от from до to включительно:
+// пустые интервалы заполнены нулями, чтобы графики не соединяли точки
+// через часы без событий.
+func resampleSeries(series []StatsBucket, from, to time.Time, step time.Duration) []StatsBucket {
+	from, to = from.UTC().Truncate(step), to.UTC().Truncate(step)
+	if to.Before(from) {
+		return []StatsBucket{}
+	}
+	out := make([]StatsBucket, 0, int(to.Sub(from)/step)+1)
+	for t := from; !t.After(to); t = t.Add(step) {
+		out = append(out, StatsBucket{Time: t})
+	}
+	for _, s := range series {
+		t := s.Time.UTC().Truncate(step)
+		if t.Before(from) || t.After(to) {
+			continue
+		}
+		out[int(t.Sub(from)/step)].add(s)
+	}
+	return out
+}
+
+// ==========================
+// REST: GET /api/series и /api/chats/{chat}/series
+// ==========================
+
+// apiChatSeries — ряд одного чата.
+func (b *Bot) apiChatSeries(w http.ResponseWriter, r *http.Request, chatID int64) {
+	b.writeSeries(w, r, b.stats.Get(chatID).Series)
+}
+
+// apiSeries — сумма рядов всех чатов.
+func (b *Bot) apiSeries(w http.ResponseWriter, r *http.Request) {
+	var sum []StatsBucket
+	for _, st := range b.stats.Snapshot() {
+		sum = mergeSeries(sum, st.Series)
+	}
+	b.writeSeries(w, r, sum)
+}
+
+// writeSeries разбирает ?from=, ?to= (RFC 3339 или Unix-время в секундах) и
+// ?step=hour|day и отдаёт ряд. Время в миллисекундах (${__from} в Grafana)
+// тоже принимается. По умолчанию — последние сутки по часам.
+func (b *Bot) writeSeries(w http.ResponseWriter, r *http.Request, series []StatsBucket) {
+	q := r.URL.Query()
+	step := time.Hour
+	switch q.Get("step") {
+	case "", "hour":
+	case "day":
+		step = 24 * time.Hour
+	default:
+		writeError(w, http.StatusBadRequest, "step должен быть hour или day")
+		return
+	}
+	now := time.Now()
+	to, err := parseSeriesTime(q.Get("to"), now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "некорректный to: "+err.Error())
+		return
+	}
+	from, err := parseSeriesTime(q.Get("from"), to.Add(-23*time.Hour))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "некорректный from: "+err.Error())
+		return
+	}
+	// вне последних seriesHours данных нет: не отдаём нулей за годы
+	if to.After(now) {
+		to = now
+	}
+	if oldest := now.Add(-seriesHours * time.Hour); from.Before(oldest) {
+		from = oldest
+	}
+	writeJSON(w, http.StatusOK, resampleSeries(series, from, to, step))
+}
+
+func parseSeriesTime(v string, def time.Time) (time.Time, error) {
+	if v == "" {
+		return def, nil
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if n > 1e11 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
@@ -0,0 +1,103 @@
+package hamster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStatsRecordsHourlySeries(t *testing.T) {
+	s := NewStats()
+	s.add(-100, func(c *ChatStats) { c.Joins++ })
+	s.add(-100, func(c *ChatStats) { c.Joins++; c.Failed++ })
+	s.add(-100, func(c *ChatStats) { c.Clicks++ }) // задержки в ряд не попадают
+
+	series := s.Get(-100).Series
+	if len(series) != 1 || series[0].Joins != 2 || series[0].Failed != 1 {
+		t.Fatalf("ожидался один час с 2 вступлениями и 1 провалом, получили %+v", series)
+	}
+	if !series[0].Time.Equal(time.Now().UTC().Truncate(time.Hour)) {
+		t.Errorf("час должен начинаться с ровного часа: %v", series[0].Time)
+	}
+}
+
+func TestRecordSeriesTrimsOldHours(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+	var c ChatStats
+	c.recordSeries(StatsBucket{Joins: 1}, now.Add(-seriesHours*time.Hour))
+	c.recordSeries(StatsBucket{Joins: 1}, now.Add(-time.Hour))
+	c.recordSeries(StatsBucket{Failed: 1}, now)
+	if len(c.Series) != 2 || c.Series[0].Joins != 1 || c.Series[1].Failed != 1 {
+		t.Errorf("час старше %d должен удаляться: %+v", seriesHours, c.Series)
+	}
+}
+
+func TestStatsMoveMergesSeries(t *testing.T) {
+	h := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	s := NewStats()
+	s.Replace(map[int64]ChatStats{
+		-1:    {Series: []StatsBucket{{Time: h, Joins: 1}, {Time: h.Add(time.Hour), Joins: 2}}},
+		-1001: {Series: []StatsBucket{{Time: h.Add(time.Hour), Joins: 3}, {Time: h.Add(2 * time.Hour), Banned: 1}}},
+	})
+	s.Move(-1, -1001)
+	got := s.Get(-1001).Series
+	if len(got) != 3 || got[0].Joins != 1 || got[1].Joins != 5 || got[2].Banned != 1 {
+		t.Errorf("ряды должны сложиться по часам: %+v", got)
+	}
+}
+
+func TestResampleSeries(t *testing.T) {
+	h := time.Date(2026, 5, 1, 22, 0, 0, 0, time.UTC)
+	series := []StatsBucket{{Time: h, Joins: 1}, {Time: h.Add(3 * time.Hour), Joins: 2, Failed: 1}}
+
+	hourly := resampleSeries(series, h, h.Add(3*time.Hour), time.Hour)
+	if len(hourly) != 4 || hourly[0].Joins != 1 || hourly[1].Joins != 0 || hourly[3].Failed != 1 {
+		t.Errorf("пустые часы должны заполняться нулями: %+v", hourly)
+	}
+	daily := resampleSeries(series, h, h.Add(3*time.Hour), 24*time.Hour)
+	if len(daily) != 2 || daily[0].Joins != 1 || daily[1].Joins != 2 {
+		t.Errorf("по суткам: %+v", daily)
+	}
+}
+
+func TestAdminAPISeries(t *testing.T) {
+	b := setupAdminBot()
+	h := b.AdminHandler()
+	hour := time.Now().UTC().Truncate(time.Hour)
+	b.stats.Replace(map[int64]ChatStats{
+		-100: {Joins: 3, Series: []StatsBucket{{Time: hour.Add(-2 * time.Hour), Joins: 1}, {Time: hour, Joins: 2}}},
+		-200: {Failed: 1, Series: []StatsBucket{{Time: hour, Failed: 1}}},
+	})
+
+	rec := adminRequest(t, h, "GET", "/api/chats/-100/series", "")
+	var got []StatsBucket
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("код %d, тело %s", rec.Code, rec.Body)
+	}
+	if len(got) != 24 || got[21].Joins != 1 || got[23].Joins != 2 {
+		t.Errorf("по умолчанию — сутки по часам: %+v", got)
+	}
+
+	from := hour.Add(-time.Hour).UnixMilli()
+	rec = adminRequest(t, h, "GET", fmt.Sprintf("/api/series?from=%d", from), "")
+	got = nil
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Joins != 2 || got[1].Failed != 1 {
+		t.Errorf("общий ряд с from в миллисекундах: %+v", got)
+	}
+
+	if rec := adminRequest(t, h, "GET", "/api/series?step=week", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("неизвестный step: код %d", rec.Code)
+	}
+
+	// список чатов для панели обходится без рядов
+	rec = adminRequest(t, h, "GET", "/api/chats", "")
+	var chats []ChatInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &chats); err != nil || len(chats) != 2 || chats[1].Stats.Series != nil {
+		t.Errorf("ряды не нужны в /api/chats: %s", rec.Body)
+	}
+}
@@ -0,0 +1,67 @@
+package hamster
+
+import (
+	"strings"
+	"time"
+)
+
+// ==========================
+// Сервисные сообщения о входе и выходе
+// ==========================
+
+// cleanServiceMessage удаляет «X вступил(а)» и «X вышел(а)», если это включено
+// в чате командой /service, независимо от исхода проверки.
+func (b *Bot) cleanServiceMessage(msg *Message) {
+	cs := b.chatSettings(msg.Chat.ID)
+	join := len(msg.NewChatMembers) > 0 && cs.DeleteJoinMessages
+	leave := msg.LeftChatMember != nil && cs.DeleteLeaveMessages
+	if join || leave {
+		b.safeDeleteMessage(b.ctx, msg.Chat.ID, msg.MessageID)
+	}
+}
+
+// ==========================
+// Команда /service
+// ==========================
+
+func (b *Bot) handleServiceCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может настраивать сервисные сообщения", 5*time.Second)
+		return
+	}
+
+	args := strings.Fields(strings.ToLower(commandArg(msg.Text, 1)))
+	if len(args) == 0 {
+		cs := b.chatSettings(chatID)
+		b.sendTemporary(chatID, "🧹 Удалять сообщения о входе: "+onOff(cs.DeleteJoinMessages)+
+			"\n🧹 Удалять сообщения о выходе: "+onOff(cs.DeleteLeaveMessages)+
+			"\n⚙️ /service join|leave on|off", 10*time.Second)
+		return
+	}
+	if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+		b.sendTemporary(chatID, "⚙️ Использование: /service join|leave on|off", 5*time.Second)
+		return
+	}
+	on := args[1] == "on"
+	switch args[0] {
+	case "join":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.DeleteJoinMessages = on })
+		b.sendTemporary(chatID, "✅ Удаление сообщений о входе: "+onOff(on), 5*time.Second)
+	case "leave":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.DeleteLeaveMessages = on })
+		b.sendTemporary(chatID, "✅ Удаление сообщений о выходе: "+onOff(on), 5*time.Second)
+	default:
+		b.sendTemporary(chatID, "⚙️ Использование: /service join|leave on|off", 5*time.Second)
+	}
+}
+
+func onOff(v bool) string {
+	if v {
+		return "включено"
+	}
+	return "выключено"
+}
@@ -0,0 +1,61 @@
+package hamster
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCleanServiceMessages(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	join := &Message{MessageID: 20, Chat: Chat{ID: -100}, From: &User{ID: 5}, NewChatMembers: []*User{{ID: 5}}}
+	leave := &Message{MessageID: 21, Chat: Chat{ID: -100}, From: &User{ID: 6}, LeftChatMember: &User{ID: 6}}
+	// sendTemporary удаляет ответы на /service по таймеру из других горутин
+	var mu sync.Mutex
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { mu.Lock(); deleted = append(deleted, msgID); mu.Unlock() }
+	takeDeleted := func() []int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		got := deleted
+		deleted = nil
+		return got
+	}
+
+	b.cleanServiceMessage(join)
+	b.cleanServiceMessage(leave)
+	if deleted := takeDeleted(); len(deleted) != 0 {
+		t.Fatalf("по умолчанию сервисные сообщения не удаляются: %v", deleted)
+	}
+
+	b.handleServiceCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/service leave on"})
+	takeDeleted()
+	b.cleanServiceMessage(join)
+	b.cleanServiceMessage(leave)
+	if deleted := takeDeleted(); len(deleted) != 1 || deleted[0] != 21 {
+		t.Fatalf("должно удаляться только сообщение о выходе: %v", deleted)
+	}
+
+	b.handleServiceCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/service join on"})
+	b.handleServiceCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/service leave off"})
+	takeDeleted()
+	b.cleanServiceMessage(join)
+	b.cleanServiceMessage(leave)
+	if deleted := takeDeleted(); len(deleted) != 1 || deleted[0] != 20 {
+		t.Errorf("должно удаляться только сообщение о входе: %v", deleted)
+	}
+}
+
+func TestServiceCommandRequiresAdmin(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:5": {status: "member", expiresAt: time.Now().Add(time.Minute)},
+	}
+	b.handleServiceCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 5}, Text: "/service join on"})
+	if b.chatSettings(-100).DeleteJoinMessages {
+		t.Error("участник не может менять настройку")
+	}
+}
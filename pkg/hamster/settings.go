@@ -0,0 +1,617 @@
+package hamster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// settingsSchemaVersion — версия формата settings.json.
+// 0 — старый timeouts.json (просто {"chatID": секунд}).
+const settingsSchemaVersion = 1
+
+const (
+	// ActionBan — при провале проверки пользователь банится навсегда.
+	ActionBan = "ban"
+	// ActionKick — при провале проверки пользователь исключается и может вернуться.
+	ActionKick = "kick"
+
+	// CaptchaButton — проверка одной кнопкой.
+	CaptchaButton = "button"
+
+	// DefaultWelcomeTemplate — приветствие по умолчанию, {name} заменяется именем участника.
+	DefaultWelcomeTemplate = "Привет, {name}!\nНажмите кнопку, чтобы подтвердить вход"
+
+	// maxWelcomeTemplateLen — с запасом до лимита Telegram в 4096 символов на сообщение.
+	maxWelcomeTemplateLen = 1024
+)
+
+// ChatSettings — все настройки одной группы. Пустые значения означают «по умолчанию».
+type ChatSettings struct {
+	Timeout         int    `json:"timeout,omitempty"`          // секунд на нажатие кнопки
+	Action          string `json:"action,omitempty"`           // ActionBan | ActionKick
+	Language        string `json:"language,omitempty"`         // язык сообщений бота, пока только "ru"
+	CaptchaType     string `json:"captcha_type,omitempty"`     // тип проверки
+	WelcomeTemplate string `json:"welcome_template,omitempty"` // текст приветствия с {name}
+
+	Disabled    bool `json:"disabled,omitempty"`     // проверка приостановлена через /hamster off
+	NoBroadcast bool `json:"no_broadcast,omitempty"` // чат отказался от рассылок /broadcast off
+
+	NameFilters      []string `json:"name_filters,omitempty"`
+	NameFilterAction string   `json:"name_filter_action,omitempty"`
+
+	ChannelFilter string `json:"channel_filter,omitempty"` // ChannelFilterDelete | ChannelFilterBan, пусто — выкл.
+
+	DeleteJoinMessages  bool `json:"delete_join_messages,omitempty"`  // удалять «X вступил(а)»
+	DeleteLeaveMessages bool `json:"delete_leave_messages,omitempty"` // удалять «X вышел(а)»
+	KeepGreeting        bool `json:"keep_greeting,omitempty"`         // оставлять приветствие после проверки
+	PlainText           bool `json:"plain_text,omitempty"`            // проверка без эмодзи, отсчёт в секундах
+
+	ProgressStyle string `json:"progress_style,omitempty"` // стиль прогрессбара, пусто — ProgressBlocks
+
+	WelcomeMedia *Media   `json:"welcome_media,omitempty"` // фото, GIF или стикер к приветствию
+	PhrasePack   string   `json:"phrase_pack,omitempty"`   // набор фраз кнопки, пусто — DefaultPhrasePack
+	Phrases      []string `json:"phrases,omitempty"`       // свои фразы чата поверх набора
+
+	Notify  []string `json:"notify,omitempty"`  // виды сообщений (MsgGreeting...), отправляемых со звуком
+	Protect []string `json:"protect,omitempty"` // виды сообщений с запретом пересылки
+
+	Rules     string `json:"rules,omitempty"`      // правила чата из /setrules
+	RulesMode string `json:"rules_mode,omitempty"` // RulesShow | RulesAccept, пусто — не показывать
+
+	LinkPolicies map[string]string `json:"link_policies,omitempty"` // ссылка-приглашение или её имя → LinkTrusted | LinkHard | LinkBan
+
+	AdminAdded string `json:"admin_added,omitempty"` // AdminAddedWelcome | AdminAddedCheck, пусто — AdminAddedSkip
+
+	Night *NightMode `json:"night,omitempty"` // ночной режим, nil — выкл.
+
+	// RaidLock — права участников до закрытия чата на время наплыва; nil — чат не закрыт.
+	RaidLock *ChatPermissions `json:"raid_lock,omitempty"`
+	// JoinQueue — кто ждёт проверки сверх MaxPendingPerChat без права писать.
+	JoinQueue []int64 `json:"join_queue,omitempty"`
+
+	SpamWords         []string `json:"spam_words,omitempty"`          // стоп-слова (или /regex/) для первых сообщений новичков
+	SpamWordsAction   string   `json:"spam_words_action,omitempty"`   // SpamWordsRechallenge | SpamWordsBan, пусто — SpamWordsDelete
+	SpamWordsMessages int      `json:"spam_words_messages,omitempty"` // сколько первых сообщений проверять, 0 — defaultSpamWordsMessages
+	RateLimit         int      `json:"rate_limit,omitempty"`          // больше стольких сообщений в минуту — мут, 0 — выкл.
+	RateLimitMute     int      `json:"rate_limit_mute,omitempty"`     // минут мута, 0 — defaultRateLimitMute
+	RateLimitAll      bool     `json:"rate_limit_all,omitempty"`      // ограничивать всех, а не только новичков (NewcomerPeriod)
+
+	ForwardFilter int `json:"forward_filter,omitempty"` // минут после проверки удалять пересылки, 0 — FORWARD_FILTER_MINUTES, ForwardFilterOff — выкл.
+
+	Blacklist []int64 `json:"blacklist,omitempty"` // ID пользователей, которых банить при вступлении без проверки
+
+	Moderators []int64 `json:"moderators,omitempty"` // ID модераторов: /verify, /report и /check без прав администратора
+
+	Federation       string `json:"federation,omitempty"`         // ID федерации чата, пусто — не в федерации
+	FederationOptOut bool   `json:"federation_opt_out,omitempty"` // не применять баны других чатов федерации
+
+	// Federations — федерации по ID; только в общих настройках (globalSettingsID).
+	Federations map[string]Federation `json:"federations,omitempty"`
+}
+
+// TimeoutSec возвращает таймаут с учётом значения по умолчанию.
+func (c ChatSettings) TimeoutSec() int {
+	if c.Timeout <= 0 {
+		return DefaultTimeoutSec
+	}
+	return c.Timeout
+}
+
+// FailAction возвращает действие при провале проверки.
+func (c ChatSettings) FailAction() string {
+	if c.Action == "" {
+		return ActionBan
+	}
+	return c.Action
+}
+
+// Captcha возвращает тип проверки.
+func (c ChatSettings) Captcha() string {
+	if c.CaptchaType == "" {
+		return CaptchaButton
+	}
+	return c.CaptchaType
+}
+
+// Welcome возвращает шаблон приветствия.
+func (c ChatSettings) Welcome() string {
+	if c.WelcomeTemplate == "" {
+		return DefaultWelcomeTemplate
+	}
+	return c.WelcomeTemplate
+}
+
+// NameAction возвращает действие при совпадении имени с шаблоном.
+func (c ChatSettings) NameAction() string {
+	if c.NameFilterAction == "" {
+		return NameFilterBan
+	}
+	return c.NameFilterAction
+}
+
+// Validate проверяет значения, заданные не через команды (REST API, ручная правка).
+func (c ChatSettings) Validate() error {
+	if c.Timeout != 0 && (c.Timeout < MinTimeoutSec || c.Timeout > MaxTimeoutSec) {
+		return fmt.Errorf("timeout должен быть от %d до %d секунд", MinTimeoutSec, MaxTimeoutSec)
+	}
+	switch c.Action {
+	case "", ActionBan, ActionKick:
+	default:
+		return fmt.Errorf("action должен быть %q или %q", ActionBan, ActionKick)
+	}
+	if _, ok := lookupChallenge(c.CaptchaType); c.CaptchaType != "" && !ok {
+		return fmt.Errorf("неизвестный captcha_type %q, доступны: %s", c.CaptchaType, strings.Join(ChallengeNames(), ", "))
+	}
+	switch c.NameFilterAction {
+	case "", NameFilterBan, NameFilterStrict:
+	default:
+		return fmt.Errorf("name_filter_action должен быть %q или %q", NameFilterBan, NameFilterStrict)
+	}
+	switch c.ChannelFilter {
+	case "", ChannelFilterDelete, ChannelFilterBan:
+	default:
+		return fmt.Errorf("channel_filter должен быть %q или %q", ChannelFilterDelete, ChannelFilterBan)
+	}
+	switch c.RulesMode {
+	case "", RulesShow, RulesAccept:
+	default:
+		return fmt.Errorf("rules_mode должен быть %q или %q", RulesShow, RulesAccept)
+	}
+	switch c.AdminAdded {
+	case "", AdminAddedSkip, AdminAddedWelcome, AdminAddedCheck:
+	default:
+		return fmt.Errorf("admin_added должен быть %q, %q или %q", AdminAddedSkip, AdminAddedWelcome, AdminAddedCheck)
+	}
+	for link, policy := range c.LinkPolicies {
+		switch policy {
+		case LinkTrusted, LinkHard, LinkBan:
+		default:
+			return fmt.Errorf("link_policies[%q] должен быть %q, %q или %q", link, LinkTrusted, LinkHard, LinkBan)
+		}
+	}
+	switch c.SpamWordsAction {
+	case "", SpamWordsDelete, SpamWordsRechallenge, SpamWordsBan:
+	default:
+		return fmt.Errorf("spam_words_action должен быть %q, %q или %q", SpamWordsDelete, SpamWordsRechallenge, SpamWordsBan)
+	}
+	if c.SpamWordsMessages < 0 || c.SpamWordsMessages > maxSpamWordsMessages {
+		return fmt.Errorf("spam_words_messages должен быть от 1 до %d", maxSpamWordsMessages)
+	}
+	if len(c.SpamWords) > maxSpamWords {
+		return fmt.Errorf("spam_words: не больше %d", maxSpamWords)
+	}
+	for _, w := range c.SpamWords {
+		if _, err := compileSpamWord(w); err != nil {
+			return fmt.Errorf("некорректное стоп-слово %q: %v", w, err)
+		}
+	}
+	if len(c.Blacklist) > maxBlacklist {
+		return fmt.Errorf("blacklist: не больше %d", maxBlacklist)
+	}
+	for _, id := range c.Blacklist {
+		if id <= 0 {
+			return fmt.Errorf("blacklist: некорректный ID пользователя %d", id)
+		}
+	}
+	if len(c.Moderators) > maxModerators {
+		return fmt.Errorf("moderators: не больше %d", maxModerators)
+	}
+	for _, id := range c.Moderators {
+		if id <= 0 {
+			return fmt.Errorf("moderators: некорректный ID пользователя %d", id)
+		}
+	}
+	for id, f := range c.Federations {
+		if f.Name == "" || len([]rune(f.Name)) > maxFederationName {
+			return fmt.Errorf("federations[%q]: название должно быть от 1 до %d символов", id, maxFederationName)
+		}
+		if len(f.Bans) > maxFederationBans {
+			return fmt.Errorf("federations[%q]: не больше %d банов", id, maxFederationBans)
+		}
+	}
+	if c.RateLimit < 0 || c.RateLimit > maxRateLimit {
+		return fmt.Errorf("rate_limit должен быть от 0 до %d", maxRateLimit)
+	}
+	if c.RateLimitMute < 0 || c.RateLimitMute > maxRateLimitMute {
+		return fmt.Errorf("rate_limit_mute должен быть от 1 до %d минут", maxRateLimitMute)
+	}
+	if c.ForwardFilter < ForwardFilterOff || c.ForwardFilter > maxForwardFilter {
+		return fmt.Errorf("forward_filter должен быть от 1 до %d минут или %d (выкл.)", maxForwardFilter, ForwardFilterOff)
+	}
+	if c.Night != nil {
+		if err := c.Night.validate(); err != nil {
+			return fmt.Errorf("night: %w", err)
+		}
+	}
+	if len([]rune(c.Rules)) > maxRulesLen {
+		return fmt.Errorf("rules длиннее %d символов", maxRulesLen)
+	}
+	for _, p := range c.NameFilters {
+		if _, err := compileNamePattern(p); err != nil {
+			return fmt.Errorf("некорректный шаблон %q: %v", p, err)
+		}
+	}
+	if _, ok := progressStyles.lookup(c.ProgressStyle); c.ProgressStyle != "" && !ok {
+		return fmt.Errorf("неизвестный progress_style %q, доступны: %s", c.ProgressStyle, strings.Join(ProgressStyleNames(), ", "))
+	}
+	if _, ok := lookupPhrasePack(c.PhrasePack); c.PhrasePack != "" && !ok {
+		return fmt.Errorf("неизвестный phrase_pack %q, доступны: %s", c.PhrasePack, strings.Join(PhrasePackNames(), ", "))
+	}
+	if len(c.Phrases) > 0 {
+		if err := (PhrasePack{Name: "phrases", Phrases: c.Phrases}).validate(); err != nil || len(c.Phrases) > maxChatPhrases {
+			return fmt.Errorf("phrases: до %d непустых фраз не длиннее %d символов", maxChatPhrases, maxPhraseLen)
+		}
+	}
+	for _, class := range append(append([]string(nil), c.Notify...), c.Protect...) {
+		if !slices.Contains(messageClasses, class) {
+			return fmt.Errorf("неизвестный вид сообщений %q, доступны: %s", class, strings.Join(messageClasses, ", "))
+		}
+	}
+	if m := c.WelcomeMedia; m != nil {
+		if _, ok := mediaMethods[m.Type]; !ok || m.FileID == "" {
+			return fmt.Errorf("welcome_media: тип должен быть %q, %q или %q и нужен file_id", MediaPhoto, MediaAnimation, MediaSticker)
+		}
+	}
+	if len([]rune(c.WelcomeTemplate)) > maxWelcomeTemplateLen {
+		return fmt.Errorf("welcome_template длиннее %d символов", maxWelcomeTemplateLen)
+	}
+	return nil
+}
+
+func (c ChatSettings) isZero() bool {
+	return c.Timeout == 0 && c.Action == "" && c.Language == "" && c.CaptchaType == "" &&
+		c.WelcomeTemplate == "" && !c.Disabled && len(c.NameFilters) == 0 && c.NameFilterAction == "" && !c.NoBroadcast &&
+		c.ChannelFilter == "" && !c.DeleteJoinMessages && !c.DeleteLeaveMessages &&
+		!c.KeepGreeting && !c.PlainText && c.ProgressStyle == "" && c.WelcomeMedia == nil && c.PhrasePack == "" && len(c.Phrases) == 0 && len(c.Notify) == 0 && len(c.Protect) == 0 && c.Rules == "" && c.RulesMode == "" && len(c.LinkPolicies) == 0 &&
+		c.AdminAdded == "" && c.Night == nil && c.RaidLock == nil && len(c.JoinQueue) == 0 &&
+		len(c.SpamWords) == 0 && c.SpamWordsAction == "" && c.SpamWordsMessages == 0 &&
+		c.RateLimit == 0 && c.RateLimitMute == 0 && !c.RateLimitAll && c.ForwardFilter == 0 && len(c.Blacklist) == 0 && len(c.Moderators) == 0 &&
+		c.Federation == "" && !c.FederationOptOut && len(c.Federations) == 0
+}
+
+func (c ChatSettings) clone() ChatSettings {
+	c.NameFilters = append([]string(nil), c.NameFilters...)
+	c.Phrases = append([]string(nil), c.Phrases...)
+	c.Notify = append([]string(nil), c.Notify...)
+	c.Protect = append([]string(nil), c.Protect...)
+	c.SpamWords = append([]string(nil), c.SpamWords...)
+	c.Blacklist = append([]int64(nil), c.Blacklist...)
+	c.Moderators = append([]int64(nil), c.Moderators...)
+	c.JoinQueue = append([]int64(nil), c.JoinQueue...)
+	if c.LinkPolicies != nil {
+		policies := make(map[string]string, len(c.LinkPolicies))
+		for k, v := range c.LinkPolicies {
+			policies[k] = v
+		}
+		c.LinkPolicies = policies
+	}
+	if c.Federations != nil {
+		feds := make(map[string]Federation, len(c.Federations))
+		for id, f := range c.Federations {
+			f.Bans = append([]int64(nil), f.Bans...)
+			feds[id] = f
+		}
+		c.Federations = feds
+	}
+	return c
+}
+
+// ==========================
+// Хранилище настроек
+// ==========================
+
+// settingsDocument — формат файла настроек: по документу ChatSettings на чат.
+type settingsDocument struct {
+	Version int                     `json:"version"`
+	Chats   map[int64]*ChatSettings `json:"chats"`
+}
+
+// Settings — потокобезопасное хранилище настроек всех групп.
+type Settings struct {
+	mu    sync.RWMutex
+	chats map[int64]*ChatSettings
+}
+
+// NewSettings создаёт пустое хранилище.
+func NewSettings() *Settings {
+	return &Settings{chats: make(map[int64]*ChatSettings)}
+}
+
+// Get возвращает копию настроек чата (пустые, если чат не настраивался).
+func (s *Settings) Get(chatID int64) ChatSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if c, ok := s.chats[chatID]; ok {
+		return c.clone()
+	}
+	return ChatSettings{}
+}
+
+// Update изменяет настройки чата под блокировкой. Чат без настроек удаляется из хранилища.
+func (s *Settings) Update(chatID int64, fn func(c *ChatSettings)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chats[chatID]
+	if !ok {
+		c = &ChatSettings{}
+	}
+	fn(c)
+	if c.isZero() {
+		delete(s.chats, chatID)
+		return
+	}
+	s.chats[chatID] = c
+}
+
+// Delete удаляет все настройки чата.
+func (s *Settings) Delete(chatID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.chats[chatID]
+	delete(s.chats, chatID)
+	return ok
+}
+
+// Move переносит настройки на новый ID чата (миграция в супергруппу).
+func (s *Settings) Move(from, to int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chats[from]
+	if !ok {
+		return false
+	}
+	s.chats[to] = c
+	delete(s.chats, from)
+	return true
+}
+
+// ChatIDs возвращает отсортированный список настроенных чатов.
+func (s *Settings) ChatIDs() []int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]int64, 0, len(s.chats))
+	for id := range s.chats {
+		if id == globalSettingsID {
+			continue // общие настройки владельцев, а не чат
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Snapshot возвращает копию настроек всех чатов.
+func (s *Settings) Snapshot() map[int64]*ChatSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[int64]*ChatSettings, len(s.chats))
+	for id, c := range s.chats {
+		cp := c.clone()
+		out[id] = &cp
+	}
+	return out
+}
+
+// Replace заменяет настройки всех чатов (загрузка из хранилища).
+func (s *Settings) Replace(chats map[int64]*ChatSettings) {
+	if chats == nil {
+		chats = make(map[int64]*ChatSettings)
+	}
+	s.mu.Lock()
+	s.chats = chats
+	s.mu.Unlock()
+}
+
+// Timeout возвращает таймаут группы или значение по умолчанию.
+func (s *Settings) Timeout(chatID int64) int {
+	return s.Get(chatID).TimeoutSec()
+}
+
+// SetTimeout задаёт таймаут группы с ограничением Min/Max.
+func (s *Settings) SetTimeout(chatID int64, seconds int) {
+	if seconds < MinTimeoutSec {
+		seconds = MinTimeoutSec
+	}
+	if seconds > MaxTimeoutSec {
+		seconds = MaxTimeoutSec
+	}
+	s.Update(chatID, func(c *ChatSettings) { c.Timeout = seconds })
+}
+
+// decodeSettings разбирает файл настроек любой известной версии.
+func decodeSettings(content []byte) (map[int64]*ChatSettings, int, error) {
+	var probe struct {
+		Version *int `json:"version"`
+	}
+	// у старого timeouts.json нет поля version, а ключи — ID чатов
+	if err := json.Unmarshal(content, &probe); err != nil || probe.Version == nil {
+		legacy := make(map[int64]int)
+		if err := json.Unmarshal(content, &legacy); err != nil {
+			return nil, 0, err
+		}
+		chats := make(map[int64]*ChatSettings, len(legacy))
+		for chatID, timeout := range legacy {
+			chats[chatID] = &ChatSettings{Timeout: timeout}
+		}
+		return chats, 0, nil
+	}
+
+	if *probe.Version > settingsSchemaVersion {
+		return nil, *probe.Version, fmt.Errorf("схема v%d новее поддерживаемой v%d", *probe.Version, settingsSchemaVersion)
+	}
+	var doc settingsDocument
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, *probe.Version, err
+	}
+	if doc.Chats == nil {
+		doc.Chats = make(map[int64]*ChatSettings)
+	}
+	return doc.Chats, doc.Version, nil
+}
+
+// ==========================
+// Перенос старых файлов
+// ==========================
+
+// MigrateLegacyFiles переносит данные из отдельных файлов прежних версий
+// (namefilters.json, disabled_chats.json) в настройки. Файлы не удаляются.
+func (s *Settings) MigrateLegacyFiles(nameFilterFile, disabledFile string, logger *Logger) bool {
+	migrated := false
+
+	var filters map[int64]struct {
+		Patterns []string `json:"patterns"`
+		Action   string   `json:"action"`
+	}
+	if readLegacyJSON(nameFilterFile, &filters, logger) {
+		for chatID, f := range filters {
+			f := f
+			s.Update(chatID, func(c *ChatSettings) {
+				if len(c.NameFilters) == 0 {
+					c.NameFilters = f.Patterns
+					c.NameFilterAction = f.Action
+				}
+			})
+		}
+		logger.Info("Перенесены фильтры имён %d чатов из %s", len(filters), nameFilterFile)
+		migrated = true
+	}
+
+	var disabled map[int64]bool
+	if readLegacyJSON(disabledFile, &disabled, logger) {
+		for chatID, off := range disabled {
+			if off {
+				s.Update(chatID, func(c *ChatSettings) { c.Disabled = true })
+			}
+		}
+		logger.Info("Перенесены выключенные чаты (%d) из %s", len(disabled), disabledFile)
+		migrated = true
+	}
+	return migrated
+}
+
+func readLegacyJSON(file string, v interface{}, logger *Logger) bool {
+	if file == "" {
+		return false
+	}
+	content, err := os.ReadFile(file)
+	if err != nil || len(content) == 0 {
+		return false
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		logger.Warn("Ошибка парсинга %s: %v", file, err)
+		return false
+	}
+	return true
+}
+
+// ==========================
+// Загрузка и сохранение из бота
+// ==========================
+
+// loadSettings загружает настройки из хранилища. Если их ещё не сохраняли,
+// переносит данные из файлов прежних версий.
+func (b *Bot) loadSettings() {
+	if b.storage == nil {
+		return
+	}
+	chats, err := b.storage.LoadSettings()
+	if err != nil {
+		b.logger.Warn("Не удалось загрузить настройки: %v", err)
+		return
+	}
+	if chats != nil {
+		b.settings.Replace(chats)
+		return
+	}
+	if b.importLegacySettings() {
+		b.writeSettings()
+	}
+}
+
+// importLegacySettings переносит settings.json (при переходе на другое хранилище),
+// timeouts.json, namefilters.json и disabled_chats.json. Уже заданные настройки не перезаписываются.
+func (b *Bot) importLegacySettings() bool {
+	files := []string{b.cfg.TimeoutFile}
+	if _, isFile := b.storage.(*fileStorage); !isFile {
+		files = append([]string{b.cfg.SettingsFile}, files...)
+	}
+
+	migrated := false
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil || len(content) == 0 {
+			continue
+		}
+		legacy := newFileStorage(file, b.logger)
+		if b.cfg.SettingsKey != "" {
+			legacy.sealer, _ = newSealer(b.cfg.SettingsKey)
+		}
+		chats, _, err := legacy.decode(content)
+		if err != nil {
+			b.logger.Warn("Ошибка парсинга %s: %v", file, err)
+			continue
+		}
+		for chatID, cs := range chats {
+			cs := cs.clone()
+			b.settings.Update(chatID, func(c *ChatSettings) {
+				if c.isZero() {
+					*c = cs
+				}
+			})
+		}
+		b.logger.Info("Перенесены настройки %d чатов из %s", len(chats), file)
+		migrated = true
+	}
+	if b.settings.MigrateLegacyFiles(b.cfg.NameFilterFile, b.cfg.DisabledChatsFile, b.logger) {
+		migrated = true
+	}
+	return migrated
+}
+
+// saveSettings сохраняет настройки в хранилище, если оно есть.
+// При запущенном боте запись откладывается, чтобы серия команд не переписывала файл каждый раз.
+func (b *Bot) saveSettings() {
+	if b.storage == nil {
+		return
+	}
+	if b.settingsSaver != nil {
+		b.settingsSaver.Trigger()
+		return
+	}
+	b.writeSettings()
+}
+
+func (b *Bot) writeSettings() {
+	if err := b.storage.SaveSettings(b.settings.Snapshot()); err != nil {
+		b.storageWarn("Не удалось сохранить настройки", err)
+	}
+}
+
+// FlushSettings немедленно записывает отложенные изменения настроек. Вызывается при остановке.
+func (b *Bot) FlushSettings() {
+	if b.settingsSaver != nil {
+		b.settingsSaver.Flush()
+	}
+}
+
+// chatSettings возвращает настройки чата.
+func (b *Bot) chatSettings(chatID int64) ChatSettings {
+	if b.settings == nil {
+		return ChatSettings{}
+	}
+	return b.settings.Get(chatID)
+}
+
+// updateChatSettings изменяет настройки чата и сохраняет их.
+func (b *Bot) updateChatSettings(chatID int64, fn func(c *ChatSettings)) {
+	b.settings.Update(chatID, fn)
+	b.saveSettings()
+}
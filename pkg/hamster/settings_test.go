@@ -0,0 +1,116 @@
+package hamster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSettingsTimeout(t *testing.T) {
+	s := NewSettings()
+
+	if got := s.Timeout(12345); got != DefaultTimeoutSec {
+		t.Errorf("ожидалось DefaultTimeoutSec %d, получили %d", DefaultTimeoutSec, got)
+	}
+
+	s.SetTimeout(12345, 42)
+	if got := s.Timeout(12345); got != 42 {
+		t.Errorf("ожидалось 42, получили %d", got)
+	}
+
+	s.SetTimeout(1, 1)
+	if got := s.Timeout(1); got != MinTimeoutSec {
+		t.Errorf("ожидалось MinTimeoutSec %d, получили %d", MinTimeoutSec, got)
+	}
+
+	s.SetTimeout(2, 1000)
+	if got := s.Timeout(2); got != MaxTimeoutSec {
+		t.Errorf("ожидалось MaxTimeoutSec %d, получили %d", MaxTimeoutSec, got)
+	}
+}
+
+func TestSettingsDefaults(t *testing.T) {
+	cs := NewSettings().Get(1)
+	if cs.FailAction() != ActionBan || cs.Captcha() != CaptchaButton || cs.Welcome() != DefaultWelcomeTemplate {
+		t.Errorf("неожиданные значения по умолчанию: %+v", cs)
+	}
+	if cs.NameAction() != NameFilterBan {
+		t.Errorf("ожидалось действие фильтра ban, получили %s", cs.NameAction())
+	}
+}
+
+func TestSettingsUpdateDropsEmpty(t *testing.T) {
+	s := NewSettings()
+	s.Update(10, func(c *ChatSettings) { c.Timeout = 123 })
+	if len(s.ChatIDs()) != 1 {
+		t.Fatal("настройки чата должны сохраниться")
+	}
+	s.Update(10, func(c *ChatSettings) { c.Timeout = 0 })
+	if len(s.ChatIDs()) != 0 {
+		t.Error("чат без настроек не должен храниться")
+	}
+}
+
+func TestSettingsGetReturnsCopy(t *testing.T) {
+	s := NewSettings()
+	_ = s.AddNameFilter(1, "spam")
+	cs := s.Get(1)
+	cs.NameFilters[0] = "changed"
+	if got := s.Get(1).NameFilters[0]; got != "spam" {
+		t.Errorf("Get должен возвращать копию, получили %q", got)
+	}
+}
+
+func TestLoadSettingsMigratesLegacyFiles(t *testing.T) {
+	dir := t.TempDir()
+	b := setupBot()
+	b.cfg = Config{
+		SettingsFile:      filepath.Join(dir, "settings.json"),
+		TimeoutFile:       filepath.Join(dir, "timeouts.json"),
+		NameFilterFile:    filepath.Join(dir, "namefilters.json"),
+		DisabledChatsFile: filepath.Join(dir, "disabled_chats.json"),
+	}
+	_ = os.WriteFile(b.cfg.TimeoutFile, []byte(`{"1": 45}`), 0644)
+	_ = os.WriteFile(b.cfg.NameFilterFile, []byte(`{"1": {"patterns": ["spam"], "action": "strict"}}`), 0644)
+	_ = os.WriteFile(b.cfg.DisabledChatsFile, []byte(`{"2": true}`), 0644)
+	b.storage = newFileStorage(b.cfg.SettingsFile, b.logger)
+
+	b.loadSettings()
+
+	cs := b.chatSettings(1)
+	if cs.Timeout != 45 || len(cs.NameFilters) != 1 || cs.NameAction() != NameFilterStrict {
+		t.Errorf("настройки чата 1 не перенесены: %+v", cs)
+	}
+	if !b.chatSettings(2).Disabled {
+		t.Error("выключенный чат не перенесён")
+	}
+	if _, err := os.Stat(b.cfg.SettingsFile); err != nil {
+		t.Fatalf("после миграции должен появиться %s: %v", b.cfg.SettingsFile, err)
+	}
+
+	// при следующем запуске читается уже новый файл
+	fresh := setupBot()
+	fresh.cfg = b.cfg
+	fresh.storage = newFileStorage(b.cfg.SettingsFile, fresh.logger)
+	fresh.loadSettings()
+	if got := fresh.settings.Timeout(1); got != 45 {
+		t.Errorf("ожидалось 45 из settings.json, получили %d", got)
+	}
+}
+
+func TestSettingsMove(t *testing.T) {
+	s := NewSettings()
+	s.SetTimeout(1, 77)
+	if !s.Move(1, 2) {
+		t.Fatal("Move должен вернуть true для существующих настроек")
+	}
+	if got := s.Timeout(2); got != 77 {
+		t.Errorf("ожидалось 77 на новом ID, получили %d", got)
+	}
+	if got := s.Timeout(1); got != DefaultTimeoutSec {
+		t.Errorf("старый ID должен вернуть значение по умолчанию, получили %d", got)
+	}
+	if s.Move(3, 4) {
+		t.Error("Move без данных должен вернуть false")
+	}
+}
@@ -0,0 +1,274 @@
+package hamster
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Стоп-слова в первых сообщениях новичков
+// ==========================
+
+const (
+	// SpamWordsDelete — сообщение со стоп-словом только удаляется (по умолчанию).
+	SpamWordsDelete = "delete"
+	// SpamWordsRechallenge — сообщение удаляется, автор проходит проверку заново.
+	SpamWordsRechallenge = "rechallenge"
+	// SpamWordsBan — сообщение удаляется, автор банится.
+	SpamWordsBan = "ban"
+)
+
+const (
+	defaultSpamWordsMessages = 3
+	maxSpamWordsMessages     = 50
+	maxSpamWords             = 100
+	// firstMessagesTTL — сколько после проверки следить за первыми сообщениями:
+	// кто молчит сутки, тот уже не «свежий» спамер.
+	firstMessagesTTL = 24 * time.Hour
+)
+
+// SpamWordsLimit возвращает, сколько первых сообщений новичка проверять.
+func (c ChatSettings) SpamWordsLimit() int {
+	if c.SpamWordsMessages <= 0 {
+		return defaultSpamWordsMessages
+	}
+	return c.SpamWordsMessages
+}
+
+// SpamAction возвращает действие при совпадении со стоп-словом.
+func (c ChatSettings) SpamAction() string {
+	if c.SpamWordsAction == "" {
+		return SpamWordsDelete
+	}
+	return c.SpamWordsAction
+}
+
+// compileSpamWord превращает стоп-слово в выражение: "/…/" — регулярное
+// выражение, иначе — подстрока. Регистр не важен.
+func compileSpamWord(word string) (*regexp.Regexp, error) {
+	if len(word) > 2 && strings.HasPrefix(word, "/") && strings.HasSuffix(word, "/") {
+		return regexp.Compile("(?i)" + word[1:len(word)-1])
+	}
+	return regexp.Compile("(?i)" + regexp.QuoteMeta(word))
+}
+
+// matchSpamWords возвращает первое стоп-слово, найденное в тексте.
+func matchSpamWords(words []string, text string) (string, bool) {
+	for _, w := range words {
+		re, err := compileSpamWord(w)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(text) {
+			return w, true
+		}
+	}
+	return "", false
+}
+
+// firstMessages считает, сколько сообщений новичков осталось проверить
+// на стоп-слова, по ключу "chatID:userID". Живёт только в памяти.
+type firstMessages struct {
+	mu sync.Mutex
+	m  map[string]firstMessagesLeft
+}
+
+type firstMessagesLeft struct {
+	left int
+	at   time.Time
+}
+
+// start начинает следить за n первыми сообщениями, попутно забывая тех,
+// за кем следят дольше firstMessagesTTL.
+func (f *firstMessages) start(chatID, userID int64, n int, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.m == nil {
+		f.m = make(map[string]firstMessagesLeft)
+	}
+	for k, e := range f.m {
+		if now.Sub(e.at) > firstMessagesTTL {
+			delete(f.m, k)
+		}
+	}
+	f.m[verifiedKey(chatID, userID)] = firstMessagesLeft{left: n, at: now}
+}
+
+// next учитывает сообщение и сообщает, нужно ли его проверять.
+func (f *firstMessages) next(chatID, userID int64, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := verifiedKey(chatID, userID)
+	e, ok := f.m[key]
+	if !ok {
+		return false
+	}
+	if now.Sub(e.at) > firstMessagesTTL {
+		delete(f.m, key)
+		return false
+	}
+	if e.left--; e.left <= 0 {
+		delete(f.m, key)
+	} else {
+		f.m[key] = e
+	}
+	return true
+}
+
+func (f *firstMessages) forget(chatID, userID int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.m, verifiedKey(chatID, userID))
+}
+
+// watchFirstMessages начинает проверку первых сообщений прошедшего проверку,
+// если в чате заданы стоп-слова.
+func (b *Bot) watchFirstMessages(chatID, userID int64) {
+	cs := b.chatSettings(chatID)
+	if len(cs.SpamWords) == 0 {
+		return
+	}
+	b.firstMessages.start(chatID, userID, cs.SpamWordsLimit(), time.Now())
+}
+
+// applySpamWords удаляет одно из первых сообщений новичка со стоп-словом и
+// поступает с автором по настройке чата. Возвращает true, если сообщение удалено.
+func (b *Bot) applySpamWords(msg *Message) bool {
+	if msg.From == nil || !b.firstMessages.next(msg.Chat.ID, msg.From.ID, time.Now()) {
+		return false
+	}
+	cs := b.chatSettings(msg.Chat.ID)
+	word, ok := matchSpamWords(cs.SpamWords, msg.Text+"\n"+msg.Caption)
+	if !ok {
+		return false
+	}
+	b.punishSpamWord(msg, cs, word)
+	return true
+}
+
+// punishSpamWord удаляет сообщение новичка со стоп-словом word и поступает
+// с автором по настройке чата.
+func (b *Bot) punishSpamWord(msg *Message, cs ChatSettings, word string) {
+	chatID, user := msg.Chat.ID, msg.From
+	b.logger.Info("Сообщение новичка %d в чате %d совпало со стоп-словом %q — %s", user.ID, chatID, word, cs.SpamAction())
+	b.safeDeleteMessage(b.ctx, chatID, msg.MessageID)
+	switch cs.SpamAction() {
+	case SpamWordsBan:
+		b.firstMessages.forget(chatID, user.ID)
+		b.safeBanUser(b.ctx, chatID, user.ID)
+		b.logBan(chatID, user.ID, BanReasonSpamWords)
+	case SpamWordsRechallenge:
+		b.firstMessages.forget(chatID, user.ID)
+		if b.pendingProgress(chatID, user.ID) == nil {
+			b.admitJoin(queuedJoin{msg: &Message{Chat: msg.Chat}, user: user, strict: true})
+		}
+	}
+}
+
+// ==========================
+// Команда /spamwords
+// ==========================
+
+var spamActionNames = map[string]string{
+	SpamWordsDelete:      "удалять сообщение",
+	SpamWordsRechallenge: "удалять и проверять заново",
+	SpamWordsBan:         "удалять и банить",
+}
+
+func (b *Bot) handleSpamWordsCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять стоп-слова", 5*time.Second)
+		return
+	}
+
+	const usage = "⚙️ Использование:\n/spamwords list\n/spamwords add <слово или /regex/>\n/spamwords remove <номер>\n" +
+		"/spamwords mode delete|rechallenge|ban\n/spamwords count <сколько первых сообщений проверять>"
+	parts := strings.Fields(msg.Text)
+	if len(parts) < 2 || parts[1] == "list" {
+		b.sendTemporary(chatID, formatSpamWords(b.chatSettings(chatID)), 30*time.Second)
+		return
+	}
+
+	switch parts[1] {
+	case "add":
+		word := commandArg(msg.Text, 2)
+		if word == "" {
+			b.sendTemporary(chatID, usage, 10*time.Second)
+			return
+		}
+		if _, err := compileSpamWord(word); err != nil {
+			b.sendTemporary(chatID, fmt.Sprintf("❌ Некорректное выражение: %v", err), 10*time.Second)
+			return
+		}
+		if len(b.chatSettings(chatID).SpamWords) >= maxSpamWords {
+			b.sendTemporary(chatID, fmt.Sprintf("❌ Не больше %d стоп-слов", maxSpamWords), 5*time.Second)
+			return
+		}
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.SpamWords = append(c.SpamWords, word) })
+		b.sendTemporary(chatID, fmt.Sprintf("✅ Стоп-слово добавлено: %s", word), 5*time.Second)
+	case "remove", "del":
+		idx := 0
+		if len(parts) > 2 {
+			idx, _ = strconv.Atoi(parts[2])
+		}
+		var removed string
+		b.updateChatSettings(chatID, func(c *ChatSettings) {
+			if idx < 1 || idx > len(c.SpamWords) {
+				return
+			}
+			removed = c.SpamWords[idx-1]
+			c.SpamWords = append(c.SpamWords[:idx-1], c.SpamWords[idx:]...)
+		})
+		if removed == "" {
+			b.sendTemporary(chatID, "⚙️ Укажите номер стоп-слова из /spamwords list", 5*time.Second)
+			return
+		}
+		b.sendTemporary(chatID, fmt.Sprintf("🗑 Стоп-слово удалено: %s", removed), 5*time.Second)
+	case "mode":
+		if len(parts) < 3 || spamActionNames[parts[2]] == "" {
+			b.sendTemporary(chatID, usage, 10*time.Second)
+			return
+		}
+		action := parts[2]
+		b.updateChatSettings(chatID, func(c *ChatSettings) {
+			c.SpamWordsAction = action
+			if action == SpamWordsDelete {
+				c.SpamWordsAction = ""
+			}
+		})
+		b.sendTemporary(chatID, "✅ При совпадении: "+spamActionNames[action], 5*time.Second)
+	case "count":
+		n := 0
+		if len(parts) > 2 {
+			n, _ = strconv.Atoi(parts[2])
+		}
+		if n < 1 || n > maxSpamWordsMessages {
+			b.sendTemporary(chatID, fmt.Sprintf("⚙️ Укажите от 1 до %d сообщений", maxSpamWordsMessages), 5*time.Second)
+			return
+		}
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.SpamWordsMessages = n })
+		b.sendTemporary(chatID, fmt.Sprintf("✅ Проверяются первые %d сообщений новичков", n), 5*time.Second)
+	default:
+		b.sendTemporary(chatID, usage, 10*time.Second)
+	}
+}
+
+func formatSpamWords(cs ChatSettings) string {
+	if len(cs.SpamWords) == 0 {
+		return "📭 Стоп-слов нет. Добавить: /spamwords add <слово или /regex/>"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🚫 Стоп-слова в первых %d сообщениях новичков (%s):\n", cs.SpamWordsLimit(), spamActionNames[cs.SpamAction()])
+	for i, w := range cs.SpamWords {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, w)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,119 @@
+package hamster
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMatchSpamWords(t *testing.T) {
+	words := []string{"крипта", `/зараб\S* от \d+/`}
+	if w, ok := matchSpamWords(words, "Лучшая КРИПТА тут"); !ok || w != "крипта" {
+		t.Errorf("подстрока без учёта регистра: %q %v", w, ok)
+	}
+	if w, ok := matchSpamWords(words, "Заработок от 500 в день"); !ok || w != words[1] {
+		t.Errorf("регулярное выражение в слэшах: %q %v", w, ok)
+	}
+	if _, ok := matchSpamWords([]string{"a.b"}, "axb"); ok {
+		t.Error("обычное слово — не регулярное выражение")
+	}
+}
+
+func TestFirstMessagesCountdown(t *testing.T) {
+	var f firstMessages
+	now := time.Now()
+	f.start(1, 2, 2, now)
+	if !f.next(1, 2, now) || !f.next(1, 2, now) || f.next(1, 2, now) {
+		t.Error("проверяются ровно два первых сообщения")
+	}
+	f.start(1, 2, 5, now)
+	if f.next(1, 2, now.Add(firstMessagesTTL+time.Minute)) {
+		t.Error("спустя сутки сообщения уже не проверяются")
+	}
+}
+
+func TestSpamWordsDeleteAndBan(t *testing.T) {
+	b := setupBot()
+	b.settings.Update(-100, func(c *ChatSettings) { c.SpamWords = []string{"казино"}; c.SpamWordsMessages = 2 })
+	var mu sync.Mutex
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { mu.Lock(); deleted = append(deleted, msgID); mu.Unlock() }
+	banned := false
+	fakeOf(b).ban = func(chatID, userID int64) { banned = true }
+	user := &User{ID: 42}
+	say := func(id int64, text string) bool {
+		return b.applySpamWords(&Message{MessageID: id, Chat: Chat{ID: -100}, From: user, Text: text})
+	}
+
+	if say(1, "лучшее казино") {
+		t.Error("до проверки сообщения не фильтруются")
+	}
+	b.markVerified(-100, 42)
+	if !say(2, "Лучшее КАЗИНО") || banned {
+		t.Error("по умолчанию сообщение только удаляется")
+	}
+	if !say(3, "казино") {
+		t.Error("второе сообщение ещё проверяется")
+	}
+	if say(4, "казино") {
+		t.Error("третье сообщение уже не проверяется")
+	}
+
+	b.settings.Update(-100, func(c *ChatSettings) { c.SpamWordsAction = SpamWordsBan })
+	b.markVerified(-100, 42)
+	if say(5, "привет") || !say(6, "казино") || !banned {
+		t.Error("в режиме ban автор совпавшего сообщения банится")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deleted) != 3 || deleted[0] != 2 || deleted[1] != 3 || deleted[2] != 6 {
+		t.Errorf("ожидали удаление сообщений 2, 3 и 6: %v", deleted)
+	}
+}
+
+func TestSpamWordsRechallenge(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.settings.Update(-100, func(c *ChatSettings) {
+		c.SpamWords = []string{"казино"}
+		c.SpamWordsAction = SpamWordsRechallenge
+	})
+	greeted := make(chan struct{}, 1)
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		greeted <- struct{}{}
+		return 10
+	}
+	b.markVerified(-100, 42)
+	if !b.applySpamWords(&Message{MessageID: 1, Chat: Chat{ID: -100}, From: &User{ID: 42, FirstName: "Аня"}, Text: "казино"}) {
+		t.Fatal("сообщение должно удаляться")
+	}
+	select {
+	case <-greeted:
+	case <-time.After(time.Second):
+		t.Fatal("автор должен получить проверку заново")
+	}
+}
+
+func TestSpamWordsCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)}}
+	cmd := func(text string) {
+		b.handleSpamWordsCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: text})
+	}
+	cmd("/spamwords add казино")
+	cmd("/spamwords add /(bad/")
+	cmd("/spamwords add /крипт[ао]/")
+	cmd("/spamwords mode ban")
+	cmd("/spamwords count 5")
+	cs := b.chatSettings(-100)
+	if len(cs.SpamWords) != 2 || cs.SpamAction() != SpamWordsBan || cs.SpamWordsLimit() != 5 {
+		t.Fatalf("настройки стоп-слов: %+v", cs)
+	}
+	cmd("/spamwords remove 1")
+	if got := b.chatSettings(-100).SpamWords; len(got) != 1 || got[0] != "/крипт[ао]/" {
+		t.Errorf("после удаления осталось %v", got)
+	}
+	if err := (ChatSettings{SpamWords: []string{"/(/"}}).Validate(); err == nil {
+		t.Error("некорректное выражение должно отклоняться")
+	}
+}
@@ -0,0 +1,162 @@
+package hamster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Удаление заброшенных чатов
+// ==========================
+
+const (
+	// staleChatInterval — как часто искать заброшенные чаты.
+	staleChatInterval = 24 * time.Hour
+	// staleChatProbeAfter — через сколько тишины спрашивать getChat, остался ли
+	// бот в чате: у активных чатов это лишние запросы.
+	staleChatProbeAfter = 24 * time.Hour
+	// seenPersistEvery — как часто сохранять отметку Seen без других изменений.
+	seenPersistEvery = time.Hour
+)
+
+// updateChatID возвращает чат обновления; 0 — у обновления нет чата.
+func updateChatID(u Update) int64 {
+	switch {
+	case u.Message != nil:
+		return u.Message.Chat.ID
+	case u.EditedMessage != nil:
+		return u.EditedMessage.Chat.ID
+	case u.Callback != nil && u.Callback.Message != nil:
+		return u.Callback.Message.Chat.ID
+	case u.MyChatMember != nil:
+		return u.MyChatMember.Chat.ID
+	case u.ChatMember != nil:
+		return u.ChatMember.Chat.ID
+	}
+	return 0
+}
+
+// touch отмечает, что из чата пришло обновление, и сообщает, стоит ли
+// сохранить состояние: отметка сдвинулась больше чем на seenPersistEvery.
+func (s *Stats) touch(chatID int64, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chats[chatID]
+	if !ok {
+		c = &ChatStats{}
+		s.chats[chatID] = c
+	}
+	persist := now.Sub(c.Seen) >= seenPersistEvery
+	c.Seen = now
+	return persist
+}
+
+// markChatSeen запоминает время последнего обновления из группы. Личные
+// переписки с ботом не учитываются: настроек и статистики у них нет.
+func (b *Bot) markChatSeen(u Update) {
+	chatID := updateChatID(u)
+	if b.stats == nil || chatID >= 0 {
+		return
+	}
+	if b.stats.touch(chatID, time.Now()) {
+		b.saveState()
+	}
+}
+
+// lastSeen — когда из чата последний раз приходило обновление. Для
+// статистики прежних версий без Seen — время последнего события.
+func (c ChatStats) lastSeen() time.Time {
+	if c.Seen.After(c.Updated) {
+		return c.Seen
+	}
+	return c.Updated
+}
+
+// isChatGone сообщает, что getChat подтвердил: бота в чате нет или чата больше нет.
+func isChatGone(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	desc := strings.ToLower(apiErr.Description)
+	return apiErr.Code == 403 || apiErr.Code == 400 && strings.Contains(desc, "chat not found")
+}
+
+// CollectStaleChats удаляет настройки и статистику чатов, откуда не было
+// обновлений дольше StaleChatAge, и чатов, где getChat подтвердил, что бота
+// там больше нет. Возвращает число удалённых чатов.
+func (b *Bot) CollectStaleChats(ctx context.Context, now time.Time) int {
+	ids := make(map[int64]struct{})
+	if b.settings != nil {
+		for _, id := range b.settings.ChatIDs() {
+			ids[id] = struct{}{}
+		}
+	}
+	var stats map[int64]ChatStats
+	if b.stats != nil {
+		stats = b.stats.Snapshot()
+		for id := range stats {
+			ids[id] = struct{}{}
+		}
+	}
+
+	removed, touched := 0, false
+	for id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		seen := stats[id].lastSeen()
+		if seen.IsZero() {
+			// чат известен только по настройкам: отсчитываем срок с этой минуты
+			if b.stats != nil {
+				b.stats.touch(id, now)
+				touched = true
+			}
+			continue
+		}
+		quiet := now.Sub(seen)
+		reason := ""
+		switch {
+		case quiet > b.cfg.StaleChatAge:
+			reason = fmt.Sprintf("нет обновлений %d дн.", int(quiet/(24*time.Hour)))
+		case quiet > staleChatProbeAfter:
+			if _, err := b.api.GetChat(ctx, strconv.FormatInt(id, 10)); isChatGone(err) {
+				reason = "getChat: " + err.Error()
+			}
+		}
+		if reason == "" {
+			continue
+		}
+		b.logger.Info("Чат %d заброшен (%s) — удаляем настройки и статистику", id, reason)
+		b.forgetChat(id)
+		removed++
+	}
+	if touched {
+		b.saveState()
+	}
+	return removed
+}
+
+// RunStaleChatGC раз в staleChatInterval удаляет заброшенные чаты. Без
+// StaleChatAge ничего не делает.
+func (b *Bot) RunStaleChatGC(ctx context.Context) {
+	if b.cfg.StaleChatAge <= 0 {
+		return
+	}
+	ticker := time.NewTicker(staleChatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if n := b.CollectStaleChats(ctx, now); n > 0 {
+				b.logger.Info("Удалено заброшенных чатов: %d", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package hamster
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMarkChatSeen(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+
+	b.markChatSeen(Update{Message: &Message{Chat: Chat{ID: -100}}})
+	b.markChatSeen(Update{Callback: &Callback{Message: &Message{Chat: Chat{ID: -200}}}})
+	b.markChatSeen(Update{Message: &Message{Chat: Chat{ID: 5, Type: "private"}}})
+
+	if b.stats.Get(-100).Seen.IsZero() || b.stats.Get(-200).Seen.IsZero() {
+		t.Error("обновления из групп должны отмечаться")
+	}
+	if _, ok := b.stats.Snapshot()[5]; ok {
+		t.Error("личные переписки не должны попадать в статистику")
+	}
+}
+
+func TestCollectStaleChats(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.verified = newVerifiedUsers()
+	b.cfg.StaleChatAge = 30 * 24 * time.Hour
+	now := time.Now()
+
+	b.settings.SetTimeout(-100, 42) // давно молчит
+	b.settings.SetTimeout(-200, 42) // бота удалили, пока он был выключен
+	b.settings.SetTimeout(-300, 42) // живой тихий чат
+	b.settings.SetTimeout(-400, 42) // активный
+	b.settings.SetTimeout(-500, 42) // только настройки, без отметок
+	b.stats.Replace(map[int64]ChatStats{
+		-100: {Joins: 3, Updated: now.Add(-40 * 24 * time.Hour)},
+		-200: {Seen: now.Add(-3 * 24 * time.Hour)},
+		-300: {Seen: now.Add(-3 * 24 * time.Hour)},
+		-400: {Seen: now.Add(-time.Minute)},
+	})
+	probed := map[string]bool{}
+	fakeOf(b).getChat = func(chatRef string) (Chat, error) {
+		probed[chatRef] = true
+		if chatRef == "-200" {
+			return Chat{}, &APIError{Method: "getChat", Code: 403, Description: "Forbidden: bot was kicked from the supergroup chat"}
+		}
+		return Chat{ID: -300, Type: "supergroup"}, nil
+	}
+
+	if n := b.CollectStaleChats(context.Background(), now); n != 2 {
+		t.Errorf("ожидали 2 удалённых чата, получили %d", n)
+	}
+	for _, id := range []int64{-100, -200} {
+		if b.chatSettings(id).Timeout != 0 {
+			t.Errorf("настройки чата %d должны быть удалены", id)
+		}
+		if _, ok := b.stats.Snapshot()[id]; ok {
+			t.Errorf("статистика чата %d должна быть удалена", id)
+		}
+	}
+	for _, id := range []int64{-300, -400, -500} {
+		if b.chatSettings(id).Timeout != 42 {
+			t.Errorf("настройки чата %d должны остаться", id)
+		}
+	}
+	if probed["-400"] || probed["-100"] {
+		t.Errorf("getChat нужен только для тихих чатов в пределах срока: %v", probed)
+	}
+	if b.stats.Get(-500).Seen.IsZero() {
+		t.Error("для чата без отметок срок должен начинаться с проверки")
+	}
+}
+
+func TestIsChatGone(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&APIError{Code: 403, Description: "Forbidden: bot was kicked from the group chat"}, true},
+		{&APIError{Code: 400, Description: "Bad Request: chat not found"}, true},
+		{&APIError{Code: 429, Description: "Too Many Requests"}, false},
+		{context.DeadlineExceeded, false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := isChatGone(c.err); got != c.want {
+			t.Errorf("isChatGone(%v) = %v, ожидали %v", c.err, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package hamster
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Статистика проверок по чатам
+// ==========================
+
+// ChatStats — счётчики проверок в одном чате.
+type ChatStats struct {
+	Joins  int64 `json:"joins"`  // новых участников (без ботов)
+	Passed int64 `json:"passed"` // нажали кнопку
+	Failed int64 `json:"failed"` // не успели — забанены или исключены
+	Banned int64 `json:"banned"` // забанены без проверки (фильтр имён, оценка)
+
+	Clicks     int64 `json:"clicks"`       // нажатий своей кнопки, по которым измерена задержка
+	ClickMsSum int64 `json:"click_ms_sum"` // сумма задержек от приветствия до нажатия, мс
+	TooFast    int64 `json:"too_fast"`     // провалены из-за слишком быстрого нажатия
+
+	// ClickHist — число нажатий по интервалам задержки clickBuckets.
+	ClickHist [clickBucketCount]int64 `json:"click_hist"`
+	// ClickHistMsSum — сумма задержек нажатий из ClickHist, мс. В отличие от
+	// ClickMsSum, без слишком быстрых нажатий (нужна для _sum в Prometheus).
+	ClickHistMsSum int64 `json:"click_hist_ms_sum,omitempty"`
+
+	// Links — счётчики по ссылкам-приглашениям (ключ — имя или сама ссылка).
+	Links map[string]LinkStats `json:"links,omitempty"`
+
+	// Updated — когда счётчики последний раз менялись (для STATS_DAYS).
+	Updated time.Time `json:"updated,omitzero"`
+	// Seen — когда из чата последний раз пришло обновление (для STALE_CHAT_DAYS).
+	Seen time.Time `json:"seen,omitzero"`
+
+	// Series — приращения Joins, Passed, Failed и Banned по часам за
+	// последние seriesHours, по возрастанию времени.
+	Series []StatsBucket `json:"series,omitempty"`
+}
+
+// clickBuckets — верхние границы интервалов гистограммы задержек; последний
+// интервал открытый — всё, что дольше.
+var clickBuckets = [...]time.Duration{
+	1 * time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second, 8 * time.Second,
+	13 * time.Second, 20 * time.Second, 30 * time.Second, 45 * time.Second, 60 * time.Second,
+	90 * time.Second, 120 * time.Second, 180 * time.Second, 300 * time.Second, 600 * time.Second,
+}
+
+const clickBucketCount = len(clickBuckets) + 1
+
+// addClick учитывает задержку нажатия в гистограмме.
+func (c *ChatStats) addClick(latency time.Duration) {
+	i := 0
+	for i < len(clickBuckets) && latency > clickBuckets[i] {
+		i++
+	}
+	c.ClickHist[i]++
+	c.ClickHistMsSum += latency.Milliseconds()
+}
+
+// ClickPercentile оценивает p-й перцентиль задержки по гистограмме — верхней
+// границей интервала. Для открытого интервала и пустой гистограммы возвращает 0, false.
+func (c ChatStats) ClickPercentile(p float64) (time.Duration, bool) {
+	total := c.clickSamples()
+	if total == 0 {
+		return 0, false
+	}
+	need := int64(math.Ceil(float64(total)*p - 1e-9))
+	var seen int64
+	for i, n := range c.ClickHist {
+		seen += n
+		if seen >= need {
+			if i == len(clickBuckets) {
+				return 0, false
+			}
+			return clickBuckets[i], true
+		}
+	}
+	return 0, false
+}
+
+// clickSamples — сколько нажатий попало в гистограмму.
+func (c ChatStats) clickSamples() int64 {
+	var total int64
+	for _, n := range c.ClickHist {
+		total += n
+	}
+	return total
+}
+
+// AvgClick возвращает среднюю задержку от приветствия до нажатия кнопки.
+func (c ChatStats) AvgClick() time.Duration {
+	if c.Clicks == 0 {
+		return 0
+	}
+	return time.Duration(c.ClickMsSum/c.Clicks) * time.Millisecond
+}
+
+// merge складывает счётчики.
+func (c *ChatStats) merge(o ChatStats) {
+	c.Joins += o.Joins
+	c.Passed += o.Passed
+	c.Failed += o.Failed
+	c.Banned += o.Banned
+	c.Clicks += o.Clicks
+	c.ClickMsSum += o.ClickMsSum
+	c.TooFast += o.TooFast
+	for i, n := range o.ClickHist {
+		c.ClickHist[i] += n
+	}
+	c.ClickHistMsSum += o.ClickHistMsSum
+	for k, l := range o.Links {
+		if c.Links == nil {
+			c.Links = make(map[string]LinkStats)
+		}
+		sum := c.Links[k]
+		sum.Joins += l.Joins
+		sum.Passed += l.Passed
+		sum.Failed += l.Failed
+		sum.Banned += l.Banned
+		c.Links[k] = sum
+	}
+	if o.Updated.After(c.Updated) {
+		c.Updated = o.Updated
+	}
+	if o.Seen.After(c.Seen) {
+		c.Seen = o.Seen
+	}
+	if len(o.Series) > 0 {
+		c.Series = mergeSeries(c.Series, o.Series)
+	}
+}
+
+// clone возвращает копию счётчиков, не разделяющую с ними Links и Series.
+func (c ChatStats) clone() ChatStats {
+	if c.Series != nil {
+		c.Series = append([]StatsBucket(nil), c.Series...)
+	}
+	if c.Links != nil {
+		links := make(map[string]LinkStats, len(c.Links))
+		for k, l := range c.Links {
+			links[k] = l
+		}
+		c.Links = links
+	}
+	return c
+}
+
+// Stats — потокобезопасные счётчики всех чатов.
+type Stats struct {
+	mu    sync.Mutex
+	chats map[int64]*ChatStats
+}
+
+// NewStats создаёт пустую статистику.
+func NewStats() *Stats {
+	return &Stats{chats: make(map[int64]*ChatStats)}
+}
+
+// Get возвращает счётчики чата.
+func (s *Stats) Get(chatID int64) ChatStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.chats[chatID]; ok {
+		return c.clone()
+	}
+	return ChatStats{}
+}
+
+func (s *Stats) add(chatID int64, fn func(c *ChatStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chats[chatID]
+	if !ok {
+		c = &ChatStats{}
+		s.chats[chatID] = c
+	}
+	before := StatsBucket{Joins: c.Joins, Passed: c.Passed, Failed: c.Failed, Banned: c.Banned}
+	fn(c)
+	now := time.Now()
+	c.Updated = now
+	c.recordSeries(StatsBucket{
+		Joins:  c.Joins - before.Joins,
+		Passed: c.Passed - before.Passed,
+		Failed: c.Failed - before.Failed,
+		Banned: c.Banned - before.Banned,
+	}, now)
+}
+
+// Total возвращает сумму счётчиков всех чатов.
+func (s *Stats) Total() ChatStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total ChatStats
+	for _, c := range s.chats {
+		total.merge(*c)
+	}
+	return total
+}
+
+// Delete удаляет счётчики чата.
+func (s *Stats) Delete(chatID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.chats, chatID)
+}
+
+// Move переносит счётчики на новый ID чата, складывая с уже накопленными.
+func (s *Stats) Move(from, to int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chats[from]
+	if !ok {
+		return
+	}
+	if dst, ok := s.chats[to]; ok {
+		dst.merge(*c)
+	} else {
+		s.chats[to] = c
+	}
+	delete(s.chats, from)
+}
+
+// Snapshot возвращает копию счётчиков всех чатов.
+func (s *Stats) Snapshot() map[int64]ChatStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[int64]ChatStats, len(s.chats))
+	for id, c := range s.chats {
+		out[id] = c.clone()
+	}
+	return out
+}
+
+// Replace заменяет все счётчики (загрузка из хранилища).
+func (s *Stats) Replace(stats map[int64]ChatStats) {
+	chats := make(map[int64]*ChatStats, len(stats))
+	for id, c := range stats {
+		c := c.clone()
+		chats[id] = &c
+	}
+	s.mu.Lock()
+	s.chats = chats
+	s.mu.Unlock()
+}
+
+// recordStat увеличивает счётчик чата и планирует сохранение состояния.
+func (b *Bot) recordStat(chatID int64, fn func(c *ChatStats)) {
+	if b.stats == nil {
+		return
+	}
+	b.stats.add(chatID, fn)
+	b.saveState()
+}
+
+// statsFor возвращает счётчики чата; без статистики — нулевые.
+func (b *Bot) statsFor(chatID int64) ChatStats {
+	if b.stats == nil {
+		return ChatStats{}
+	}
+	return b.stats.Get(chatID)
+}
+
+// ==========================
+// Команда /stats
+// ==========================
+
+func (b *Bot) handleStatsCommand(msg *Message) {
+	if msg.From == nil || msg.Chat.Type == "private" {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может смотреть статистику", 5*time.Second)
+		return
+	}
+	text := "📊 Статистика чата\n" + formatStats(b.statsFor(chatID))
+	if b.stats != nil {
+		if advice := formatTimeoutAdvice(b.stats.Get(chatID), b.stats.Total(), b.chatSettings(chatID).TimeoutSec()); advice != "" {
+			text += "\n" + advice
+		}
+	}
+	b.sendTemporary(chatID, text, time.Minute)
+}
+
+// formatStats описывает счётчики чата.
+func formatStats(st ChatStats) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Новых участников: %d\nПрошли проверку: %d\nНе прошли: %d\nЗабанены без проверки: %d\n",
+		st.Joins, st.Passed, st.Failed, st.Banned)
+	if st.Clicks > 0 {
+		fmt.Fprintf(&sb, "⏱ Среднее время до нажатия: %.1f сек. (%d нажатий)\n", st.AvgClick().Seconds(), st.Clicks)
+	}
+	if dist := formatClickDistribution(st); dist != "" {
+		sb.WriteString(dist + "\n")
+	}
+	if st.TooFast > 0 {
+		fmt.Fprintf(&sb, "🤖 Слишком быстрых нажатий: %d\n", st.TooFast)
+	}
+	if links := formatLinkStats(st.Links); links != "" {
+		sb.WriteString(links + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
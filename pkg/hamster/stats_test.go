@@ -0,0 +1,76 @@
+package hamster
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsMoveMerges(t *testing.T) {
+	s := NewStats()
+	s.add(-1, func(c *ChatStats) { c.Joins = 2; c.Passed = 1 })
+	s.add(-1001, func(c *ChatStats) { c.Joins = 1 })
+
+	s.Move(-1, -1001)
+	if got := s.Get(-1001); got.Joins != 3 || got.Passed != 1 {
+		t.Errorf("счётчики должны сложиться, получили %+v", got)
+	}
+	if got := s.Get(-1); !reflect.DeepEqual(got, ChatStats{}) {
+		t.Errorf("старый чат должен быть пуст, получили %+v", got)
+	}
+}
+
+func TestJoinRecordsStats(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { return 10 }
+
+	b.handleJoinMessage(&Message{Chat: Chat{ID: 1}, NewChatMembers: []*User{{ID: 5, FirstName: "Иван", Username: "ivan"}}})
+	if got := b.stats.Get(1).Joins; got != 1 {
+		t.Errorf("ожидался один вход, получили %d", got)
+	}
+}
+
+func TestFormatStatsLatency(t *testing.T) {
+	text := formatStats(ChatStats{Joins: 4, Passed: 3, Clicks: 2, ClickMsSum: 5000, TooFast: 1})
+	for _, want := range []string{"Прошли проверку: 3", "2.5 сек.", "Слишком быстрых нажатий: 1"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("нет %q в %q", want, text)
+		}
+	}
+	if strings.Contains(formatStats(ChatStats{}), "нажатий") {
+		t.Error("без нажатий строки о задержке не нужны")
+	}
+}
+
+func TestStatsMoveMergesLatency(t *testing.T) {
+	s := NewStats()
+	s.add(1, func(c *ChatStats) { c.Clicks, c.ClickMsSum, c.TooFast = 1, 100, 1 })
+	s.add(2, func(c *ChatStats) { c.Clicks, c.ClickMsSum = 1, 300 })
+	s.Move(1, 2)
+	if st := s.Get(2); st.Clicks != 2 || st.ClickMsSum != 400 || st.TooFast != 1 {
+		t.Errorf("счётчики задержки не сложились: %+v", st)
+	}
+}
+
+func TestClickPercentile(t *testing.T) {
+	var st ChatStats
+	if _, ok := st.ClickPercentile(0.95); ok {
+		t.Error("пустая гистограмма не даёт перцентиля")
+	}
+	for _, ms := range []int{500, 1500, 1500, 2500, 4000, 4000, 4000, 4000, 4000, 70000} {
+		st.addClick(time.Duration(ms) * time.Millisecond)
+	}
+	if p, ok := st.ClickPercentile(0.5); !ok || p != 5*time.Second {
+		t.Errorf("медиана: %v, %v", p, ok)
+	}
+	if p, ok := st.ClickPercentile(0.95); !ok || p != 90*time.Second {
+		t.Errorf("p95: %v, %v", p, ok)
+	}
+	st.addClick(time.Hour)
+	st.addClick(time.Hour)
+	if _, ok := st.ClickPercentile(0.95); ok {
+		t.Error("перцентиль в открытом интервале неизвестен")
+	}
+}
@@ -0,0 +1,614 @@
+package hamster
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Хранилище состояния
+// ==========================
+
+const (
+	// StorageFile — настройки в JSON-файле, остальное состояние только в памяти.
+	StorageFile = "file"
+	// StorageBolt — всё состояние во встроенной базе bbolt.
+	StorageBolt = "bolt"
+	// StoragePostgres — всё состояние в PostgreSQL по STORAGE_DSN.
+	StoragePostgres = "postgres"
+)
+
+// Storage — постоянное хранилище настроек, верификаций и статистики.
+// Load-методы возвращают nil без ошибки, если данных ещё не сохраняли.
+type Storage interface {
+	LoadSettings() (map[int64]*ChatSettings, error)
+	SaveSettings(chats map[int64]*ChatSettings) error
+
+	// LoadVerified и SaveVerified работают с ключами "chatID:userID".
+	LoadVerified() (map[string]time.Time, error)
+	SaveVerified(verified map[string]time.Time) error
+
+	LoadStats() (map[int64]ChatStats, error)
+	SaveStats(stats map[int64]ChatStats) error
+
+	// LoadSentMessages и SaveSentMessages — неудалённые сообщения бота по чатам.
+	LoadSentMessages() (map[int64][]SentMessage, error)
+	SaveSentMessages(sent map[int64][]SentMessage) error
+
+	// LoadUpdateOffset и SaveUpdateOffset — смещение getUpdates; нулевое — не сохранялось.
+	LoadUpdateOffset() (UpdateOffset, error)
+	SaveUpdateOffset(offset UpdateOffset) error
+
+	// LogBan дописывает запись в журнал банов.
+	LogBan(entry BanLogEntry) error
+	// BanLog возвращает записи журнала банов по запросу, новые первыми.
+	BanLog(q BanLogQuery) ([]BanLogEntry, error)
+	// ForgetUser удаляет баны пользователя из журнала и возвращает их число.
+	ForgetUser(userID int64) (int, error)
+	// PruneBanLog удаляет записи журнала банов старше before и возвращает их число.
+	PruneBanLog(before time.Time) (int, error)
+
+	Close() error
+}
+
+// BanLogEntry — запись журнала банов.
+type BanLogEntry struct {
+	ChatID int64     `json:"chat_id"`
+	UserID int64     `json:"user_id"`
+	Reason string    `json:"reason"`       // BanReasonTimeout, BanReasonNameFilter, ...
+	By     int64     `json:"by,omitempty"` // администратор, забанивший вручную; 0 — бот
+	At     time.Time `json:"at"`
+}
+
+// BanLogQuery — отбор записей журнала банов.
+type BanLogQuery struct {
+	ChatID int64
+	UserID int64  // 0 — все пользователи
+	Reason string // "" — все причины
+	Limit  int    // 0 — без ограничения
+}
+
+func (q BanLogQuery) match(e BanLogEntry) bool {
+	return e.ChatID == q.ChatID && (q.UserID == 0 || e.UserID == q.UserID) && (q.Reason == "" || e.Reason == q.Reason)
+}
+
+// full сообщает, что записей набрано сколько просили.
+func (q BanLogQuery) full(n int) bool {
+	return q.Limit > 0 && n >= q.Limit
+}
+
+// Причины банов для журнала.
+const (
+	BanReasonTimeout     = "timeout"
+	BanReasonWrongAnswer = "wrong_answer"
+	BanReasonTooFast     = "too_fast"
+	BanReasonNameFilter  = "namefilter"
+	BanReasonScore       = "score"
+	BanReasonChannel     = "channel" // user_id — ID канала
+	BanReasonInviteLink  = "invite_link"
+	BanReasonSpamWords   = "spam_words"
+	BanReasonBlacklist   = "blacklist"
+	BanReasonFederation  = "federation" // по списку федерации или /fban
+	BanReasonAdmin       = "admin"      // забанен администратором, а не ботом
+)
+
+// banReasonNames — причины банов для сообщений в чате.
+var banReasonNames = map[string]string{
+	BanReasonTimeout:     "не прошёл проверку вовремя",
+	BanReasonWrongAnswer: "неверный ответ",
+	BanReasonTooFast:     "слишком быстрое нажатие",
+	BanReasonNameFilter:  "фильтр имён",
+	BanReasonScore:       "подозрительный аккаунт",
+	BanReasonChannel:     "сообщение от имени канала",
+	BanReasonInviteLink:  "ссылка-приглашение",
+	BanReasonSpamWords:   "стоп-слово",
+	BanReasonBlacklist:   "чёрный список",
+	BanReasonFederation:  "бан федерации",
+	BanReasonAdmin:       "бан администратором",
+}
+
+// banReasonName возвращает причину бана по-русски, неизвестную — как есть.
+func banReasonName(reason string) string {
+	if name, ok := banReasonNames[reason]; ok {
+		return name
+	}
+	return reason
+}
+
+// OpenStorage открывает хранилище, выбранное в cfg.Storage.
+func OpenStorage(cfg Config, logger *Logger) (Storage, error) {
+	switch cfg.Storage {
+	case "", StorageFile:
+		fs := newFileStorage(cfg.SettingsFile, logger)
+		if cfg.SettingsFile != "" {
+			fs.sentFile = filepath.Join(filepath.Dir(cfg.SettingsFile), "sent_messages.json")
+			fs.offsetFile = filepath.Join(filepath.Dir(cfg.SettingsFile), "update_offset.json")
+			fs.banFile = filepath.Join(filepath.Dir(cfg.SettingsFile), "ban_log.jsonl")
+		}
+		if cfg.SettingsKey != "" {
+			sl, err := newSealer(cfg.SettingsKey)
+			if err != nil {
+				return nil, fmt.Errorf("SETTINGS_KEY: %w", err)
+			}
+			fs.sealer = sl
+			logger.Info("🔒 Файл настроек %s и журнал банов шифруются", cfg.SettingsFile)
+		}
+		return fs, nil
+	case StorageBolt:
+		return openBoltStorage(cfg.BoltFile, logger)
+	case StoragePostgres:
+		return openPostgresStorage(cfg.StorageDSN, logger)
+	default:
+		return nil, fmt.Errorf("неизвестное хранилище %q", cfg.Storage)
+	}
+}
+
+// ==========================
+// JSON-файл
+// ==========================
+
+// stateFilePerm — права файла настроек и файлов состояния рядом с ним: в
+// чёрных списках, журнале банов и списке сообщений бота есть ID пользователей.
+const stateFilePerm = 0600
+
+// fileStorage хранит настройки в settings.json. Верификации и статистика в файл не пишутся,
+// а сообщения бота и смещение getUpdates — пишутся в файлы рядом, чтобы
+// после сбоя убрать сообщения и не обработать обновления повторно. Журнал
+// банов дописывается построчно в ban_log.jsonl. При заданном ключе шифруются
+// настройки и каждая строка журнала банов; сообщения бота и смещение
+// хранятся открытыми, но доступны только владельцу.
+type fileStorage struct {
+	file       string
+	sentFile   string // пустой — сообщения бота не сохраняются
+	offsetFile string // пустой — смещение не сохраняется
+	banFile    string // пустой — журнал банов не ведётся
+	logger     *Logger
+	sealer     *sealer // nil — настройки и журнал банов хранятся открытым текстом
+
+	mu  sync.Mutex
+	sum [sha256.Size]byte // контрольная сумма последнего прочитанного или записанного содержимого
+}
+
+func newFileStorage(file string, logger *Logger) *fileStorage {
+	return &fileStorage{file: file, logger: logger}
+}
+
+func (f *fileStorage) LoadSettings() (map[int64]*ChatSettings, error) {
+	if f.file == "" {
+		return nil, nil
+	}
+	chats, version, err := f.read()
+	if err != nil || chats == nil {
+		return chats, err
+	}
+	if version < settingsSchemaVersion {
+		f.logger.Info("Настройки %s мигрированы со схемы v%d на v%d", f.file, version, settingsSchemaVersion)
+	}
+	f.logger.Info("Загружены настройки %d чатов из %s", len(chats), f.file)
+	return chats, nil
+}
+
+// read разбирает файл настроек и запоминает его контрольную сумму.
+func (f *fileStorage) read() (map[int64]*ChatSettings, int, error) {
+	content, err := os.ReadFile(f.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			f.logger.Info("Файл %s не найден, используем пустые настройки", f.file)
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	if len(content) == 0 {
+		return nil, 0, nil
+	}
+	chats, version, err := f.decode(content)
+	if err != nil {
+		return nil, version, fmt.Errorf("ошибка парсинга %s: %w", f.file, err)
+	}
+	f.mu.Lock()
+	f.sum = sha256.Sum256(content)
+	f.mu.Unlock()
+	return chats, version, nil
+}
+
+// decode расшифровывает (если нужно) и разбирает содержимое файла.
+// Открытый файл при заданном ключе читается как есть и будет зашифрован при следующей записи.
+func (f *fileStorage) decode(content []byte) (map[int64]*ChatSettings, int, error) {
+	if isEncrypted(content) {
+		if f.sealer == nil {
+			return nil, 0, errors.New("файл зашифрован, а SETTINGS_KEY не задан")
+		}
+		plain, err := f.sealer.open(content)
+		if err != nil {
+			return nil, 0, err
+		}
+		content = plain
+	} else if f.sealer != nil {
+		f.logger.Info("Файл %s не зашифрован — будет зашифрован при следующем сохранении", f.file)
+	}
+	return decodeSettings(content)
+}
+
+// SaveSettings атомарно сохраняет настройки: пишет во временный файл и переименовывает его.
+func (f *fileStorage) SaveSettings(chats map[int64]*ChatSettings) error {
+	if f.file == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(settingsDocument{Version: settingsSchemaVersion, Chats: chats}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if f.sealer != nil {
+		if content, err = f.sealer.seal(content); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(f.file, content, stateFilePerm); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.sum = sha256.Sum256(content)
+	f.mu.Unlock()
+	return nil
+}
+
+// reload перечитывает файл, если он изменился с последней загрузки или записи.
+// changed=false означает, что применять нечего.
+func (f *fileStorage) reload() (map[int64]*ChatSettings, bool, error) {
+	content, err := os.ReadFile(f.file)
+	if err != nil {
+		return nil, false, err
+	}
+	sum := sha256.Sum256(content)
+	f.mu.Lock()
+	same := sum == f.sum
+	f.mu.Unlock()
+	if same {
+		return nil, false, nil
+	}
+
+	chats, _, err := f.decode(content)
+	if err != nil {
+		return nil, false, err
+	}
+	f.mu.Lock()
+	f.sum = sum
+	f.mu.Unlock()
+	return chats, true, nil
+}
+
+func (f *fileStorage) LoadVerified() (map[string]time.Time, error) { return nil, nil }
+func (f *fileStorage) SaveVerified(map[string]time.Time) error     { return nil }
+func (f *fileStorage) LoadStats() (map[int64]ChatStats, error)     { return nil, nil }
+func (f *fileStorage) SaveStats(map[int64]ChatStats) error         { return nil }
+
+func (f *fileStorage) LogBan(entry BanLogEntry) error {
+	if f.banFile == "" {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if f.sealer != nil {
+		if line, err = f.sealer.seal(line); err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.banFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, stateFilePerm)
+	if err != nil {
+		return err
+	}
+	// журнал, созданный прежними версиями, был доступен всем
+	if err := file.Chmod(stateFilePerm); err != nil {
+		f.logger.Warn("Не удалось ограничить права %s: %v", f.banFile, err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (f *fileStorage) BanLog(q BanLogQuery) ([]BanLogEntry, error) {
+	if f.banFile == "" {
+		return nil, nil
+	}
+	f.mu.Lock()
+	content, err := os.ReadFile(f.banFile)
+	f.mu.Unlock()
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.Split(bytes.TrimSpace(content), []byte("\n"))
+	var out []BanLogEntry
+	for i := len(lines) - 1; i >= 0 && !q.full(len(out)); i-- {
+		e, ok := f.decodeBanLine(lines[i])
+		if !ok {
+			continue
+		}
+		if q.match(e) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fileStorage) ForgetUser(userID int64) (int, error) {
+	return f.removeBanLog(func(e BanLogEntry) bool { return e.UserID == userID })
+}
+
+func (f *fileStorage) PruneBanLog(before time.Time) (int, error) {
+	return f.removeBanLog(func(e BanLogEntry) bool { return e.At.Before(before) })
+}
+
+// removeBanLog переписывает журнал банов без записей, подходящих под match.
+func (f *fileStorage) removeBanLog(match func(BanLogEntry) bool) (int, error) {
+	if f.banFile == "" {
+		return 0, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	content, err := os.ReadFile(f.banFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var kept bytes.Buffer
+	removed := 0
+	for _, line := range bytes.Split(bytes.TrimSpace(content), []byte("\n")) {
+		if e, ok := f.decodeBanLine(line); ok && match(e) {
+			removed++
+			continue
+		}
+		if len(line) > 0 {
+			kept.Write(line)
+			kept.WriteByte('\n')
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, writeFileAtomic(f.banFile, kept.Bytes(), stateFilePerm)
+}
+
+// decodeBanLine разбирает строку журнала банов, зашифрованную или открытую
+// (записанную до появления ключа). false — строка недописана при сбое или
+// зашифрована, а ключа нет.
+func (f *fileStorage) decodeBanLine(line []byte) (BanLogEntry, bool) {
+	var e BanLogEntry
+	if isEncrypted(line) {
+		if f.sealer == nil {
+			return e, false
+		}
+		plain, err := f.sealer.open(line)
+		if err != nil {
+			return e, false
+		}
+		line = plain
+	}
+	return e, json.Unmarshal(line, &e) == nil
+}
+
+func (f *fileStorage) LoadSentMessages() (map[int64][]SentMessage, error) {
+	if f.sentFile == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(f.sentFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sent map[int64][]SentMessage
+	if err := json.Unmarshal(content, &sent); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга %s: %w", f.sentFile, err)
+	}
+	return sent, nil
+}
+
+func (f *fileStorage) SaveSentMessages(sent map[int64][]SentMessage) error {
+	if f.sentFile == "" {
+		return nil
+	}
+	content, err := json.Marshal(sent)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.sentFile, content, stateFilePerm)
+}
+
+func (f *fileStorage) LoadUpdateOffset() (UpdateOffset, error) {
+	var o UpdateOffset
+	if f.offsetFile == "" {
+		return o, nil
+	}
+	content, err := os.ReadFile(f.offsetFile)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return o, err
+	}
+	if err := json.Unmarshal(content, &o); err != nil {
+		return o, fmt.Errorf("ошибка парсинга %s: %w", f.offsetFile, err)
+	}
+	return o, nil
+}
+
+func (f *fileStorage) SaveUpdateOffset(o UpdateOffset) error {
+	if f.offsetFile == "" {
+		return nil
+	}
+	content, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.offsetFile, content, stateFilePerm)
+}
+func (f *fileStorage) Close() error { return nil }
+
+// writeFileAtomic пишет данные во временный файл рядом с целевым и переименовывает его,
+// чтобы при сбое на диске остался либо старый, либо новый файл целиком.
+func writeFileAtomic(file string, content []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // после успешного Rename файла уже нет
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, file); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(file))
+	return nil
+}
+
+// syncDir сбрасывает на диск запись каталога, чтобы переименование пережило сбой питания.
+// Не на всех системах каталог можно синхронизировать, поэтому ошибки игнорируются.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// ==========================
+// Верификации и статистика
+// ==========================
+
+const (
+	// stateSaveDelay и stateSaveMaxWait — как часто сбрасывать верификации и статистику в хранилище.
+	stateSaveDelay   = 5 * time.Second
+	stateSaveMaxWait = 30 * time.Second
+)
+
+// loadState загружает верификации, статистику и сообщения бота из хранилища.
+func (b *Bot) loadState() {
+	if b.storage == nil {
+		return
+	}
+	if verified, err := b.storage.LoadVerified(); err != nil {
+		b.logger.Warn("Не удалось загрузить верификации: %v", err)
+	} else if verified != nil {
+		b.verified.replace(verified)
+	}
+	if stats, err := b.storage.LoadStats(); err != nil {
+		b.logger.Warn("Не удалось загрузить статистику: %v", err)
+	} else if stats != nil {
+		b.stats.Replace(stats)
+	}
+	if sent, err := b.storage.LoadSentMessages(); err != nil {
+		b.logger.Warn("Не удалось загрузить сообщения бота: %v", err)
+	} else if sent != nil {
+		b.sent.replace(sent)
+	}
+	if offset, err := b.storage.LoadUpdateOffset(); err != nil {
+		b.logger.Warn("Не удалось загрузить смещение обновлений: %v", err)
+	} else {
+		b.offset.set(offset)
+	}
+}
+
+// saveState планирует сохранение верификаций и статистики.
+func (b *Bot) saveState() {
+	if b.storage == nil {
+		return
+	}
+	if b.stateSaver != nil {
+		b.stateSaver.Trigger()
+		return
+	}
+	b.writeState()
+}
+
+func (b *Bot) writeState() {
+	if err := b.storage.SaveVerified(b.verified.snapshot()); err != nil {
+		b.storageWarn("Не удалось сохранить верификации", err)
+	}
+	if err := b.storage.SaveStats(b.stats.Snapshot()); err != nil {
+		b.storageWarn("Не удалось сохранить статистику", err)
+	}
+	if err := b.storage.SaveSentMessages(b.sent.snapshot(time.Now())); err != nil {
+		b.storageWarn("Не удалось сохранить сообщения бота", err)
+	}
+	if offset := b.offset.get(); offset.Offset != 0 {
+		if err := b.storage.SaveUpdateOffset(offset); err != nil {
+			b.storageWarn("Не удалось сохранить смещение обновлений", err)
+		}
+	}
+}
+
+// logBan записывает бан, выданный ботом, в журнал хранилища.
+func (b *Bot) logBan(chatID, userID int64, reason string) {
+	b.writeBanLog(BanLogEntry{ChatID: chatID, UserID: userID, Reason: reason, At: time.Now()})
+}
+
+func (b *Bot) writeBanLog(entry BanLogEntry) {
+	b.fireHook(hookBanned, MemberEvent{ChatID: entry.ChatID, UserID: entry.UserID, Reason: entry.Reason})
+	entry.UserID, entry.By = b.storedUserID(entry.UserID), b.storedUserID(entry.By)
+	b.recentBans.add(entry)
+	if b.storage == nil {
+		return
+	}
+	if err := b.storage.LogBan(entry); err != nil {
+		b.storageWarn(fmt.Sprintf("Не удалось записать бан %d в журнал", entry.UserID), err)
+	}
+}
+
+// Close прерывает незавершённые запросы к Telegram, выгружает трассировку,
+// записывает отложенные изменения и закрывает хранилище.
+func (b *Bot) Close() error {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	if q, ok := b.api.(*queuedAPI); ok {
+		q.close()
+	}
+	if err := b.tracer.flush(); err != nil {
+		b.logger.Warn("Не удалось выгрузить трассировку: %v", err)
+	}
+	if err := b.recorder.close(); err != nil {
+		b.logger.Warn("Ошибка закрытия файла записи обновлений: %v", err)
+	}
+	b.FlushSettings()
+	if b.stateSaver != nil {
+		b.stateSaver.Flush()
+	}
+	if b.storage == nil {
+		return nil
+	}
+	return b.storage.Close()
+}
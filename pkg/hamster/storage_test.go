@@ -0,0 +1,109 @@
+package hamster
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStorageSaveLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "settings.json")
+	fs := newFileStorage(file, NewLogger())
+
+	if chats, err := fs.LoadSettings(); err != nil || chats != nil {
+		t.Fatalf("без файла ожидался nil без ошибки: %v %v", chats, err)
+	}
+
+	s := NewSettings()
+	s.SetTimeout(1, 100)
+	s.Update(2, func(c *ChatSettings) {
+		c.Action = ActionKick
+		c.WelcomeTemplate = "Здравствуйте, {name}"
+		c.Disabled = true
+	})
+	if err := fs.SaveSettings(s.Snapshot()); err != nil {
+		t.Fatalf("SaveSettings вернул ошибку: %v", err)
+	}
+
+	chats, err := fs.LoadSettings()
+	if err != nil {
+		t.Fatalf("LoadSettings вернул ошибку: %v", err)
+	}
+	loaded := NewSettings()
+	loaded.Replace(chats)
+	if got := loaded.Timeout(1); got != 100 {
+		t.Errorf("ожидалось 100, получили %d", got)
+	}
+	if got := loaded.Get(2); got.Action != ActionKick || !got.Disabled || got.WelcomeTemplate != "Здравствуйте, {name}" {
+		t.Errorf("настройки чата 2 не восстановлены: %+v", got)
+	}
+
+	// временных файлов после атомарной записи не остаётся
+	entries, _ := os.ReadDir(filepath.Dir(file))
+	if len(entries) != 1 {
+		t.Errorf("ожидался один файл, получили %d", len(entries))
+	}
+}
+
+func TestFileStorageLoadLegacyTimeouts(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "timeouts.json")
+	if err := os.WriteFile(file, []byte(`{"-100": 90, "-200": 30}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	chats, err := newFileStorage(file, NewLogger()).LoadSettings()
+	if err != nil {
+		t.Fatalf("LoadSettings вернул ошибку: %v", err)
+	}
+	if chats[-100].Timeout != 90 || chats[-200].Timeout != 30 {
+		t.Errorf("таймауты старого формата не прочитаны: %+v", chats)
+	}
+}
+
+func TestFileStorageRejectsNewerSchema(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(file, []byte(`{"version": 99, "chats": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newFileStorage(file, NewLogger()).LoadSettings(); err == nil {
+		t.Error("файл новой схемы не должен загружаться")
+	}
+}
+
+func TestOpenStorageUnknown(t *testing.T) {
+	if _, err := OpenStorage(Config{Storage: "redis"}, NewLogger()); err == nil {
+		t.Error("неизвестное хранилище должно давать ошибку")
+	}
+}
+
+func TestBotStatePersistsAcrossRestart(t *testing.T) {
+	cfg := Config{Storage: StorageBolt, BoltFile: filepath.Join(t.TempDir(), "hamster.db")}
+
+	b, err := NewBot("token", cfg)
+	if err != nil {
+		t.Fatalf("NewBot вернул ошибку: %v", err)
+	}
+	b.settings.SetTimeout(-100, 45)
+	b.saveSettings()
+	b.verified.mark(-100, 7, time.Now())
+	b.recordStat(-100, func(c *ChatStats) { c.Joins++ })
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close вернул ошибку: %v", err)
+	}
+
+	restarted, err := NewBot("token", cfg)
+	if err != nil {
+		t.Fatalf("NewBot после перезапуска вернул ошибку: %v", err)
+	}
+	defer restarted.Close()
+	if got := restarted.settings.Timeout(-100); got != 45 {
+		t.Errorf("таймаут не сохранился, получили %d", got)
+	}
+	if _, ok := restarted.verified.since(-100, 7); !ok {
+		t.Error("верификация не сохранилась")
+	}
+	if got := restarted.stats.Get(-100).Joins; got != 1 {
+		t.Errorf("статистика не сохранилась, получили %d", got)
+	}
+}
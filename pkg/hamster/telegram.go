@@ -0,0 +1,391 @@
+package hamster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Клиент Telegram Bot API
+// ==========================
+
+// TelegramAPI — методы Bot API, которыми пользуется бот. Продакшен-реализацию
+// возвращает NewTelegramAPI; свою (обёртку с метриками, фейк для тестов)
+// можно передать через WithTelegramAPI.
+type TelegramAPI interface {
+	// GetUpdates ждёт обновлений до timeout секунд; limit 0 — по умолчанию Telegram (100).
+	GetUpdates(ctx context.Context, offset int64, timeout, limit int) ([]Update, error)
+	GetMe(ctx context.Context) (User, error)
+	GetChat(ctx context.Context, chatRef string) (Chat, error)
+	GetChatMember(ctx context.Context, chatID, userID int64) (ChatMember, error)
+	GetChatAdministrators(ctx context.Context, chatID int64) ([]ChatMember, error)
+
+	// SendMessage отправляет сообщение (беззвучное, если opts не просит иного);
+	// markup — reply_markup или nil.
+	SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts SendOptions) (int64, error)
+	// EditMessage меняет текст сообщения; parseMode — как в SendOptions.
+	EditMessage(ctx context.Context, chatID, msgID int64, text, parseMode string) error
+	// SendMedia отправляет фото, анимацию или стикер; у стикера
+	// подписи не бывает, caption для него игнорируется.
+	SendMedia(ctx context.Context, chatID int64, media Media, caption string, markup interface{}, opts SendOptions) (int64, error)
+	// EditCaption меняет подпись под медиа и убирает клавиатуру.
+	EditCaption(ctx context.Context, chatID, msgID int64, caption, parseMode string) error
+	// SendDocument загружает файл name с содержимым content.
+	SendDocument(ctx context.Context, chatID int64, name string, content []byte, caption string) (int64, error)
+	DeleteMessage(ctx context.Context, chatID, msgID int64) error
+	AnswerCallback(ctx context.Context, callbackID, text string, showAlert bool) error
+
+	Ban(ctx context.Context, chatID, userID int64) error
+	// Unban снимает бан, не трогая тех, кто в чате (only_if_banned).
+	Unban(ctx context.Context, chatID, userID int64) error
+	// Restrict меняет права участника до until; нулевое until — бессрочно.
+	Restrict(ctx context.Context, chatID, userID int64, perms ChatPermissions, until time.Time) error
+	// BanSenderChat запрещает каналу писать в чат от своего имени.
+	BanSenderChat(ctx context.Context, chatID, senderChatID int64) error
+	// SetChatPermissions меняет права всех участников чата, кроме администраторов.
+	SetChatPermissions(ctx context.Context, chatID int64, perms ChatPermissions) error
+	LeaveChat(ctx context.Context, chatID int64) error
+}
+
+// APIError — ответ Bot API с ok=false.
+type APIError struct {
+	Method      string
+	Code        int
+	Description string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %d %s", e.Method, e.Code, e.Description)
+}
+
+// apiRetries — сколько раз повторять запрос при сетевой ошибке или 429.
+const apiRetries = 3
+
+type httpTelegramAPI struct {
+	baseURL string
+	client  HTTPClient
+	breaker *circuitBreaker
+}
+
+// DefaultAPIURL — адрес облачного Bot API.
+const DefaultAPIURL = "https://api.telegram.org"
+
+// NewTelegramAPI возвращает клиент облачного Bot API поверх HTTP. Во время сбоя
+// Telegram запросы приостанавливаются для всего бота сразу (см. ErrAPIUnavailable).
+func NewTelegramAPI(token string, client HTTPClient) TelegramAPI {
+	return NewTelegramAPIWithURL(DefaultAPIURL, token, client)
+}
+
+// NewTelegramAPIWithURL возвращает клиент Bot API по адресу apiURL — например,
+// своего сервера telegram-bot-api (http://localhost:8081). Методы вызываются
+// по apiURL/bot<токен>/<метод>; если в apiURL есть {token}, токен
+// подставляется на его место — для прокси с другой схемой путей.
+func NewTelegramAPIWithURL(apiURL, token string, client HTTPClient) TelegramAPI {
+	return &httpTelegramAPI{baseURL: apiBaseURL(apiURL, token), client: client, breaker: newCircuitBreaker()}
+}
+
+// apiBaseURL строит префикс методов Bot API.
+func apiBaseURL(apiURL, token string) string {
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	apiURL = strings.TrimRight(apiURL, "/")
+	if strings.Contains(apiURL, "{token}") {
+		return strings.ReplaceAll(apiURL, "{token}", token)
+	}
+	return apiURL + "/bot" + token
+}
+
+// call выполняет метод с JSON-параметрами и раскладывает result в out (может быть nil).
+// Сетевые ошибки и 429 повторяются, остальные ответы с ok=false возвращаются как *APIError.
+func (a *httpTelegramAPI) call(ctx context.Context, method string, params map[string]interface{}, out interface{}) error {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return a.post(ctx, method, body, "application/json", out)
+}
+
+// post отправляет готовое тело запроса с повторами, как call.
+func (a *httpTelegramAPI) post(ctx context.Context, method string, body []byte, contentType string, out interface{}) error {
+	var lastErr error
+	for i := 0; i < apiRetries; i++ {
+		if !a.breaker.allow() {
+			return fmt.Errorf("%s: %w", method, ErrAPIUnavailable)
+		}
+		var retryAfter time.Duration
+		retryAfter, lastErr = a.do(ctx, method, body, contentType, out)
+		if retryAfter > 0 {
+			a.breaker.record(nil) // 429 — Telegram отвечает, ограничен лишь этот чат или метод
+		} else if lastErr == nil || ctx.Err() == nil {
+			a.breaker.record(lastErr) // отменённый запрос ничего не говорит о Telegram
+		}
+		if lastErr == nil || ctx.Err() != nil {
+			return lastErr
+		}
+		if retryAfter == 0 {
+			if _, ok := lastErr.(*APIError); ok {
+				return lastErr
+			}
+			retryAfter = time.Duration(i+1) * 500 * time.Millisecond
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryAfter):
+		}
+	}
+	return lastErr
+}
+
+// do выполняет один запрос. retryAfter > 0 — сервер просит подождать (429).
+func (a *httpTelegramAPI) do(ctx context.Context, method string, body []byte, contentType string, out interface{}) (retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Ok          bool            `json:"ok"`
+		ErrorCode   int             `json:"error_code"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("%s: %w", method, err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter = 2 * time.Second
+		if result.Parameters.RetryAfter > 0 {
+			retryAfter = time.Duration(result.Parameters.RetryAfter) * time.Second
+		}
+		return retryAfter, fmt.Errorf("%s: 429 rate limit", method)
+	}
+	if !result.Ok {
+		return 0, &APIError{Method: method, Code: result.ErrorCode, Description: result.Description}
+	}
+	if out != nil && len(result.Result) > 0 {
+		if err := json.Unmarshal(result.Result, out); err != nil {
+			return 0, fmt.Errorf("%s: %w", method, err)
+		}
+	}
+	return 0, nil
+}
+
+func (a *httpTelegramAPI) GetUpdates(ctx context.Context, offset int64, timeout, limit int) ([]Update, error) {
+	var updates []Update
+	params := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": allowedUpdates,
+	}
+	if limit > 0 {
+		params["limit"] = limit
+	}
+	err := a.call(ctx, "getUpdates", params, &updates)
+	return updates, err
+}
+
+func (a *httpTelegramAPI) GetMe(ctx context.Context) (User, error) {
+	var me User
+	err := a.call(ctx, "getMe", nil, &me)
+	return me, err
+}
+
+func (a *httpTelegramAPI) GetChat(ctx context.Context, chatRef string) (Chat, error) {
+	var chat Chat
+	err := a.call(ctx, "getChat", map[string]interface{}{"chat_id": chatRef}, &chat)
+	return chat, err
+}
+
+func (a *httpTelegramAPI) GetChatMember(ctx context.Context, chatID, userID int64) (ChatMember, error) {
+	var member ChatMember
+	err := a.call(ctx, "getChatMember", map[string]interface{}{"chat_id": chatID, "user_id": userID}, &member)
+	return member, err
+}
+
+func (a *httpTelegramAPI) GetChatAdministrators(ctx context.Context, chatID int64) ([]ChatMember, error) {
+	var admins []ChatMember
+	err := a.call(ctx, "getChatAdministrators", map[string]interface{}{"chat_id": chatID}, &admins)
+	return admins, err
+}
+
+// SendOptions — необязательные параметры отправки сообщения.
+type SendOptions struct {
+	ParseMode string // ParseModeHTML; пусто — простой текст
+	Notify    bool   // со звуком; по умолчанию сообщения беззвучные
+	Protect   bool   // protect_content: запретить пересылку и сохранение
+}
+
+// apply добавляет параметры отправки к запросу.
+func (o SendOptions) apply(params map[string]interface{}, markup interface{}) {
+	params["disable_notification"] = !o.Notify
+	if o.Protect {
+		params["protect_content"] = true
+	}
+	if o.ParseMode != "" {
+		params["parse_mode"] = o.ParseMode
+	}
+	if markup != nil {
+		params["reply_markup"] = markup
+	}
+}
+
+func (a *httpTelegramAPI) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts SendOptions) (int64, error) {
+	params := map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	opts.apply(params, markup)
+	var msg Message
+	err := a.call(ctx, "sendMessage", params, &msg)
+	return msg.MessageID, err
+}
+
+func (a *httpTelegramAPI) EditMessage(ctx context.Context, chatID, msgID int64, text, parseMode string) error {
+	params := map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": msgID,
+		"text":       text,
+	}
+	if parseMode != "" {
+		params["parse_mode"] = parseMode
+	}
+	return a.call(ctx, "editMessageText", params, nil)
+}
+
+// mediaMethods — метод Bot API и имя поля с file_id для каждого типа медиа.
+var mediaMethods = map[string][2]string{
+	MediaPhoto:     {"sendPhoto", "photo"},
+	MediaAnimation: {"sendAnimation", "animation"},
+	MediaSticker:   {"sendSticker", "sticker"},
+}
+
+func (a *httpTelegramAPI) SendMedia(ctx context.Context, chatID int64, media Media, caption string, markup interface{}, opts SendOptions) (int64, error) {
+	m, ok := mediaMethods[media.Type]
+	if !ok {
+		return 0, fmt.Errorf("неизвестный тип медиа %q", media.Type)
+	}
+	params := map[string]interface{}{
+		"chat_id": chatID,
+		m[1]:      media.FileID,
+	}
+	if caption != "" && media.Type != MediaSticker {
+		params["caption"] = caption
+	}
+	opts.apply(params, markup)
+	var msg Message
+	err := a.call(ctx, m[0], params, &msg)
+	return msg.MessageID, err
+}
+
+func (a *httpTelegramAPI) EditCaption(ctx context.Context, chatID, msgID int64, caption, parseMode string) error {
+	params := map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": msgID,
+		"caption":    caption,
+	}
+	if parseMode != "" {
+		params["parse_mode"] = parseMode
+	}
+	return a.call(ctx, "editMessageCaption", params, nil)
+}
+
+// SendDocument загружает файл через multipart/form-data: JSON для этого не годится.
+func (a *httpTelegramAPI) SendDocument(ctx context.Context, chatID int64, name string, content []byte, caption string) (int64, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("chat_id", strconv.FormatInt(chatID, 10))
+	_ = mw.WriteField("disable_notification", "true")
+	if caption != "" {
+		_ = mw.WriteField("caption", caption)
+	}
+	part, err := mw.CreateFormFile("document", name)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := part.Write(content); err != nil {
+		return 0, err
+	}
+	if err := mw.Close(); err != nil {
+		return 0, err
+	}
+	var msg Message
+	err = a.post(ctx, "sendDocument", body.Bytes(), mw.FormDataContentType(), &msg)
+	return msg.MessageID, err
+}
+
+func (a *httpTelegramAPI) DeleteMessage(ctx context.Context, chatID, msgID int64) error {
+	return a.call(ctx, "deleteMessage", map[string]interface{}{"chat_id": chatID, "message_id": msgID}, nil)
+}
+
+func (a *httpTelegramAPI) AnswerCallback(ctx context.Context, callbackID, text string, showAlert bool) error {
+	return a.call(ctx, "answerCallbackQuery", map[string]interface{}{
+		"callback_query_id": callbackID,
+		"text":              text,
+		"show_alert":        showAlert,
+	}, nil)
+}
+
+func (a *httpTelegramAPI) Ban(ctx context.Context, chatID, userID int64) error {
+	return a.call(ctx, "banChatMember", map[string]interface{}{"chat_id": chatID, "user_id": userID}, nil)
+}
+
+func (a *httpTelegramAPI) Unban(ctx context.Context, chatID, userID int64) error {
+	return a.call(ctx, "unbanChatMember", map[string]interface{}{
+		"chat_id":        chatID,
+		"user_id":        userID,
+		"only_if_banned": true,
+	}, nil)
+}
+
+func (a *httpTelegramAPI) Restrict(ctx context.Context, chatID, userID int64, perms ChatPermissions, until time.Time) error {
+	params := map[string]interface{}{
+		"chat_id":                          chatID,
+		"user_id":                          userID,
+		"permissions":                      perms,
+		"use_independent_chat_permissions": true,
+	}
+	if !until.IsZero() {
+		params["until_date"] = until.Unix()
+	}
+	return a.call(ctx, "restrictChatMember", params, nil)
+}
+
+func (a *httpTelegramAPI) BanSenderChat(ctx context.Context, chatID, senderChatID int64) error {
+	return a.call(ctx, "banChatSenderChat", map[string]interface{}{
+		"chat_id":        chatID,
+		"sender_chat_id": senderChatID,
+	}, nil)
+}
+
+func (a *httpTelegramAPI) SetChatPermissions(ctx context.Context, chatID int64, perms ChatPermissions) error {
+	return a.call(ctx, "setChatPermissions", map[string]interface{}{
+		"chat_id":                          chatID,
+		"permissions":                      perms,
+		"use_independent_chat_permissions": true,
+	}, nil)
+}
+
+func (a *httpTelegramAPI) LeaveChat(ctx context.Context, chatID int64) error {
+	return a.call(ctx, "leaveChat", map[string]interface{}{"chat_id": chatID}, nil)
+}
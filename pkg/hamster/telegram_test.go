@@ -0,0 +1,342 @@
+package hamster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeAPI — TelegramAPI для тестов. Незаданные функции ничего не делают,
+// а отправка возвращает ID сообщения 1.
+type fakeAPI struct {
+	sendSilent            func(chatID int64, text string) int64
+	sendWithMarkup        func(chatID int64, text string, markup interface{}) int64
+	sendOptions           func(chatID int64, text string, opts SendOptions)
+	editMessage           func(chatID, msgID int64, text string)
+	deleteMessage         func(chatID, msgID int64)
+	sendMedia             func(chatID int64, media Media, caption string, markup interface{}) int64
+	editCaption           func(chatID, msgID int64, caption string)
+	answerCallback        func(callbackID, text string, showAlert bool)
+	ban                   func(chatID, userID int64)
+	unban                 func(chatID, userID int64)
+	restrict              func(chatID, userID int64, perms ChatPermissions, until time.Time)
+	getMe                 func() *User
+	getChatType           func(chatRef string) string
+	getChat               func(chatRef string) (Chat, error)
+	banSenderChat         func(chatID, senderChatID int64)
+	getChatMember         func(chatID, userID int64) (ChatMember, error)
+	getChatAdministrators func(chatID int64) ([]ChatMember, error)
+	leaveChat             func(chatID int64) error
+	setChatPermissions    func(chatID int64, perms ChatPermissions)
+	sendDocument          func(chatID int64, name string, content []byte) error
+}
+
+// fakeOf возвращает фейковый API тестового бота.
+func fakeOf(b *Bot) *fakeAPI {
+	return b.api.(*fakeAPI)
+}
+
+func (f *fakeAPI) GetUpdates(ctx context.Context, offset int64, timeout, limit int) ([]Update, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *fakeAPI) GetMe(ctx context.Context) (User, error) {
+	if f.getMe == nil {
+		return User{}, errors.New("getMe не задан")
+	}
+	if me := f.getMe(); me != nil {
+		return *me, nil
+	}
+	return User{}, errors.New("getMe failed")
+}
+
+func (f *fakeAPI) GetChat(ctx context.Context, chatRef string) (Chat, error) {
+	if f.getChat != nil {
+		return f.getChat(chatRef)
+	}
+	if f.getChatType == nil {
+		return Chat{}, nil
+	}
+	return Chat{Type: f.getChatType(chatRef)}, nil
+}
+
+func (f *fakeAPI) GetChatMember(ctx context.Context, chatID, userID int64) (ChatMember, error) {
+	if f.getChatMember == nil {
+		return ChatMember{}, nil
+	}
+	return f.getChatMember(chatID, userID)
+}
+
+func (f *fakeAPI) GetChatAdministrators(ctx context.Context, chatID int64) ([]ChatMember, error) {
+	if f.getChatAdministrators == nil {
+		return nil, nil
+	}
+	return f.getChatAdministrators(chatID)
+}
+
+func (f *fakeAPI) SendMessage(ctx context.Context, chatID int64, text string, markup interface{}, opts SendOptions) (int64, error) {
+	if f.sendOptions != nil {
+		f.sendOptions(chatID, text, opts)
+	}
+	switch {
+	case markup != nil && f.sendWithMarkup != nil:
+		return f.sendWithMarkup(chatID, text, markup), nil
+	case f.sendSilent != nil:
+		return f.sendSilent(chatID, text), nil
+	}
+	return 1, nil
+}
+
+func (f *fakeAPI) EditMessage(ctx context.Context, chatID, msgID int64, text, parseMode string) error {
+	if f.editMessage != nil {
+		f.editMessage(chatID, msgID, text)
+	}
+	return nil
+}
+
+func (f *fakeAPI) SendMedia(ctx context.Context, chatID int64, media Media, caption string, markup interface{}, opts SendOptions) (int64, error) {
+	if f.sendMedia != nil {
+		return f.sendMedia(chatID, media, caption, markup), nil
+	}
+	return 1, nil
+}
+
+func (f *fakeAPI) EditCaption(ctx context.Context, chatID, msgID int64, caption, parseMode string) error {
+	if f.editCaption != nil {
+		f.editCaption(chatID, msgID, caption)
+	}
+	return nil
+}
+
+func (f *fakeAPI) DeleteMessage(ctx context.Context, chatID, msgID int64) error {
+	if f.deleteMessage != nil {
+		f.deleteMessage(chatID, msgID)
+	}
+	return nil
+}
+
+func (f *fakeAPI) AnswerCallback(ctx context.Context, callbackID, text string, showAlert bool) error {
+	if f.answerCallback != nil {
+		f.answerCallback(callbackID, text, showAlert)
+	}
+	return nil
+}
+
+func (f *fakeAPI) Ban(ctx context.Context, chatID, userID int64) error {
+	if f.ban != nil {
+		f.ban(chatID, userID)
+	}
+	return nil
+}
+
+func (f *fakeAPI) Unban(ctx context.Context, chatID, userID int64) error {
+	if f.unban != nil {
+		f.unban(chatID, userID)
+	}
+	return nil
+}
+
+func (f *fakeAPI) BanSenderChat(ctx context.Context, chatID, senderChatID int64) error {
+	if f.banSenderChat != nil {
+		f.banSenderChat(chatID, senderChatID)
+	}
+	return nil
+}
+
+func (f *fakeAPI) Restrict(ctx context.Context, chatID, userID int64, perms ChatPermissions, until time.Time) error {
+	if f.restrict != nil {
+		f.restrict(chatID, userID, perms, until)
+	}
+	return nil
+}
+
+func (f *fakeAPI) SendDocument(ctx context.Context, chatID int64, name string, content []byte, caption string) (int64, error) {
+	if f.sendDocument != nil {
+		if err := f.sendDocument(chatID, name, content); err != nil {
+			return 0, err
+		}
+	}
+	return 1, nil
+}
+
+func (f *fakeAPI) SetChatPermissions(ctx context.Context, chatID int64, perms ChatPermissions) error {
+	if f.setChatPermissions != nil {
+		f.setChatPermissions(chatID, perms)
+	}
+	return nil
+}
+
+func (f *fakeAPI) LeaveChat(ctx context.Context, chatID int64) error {
+	if f.leaveChat == nil {
+		return nil
+	}
+	return f.leaveChat(chatID)
+}
+
+// -------------------------
+// httpTelegramAPI
+// -------------------------
+
+func jsonResponse(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestTelegramAPISendMessage(t *testing.T) {
+	var gotURL string
+	var params map[string]interface{}
+	api := NewTelegramAPI("TOKEN", &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		gotURL = req.URL.String()
+		params = nil
+		_ = json.NewDecoder(req.Body).Decode(&params)
+		return jsonResponse(200, `{"ok":true,"result":{"message_id":77}}`), nil
+	}})
+
+	id, err := api.SendMessage(context.Background(), -100, "привет", map[string]interface{}{"inline_keyboard": []interface{}{}}, SendOptions{})
+	if err != nil || id != 77 {
+		t.Fatalf("ожидали id 77, получили %d, %v", id, err)
+	}
+	if gotURL != "https://api.telegram.org/botTOKEN/sendMessage" {
+		t.Errorf("URL: %s", gotURL)
+	}
+	if params["disable_notification"] != true || params["reply_markup"] == nil || params["chat_id"] != float64(-100) {
+		t.Errorf("параметры: %v", params)
+	}
+
+	if _, err := api.SendMessage(context.Background(), 1, "x", nil, SendOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := params["reply_markup"]; ok {
+		t.Error("без клавиатуры reply_markup не передаётся")
+	}
+	if _, ok := params["parse_mode"]; ok {
+		t.Error("без ParseMode текст отправляется как есть")
+	}
+
+	if _, err := api.SendMessage(context.Background(), 1, "<b>x</b>", nil, SendOptions{ParseMode: ParseModeHTML}); err != nil {
+		t.Fatal(err)
+	}
+	if params["parse_mode"] != "HTML" {
+		t.Errorf("parse_mode: %v", params["parse_mode"])
+	}
+
+	if _, err := api.SendMessage(context.Background(), 1, "x", nil, SendOptions{Notify: true, Protect: true}); err != nil {
+		t.Fatal(err)
+	}
+	if params["disable_notification"] != false || params["protect_content"] != true {
+		t.Errorf("Notify и Protect: %v", params)
+	}
+}
+
+func TestTelegramAPISendDocument(t *testing.T) {
+	var form *multipart.Form
+	api := NewTelegramAPI("TOKEN", &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ожидали multipart/form-data: %v", err)
+		}
+		form = req.MultipartForm
+		return jsonResponse(200, `{"ok":true,"result":{"message_id":5}}`), nil
+	}})
+	id, err := api.SendDocument(context.Background(), 42, "chat.csv", []byte("a,b\n"), "выгрузка")
+	if err != nil || id != 5 {
+		t.Fatalf("ожидали id 5, получили %d, %v", id, err)
+	}
+	if form.Value["chat_id"][0] != "42" || form.Value["caption"][0] != "выгрузка" {
+		t.Errorf("поля: %v", form.Value)
+	}
+	if f := form.File["document"]; len(f) != 1 || f[0].Filename != "chat.csv" || f[0].Size != 4 {
+		t.Errorf("файл: %v", form.File)
+	}
+}
+
+func TestTelegramAPIErrors(t *testing.T) {
+	calls := 0
+	api := NewTelegramAPI("T", &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		calls++
+		return jsonResponse(400, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`), nil
+	}})
+	err := api.LeaveChat(context.Background(), 1)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 400 || !strings.Contains(apiErr.Description, "chat not found") {
+		t.Fatalf("ожидали APIError, получили %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("ошибки Bot API не повторяются, запросов: %d", calls)
+	}
+
+	calls = 0
+	api = NewTelegramAPI("T", &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return jsonResponse(429, `{"ok":false,"error_code":429,"parameters":{"retry_after":0}}`), nil
+		}
+		return jsonResponse(200, `{"ok":true,"result":true}`), nil
+	}})
+	if err := api.Ban(context.Background(), 1, 2); err != nil || calls != 2 {
+		t.Errorf("429 должен повторяться: %v, запросов %d", err, calls)
+	}
+}
+
+func TestAPIBaseURL(t *testing.T) {
+	cases := []struct{ apiURL, want string }{
+		{"", "https://api.telegram.org/botT"},
+		{"http://localhost:8081/", "http://localhost:8081/botT"},
+		{"https://proxy.example/tg/{token}", "https://proxy.example/tg/T"},
+	}
+	for _, c := range cases {
+		if got := apiBaseURL(c.apiURL, "T"); got != c.want {
+			t.Errorf("apiBaseURL(%q) = %q, ожидали %q", c.apiURL, got, c.want)
+		}
+	}
+
+	var gotURL string
+	api := NewTelegramAPIWithURL("http://localhost:8081", "T", &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		gotURL = req.URL.String()
+		return jsonResponse(200, `{"ok":true,"result":true}`), nil
+	}})
+	if err := api.LeaveChat(context.Background(), 1); err != nil || gotURL != "http://localhost:8081/botT/leaveChat" {
+		t.Errorf("запрос к локальному серверу: %s, %v", gotURL, err)
+	}
+}
+
+func TestTelegramAPIGetUpdatesLimit(t *testing.T) {
+	var params map[string]interface{}
+	api := NewTelegramAPI("T", &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		params = nil
+		_ = json.NewDecoder(req.Body).Decode(&params)
+		return jsonResponse(200, `{"ok":true,"result":[]}`), nil
+	}})
+	if _, err := api.GetUpdates(context.Background(), 5, 50, 20); err != nil {
+		t.Fatal(err)
+	}
+	if params["timeout"] != float64(50) || params["limit"] != float64(20) {
+		t.Errorf("параметры: %v", params)
+	}
+	if _, err := api.GetUpdates(context.Background(), 5, 50, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := params["limit"]; ok {
+		t.Error("без PollLimit limit не передаётся")
+	}
+}
+
+func TestTelegramAPIGetUpdatesCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	api := NewTelegramAPI("T", &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		return nil, req.Context().Err()
+	}})
+	start := time.Now()
+	if _, err := api.GetUpdates(ctx, 0, 30, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("ожидали context.Canceled, получили %v", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Error("отменённый запрос не должен повторяться")
+	}
+}
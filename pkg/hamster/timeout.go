@@ -0,0 +1,63 @@
+package hamster
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultTimeoutSec = 60
+	MinTimeoutSec     = 5
+	MaxTimeoutSec     = 600
+)
+
+// ==========================
+// /timeout auto
+// ==========================
+
+const (
+	// autoTimeoutMinClicks — сколько нажатий нужно, чтобы доверять статистике.
+	autoTimeoutMinClicks = 20
+	// autoTimeoutPercentile — какую долю участников должен успевать пропускать таймаут.
+	autoTimeoutPercentile = 0.95
+	// autoTimeoutMinMargin — минимальный запас сверх перцентиля.
+	autoTimeoutMinMargin = 10 * time.Second
+)
+
+// suggestTimeout предлагает таймаут по статистике нажатий: p95 плюс запас
+// в половину p95, но не меньше autoTimeoutMinMargin. ok = false, если данных мало.
+func suggestTimeout(st ChatStats) (sec int, ok bool) {
+	if st.clickSamples() < autoTimeoutMinClicks {
+		return 0, false
+	}
+	p, ok := st.ClickPercentile(autoTimeoutPercentile)
+	if !ok {
+		return MaxTimeoutSec, true // заметная доля нажимает дольше последнего интервала
+	}
+	margin := p / 2
+	if margin < autoTimeoutMinMargin {
+		margin = autoTimeoutMinMargin
+	}
+	sec = int((p + margin).Seconds())
+	if sec < MinTimeoutSec {
+		sec = MinTimeoutSec
+	}
+	if sec > MaxTimeoutSec {
+		sec = MaxTimeoutSec
+	}
+	return sec, true
+}
+
+// handleTimeoutAuto ставит таймаут чата по статистике нажатий.
+func (b *Bot) handleTimeoutAuto(msg *Message) {
+	st := b.statsFor(msg.Chat.ID)
+	sec, ok := suggestTimeout(st)
+	if !ok {
+		b.sendTemporary(msg.Chat.ID, fmt.Sprintf("📉 Мало данных: нужно хотя бы %d нажатий, сейчас %d",
+			autoTimeoutMinClicks, st.clickSamples()), 10*time.Second)
+		return
+	}
+	b.settings.SetTimeout(msg.Chat.ID, sec)
+	b.saveSettings()
+	b.sendTemporary(msg.Chat.ID, fmt.Sprintf("✅ Таймаут установлен по статистике: %d сек. (95%% участников нажимают кнопку быстрее, плюс запас)", sec), 10*time.Second)
+}
@@ -0,0 +1,55 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func statsWithClicks(latency time.Duration, n int) ChatStats {
+	var st ChatStats
+	for i := 0; i < n; i++ {
+		st.addClick(latency)
+	}
+	return st
+}
+
+func TestSuggestTimeout(t *testing.T) {
+	if _, ok := suggestTimeout(statsWithClicks(3*time.Second, autoTimeoutMinClicks-1)); ok {
+		t.Error("при малом числе нажатий таймаут не предлагается")
+	}
+
+	st := statsWithClicks(3*time.Second, 19)
+	st.addClick(40 * time.Second) // единичный долгий клик не должен влиять на p95
+	if sec, ok := suggestTimeout(st); !ok || sec != 13 {
+		t.Errorf("p95 = 3 сек. плюс минимальный запас 10 сек.: %d, %v", sec, ok)
+	}
+	if sec, _ := suggestTimeout(statsWithClicks(40*time.Second, 30)); sec != 67 {
+		t.Errorf("p95 = 45 сек. плюс половина: %d", sec)
+	}
+	if sec, _ := suggestTimeout(statsWithClicks(time.Hour, 30)); sec != MaxTimeoutSec {
+		t.Errorf("очень долгие нажатия — максимальный таймаут: %d", sec)
+	}
+}
+
+func TestTimeoutAutoCommand(t *testing.T) {
+	b := setupBot()
+	b.stats = NewStats()
+	b.adminCache = map[string]adminCacheEntry{
+		"-1:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	var reply string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { reply = text; return 1 }
+	cmd := &Message{Chat: Chat{ID: -1, Type: "supergroup"}, From: &User{ID: 10}, Text: "/timeout auto"}
+
+	b.handleTimeoutCommand(cmd)
+	if !strings.Contains(reply, "Мало данных") || b.chatSettings(-1).Timeout != 0 {
+		t.Fatalf("без статистики таймаут не меняется: %q", reply)
+	}
+
+	b.stats.Replace(map[int64]ChatStats{-1: statsWithClicks(7*time.Second, 25)})
+	b.handleTimeoutCommand(cmd)
+	if got := b.chatSettings(-1).Timeout; got != 18 {
+		t.Errorf("ожидали таймаут 8+10 сек., получили %d (%q)", got, reply)
+	}
+}
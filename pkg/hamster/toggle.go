@@ -0,0 +1,43 @@
+package hamster
+
+import (
+	"strings"
+	"time"
+)
+
+// ==========================
+// Команда /hamster on|off
+// ==========================
+
+// chatEnabled сообщает, нужно ли проверять новых участников чата.
+func (b *Bot) chatEnabled(chatID int64) bool {
+	return !b.chatSettings(chatID).Disabled
+}
+
+func (b *Bot) handleHamsterCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может включать и выключать проверку", 5*time.Second)
+		return
+	}
+
+	switch strings.ToLower(commandArg(msg.Text, 1)) {
+	case "on":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Disabled = false })
+		b.sendTemporary(chatID, "✅ Проверка новых участников включена", 5*time.Second)
+	case "off":
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.Disabled = true })
+		b.sendTemporary(chatID, "⏸ Проверка новых участников приостановлена. Настройки сохранены, включить: /hamster on", 10*time.Second)
+	case "":
+		status := "✅ включена"
+		if !b.chatEnabled(chatID) {
+			status = "⏸ приостановлена"
+		}
+		b.sendTemporary(chatID, "🐹 Проверка новых участников "+status+"\n⚙️ /hamster on|off", 10*time.Second)
+	default:
+		b.sendTemporary(chatID, "⚙️ Использование: /hamster on|off", 5*time.Second)
+	}
+}
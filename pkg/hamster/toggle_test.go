@@ -0,0 +1,30 @@
+package hamster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHamsterOffIgnoresJoins(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"1:42": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+
+	b.handleHamsterCommand(&Message{Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "/hamster off"})
+	if b.chatEnabled(1) {
+		t.Fatal("/hamster off должен выключить проверку")
+	}
+
+	greeted := false
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { greeted = true; return 1 }
+	b.handleJoinMessage(&Message{Chat: Chat{ID: 1}, NewChatMembers: []*User{{ID: 5, FirstName: "Новичок"}}})
+	if greeted {
+		t.Error("при выключенной проверке вход должен игнорироваться")
+	}
+
+	b.handleHamsterCommand(&Message{Chat: Chat{ID: 1}, From: &User{ID: 42}, Text: "/hamster@hamster_bot on"})
+	if !b.chatEnabled(1) {
+		t.Error("/hamster on должен включить проверку")
+	}
+}
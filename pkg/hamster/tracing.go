@@ -0,0 +1,307 @@
+package hamster
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Трассировка (OTLP/HTTP, JSON)
+// ==========================
+
+const (
+	// traceFlushInterval — как часто выгружать накопленные спаны.
+	traceFlushInterval = 5 * time.Second
+	// traceBufferLimit — сколько спанов держать до выгрузки; лишние
+	// отбрасываются, если коллектор недоступен.
+	traceBufferLimit = 4096
+)
+
+// Виды спанов OTLP.
+const (
+	spanServer = 2
+	spanClient = 3
+)
+
+// TraceConfig — куда выгружать трассировку. Пустой Endpoint отключает её.
+type TraceConfig struct {
+	// Endpoint — адрес приёма спанов коллектора, например
+	// http://localhost:4318/v1/traces.
+	Endpoint string
+	// Headers — дополнительные заголовки запроса (например, ключ облачного бэкенда).
+	Headers map[string]string
+	// ServiceName — service.name в ресурсе; пустое — tg-hamster.
+	ServiceName string
+}
+
+// ParseTraceHeaders разбирает заголовки в формате OTEL_EXPORTER_OTLP_HEADERS: «k1=v1,k2=v2».
+func ParseTraceHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("ожидалось ключ=значение, получено %q", pair)
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers, nil
+}
+
+// tracer копит завершённые спаны и выгружает их пачками. Методы nil-трейсера
+// и nil-спана ничего не делают, поэтому вызывающему коду проверки не нужны.
+type tracer struct {
+	cfg    TraceConfig
+	client HTTPClient
+	logger *Logger
+
+	mu      sync.Mutex
+	spans   []otlpSpan
+	dropped int
+}
+
+func newTracer(cfg TraceConfig, client HTTPClient, logger *Logger) *tracer {
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "tg-hamster"
+	}
+	return &tracer{cfg: cfg, client: client, logger: logger}
+}
+
+// span — незавершённый спан.
+type span struct {
+	t        *tracer
+	traceID  string
+	spanID   string
+	parentID string
+	name     string
+	kind     int
+	start    time.Time
+	attrs    []otlpAttr
+}
+
+// start начинает спан; parent nil — новая трасса.
+func (t *tracer) start(name string, parent *span, kind int) *span {
+	if t == nil {
+		return nil
+	}
+	s := &span{t: t, spanID: randomHex(8), name: name, kind: kind, start: time.Now()}
+	if parent != nil {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		s.traceID = randomHex(16)
+	}
+	return s
+}
+
+// set добавляет атрибут: строку, целое или bool.
+func (s *span) set(key string, value interface{}) *span {
+	if s == nil {
+		return nil
+	}
+	var v otlpValue
+	switch x := value.(type) {
+	case string:
+		v.StringValue = &x
+	case int:
+		i := strconv.Itoa(x)
+		v.IntValue = &i
+	case int64:
+		i := strconv.FormatInt(x, 10)
+		v.IntValue = &i
+	case bool:
+		v.BoolValue = &x
+	default:
+		str := fmt.Sprint(x)
+		v.StringValue = &str
+	}
+	s.attrs = append(s.attrs, otlpAttr{Key: key, Value: v})
+	return s
+}
+
+// end завершает спан; ошибка попадает в его статус.
+func (s *span) end(err error) {
+	if s == nil {
+		return
+	}
+	out := otlpSpan{
+		TraceID:      s.traceID,
+		SpanID:       s.spanID,
+		ParentSpanID: s.parentID,
+		Name:         s.name,
+		Kind:         s.kind,
+		Start:        strconv.FormatInt(s.start.UnixNano(), 10),
+		End:          strconv.FormatInt(time.Now().UnixNano(), 10),
+		Attributes:   s.attrs,
+		Status:       otlpStatus{Code: 1},
+	}
+	if err != nil {
+		out.Status = otlpStatus{Code: 2, Message: err.Error()}
+	}
+	t := s.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.spans) >= traceBufferLimit {
+		t.dropped++
+		return
+	}
+	t.spans = append(t.spans, out)
+}
+
+// flush выгружает накопленные спаны коллектору.
+func (t *tracer) flush() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans, dropped := t.spans, t.dropped
+	t.spans, t.dropped = nil, 0
+	t.mu.Unlock()
+	if dropped > 0 {
+		t.logger.Warn("Трассировка: отброшено %d спанов, коллектор не успевает", dropped)
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	service, version := t.cfg.ServiceName, Version
+	body, err := json.Marshal(otlpExport{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttr{{Key: "service.name", Value: otlpValue{StringValue: &service}}, {Key: "service.version", Value: otlpValue{StringValue: &version}}}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/teleta/tg-hamster/pkg/hamster"},
+			Spans: spans,
+		}},
+	}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("коллектор ответил %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// RunTracing выгружает спаны каждые traceFlushInterval до отмены ctx и
+// напоследок выгружает остаток.
+func (b *Bot) RunTracing(ctx context.Context) {
+	if b.tracer == nil {
+		return
+	}
+	ticker := time.NewTicker(traceFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := b.tracer.flush(); err != nil {
+				b.logger.Warn("Не удалось выгрузить трассировку: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := b.tracer.flush(); err != nil {
+				b.logger.Warn("Не удалось выгрузить трассировку: %v", err)
+			}
+		}
+	}
+}
+
+// traceUpdate начинает спан обработки обновления с его типом и чатом.
+func (b *Bot) traceUpdate(u Update, parent *span) *span {
+	sp := b.tracer.start("handle", parent, spanServer)
+	if sp == nil {
+		return nil
+	}
+	kind, chatID, _ := updateContext(u)
+	sp.set("update.id", u.UpdateID).set("update.type", kind).set("chat.id", chatID)
+	if u.Message != nil {
+		if cmd := commandName(u.Message.Text); cmd != "" {
+			sp.set("command", cmd)
+		}
+	}
+	return sp
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Структуры OTLP/JSON: идентификаторы — hex, целые и время — строки.
+type otlpExport struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttr `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string     `json:"traceId"`
+	SpanID       string     `json:"spanId"`
+	ParentSpanID string     `json:"parentSpanId,omitempty"`
+	Name         string     `json:"name"`
+	Kind         int        `json:"kind"`
+	Start        string     `json:"startTimeUnixNano"`
+	End          string     `json:"endTimeUnixNano"`
+	Attributes   []otlpAttr `json:"attributes,omitempty"`
+	Status       otlpStatus `json:"status"`
+}
+
+type otlpAttr struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,108 @@
+package hamster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// exportedSpans выгружает спаны трейсера и возвращает их из тела запроса.
+func exportedSpans(t *testing.T, tr *tracer) ([]otlpSpan, *http.Request) {
+	t.Helper()
+	var got otlpExport
+	var gotReq *http.Request
+	tr.client = &mockHTTPClient{DoFunc: func(req *http.Request) (*http.Response, error) {
+		gotReq = req
+		body, _ := io.ReadAll(req.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Fatalf("тело выгрузки не OTLP/JSON: %v", err)
+		}
+		return jsonResponse(200, `{}`), nil
+	}}
+	if err := tr.flush(); err != nil {
+		t.Fatalf("flush вернул ошибку: %v", err)
+	}
+	if len(got.ResourceSpans) != 1 || len(got.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("неожиданная структура выгрузки: %+v", got)
+	}
+	return got.ResourceSpans[0].ScopeSpans[0].Spans, gotReq
+}
+
+func TestTracerExportsOTLP(t *testing.T) {
+	tr := newTracer(TraceConfig{Endpoint: "http://collector:4318/v1/traces", Headers: map[string]string{"X-Api-Key": "k"}}, nil, NewLogger())
+	recv := tr.start("receive", nil, spanClient)
+	handle := tr.start("handle", recv, spanServer).set("chat.id", int64(-100))
+	handle.end(errors.New("boom"))
+	recv.end(nil)
+
+	spans, req := exportedSpans(t, tr)
+	if req.URL.String() != "http://collector:4318/v1/traces" || req.Header.Get("X-Api-Key") != "k" {
+		t.Errorf("запрос: %s, заголовки %v", req.URL, req.Header)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("ожидали 2 спана, получили %d", len(spans))
+	}
+	h, r := spans[0], spans[1]
+	if h.TraceID != r.TraceID || h.ParentSpanID != r.SpanID || len(h.TraceID) != 32 || len(h.SpanID) != 16 {
+		t.Errorf("handle должен быть дочерним к receive: %+v / %+v", h, r)
+	}
+	if h.Status.Code != 2 || h.Status.Message != "boom" || r.Status.Code != 1 {
+		t.Errorf("статусы: %+v, %+v", h.Status, r.Status)
+	}
+	if len(h.Attributes) != 1 || *h.Attributes[0].Value.IntValue != "-100" {
+		t.Errorf("атрибуты: %+v", h.Attributes)
+	}
+	if err := tr.flush(); err != nil {
+		t.Errorf("пустая выгрузка не должна обращаться к коллектору: %v", err)
+	}
+}
+
+func TestTracerDisabled(t *testing.T) {
+	tr := newTracer(TraceConfig{}, nil, NewLogger())
+	if tr != nil {
+		t.Fatal("без Endpoint трассировка выключена")
+	}
+	// методы nil-трейсера и nil-спана безопасны
+	tr.start("x", nil, spanClient).set("a", 1).end(nil)
+	if err := tr.flush(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueuedAPITracesCalls(t *testing.T) {
+	tr := newTracer(TraceConfig{Endpoint: "http://collector/v1/traces"}, nil, NewLogger())
+	q := newQueuedAPI(&fakeAPI{}, 1)
+	q.tracer = tr
+	q.DeleteMessage(context.Background(), -100, 5)
+	q.close()
+
+	spans, _ := exportedSpans(t, tr)
+	if len(spans) != 1 || spans[0].Name != "telegram.deleteMessage" {
+		t.Fatalf("ожидали спан telegram.deleteMessage, получили %+v", spans)
+	}
+	keys := map[string]bool{}
+	for _, a := range spans[0].Attributes {
+		keys[a.Key] = true
+	}
+	if !keys["queue.wait_ms"] || !keys["priority"] {
+		t.Errorf("у спана запроса нет времени ожидания в очереди: %+v", spans[0].Attributes)
+	}
+}
+
+func TestTraceConfigFromEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "authorization=Bearer x, x-team = hamster")
+	tc := ConfigFromEnv(NewLogger()).Trace
+	if tc.Endpoint != "http://collector:4318/v1/traces" {
+		t.Errorf("Endpoint = %q", tc.Endpoint)
+	}
+	if tc.Headers["authorization"] != "Bearer x" || tc.Headers["x-team"] != "hamster" {
+		t.Errorf("Headers = %v", tc.Headers)
+	}
+	if _, err := ParseTraceHeaders("broken"); err == nil {
+		t.Error("заголовок без = должен отклоняться")
+	}
+}
@@ -0,0 +1,69 @@
+package hamster
+
+import (
+	"strings"
+)
+
+// ==========================
+// Облегчённая проверка для похожих на людей аккаунтов
+// ==========================
+
+const (
+	// TrustSkip — такие аккаунты не проходят проверку.
+	TrustSkip = "skip"
+	// TrustSoften — проверка одной кнопкой, какой бы тип ни был выбран в чате.
+	TrustSoften = "soften"
+)
+
+// Признаки аккаунта, которому можно облегчить проверку.
+const (
+	SignUsername = "username" // есть @username
+	SignPremium  = "premium"  // Telegram Premium
+	SignOld      = "old"      // ID меньше OldAccountID — аккаунт заведён давно
+)
+
+// defaultTrustSigns — признаки по умолчанию: @username заводят и боты-спамеры.
+var defaultTrustSigns = []string{SignPremium, SignOld}
+
+// parseTrustSigns разбирает список признаков через запятую; неизвестные
+// возвращаются отдельно.
+func parseTrustSigns(s string) (signs, unknown []string) {
+	for _, sign := range strings.Split(s, ",") {
+		switch sign = strings.ToLower(strings.TrimSpace(sign)); sign {
+		case "":
+		case SignUsername, SignPremium, SignOld:
+			signs = append(signs, sign)
+		default:
+			unknown = append(unknown, sign)
+		}
+	}
+	return signs, unknown
+}
+
+// trustedSign возвращает первый признак из signs, которым обладает
+// пользователь; "" — ни одного.
+func trustedSign(u *User, signs []string, oldAccountID int64) string {
+	for _, sign := range signs {
+		switch {
+		case sign == SignUsername && u.Username != "",
+			sign == SignPremium && u.IsPremium,
+			sign == SignOld && oldAccountID > 0 && u.ID < oldAccountID:
+			return sign
+		}
+	}
+	return ""
+}
+
+// trustedAccount возвращает, как проверять вступившего по TrustedAccounts:
+// TrustSkip, TrustSoften или "" — как всех.
+func (b *Bot) trustedAccount(chatID int64, user *User) string {
+	if b.cfg.TrustedAccounts == "" {
+		return ""
+	}
+	sign := trustedSign(user, b.cfg.TrustedAccountSigns, b.cfg.OldAccountID)
+	if sign == "" {
+		return ""
+	}
+	b.logger.Info("Участник %d чата %d похож на человека (%s) — %s", user.ID, chatID, sign, b.cfg.TrustedAccounts)
+	return b.cfg.TrustedAccounts
+}
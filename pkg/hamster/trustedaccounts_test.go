@@ -0,0 +1,88 @@
+package hamster
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTrustedSign(t *testing.T) {
+	signs := []string{SignUsername, SignPremium, SignOld}
+	cases := []struct {
+		user User
+		want string
+	}{
+		{User{ID: 8_000_000_000, Username: "anya"}, SignUsername},
+		{User{ID: 8_000_000_000, IsPremium: true}, SignPremium},
+		{User{ID: 12345}, SignOld},
+		{User{ID: 8_000_000_000}, ""},
+	}
+	for _, c := range cases {
+		if got := trustedSign(&c.user, signs, 1_000_000_000); got != c.want {
+			t.Errorf("%+v: ожидали %q, получили %q", c.user, c.want, got)
+		}
+	}
+	if got := trustedSign(&User{ID: 8_000_000_000, Username: "anya"}, defaultTrustSigns, 1_000_000_000); got != "" {
+		t.Errorf("по умолчанию @username не признак человека: %q", got)
+	}
+}
+
+func TestParseTrustSigns(t *testing.T) {
+	signs, unknown := parseTrustSigns(" Premium, old ,age,")
+	if !reflect.DeepEqual(signs, []string{SignPremium, SignOld}) || !reflect.DeepEqual(unknown, []string{"age"}) {
+		t.Errorf("разбор признаков: %v %v", signs, unknown)
+	}
+}
+
+func TestConfigTrustedAccounts(t *testing.T) {
+	t.Setenv("TRUSTED_ACCOUNTS", "soften")
+	t.Setenv("TRUSTED_ACCOUNT_SIGNS", "username")
+	t.Setenv("OLD_ACCOUNT_ID", "500")
+	cfg := ConfigFromEnv(NewLogger())
+	if cfg.TrustedAccounts != TrustSoften || !reflect.DeepEqual(cfg.TrustedAccountSigns, []string{SignUsername}) || cfg.OldAccountID != 500 {
+		t.Errorf("настройки не прочитаны: %q %v %d", cfg.TrustedAccounts, cfg.TrustedAccountSigns, cfg.OldAccountID)
+	}
+	t.Setenv("TRUSTED_ACCOUNTS", "always")
+	if cfg := ConfigFromEnv(NewLogger()); cfg.TrustedAccounts != "" {
+		t.Errorf("неизвестный режим должен выключать облегчение: %q", cfg.TrustedAccounts)
+	}
+}
+
+func TestTrustedAccountsJoin(t *testing.T) {
+	b := setupBot()
+	b.cfg = DefaultConfig()
+	b.cfg.TrustedAccounts = TrustSoften
+	b.verified = newVerifiedUsers()
+	b.settings.Update(-100, func(c *ChatSettings) { c.CaptchaType = CaptchaMath })
+	var mu sync.Mutex
+	greetings := map[int64]string{}
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		id := int64(len(greetings) + 1)
+		greetings[id] = text
+		return id
+	}
+
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 8_000_000_001, FirstName: "Премиум", IsPremium: true}}})
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 8_000_000_002, FirstName: "Новый"}}})
+
+	b.cfg.TrustedAccounts = TrustSkip
+	b.handleJoinMessage(&Message{Chat: Chat{ID: -100}, NewChatMembers: []*User{{ID: 777, FirstName: "Старожил"}}})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(greetings) != 2 {
+		t.Fatalf("давний аккаунт в режиме skip не проверяется: %v", greetings)
+	}
+	if strings.Contains(greetings[1], "Сколько будет") {
+		t.Errorf("премиум-аккаунту — проверка одной кнопкой: %q", greetings[1])
+	}
+	if !strings.Contains(greetings[2], "Сколько будет") {
+		t.Errorf("новому аккаунту — проверка чата: %q", greetings[2])
+	}
+	if !isVerified(b, -100, 777) {
+		t.Error("пропущенный без проверки считается проверенным")
+	}
+}
@@ -0,0 +1,233 @@
+package hamster
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==========================
+// Проверка через Mini App
+// ==========================
+
+// CaptchaWebApp — слайдер в Telegram Mini App. Кнопки web_app в группах не
+// поддерживаются, поэтому приветствие ведёт на прямую ссылку t.me/<бот>/<app>,
+// а страница сообщает результат боту по HTTP вместе с подписанным initData.
+const CaptchaWebApp = "webapp"
+
+const (
+	// webAppTolerance — допустимый промах слайдера, в процентах ширины.
+	webAppTolerance = 4
+	// webAppInitDataTTL — сколько действителен initData после открытия приложения.
+	webAppInitDataTTL = time.Hour
+)
+
+//go:embed web/captcha.html
+var captchaHTML []byte
+
+func init() {
+	RegisterChallenge(CaptchaWebApp, webAppChallenge{})
+}
+
+// webAppChallenge хранит в Answer положение цели слайдера (10..90 %).
+type webAppChallenge struct{}
+
+func (webAppChallenge) Render(s *ChallengeSession) ChallengePrompt {
+	s.Answer = strconv.Itoa(10 + randIntn(81))
+	return ChallengePrompt{
+		Text:    "🧩 Откройте проверку кнопкой ниже и передвиньте ползунок на отметку",
+		Buttons: [][]ChallengeButton{{{Text: "🧩 Пройти проверку", WebApp: true}}},
+	}
+}
+
+func (webAppChallenge) HandleCallback(s *ChallengeSession, data string) ChallengeResult {
+	return ChallengePending
+}
+
+func (webAppChallenge) HandleMessage(s *ChallengeSession, msg *Message) ChallengeResult {
+	return ChallengePending
+}
+
+func (webAppChallenge) OnTimeout(s *ChallengeSession) string { return timeoutAction(s) }
+
+// webAppLink — прямая ссылка на Mini App; пустая, если WEBAPP_NAME не задан
+// или имя бота ещё неизвестно.
+func (b *Bot) webAppLink() string {
+	if b.cfg.WebAppName == "" || b.self.Username == "" {
+		return ""
+	}
+	return "https://t.me/" + b.self.Username + "/" + b.cfg.WebAppName
+}
+
+// webAppKey — ключ проверки подписи initData: HMAC-SHA256 токена бота с ключом "WebAppData".
+func webAppKey(token string) []byte {
+	mac := hmac.New(sha256.New, []byte("WebAppData"))
+	mac.Write([]byte(token))
+	return mac.Sum(nil)
+}
+
+// parseInitData проверяет подпись и срок initData и возвращает пользователя.
+func parseInitData(initData string, key []byte, now time.Time) (*User, error) {
+	vals, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, err
+	}
+	hash := vals.Get("hash")
+	if hash == "" {
+		return nil, errors.New("нет подписи")
+	}
+	keys := make([]string, 0, len(vals))
+	for k := range vals {
+		if k != "hash" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, k+"="+vals.Get(k))
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	want := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(hash)) {
+		return nil, errors.New("неверная подпись")
+	}
+	authDate, err := strconv.ParseInt(vals.Get("auth_date"), 10, 64)
+	if err != nil || now.Sub(time.Unix(authDate, 0)) > webAppInitDataTTL {
+		return nil, errors.New("данные устарели")
+	}
+	var user User
+	if err := json.Unmarshal([]byte(vals.Get("user")), &user); err != nil || user.ID == 0 {
+		return nil, errors.New("нет пользователя")
+	}
+	return &user, nil
+}
+
+// progressByToken ищет незавершённую проверку по токену из startapp.
+func (b *Bot) progressByToken(token string) *progressData {
+	var found *progressData
+	b.progressStore.each(func(p *progressData) {
+		if p.token == token && p.failReason == "" {
+			found = p
+		}
+	})
+	return found
+}
+
+type webAppRequest struct {
+	InitData string `json:"init_data"`
+	Token    string `json:"token"`
+	Position *int   `json:"position,omitempty"`
+}
+
+// WebAppHandler возвращает HTTP-обработчик страницы проверки и её API:
+//
+//	GET  /webapp/             — страница Mini App
+//	POST /webapp/api/puzzle   — положение цели для пользователя из initData
+//	POST /webapp/api/verify   — ответ пользователя
+func (b *Bot) WebAppHandler() http.Handler {
+	var verifyMu sync.Mutex // один ответ на проверку, даже при повторных запросах
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /webapp/{$}", serveCaptchaPage)
+	mux.HandleFunc("POST /webapp/api/puzzle", func(w http.ResponseWriter, r *http.Request) {
+		_, p, ok := b.webAppProgress(w, r)
+		if !ok {
+			return
+		}
+		_, s := progressChallenge(p)
+		target, _ := strconv.Atoi(s.Answer)
+		writeJSON(w, http.StatusOK, map[string]int{"target": target})
+	})
+	mux.HandleFunc("POST /webapp/api/verify", func(w http.ResponseWriter, r *http.Request) {
+		verifyMu.Lock()
+		defer verifyMu.Unlock()
+		req, p, ok := b.webAppProgress(w, r)
+		if !ok {
+			return
+		}
+		if req.Position == nil {
+			writeError(w, http.StatusBadRequest, "нет ответа")
+			return
+		}
+		_, s := progressChallenge(p)
+		target, _ := strconv.Atoi(s.Answer)
+		diff := *req.Position - target
+		if diff < -webAppTolerance || diff > webAppTolerance {
+			b.logger.Info("Mini App: неверный ответ %d в чате %d", p.userID, p.chatID)
+			b.failChallenge(p, BanReasonWrongAnswer)
+			writeJSON(w, http.StatusOK, map[string]bool{"passed": false})
+			return
+		}
+		if !b.passChallenge(p.chatID, s.User, p) {
+			writeError(w, http.StatusNotFound, "проверка не найдена или уже завершена")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]bool{"passed": true})
+	})
+	return mux
+}
+
+// webAppProgress разбирает запрос Mini App и находит проверку его отправителя.
+func (b *Bot) webAppProgress(w http.ResponseWriter, r *http.Request) (webAppRequest, *progressData, bool) {
+	var req webAppRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<14)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "некорректный запрос")
+		return req, nil, false
+	}
+	user, err := parseInitData(req.InitData, b.webAppKey, time.Now())
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return req, nil, false
+	}
+	p := b.progressByToken(req.Token)
+	if p == nil || p.userID != user.ID {
+		writeError(w, http.StatusNotFound, "проверка не найдена или уже завершена")
+		return req, nil, false
+	}
+	return req, p, true
+}
+
+func serveCaptchaPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline' https://telegram.org; style-src 'unsafe-inline'")
+	_, _ = w.Write(captchaHTML)
+}
+
+// ServeWebApp запускает сервер Mini App на WebAppAddr до отмены ctx.
+func (b *Bot) ServeWebApp(ctx context.Context) {
+	if b.cfg.WebAppAddr == "" {
+		return
+	}
+	if b.cfg.WebAppName == "" {
+		b.logger.Warn("WEBAPP_ADDR задан без WEBAPP_NAME — Mini App не запущен")
+		return
+	}
+	srv := &http.Server{
+		Addr:              b.cfg.WebAppAddr,
+		Handler:           b.WebAppHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	b.logger.Info("🧩 Mini App слушает %s", b.cfg.WebAppAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		b.logger.Error("Mini App остановлен: %v", err)
+	}
+}
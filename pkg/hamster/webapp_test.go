@@ -0,0 +1,157 @@
+package hamster
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signInitData собирает initData так, как его подписывает Telegram.
+func signInitData(key []byte, userID int64, authDate time.Time) string {
+	vals := url.Values{}
+	vals.Set("auth_date", fmt.Sprint(authDate.Unix()))
+	vals.Set("query_id", "AAH")
+	vals.Set("user", fmt.Sprintf(`{"id":%d,"first_name":"Аня"}`, userID))
+	keys := make([]string, 0, len(vals))
+	for k := range vals {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, k+"="+vals.Get(k))
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	vals.Set("hash", hex.EncodeToString(mac.Sum(nil)))
+	return vals.Encode()
+}
+
+func TestParseInitData(t *testing.T) {
+	key := webAppKey("123:ABC")
+	now := time.Now()
+
+	user, err := parseInitData(signInitData(key, 42, now), key, now)
+	if err != nil || user.ID != 42 || user.FirstName != "Аня" {
+		t.Fatalf("подписанные данные не приняты: %+v, %v", user, err)
+	}
+	if _, err := parseInitData(signInitData(webAppKey("other"), 42, now), key, now); err == nil {
+		t.Error("подпись чужого бота должна отклоняться")
+	}
+	if _, err := parseInitData(signInitData(key, 42, now.Add(-2*webAppInitDataTTL)), key, now); err == nil {
+		t.Error("устаревшие данные должны отклоняться")
+	}
+	forged := strings.Replace(signInitData(key, 42, now), "42", "43", 1)
+	if _, err := parseInitData(forged, key, now); err == nil {
+		t.Error("изменённые данные должны отклоняться")
+	}
+}
+
+func setupWebAppBot() *Bot {
+	b := setupBot()
+	b.stats = NewStats()
+	b.webAppKey = webAppKey("123:ABC")
+	b.progressStore.add(&progressData{
+		stopChan:   make(chan struct{}),
+		token:      "TOK",
+		chatID:     -1,
+		userID:     42,
+		greetMsgID: 10,
+		challenge:  webAppChallenge{},
+		session:    &ChallengeSession{ChatID: -1, User: &User{ID: 42, FirstName: "Аня"}, Answer: "50"},
+	})
+	return b
+}
+
+func webAppRequestBody(b *Bot, userID int64, token string, position *int) string {
+	body, _ := json.Marshal(webAppRequest{
+		InitData: signInitData(b.webAppKey, userID, time.Now()),
+		Token:    token,
+		Position: position,
+	})
+	return string(body)
+}
+
+func webAppCall(h http.Handler, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", path, strings.NewReader(body)))
+	return rec
+}
+
+func TestWebAppPuzzle(t *testing.T) {
+	b := setupWebAppBot()
+	h := b.WebAppHandler()
+
+	rec := webAppCall(h, "/webapp/api/puzzle", webAppRequestBody(b, 42, "TOK", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"target":50`) {
+		t.Fatalf("puzzle: код %d, тело %s", rec.Code, rec.Body)
+	}
+	if rec := webAppCall(h, "/webapp/api/puzzle", webAppRequestBody(b, 43, "TOK", nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("чужая проверка: код %d, ожидали 404", rec.Code)
+	}
+	if rec := webAppCall(h, "/webapp/api/puzzle", `{"init_data":"user=1&hash=00","token":"TOK"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("без подписи: код %d, ожидали 401", rec.Code)
+	}
+}
+
+func TestWebAppVerify(t *testing.T) {
+	b := setupWebAppBot()
+	h := b.WebAppHandler()
+	var greeting string
+	fakeOf(b).sendSilent = func(chatID int64, text string) int64 { greeting = text; return 1 }
+
+	pos := 52
+	rec := webAppCall(h, "/webapp/api/verify", webAppRequestBody(b, 42, "TOK", &pos))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"passed":true`) {
+		t.Fatalf("verify: код %d, тело %s", rec.Code, rec.Body)
+	}
+	if b.stats.Get(-1).Passed != 1 || !strings.Contains(greeting, "Аня") {
+		t.Errorf("проверка должна засчитаться: %+v, %q", b.stats.Get(-1), greeting)
+	}
+	if rec := webAppCall(h, "/webapp/api/verify", webAppRequestBody(b, 42, "TOK", &pos)); rec.Code != http.StatusNotFound {
+		t.Errorf("повторный ответ: код %d, ожидали 404", rec.Code)
+	}
+}
+
+func TestWebAppVerifyWrong(t *testing.T) {
+	b := setupWebAppBot()
+	p := b.progressStore.get(-1, 42)
+
+	pos := 90
+	rec := webAppCall(b.WebAppHandler(), "/webapp/api/verify", webAppRequestBody(b, 42, "TOK", &pos))
+	if !strings.Contains(rec.Body.String(), `"passed":false`) {
+		t.Fatalf("verify: код %d, тело %s", rec.Code, rec.Body)
+	}
+	if p.failReason != BanReasonWrongAnswer {
+		t.Errorf("промах должен проваливать проверку, причина %q", p.failReason)
+	}
+}
+
+func TestWebAppMarkup(t *testing.T) {
+	s := &ChallengeSession{}
+	prompt := webAppChallenge{}.Render(s)
+	rows := challengeMarkup(prompt, 42, "TOK", "https://t.me/hamster_bot/captcha")["inline_keyboard"].([][]interface{})
+	btn := rows[0][0].(map[string]interface{})
+	if btn["url"] != "https://t.me/hamster_bot/captcha?startapp=TOK" || btn["callback_data"] != nil {
+		t.Errorf("кнопка Mini App: %+v", btn)
+	}
+
+	b := setupBot()
+	if b.webAppLink() != "" {
+		t.Error("без WEBAPP_NAME ссылки быть не должно")
+	}
+	b.cfg.WebAppName = "captcha"
+	b.self.Username = "hamster_bot"
+	if b.webAppLink() != "https://t.me/hamster_bot/captcha" {
+		t.Errorf("ссылка: %q", b.webAppLink())
+	}
+}
@@ -0,0 +1,96 @@
+package hamster
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ==========================
+// Медиа в приветствии
+// ==========================
+
+// Типы медиа приветствия.
+const (
+	MediaPhoto     = "photo"
+	MediaAnimation = "animation" // GIF
+	MediaSticker   = "sticker"
+)
+
+// maxCaptionLen — лимит Telegram на подпись к медиа. Более длинное
+// приветствие уходит отдельным сообщением после медиа.
+const maxCaptionLen = 1024
+
+// Media — вложение по file_id, которое бот может переотправить.
+type Media struct {
+	Type   string `json:"type"` // MediaPhoto | MediaAnimation | MediaSticker
+	FileID string `json:"file_id"`
+}
+
+// mediaOf возвращает медиа из сообщения или nil, если его нет.
+func mediaOf(msg *Message) *Media {
+	switch {
+	case msg.Animation != nil:
+		return &Media{Type: MediaAnimation, FileID: msg.Animation.FileID}
+	case msg.Sticker != nil:
+		return &Media{Type: MediaSticker, FileID: msg.Sticker.FileID}
+	case len(msg.Photo) > 0:
+		return &Media{Type: MediaPhoto, FileID: msg.Photo[len(msg.Photo)-1].FileID}
+	}
+	return nil
+}
+
+// sendGreeting отправляет приветствие с кнопками проверки и возвращает ID
+// сообщения с кнопками; text — в HTML. Фото и анимация идут одним сообщением с подписью;
+// стикер подписи не поддерживает, поэтому отправляется перед текстом.
+func (b *Bot) sendGreeting(ctx context.Context, chatID int64, text string, markup interface{}, s *ChallengeSession) int64 {
+	opts := s.Settings.sendOptions(MsgGreeting)
+	opts.ParseMode = ParseModeHTML
+	m := s.Settings.WelcomeMedia
+	if m == nil {
+		return b.safeSend(ctx, chatID, text, markup, opts)
+	}
+	if m.Type != MediaSticker && len([]rune(text)) <= maxCaptionLen {
+		if msgID := b.safeSendMedia(ctx, chatID, *m, text, markup, opts); msgID != 0 {
+			s.captioned = true
+			return msgID
+		}
+		// file_id мог стать недоступен — проверка важнее картинки
+		return b.safeSend(ctx, chatID, text, markup, opts)
+	}
+	// звук — у стикера, идущего первым, чтобы не было двух уведомлений
+	s.mediaMsgID = b.safeSendMedia(ctx, chatID, *m, "", nil, opts)
+	opts.Notify = false
+	return b.safeSend(ctx, chatID, text, markup, opts)
+}
+
+// ==========================
+// Команда /setwelcomemedia
+// ==========================
+
+func (b *Bot) handleSetWelcomeMediaCommand(msg *Message) {
+	if msg.From == nil {
+		return
+	}
+	chatID := msg.Chat.ID
+	if !b.isAdminMessage(msg) {
+		b.sendTemporary(chatID, "❌ Только администратор может менять приветствие", 5*time.Second)
+		return
+	}
+
+	if strings.ToLower(commandArg(msg.Text, 1)) == "clear" {
+		b.updateChatSettings(chatID, func(c *ChatSettings) { c.WelcomeMedia = nil })
+		b.sendTemporary(chatID, "✅ Медиа убрано из приветствия", 5*time.Second)
+		return
+	}
+	var m *Media
+	if msg.ReplyToMessage != nil {
+		m = mediaOf(msg.ReplyToMessage)
+	}
+	if m == nil {
+		b.sendTemporary(chatID, "⚙️ Ответьте командой /setwelcomemedia на фото, GIF или стикер; /setwelcomemedia clear — убрать", 10*time.Second)
+		return
+	}
+	b.updateChatSettings(chatID, func(c *ChatSettings) { c.WelcomeMedia = m })
+	b.sendTemporary(chatID, "✅ Медиа добавлено к приветствию", 5*time.Second)
+}
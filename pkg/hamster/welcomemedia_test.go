@@ -0,0 +1,130 @@
+package hamster
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMediaOf(t *testing.T) {
+	cases := []struct {
+		msg  *Message
+		want *Media
+	}{
+		{&Message{Text: "привет"}, nil},
+		{&Message{Photo: []File{{FileID: "small"}, {FileID: "big"}}}, &Media{Type: MediaPhoto, FileID: "big"}},
+		{&Message{Animation: &File{FileID: "gif"}}, &Media{Type: MediaAnimation, FileID: "gif"}},
+		{&Message{Sticker: &File{FileID: "st"}}, &Media{Type: MediaSticker, FileID: "st"}},
+	}
+	for _, c := range cases {
+		got := mediaOf(c.msg)
+		if (got == nil) != (c.want == nil) || got != nil && *got != *c.want {
+			t.Errorf("mediaOf(%+v) = %+v, want %+v", c.msg, got, c.want)
+		}
+	}
+}
+
+func TestSendGreetingWithPhotoCaption(t *testing.T) {
+	b := setupBot()
+	var caption string
+	var markup interface{}
+	fakeOf(b).sendMedia = func(chatID int64, m Media, c string, mk interface{}) int64 {
+		caption, markup = c, mk
+		return 7
+	}
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 {
+		t.Error("фото с подписью не требует отдельного текста")
+		return 8
+	}
+	s := &ChallengeSession{Settings: ChatSettings{WelcomeMedia: &Media{Type: MediaPhoto, FileID: "p"}}}
+	if id := b.sendGreeting(b.ctx, 1, "Привет!", "kb", s); id != 7 {
+		t.Errorf("кнопки должны быть под фото, id = %d", id)
+	}
+	if caption != "Привет!" || markup != "kb" || !s.captioned {
+		t.Errorf("caption = %q, markup = %v, captioned = %v", caption, markup, s.captioned)
+	}
+}
+
+func TestSendGreetingWithSticker(t *testing.T) {
+	b := setupBot()
+	fakeOf(b).sendMedia = func(chatID int64, m Media, c string, mk interface{}) int64 {
+		if c != "" || mk != nil {
+			t.Error("стикер отправляется без подписи и кнопок")
+		}
+		return 7
+	}
+	fakeOf(b).sendWithMarkup = func(chatID int64, text string, markup interface{}) int64 { return 8 }
+	s := &ChallengeSession{Settings: ChatSettings{WelcomeMedia: &Media{Type: MediaSticker, FileID: "st"}}}
+	if id := b.sendGreeting(b.ctx, 1, "Привет!", "kb", s); id != 8 {
+		t.Errorf("кнопки должны быть под текстом, id = %d", id)
+	}
+	if s.mediaMsgID != 7 || s.captioned {
+		t.Errorf("mediaMsgID = %d, captioned = %v", s.mediaMsgID, s.captioned)
+	}
+}
+
+func TestPassDeletesGreetingSticker(t *testing.T) {
+	b := setupBot()
+	fakeOf(b).editMessage = func(chatID, msgID int64, text string) {}
+	var deleted []int64
+	fakeOf(b).deleteMessage = func(chatID, msgID int64) { deleted = append(deleted, msgID) }
+	done := startTestChallenge(b, ActionBan, 60)
+	p := b.pendingProgress(1, 42)
+	p.session.mediaMsgID = 77
+
+	b.passChallenge(1, &User{ID: 42}, p)
+	<-done
+
+	for _, id := range deleted {
+		if id == 77 {
+			return
+		}
+	}
+	t.Errorf("стикер приветствия должен удаляться вместе с ним, удалены %v", deleted)
+}
+
+func TestKeepGreetingEditsCaption(t *testing.T) {
+	b := setupBot()
+	var caption string
+	fakeOf(b).editCaption = func(chatID, msgID int64, c string) { caption = c }
+	fakeOf(b).editMessage = func(chatID, msgID int64, text string) {
+		if msgID == 10 {
+			t.Error("текст подписи меняется через editMessageCaption")
+		}
+	}
+	p := &progressData{greetMsgID: 10, session: &ChallengeSession{captioned: true}}
+	b.keepGreeting(1, p, ChatSettings{}, &User{ID: 42, FirstName: "Аня"})
+	if !strings.Contains(caption, "Проверка пройдена") {
+		t.Errorf("подпись должна смениться отметкой о проверке: %q", caption)
+	}
+}
+
+func TestSetWelcomeMediaCommand(t *testing.T) {
+	b := setupBot()
+	b.adminCache = map[string]adminCacheEntry{
+		"-100:10": {status: "administrator", expiresAt: time.Now().Add(time.Minute)},
+	}
+	reply := &Message{Animation: &File{FileID: "gif"}}
+	b.handleSetWelcomeMediaCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/setwelcomemedia", ReplyToMessage: reply})
+	if m := b.chatSettings(-100).WelcomeMedia; m == nil || *m != (Media{Type: MediaAnimation, FileID: "gif"}) {
+		t.Fatalf("медиа должно сохраниться: %+v", m)
+	}
+	b.handleSetWelcomeMediaCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 10}, Text: "/setwelcomemedia clear"})
+	if b.chatSettings(-100).WelcomeMedia != nil {
+		t.Error("/setwelcomemedia clear должен убирать медиа")
+	}
+
+	b.handleSetWelcomeMediaCommand(&Message{Chat: Chat{ID: -100}, From: &User{ID: 11}, Text: "/setwelcomemedia", ReplyToMessage: reply})
+	if b.chatSettings(-100).WelcomeMedia != nil {
+		t.Error("не админ не может менять приветствие")
+	}
+}
+
+func TestValidateWelcomeMedia(t *testing.T) {
+	if err := (ChatSettings{WelcomeMedia: &Media{Type: "video", FileID: "x"}}).Validate(); err == nil {
+		t.Error("неизвестный тип медиа должен отклоняться")
+	}
+	if err := (ChatSettings{WelcomeMedia: &Media{Type: MediaPhoto, FileID: "x"}}).Validate(); err != nil {
+		t.Errorf("фото должно проходить проверку: %v", err)
+	}
+}